@@ -24,8 +24,10 @@ import (
 const (
 	flagSource = "source"
 	flagTask   = "task"
+	flagOn     = "on"
 
 	hintSource = "Branch from a specific source instead of the default branch"
+	hintOn     = "Stack on another worktree's branch; restack later with 'rimba sync --stack'"
 )
 
 var prArgRe = regexp.MustCompile(`^pr:(\d+)$`)
@@ -44,13 +46,29 @@ pr:<num> requires gh installed and authenticated. For cross-fork PRs, rimba adds
 gh-fork-<owner> remote automatically. Without --task, the task name is derived as
 review/<num>-<slug>. The --task flag is only valid in pr:<num> mode.
 branch:<branch> requires that <branch> is the currently checked-out branch in the
-main repo and is not the default branch. --source is not valid in branch: mode.`,
+main repo and is not the default branch. --source is not valid in branch: mode.
+
+Use --on <task> to stack the new worktree on another worktree's branch. The
+parent/child link is also recorded when --source names a branch checked out
+in another worktree; 'rimba sync --stack' restacks children onto their parent.
+
+Adding a task or PR is all or nothing: if copying files, installing
+dependencies, or a post-create hook fails — or the command is interrupted —
+the worktree, its branch, and any fork remote added for it are removed again.
+Use --keep-on-failure to leave them in place for debugging.
+
+Use --profile <name> to apply a [profiles.<name>] config section: it can
+override copy_files, post_create, and deps, the source branch, and the prefix
+type. Prefix flags and --source still win over the profile.`,
 	Example: `  rimba add my-feature
   rimba add my-feature --bugfix          # use bugfix/ prefix
   rimba add auth-api/my-feature          # monorepo service scope
+  rimba add auth-ui --on auth            # stack on the auth worktree's branch
   rimba add pr:123                       # create worktree from PR #123
   rimba add pr:123 --task review/auth    # override auto-derived task name
-  rimba add branch:feature/my-feature   # promote current branch to worktree`,
+  rimba add branch:feature/my-feature   # promote current branch to worktree
+  rimba add my-feature --keep-on-failure # keep the worktree if a hook fails
+  rimba add bench-io --profile perf      # use the [profiles.perf] setup`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := config.FromContext(cmd.Context())
@@ -62,9 +80,16 @@ main repo and is not the default branch. --source is not valid in branch: mode.`
 			return err
 		}
 
+		// Trust is checked against cfg itself: it covers every profile.
+		setupCfg, err := applyProfileFlag(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
 		skipDeps, _ := cmd.Flags().GetBool(flagSkipDeps)
 		skipHooks, _ := cmd.Flags().GetBool(flagSkipHooks)
-		postOpts := buildPostCreateOptions(cfg, repoRoot, skipDeps, skipHooks)
+		postOpts := buildPostCreateOptions(setupCfg, repoRoot, skipDeps, skipHooks)
+		postOpts.KeepOnFailure, _ = cmd.Flags().GetBool(flagKeepOnFail)
 
 		s := spinner.New(spinnerOpts(cmd))
 		defer s.Stop()
@@ -85,6 +110,12 @@ main repo and is not the default branch. --source is not valid in branch: mode.`
 					"remove the --source flag: branch: promotes an existing branch, not a new one",
 				)
 			}
+			if cmd.Flags().Changed(flagProfile) {
+				return errhint.WithFix(
+					errors.New("--profile is not valid in branch: mode"),
+					"remove the --profile flag: branch: promotion runs no setup for a profile to change",
+				)
+			}
 			return runAddBranch(cmd, r, cfg, repoRoot, m[1])
 		}
 
@@ -98,7 +129,7 @@ main repo and is not the default branch. --source is not valid in branch: mode.`
 		if err := ensureTrust(cmd, repoRoot, cfg); err != nil {
 			return err
 		}
-		return runAddTask(cmd, r, args[0], cfg, repoRoot, postOpts, s)
+		return runAddTask(cmd, r, args[0], setupCfg, repoRoot, postOpts, s)
 	},
 }
 
@@ -110,14 +141,13 @@ func runAddPR(cmd *cobra.Command, r git.Runner, ghR gh.Runner, prNum int, postOp
 		TaskOverride:      taskOverride,
 		PostCreateOptions: postOpts,
 	}, func(msg string) { s.Update(msg) })
+	s.Stop()
+	n := prNum
 	if err != nil {
-		return err
+		return addFailed(cmd, "pr", &n, result, err)
 	}
 
-	s.Stop()
-
 	if isJSON(cmd) {
-		n := prNum
 		return writeAddJSON(cmd, "pr", &n, result)
 	}
 
@@ -128,23 +158,30 @@ func runAddPR(cmd *cobra.Command, r git.Runner, ghR gh.Runner, prNum int, postOp
 func runAddTask(cmd *cobra.Command, r git.Runner, arg string, cfg *config.Config, repoRoot string, postOpts operations.PostCreateOptions, s *spinner.Spinner) error {
 	ps := cfg.PrefixSet()
 	service, task := operations.ResolveTaskInput(arg, repoRoot, ps)
-	prefix, aliasUsed, aliasToken := resolveAddPrefix(cmd, arg, ps)
+	prefix, aliasUsed, aliasToken := resolveAddPrefix(cmd, arg, ps, profilePrefix(cmd, cfg, ps))
 	if aliasUsed && !isJSON(cmd) {
 		printAliasNotice(cmd, aliasToken, prefix)
 	}
 
 	source, _ := cmd.Flags().GetString(flagSource)
+	parent, err := resolveAddParent(cmd, r, cfg, source)
+	if err != nil {
+		return err
+	}
 	if source == "" {
-		source = cfg.DefaultSource
+		profile, _ := cmd.Flags().GetString(flagProfile)
+		source = cfg.AddSource(profile, prefix)
 	} else if err := gitref.Validate(source); err != nil {
 		return fmt.Errorf("--source: %w", err)
 	}
+	postOpts = postOpts.WithPrefixDefaults(cfg.PrefixDefaults(prefix))
 
 	if !isJSON(cmd) {
 		hint.New(cmd, hintPainter(cmd)).
 			Add(flagSkipDeps, hintSkipDeps).
 			Add(flagSkipHooks, hintSkipHooks).
 			Add(flagSource, hintSource).
+			Add(flagOn, hintOn).
 			Show()
 	}
 
@@ -154,14 +191,15 @@ func runAddTask(cmd *cobra.Command, r git.Runner, arg string, cfg *config.Config
 		Service:           service,
 		Prefix:            prefix,
 		Source:            source,
+		Parent:            parent,
+		Base:              addTrackedBase(cfg, service, prefix, task, source, parent),
 		PostCreateOptions: postOpts,
 	}, func(msg string) { s.Update(msg) })
+	s.Stop()
 	if err != nil {
-		return err
+		return addFailed(cmd, "task", nil, result, err)
 	}
 
-	s.Stop()
-
 	if isJSON(cmd) {
 		return writeAddJSON(cmd, "task", nil, result)
 	}
@@ -175,10 +213,55 @@ func runAddTask(cmd *cobra.Command, r git.Runner, arg string, cfg *config.Config
 	return nil
 }
 
-// resolveAddPrefix prefers an explicit prefix flag over a positional segment.
+// addTrackedBase returns the base to record for a new task worktree: its
+// source when that differs from the configured base, and "" when stacked,
+// since a stacked worktree follows its parent instead.
+func addTrackedBase(cfg *config.Config, service, prefix, task, source, parent string) string {
+	if parent != "" {
+		return ""
+	}
+	return operations.TrackedBase(cfg, resolver.FullBranchName(service, prefix, task), source)
+}
+
+// resolveAddParent returns the stack parent branch for a new worktree: the
+// branch of the --on worktree, or --source when another worktree has it
+// checked out. Returns "" for an unstacked worktree.
+func resolveAddParent(cmd *cobra.Command, r git.Runner, cfg *config.Config, source string) (string, error) {
+	on, _ := cmd.Flags().GetString(flagOn)
+	if on != "" {
+		if source != "" {
+			return "", errhint.WithFix(
+				errors.New("--on and --source cannot be used together"),
+				"drop --source: --on branches from the parent worktree's branch",
+			)
+		}
+		wt, err := findWorktree(cmd.Context(), r, on)
+		if err != nil {
+			return "", err
+		}
+		return wt.Branch, nil
+	}
+
+	if source == "" || source == cfg.DefaultSource {
+		return "", nil
+	}
+	worktrees, err := listWorktreeInfos(cmd.Context(), r)
+	if err != nil {
+		return "", err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == source {
+			return source, nil
+		}
+	}
+	return "", nil
+}
+
+// resolveAddPrefix prefers an explicit prefix flag over a positional segment,
+// then fallback (a --profile type's prefix), then the default prefix.
 // aliasToken carries the matched alias text (built-in "fix" or a custom
 // alias) so printAliasNotice can word the notice correctly.
-func resolveAddPrefix(cmd *cobra.Command, arg string, ps *resolver.PrefixSet) (prefix string, aliasUsed bool, aliasToken string) {
+func resolveAddPrefix(cmd *cobra.Command, arg string, ps *resolver.PrefixSet, fallback string) (prefix string, aliasUsed bool, aliasToken string) {
 	sel := resolvePrefixSelection(cmd)
 	if sel.Explicit {
 		if sel.Alias {
@@ -193,6 +276,9 @@ func resolveAddPrefix(cmd *cobra.Command, arg string, ps *resolver.PrefixSet) (p
 		}
 	}
 
+	if fallback != "" {
+		return fallback, false, ""
+	}
 	return sel.Prefix, false, ""
 }
 
@@ -240,20 +326,53 @@ func runAddBranch(cmd *cobra.Command, r git.Runner, cfg *config.Config, repoRoot
 
 // writeAddJSON handles task/pr modes; branch-promote has no AddResult and builds its own AddData.
 func writeAddJSON(cmd *cobra.Command, mode string, prNumber *int, result operations.AddResult) error {
-	return output.WriteJSON(cmd.OutOrStdout(), version, "add", output.AddData{
+	return output.WriteJSON(cmd.OutOrStdout(), version, "add", addJSONData(mode, prNumber, result))
+}
+
+// addFailed reports a failed task or pr add. In JSON mode the transaction —
+// which steps completed and whether they were rolled back — is written with
+// the error, so callers can tell what was left behind.
+func addFailed(cmd *cobra.Command, mode string, prNumber *int, result operations.AddResult, err error) error {
+	txn := result.Transaction
+	if !isJSON(cmd) || (len(txn.Completed) == 0 && txn.Failed == "") {
+		return err
+	}
+	data := addJSONData(mode, prNumber, result)
+	data.Error = err.Error()
+	_ = output.WriteJSON(cmd.OutOrStdout(), version, "add", data)
+	return &output.SilentError{ExitCode: 1}
+}
+
+func addJSONData(mode string, prNumber *int, result operations.AddResult) output.AddData {
+	return output.AddData{
 		Mode:            mode,
 		Task:            result.Task,
 		Service:         result.Service,
 		Branch:          result.Branch,
 		Path:            result.Path,
 		Source:          result.Source,
+		Parent:          result.Parent,
 		PRNumber:        prNumber,
 		Copied:          nonNilStrings(result.Copied),
 		Skipped:         nonNilStrings(result.Skipped),
 		SkippedSymlinks: nonNilStrings(result.SkippedSymlinks),
 		Deps:            buildDepResults(result.DepsResults),
 		Hooks:           buildHookResults(result.HookResults),
-	})
+		Transaction:     buildTransaction(result.Transaction),
+	}
+}
+
+func buildTransaction(report operations.TxnReport) *output.TransactionJSON {
+	errs := make([]string, 0, len(report.RollbackErrors))
+	for _, err := range report.RollbackErrors {
+		errs = append(errs, err.Error())
+	}
+	return &output.TransactionJSON{
+		Completed:      nonNilStrings(report.Completed),
+		Failed:         report.Failed,
+		RolledBack:     report.RolledBack,
+		RollbackErrors: errs,
+	}
 }
 
 func printWorktreeResult(cmd *cobra.Command, header string, result operations.AddResult) {
@@ -261,6 +380,9 @@ func printWorktreeResult(cmd *cobra.Command, header string, result operations.Ad
 	fmt.Fprintln(out, header)
 	fmt.Fprintf(out, "  Branch: %s\n", result.Branch)
 	fmt.Fprintf(out, "  Path:   %s\n", result.Path)
+	if result.Parent != "" {
+		fmt.Fprintf(out, "  Stacked on: %s\n", result.Parent)
+	}
 	if len(result.Copied) > 0 {
 		fmt.Fprintf(out, "  Copied: %v\n", result.Copied)
 	}
@@ -274,6 +396,24 @@ func printWorktreeResult(cmd *cobra.Command, header string, result operations.Ad
 	printHookResultsList(out, result.HookResults)
 }
 
+// applyProfileFlag returns cfg with the --profile overrides applied, or cfg
+// itself when no profile is selected.
+func applyProfileFlag(cmd *cobra.Command, cfg *config.Config) (*config.Config, error) {
+	name, _ := cmd.Flags().GetString(flagProfile)
+	return cfg.WithProfile(name)
+}
+
+// profilePrefix returns the branch prefix of the --profile type, or "" when
+// no profile is selected or it sets no type.
+func profilePrefix(cmd *cobra.Command, cfg *config.Config, ps *resolver.PrefixSet) string {
+	name, _ := cmd.Flags().GetString(flagProfile)
+	if name == "" {
+		return ""
+	}
+	prefix, _ := ps.TypeToPrefix(cfg.Profiles[name].Type)
+	return prefix
+}
+
 func buildPostCreateOptions(cfg *config.Config, repoRoot string, skipDeps, skipHooks bool) operations.PostCreateOptions {
 	var configModules []config.ModuleConfig
 	if cfg.Deps != nil {
@@ -296,10 +436,17 @@ func init() {
 	addPrefixFlags(addCmd)
 	addCmd.Flags().StringP(flagSource, "s", "", "source branch to create worktree from (default from config)")
 	addCmd.Flags().String(flagTask, "", "override auto-derived task name (pr:<num> mode only)")
+	addCmd.Flags().String(flagOn, "", "stack the new worktree on another worktree's branch")
 	addCmd.Flags().Bool(flagSkipDeps, false, "skip dependency detection and installation")
 	addCmd.Flags().Bool(flagSkipHooks, false, "skip post-create hooks")
+	addCmd.Flags().String(flagProfile, "", "apply a [profiles.<name>] section from the config")
+	addCmd.Flags().Bool(flagKeepOnFail, false, "keep a partially created worktree when a step fails instead of rolling back")
 	_ = addCmd.RegisterFlagCompletionFunc(flagSource, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return completeBranchNames(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
 	})
+	_ = addCmd.RegisterFlagCompletionFunc(flagProfile, completeProfilesFlag)
+	_ = addCmd.RegisterFlagCompletionFunc(flagOn, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
 	rootCmd.AddCommand(addCmd)
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/output"
 	"github.com/lugassawan/rimba/internal/resolver"
@@ -927,7 +929,7 @@ func TestResolveAddPrefixAgreesWithResolveTaskInput(t *testing.T) {
 
 			cmd, _ := newTestCmd()
 			addPrefixFlags(cmd)
-			prefix, _, _ := resolveAddPrefix(cmd, tt.arg, resolver.DefaultPrefixSet())
+			prefix, _, _ := resolveAddPrefix(cmd, tt.arg, resolver.DefaultPrefixSet(), "")
 			if prefix != tt.wantPrefix {
 				t.Errorf("resolveAddPrefix(%q) prefix = %q, want %q", tt.arg, prefix, tt.wantPrefix)
 			}
@@ -945,7 +947,7 @@ func TestResolveAddPrefixCustomAlias(t *testing.T) {
 	cmd, _ := newTestCmd()
 	addPrefixFlags(cmd)
 
-	prefix, aliasUsed, aliasToken := resolveAddPrefix(cmd, "proj/x", ps)
+	prefix, aliasUsed, aliasToken := resolveAddPrefix(cmd, "proj/x", ps, "")
 	if prefix != "PROJ-" {
 		t.Errorf("resolveAddPrefix(%q) prefix = %q, want %q", "proj/x", prefix, "PROJ-")
 	}
@@ -1169,3 +1171,185 @@ func TestAddBranchPromoteJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveAddParentRejectsOnWithSource(t *testing.T) {
+	cmd, _ := newTestCmd()
+	cmd.Flags().String(flagOn, "", "")
+	_ = cmd.Flags().Set(flagOn, "login")
+
+	_, err := resolveAddParent(cmd, &mockRunner{}, &config.Config{DefaultSource: branchMain}, "develop")
+	if err == nil || !strings.Contains(err.Error(), "--on and --source cannot be used together") {
+		t.Fatalf("err = %v, want --on/--source conflict", err)
+	}
+}
+
+func TestResolveAddParentDetectsWorktreeSource(t *testing.T) {
+	r := &mockRunner{
+		run: func(_ ...string) (string, error) {
+			return wtRepo + headMainBlock + "\nworktree " + pathWtFeatureLogin + "\nHEAD def456\nbranch refs/heads/" + branchFeature, nil
+		},
+		runInDir: noopRunInDir,
+	}
+	cfg := &config.Config{DefaultSource: branchMain}
+	cmd, _ := newTestCmd()
+
+	for source, want := range map[string]string{branchFeature: branchFeature, "develop": "", branchMain: ""} {
+		got, err := resolveAddParent(cmd, r, cfg, source)
+		if err != nil {
+			t.Fatalf("resolveAddParent(%q): %v", source, err)
+		}
+		if got != want {
+			t.Errorf("resolveAddParent(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestAddTaskJSONReportsRolledBackFailure(t *testing.T) {
+	t.Setenv("RIMBA_TRUST_YES", "1")
+	repoDir := t.TempDir()
+	cfg := &config.Config{DefaultSource: branchMain, WorktreeDir: "worktrees", PostCreate: []string{"exit 1"}}
+
+	restore := overrideNewRunner(makeWorktreeGitRunner(repoDir))
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	cmd.Flags().StringP(flagSource, "s", "", "")
+	cmd.Flags().Bool(flagSkipDeps, false, "")
+	cmd.Flags().Bool(flagSkipHooks, false, "")
+	cmd.Flags().Bool(flagKeepOnFail, false, "")
+	addPrefixFlags(cmd)
+	_ = cmd.Flags().Set(flagSkipDeps, "true")
+	_ = cmd.Flags().Set(flagJSON, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), cfg))
+
+	err := addCmd.RunE(cmd, []string{"my-task"})
+	var silent *output.SilentError
+	if !errors.As(err, &silent) {
+		t.Fatalf("err = %v, want SilentError after writing JSON", err)
+	}
+
+	_, data := decodeAddEnvelope(t, buf.Bytes())
+	if data["error"] == "" || data["error"] == nil {
+		t.Error("error should be reported")
+	}
+	txn, ok := data["transaction"].(map[string]any)
+	if !ok {
+		t.Fatalf("transaction = %#v", data["transaction"])
+	}
+	if txn["failed"] != "run hooks" || txn["rolled_back"] != true {
+		t.Errorf("transaction = %v", txn)
+	}
+	if completed, _ := txn["completed"].([]any); len(completed) == 0 || completed[0] != "create worktree" {
+		t.Errorf("completed = %v", txn["completed"])
+	}
+}
+
+func TestAddTaskWithProfile(t *testing.T) {
+	repoDir := t.TempDir()
+	cfg := &config.Config{
+		DefaultSource: branchMain,
+		WorktreeDir:   "worktrees",
+		Profiles:      map[string]config.Profile{"perf": {Source: "develop", Type: "bugfix"}},
+	}
+
+	restore := overrideNewRunner(makeWorktreeGitRunner(repoDir))
+	defer restore()
+
+	newCmd := func(profile string) (*cobra.Command, *bytes.Buffer) {
+		cmd, buf := newTestCmd()
+		cmd.Flags().StringP(flagSource, "s", "", "")
+		cmd.Flags().Bool(flagSkipDeps, false, "")
+		cmd.Flags().Bool(flagSkipHooks, false, "")
+		cmd.Flags().String(flagProfile, "", "")
+		addPrefixFlags(cmd)
+		_ = cmd.Flags().Set(flagSkipDeps, "true")
+		_ = cmd.Flags().Set(flagJSON, "true")
+		_ = cmd.Flags().Set(flagProfile, profile)
+		cmd.SetContext(config.WithConfig(context.Background(), cfg))
+		return cmd, buf
+	}
+
+	cmd, buf := newCmd("perf")
+	if err := addCmd.RunE(cmd, []string{"bench"}); err != nil {
+		t.Fatalf("addCmd.RunE: %v", err)
+	}
+	_, data := decodeAddEnvelope(t, buf.Bytes())
+	if data["branch"] != "bugfix/bench" || data["source"] != "develop" {
+		t.Errorf("branch = %v, source = %v; want the profile's type and source", data["branch"], data["source"])
+	}
+
+	cmd, _ = newCmd("nope")
+	if err := addCmd.RunE(cmd, []string{"bench"}); err == nil || !strings.Contains(err.Error(), `unknown profile "nope"`) {
+		t.Errorf("err = %v, want unknown profile", err)
+	}
+}
+
+func TestAddTaskPrefixTypeSource(t *testing.T) {
+	repoDir := t.TempDir()
+	cfg := &config.Config{
+		DefaultSource: branchMain,
+		WorktreeDir:   "worktrees",
+		Resolver: &config.ResolverConfig{Prefix: []config.PrefixEntry{
+			{Prefix: "hotfix/", Source: "release/current", SkipDeps: true},
+		}},
+	}
+
+	restore := overrideNewRunner(makeWorktreeGitRunner(repoDir))
+	defer restore()
+
+	run := func(args ...string) map[string]any {
+		t.Helper()
+		cmd, buf := newTestCmd()
+		cmd.Flags().StringP(flagSource, "s", "", "")
+		cmd.Flags().Bool(flagSkipDeps, false, "")
+		cmd.Flags().Bool(flagSkipHooks, false, "")
+		addPrefixFlags(cmd)
+		_ = cmd.Flags().Set(flagJSON, "true")
+		_ = cmd.Flags().Set("hotfix", "true")
+		if len(args) > 1 {
+			_ = cmd.Flags().Set(flagSource, args[1])
+		}
+		cmd.SetContext(config.WithConfig(context.Background(), cfg))
+		if err := addCmd.RunE(cmd, args[:1]); err != nil {
+			t.Fatalf("addCmd.RunE: %v", err)
+		}
+		_, data := decodeAddEnvelope(t, buf.Bytes())
+		return data
+	}
+
+	if data := run("crash"); data["branch"] != "hotfix/crash" || data["source"] != "release/current" {
+		t.Errorf("branch = %v, source = %v; want hotfix/crash from release/current", data["branch"], data["source"])
+	}
+	if data := run("leak", "develop"); data["source"] != "develop" {
+		t.Errorf("source = %v, want --source to win over the type's source", data["source"])
+	}
+
+	// Only the off-type source is recorded as the worktree's base.
+	s, err := meta.Load(filepath.Join(repoDir, ".git"))
+	if err != nil {
+		t.Fatalf("meta.Load: %v", err)
+	}
+	if got := s.Get("hotfix/crash").Base; got != "" {
+		t.Errorf("hotfix/crash base = %q, want none (its type's source)", got)
+	}
+	if got := s.Get("hotfix/leak").Base; got != "develop" {
+		t.Errorf("hotfix/leak base = %q, want develop", got)
+	}
+}
+
+func TestResolveAddPrefixProfileFallback(t *testing.T) {
+	cmd, _ := newTestCmd()
+	addPrefixFlags(cmd)
+	ps := resolver.DefaultPrefixSet()
+
+	if prefix, _, _ := resolveAddPrefix(cmd, "bench", ps, "chore/"); prefix != "chore/" {
+		t.Errorf("prefix = %q, want the profile's chore/", prefix)
+	}
+	if prefix, _, _ := resolveAddPrefix(cmd, "bugfix/bench", ps, "chore/"); prefix != "bugfix/" {
+		t.Errorf("prefix = %q, want the positional bugfix/ to win", prefix)
+	}
+	_ = cmd.Flags().Set("docs", "true")
+	if prefix, _, _ := resolveAddPrefix(cmd, "bench", ps, "chore/"); prefix != "docs/" {
+		t.Errorf("prefix = %q, want the flag's docs/ to win", prefix)
+	}
+}
@@ -3,22 +3,43 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/hint"
 	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/spinner"
 	"github.com/spf13/cobra"
 )
 
 const (
-	hintForceArchive = "Force archival even if the worktree has uncommitted changes"
+	flagBundle      = "bundle"
+	flagNoCopyFiles = "no-copy-files"
+
+	hintForceArchive = "Discard uncommitted changes instead of saving them for restore"
+	hintBundle       = "Export the task to one file that 'rimba restore --from-bundle' imports into any clone"
 )
 
 var archiveCmd = &cobra.Command{
 	Use:   "archive <task>",
 	Short: "Archive a worktree (remove directory, keep branch)",
-	Long:  "Removes the worktree directory but preserves the local branch for later restoration with `rimba restore`. Use --dry-run to preview what would be archived without making changes.",
-	Example: `  rimba archive auth           # archive worktree, keep branch
-  rimba archive auth --dry-run # preview without archiving`,
+	Long: `Removes the worktree directory but preserves the local branch for later
+restoration with 'rimba restore'. Uncommitted and untracked changes are saved
+to a hidden ref (refs/rimba/archive/<branch>) and the copy_files entries are
+saved alongside, so restore brings the worktree back exactly as it was left.
+Use --force to discard them instead, and --dry-run to preview what would be
+archived without making changes.
+
+With --bundle, the archived task is also exported to one portable file: a git
+bundle of the branch's commits since its merge-base with the default branch,
+the saved uncommitted changes, the copy_files entries (unless
+--no-copy-files), and a manifest with task, service, type, and metadata.
+'rimba restore --from-bundle <file>' imports it into any clone of the repo.`,
+	Example: `  rimba archive auth                       # archive worktree, keep branch and changes
+  rimba archive auth --force               # archive and discard uncommitted changes
+  rimba archive auth --dry-run             # preview without archiving
+  rimba archive auth --bundle auth.rimba   # also export to a portable file`,
 	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
@@ -37,21 +58,36 @@ var archiveCmd = &cobra.Command{
 
 		force, _ := cmd.Flags().GetBool(flagForce)
 		dryRun, _ := cmd.Flags().GetBool(flagDryRun)
+		bundlePath, _ := cmd.Flags().GetString(flagBundle)
+		noCopyFiles, _ := cmd.Flags().GetBool(flagNoCopyFiles)
+		if noCopyFiles && bundlePath == "" {
+			return errhint.WithFix(
+				fmt.Errorf("--%s requires --%s", flagNoCopyFiles, flagBundle),
+				"run: rimba archive "+task+" --bundle <file> --no-copy-files",
+			)
+		}
 
 		hint.New(cmd, hintPainter(cmd)).
 			Add(flagForce, hintForceArchive).
+			Add(flagBundle, hintBundle).
 			Add(flagDryRun, hintDryRun).
 			Show()
 
 		s := spinner.New(spinnerOpts(cmd))
 		defer s.Stop()
 
+		var copyFiles []string
+		if cfg := config.FromContext(cmd.Context()); cfg != nil {
+			copyFiles = cfg.CopyFiles
+		}
+
 		s.Start("Archiving worktree...")
 		result, err := operations.ArchiveWorktree(cmd.Context(), r, operations.ArchiveParams{
-			Path:   wt.Path,
-			Branch: wt.Branch,
-			Force:  force,
-			DryRun: dryRun,
+			Path:      wt.Path,
+			Branch:    wt.Branch,
+			CopyFiles: copyFiles,
+			Force:     force,
+			DryRun:    dryRun,
 		})
 		if err != nil {
 			return err
@@ -63,19 +99,69 @@ var archiveCmd = &cobra.Command{
 			for _, step := range result.Plan.Steps {
 				fmt.Fprintf(out, "[dry-run] %s\n", step)
 			}
+			if bundlePath != "" {
+				fmt.Fprintf(out, "[dry-run] write bundle: %s\n", bundlePath)
+			}
 			return nil
 		}
 
 		out := cmd.OutOrStdout()
 		fmt.Fprintf(out, "Archived worktree: %s\n", result.Path)
 		fmt.Fprintf(out, "  Branch preserved: %s\n", result.Branch)
+		if result.Snapshot.Stashed {
+			fmt.Fprintf(out, "  Uncommitted changes saved: %s\n", operations.ArchiveRef(result.Branch))
+		}
+		if len(result.Snapshot.Files) > 0 {
+			fmt.Fprintf(out, "  Files saved: %v\n", result.Snapshot.Files)
+		}
 		fmt.Fprintf(out, "  To restore: rimba restore %s\n", task)
+
+		if bundlePath == "" {
+			return nil
+		}
+		if noCopyFiles {
+			copyFiles = nil
+		}
+		if err := exportArchiveBundle(cmd, r, wt, bundlePath, copyFiles); err != nil {
+			return errhint.WithFix(
+				fmt.Errorf("worktree archived, but writing the bundle failed: %w", err),
+				fmt.Sprintf("run: rimba restore %s, then retry the archive with --bundle", task),
+			)
+		}
+		fmt.Fprintf(out, "  Bundle written: %s\n", bundlePath)
+		fmt.Fprintf(out, "  To import elsewhere: rimba restore --from-bundle %s\n", bundlePath)
 		return nil
 	},
 }
 
+// exportArchiveBundle writes the just-archived task to a portable bundle.
+func exportArchiveBundle(cmd *cobra.Command, r git.Runner, wt resolver.WorktreeInfo, dst string, copyFiles []string) error {
+	ctx := cmd.Context()
+	var defaultSource string
+	if cfg := config.FromContext(ctx); cfg != nil {
+		defaultSource = cfg.DefaultSource
+	}
+	mainBranch, err := operations.ResolveMainBranch(ctx, r, defaultSource)
+	if err != nil {
+		return err
+	}
+	task, typeName := resolver.TaskAndType(wt.Branch, config.PrefixSetFromContext(ctx).Strip())
+	_, err = operations.ExportBundle(ctx, r, operations.ExportBundleParams{
+		Dst:        dst,
+		Task:       task,
+		Service:    wt.Service,
+		Type:       typeName,
+		Branch:     wt.Branch,
+		MainBranch: mainBranch,
+		CopyFiles:  copyFiles,
+	})
+	return err
+}
+
 func init() {
-	archiveCmd.Flags().BoolP(flagForce, "f", false, "force archival even if worktree is dirty")
+	archiveCmd.Flags().BoolP(flagForce, "f", false, "discard uncommitted changes instead of saving them for restore")
 	archiveCmd.Flags().Bool(flagDryRun, false, "preview what would be archived without making changes")
+	archiveCmd.Flags().String(flagBundle, "", "also export the archived task to this portable bundle file")
+	archiveCmd.Flags().Bool(flagNoCopyFiles, false, "leave copy_files entries out of the bundle")
 	rootCmd.AddCommand(archiveCmd)
 }
@@ -92,3 +92,66 @@ func TestArchiveNotFound(t *testing.T) {
 		t.Fatal("expected error for nonexistent task")
 	}
 }
+
+func TestArchiveReportsSavedChanges(t *testing.T) {
+	var updatedRef string
+	restore := overrideNewRunner(&mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case args[0] == cmdRevParse && args[1] == cmdShowToplevel:
+				return repoPath, nil
+			case args[0] == cmdWorktreeTest && args[1] == cmdList:
+				return wtRepo + headMainBlock + "\n" +
+					wtFeatureLogin + "\n" + headDEF456 + "\n" + branchRefFeatureLogin + "\n", nil
+			case args[0] == "update-ref":
+				updatedRef = args[1]
+			}
+			return "", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			switch {
+			case args[0] == "status":
+				return " M main.go", nil
+			case args[0] == cmdRevParse:
+				return "stash123", nil
+			case args[0] == "stash" && args[1] == "list":
+				return "stash123 stash@{0}", nil
+			}
+			return "", nil
+		},
+	})
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	if err := archiveCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("archiveCmd.RunE: %v", err)
+	}
+	if updatedRef != "refs/rimba/archive/"+branchFeature {
+		t.Errorf("update-ref target = %q", updatedRef)
+	}
+	if !strings.Contains(buf.String(), "Uncommitted changes saved: refs/rimba/archive/"+branchFeature) {
+		t.Errorf("output = %q, want saved-changes line", buf.String())
+	}
+}
+
+func TestArchiveNoCopyFilesRequiresBundle(t *testing.T) {
+	restore := overrideNewRunner(&mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == cmdWorktreeTest && args[1] == cmdList {
+				return wtRepo + headMainBlock + "\n" +
+					wtFeatureLogin + "\n" + headDEF456 + "\n" + branchRefFeatureLogin + "\n", nil
+			}
+			return "", nil
+		},
+		runInDir: noopRunInDir,
+	})
+	defer restore()
+
+	cmd, _ := newTestCmd()
+	cmd.Flags().Bool(flagNoCopyFiles, false, "")
+	_ = cmd.Flags().Set(flagNoCopyFiles, "true")
+	err := archiveCmd.RunE(cmd, []string{taskLogin})
+	if err == nil || !strings.Contains(err.Error(), "--no-copy-files requires --bundle") {
+		t.Fatalf("err = %v, want --no-copy-files requires --bundle", err)
+	}
+}
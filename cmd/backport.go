@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/gitref"
+	"github.com/lugassawan/rimba/internal/hint"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/spinner"
+	"github.com/lugassawan/rimba/internal/termcolor"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagTo = "to"
+
+	hintDryRunBackport = "Preview the worktrees and cherry-picks without creating anything"
+
+	backportPicked   = "picked"
+	backportConflict = "conflict"
+	backportFailed   = "failed"
+	backportPlanned  = "planned"
+)
+
+var backportCmd = &cobra.Command{
+	Use:   "backport <task> --to <branch>...",
+	Short: "Cherry-pick a task's commits onto one or more release branches",
+	Long: `Backports a task to release branches: for each --to branch, creates a
+worktree on a new branch cut from it and cherry-picks the task's commits —
+every commit since the task's branch left its base — with 'git cherry-pick -x'.
+
+Each backport branch keeps the task's prefix and appends the target to the
+task name (bugfix/crash to release/2.3 becomes bugfix/crash-release-2.3), and
+records the target as its base, so 'rimba sync' and 'rimba merge' follow the
+release line.
+
+Targets are independent. A conflicting cherry-pick is left in progress in its
+worktree for manual resolution, with the command that resumes it; the other
+targets carry on. The command exits non-zero when any target needs attention.`,
+	Example: `  rimba backport crash --to release/2.3                        # one release line
+  rimba backport crash --to release/2.3 --to release/2.2       # several at once
+  rimba backport crash --to release/2.3,release/2.2 --dry-run  # preview only`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := backportTargets(cmd)
+		if err != nil {
+			return err
+		}
+		dryRun, _ := cmd.Flags().GetBool(flagDryRun)
+
+		cfg := config.FromContext(cmd.Context())
+		r := newRunner(cmd.Context())
+
+		repoRoot, err := git.MainRepoRoot(cmd.Context(), r)
+		if err != nil {
+			return err
+		}
+		wt, err := findWorktree(cmd.Context(), r, args[0])
+		if err != nil {
+			return err
+		}
+		service, task, prefix := resolver.ServiceFromBranch(wt.Branch, cfg.PrefixSet().Strip())
+
+		if !isJSON(cmd) {
+			hint.New(cmd, hintPainter(cmd)).
+				Add(flagDryRun, hintDryRunBackport).
+				Show()
+		}
+
+		baseOf, err := operations.BaseResolver(cmd.Context(), r, cfg, cfg.DefaultSource)
+		if err != nil {
+			return err
+		}
+
+		s := spinner.New(spinnerOpts(cmd))
+		defer s.Stop()
+		s.Start("Collecting commits...")
+
+		result, err := operations.BackportWorktree(cmd.Context(), r, operations.BackportParams{
+			Source:      wt,
+			Base:        baseOf(wt.Branch),
+			Config:      cfg,
+			Targets:     targets,
+			Service:     service,
+			Prefix:      prefix,
+			Task:        task,
+			WorktreeDir: filepath.Join(repoRoot, cfg.WorktreeDir),
+			DryRun:      dryRun,
+		}, func(msg string) { s.Update(msg) })
+		s.Stop()
+		if err != nil {
+			return err
+		}
+
+		if isJSON(cmd) {
+			_ = output.WriteJSON(cmd.OutOrStdout(), version, "backport", backportJSON(result, dryRun))
+			if result.NeedsAttention() {
+				return &output.SilentError{ExitCode: 1}
+			}
+			return nil
+		}
+
+		noColor, _ := cmd.Flags().GetBool(flagNoColor)
+		printBackportResult(cmd, termcolor.NewPainter(noColor), result, dryRun)
+		if result.NeedsAttention() {
+			return errors.New("one or more backports need attention")
+		}
+		return nil
+	},
+}
+
+func init() {
+	backportCmd.Flags().StringSlice(flagTo, nil, "release branch to backport onto (repeatable or comma-separated)")
+	_ = backportCmd.MarkFlagRequired(flagTo)
+	backportCmd.Flags().Bool(flagDryRun, false, "preview the backport without making changes")
+	_ = backportCmd.RegisterFlagCompletionFunc(flagTo, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeBranchNames(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(backportCmd)
+}
+
+// backportTargets reads and validates --to, dropping repeats.
+func backportTargets(cmd *cobra.Command) ([]string, error) {
+	raw, _ := cmd.Flags().GetStringSlice(flagTo)
+	var targets []string
+	seen := make(map[string]bool, len(raw))
+	for _, t := range raw {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		if err := gitref.Validate(t); err != nil {
+			return nil, fmt.Errorf("--to %q: %w", t, err)
+		}
+		seen[t] = true
+		targets = append(targets, t)
+	}
+	if len(targets) == 0 {
+		return nil, errhint.WithFix(
+			errors.New("no target branch given"),
+			"pass one or more release branches: --to release/2.3",
+		)
+	}
+	return targets, nil
+}
+
+// backportStatus names a target's outcome for JSON output.
+func backportStatus(t operations.BackportTarget, dryRun bool) string {
+	switch {
+	case t.Error != nil:
+		return backportFailed
+	case t.Conflicted:
+		return backportConflict
+	case dryRun:
+		return backportPlanned
+	}
+	return backportPicked
+}
+
+func backportJSON(result operations.BackportResult, dryRun bool) output.BackportData {
+	data := output.BackportData{
+		Branch:  result.Branch,
+		Commits: nonNilStrings(result.Commits),
+		DryRun:  dryRun,
+		Targets: make([]output.BackportTargetJSON, 0, len(result.Targets)),
+	}
+	for _, t := range result.Targets {
+		data.Targets = append(data.Targets, output.BackportTargetJSON{
+			Target:    t.Target,
+			Branch:    t.Branch,
+			Path:      t.Path,
+			Status:    backportStatus(t, dryRun),
+			Conflicts: t.Conflicts,
+			Resume:    t.Resume,
+			Error:     errStr(t.Error),
+		})
+	}
+	return data
+}
+
+func printBackportResult(cmd *cobra.Command, p *termcolor.Painter, result operations.BackportResult, dryRun bool) {
+	out := cmd.OutOrStdout()
+	if dryRun {
+		for _, step := range result.Plan.Steps {
+			fmt.Fprintf(out, "[dry-run] %s\n", step)
+		}
+	}
+	fmt.Fprintf(out, "Backporting %s (%d commit(s))\n", result.Branch, len(result.Commits))
+	for _, t := range result.Targets {
+		switch backportStatus(t, dryRun) {
+		case backportFailed:
+			fmt.Fprintf(out, "  %s %s: %v\n", p.Paint("✗", termcolor.Red), t.Target, t.Error)
+		case backportConflict:
+			fmt.Fprintf(out, "  %s %s: conflicts in %s\n", p.Paint("!", termcolor.Yellow), t.Target, strings.Join(t.Conflicts, ", "))
+			fmt.Fprintf(out, "      resume: %s\n", t.Resume)
+		case backportPlanned:
+			fmt.Fprintf(out, "  - %s → %s\n", t.Target, t.Branch)
+		default:
+			fmt.Fprintf(out, "  %s %s → %s (%s)\n", p.Paint("✓", termcolor.Green), t.Target, t.Branch, t.Path)
+		}
+	}
+}
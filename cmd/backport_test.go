@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// backportTestRunner serves `rimba backport login` against a feature/login
+// worktree with one commit; no backport branch exists yet, and cherry-picks
+// fail with pickErr, leaving conflict.go unmerged.
+func backportTestRunner(commonDir string, pickErr error) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case len(args) >= 2 && args[1] == cmdGitCommonDir:
+				return commonDir, nil
+			case args[0] == cmdRevParse && args[1] == cmdShowToplevel:
+				return repoPath, nil
+			case args[0] == cmdWorktreeTest && args[1] == cmdList:
+				return wtRepo + headMainBlock + "\n" +
+					wtFeatureLogin + "\n" + headDEF456 + "\n" + branchRefFeatureLogin + "\n", nil
+			case args[0] == cmdRevParse && strings.HasPrefix(args[len(args)-1], "refs/heads/"):
+				return "", errGitFailed
+			case args[0] == "rev-list":
+				return "aaa", nil
+			}
+			return "abc123", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			switch args[0] {
+			case "cherry-pick":
+				return "", pickErr
+			case "diff":
+				if pickErr != nil {
+					return "conflict.go", nil
+				}
+			}
+			return "", nil
+		},
+	}
+}
+
+func newBackportTestCmd(dryRun bool, targets ...string) (*cobra.Command, *bytes.Buffer) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().StringSlice(flagTo, nil, "")
+	cmd.Flags().Bool(flagDryRun, false, "")
+	for _, t := range targets {
+		_ = cmd.Flags().Set(flagTo, t)
+	}
+	if dryRun {
+		_ = cmd.Flags().Set(flagDryRun, "true")
+	}
+	cmd.SetContext(config.WithConfig(context.Background(), testSyncConfig()))
+	return cmd, buf
+}
+
+func TestBackportPicked(t *testing.T) {
+	restore := overrideNewRunner(backportTestRunner(t.TempDir(), nil))
+	defer restore()
+
+	cmd, out := newBackportTestCmd(false, branchRelease23)
+	if err := backportCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("backportCmd.RunE: %v", err)
+	}
+	for _, want := range []string{"Backporting feature/login (1 commit(s))", "release/2.3 → feature/login-release-2.3"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q: %s", want, out.String())
+		}
+	}
+}
+
+func TestBackportConflictJSON(t *testing.T) {
+	restore := overrideNewRunner(backportTestRunner(t.TempDir(), errors.New("could not apply aaa")))
+	defer restore()
+
+	cmd, out := newBackportTestCmd(false, branchRelease23)
+	_ = cmd.Flags().Set(flagJSON, "true")
+	err := backportCmd.RunE(cmd, []string{taskLogin})
+	var silent *output.SilentError
+	if !errors.As(err, &silent) || silent.ExitCode != 1 {
+		t.Fatalf("err = %v, want SilentError exit 1", err)
+	}
+	for _, want := range []string{`"command": "backport"`, `"status": "conflict"`, `"conflict.go"`, `git cherry-pick --continue`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %s: %s", want, out.String())
+		}
+	}
+}
+
+func TestBackportDryRun(t *testing.T) {
+	restore := overrideNewRunner(backportTestRunner(t.TempDir(), errors.New("must not run")))
+	defer restore()
+
+	cmd, out := newBackportTestCmd(true, branchRelease23)
+	if err := backportCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("backportCmd.RunE: %v", err)
+	}
+	for _, want := range []string{"[dry-run] create worktree", "[dry-run] cherry-pick 1 commit(s)", "- release/2.3 → feature/login-release-2.3"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q: %s", want, out.String())
+		}
+	}
+}
+
+func TestBackportTargetsValidation(t *testing.T) {
+	cmd, _ := newBackportTestCmd(false, "bad..ref")
+	if _, err := backportTargets(cmd); err == nil || !strings.Contains(err.Error(), "--to") {
+		t.Errorf("err = %v, want --to validation error", err)
+	}
+
+	cmd, _ = newBackportTestCmd(false, " ", branchRelease23, branchRelease23)
+	targets, err := backportTargets(cmd)
+	if err != nil || len(targets) != 1 {
+		t.Errorf("targets = %v, %v; want one deduplicated target", targets, err)
+	}
+
+	cmd, _ = newBackportTestCmd(false, " ")
+	if _, err := backportTargets(cmd); err == nil {
+		t.Error("expected error for empty --to")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/hint"
 	"github.com/lugassawan/rimba/internal/operations"
@@ -31,17 +32,24 @@ const (
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Prune stale worktree references or remove merged worktrees",
-	Long:  "Runs git worktree prune to clean up stale references and prunes stale remote-tracking refs across all remotes. Use --merged to detect and remove worktrees whose branches have been merged into main.",
+	Long:  "Runs git worktree prune to clean up stale references and prunes stale remote-tracking refs across all remotes. Use --merged to detect and remove worktrees whose branches have been merged into main. With --merged or --stale, --tag and --owner limit removal to worktrees with matching metadata.",
 	Example: `  rimba clean
   rimba clean --dry-run
   rimba clean --merged
-  rimba clean --stale --stale-days 7`,
+  rimba clean --stale --stale-days 7
+  rimba clean --stale --tag spike`,
 	Annotations: map[string]string{annotationSkipConfig: annotationValueTrue},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SetContext(withBestEffortConfig(cmd))
 		r := newRunner(cmd.Context())
 		merged, _ := cmd.Flags().GetBool(flagMerged)
 		stale, _ := cmd.Flags().GetBool(flagStale)
+		if !merged && !stale && !metaFilterFromFlags(cmd).IsEmpty() {
+			return errhint.WithFix(
+				errors.New("--tag and --owner require --merged or --stale"),
+				"run: rimba clean --merged --tag <tag>  OR  rimba clean --stale --tag <tag>",
+			)
+		}
 
 		switch {
 		case merged:
@@ -60,6 +68,7 @@ func init() {
 	cleanCmd.Flags().Bool(flagStale, false, "remove worktrees with no recent commits")
 	cleanCmd.Flags().Int(flagStaleDays, defaultStaleDays, "number of days to consider a worktree stale (used with --stale)")
 	cleanCmd.Flags().Bool(flagForce, false, "skip confirmation and force-remove dirty worktrees (with --merged or --stale)")
+	addMetaFilterFlags(cleanCmd)
 
 	cleanCmd.MarkFlagsMutuallyExclusive(flagMerged, flagStale)
 
@@ -340,11 +349,14 @@ func cleanMerged(ctx context.Context, cmd *cobra.Command, r git.Runner) error {
 			return err
 		},
 		find: func(rr git.Runner) ([]operations.CleanCandidate, []string, error) {
-			result, err := operations.FindMergedCandidates(ctx, rr, mergeRef, mainBranch)
+			baseOf, _ := operations.BaseResolver(ctx, rr, config.FromContext(cmd.Context()), mainBranch) // main as a guessed base finds fewer merged branches, never more
+			refOf := operations.BaseMergeRefs(ctx, rr, mainBranch, mergeRef, baseOf)
+			result, err := operations.FindMergedCandidatesFor(ctx, rr, mergeRef, mainBranch, refOf)
 			if err != nil {
 				return nil, nil, err
 			}
-			return result.Candidates, result.Warnings, nil
+			candidates, err := operations.SelectByMeta(ctx, rr, result.Candidates, cleanCandidateBranch, metaFilterFromFlags(cmd))
+			return candidates, result.Warnings, err
 		},
 		printRows: func(candidates []operations.CleanCandidate) {
 			printMergedCandidates(cmd, candidates, remotePresent)
@@ -388,7 +400,12 @@ func cleanStale(ctx context.Context, cmd *cobra.Command, r git.Runner) error {
 			if err != nil {
 				return nil, nil, err
 			}
-			staleCandidates = result.Candidates
+			staleCandidates, err = operations.SelectByMeta(ctx, rr, result.Candidates, func(c operations.StaleCandidate) string {
+				return c.Branch
+			}, metaFilterFromFlags(cmd))
+			if err != nil {
+				return nil, nil, err
+			}
 			return flattenStaleCandidates(result.Candidates), result.Warnings, nil
 		},
 		printRows: func(_ []operations.CleanCandidate) {
@@ -442,6 +459,10 @@ func cleanRemoveCandidates(ctx context.Context, cmd *cobra.Command, r git.Runner
 	return removed
 }
 
+func cleanCandidateBranch(c operations.CleanCandidate) string {
+	return c.Branch
+}
+
 func flattenStaleCandidates(candidates []operations.StaleCandidate) []operations.CleanCandidate {
 	out := make([]operations.CleanCandidate, len(candidates))
 	for i, c := range candidates {
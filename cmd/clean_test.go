@@ -1208,3 +1208,16 @@ func TestCleanStaleJSON(t *testing.T) {
 		t.Errorf("last_commit = %q, not valid RFC3339: %v", lastCommit, err)
 	}
 }
+
+func TestCleanMetaFilterRequiresMergedOrStale(t *testing.T) {
+	cmd, _ := newTestCmd()
+	cmd.Flags().Bool(flagMerged, false, "")
+	cmd.Flags().Bool(flagStale, false, "")
+	addMetaFilterFlags(cmd)
+	_ = cmd.Flags().Set(flagTag, "spike")
+
+	err := cleanCmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "require --merged or --stale") {
+		t.Fatalf("err = %v, want --tag/--owner mode error", err)
+	}
+}
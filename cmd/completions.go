@@ -94,6 +94,21 @@ func completeOpenShortcuts(cmd *cobra.Command, toComplete string) []string {
 	return names
 }
 
+// completeProfilesFlag completes --profile with the configured profile names.
+func completeProfilesFlag(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg := config.FromContext(cmdContext(cmd))
+	if cfg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, name := range cfg.ProfileNames() {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
 // completeArchivedTasks returns task names from archived branches (branches not in any active worktree).
 func completeArchivedTasks(cmd *cobra.Command, toComplete string) []string {
 	ctx := cmdContext(cmd)
@@ -464,3 +464,19 @@ func TestMergeIntoFlagCompletion(t *testing.T) {
 		}
 	})
 }
+
+func TestCompleteProfilesFlag(t *testing.T) {
+	cfg := &config.Config{Profiles: map[string]config.Profile{"perf": {}, "review": {}, "profile-x": {}}}
+	cmd, _ := newTestCmd()
+	cmd.SetContext(config.WithConfig(context.Background(), cfg))
+
+	names, _ := completeProfilesFlag(cmd, nil, "p")
+	if len(names) != 2 || names[0] != "perf" || names[1] != "profile-x" {
+		t.Errorf("names = %v, want [perf profile-x]", names)
+	}
+
+	cmd.SetContext(context.Background())
+	if names, _ := completeProfilesFlag(cmd, nil, ""); names != nil {
+		t.Errorf("names = %v, want nil without config", names)
+	}
+}
@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/hint"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/output"
@@ -20,24 +22,53 @@ type conflictCheckJSONData struct {
 	DryMerges     []conflict.DryMergeResult `json:"dry_merges,omitempty"`
 	TotalFiles    int                       `json:"total_files"`
 	TotalBranches int                       `json:"total_branches"`
+	// Heatmap rolls the overlaps up by directory.
+	Heatmap []conflict.DirHeat `json:"heatmap"`
+	// Nodes and Edges are the conflict graph (see --format).
+	Nodes []conflict.GraphNode `json:"nodes"`
+	Edges []conflict.GraphEdge `json:"edges"`
 }
 
 const (
 	flagDryMerge = "dry-merge"
+	flagHunks    = "hunks"
+
+	flagIncludeWorktree  = "include-worktree"
+	flagIncludeUntracked = "include-untracked"
+	flagWithPRs          = "with-prs"
+	flagNoCache          = "no-cache"
 
 	hintDryMerge = "Simulate merges with git merge-tree (git 2.38+)"
+	hintHunks    = "Compare changed line ranges, not just files"
+
+	hintIncludeWorktree = "Include uncommitted changes in each worktree"
+	hintWithPRs         = "Check against teammates' open PRs too"
 )
 
 var conflictCheckCmd = &cobra.Command{
 	Use:   "conflict-check",
 	Short: "Detect file overlaps between worktree branches",
-	Long:  "Scans all active worktrees and reports files modified in multiple branches, indicating potential merge conflicts.",
+	Long:  "Scans all active worktrees and reports files modified in multiple branches, indicating potential merge conflicts.\n\nWith --hunks, the line ranges each branch changes are compared too (following renames): a file two branches change in disjoint places is reported as low severity, and only overlapping or adjacent line ranges are high.\n\nWith --include-worktree, each worktree's staged and unstaged changes count too, and with --include-untracked its untracked files; branches whose side of an overlap is uncommitted are marked with *.\n\nWith --with-prs, the open PRs against the default branch are listed with gh, their heads fetched into refs/rimba/prs/, and checked too; overlaps with a PR name its number and author.\n\nEach overlap names its file's owners from the main worktree's CODEOWNERS (.github/, the root, or docs/), and the overlaps are rolled up into a heatmap of the directories they fall in. With --owner, only the overlaps (and dry merge conflicts) in files that owner owns are reported.\n\nWith --format dot or --format mermaid, the overlaps are printed as a graph instead: branches as nodes, numbered by their step in the merge plan (see merge-plan), joined by edges weighted by their overlaps. JSON output always includes the graph as nodes and edges.\n\nCommitted diffs and dry merges are cached under the git common dir, keyed by commit SHAs, so a rerun only recomputes branches that moved; --no-cache bypasses the cache.",
 	Example: `  rimba conflict-check
-  rimba conflict-check --dry-merge`,
+  rimba conflict-check --hunks
+  rimba conflict-check --include-worktree
+  rimba conflict-check --with-prs
+  rimba conflict-check --dry-merge
+  rimba conflict-check --owner @org/payments
+  rimba conflict-check --format mermaid`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		cfg := config.FromContext(cmd.Context())
 
+		format, err := graphFormat(cmd)
+		if err != nil {
+			return err
+		}
+
 		r := newRunner(cmd.Context())
+		owners, owner, err := codeOwnersFilter(cmd, r)
+		if err != nil {
+			return err
+		}
 
 		worktrees, err := listWorktreeInfos(cmd.Context(), r)
 		if err != nil {
@@ -49,17 +80,28 @@ var conflictCheckCmd = &cobra.Command{
 		eligible := operations.FilterEligible(worktrees, prefixes, cfg.DefaultSource, allTasks, true)
 
 		if len(eligible) == 0 {
+			empty := conflict.BuildGraph(nil, nil, nil, nil)
 			if isJSON(cmd) {
 				return output.WriteJSON(cmd.OutOrStdout(), version, "conflict-check", conflictCheckJSONData{
 					Overlaps: make([]conflict.FileOverlap, 0),
+					Heatmap:  make([]conflict.DirHeat, 0),
+					Nodes:    empty.Nodes,
+					Edges:    empty.Edges,
 				})
 			}
+			if format != "" {
+				writeGraph(cmd, empty, format, prefixes)
+				return nil
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), "No active worktree branches found.")
 			return nil
 		}
 
-		if !isJSON(cmd) {
+		if !isJSON(cmd) && format == "" {
 			hint.New(cmd, hintPainter(cmd)).
+				Add(flagHunks, hintHunks).
+				Add(flagIncludeWorktree, hintIncludeWorktree).
+				Add(flagWithPRs, hintWithPRs).
 				Add(flagDryMerge, hintDryMerge).
 				Show()
 		}
@@ -68,25 +110,50 @@ var conflictCheckCmd = &cobra.Command{
 		defer s.Stop()
 		s.Start("Collecting file changes...")
 
-		diffs, err := conflict.CollectDiffs(cmd.Context(), r, cfg.DefaultSource, eligible)
+		branches, prs, err := withOpenPRs(cmd, r, cfg, eligible, s)
 		if err != nil {
 			return err
 		}
 
-		result := conflict.DetectOverlaps(diffs)
+		opts := conflictOptions(cmd)
+		opts.Cache = conflictCache(cmd, r)
+		s.Update("Collecting file changes...")
+		baseOf, _ := operations.BaseResolver(cmd.Context(), r, cfg, cfg.DefaultSource) // a report: configured bases will do
+		result, err := conflict.Analyze(cmd.Context(), r, baseOf, branches, opts)
+		if err != nil {
+			return err
+		}
+		conflict.AttachPRs(result, prs)
 
 		dryMerge, _ := cmd.Flags().GetBool(flagDryMerge)
 		var dryResults []conflict.DryMergeResult
 		if dryMerge {
 			s.Update("Running dry merges...")
-			dryResults, err = conflict.DryMergeAll(cmd.Context(), r, eligible)
+			dryResults, err = conflict.DryMergeAllCached(cmd.Context(), r, branches, opts.Cache)
 			if err != nil {
 				return err
 			}
+			dryResults = conflict.AttachDryMergePRs(dryResults, prs)
 		}
+		dryResults = applyOwners(result, dryResults, owners, owner)
+		_ = opts.Cache.Save() // best-effort: the next run recomputes what is missing
 
 		s.Stop()
 
+		planOpts, err := operations.MergePlanOptions(cmd.Context(), r, eligible, prefixes, nil)
+		if err != nil {
+			return err
+		}
+		planOpts.DryMerges = dryResults
+		_, graph, err := operations.PlanMergeGraph(result.Overlaps, eligible, prs, planOpts)
+		if err != nil {
+			return err
+		}
+		if format != "" {
+			writeGraph(cmd, graph, format, prefixes)
+			return nil
+		}
+
 		if isJSON(cmd) {
 			overlaps := result.Overlaps
 			if overlaps == nil {
@@ -96,6 +163,9 @@ var conflictCheckCmd = &cobra.Command{
 				Overlaps:      overlaps,
 				TotalFiles:    result.TotalFiles,
 				TotalBranches: result.TotalBranches,
+				Heatmap:       conflict.Heatmap(result.Overlaps),
+				Nodes:         graph.Nodes,
+				Edges:         graph.Edges,
 			}
 			if dryMerge {
 				data.DryMerges = dryResults
@@ -107,12 +177,17 @@ var conflictCheckCmd = &cobra.Command{
 		p := termcolor.NewPainter(noColor)
 
 		if len(result.Overlaps) == 0 && !hasConflicts(dryResults) {
+			if owner != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "No file overlaps found in files owned by %s.\n", owner)
+				return nil
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), "No file overlaps found between active worktree branches.")
 			return nil
 		}
 
 		if len(result.Overlaps) > 0 {
-			renderOverlapTable(cmd, p, result, prefixes)
+			renderOverlapTable(cmd, p, result, prefixes, opts.Hunks)
+			renderHeatmap(cmd, p, result.Overlaps, prefixes)
 		}
 
 		if dryMerge {
@@ -121,6 +196,9 @@ var conflictCheckCmd = &cobra.Command{
 
 		fmt.Fprintf(cmd.OutOrStdout(), "\n%d file overlap(s) found across %d branches.\n",
 			len(result.Overlaps), result.TotalBranches)
+		if n := overlapsWithPRs(result.Overlaps); n > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d of them involve open PRs.\n", n)
+		}
 
 		return nil
 	},
@@ -128,27 +206,63 @@ var conflictCheckCmd = &cobra.Command{
 
 func init() {
 	conflictCheckCmd.Flags().Bool(flagDryMerge, false, "simulate merges with git merge-tree (git 2.38+)")
+	conflictCheckCmd.Flags().Bool(flagHunks, false, "compare the line ranges each branch changes (git diff -U0 -M), not just files")
+	conflictCheckCmd.Flags().Bool(flagIncludeWorktree, false, "include each worktree's staged and unstaged changes")
+	conflictCheckCmd.Flags().Bool(flagIncludeUntracked, false, "include each worktree's untracked files (implies --include-worktree)")
+	conflictCheckCmd.Flags().Bool(flagWithPRs, false, "check against the open PRs against the default branch too (requires gh)")
+	conflictCheckCmd.Flags().Bool(flagNoCache, false, "recompute every diff and dry merge instead of reusing cached results")
+	conflictCheckCmd.Flags().String(flagOwner, "", "only overlaps in files this CODEOWNERS owner owns (e.g. @org/team)")
+	addFormatFlag(conflictCheckCmd)
 	rootCmd.AddCommand(conflictCheckCmd)
 }
 
-func renderOverlapTable(cmd *cobra.Command, p *termcolor.Painter, result *conflict.CheckResult, prefixes []string) {
+// conflictOptions reads the overlap detection flags.
+func conflictOptions(cmd *cobra.Command) conflict.Options {
+	hunks, _ := cmd.Flags().GetBool(flagHunks)
+	worktree, _ := cmd.Flags().GetBool(flagIncludeWorktree)
+	untracked, _ := cmd.Flags().GetBool(flagIncludeUntracked)
+	return conflict.Options{Hunks: hunks, Worktree: worktree || untracked, Untracked: untracked}
+}
+
+// conflictCache loads the conflict analysis cache, or returns nil — no
+// caching — with --no-cache.
+func conflictCache(cmd *cobra.Command, r git.Runner) *conflict.Cache {
+	if noCache, _ := cmd.Flags().GetBool(flagNoCache); noCache {
+		return nil
+	}
+	return operations.LoadConflictCache(cmd.Context(), r)
+}
+
+// withOpenPRs adds the open PRs against the default branch to branches
+// when --with-prs is set, returning the PRs keyed by their fetched ref.
+func withOpenPRs(cmd *cobra.Command, r git.Runner, cfg *config.Config, branches []resolver.WorktreeInfo, s *spinner.Spinner) ([]resolver.WorktreeInfo, map[string]conflict.PR, error) {
+	if withPRs, _ := cmd.Flags().GetBool(flagWithPRs); !withPRs {
+		return branches, nil, nil
+	}
+	open, err := operations.FetchOpenPRs(cmd.Context(), r, newGHRunner(cmd.Context()), cfg.DefaultSource, branches, func(msg string) { s.Update(msg) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(slices.Clone(branches), open.Branches...), open.PRs, nil
+}
+
+func renderOverlapTable(cmd *cobra.Command, p *termcolor.Painter, result *conflict.CheckResult, prefixes []string, hunks bool) {
 	tbl := termcolor.NewTable(2)
-	tbl.AddRow(
-		p.Paint("FILE", termcolor.Bold),
-		p.Paint("BRANCHES", termcolor.Bold),
-		p.Paint("SEVERITY", termcolor.Bold),
-	)
+	header := []string{p.Paint("FILE", termcolor.Bold), p.Paint("BRANCHES", termcolor.Bold)}
+	if hunks {
+		header = append(header, p.Paint("LINES", termcolor.Bold))
+	}
+	header = append(header, p.Paint("SEVERITY", termcolor.Bold))
+	owned := hasOwners(result.Overlaps)
+	if owned {
+		header = append(header, p.Paint("OWNERS", termcolor.Bold))
+	}
+	tbl.AddRow(header...)
 
+	uncommitted := false
 	for _, o := range result.Overlaps {
-		branchLabels := make([]string, len(o.Branches))
-		for i, b := range o.Branches {
-			task, prefix := resolver.PureTaskFromBranch(b, prefixes)
-			if prefix != "" {
-				branchLabels[i] = task
-			} else {
-				branchLabels[i] = b
-			}
-		}
+		branchLabels := overlapBranchLabels(o, prefixes)
+		uncommitted = uncommitted || len(o.Uncommitted) > 0
 
 		sevLabel := conflict.SeverityLabel(o)
 		var sevColor termcolor.Color
@@ -158,14 +272,74 @@ func renderOverlapTable(cmd *cobra.Command, p *termcolor.Painter, result *confli
 			sevColor = termcolor.Yellow
 		}
 
-		tbl.AddRow(
-			o.File,
-			strings.Join(branchLabels, ", "),
-			p.Paint(sevLabel, sevColor),
-		)
+		row := []string{overlapFileLabel(o), strings.Join(branchLabels, ", ")}
+		if hunks {
+			row = append(row, conflict.LinesLabel(o))
+		}
+		row = append(row, p.Paint(sevLabel, sevColor))
+		if owned {
+			row = append(row, strings.Join(o.Owners, ", "))
+		}
+		tbl.AddRow(row...)
 	}
 
 	tbl.Render(cmd.OutOrStdout())
+	if uncommitted {
+		fmt.Fprintln(cmd.OutOrStdout(), p.Paint("* uncommitted changes in the worktree", termcolor.Gray))
+	}
+}
+
+// overlapBranchLabels returns the overlap's branches as tasks, marking
+// those whose side of it is uncommitted with *.
+func overlapBranchLabels(o conflict.FileOverlap, prefixes []string) []string {
+	labels := make([]string, len(o.Branches))
+	for i, b := range o.Branches {
+		labels[i] = branchLabel(b, prefixes, o.PRs)
+		if slices.Contains(o.Uncommitted, b) {
+			labels[i] += "*"
+		}
+	}
+	return labels
+}
+
+// branchLabel returns branch as a task, or as "#<number> (@<author>)" when
+// it is the fetched head of one of prs.
+func branchLabel(branch string, prefixes []string, prs []conflict.PR) string {
+	if i := slices.IndexFunc(prs, func(pr conflict.PR) bool { return pr.Ref == branch }); i >= 0 {
+		return prs[i].Label()
+	}
+	task, prefix := resolver.PureTaskFromBranch(branch, prefixes)
+	if prefix == "" {
+		return branch
+	}
+	return task
+}
+
+// overlapsWithPRs counts the overlaps involving an open PR.
+func overlapsWithPRs(overlaps []conflict.FileOverlap) int {
+	n := 0
+	for _, o := range overlaps {
+		if len(o.PRs) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// overlapFileLabel returns the overlap's file, followed by the paths
+// branches renamed it to.
+func overlapFileLabel(o conflict.FileOverlap) string {
+	if len(o.Renames) == 0 {
+		return o.File
+	}
+	var renamed []string
+	for _, path := range o.Renames {
+		if !slices.Contains(renamed, path) {
+			renamed = append(renamed, path)
+		}
+	}
+	slices.Sort(renamed)
+	return o.File + " → " + strings.Join(renamed, ", ")
 }
 
 func renderDryMergeResults(cmd *cobra.Command, p *termcolor.Painter, results []conflict.DryMergeResult, prefixes []string) {
@@ -191,10 +365,8 @@ func renderDryMergeResults(cmd *cobra.Command, p *termcolor.Painter, results []c
 	)
 
 	for _, r := range conflicting {
-		task1, _ := resolver.PureTaskFromBranch(r.Branch1, prefixes)
-		task2, _ := resolver.PureTaskFromBranch(r.Branch2, prefixes)
 		files := p.Paint(strings.Join(r.ConflictFiles, ", "), termcolor.Red)
-		tbl.AddRow(task1, task2, files)
+		tbl.AddRow(branchLabel(r.Branch1, prefixes, r.PRs), branchLabel(r.Branch2, prefixes, r.PRs), files)
 	}
 
 	tbl.Render(cmd.OutOrStdout())
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -256,7 +260,7 @@ func TestRenderOverlapTableBranchWithoutPrefix(t *testing.T) {
 		TotalBranches: 2,
 	}
 
-	renderOverlapTable(cmd, p, result, nil)
+	renderOverlapTable(cmd, p, result, nil, false)
 	out := buf.String()
 	if !strings.Contains(out, "custom-branch") {
 		t.Errorf("expected branch name without prefix, got: %s", out)
@@ -380,3 +384,192 @@ func TestConflictCheckDiffError(t *testing.T) {
 		t.Fatal("expected error from diff failure")
 	}
 }
+
+func TestConflictCheckHunks(t *testing.T) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagHunks, false, "")
+	_ = cmd.Flags().Set(flagHunks, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == cmdWorktreeTest && args[1] == cmdList {
+				return worktreeListOutput(branchFeatureA, branchFeatureB), nil
+			}
+			if args[0] == cmdDiff {
+				// a edits the top of big.go and renames old.go; b edits
+				// the bottom of big.go and the same lines of old.go.
+				if strings.Contains(args[len(args)-1], branchFeatureA) {
+					return "diff --git a/big.go b/big.go\n@@ -3,2 +3,2 @@\n" +
+						"diff --git a/old.go b/new.go\nrename from old.go\nrename to new.go\n@@ -7 +7 @@", nil
+				}
+				return "diff --git a/big.go b/big.go\n@@ -2900 +2900 @@\n" +
+					"diff --git a/old.go b/old.go\n@@ -6,3 +6,3 @@", nil
+			}
+			return "", nil
+		},
+	}
+	restore := overrideNewRunner(r)
+	defer restore()
+
+	if err := conflictCheckCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"LINES", "old.go → new.go", "6-8", "high (2)", "low (2)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConflictCheckIncludeWorktree(t *testing.T) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagIncludeWorktree, false, "")
+	_ = cmd.Flags().Set(flagIncludeWorktree, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == cmdWorktreeTest && args[1] == cmdList {
+				return worktreeListOutput(branchFeatureA, branchFeatureB), nil
+			}
+			if args[0] == cmdDiff && strings.Contains(args[len(args)-1], branchFeatureA) {
+				return diffOutputSharedA, nil
+			}
+			return "b-only.go", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			// feature/b is editing shared.go without having committed it.
+			if args[0] == cmdDiff && dir == "/wt/feature-b" {
+				return "shared.go", nil
+			}
+			return "", nil
+		},
+	}
+	restore := overrideNewRunner(r)
+	defer restore()
+
+	if err := conflictCheckCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"shared.go", "a, b*", "* uncommitted changes in the worktree"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConflictCheckIncludeUntrackedImpliesWorktree(t *testing.T) {
+	cmd, _ := newTestCmd()
+	cmd.Flags().Bool(flagIncludeUntracked, false, "")
+	_ = cmd.Flags().Set(flagIncludeUntracked, "true")
+	if opts := conflictOptions(cmd); !opts.Worktree || !opts.Untracked {
+		t.Errorf("options = %+v, want worktree and untracked", opts)
+	}
+}
+
+const openPRJSON = `[{"number":12,"author":{"login":"alice"},"headRefName":"search","isCrossRepository":false}]`
+
+// withOpenPRGh puts a dummy gh on PATH and serves openPRJSON as the open
+// PRs for --with-prs.
+func withOpenPRGh(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Cleanup(overrideGHRunner(makeOKGhRunner(openPRJSON)))
+}
+
+// openPRGitRunner lists feature/a as the only worktree and has it share
+// shared.go with the fetched head of PR #12.
+func openPRGitRunner(fetched *[]string) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case args[0] == cmdWorktreeTest && args[1] == cmdList:
+				return worktreeListOutput(branchFeatureA), nil
+			case args[0] == "fetch":
+				*fetched = args
+			case args[0] == cmdDiff && strings.Contains(args[len(args)-1], "refs/rimba/prs/12"):
+				return "shared.go\npr-only.go", nil
+			case args[0] == cmdDiff:
+				return diffOutputSharedA, nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestConflictCheckWithPRs(t *testing.T) {
+	withOpenPRGh(t)
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagWithPRs, false, "")
+	_ = cmd.Flags().Set(flagWithPRs, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	var fetched []string
+	restore := overrideNewRunner(openPRGitRunner(&fetched))
+	defer restore()
+
+	if err := conflictCheckCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	if !slices.Contains(fetched, "+refs/pull/12/head:refs/rimba/prs/12") {
+		t.Errorf("fetch args = %v, want PR #12's head fetched into refs/rimba/prs/12", fetched)
+	}
+	out := buf.String()
+	for _, want := range []string{"shared.go", "a, #12 (@alice)", "1 of them involve open PRs."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConflictCheckWithPRsJSON(t *testing.T) {
+	withOpenPRGh(t)
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagWithPRs, false, "")
+	_ = cmd.Flags().Set(flagJSON, "true")
+	_ = cmd.Flags().Set(flagWithPRs, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	var fetched []string
+	restore := overrideNewRunner(openPRGitRunner(&fetched))
+	defer restore()
+
+	if err := conflictCheckCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	var env struct {
+		Data conflictCheckJSONData `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	if len(env.Data.Overlaps) != 1 {
+		t.Fatalf("overlaps = %+v, want shared.go only", env.Data.Overlaps)
+	}
+	want := []conflict.PR{{Ref: "refs/rimba/prs/12", Number: 12, Author: "alice"}}
+	if got := env.Data.Overlaps[0].PRs; !reflect.DeepEqual(got, want) {
+		t.Errorf("prs = %+v, want %+v", got, want)
+	}
+	if n := len(env.Data.Nodes); n != 2 || env.Data.Nodes[1].PR == nil || env.Data.Nodes[1].Order != 0 {
+		t.Errorf("nodes = %+v, want feature/a then PR #12 without a step", env.Data.Nodes)
+	}
+	if len(env.Data.Edges) != 1 || env.Data.Edges[0].To != "refs/rimba/prs/12" {
+		t.Errorf("edges = %+v, want feature/a joined to PR #12", env.Data.Edges)
+	}
+}
+
+func TestConflictCacheNoCache(t *testing.T) {
+	cmd, _ := newTestCmd()
+	cmd.Flags().Bool(flagNoCache, false, "")
+	_ = cmd.Flags().Set(flagNoCache, "true")
+	if c := conflictCache(cmd, &mockRunner{}); c != nil {
+		t.Errorf("conflictCache with --no-cache = %+v, want nil", c)
+	}
+}
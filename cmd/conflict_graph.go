@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagFormat = "format"
+
+	formatTable   = "table"
+	formatDOT     = "dot"
+	formatMermaid = "mermaid"
+)
+
+// addFormatFlag registers --format on conflict-check and merge-plan.
+func addFormatFlag(c *cobra.Command) {
+	c.Flags().String(flagFormat, formatTable, "output format: table, or the conflict graph as dot or mermaid")
+	_ = c.RegisterFlagCompletionFunc(flagFormat, cobra.FixedCompletions(
+		[]string{formatTable, formatDOT, formatMermaid}, cobra.ShellCompDirectiveNoFileComp,
+	))
+}
+
+// graphFormat reads --format, returning "" for the table.
+func graphFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString(flagFormat)
+	switch format {
+	case formatTable, "":
+		return "", nil
+	case formatDOT, formatMermaid:
+		if isJSON(cmd) {
+			return "", errhint.WithFix(
+				errors.New("--format and --json cannot be combined"),
+				"drop --format: JSON output already includes the graph as nodes and edges",
+			)
+		}
+		return format, nil
+	default:
+		return "", errhint.WithFix(
+			fmt.Errorf("invalid format %q", format),
+			"use --format table, dot, or mermaid",
+		)
+	}
+}
+
+// planLabel labels branches as the tables do, and open PRs in prs by
+// number and author.
+func planLabel(prefixes []string, prs map[string]conflict.PR) func(string) string {
+	return func(branch string) string {
+		if pr, ok := prs[branch]; ok {
+			return pr.Label()
+		}
+		return branchLabel(branch, prefixes, nil)
+	}
+}
+
+// writeGraph prints g in format, labeling branches as the tables do.
+func writeGraph(cmd *cobra.Command, g conflict.Graph, format string, prefixes []string) {
+	label := func(n conflict.GraphNode) string {
+		if n.PR != nil {
+			return n.PR.Label()
+		}
+		return branchLabel(n.Branch, prefixes, nil)
+	}
+	if format == formatDOT {
+		fmt.Fprint(cmd.OutOrStdout(), g.DOT(label))
+		return
+	}
+	fmt.Fprint(cmd.OutOrStdout(), g.Mermaid(label))
+}
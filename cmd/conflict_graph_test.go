@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+)
+
+// overlapABRunner lists feature/a, feature/b and feature/c, with a and b
+// sharing shared.go.
+func overlapABRunner() *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == cmdWorktreeTest && args[1] == cmdList {
+				return worktreeListOutput(branchFeatureA, branchFeatureB, branchFeatureC), nil
+			}
+			if args[0] == cmdDiff {
+				last := args[len(args)-1]
+				if strings.Contains(last, branchFeatureA) {
+					return diffOutputSharedA, nil
+				}
+				if strings.Contains(last, branchFeatureB) {
+					return diffOutputSharedB, nil
+				}
+				return "c-only.go", nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestGraphFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		json    bool
+		want    string
+		wantErr string
+	}{
+		{format: formatTable},
+		{format: formatDOT, want: formatDOT},
+		{format: formatMermaid, want: formatMermaid},
+		{format: "svg", wantErr: `invalid format "svg"`},
+		{format: formatDOT, json: true, wantErr: "cannot be combined"},
+	}
+	for _, tt := range tests {
+		cmd, _ := newTestCmd()
+		addFormatFlag(cmd)
+		_ = cmd.Flags().Set(flagFormat, tt.format)
+		if tt.json {
+			_ = cmd.Flags().Set(flagJSON, "true")
+		}
+
+		got, err := graphFormat(cmd)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("graphFormat(%q, json=%v) error = %v, want %q", tt.format, tt.json, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("graphFormat(%q) = %q, %v; want %q", tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestMergePlanFormatDOT(t *testing.T) {
+	cmd, buf := newTestCmd()
+	addFormatFlag(cmd)
+	_ = cmd.Flags().Set(flagFormat, formatDOT)
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	restore := overrideNewRunner(overlapABRunner())
+	defer restore()
+
+	if err := mergePlanCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"graph conflicts {",
+		`"feature/c" [label="1. c"];`,
+		`"feature/a" [label="2. a"];`,
+		`"feature/a" -- "feature/b" [label="1"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Merge in this order") {
+		t.Errorf("graph output should not include the table footer:\n%s", out)
+	}
+}
+
+func TestMergePlanJSON(t *testing.T) {
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	restore := overrideNewRunner(overlapABRunner())
+	defer restore()
+
+	if err := mergePlanCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	var env struct {
+		Command string            `json:"command"`
+		Data    mergePlanJSONData `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	if env.Command != "merge-plan" {
+		t.Errorf("command = %q, want merge-plan", env.Command)
+	}
+	if len(env.Data.Steps) != 3 || env.Data.Steps[0].Branch != branchFeatureC || env.Data.Steps[0].Task != "c" {
+		t.Errorf("steps = %+v, want feature/c first", env.Data.Steps)
+	}
+	if len(env.Data.Nodes) != 3 || env.Data.Nodes[0].Order != 1 {
+		t.Errorf("nodes = %+v, want 3 in plan order", env.Data.Nodes)
+	}
+	if len(env.Data.Edges) != 1 || env.Data.Edges[0].Weight != 1 {
+		t.Errorf("edges = %+v, want a-b of weight 1", env.Data.Edges)
+	}
+}
+
+func TestConflictCheckFormatMermaid(t *testing.T) {
+	cmd, buf := newTestCmd()
+	addFormatFlag(cmd)
+	_ = cmd.Flags().Set(flagFormat, formatMermaid)
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	restore := overrideNewRunner(overlapABRunner())
+	defer restore()
+
+	if err := conflictCheckCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	want := "graph LR\n" +
+		"  n0[\"1. c\"]\n" +
+		"  n1[\"2. a\"]\n" +
+		"  n2[\"3. b\"]\n" +
+		"  n1 ---|1| n2\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConflictCheckFormatNoWorktrees(t *testing.T) {
+	cmd, buf := newTestCmd()
+	addFormatFlag(cmd)
+	_ = cmd.Flags().Set(flagFormat, formatDOT)
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	restore := overrideNewRunner(&mockRunner{
+		run: func(args ...string) (string, error) {
+			return "worktree /repo\nHEAD abc123\nbranch refs/heads/main\n", nil
+		},
+	})
+	defer restore()
+
+	if err := conflictCheckCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	if got := buf.String(); got != "graph conflicts {\n}\n" {
+		t.Errorf("output = %q, want an empty graph", got)
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/termcolor"
+	"github.com/spf13/cobra"
+)
+
+// heatmapRows caps the directories the heatmap prints; JSON output has
+// them all.
+const heatmapRows = 10
+
+// codeOwnersFilter loads CODEOWNERS and reads --owner, which needs a
+// CODEOWNERS file to filter by.
+func codeOwnersFilter(cmd *cobra.Command, r git.Runner) (conflict.CodeOwners, string, error) {
+	owners := operations.LoadCodeOwners(cmd.Context(), r)
+	owner, _ := cmd.Flags().GetString(flagOwner)
+	if owner != "" && owners.Empty() {
+		return conflict.CodeOwners{}, "", errhint.WithFix(
+			errors.New("--owner needs a CODEOWNERS file, and none was found"),
+			"add one at "+strings.Join(conflict.CodeOwnersPaths, ", ")+" in the main worktree",
+		)
+	}
+	return owners, owner, nil
+}
+
+// applyOwners annotates the overlaps with their owners and, with an owner
+// to filter by, narrows them and the dry merges to that owner's files.
+func applyOwners(result *conflict.CheckResult, dryResults []conflict.DryMergeResult, owners conflict.CodeOwners, owner string) []conflict.DryMergeResult {
+	conflict.AttachOwners(result, owners)
+	if owner == "" {
+		return dryResults
+	}
+	result.Overlaps = conflict.OwnedOverlaps(result.Overlaps, owner)
+	if dryResults != nil {
+		dryResults = conflict.OwnedDryMerges(dryResults, owners, owner)
+	}
+	return dryResults
+}
+
+// hasOwners reports whether any overlap has owners, i.e. whether the
+// overlap table needs an OWNERS column.
+func hasOwners(overlaps []conflict.FileOverlap) bool {
+	for _, o := range overlaps {
+		if len(o.Owners) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// renderHeatmap prints the directories with the most overlaps.
+func renderHeatmap(cmd *cobra.Command, p *termcolor.Painter, overlaps []conflict.FileOverlap, prefixes []string) {
+	heat := conflict.Heatmap(overlaps)
+	if len(heat) == 0 {
+		return
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	fmt.Fprintln(cmd.OutOrStdout(), p.Paint("Hot directories:", termcolor.Bold))
+
+	tbl := termcolor.NewTable(2)
+	tbl.AddRow(
+		p.Paint("DIRECTORY", termcolor.Bold),
+		p.Paint("OVERLAPS", termcolor.Bold),
+		p.Paint("HIGH", termcolor.Bold),
+		p.Paint("BRANCHES", termcolor.Bold),
+	)
+	prs := prsOf(overlaps)
+	for _, h := range heat[:min(len(heat), heatmapRows)] {
+		labels := make([]string, len(h.Branches))
+		for i, b := range h.Branches {
+			labels[i] = branchLabel(b, prefixes, prs)
+		}
+		high := strconv.Itoa(h.High)
+		if h.High > 0 {
+			high = p.Paint(high, termcolor.Red)
+		}
+		tbl.AddRow(h.Dir, strconv.Itoa(h.Overlaps), high, strings.Join(labels, ", "))
+	}
+	tbl.Render(cmd.OutOrStdout())
+
+	if more := len(heat) - heatmapRows; more > 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), p.Paint(fmt.Sprintf("... and %d more (see --json)", more), termcolor.Gray))
+	}
+}
+
+// prsOf returns the open PRs among the overlaps.
+func prsOf(overlaps []conflict.FileOverlap) []conflict.PR {
+	var prs []conflict.PR
+	for _, o := range overlaps {
+		prs = append(prs, o.PRs...)
+	}
+	return prs
+}
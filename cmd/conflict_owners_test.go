@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/termcolor"
+)
+
+// codeOwnersRunner is overlapABRunner in a repository rooted at a temp dir
+// holding codeowners as its CODEOWNERS file, if not empty.
+func codeOwnersRunner(t *testing.T, codeowners string) *mockRunner {
+	t.Helper()
+	root := t.TempDir()
+	if codeowners != "" {
+		if err := os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte(codeowners), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := overlapABRunner()
+	run := r.run
+	r.run = func(args ...string) (string, error) {
+		if len(args) >= 2 && args[0] == cmdRevParse && args[1] == "--git-common-dir" {
+			return filepath.Join(root, ".git"), nil
+		}
+		return run(args...)
+	}
+	return r
+}
+
+func runConflictCheckOwners(t *testing.T, r *mockRunner, flags map[string]string) (string, error) {
+	t.Helper()
+	cmd, buf := newTestCmd()
+	cmd.Flags().String(flagOwner, "", "")
+	for name, value := range flags {
+		_ = cmd.Flags().Set(name, value)
+	}
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+	restore := overrideNewRunner(r)
+	defer restore()
+
+	err := conflictCheckCmd.RunE(cmd, nil)
+	return buf.String(), err
+}
+
+func TestConflictCheckShowsOwnersAndHeatmap(t *testing.T) {
+	out, err := runConflictCheckOwners(t, codeOwnersRunner(t, "*.go @org/core\n"), nil)
+	if err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+
+	for _, want := range []string{"OWNERS", "@org/core", "Hot directories:", "DIRECTORY"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConflictCheckOwnerFiltersOverlaps(t *testing.T) {
+	r := codeOwnersRunner(t, "*.go @org/core\n")
+
+	out, err := runConflictCheckOwners(t, r, map[string]string{flagOwner: "@org/web"})
+	if err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	if !strings.Contains(out, "No file overlaps found in files owned by @org/web.") {
+		t.Errorf("output = %q, want no owned overlaps", out)
+	}
+
+	out, err = runConflictCheckOwners(t, r, map[string]string{flagOwner: "org/core"})
+	if err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	if !strings.Contains(out, "1 file overlap(s)") {
+		t.Errorf("output = %q, want the owned overlap", out)
+	}
+}
+
+func TestConflictCheckOwnerWithoutCodeOwners(t *testing.T) {
+	_, err := runConflictCheckOwners(t, codeOwnersRunner(t, ""), map[string]string{flagOwner: "@org/core"})
+
+	if err == nil || !strings.Contains(err.Error(), "needs a CODEOWNERS file") {
+		t.Errorf("err = %v, want a missing CODEOWNERS error", err)
+	}
+}
+
+func TestConflictCheckJSONOwnersAndHeatmap(t *testing.T) {
+	out, err := runConflictCheckOwners(t, codeOwnersRunner(t, "shared.go @org/core\n"), map[string]string{flagJSON: "true"})
+	if err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+
+	var env struct {
+		Data conflictCheckJSONData `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &env); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(env.Data.Overlaps) != 1 || !reflect.DeepEqual(env.Data.Overlaps[0].Owners, []string{"@org/core"}) {
+		t.Errorf("overlaps = %+v, want shared.go owned by @org/core", env.Data.Overlaps)
+	}
+	want := []conflict.DirHeat{{Dir: ".", Overlaps: 1, Branches: []string{branchFeatureA, branchFeatureB}}}
+	if !reflect.DeepEqual(env.Data.Heatmap, want) {
+		t.Errorf("heatmap = %+v, want %+v", env.Data.Heatmap, want)
+	}
+}
+
+func TestRenderHeatmapCapsRows(t *testing.T) {
+	cmd, buf := newTestCmd()
+	var overlaps []conflict.FileOverlap
+	for _, dir := range strings.Fields("a b c d e f g h i j k l") {
+		overlaps = append(overlaps, conflict.FileOverlap{File: dir + "/x.go", Branches: []string{branchFeatureA, branchFeatureB}})
+	}
+
+	renderHeatmap(cmd, termcolor.NewPainter(true), overlaps, nil)
+
+	out := buf.String()
+	if !strings.Contains(out, "... and 2 more (see --json)") {
+		t.Errorf("output = %q, want the last two directories elided", out)
+	}
+	if strings.Contains(out, "\nl ") {
+		t.Errorf("output = %q, want directory l elided", out)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/hint"
 	"github.com/lugassawan/rimba/internal/operations"
@@ -25,10 +26,11 @@ const (
 var duplicateCmd = &cobra.Command{
 	Use:   "duplicate <task>",
 	Short: "Create a new worktree from an existing worktree",
-	Long:  "Creates a new worktree branched from an existing worktree's branch, inheriting its prefix. Auto-suffixes with -1, -2, etc. unless --as is provided. Use --dry-run to preview what would be created without making changes.",
+	Long:  "Creates a new worktree branched from an existing worktree's branch, inheriting its prefix. Auto-suffixes with -1, -2, etc. unless --as is provided. Use --dry-run to preview what would be created without making changes. If a step fails or the command is interrupted, the new worktree and branch are removed again unless --keep-on-failure is set. Use --profile to apply a [profiles.<name>] section's copy_files, post_create, and deps.",
 	Example: `  rimba duplicate auth             # duplicate auth worktree (auto-suffix)
   rimba duplicate auth --as copy    # duplicate with custom name
-  rimba duplicate auth --dry-run    # preview without duplicating`,
+  rimba duplicate auth --dry-run    # preview without duplicating
+  rimba duplicate auth --profile perf # set up with the [profiles.perf] section`,
 	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
@@ -114,6 +116,20 @@ var duplicateCmd = &cobra.Command{
 			return fmt.Errorf("worktree path already exists: %s", wtPath)
 		}
 
+		// The copy tracks the base the source does.
+		baseOf, err := operations.BaseResolver(ctx, r, cfg, cfg.DefaultSource)
+		if err != nil {
+			return err
+		}
+		base := operations.TrackedBase(cfg, newBranch, baseOf(wt.Branch))
+
+		// A profile changes only the setup: the branch comes from the
+		// duplicated worktree.
+		setupCfg, err := applyProfileFlag(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
 		dryRun, _ := cmd.Flags().GetBool(flagDryRun)
 		skipDeps, _ := cmd.Flags().GetBool(flagSkipDeps)
 		skipHooks, _ := cmd.Flags().GetBool(flagSkipHooks)
@@ -128,13 +144,13 @@ var duplicateCmd = &cobra.Command{
 		if dryRun {
 			out := cmd.OutOrStdout()
 			fmt.Fprintf(out, "[dry-run] would create worktree: %s (branch %s from %s)\n", wtPath, newBranch, wt.Branch)
-			if len(cfg.CopyFiles) > 0 {
-				fmt.Fprintf(out, "[dry-run] would copy files: %v\n", cfg.CopyFiles)
+			if len(setupCfg.CopyFiles) > 0 {
+				fmt.Fprintf(out, "[dry-run] would copy files: %v\n", setupCfg.CopyFiles)
 			}
 			if !skipDeps {
 				fmt.Fprintf(out, "[dry-run] would install deps\n")
 			}
-			if !skipHooks && len(cfg.PostCreate) > 0 {
+			if !skipHooks && len(setupCfg.PostCreate) > 0 {
 				fmt.Fprintf(out, "[dry-run] would run post-create hooks\n")
 			}
 			return nil
@@ -147,16 +163,28 @@ var duplicateCmd = &cobra.Command{
 		s := spinner.New(spinnerOpts(cmd))
 		defer s.Stop()
 
-		// Create worktree from source branch
+		// Create worktree from source branch. A failed step rolls back what
+		// completed unless --keep-on-failure is set.
+		keep, _ := cmd.Flags().GetBool(flagKeepOnFail)
+		txn := &operations.Txn{Keep: keep}
 		s.Start("Creating worktree...")
-		if err := git.AddWorktree(ctx, r, wtPath, newBranch, wt.Branch); err != nil {
-			return err
+		if err := operations.CreateWorktreeStep(ctx, r, txn, wtPath, newBranch, wt.Branch); err != nil {
+			return txn.Rollback(err)
+		}
+		if err := operations.RecordCreatedStep(ctx, r, txn, newBranch, base); err != nil {
+			if txn.RollsBack() {
+				return txn.Rollback(fmt.Errorf("base %q not recorded: %w", base, err))
+			}
+			return txn.Rollback(errhint.WithFix(
+				fmt.Errorf("worktree created but base %q not recorded: %w", base, err),
+				"remove it and retry: rimba remove "+newTask,
+			))
 		}
 
 		// Post-create setup: copy files, deps, hooks
 		var configModules []config.ModuleConfig
-		if cfg.Deps != nil {
-			configModules = cfg.Deps.Modules
+		if setupCfg.Deps != nil {
+			configModules = setupCfg.Deps.Modules
 		}
 
 		pcResult, err := operations.PostCreateSetup(cmd.Context(), r, operations.PostCreateParams{
@@ -164,17 +192,18 @@ var duplicateCmd = &cobra.Command{
 			WtPath:        wtPath,
 			Task:          newTask,
 			Service:       svc,
-			CopyFiles:     cfg.CopyFiles,
+			CopyFiles:     setupCfg.CopyFiles,
 			SkipDeps:      skipDeps,
-			AutoDetect:    cfg.IsAutoDetectDeps(),
+			AutoDetect:    setupCfg.IsAutoDetectDeps(),
 			ConfigModules: configModules,
 			SkipHooks:     skipHooks,
-			PostCreate:    cfg.PostCreate,
+			PostCreate:    setupCfg.PostCreate,
 			SourcePath:    wt.Path,
-			Concurrency:   cfg.DepsConcurrency(),
+			Concurrency:   setupCfg.DepsConcurrency(),
+			Txn:           txn,
 		}, func(msg string) { s.Update(msg) })
 		if err != nil {
-			return err
+			return txn.Rollback(err)
 		}
 
 		s.Stop()
@@ -204,6 +233,9 @@ func init() {
 	duplicateCmd.Flags().String(flagAs, "", "custom name for the duplicate worktree")
 	duplicateCmd.Flags().Bool(flagSkipDeps, false, "skip dependency detection and installation")
 	duplicateCmd.Flags().Bool(flagSkipHooks, false, "skip post-create hooks")
+	duplicateCmd.Flags().String(flagProfile, "", "apply a [profiles.<name>] section's setup from the config")
+	_ = duplicateCmd.RegisterFlagCompletionFunc(flagProfile, completeProfilesFlag)
+	duplicateCmd.Flags().Bool(flagKeepOnFail, false, "keep a partially created worktree when a step fails instead of rolling back")
 	duplicateCmd.Flags().Bool(flagDryRun, false, "preview what would be duplicated without making changes")
 	rootCmd.AddCommand(duplicateCmd)
 }
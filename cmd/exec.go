@@ -13,6 +13,7 @@ import (
 	"github.com/lugassawan/rimba/internal/executor"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/hint"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/observability"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/output"
@@ -32,6 +33,7 @@ const (
 	hintExecDirty   = "Run only in worktrees with uncommitted changes"
 	hintFailFast    = "Stop execution after the first failure"
 	hintConcurrency = "Limit the number of parallel executions"
+	hintExecTag     = "Run only in worktrees carrying a tag (set with 'rimba tag')"
 )
 
 // execRunner is the injectable executor function type, matching executor.Run.
@@ -40,9 +42,10 @@ type execRunner func(context.Context, executor.Config) []executor.Result
 var execCmd = &cobra.Command{
 	Use:   "exec <command>",
 	Short: "Run a shell command across worktrees",
-	Long:  "Executes a shell command in parallel across matching worktrees. Use --all to target all worktrees, or --type to filter by prefix type.",
+	Long:  "Executes a shell command in parallel across matching worktrees. Use --all to target all worktrees, or --type to filter by prefix type. --tag and --owner narrow either selection by worktree metadata.",
 	Example: `  rimba exec --all "git status"
-  rimba exec --type bugfix "npm test"`,
+  rimba exec --type bugfix "npm test"
+  rimba exec --all --tag spike "git log -1"`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runExec(cmd, args, newRunner(cmd.Context()), executor.Run)
@@ -102,6 +105,7 @@ func addExecFlags(c *cobra.Command) {
 	c.Flags().Bool(flagDirty, false, "run only in dirty worktrees")
 	c.Flags().Bool(flagFailFast, false, "stop after the first failure")
 	c.Flags().Int(flagConcurrency, 0, "max parallel executions (0 = unlimited)")
+	addMetaFilterFlags(c)
 }
 
 // buildExecCmd constructs a testable exec command with injected deps.
@@ -127,6 +131,7 @@ type execOpts struct {
 	dirty       bool
 	failFast    bool
 	concurrency int
+	meta        meta.Filter
 }
 
 type dirtyResult struct {
@@ -147,6 +152,7 @@ func execReadFlags(cmd *cobra.Command) execOpts {
 		dirty:       dirty,
 		failFast:    failFast,
 		concurrency: concurrency,
+		meta:        metaFilterFromFlags(cmd),
 	}
 }
 
@@ -179,6 +185,7 @@ func execShowHints(cmd *cobra.Command) {
 		Add(flagDirty, hintExecDirty).
 		Add(flagFailFast, hintFailFast).
 		Add(flagConcurrency, hintConcurrency).
+		Add(flagTag, hintExecTag).
 		Show()
 }
 
@@ -199,6 +206,11 @@ func execSelectWorktrees(cmd *cobra.Command, r git.Runner, s *spinner.Spinner, o
 		filtered = operations.FilterEligible(worktrees, prefixes, cfg.DefaultSource, allTasks, true)
 	}
 
+	filtered, err = operations.SelectByMeta(ctx, r, filtered, operations.WorktreeBranch, opts.meta)
+	if err != nil {
+		return nil, err
+	}
+
 	if opts.dirty {
 		filtered = filterDirtyWorktrees(ctx, cmd, r, s, filtered)
 	}
@@ -15,6 +15,7 @@ import (
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/executor"
 	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/output"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/spinner"
@@ -810,3 +811,40 @@ func TestExecCmdNoMatchingWorktrees(t *testing.T) {
 		t.Errorf("expected 'No worktrees match' in output, got: %q", buf.String())
 	}
 }
+
+func TestExecCmdFiltersByTag(t *testing.T) {
+	commonDir := t.TempDir()
+	seed := &meta.Store{Worktrees: map[string]meta.Meta{"feature/bar": {Tags: []string{"spike"}}}}
+	if err := seed.Save(commonDir); err != nil {
+		t.Fatal(err)
+	}
+	porcelain := strings.Join([]string{
+		"worktree /repo", "HEAD abc", "branch refs/heads/main", "",
+		"worktree /wt/foo", "HEAD def", "branch refs/heads/feature/foo", "",
+		"worktree /wt/bar", "HEAD fed", "branch refs/heads/feature/bar", "",
+	}, "\n")
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[1] == cmdGitCommonDir {
+				return commonDir, nil
+			}
+			return porcelain, nil
+		},
+		runInDir: noopRunInDir,
+	}
+
+	var targets []executor.Target
+	fakeExec := func(_ context.Context, cfg executor.Config) []executor.Result {
+		targets = cfg.Targets
+		return nil
+	}
+
+	cmd, _ := newExecCmd(r, fakeExec)
+	cmd.SetArgs([]string{"--all", "--tag", "spike", "echo ok"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(targets) != 1 || targets[0].Branch != "feature/bar" {
+		t.Errorf("targets = %+v, want only feature/bar", targets)
+	}
+}
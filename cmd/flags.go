@@ -6,6 +6,7 @@ import (
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/spf13/cobra"
 )
@@ -37,3 +38,17 @@ func typeFilterCompletion() cobra.CompletionFunc {
 		return types, cobra.ShellCompDirectiveNoFileComp
 	}
 }
+
+// addMetaFilterFlags registers --tag and --owner, which select worktrees by
+// their recorded metadata. Shared by list, exec, and clean.
+func addMetaFilterFlags(c *cobra.Command) {
+	c.Flags().String(flagTag, "", "only worktrees carrying this tag (see rimba tag)")
+	c.Flags().String(flagOwner, "", "only worktrees with this owner (see rimba note)")
+}
+
+// metaFilterFromFlags reads the --tag and --owner filters.
+func metaFilterFromFlags(cmd *cobra.Command) meta.Filter {
+	tag, _ := cmd.Flags().GetString(flagTag)
+	owner, _ := cmd.Flags().GetString(flagOwner)
+	return meta.Filter{Tag: tag, Owner: owner}
+}
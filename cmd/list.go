@@ -8,6 +8,7 @@ import (
 	"github.com/lugassawan/rimba/internal/gh"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/hint"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/spinner"
@@ -27,6 +28,7 @@ const (
 	hintBehind  = "Show only worktrees behind upstream"
 	hintFull    = "Show all columns (branch, path, PR/CI when gh is available)"
 	hintService = "Filter by service name (monorepo)"
+	hintTag     = "Filter by tag (set with 'rimba tag')"
 )
 
 var listCmd = &cobra.Command{
@@ -35,7 +37,9 @@ var listCmd = &cobra.Command{
 	Long: `Lists all git worktrees with task, type, and status.
 
 Use --full to show branch, path, and (when gh is installed and authenticated) PR number
-and CI rollup. CI symbols: ✓ success · ● pending · ✗ failure · – unknown.
+and CI rollup. CI symbols: ✓ success · ● pending · ✗ failure · – unknown. When any
+worktree has metadata (see 'rimba note' and 'rimba tag'), --full also shows TAGS,
+OWNER, ISSUE, and NOTE columns. --tag and --owner filter by that metadata.
 
 --archived is mutually exclusive with --type, --dirty, --behind, and --full.`,
 	Example: `  rimba list                     # compact view
@@ -44,6 +48,8 @@ and CI rollup. CI symbols: ✓ success · ● pending · ✗ failure · – unkn
   rimba list --service auth-api  # filter by service (monorepo)
   rimba list --dirty             # only worktrees with uncommitted changes
   rimba list --behind            # only worktrees behind upstream
+  rimba list --tag needs-review  # only worktrees carrying a tag
+  rimba list --owner dana        # only worktrees owned by dana
   rimba list --archived          # archived branches (no active worktree)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		opts := listReadFlags(cmd)
@@ -89,6 +95,7 @@ and CI rollup. CI symbols: ✓ success · ● pending · ✗ failure · – unkn
 			Service:     opts.service,
 			CurrentPath: cwd,
 			WorktreeDir: filepath.Join(repoRoot, cfg.WorktreeDir),
+			Meta:        opts.meta,
 		})
 		s.Stop()
 		if err != nil {
@@ -97,7 +104,7 @@ and CI rollup. CI symbols: ✓ success · ● pending · ✗ failure · – unkn
 
 		if len(res.Rows) == 0 {
 			msg := "No worktrees found."
-			if opts.dirty || opts.behind || opts.typeFilter != "" || opts.service != "" {
+			if opts.dirty || opts.behind || opts.typeFilter != "" || opts.service != "" || !opts.meta.IsEmpty() {
 				msg = "No worktrees match the given filters."
 			}
 			return listRenderEmpty(cmd, msg)
@@ -118,6 +125,7 @@ type listOpts struct {
 	archived   bool
 	full       bool
 	service    string
+	meta       meta.Filter
 }
 
 func listReadFlags(cmd *cobra.Command) listOpts {
@@ -134,6 +142,7 @@ func listReadFlags(cmd *cobra.Command) listOpts {
 		archived:   archived,
 		full:       full,
 		service:    service,
+		meta:       metaFilterFromFlags(cmd),
 	}
 }
 
@@ -151,6 +160,7 @@ func listShowHints(cmd *cobra.Command) {
 		Add(flagService, hintService).
 		Add(flagDirty, hintDirty).
 		Add(flagBehind, hintBehind).
+		Add(flagTag, hintTag).
 		Show()
 }
 
@@ -163,6 +173,7 @@ func init() {
 	listCmd.Flags().Bool(flagBehind, false, "show only worktrees behind upstream")
 	listCmd.Flags().Bool(flagArchived, false, "show archived branches (not in any active worktree)")
 	listCmd.Flags().Bool(flagFull, false, "show all columns (branch, path, PR/CI when gh is available)")
+	addMetaFilterFlags(listCmd)
 
 	listCmd.MarkFlagsMutuallyExclusive(flagArchived, flagType)
 	listCmd.MarkFlagsMutuallyExclusive(flagArchived, flagDirty)
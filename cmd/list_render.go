@@ -3,17 +3,24 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/gh"
 	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/output"
 	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/stack"
 	"github.com/lugassawan/rimba/internal/termcolor"
 	"github.com/spf13/cobra"
 )
 
+// maxNoteCell caps the NOTE column so one long note does not widen the table.
+const maxNoteCell = 40
+
 // defaultSourceFromContext returns the configured main branch, or "" when no
 // config is present in ctx (nil-safe; config.FromContext may return nil).
 func defaultSourceFromContext(ctx context.Context) string {
@@ -43,6 +50,8 @@ func listRenderJSON(cmd *cobra.Command, rows []resolver.WorktreeDetail, prInfos
 			Path:      r.Path,
 			IsCurrent: r.IsCurrent,
 			Status:    r.Status,
+			Parent:    r.Parent,
+			Meta:      r.Meta,
 		}
 		if info, ok := prInfos[r.Branch]; ok {
 			if info.Number != 0 {
@@ -60,6 +69,7 @@ func listRenderJSON(cmd *cobra.Command, rows []resolver.WorktreeDetail, prInfos
 
 func listRenderTable(cmd *cobra.Command, rows []resolver.WorktreeDetail, full bool, prInfos map[string]operations.PRInfo, ghWarning string) {
 	hasService := resolver.HasService(rows)
+	showMeta := full && hasMeta(rows)
 	noColor, _ := cmd.Flags().GetBool(flagNoColor)
 	p := termcolor.NewPainter(noColor)
 
@@ -71,14 +81,22 @@ func listRenderTable(cmd *cobra.Command, rows []resolver.WorktreeDetail, full bo
 	mainBranch := defaultSourceFromContext(cmd.Context())
 	flagOrphans := ps.HasCustom()
 
+	branches := make([]string, len(rows))
+	parents := make(map[string]string, len(rows))
+	for i, row := range rows {
+		branches[i] = row.Branch
+		parents[row.Branch] = row.Parent
+	}
+	indents := stackIndents(branches, parents)
+
 	tbl := termcolor.NewTable(2)
-	tbl.AddRow(listHeader(p, hasService, full)...)
+	tbl.AddRow(listHeader(p, hasService, full, showMeta)...)
 
 	var orphaned int
 	for _, row := range rows {
-		taskCell := "  " + row.Task
+		taskCell := "  " + indents[row.Branch] + row.Task
 		if row.IsCurrent {
-			taskCell = "* " + row.Task
+			taskCell = "* " + indents[row.Branch] + row.Task
 			taskCell = p.Paint(taskCell, termcolor.Green, termcolor.Bold)
 		}
 
@@ -97,6 +115,9 @@ func listRenderTable(cmd *cobra.Command, rows []resolver.WorktreeDetail, full bo
 			info := prInfos[row.Branch]
 			cells = append(cells, formatPRCell(info.Number, p), formatCICell(info.CIStatus, p))
 		}
+		if showMeta {
+			cells = append(cells, metaCells(row.Meta, p)...)
+		}
 		tbl.AddRow(cells...)
 	}
 
@@ -107,7 +128,20 @@ func listRenderTable(cmd *cobra.Command, rows []resolver.WorktreeDetail, full bo
 	}
 }
 
-func listHeader(p *termcolor.Painter, hasService, full bool) []string {
+// stackIndents returns the tree prefix for each stacked branch, so a child
+// renders beneath its parent. Unstacked branches have no entry.
+func stackIndents(branches []string, parents map[string]string) map[string]string {
+	order, depths := stack.Tree(branches, parents)
+	indents := make(map[string]string)
+	for i, idx := range order {
+		if depths[i] > 0 {
+			indents[branches[idx]] = strings.Repeat("   ", depths[i]-1) + "└─ "
+		}
+	}
+	return indents
+}
+
+func listHeader(p *termcolor.Painter, hasService, full, showMeta bool) []string {
 	h := []string{p.Paint("TASK", termcolor.Bold)}
 	if hasService {
 		h = append(h, p.Paint("SERVICE", termcolor.Bold))
@@ -120,9 +154,43 @@ func listHeader(p *termcolor.Painter, hasService, full bool) []string {
 	if full {
 		h = append(h, p.Paint("PR", termcolor.Bold), p.Paint("CI", termcolor.Bold))
 	}
+	if showMeta {
+		h = append(h, p.Paint("TAGS", termcolor.Bold), p.Paint("OWNER", termcolor.Bold),
+			p.Paint("ISSUE", termcolor.Bold), p.Paint("NOTE", termcolor.Bold))
+	}
 	return h
 }
 
+// hasMeta reports whether any row carries metadata worth a column.
+func hasMeta(rows []resolver.WorktreeDetail) bool {
+	for _, r := range rows {
+		if r.Meta.Note != "" || len(r.Meta.Tags) > 0 || r.Meta.Owner != "" || r.Meta.Issue != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// metaCells renders the TAGS, OWNER, ISSUE, and NOTE cells; blanks show as "–".
+func metaCells(m meta.Meta, p *termcolor.Painter) []string {
+	cells := []string{strings.Join(m.Tags, ","), m.Owner, m.Issue, truncateRunes(m.Note, maxNoteCell)}
+	for i, c := range cells {
+		if c == "" {
+			cells[i] = p.Paint("–", termcolor.Gray)
+		}
+	}
+	return cells
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
 func listRow(taskCell string, row resolver.WorktreeDetail, typeCell, statusCell string, hasService, full bool) []string {
 	cells := []string{taskCell}
 	if hasService {
@@ -161,6 +229,12 @@ func listArchivedBranches(cmd *cobra.Command, r git.Runner, mainBranch string) e
 	if err != nil {
 		return err
 	}
+	archived, err = operations.SelectByMeta(cmd.Context(), r, archived, func(b string) string { return b }, metaFilterFromFlags(cmd))
+	if err != nil {
+		return err
+	}
+	// Metadata is keyed by branch, so an archived branch keeps its note and tags.
+	metaStore, _ := operations.LoadMeta(cmd.Context(), r)
 
 	prefixes := config.PrefixSetFromContext(cmdContext(cmd)).Strip()
 
@@ -168,7 +242,7 @@ func listArchivedBranches(cmd *cobra.Command, r git.Runner, mainBranch string) e
 		items := make([]output.ListArchivedItem, 0, len(archived))
 		for _, b := range archived {
 			task, typeName := resolver.TaskAndType(b, prefixes)
-			items = append(items, output.ListArchivedItem{Task: task, Type: typeName, Branch: b})
+			items = append(items, output.ListArchivedItem{Task: task, Type: typeName, Branch: b, Meta: metaStore.Get(b)})
 		}
 		return output.WriteJSON(cmd.OutOrStdout(), version, "list", items)
 	}
@@ -181,12 +255,17 @@ func listArchivedBranches(cmd *cobra.Command, r git.Runner, mainBranch string) e
 	noColor, _ := cmd.Flags().GetBool(flagNoColor)
 	p := termcolor.NewPainter(noColor)
 
-	tbl := termcolor.NewTable(2)
-	tbl.AddRow(
+	showNote := slices.ContainsFunc(archived, func(b string) bool { return metaStore.Get(b).Note != "" })
+	header := []string{
 		p.Paint("TASK", termcolor.Bold),
 		p.Paint("TYPE", termcolor.Bold),
 		p.Paint("BRANCH", termcolor.Bold),
-	)
+	}
+	if showNote {
+		header = append(header, p.Paint("NOTE", termcolor.Bold))
+	}
+	tbl := termcolor.NewTable(2)
+	tbl.AddRow(header...)
 
 	for _, b := range archived {
 		task, typeName := resolver.TaskAndType(b, prefixes)
@@ -196,7 +275,11 @@ func listArchivedBranches(cmd *cobra.Command, r git.Runner, mainBranch string) e
 			typeCell = p.Paint(typeCell, c)
 		}
 
-		tbl.AddRow("  "+task, typeCell, b)
+		cells := []string{"  " + task, typeCell, b}
+		if showNote {
+			cells = append(cells, truncateRunes(metaStore.Get(b).Note, maxNoteCell))
+		}
+		tbl.AddRow(cells...)
 	}
 
 	fmt.Fprintln(cmd.OutOrStdout(), "Archived branches:")
@@ -9,6 +9,7 @@ import (
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/gh"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/output"
 	"github.com/lugassawan/rimba/internal/resolver"
@@ -881,3 +882,42 @@ func TestListRenderJSONPopulatesPRInfo(t *testing.T) {
 		t.Errorf("CIStatus = %v, want PENDING", got.CIStatus)
 	}
 }
+
+func TestListRenderTableIndentsStackedWorktrees(t *testing.T) {
+	cmd, buf := newListTestCmd()
+	rows := []resolver.WorktreeDetail{
+		{Task: "login", Branch: branchFeature, Type: "feature", Path: pathWtFeatureLogin},
+		{Task: "login-ui", Branch: branchLoginUI, Type: "feature", Path: pathWtLoginUI, Parent: branchFeature},
+	}
+	listRenderTable(cmd, rows, false, nil, "")
+	if !strings.Contains(buf.String(), "└─ login-ui") {
+		t.Errorf("want stacked child indented under its parent: %s", buf.String())
+	}
+}
+
+func TestListRenderTableFullShowsMetaColumns(t *testing.T) {
+	cmd, buf := newListTestCmd()
+	rows := []resolver.WorktreeDetail{
+		{Task: "login", Branch: branchFeature, Type: "feature", Path: pathWtFeatureLogin,
+			Meta: meta.Meta{Tags: []string{"spike", "urgent"}, Owner: "dana", Note: strings.Repeat("n", 50)}},
+		{Task: "typo", Branch: branchBugfixTypo, Type: "bugfix", Path: "/wt/typo"},
+	}
+	listRenderTable(cmd, rows, true, nil, "")
+	out := buf.String()
+	for _, want := range []string{"TAGS", "OWNER", "ISSUE", "NOTE", "spike,urgent", "dana", strings.Repeat("n", 39) + "…"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want %q in output: %s", want, out)
+		}
+	}
+}
+
+func TestListRenderTableCompactHidesMetaColumns(t *testing.T) {
+	cmd, buf := newListTestCmd()
+	rows := []resolver.WorktreeDetail{
+		{Task: "login", Branch: branchFeature, Type: "feature", Meta: meta.Meta{Owner: "dana"}},
+	}
+	listRenderTable(cmd, rows, false, nil, "")
+	if strings.Contains(buf.String(), "OWNER") {
+		t.Errorf("compact view should not show metadata columns: %s", buf.String())
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/git"
@@ -13,10 +14,11 @@ import (
 )
 
 const (
-	flagInto   = "into"
-	flagNoFF   = "no-ff"
-	flagKeep   = "keep"
-	flagDelete = "delete"
+	flagInto     = "into"
+	flagNoFF     = "no-ff"
+	flagKeep     = "keep"
+	flagDelete   = "delete"
+	flagNoVerify = "no-verify"
 
 	hintNoFF = "Force a merge commit (preserves branch history in git log)"
 	hintKeep = "Keep source worktree after merge (continue working on it)"
@@ -26,10 +28,12 @@ const (
 var mergeCmd = &cobra.Command{
 	Use:   "merge <source-task>",
 	Short: "Merge a worktree branch into main or another worktree",
-	Long:  "Merges the source worktree's branch into main (default) or another worktree. Auto-deletes the source when merging to main unless --keep is set. Keeps the source when merging between worktrees unless --delete is set. Use --dry-run to preview what would happen without making changes.",
+	Long:  "Merges the source worktree's branch into main (default) or another worktree. Auto-deletes the source when merging to main unless --keep is set. Keeps the source when merging between worktrees unless --delete is set. Use --dry-run to preview what would happen without making changes.\n\nUse --squash to squash the source's commits into one commit on the target. Its message is built from the branch type and task, e.g. 'feat(auth): add auth', followed by the squashed commit subjects; pass --message to use your own, or --edit to edit it in $EDITOR. 'rimba clean --merged' still recognises a squash-merged branch.\n\nWhen [merge] verify is configured, its commands run against the merge result in a scratch worktree first; the target is only updated when they all pass. Skip them with --no-verify.",
 	Example: `  rimba merge auth             # merge auth into main
   rimba merge auth --keep      # merge but keep the worktree
-  rimba merge auth --dry-run   # preview without merging`,
+  rimba merge auth --dry-run   # preview without merging
+  rimba merge auth --squash --edit   # squash into one commit, editing its message
+  rimba merge auth --no-verify       # skip the [merge] verify commands`,
 	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
@@ -67,6 +71,22 @@ var mergeCmd = &cobra.Command{
 		keep, _ := cmd.Flags().GetBool(flagKeep)
 		del, _ := cmd.Flags().GetBool(flagDelete)
 		dryRun, _ := cmd.Flags().GetBool(flagDryRun)
+		squash, message, editMessage, err := squashFlags(cmd)
+		if err != nil {
+			return err
+		}
+		verify, err := mergeVerify(cmd, cfg, repoRoot, dryRun)
+		if err != nil {
+			return err
+		}
+		// A dry run never merges, so it regenerates no lockfiles and
+		// needs no trust for the install commands.
+		var lockfiles *operations.LockfileResolver
+		if !dryRun {
+			if lockfiles, err = lockfileResolver(cmd, repoRoot, cfg); err != nil {
+				return err
+			}
+		}
 
 		// A confident reap does a real os.Remove, so --dry-run must skip it too.
 		if !dryRun {
@@ -82,6 +102,11 @@ var mergeCmd = &cobra.Command{
 				Show()
 		}
 
+		baseOf, err := operations.BaseResolver(cmd.Context(), r, cfg, cfg.DefaultSource)
+		if err != nil {
+			return err
+		}
+
 		s := spinner.New(spinnerOpts(cmd))
 		defer s.Stop()
 
@@ -94,10 +119,16 @@ var mergeCmd = &cobra.Command{
 			IntoService:   intoService,
 			RepoRoot:      repoRoot,
 			MainBranch:    cfg.DefaultSource,
+			Base:          baseOf(source.Branch),
 			NoFF:          noFF,
 			Keep:          keep,
 			Delete:        del,
 			DryRun:        dryRun,
+			Lockfiles:     lockfiles,
+			Squash:        squash,
+			Message:       message,
+			EditMessage:   stopSpinnerFor(s, editMessage),
+			Verify:        verify,
 		}, func(msg string) { s.Update(msg) })
 		if err != nil {
 			return err
@@ -137,10 +168,18 @@ var mergeCmd = &cobra.Command{
 				RemoteError:     errStr(result.RemoteError),
 				DryRun:          false,
 				Steps:           result.Plan.Steps,
+
+				LockfilesRegenerated: result.LockfilesRegenerated,
+				Squashed:             result.Squashed,
+				CommitMessage:        result.CommitMessage,
+				Verify:               mergeVerifyJSON(result.Verify),
 			})
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Merged %s into %s\n", result.SourceBranch, result.TargetLabel)
+		printMerged(cmd, result)
+		if len(result.LockfilesRegenerated) > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Regenerated conflicting lockfiles: %s\n", strings.Join(result.LockfilesRegenerated, ", "))
+		}
 
 		// Format cleanup results
 		if result.SourceRemoved {
@@ -168,6 +207,45 @@ var mergeCmd = &cobra.Command{
 	},
 }
 
+// mergeVerify returns the [merge] verify commands to run, nil with
+// --no-verify, after the trust gate has approved them. A dry run only
+// reports the verification in its plan, so it skips the gate.
+func mergeVerify(cmd *cobra.Command, cfg *config.Config, repoRoot string, dryRun bool) ([]string, error) {
+	if noVerify, _ := cmd.Flags().GetBool(flagNoVerify); noVerify {
+		return nil, nil
+	}
+	verify := cfg.MergeVerify()
+	if len(verify) == 0 || dryRun {
+		return verify, nil
+	}
+	if err := ensureTrust(cmd, repoRoot, cfg); err != nil {
+		return nil, err
+	}
+	return verify, nil
+}
+
+// mergeVerifyJSON converts a merge's verify outcome for JSON output.
+func mergeVerifyJSON(v *operations.MergeVerify) *output.MergeVerifyJSON {
+	if v == nil {
+		return nil
+	}
+	return &output.MergeVerifyJSON{Commands: v.Commands, Passed: v.Passed, Failure: v.Failure}
+}
+
+// printMerged prints the merge (or squash) line of a successful merge,
+// after the commands that verified it.
+func printMerged(cmd *cobra.Command, result operations.MergeResult) {
+	if result.Verify != nil && result.Verify.Passed {
+		fmt.Fprintf(cmd.OutOrStdout(), "Verified merge result: %s\n", strings.Join(result.Verify.Commands, "; "))
+	}
+	if result.Squashed == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "Merged %s into %s\n", result.SourceBranch, result.TargetLabel)
+		return
+	}
+	header, _, _ := strings.Cut(result.CommitMessage, "\n")
+	fmt.Fprintf(cmd.OutOrStdout(), "Squashed %d commit(s) of %s into %s: %s\n", result.Squashed, result.SourceBranch, result.TargetLabel, header)
+}
+
 // printMergeWorktreeRemoved prints the cleanup success line, distinguishing
 // a prunable recovery (git worktree prune — directory left on disk) from a
 // real worktree removal, matching cmd/clean.go and cmd/remove.go.
@@ -185,7 +263,12 @@ func init() {
 	mergeCmd.Flags().Bool(flagKeep, false, "keep source worktree after merging into main")
 	mergeCmd.Flags().Bool(flagDelete, false, "delete source worktree after merging into another worktree")
 	mergeCmd.Flags().Bool(flagDryRun, false, "preview what would be merged/cleaned up without making changes")
+	mergeCmd.Flags().Bool(flagSquash, false, "squash the source's commits into one commit on the target")
+	mergeCmd.Flags().StringP(flagMessage, "m", "", "commit message for --squash (default: generated from the branch type, task and commits)")
+	mergeCmd.Flags().Bool(flagEdit, false, "edit the --squash commit message in $EDITOR before committing")
+	mergeCmd.Flags().Bool(flagNoVerify, false, "skip the [merge] verify commands")
 	mergeCmd.MarkFlagsMutuallyExclusive(flagKeep, flagDelete)
+	mergeCmd.MarkFlagsMutuallyExclusive(flagSquash, flagNoFF)
 
 	_ = mergeCmd.RegisterFlagCompletionFunc(flagInto, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
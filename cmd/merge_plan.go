@@ -7,20 +7,46 @@ import (
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/conflict"
 	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/spinner"
 	"github.com/lugassawan/rimba/internal/termcolor"
 	"github.com/spf13/cobra"
 )
 
+type mergePlanJSONData struct {
+	Steps []mergePlanJSONStep `json:"steps"`
+	// Exact reports that the order was found by exhaustive search.
+	Exact bool                 `json:"exact"`
+	Nodes []conflict.GraphNode `json:"nodes"`
+	Edges []conflict.GraphEdge `json:"edges"`
+}
+
+type mergePlanJSONStep struct {
+	Order     int               `json:"order"`
+	Task      string            `json:"task"`
+	Branch    string            `json:"branch"`
+	Conflicts int               `json:"conflicts"`
+	Against   []conflict.Weight `json:"against,omitempty"`
+	Pinned    bool              `json:"pinned,omitempty"`
+	Reason    string            `json:"reason"`
+}
+
+const flagFirst = "first"
+
 var mergePlanCmd = &cobra.Command{
 	Use:     "merge-plan",
 	Short:   "Recommend optimal merge order",
-	Long:    "Analyzes file overlaps between worktree branches and recommends a merge order that minimizes conflicts.",
-	Example: "  rimba merge-plan",
+	Long:    "Analyzes file overlaps between worktree branches and recommends a merge order that minimizes conflicts. Each step's conflicts weigh once for every branch still waiting to merge, so conflicting merges land as late as possible; up to 16 branches are searched exhaustively for the cheapest order, more are ordered greedily. Each step says why it is where it is.\n\nStacked branches (see stack) always merge after their parents, and --first pins tasks to lead the plan in the order given.\n\nWith --hunks, branches are weighed by the line ranges they both change rather than the files they share, so edits to disjoint parts of a file do not count as conflicts. With --dry-merge, each pair is weighed by the files git merge-tree finds conflicting instead, so overlaps that merge cleanly weigh nothing.\n\nWith --with-prs, a branch's overlaps with the open PRs against the default branch count toward its conflicts too, as those PRs may land first; the PRs themselves take no step in the plan.\n\nWith --format dot or --format mermaid, the plan is printed as its conflict graph: branches as nodes, numbered by their step, joined by edges weighted by their conflicts. JSON output includes the graph as nodes and edges.\n\nCommitted diffs are cached as in conflict-check; --no-cache bypasses the cache.",
+	Example: "  rimba merge-plan\n  rimba merge-plan --hunks\n  rimba merge-plan --dry-merge\n  rimba merge-plan --first auth-flow\n  rimba merge-plan --with-prs\n  rimba merge-plan --format dot | dot -Tsvg > plan.svg",
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		cfg := config.FromContext(cmd.Context())
 
+		format, err := graphFormat(cmd)
+		if err != nil {
+			return err
+		}
+
 		r := newRunner(cmd.Context())
 
 		worktrees, err := listWorktreeInfos(cmd.Context(), r)
@@ -33,70 +59,154 @@ var mergePlanCmd = &cobra.Command{
 		eligible := operations.FilterEligible(worktrees, prefixes, cfg.DefaultSource, allTasks, true)
 
 		if len(eligible) == 0 {
+			empty := conflict.BuildGraph(nil, nil, nil, nil)
+			if isJSON(cmd) {
+				return output.WriteJSON(cmd.OutOrStdout(), version, "merge-plan", mergePlanJSONData{
+					Steps: make([]mergePlanJSONStep, 0),
+					Exact: true,
+					Nodes: empty.Nodes,
+					Edges: empty.Edges,
+				})
+			}
+			if format != "" {
+				writeGraph(cmd, empty, format, prefixes)
+				return nil
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), "No active worktree branches found.")
 			return nil
 		}
 
+		first, _ := cmd.Flags().GetStringSlice(flagFirst)
+		planOpts, err := operations.MergePlanOptions(cmd.Context(), r, eligible, prefixes, first)
+		if err != nil {
+			return err
+		}
+
 		s := spinner.New(spinnerOpts(cmd))
 		defer s.Stop()
 		s.Start("Collecting file changes...")
 
-		diffs, err := conflict.CollectDiffs(cmd.Context(), r, cfg.DefaultSource, eligible)
+		branches, prs, err := withOpenPRs(cmd, r, cfg, eligible, s)
 		if err != nil {
 			return err
 		}
 
-		result := conflict.DetectOverlaps(diffs)
-
-		branchNames := make([]string, len(eligible))
-		for i, wt := range eligible {
-			branchNames[i] = wt.Branch
+		hunks, _ := cmd.Flags().GetBool(flagHunks)
+		s.Update("Collecting file changes...")
+		opts := conflict.Options{Hunks: hunks, Cache: conflictCache(cmd, r)}
+		baseOf, _ := operations.BaseResolver(cmd.Context(), r, cfg, cfg.DefaultSource) // a report: configured bases will do
+		result, err := conflict.Analyze(cmd.Context(), r, baseOf, branches, opts)
+		if err != nil {
+			return err
 		}
+		if dryMerge, _ := cmd.Flags().GetBool(flagDryMerge); dryMerge {
+			s.Update("Running dry merges...")
+			planOpts.DryMerges, err = conflict.DryMergeAllCached(cmd.Context(), r, branches, opts.Cache)
+			if err != nil {
+				return err
+			}
+		}
+		_ = opts.Cache.Save() // best-effort: the next run recomputes what is missing
 
-		steps := conflict.PlanMergeOrder(result.Overlaps, branchNames)
+		plan, graph, err := operations.PlanMergeGraph(result.Overlaps, eligible, prs, planOpts)
+		if err != nil {
+			return err
+		}
 
 		s.Stop()
 
-		noColor, _ := cmd.Flags().GetBool(flagNoColor)
-		p := termcolor.NewPainter(noColor)
+		label := planLabel(prefixes, prs)
+		if isJSON(cmd) {
+			return output.WriteJSON(cmd.OutOrStdout(), version, "merge-plan", mergePlanJSONData{
+				Steps: mergePlanJSONSteps(plan.Steps, prefixes, label),
+				Exact: plan.Exact,
+				Nodes: graph.Nodes,
+				Edges: graph.Edges,
+			})
+		}
+		if format != "" {
+			writeGraph(cmd, graph, format, prefixes)
+			return nil
+		}
+
+		renderMergePlan(cmd, plan, prefixes, label)
+		if len(prs) > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Conflicts include overlaps with %d open PR(s).\n", len(prs))
+		}
 
-		tbl := termcolor.NewTable(2)
-		tbl.AddRow(
-			p.Paint("ORDER", termcolor.Bold),
-			p.Paint("BRANCH", termcolor.Bold),
-			p.Paint("CONFLICTS", termcolor.Bold),
-		)
+		return nil
+	},
+}
+
+func init() {
+	mergePlanCmd.Flags().Bool(flagHunks, false, "weigh branches by the line ranges they both change (git diff -U0 -M), not shared files")
+	mergePlanCmd.Flags().Bool(flagDryMerge, false, "weigh branches by the files git merge-tree finds conflicting (git 2.38+)")
+	mergePlanCmd.Flags().StringSlice(flagFirst, nil, "pin a task to lead the plan (repeatable or comma-separated, in order)")
+	mergePlanCmd.Flags().Bool(flagWithPRs, false, "count overlaps with the open PRs against the default branch (requires gh)")
+	mergePlanCmd.Flags().Bool(flagNoCache, false, "recompute every diff instead of reusing cached results")
+	addFormatFlag(mergePlanCmd)
+	_ = mergePlanCmd.RegisterFlagCompletionFunc(flagFirst, func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(mergePlanCmd)
+}
 
-		for _, step := range steps {
-			task, typeName := resolver.TaskAndType(step.Branch, prefixes)
+func renderMergePlan(cmd *cobra.Command, plan conflict.Plan, prefixes []string, label func(string) string) {
+	noColor, _ := cmd.Flags().GetBool(flagNoColor)
+	p := termcolor.NewPainter(noColor)
 
-			branchCell := task
-			if c := typeColor(typeName); c != "" {
-				branchCell = p.Paint(branchCell, c)
-			}
+	tbl := termcolor.NewTable(2)
+	tbl.AddRow(
+		p.Paint("ORDER", termcolor.Bold),
+		p.Paint("BRANCH", termcolor.Bold),
+		p.Paint("CONFLICTS", termcolor.Bold),
+		p.Paint("WHY", termcolor.Bold),
+	)
 
-			conflictStr := strconv.Itoa(step.Conflicts)
-			var conflictColor termcolor.Color
-			if step.Conflicts == 0 {
-				conflictColor = termcolor.Green
-			} else {
-				conflictColor = termcolor.Yellow
-			}
+	for _, step := range plan.Steps {
+		task, typeName := resolver.TaskAndType(step.Branch, prefixes)
 
-			tbl.AddRow(
-				strconv.Itoa(step.Order),
-				branchCell,
-				p.Paint(conflictStr, conflictColor),
-			)
+		branchCell := task
+		if c := typeColor(typeName); c != "" {
+			branchCell = p.Paint(branchCell, c)
 		}
 
-		tbl.Render(cmd.OutOrStdout())
-		fmt.Fprintln(cmd.OutOrStdout(), "\nMerge in this order to minimize conflicts.")
+		conflictStr := strconv.Itoa(step.Conflicts)
+		var conflictColor termcolor.Color
+		if step.Conflicts == 0 {
+			conflictColor = termcolor.Green
+		} else {
+			conflictColor = termcolor.Yellow
+		}
 
-		return nil
-	},
+		tbl.AddRow(
+			strconv.Itoa(step.Order),
+			branchCell,
+			p.Paint(conflictStr, conflictColor),
+			p.Paint(step.Explain(label), termcolor.Gray),
+		)
+	}
+
+	tbl.Render(cmd.OutOrStdout())
+	fmt.Fprintln(cmd.OutOrStdout(), "\nMerge in this order to minimize conflicts.")
+	if !plan.Exact {
+		fmt.Fprintf(cmd.OutOrStdout(), "More than %d branches: the order is greedy and may not be the best.\n", conflict.MaxExactBranches)
+	}
 }
 
-func init() {
-	rootCmd.AddCommand(mergePlanCmd)
+func mergePlanJSONSteps(steps []conflict.MergeStep, prefixes []string, label func(string) string) []mergePlanJSONStep {
+	out := make([]mergePlanJSONStep, len(steps))
+	for i, step := range steps {
+		task, _ := resolver.TaskAndType(step.Branch, prefixes)
+		out[i] = mergePlanJSONStep{
+			Order:     step.Order,
+			Task:      task,
+			Branch:    step.Branch,
+			Conflicts: step.Conflicts,
+			Against:   step.Against,
+			Pinned:    step.Pinned,
+			Reason:    step.Explain(label),
+		}
+	}
+	return out
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -143,3 +144,140 @@ func TestMergePlanDiffError(t *testing.T) {
 		t.Fatal("expected error from diff failure")
 	}
 }
+
+func TestMergePlanHunksIgnoresDisjointEdits(t *testing.T) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagHunks, false, "")
+	_ = cmd.Flags().Set(flagHunks, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == cmdWorktreeTest && args[1] == cmdList {
+				return worktreeListOutput(branchFeatureA, branchFeatureB), nil
+			}
+			if args[0] == cmdDiff {
+				if strings.Contains(args[len(args)-1], branchFeatureA) {
+					return "diff --git a/shared.go b/shared.go\n@@ -1,2 +1,2 @@", nil
+				}
+				return "diff --git a/shared.go b/shared.go\n@@ -3000 +3000 @@", nil
+			}
+			return "", nil
+		},
+	}
+	restore := overrideNewRunner(r)
+	defer restore()
+
+	if err := mergePlanCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] >= "1" && fields[0] <= "9" && fields[2] != "0" {
+			t.Errorf("step %q has conflicts; disjoint edits should weigh nothing", line)
+		}
+	}
+}
+
+func TestMergePlanWithPRs(t *testing.T) {
+	withOpenPRGh(t)
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagWithPRs, false, "")
+	_ = cmd.Flags().Set(flagWithPRs, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	var fetched []string
+	restore := overrideNewRunner(openPRGitRunner(&fetched))
+	defer restore()
+
+	if err := mergePlanCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "2      ") {
+		t.Errorf("the open PR should take no step in the plan:\n%s", out)
+	}
+	for _, want := range []string{"1      a       1          conflicts with #12 (@alice) (1)", "Conflicts include overlaps with 1 open PR(s)."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMergePlanFirstPinsTask(t *testing.T) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().StringSlice(flagFirst, nil, "")
+	_ = cmd.Flags().Set(flagFirst, "b")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	restore := overrideNewRunner(overlapABRunner())
+	defer restore()
+
+	if err := mergePlanCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"pinned first; conflicts with a (1)", "no conflicts with later branches"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "1 ") && !strings.Contains(line, "pinned first") {
+			t.Errorf("step 1 should be the pinned task, got: %s", line)
+		}
+	}
+}
+
+func TestMergePlanFirstUnknownTask(t *testing.T) {
+	cmd, _ := newTestCmd()
+	cmd.Flags().StringSlice(flagFirst, nil, "")
+	_ = cmd.Flags().Set(flagFirst, "nope")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	restore := overrideNewRunner(overlapABRunner())
+	defer restore()
+
+	err := mergePlanCmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), `cannot pin "nope" first`) {
+		t.Errorf("err = %v, want an unknown pin error", err)
+	}
+}
+
+func TestMergePlanDryMergeWeights(t *testing.T) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagDryMerge, false, "")
+	_ = cmd.Flags().Set(flagDryMerge, "true")
+	_ = cmd.Flags().Set(flagJSON, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), testConflictCheckConfig()))
+
+	// a and b share shared.go, but merge-tree merges them cleanly.
+	r := overlapABRunner()
+	overlaps := r.run
+	r.run = func(args ...string) (string, error) {
+		if args[0] == "merge-tree" {
+			return "abc123\n", nil
+		}
+		return overlaps(args...)
+	}
+	restore := overrideNewRunner(r)
+	defer restore()
+
+	if err := mergePlanCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("RunE: %v", err)
+	}
+	var env struct {
+		Data mergePlanJSONData `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	for _, step := range env.Data.Steps {
+		if step.Conflicts != 0 || step.Reason != "no conflicts with later branches" {
+			t.Errorf("step %+v, want no conflicts after clean dry merges", step)
+		}
+	}
+	if len(env.Data.Edges) != 0 {
+		t.Errorf("edges = %+v, want none", env.Data.Edges)
+	}
+}
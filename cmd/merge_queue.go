@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/lugassawan/rimba/internal/progress"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/spinner"
+	"github.com/spf13/cobra"
+)
+
+var mergeQueueCmd = &cobra.Command{
+	Use:   "merge-queue [tasks...]",
+	Short: "Merge branches into main one at a time, verifying each",
+	Long: "Lands worktree branches on main one at a time, in the order 'rimba merge-plan' recommends: the given tasks, or every active worktree. Each branch is merged into main's tip in a temporary integration worktree and the [merge_queue] verify commands run there; main is fast-forwarded only when they all pass.\n\n" +
+		"The queue stops on the first branch that conflicts or fails verification, leaving main at its previous SHA. Fix that branch, then run 'rimba merge-queue --continue' to retry it and land the rest, or 'rimba merge-queue --abort' to drop the queue, keeping what landed. Worktrees are kept; remove the landed ones with 'rimba clean --merged'.",
+	Example: `  rimba merge-queue                  # land every active worktree in plan order
+  rimba merge-queue auth dashboard   # land two tasks
+  rimba merge-queue --continue       # retry the failed branch, then land the rest
+  rimba merge-queue --abort          # drop the queue`,
+	ValidArgsFunction: func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.FromContext(cmd.Context())
+		r := newRunner(cmd.Context())
+
+		cont, _ := cmd.Flags().GetBool(flagContinue)
+		abort, _ := cmd.Flags().GetBool(flagAbort)
+		if (cont || abort) && len(args) > 0 {
+			return errhint.WithFix(
+				errors.New("--continue and --abort take no tasks"),
+				"the queue keeps its own branches: rimba merge-queue --continue",
+			)
+		}
+		if abort {
+			return mergeQueueAbort(cmd, r, cfg)
+		}
+
+		repoRoot, err := git.MainRepoRoot(cmd.Context(), r)
+		if err != nil {
+			return err
+		}
+		verify := cfg.MergeQueueVerify()
+		if len(verify) > 0 {
+			if err := ensureTrust(cmd, repoRoot, cfg); err != nil {
+				return err
+			}
+		}
+
+		s := spinner.New(spinnerOpts(cmd))
+		defer s.Stop()
+		onProgress := func(msg string) { s.Update(msg) }
+
+		var result operations.MergeQueueResult
+		if cont {
+			s.Start("Resuming merge queue...")
+			result, err = operations.ContinueMergeQueue(cmd.Context(), r, verify, onProgress)
+		} else {
+			s.Start("Planning merge order...")
+			result, err = startMergeQueue(cmd.Context(), r, cfg, repoRoot, args, onProgress)
+		}
+		s.Stop()
+		if err != nil {
+			return err
+		}
+		if len(result.Steps) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No branches to merge.")
+			return nil
+		}
+		return reportMergeQueue(cmd, result)
+	},
+}
+
+// startMergeQueue queues the branches of tasks in merge-plan order and
+// lands them on the main branch checked out in repoRoot. An empty result
+// means there was nothing to queue.
+func startMergeQueue(ctx context.Context, r git.Runner, cfg *config.Config, repoRoot string, tasks []string, onProgress progress.Func) (operations.MergeQueueResult, error) {
+	branches, err := queueBranches(ctx, r, cfg, repoRoot, tasks)
+	if err != nil || len(branches) == 0 {
+		return operations.MergeQueueResult{}, err
+	}
+	return operations.StartMergeQueue(ctx, r, operations.MergeQueueParams{
+		Target:     cfg.DefaultSource,
+		TargetPath: repoRoot,
+		Branches:   branches,
+		Verify:     cfg.MergeQueueVerify(),
+	}, onProgress)
+}
+
+// queueBranches returns the branches to queue — the worktrees of tasks, or
+// every active worktree — in merge-plan order. Branches already on the main
+// branch are left out, as are branches based on another branch, which
+// would be an error when named.
+func queueBranches(ctx context.Context, r git.Runner, cfg *config.Config, repoRoot string, tasks []string) ([]string, error) {
+	candidates, err := queueCandidates(ctx, r, cfg, repoRoot, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	baseOf, err := operations.BaseResolver(ctx, r, cfg, cfg.DefaultSource)
+	if err != nil {
+		return nil, err
+	}
+	var queued []resolver.WorktreeInfo
+	var names []string
+	for _, wt := range candidates {
+		if base := baseOf(wt.Branch); base != cfg.DefaultSource {
+			if len(tasks) == 0 {
+				continue
+			}
+			return nil, errhint.WithFix(
+				fmt.Errorf("%s is based on %s, not %s", wt.Branch, base, cfg.DefaultSource),
+				"merge it into its base with: rimba merge <task>",
+			)
+		}
+		if git.IsMergeBaseAncestor(ctx, r, wt.Branch, cfg.DefaultSource) {
+			continue
+		}
+		queued = append(queued, wt)
+		names = append(names, wt.Branch)
+	}
+	if len(queued) == 0 {
+		return nil, nil
+	}
+
+	diffs, err := conflict.CollectDiffsFrom(ctx, r, baseOf, queued)
+	if err != nil {
+		return nil, err
+	}
+	steps := conflict.PlanMergeOrder(conflict.DetectOverlaps(diffs).Overlaps, names)
+	ordered := make([]string, len(steps))
+	for i, step := range steps {
+		ordered[i] = step.Branch
+	}
+	return ordered, nil
+}
+
+// queueCandidates resolves tasks to their worktrees, or returns every active
+// worktree when no task is given.
+func queueCandidates(ctx context.Context, r git.Runner, cfg *config.Config, repoRoot string, tasks []string) ([]resolver.WorktreeInfo, error) {
+	if len(tasks) == 0 {
+		worktrees, err := listWorktreeInfos(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		prefixes := cfg.PrefixSet().Strip()
+		return operations.FilterEligible(worktrees, prefixes, cfg.DefaultSource, operations.CollectTasks(worktrees, prefixes), true), nil
+	}
+
+	ps := cfg.PrefixSet()
+	var out []resolver.WorktreeInfo
+	for _, task := range tasks {
+		service, name := operations.ResolveTaskInput(task, repoRoot, ps)
+		wt, err := operations.FindWorktree(ctx, r, service, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := operations.GuardKnownPrefix(ps, wt.Branch, cfg.DefaultSource, false); err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(out, func(o resolver.WorktreeInfo) bool { return o.Branch == wt.Branch }) {
+			out = append(out, wt)
+		}
+	}
+	return out, nil
+}
+
+// reportMergeQueue prints each branch the run reached, failing with how to
+// resume when the queue stopped.
+func reportMergeQueue(cmd *cobra.Command, result operations.MergeQueueResult) error {
+	if isJSON(cmd) {
+		data := output.MergeQueueData{
+			Target:   result.Target,
+			Stopped:  result.Stopped,
+			Branches: make([]output.MergeQueueBranchJSON, 0, len(result.Steps)),
+			Landed:   nonNilStrings(result.Landed),
+			Pending:  nonNilStrings(result.Pending),
+		}
+		for _, step := range result.Steps {
+			data.Branches = append(data.Branches, output.MergeQueueBranchJSON{
+				Branch: step.Branch, Landed: step.Landed, PreSHA: step.PreSHA, SHA: step.SHA, Reason: step.Reason,
+			})
+		}
+		if err := output.WriteJSON(cmd.OutOrStdout(), version, "merge-queue", data); err != nil {
+			return err
+		}
+		if result.Stopped {
+			return &output.SilentError{ExitCode: 1}
+		}
+		return nil
+	}
+
+	out := cmd.OutOrStdout()
+	var stoppedOn string
+	for _, step := range result.Steps {
+		if step.Landed {
+			fmt.Fprintf(out, "Landed %s on %s (%s)\n", step.Branch, result.Target, shortSHA(step.SHA))
+			continue
+		}
+		stoppedOn = step.Branch
+		fmt.Fprintf(out, "Failed %s: %s\n", step.Branch, strings.ReplaceAll(step.Reason, "\n", "\n  "))
+	}
+	if !result.Stopped {
+		fmt.Fprintf(out, "Merge queue done: %d branch(es) landed on %s\n", len(result.Landed), result.Target)
+		fmt.Fprintln(out, "Remove the landed worktrees with: rimba clean --merged")
+		return nil
+	}
+	if len(result.Pending) > 0 {
+		fmt.Fprintf(out, "Still queued: %s\n", strings.Join(result.Pending, ", "))
+	}
+	return errhint.WithFix(
+		fmt.Errorf("merge queue stopped on %s; %s was left at its previous SHA", stoppedOn, result.Target),
+		"fix the branch in its worktree, then run 'rimba merge-queue --continue' (or 'rimba merge-queue --abort' to drop the queue)",
+	)
+}
+
+// mergeQueueAbort drops the queue in progress, reporting the branches it
+// had landed.
+func mergeQueueAbort(cmd *cobra.Command, r git.Runner, cfg *config.Config) error {
+	landed, err := operations.AbortMergeQueue(cmd.Context(), r)
+	if err != nil {
+		return err
+	}
+	if isJSON(cmd) {
+		return output.WriteJSON(cmd.OutOrStdout(), version, "merge-queue", output.MergeQueueData{
+			Target:   cfg.DefaultSource,
+			Aborted:  true,
+			Branches: []output.MergeQueueBranchJSON{},
+			Landed:   nonNilStrings(landed),
+			Pending:  []string{},
+		})
+	}
+	out := cmd.OutOrStdout()
+	if len(landed) == 0 {
+		fmt.Fprintln(out, "Dropped the merge queue; nothing had landed")
+		return nil
+	}
+	fmt.Fprintf(out, "Dropped the merge queue; %d branch(es) stay landed on %s: %s\n", len(landed), cfg.DefaultSource, strings.Join(landed, ", "))
+	return nil
+}
+
+func init() {
+	mergeQueueCmd.Flags().Bool(flagContinue, false, "retry the branch the queue stopped on, then land the rest")
+	mergeQueueCmd.Flags().Bool(flagAbort, false, "drop the queue in progress; branches it landed stay on main")
+	mergeQueueCmd.MarkFlagsMutuallyExclusive(flagContinue, flagAbort)
+
+	rootCmd.AddCommand(mergeQueueCmd)
+}
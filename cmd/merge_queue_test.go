@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+)
+
+func stoppedQueueResult() operations.MergeQueueResult {
+	return operations.MergeQueueResult{
+		Target: branchMain,
+		Steps: []operations.MergeQueueStep{
+			{Branch: "feature/a", Landed: true, PreSHA: "aaaaaaa1111", SHA: "bbbbbbb2222"},
+			{Branch: "feature/b", PreSHA: "bbbbbbb2222", Reason: "verify \"make test\" failed: exit status 2\nFAIL TestLogin"},
+		},
+		Landed:  []string{"feature/a"},
+		Pending: []string{"feature/c"},
+		Stopped: true,
+	}
+}
+
+func TestMergeQueueRejectsTasksWithContinue(t *testing.T) {
+	cmd, _ := newTestCmd()
+	cmd.Flags().Bool(flagContinue, false, "")
+	cmd.Flags().Bool(flagAbort, false, "")
+	_ = cmd.Flags().Set(flagContinue, "true")
+
+	err := mergeQueueCmd.RunE(cmd, []string{"auth"})
+	if err == nil || !strings.Contains(err.Error(), "take no tasks") {
+		t.Fatalf("err = %v, want --continue with tasks rejected", err)
+	}
+}
+
+func TestReportMergeQueueStopped(t *testing.T) {
+	cmd, buf := newTestCmd()
+	err := reportMergeQueue(cmd, stoppedQueueResult())
+	if err == nil || !strings.Contains(err.Error(), "stopped on feature/b") {
+		t.Fatalf("err = %v, want the queue stopped on feature/b", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Landed feature/a on main (bbbbbbb)",
+		"Failed feature/b: verify \"make test\" failed: exit status 2\n  FAIL TestLogin",
+		"Still queued: feature/c",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestReportMergeQueueDone(t *testing.T) {
+	cmd, buf := newTestCmd()
+	result := operations.MergeQueueResult{
+		Target: branchMain,
+		Steps:  []operations.MergeQueueStep{{Branch: "feature/a", Landed: true, SHA: "bbbbbbb2222"}},
+		Landed: []string{"feature/a"},
+	}
+	if err := reportMergeQueue(cmd, result); err != nil {
+		t.Fatalf("reportMergeQueue: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Merge queue done: 1 branch(es) landed on main") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestReportMergeQueueJSON(t *testing.T) {
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+
+	err := reportMergeQueue(cmd, stoppedQueueResult())
+	var silent *output.SilentError
+	if !errors.As(err, &silent) {
+		t.Fatalf("err = %v, want a silent exit for a stopped queue", err)
+	}
+
+	var env struct {
+		Data output.MergeQueueData `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	d := env.Data
+	if !d.Stopped || len(d.Branches) != 2 || d.Branches[1].Landed || d.Branches[0].SHA != "bbbbbbb2222" {
+		t.Errorf("data = %+v", d)
+	}
+	if len(d.Landed) != 1 || len(d.Pending) != 1 {
+		t.Errorf("landed = %v, pending = %v", d.Landed, d.Pending)
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/spinner"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagSquash  = "squash"
+	flagMessage = "message"
+	flagEdit    = "edit"
+
+	// squashEditHelp follows the message opened by --edit; git strips it.
+	squashEditHelp = "\n\n# Edit the squash commit message. Lines starting with '#' are ignored,\n# and an empty message aborts the merge.\n"
+)
+
+// squashFlags reads --squash, --message and --edit. --message and --edit
+// only apply to a squash; edit is nil without --edit.
+func squashFlags(cmd *cobra.Command) (squash bool, message string, edit func(string) (string, error), err error) {
+	squash, _ = cmd.Flags().GetBool(flagSquash)
+	message, _ = cmd.Flags().GetString(flagMessage)
+	wantEdit, _ := cmd.Flags().GetBool(flagEdit)
+	if !squash && (message != "" || wantEdit) {
+		return false, "", nil, errhint.WithFix(
+			errors.New("--message and --edit require --squash"),
+			"add --squash, or drop them: a regular merge keeps the source's commits",
+		)
+	}
+	if wantEdit {
+		edit = editMessage
+	}
+	return squash, message, edit, nil
+}
+
+// editMessage opens message in $EDITOR (vi when unset) on the terminal and
+// returns what was saved.
+func editMessage(message string) (string, error) {
+	f, err := os.CreateTemp("", "rimba-squash-*.txt")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.WriteString(message + squashEditHelp)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// Through sh, so an $EDITOR with arguments ("code --wait") works.
+	sub := exec.Command("sh", "-c", editor+` "$1"`, "sh", path) //nolint:gosec // Intentional: the user's own $EDITOR
+	sub.Stdin = os.Stdin
+	sub.Stdout = os.Stdout
+	sub.Stderr = os.Stderr
+	if err := sub.Run(); err != nil {
+		return "", errhint.WithFix(
+			fmt.Errorf("editor %q failed: %w", editor, err),
+			"set $EDITOR to a working editor, or pass the message with --message",
+		)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// stopSpinnerFor returns edit, stopping s first so the spinner does not draw
+// over the editor; nil when edit is nil.
+func stopSpinnerFor(s *spinner.Spinner, edit func(string) (string, error)) func(string) (string, error) {
+	if edit == nil {
+		return nil
+	}
+	return func(message string) (string, error) {
+		s.Stop()
+		return edit(message)
+	}
+}
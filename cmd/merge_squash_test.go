@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// squashTestCmd is a merge test command with the squash flags defined and
+// set from flags.
+func squashTestCmd(t *testing.T, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd, buf := newTestCmd()
+	cmd.Flags().String(flagInto, "", "")
+	cmd.Flags().Bool(flagKeep, false, "")
+	cmd.Flags().Bool(flagSquash, false, "")
+	cmd.Flags().String(flagMessage, "", "")
+	cmd.Flags().Bool(flagEdit, false, "")
+	for name, value := range flags {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd.SetContext(config.WithConfig(context.Background(), &config.Config{DefaultSource: branchMain, WorktreeDir: defaultRelativeWtDir}))
+	return cmd, buf
+}
+
+// squashTestRunner serves a squash of feature/login's two commits into
+// main, recording the message committed.
+func squashTestRunner(committed *string) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case len(args) >= 2 && args[1] == cmdShowToplevel:
+				return repoPath, nil
+			case args[0] == "log":
+				return "add form\nadd tests", nil
+			}
+			return mergeWorktreeOut, nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			if args[0] == "commit" {
+				*committed = args[len(args)-1]
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestMergeSquashGeneratesMessage(t *testing.T) {
+	var committed string
+	restore := overrideNewRunner(squashTestRunner(&committed))
+	defer restore()
+
+	cmd, buf := squashTestCmd(t, map[string]string{flagSquash: "true", flagKeep: "true"})
+	if err := mergeCmd.RunE(cmd, []string{"login"}); err != nil {
+		t.Fatalf(fatalMergeRunE, err)
+	}
+	if !strings.HasPrefix(committed, "feat(login): login\n\nSquashed commits:\n- add form\n- add tests") {
+		t.Errorf("committed message = %q", committed)
+	}
+	if out := buf.String(); !strings.Contains(out, "Squashed 2 commit(s) of feature/login into main: feat(login): login") {
+		t.Errorf("output = %q, want the squash line", out)
+	}
+}
+
+func TestMergeSquashEditsMessage(t *testing.T) {
+	t.Setenv("EDITOR", `printf 'feat(login): sign in with email\n' >`)
+	var committed string
+	restore := overrideNewRunner(squashTestRunner(&committed))
+	defer restore()
+
+	cmd, _ := squashTestCmd(t, map[string]string{flagSquash: "true", flagEdit: "true", flagKeep: "true"})
+	if err := mergeCmd.RunE(cmd, []string{"login"}); err != nil {
+		t.Fatalf(fatalMergeRunE, err)
+	}
+	if strings.TrimSpace(committed) != "feat(login): sign in with email" {
+		t.Errorf("committed message = %q, want the editor's", committed)
+	}
+}
+
+func TestMergeMessageRequiresSquash(t *testing.T) {
+	var committed string
+	restore := overrideNewRunner(squashTestRunner(&committed))
+	defer restore()
+
+	cmd, _ := squashTestCmd(t, map[string]string{flagMessage: "Ship it"})
+	err := mergeCmd.RunE(cmd, []string{"login"})
+	if err == nil || !strings.Contains(err.Error(), "require --squash") {
+		t.Fatalf("err = %v, want --squash required", err)
+	}
+}
@@ -956,3 +956,80 @@ func TestMergeRemoveErrorJSON(t *testing.T) {
 		t.Errorf("remove_error = %v, want a non-empty string", data["remove_error"])
 	}
 }
+
+func TestMergeVerifySkippedWithNoVerify(t *testing.T) {
+	cmd, _ := newTestCmd()
+	cmd.Flags().Bool(flagNoVerify, false, "")
+	_ = cmd.Flags().Set(flagNoVerify, "true")
+	cfg := &config.Config{Merge: &config.MergeConfig{Verify: []string{"make test"}}}
+
+	// No trust prompt: --no-verify never reaches the gate.
+	verify, err := mergeVerify(cmd, cfg, t.TempDir(), false)
+	if err != nil || verify != nil {
+		t.Errorf("mergeVerify = %v, %v; want nil, nil", verify, err)
+	}
+}
+
+func TestMergeVerifyDryRunSkipsTrustGate(t *testing.T) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagNoVerify, false, "")
+	cfg := &config.Config{Merge: &config.MergeConfig{Verify: []string{"make test"}}}
+
+	// An untrusted repo would prompt; a dry run must not reach the gate.
+	verify, err := mergeVerify(cmd, cfg, t.TempDir(), true)
+	if err != nil || len(verify) != 1 || verify[0] != "make test" {
+		t.Errorf("mergeVerify = %v, %v; want [make test], nil", verify, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want no trust prompt", buf.String())
+	}
+}
+
+func TestMergeDryRunReportsVerify(t *testing.T) {
+	cfg := &config.Config{
+		DefaultSource: branchMain,
+		WorktreeDir:   defaultRelativeWtDir,
+		Merge:         &config.MergeConfig{Verify: []string{"make test"}},
+		Deps:          &config.DepsConfig{ResolveLockfiles: true},
+	}
+	r := mergeTestRunner(nil)
+	restore := overrideNewRunner(r)
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	cmd.Flags().String(flagInto, "", "")
+	cmd.Flags().Bool(flagNoFF, false, "")
+	cmd.Flags().Bool(flagKeep, false, "")
+	cmd.Flags().Bool(flagDelete, false, "")
+	cmd.Flags().Bool(flagNoVerify, false, "")
+	cmd.Flags().Bool(flagDryRun, false, "")
+	_ = cmd.Flags().Set(flagDryRun, "true")
+	cmd.SetContext(config.WithConfig(context.Background(), cfg))
+
+	if err := mergeCmd.RunE(cmd, []string{"login"}); err != nil {
+		t.Fatalf(fatalMergeRunE, err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "not been approved") {
+		t.Errorf("output = %q, dry run must not prompt for trust", out)
+	}
+	if !strings.Contains(out, "verify merge in a scratch worktree: make test") {
+		t.Errorf("output = %q, want the verify step in the plan", out)
+	}
+}
+
+func TestPrintMergedVerified(t *testing.T) {
+	cmd, buf := newTestCmd()
+	printMerged(cmd, operations.MergeResult{
+		SourceBranch: "feature/login",
+		TargetLabel:  branchMain,
+		Verify:       &operations.MergeVerify{Commands: []string{"make lint", "make test"}, Passed: true},
+	})
+	want := "Verified merge result: make lint; make test\nMerged feature/login into main\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if mergeVerifyJSON(nil) != nil {
+		t.Error("mergeVerifyJSON(nil) should be nil")
+	}
+}
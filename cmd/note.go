@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/hint"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagOwner = "owner"
+	flagIssue = "issue"
+	flagSet   = "set"
+	flagClear = "clear"
+
+	hintNoteSet = "Record a free-form field, e.g. --set env=staging (empty value removes it)"
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note <task> [text]",
+	Short: "Show or edit a worktree's note, owner, linked issue, and fields",
+	Long: `Records why a worktree exists. With text, replaces the worktree's note;
+--owner, --issue, and --set edit the other fields. With neither, prints the
+worktree's metadata, including who created it and when.
+
+Metadata is keyed by branch: it survives archive and restore, follows
+rename, and is dropped when the branch is deleted. Use 'rimba tag' for tags,
+and --tag/--owner on list, exec, and clean to filter by metadata.`,
+	Example: `  rimba note auth                              # show metadata
+  rimba note auth "token refresh spike"        # set the note
+  rimba note auth --owner dana --issue GH-142  # set owner and linked issue
+  rimba note auth --set env=staging            # set a free-form field
+  rimba note auth --set env=                   # remove a field
+  rimba note auth --clear                      # remove the note`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r := newRunner(cmd.Context())
+		wt, err := findWorktree(cmd.Context(), r, args[0])
+		if err != nil {
+			return err
+		}
+
+		edit, editing, err := noteEdit(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		if !isJSON(cmd) {
+			hint.New(cmd, hintPainter(cmd)).
+				Add(flagSet, hintNoteSet).
+				Show()
+		}
+
+		var m meta.Meta
+		if !editing {
+			s, err := operations.LoadMeta(cmd.Context(), r)
+			if err != nil {
+				return err
+			}
+			m = s.Get(wt.Branch)
+		} else if m, err = operations.UpdateMeta(cmd.Context(), r, wt.Branch, edit); err != nil {
+			return errhint.WithFix(err, "check that the git common dir is writable, then retry")
+		}
+
+		if isJSON(cmd) {
+			return output.WriteJSON(cmd.OutOrStdout(), version, "note", output.MetaData{Task: args[0], Branch: wt.Branch, Meta: m})
+		}
+		if editing {
+			fmt.Fprintf(cmd.OutOrStdout(), "Updated metadata for %s\n", wt.Branch)
+		}
+		printMeta(cmd.OutOrStdout(), wt.Branch, m)
+		return nil
+	},
+}
+
+// noteEdit builds the metadata change requested by args and flags; editing
+// is false when the invocation only reads.
+func noteEdit(cmd *cobra.Command, args []string) (edit func(m *meta.Meta), editing bool, err error) {
+	clearNote, _ := cmd.Flags().GetBool(flagClear)
+	if clearNote && len(args) == 2 {
+		return nil, false, errhint.WithFix(
+			fmt.Errorf("--%s cannot be combined with note text", flagClear),
+			"drop --clear to replace the note, or drop the text to remove it",
+		)
+	}
+
+	sets, _ := cmd.Flags().GetStringArray(flagSet)
+	fields := make([][2]string, 0, len(sets))
+	for _, arg := range sets {
+		k, v, err := meta.ParseField(arg)
+		if err != nil {
+			return nil, false, errhint.WithFix(err, "run: rimba note <task> --set key=value")
+		}
+		fields = append(fields, [2]string{k, v})
+	}
+
+	owner, ownerSet := changedString(cmd, flagOwner)
+	issue, issueSet := changedString(cmd, flagIssue)
+	if len(args) < 2 && !clearNote && !ownerSet && !issueSet && len(fields) == 0 {
+		return nil, false, nil
+	}
+
+	return func(m *meta.Meta) {
+		switch {
+		case clearNote:
+			m.Note = ""
+		case len(args) == 2:
+			m.Note = args[1]
+		}
+		if ownerSet {
+			m.Owner = owner
+		}
+		if issueSet {
+			m.Issue = issue
+		}
+		for _, f := range fields {
+			m.SetField(f[0], f[1])
+		}
+	}, true, nil
+}
+
+// changedString returns a string flag's value and whether the user set it,
+// so an explicit empty value can clear a field.
+func changedString(cmd *cobra.Command, name string) (string, bool) {
+	v, _ := cmd.Flags().GetString(name)
+	return v, cmd.Flags().Changed(name)
+}
+
+// printMeta renders a branch's metadata as an indented key/value block.
+func printMeta(out io.Writer, branch string, m meta.Meta) {
+	if m.IsZero() {
+		fmt.Fprintf(out, "No metadata for %s\n", branch)
+		return
+	}
+	fmt.Fprintf(out, "Metadata for %s:\n", branch)
+	if m.Note != "" {
+		fmt.Fprintf(out, "  Note:    %s\n", m.Note)
+	}
+	if len(m.Tags) > 0 {
+		fmt.Fprintf(out, "  Tags:    %s\n", strings.Join(m.Tags, ", "))
+	}
+	if m.Owner != "" {
+		fmt.Fprintf(out, "  Owner:   %s\n", m.Owner)
+	}
+	if m.Issue != "" {
+		fmt.Fprintf(out, "  Issue:   %s\n", m.Issue)
+	}
+	if created := formatCreated(m); created != "" {
+		fmt.Fprintf(out, "  Created: %s\n", created)
+	}
+	keys := make([]string, 0, len(m.Fields))
+	for k := range m.Fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(out, "  %s: %s\n", k, m.Fields[k])
+	}
+}
+
+// formatCreated renders "<local time> by <who>", omitting unknown parts.
+func formatCreated(m meta.Meta) string {
+	var parts []string
+	if !m.CreatedAt.IsZero() {
+		parts = append(parts, m.CreatedAt.Local().Format(time.DateTime))
+	}
+	if m.CreatedBy != "" {
+		parts = append(parts, "by "+m.CreatedBy)
+	}
+	return strings.Join(parts, " ")
+}
+
+func init() {
+	noteCmd.Flags().String(flagOwner, "", "set the worktree's owner (empty clears it)")
+	noteCmd.Flags().String(flagIssue, "", "link an issue, e.g. GH-142 or a URL (empty clears it)")
+	noteCmd.Flags().StringArray(flagSet, nil, "set a free-form field as key=value (repeatable; empty value removes it)")
+	noteCmd.Flags().Bool(flagClear, false, "remove the note")
+	rootCmd.AddCommand(noteCmd)
+}
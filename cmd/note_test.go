@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/spf13/cobra"
+)
+
+// metaCmdRunner serves the lookups note and tag make: the common dir that
+// holds the metadata store, and a worktree list with the login worktree.
+func metaCmdRunner(commonDir string) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case len(args) >= 2 && args[0] == cmdRevParse && args[1] == cmdGitCommonDir:
+				return commonDir, nil
+			case len(args) >= 2 && args[0] == cmdWorktreeTest && args[1] == cmdList:
+				return wtRepo + headMainBlock + "\n" +
+					wtFeatureLogin + "\n" + headDEF456 + "\n" + branchRefFeatureLogin + "\n", nil
+			}
+			return "", nil
+		},
+		runInDir: noopRunInDir,
+	}
+}
+
+func newNoteTestCmd() (*cobra.Command, *bytes.Buffer) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().String(flagOwner, "", "")
+	cmd.Flags().String(flagIssue, "", "")
+	cmd.Flags().StringArray(flagSet, nil, "")
+	cmd.Flags().Bool(flagClear, false, "")
+	return cmd, buf
+}
+
+func loadTestMeta(t *testing.T, commonDir string) meta.Meta {
+	t.Helper()
+	s, err := meta.Load(commonDir)
+	if err != nil {
+		t.Fatalf("meta.Load: %v", err)
+	}
+	return s.Get(branchFeature)
+}
+
+func TestNoteSetsNoteOwnerAndFields(t *testing.T) {
+	commonDir := filepath.Join(t.TempDir(), ".git")
+	restore := overrideNewRunner(metaCmdRunner(commonDir))
+	defer restore()
+
+	cmd, buf := newNoteTestCmd()
+	_ = cmd.Flags().Set(flagOwner, "dana")
+	_ = cmd.Flags().Set(flagSet, "env=staging")
+	if err := noteCmd.RunE(cmd, []string{taskLogin, "token refresh spike"}); err != nil {
+		t.Fatalf("noteCmd.RunE: %v", err)
+	}
+
+	m := loadTestMeta(t, commonDir)
+	if m.Note != "token refresh spike" || m.Owner != "dana" || m.Fields["env"] != "staging" {
+		t.Errorf("stored meta = %+v", m)
+	}
+	out := buf.String()
+	for _, want := range []string{"Updated metadata for feature/login", "Note:    token refresh spike", "Owner:   dana", "env: staging"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestNoteShowsNothingRecorded(t *testing.T) {
+	restore := overrideNewRunner(metaCmdRunner(t.TempDir()))
+	defer restore()
+
+	cmd, buf := newNoteTestCmd()
+	if err := noteCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("noteCmd.RunE: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No metadata for feature/login") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestNoteClearKeepsOtherFields(t *testing.T) {
+	commonDir := t.TempDir()
+	seed := &meta.Store{Worktrees: map[string]meta.Meta{branchFeature: {Note: "old", Owner: "dana"}}}
+	if err := seed.Save(commonDir); err != nil {
+		t.Fatal(err)
+	}
+	restore := overrideNewRunner(metaCmdRunner(commonDir))
+	defer restore()
+
+	cmd, _ := newNoteTestCmd()
+	_ = cmd.Flags().Set(flagClear, "true")
+	if err := noteCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("noteCmd.RunE: %v", err)
+	}
+	if m := loadTestMeta(t, commonDir); m.Note != "" || m.Owner != "dana" {
+		t.Errorf("stored meta = %+v, want note cleared and owner kept", m)
+	}
+}
+
+func TestNoteEditRejectsClearWithText(t *testing.T) {
+	cmd, _ := newNoteTestCmd()
+	_ = cmd.Flags().Set(flagClear, "true")
+	if _, _, err := noteEdit(cmd, []string{taskLogin, "text"}); err == nil {
+		t.Fatal("expected error for --clear with note text")
+	}
+}
+
+func TestNoteEditRejectsMalformedField(t *testing.T) {
+	cmd, _ := newNoteTestCmd()
+	_ = cmd.Flags().Set(flagSet, "novalue")
+	if _, _, err := noteEdit(cmd, []string{taskLogin}); err == nil || !strings.Contains(err.Error(), "key=value") {
+		t.Fatalf("err = %v, want key=value error", err)
+	}
+}
+
+func TestNoteJSON(t *testing.T) {
+	restore := overrideNewRunner(metaCmdRunner(t.TempDir()))
+	defer restore()
+
+	cmd, buf := newNoteTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	_ = cmd.Flags().Set(flagIssue, "GH-142")
+	if err := noteCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("noteCmd.RunE: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{`"command": "note"`, `"branch": "feature/login"`, `"issue": "GH-142"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %s, want %s", out, want)
+		}
+	}
+}
+
+func TestFormatCreated(t *testing.T) {
+	m := meta.Meta{CreatedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), CreatedBy: "Dana"}
+	if got := formatCreated(m); !strings.HasSuffix(got, " by Dana") || !strings.HasPrefix(got, "2026-03-01") {
+		t.Errorf("formatCreated = %q", got)
+	}
+	if got := formatCreated(meta.Meta{}); got != "" {
+		t.Errorf("formatCreated(zero) = %q, want empty", got)
+	}
+}
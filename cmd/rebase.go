@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/gitref"
+	"github.com/lugassawan/rimba/internal/hint"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/lugassawan/rimba/internal/spinner"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagOnto = "onto"
+
+	hintDryRunRebase = "Preview the rebase without changing the worktree"
+)
+
+var rebaseCmd = &cobra.Command{
+	Use:   "rebase <task> --onto <ref>",
+	Short: "Move a worktree onto a different base branch",
+	Long: `Moves a worktree onto a new base branch and records it as the base the
+worktree tracks from then on: status compares against it, and sync, merge,
+clean --merged, and conflict-check use it in place of the default branch.
+
+Only the worktree's own commits move: they are replayed with
+'git rebase --onto <ref>' from where the branch left its current base. A
+conflicting rebase is aborted, leaving the worktree as it was. The worktree
+must be clean, and stacked worktrees (see 'rimba add --on') follow their
+parent instead — restack them with 'rimba sync --stack'.
+
+Rebasing onto the base config already derives for the branch (the default
+branch, or its prefix type's source or sync_target) clears the recorded base.`,
+	Example: `  rimba rebase auth --onto release/2.3        # retarget auth at the release line
+  rimba rebase auth --onto main               # move it back to the default branch
+  rimba rebase auth --onto release/2.4 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		task := args[0]
+		onto, _ := cmd.Flags().GetString(flagOnto)
+		if err := gitref.Validate(onto); err != nil {
+			return fmt.Errorf("--onto: %w", err)
+		}
+		dryRun, _ := cmd.Flags().GetBool(flagDryRun)
+
+		cfg := config.FromContext(cmd.Context())
+		r := newRunner(cmd.Context())
+
+		wt, err := findWorktree(cmd.Context(), r, task)
+		if err != nil {
+			return err
+		}
+
+		if !isJSON(cmd) {
+			hint.New(cmd, hintPainter(cmd)).
+				Add(flagDryRun, hintDryRunRebase).
+				Show()
+		}
+
+		s := spinner.New(spinnerOpts(cmd))
+		defer s.Stop()
+
+		s.Start(fmt.Sprintf("Rebasing onto %s...", onto))
+		baseOf, err := operations.BaseResolver(cmd.Context(), r, cfg, cfg.DefaultSource)
+		if err != nil {
+			return err
+		}
+		result, err := operations.RebaseWorktree(cmd.Context(), r, operations.RebaseParams{
+			Worktree:   wt,
+			From:       baseOf(wt.Branch),
+			Onto:       onto,
+			ConfigBase: cfg.BaseBranch(wt.Branch),
+			DryRun:     dryRun,
+		})
+		s.Stop()
+		if err != nil {
+			return err
+		}
+
+		if isJSON(cmd) {
+			return output.WriteJSON(cmd.OutOrStdout(), version, "rebase", output.RebaseData{
+				Branch: result.Branch,
+				Path:   result.Path,
+				From:   result.From,
+				Onto:   result.Onto,
+				DryRun: dryRun,
+				Steps:  result.Plan.Steps,
+			})
+		}
+
+		out := cmd.OutOrStdout()
+		if dryRun {
+			for _, step := range result.Plan.Steps {
+				fmt.Fprintf(out, "[dry-run] %s\n", step)
+			}
+			return nil
+		}
+		fmt.Fprintf(out, "Rebased %s onto %s (was %s)\n", result.Branch, result.Onto, result.From)
+		return nil
+	},
+}
+
+func init() {
+	rebaseCmd.Flags().String(flagOnto, "", "branch or ref to move the worktree onto")
+	_ = rebaseCmd.MarkFlagRequired(flagOnto)
+	rebaseCmd.Flags().Bool(flagDryRun, false, "preview the rebase without making changes")
+	_ = rebaseCmd.RegisterFlagCompletionFunc(flagOnto, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeBranchNames(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(rebaseCmd)
+}
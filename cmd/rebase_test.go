@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/spf13/cobra"
+)
+
+const branchRelease23 = "release/2.3"
+
+// rebaseTestRunner serves `rimba rebase login` against a clean feature/login
+// worktree whose state lives in commonDir; rebases are counted.
+func rebaseTestRunner(commonDir string, rebases *int) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case len(args) >= 2 && args[1] == cmdGitCommonDir:
+				return commonDir, nil
+			case args[0] == cmdRevParse && args[1] == cmdShowToplevel:
+				return repoPath, nil
+			case args[0] == cmdWorktreeTest && args[1] == cmdList:
+				return wtRepo + headMainBlock + "\n" +
+					wtFeatureLogin + "\n" + headDEF456 + "\n" + branchRefFeatureLogin + "\n", nil
+			}
+			return "abc123", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			if args[0] == cmdRebase {
+				*rebases++
+			}
+			return "", nil
+		},
+	}
+}
+
+func newRebaseTestCmd(onto string, dryRun bool) (*cobra.Command, *bytes.Buffer) {
+	cmd, buf := newTestCmd()
+	cmd.Flags().String(flagOnto, "", "")
+	cmd.Flags().Bool(flagDryRun, false, "")
+	_ = cmd.Flags().Set(flagOnto, onto)
+	if dryRun {
+		_ = cmd.Flags().Set(flagDryRun, "true")
+	}
+	cmd.SetContext(config.WithConfig(context.Background(), testSyncConfig()))
+	return cmd, buf
+}
+
+func TestRebaseRecordsBase(t *testing.T) {
+	commonDir := t.TempDir()
+	var rebases int
+	restore := overrideNewRunner(rebaseTestRunner(commonDir, &rebases))
+	defer restore()
+
+	cmd, out := newRebaseTestCmd(branchRelease23, false)
+	if err := rebaseCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("rebaseCmd.RunE: %v", err)
+	}
+	if rebases != 1 {
+		t.Errorf("rebases = %d, want 1", rebases)
+	}
+	if !strings.Contains(out.String(), "Rebased feature/login onto "+branchRelease23+" (was main)") {
+		t.Errorf("output = %q", out.String())
+	}
+	s, _ := meta.Load(commonDir)
+	if got := s.Get("feature/login").Base; got != branchRelease23 {
+		t.Errorf("recorded base = %q, want %s", got, branchRelease23)
+	}
+}
+
+func TestRebaseDryRunJSON(t *testing.T) {
+	commonDir := t.TempDir()
+	var rebases int
+	restore := overrideNewRunner(rebaseTestRunner(commonDir, &rebases))
+	defer restore()
+
+	cmd, out := newRebaseTestCmd(branchRelease23, true)
+	_ = cmd.Flags().Set(flagJSON, "true")
+	if err := rebaseCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("rebaseCmd.RunE: %v", err)
+	}
+	if rebases != 0 {
+		t.Errorf("dry run rebased %d time(s)", rebases)
+	}
+	for _, want := range []string{`"command": "rebase"`, `"onto": "release/2.3"`, `"dry_run": true`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %s: %s", want, out.String())
+		}
+	}
+}
+
+func TestRebaseInvalidOnto(t *testing.T) {
+	cmd, _ := newRebaseTestCmd("bad..ref", false)
+	err := rebaseCmd.RunE(cmd, []string{taskLogin})
+	if err == nil || !strings.Contains(err.Error(), "--onto") {
+		t.Errorf("err = %v, want --onto validation error", err)
+	}
+}
@@ -1,31 +1,53 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"path/filepath"
+	"io"
+	"strings"
 
 	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/hint"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/operations"
-	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/spinner"
 	"github.com/spf13/cobra"
 )
 
+const flagFromBundle = "from-bundle"
+
 var restoreCmd = &cobra.Command{
-	Use:   "restore <task>",
+	Use:   "restore <task> | --from-bundle <file>",
 	Short: "Restore an archived worktree from its preserved branch",
 	Long: `Recreates a worktree from a branch that was previously archived with
 rimba archive. The archived branch must still exist locally — restore does not
-fetch from a remote.
+fetch from a remote. Uncommitted changes and copy_files entries saved by
+archive are reapplied, so the worktree comes back exactly as it was left.
 
 If no archived branch is found for the task, restore fails with
 "no archived branch found for task <name>". Use 'rimba list --archived'
-to see available archived branches.`,
+to see available archived branches.
+
+With --from-bundle, the task is imported from a file written by
+'rimba archive --bundle' — on another machine or in CI — instead of a local
+archive. The clone must already have the commits the branch was built on, and
+the branch must not exist yet.
+
+If a step fails — or the command is interrupted — the restore is rolled back:
+the worktree is removed, reapplied changes go back into the archive snapshot,
+and a task imported from a bundle is forgotten. Use --keep-on-failure to
+leave the partial worktree in place instead.
+
+Use --profile <name> to set the worktree up with a [profiles.<name>] config
+section's copy_files, post_create, and deps.`,
 	Example: `  rimba restore auth
-  rimba restore auth --skip-hooks`,
-	Args: cobra.ExactArgs(1),
+  rimba restore auth --skip-hooks
+  rimba restore auth --keep-on-failure
+  rimba restore auth --profile perf
+  rimba restore --from-bundle auth.rimba`,
+	Args: cobra.MaximumNArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -40,20 +62,19 @@ to see available archived branches.`,
 			return err
 		}
 
-		service, task := operations.ResolveTaskInput(args[0], repoRoot, config.PrefixSetFromContext(cmd.Context()))
 		cfg := config.FromContext(cmd.Context())
-
-		branch, err := operations.FindArchivedBranch(cmd.Context(), r, service, task)
+		params, err := restoreTarget(cmd, r, repoRoot, args)
 		if err != nil {
 			return err
 		}
-
-		if err := ensureTrust(cmd, repoRoot, cfg); err != nil {
+		setupCfg, err := applyProfileFlag(cmd, cfg)
+		if err != nil {
 			return err
 		}
-
-		wtDir := filepath.Join(repoRoot, cfg.WorktreeDir)
-		wtPath := resolver.WorktreePath(wtDir, branch)
+		skipDeps, _ := cmd.Flags().GetBool(flagSkipDeps)
+		skipHooks, _ := cmd.Flags().GetBool(flagSkipHooks)
+		params.PostCreateOptions = buildPostCreateOptions(setupCfg, repoRoot, skipDeps, skipHooks)
+		params.KeepOnFailure, _ = cmd.Flags().GetBool(flagKeepOnFail)
 
 		hint.New(cmd, hintPainter(cmd)).
 			Add(flagSkipDeps, hintSkipDeps).
@@ -63,33 +84,8 @@ to see available archived branches.`,
 		s := spinner.New(spinnerOpts(cmd))
 		defer s.Stop()
 
-		// Create worktree from existing branch
 		s.Start("Restoring worktree...")
-		if err := git.AddWorktreeFromBranch(cmd.Context(), r, wtPath, branch); err != nil {
-			return err
-		}
-
-		// Post-create setup: copy files, deps, hooks
-		skipDeps, _ := cmd.Flags().GetBool(flagSkipDeps)
-		skipHooks, _ := cmd.Flags().GetBool(flagSkipHooks)
-		var configModules []config.ModuleConfig
-		if cfg.Deps != nil {
-			configModules = cfg.Deps.Modules
-		}
-
-		pcResult, err := operations.PostCreateSetup(cmd.Context(), r, operations.PostCreateParams{
-			RepoRoot:      repoRoot,
-			WtPath:        wtPath,
-			Task:          task,
-			Service:       service,
-			CopyFiles:     cfg.CopyFiles,
-			SkipDeps:      skipDeps,
-			AutoDetect:    cfg.IsAutoDetectDeps(),
-			ConfigModules: configModules,
-			SkipHooks:     skipHooks,
-			PostCreate:    cfg.PostCreate,
-			Concurrency:   cfg.DepsConcurrency(),
-		}, func(msg string) { s.Update(msg) })
+		result, err := operations.RestoreWorktree(cmd.Context(), r, params, func(msg string) { s.Update(msg) })
 		if err != nil {
 			return err
 		}
@@ -97,28 +93,91 @@ to see available archived branches.`,
 		s.Stop()
 
 		out := cmd.OutOrStdout()
-		fmt.Fprintf(out, "Restored worktree for task %q\n", task)
-		fmt.Fprintf(out, "  Branch: %s\n", branch)
-		fmt.Fprintf(out, "  Path:   %s\n", wtPath)
-		if len(pcResult.Copied) > 0 {
-			fmt.Fprintf(out, "  Copied: %v\n", pcResult.Copied)
+		if result.Imported {
+			fmt.Fprintf(out, "Imported %s from %s\n", result.Branch, params.Bundle)
+		}
+		fmt.Fprintf(out, "Restored worktree for task %q\n", result.Task)
+		fmt.Fprintf(out, "  Branch: %s\n", result.Branch)
+		fmt.Fprintf(out, "  Path:   %s\n", result.Path)
+		if store, err := operations.LoadMeta(cmd.Context(), r); err == nil {
+			printRestoredMeta(out, store.Get(result.Branch))
+		}
+		if result.Snapshot.Stashed {
+			fmt.Fprintln(out, "  Reapplied uncommitted changes saved by archive")
+		}
+		if len(result.Snapshot.Files) > 0 {
+			fmt.Fprintf(out, "  Restored from archive: %v\n", result.Snapshot.Files)
+		}
+		if len(result.Copied) > 0 {
+			fmt.Fprintf(out, "  Copied: %v\n", result.Copied)
 		}
-		if len(pcResult.Skipped) > 0 {
-			fmt.Fprintf(out, "  Skipped (not found): %v\n", pcResult.Skipped)
+		if len(result.Skipped) > 0 {
+			fmt.Fprintf(out, "  Skipped (not found): %v\n", result.Skipped)
 		}
-		if len(pcResult.SkippedSymlinks) > 0 {
-			fmt.Fprintf(out, "  Skipped (symlinks): %v\n", pcResult.SkippedSymlinks)
+		if len(result.SkippedSymlinks) > 0 {
+			fmt.Fprintf(out, "  Skipped (symlinks): %v\n", result.SkippedSymlinks)
 		}
 
-		printInstallResults(out, pcResult.DepsResults)
-		printHookResultsList(out, pcResult.HookResults)
+		printInstallResults(out, result.DepsResults)
+		printHookResultsList(out, result.HookResults)
 
 		return nil
 	},
 }
 
+// restoreTarget resolves the archived branch to restore, or the bundle to
+// import it from with --from-bundle. Trust is checked before anything is
+// imported into the repository.
+func restoreTarget(cmd *cobra.Command, r git.Runner, repoRoot string, args []string) (operations.RestoreParams, error) {
+	ctx := cmd.Context()
+	cfg := config.FromContext(ctx)
+	fromBundle, _ := cmd.Flags().GetString(flagFromBundle)
+
+	switch {
+	case fromBundle != "" && len(args) > 0:
+		return operations.RestoreParams{}, errhint.WithFix(
+			fmt.Errorf("--%s takes the task from the bundle", flagFromBundle),
+			"run: rimba restore --from-bundle "+fromBundle,
+		)
+	case fromBundle == "" && len(args) == 0:
+		return operations.RestoreParams{}, errhint.WithFix(
+			errors.New("no task given"),
+			"run: rimba restore <task>  OR  rimba restore --from-bundle <file>",
+		)
+	}
+
+	params := operations.RestoreParams{Bundle: fromBundle}
+	if fromBundle == "" {
+		params.Service, params.Task = operations.ResolveTaskInput(args[0], repoRoot, config.PrefixSetFromContext(ctx))
+		branch, err := operations.FindArchivedBranch(ctx, r, params.Service, params.Task)
+		if err != nil {
+			return operations.RestoreParams{}, err
+		}
+		params.Branch = branch
+	}
+	if err := ensureTrust(cmd, repoRoot, cfg); err != nil {
+		return operations.RestoreParams{}, err
+	}
+	return params, nil
+}
+
+// printRestoredMeta reminds the user why the worktree existed: metadata is
+// keyed by branch, so it came back with the branch.
+func printRestoredMeta(out io.Writer, m meta.Meta) {
+	if m.Note != "" {
+		fmt.Fprintf(out, "  Note:   %s\n", m.Note)
+	}
+	if len(m.Tags) > 0 {
+		fmt.Fprintf(out, "  Tags:   %s\n", strings.Join(m.Tags, ", "))
+	}
+}
+
 func init() {
 	restoreCmd.Flags().Bool(flagSkipDeps, false, "skip dependency detection and installation")
 	restoreCmd.Flags().Bool(flagSkipHooks, false, "skip post-create hooks")
+	restoreCmd.Flags().String(flagProfile, "", "apply a [profiles.<name>] section's setup from the config")
+	_ = restoreCmd.RegisterFlagCompletionFunc(flagProfile, completeProfilesFlag)
+	restoreCmd.Flags().Bool(flagKeepOnFail, false, "keep a partially restored worktree when a step fails instead of rolling back")
+	restoreCmd.Flags().String(flagFromBundle, "", "import the task from a bundle written by 'rimba archive --bundle'")
 	rootCmd.AddCommand(restoreCmd)
 }
@@ -59,3 +59,27 @@ func TestRestoreSuccess(t *testing.T) {
 		t.Errorf("expected 'Restored worktree', got: %q", output)
 	}
 }
+
+func TestRestoreTargetRequiresTaskOrBundle(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		fromBundle string
+		wantErr    string
+	}{
+		{name: "neither", wantErr: "no task given"},
+		{name: "both", args: []string{"auth"}, fromBundle: "auth.rimba", wantErr: "takes the task from the bundle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, _ := newTestCmd()
+			cmd.Flags().String(flagFromBundle, "", "")
+			_ = cmd.Flags().Set(flagFromBundle, tt.fromBundle)
+
+			_, err := restoreTarget(cmd, &mockRunner{run: func(_ ...string) (string, error) { return "", nil }, runInDir: noopRunInDir}, repoPath, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -25,7 +25,9 @@ const (
 	flagDryRun       = "dry-run"
 	flagForce        = "force"
 	flagJSON         = "json"
+	flagKeepOnFail   = "keep-on-failure"
 	flagNoColor      = "no-color"
+	flagProfile      = "profile"
 	flagPush         = "push"
 	flagSkipDeps     = "skip-deps"
 	flagSkipHooks    = "skip-hooks"
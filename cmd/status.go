@@ -41,7 +41,10 @@ in the last 7 days. With --detail, rows are sorted largest-first.`,
 		}
 		s := spinner.New(spinnerOpts(cmd))
 		s.Start("Collecting worktree status...")
-		res, err := operations.StatusDashboard(ctx, r, operations.StatusDashboardRequest{Detail: detail})
+		res, err := operations.StatusDashboard(ctx, r, operations.StatusDashboardRequest{
+			Detail: detail,
+			Config: config.FromContext(ctx),
+		})
 		s.Stop()
 		if err != nil {
 			return err
@@ -127,13 +130,21 @@ func renderStatusDashboard(out io.Writer, p *termcolor.Painter, r statusRender)
 
 	flagOrphans := r.ps.HasCustom()
 
+	branches := make([]string, len(r.results))
+	parents := make(map[string]string, len(r.results))
+	for i, e := range r.results {
+		branches[i] = e.Entry.Branch
+		parents[e.Entry.Branch] = e.Parent
+	}
+	indents := stackIndents(branches, parents)
+
 	var orphaned int
 	for _, e := range r.results {
 		isOrphan := flagOrphans && r.ps.IsOrphan(e.Entry.Branch, r.mainBranch)
 		if isOrphan {
 			orphaned++
 		}
-		tbl.AddRow(buildStatusRow(e, prefixes, staleThreshold, p, r.detail, isOrphan)...)
+		tbl.AddRow(buildStatusRow(e, prefixes, staleThreshold, p, r.detail, isOrphan, indents[e.Entry.Branch])...)
 	}
 
 	tbl.Render(out)
@@ -189,11 +200,12 @@ func renderActionHints(out io.Writer, p *termcolor.Painter, summary operations.S
 	}
 }
 
-// buildStatusRow formats a single worktree row for the status table.
-func buildStatusRow(r operations.StatusEntry, prefixes []string, staleThreshold time.Time, p *termcolor.Painter, detail, isOrphan bool) []string {
+// buildStatusRow formats a single worktree row for the status table; indent
+// is the stack-tree prefix for a stacked worktree.
+func buildStatusRow(r operations.StatusEntry, prefixes []string, staleThreshold time.Time, p *termcolor.Painter, detail, isOrphan bool, indent string) []string {
 	task, typeName := resolver.TaskAndType(r.Entry.Branch, prefixes)
 
-	taskCell := "  " + task
+	taskCell := "  " + indent + task
 	typeCell := typeName
 	if isOrphan {
 		typeCell += " ⚠"
@@ -202,13 +214,29 @@ func buildStatusRow(r operations.StatusEntry, prefixes []string, staleThreshold
 		typeCell = p.Paint(typeCell, c)
 	}
 
-	row := []string{taskCell, typeCell, r.Entry.Branch, colorStatus(p, r.Status), formatAgeCell(r, staleThreshold, p)}
+	row := []string{taskCell, typeCell, formatBranchCell(r, p), colorStatus(p, r.Status), formatAgeCell(r, staleThreshold, p)}
 	if detail {
 		row = append(row, formatSizeCell(r, p), formatRecentCell(r, p))
 	}
 	return row
 }
 
+// formatBranchCell renders the BRANCH column, noting a non-main base and how
+// far the branch has drifted from it.
+func formatBranchCell(r operations.StatusEntry, p *termcolor.Painter) string {
+	if r.Base == "" {
+		return r.Entry.Branch
+	}
+	note := "base: " + r.Base
+	if r.BaseAhead > 0 {
+		note += fmt.Sprintf(" ↑%d", r.BaseAhead)
+	}
+	if r.BaseBehind > 0 {
+		note += fmt.Sprintf(" ↓%d", r.BaseBehind)
+	}
+	return r.Entry.Branch + " " + p.Paint("("+note+")", termcolor.Gray)
+}
+
 // formatSizeCell renders the SIZE column. Nil (errored) renders as "?".
 func formatSizeCell(r operations.StatusEntry, p *termcolor.Painter) string {
 	if r.SizeBytes == nil {
@@ -261,6 +289,10 @@ func writeStatusJSON(cmd *cobra.Command, results []operations.StatusEntry, stale
 			Status:    r.Status,
 			SizeBytes: r.SizeBytes,
 			Recent7D:  r.Recent7D,
+			Parent:    r.Parent,
+		}
+		if r.Base != "" {
+			item.Base = &output.StatusBase{Branch: r.Base, Ahead: r.BaseAhead, Behind: r.BaseBehind}
 		}
 
 		if r.HasTime {
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/output"
 	"github.com/lugassawan/rimba/internal/termcolor"
@@ -47,6 +48,23 @@ func TestFormatSizeCell(t *testing.T) {
 	}
 }
 
+func TestFormatBranchCell(t *testing.T) {
+	p := termcolor.NewPainter(true)
+	e := operations.StatusEntry{Entry: git.WorktreeEntry{Branch: "feature/login"}}
+
+	if got := formatBranchCell(e, p); got != "feature/login" {
+		t.Errorf("main-based cell = %q, want plain branch", got)
+	}
+	e.Base, e.BaseAhead, e.BaseBehind = "release/2.3", 2, 3
+	if got := formatBranchCell(e, p); got != "feature/login (base: release/2.3 ↑2 ↓3)" {
+		t.Errorf("based cell = %q", got)
+	}
+	e.BaseAhead, e.BaseBehind = 0, 0
+	if got := formatBranchCell(e, p); got != "feature/login (base: release/2.3)" {
+		t.Errorf("in-sync based cell = %q", got)
+	}
+}
+
 func TestFormatRecentCell(t *testing.T) {
 	p := termcolor.NewPainter(true)
 
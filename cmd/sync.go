@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/lugassawan/rimba/internal/config"
@@ -22,11 +23,24 @@ const (
 	flagSyncMerge        = "merge"
 	flagIncludeInherited = "include-inherited"
 	flagNoPush           = "no-push"
+	flagStack            = "stack"
+	flagStopOnConflict   = "stop-on-conflict"
+	flagContinue         = "continue"
+	flagAbort            = "abort"
+	flagAutostash        = "autostash"
+	flagFetch            = "fetch"
+	flagPredict          = "predict"
+	flagPredictOnly      = "predict-only"
 
 	hintAll              = "Sync all eligible worktrees at once"
 	hintSyncMerge        = "Use merge instead of rebase (preserves history, creates merge commits)"
 	hintIncludeInherited = "Include inherited/duplicate worktrees when using --all"
 	hintNoPush           = "Skip pushing after sync (useful for local-only rebase/merge)"
+	hintStack            = "Restack stacked worktrees onto their parent's new tip"
+	hintStopOnConflict   = "Stop at the first conflict and leave it in place, resumable with --continue"
+	hintAutostash        = "Stash uncommitted changes around the sync instead of skipping dirty worktrees"
+	hintFetch            = "Fetch first and sync onto the remote's branches instead of the local ones"
+	hintPredict          = "Predict conflicts first and skip the worktrees that would conflict"
 )
 
 // syncContext bundles shared state for sync operations.
@@ -37,8 +51,26 @@ type syncContext struct {
 	s        *spinner.Spinner
 	repoRoot string
 	dryRun   bool
-	res      *syncResult // used by syncAll goroutines
-	mu       sync.Mutex  // guards res, jsonResults, and output in syncAll
+	// autostash stashes a dirty worktree around its sync instead of skipping it.
+	autostash bool
+	baseOf    func(branch string) string // nil falls back to cfg.BaseBranch
+	// bases maps each base to the ref synced onto and its tip; nil syncs
+	// onto the base as given without resolving it.
+	bases *operations.SyncBases
+	fetch *operations.SyncFetch // set by --fetch
+	// predict skips worktrees git merge-tree predicts would conflict;
+	// predictOnly reports the predictions without syncing anything.
+	predict, predictOnly bool
+	// lockfiles resolves lockfile-only conflicts; nil unless
+	// [deps] resolve_lockfiles is set.
+	lockfiles *operations.LockfileResolver
+	res       *syncResult // used by syncAll goroutines
+	mu        sync.Mutex  // guards res, jsonResults, halted, and output in syncAll
+
+	// run is the --stop-on-conflict run being synced; nil otherwise.
+	run            *operations.SyncRun
+	stopOnConflict bool
+	halted         bool // a worktree stopped on a conflict; guarded by mu
 
 	jsonResults []operations.SyncWorktreeResult // JSON mode only; guarded by mu
 }
@@ -46,17 +78,38 @@ type syncContext struct {
 // syncResult tracks the outcome of syncing multiple worktrees.
 type syncResult struct {
 	synced, skippedDirty, failed    int
+	pending                         int
 	pushed, pushSkipped, pushFailed int
+	stashed, predicted              int
 	failures                        []string
 }
 
 var syncCmd = &cobra.Command{
 	Use:   "sync [task]",
 	Short: "Sync worktree(s) with the main branch",
-	Long:  "Rebases (or merges) worktree branches onto the latest main branch and pushes the result. Use --no-push to skip pushing. Use --all to sync all eligible worktrees. Use --dry-run to preview what would be synced without making changes.",
+	Long: `Rebases (or merges) worktree branches onto the latest main branch and pushes the result. Use --no-push to skip pushing. Use --all to sync all eligible worktrees. Use --dry-run to preview what would be synced without making changes.
+
+Use --stack to restack worktrees created with 'rimba add --on': each child is rebased with --onto its parent's new tip, parents first. With a task, that task is synced first and only its descendants are restacked; without one, every stack is restacked. --all skips stacked worktrees, since rebasing them onto main would detach them from their parent.
+
+Use --fetch (or fetch = true under [sync]) to fetch the remote once and sync onto its remote-tracking branches, e.g. origin/main, rather than the possibly stale local ones. A failed fetch is then an error. If the remote's default branch changed, the new one is followed; the main worktree's local default branch is fast-forwarded when it is checked out and clean.
+
+Use --predict to predict each worktree's conflicts with git merge-tree first, in parallel, and sync only those predicted to apply cleanly; the rest are skipped and listed with the files expected to conflict. --predict-only reports the predictions without touching any worktree.
+
+Dirty worktrees are skipped unless --autostash is given (or autostash = true is set under [sync] in config): their uncommitted changes, untracked files included, are stashed before the rebase or merge and reapplied after it. A stash that does not reapply cleanly is kept and its ref reported.
+
+With --all, a failed rebase is normally aborted and reported. Use --stop-on-conflict to stop instead: the conflicted rebase (or merge) is left in place, no further worktrees are started, and the run's progress is saved. Resolve and stage the conflicts, then run 'rimba sync --continue' to finish that worktree and sync the rest, or 'rimba sync --abort' to reset every worktree the run touched to its pre-sync commit.`,
 	Example: `  rimba sync auth             # rebase auth onto main
   rimba sync --all            # sync all eligible worktrees
-  rimba sync auth --dry-run   # preview without syncing`,
+  rimba sync --all --autostash   # include dirty worktrees, stashing their changes
+  rimba sync --all --fetch    # sync onto origin/main instead of local main
+  rimba sync --all --predict  # skip worktrees predicted to conflict
+  rimba sync --all --predict-only   # only report predicted conflicts
+  rimba sync auth --stack     # sync auth, then restack everything stacked on it
+  rimba sync --stack          # restack every stacked worktree
+  rimba sync auth --dry-run   # preview without syncing
+  rimba sync --all --stop-on-conflict   # stop at the first conflict
+  rimba sync --continue       # resume after resolving it
+  rimba sync --abort          # roll the run back`,
 	Args: cobra.MaximumNArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
@@ -73,10 +126,34 @@ var syncCmd = &cobra.Command{
 		includeInherited, _ := cmd.Flags().GetBool(flagIncludeInherited)
 		noPush, _ := cmd.Flags().GetBool(flagNoPush)
 		dryRun, _ := cmd.Flags().GetBool(flagDryRun)
+		stack, _ := cmd.Flags().GetBool(flagStack)
 		push := !noPush
-
-		if !all && len(args) == 0 {
-			return errors.New("provide a task name or use --all to sync all worktrees")
+		autostash := cfg.SyncAutostash()
+		if cmd.Flags().Changed(flagAutostash) {
+			autostash, _ = cmd.Flags().GetBool(flagAutostash)
+		}
+		fetch := cfg.SyncFetch()
+		if cmd.Flags().Changed(flagFetch) {
+			fetch, _ = cmd.Flags().GetBool(flagFetch)
+		}
+		predictOnly, _ := cmd.Flags().GetBool(flagPredictOnly)
+		predict, _ := cmd.Flags().GetBool(flagPredict)
+		predict = predict || predictOnly
+
+		runFlags := readSyncRunFlags(cmd)
+		others := all || stack || useMerge || includeInherited || noPush || dryRun || len(args) > 0 ||
+			cmd.Flags().Changed(flagAutostash) || cmd.Flags().Changed(flagFetch) || predict
+		if err := runFlags.validate(all, others); err != nil {
+			return err
+		}
+		if runFlags.cont || runFlags.abort {
+			return syncResume(cmd, r, cfg, runFlags.abort)
+		}
+		if err := validateSyncFlags(all, stack, useMerge, len(args)); err != nil {
+			return err
+		}
+		if err := validatePredictFlags(stack, predict); err != nil {
+			return err
 		}
 
 		if !isJSON(cmd) {
@@ -85,6 +162,11 @@ var syncCmd = &cobra.Command{
 				Add(flagSyncMerge, hintSyncMerge).
 				Add(flagIncludeInherited, hintIncludeInherited).
 				Add(flagNoPush, hintNoPush).
+				Add(flagStack, hintStack).
+				Add(flagStopOnConflict, hintStopOnConflict).
+				Add(flagAutostash, hintAutostash).
+				Add(flagFetch, hintFetch).
+				Add(flagPredict, hintPredict).
 				Add(flagDryRun, hintDryRun).
 				Show()
 		}
@@ -92,37 +174,38 @@ var syncCmd = &cobra.Command{
 		s := spinner.New(spinnerOpts(cmd))
 		defer s.Stop()
 
-		// Fetch latest from origin (non-fatal if no remote configured)
-		if dryRun {
-			if !isJSON(cmd) {
-				fmt.Fprintln(cmd.OutOrStdout(), "[dry-run] would fetch origin")
-			}
-		} else {
-			s.Start("Fetching from origin...")
-			if err := git.Fetch(cmd.Context(), r, "origin", git.FetchArgs{}); err != nil {
-				s.Stop()
-				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-					return err
-				}
-				if !isJSON(cmd) {
-					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: fetch failed (no remote?): continuing with local state\n")
-				}
-			}
-		}
-
 		repoRoot, err := git.MainRepoRoot(cmd.Context(), r)
 		if err != nil {
 			return err
 		}
+		lockfiles, err := lockfileResolver(cmd, repoRoot, cfg)
+		if err != nil {
+			return err
+		}
+
+		sc := &syncContext{
+			cmd: cmd, r: r, cfg: cfg, s: s, repoRoot: repoRoot, dryRun: dryRun, autostash: autostash,
+			predict: predict, predictOnly: predictOnly, stopOnConflict: runFlags.stopOnConflict,
+			lockfiles: lockfiles,
+		}
+		if err := sc.fetchBase(cmd.Context(), fetch); err != nil {
+			return err
+		}
 
 		worktrees, err := listWorktreeInfos(cmd.Context(), r)
 		if err != nil {
 			return err
 		}
 
-		prefixes := cfg.PrefixSet().Strip()
-		sc := &syncContext{cmd: cmd, r: r, cfg: cfg, s: s, repoRoot: repoRoot, dryRun: dryRun}
+		prefixes := sc.cfg.PrefixSet().Strip()
+		sc.baseOf, err = operations.BaseResolver(cmd.Context(), r, sc.cfg, sc.cfg.DefaultSource)
+		if err != nil {
+			return err
+		}
 
+		if stack {
+			return syncStack(cmd.Context(), sc, args, worktrees, prefixes, push)
+		}
 		if all {
 			return syncAll(cmd.Context(), sc, worktrees, prefixes, useMerge, includeInherited, push)
 		}
@@ -135,11 +218,101 @@ func init() {
 	syncCmd.Flags().Bool(flagSyncMerge, false, "use merge instead of rebase")
 	syncCmd.Flags().Bool(flagIncludeInherited, false, "include inherited/duplicate worktrees when using --all")
 	syncCmd.Flags().Bool(flagNoPush, false, "skip pushing after sync")
+	syncCmd.Flags().Bool(flagStack, false, "restack stacked worktrees onto their parent's new tip")
 	syncCmd.Flags().Bool(flagDryRun, false, "preview what would be synced without making changes")
+	syncCmd.Flags().Bool(flagStopOnConflict, false, "with --all, stop at the first conflict and leave it in place")
+	syncCmd.Flags().Bool(flagContinue, false, "resume a stopped sync run after resolving its conflict")
+	syncCmd.Flags().Bool(flagAbort, false, "roll back every worktree a stopped sync run touched")
+	syncCmd.Flags().Bool(flagAutostash, false, "stash uncommitted changes around the sync instead of skipping dirty worktrees (default from config)")
+	syncCmd.Flags().Bool(flagFetch, false, "fetch the remote first and sync onto its default branch (default from config)")
+	syncCmd.Flags().Bool(flagPredict, false, "predict conflicts with git merge-tree and sync only the worktrees predicted clean")
+	syncCmd.Flags().Bool(flagPredictOnly, false, "report predicted conflicts without syncing any worktree")
 
 	rootCmd.AddCommand(syncCmd)
 }
 
+// fetchBase fetches before syncing. With fetch, the configured remote is
+// fetched once — a failure is an error — and worktrees sync onto its
+// remote-tracking branches; without it, origin is fetched best-effort and
+// worktrees sync onto their local bases.
+func (sc *syncContext) fetchBase(ctx context.Context, fetch bool) error {
+	if !fetch {
+		sc.bases = operations.NewSyncBases(sc.r, "")
+		return sc.fetchOrigin(ctx)
+	}
+
+	remote := sc.cfg.SyncRemote()
+	sc.bases = operations.NewSyncBases(sc.r, remote)
+	if sc.dryRun {
+		if !isJSON(sc.cmd) {
+			fmt.Fprintf(sc.cmd.OutOrStdout(), "[dry-run] would fetch %s and sync onto %s/%s\n", remote, remote, sc.cfg.DefaultSource)
+		}
+		return nil
+	}
+
+	sc.s.Start(fmt.Sprintf("Fetching from %s...", remote))
+	mainRoot := sc.repoRoot
+	if sc.predictOnly {
+		mainRoot = "" // touch no worktree, not even main's
+	}
+	f, err := operations.FetchForSync(ctx, sc.r, remote, sc.cfg.DefaultSource, mainRoot)
+	sc.s.Stop()
+	if err != nil {
+		return err
+	}
+	sc.fetch = &f
+	if f.Branch != sc.cfg.DefaultSource {
+		cfg := *sc.cfg
+		cfg.DefaultSource = f.Branch
+		sc.cfg = &cfg
+	}
+	if !isJSON(sc.cmd) {
+		printSyncFetch(sc.cmd, f)
+	}
+	sc.s.Start(fmt.Sprintf("Syncing onto %s (%s)...", f.Ref(), shortSHA(f.SHA)))
+	return nil
+}
+
+// fetchOrigin fetches origin, continuing with local state when that fails
+// (no remote configured).
+func (sc *syncContext) fetchOrigin(ctx context.Context) error {
+	if sc.dryRun {
+		if !isJSON(sc.cmd) {
+			fmt.Fprintln(sc.cmd.OutOrStdout(), "[dry-run] would fetch origin")
+		}
+		return nil
+	}
+	sc.s.Start("Fetching from origin...")
+	if err := git.Fetch(ctx, sc.r, "origin", git.FetchArgs{}); err != nil {
+		sc.s.Stop()
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		if !isJSON(sc.cmd) {
+			fmt.Fprintf(sc.cmd.ErrOrStderr(), "Warning: fetch failed (no remote?): continuing with local state\n")
+		}
+	}
+	return nil
+}
+
+// printSyncFetch reports a --fetch: a changed remote default branch, and
+// whether the main worktree's local default branch was fast-forwarded.
+func printSyncFetch(cmd *cobra.Command, f operations.SyncFetch) {
+	if f.PreviousHead != "" {
+		msg := fmt.Sprintf("Warning: %s's default branch changed from %s to %s", f.Remote, f.PreviousHead, f.Head)
+		if f.Branch == f.Head {
+			msg += "; syncing onto " + f.Ref()
+		}
+		fmt.Fprintln(cmd.ErrOrStderr(), msg)
+	}
+	switch {
+	case f.FastForwarded:
+		fmt.Fprintf(cmd.OutOrStdout(), "Fast-forwarded %s to %s (%s)\n", f.Branch, f.Ref(), shortSHA(f.SHA))
+	case f.FastForwardSkip != "":
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: did not fast-forward local %s: %s\n", f.Branch, f.FastForwardSkip)
+	}
+}
+
 func syncOne(ctx context.Context, sc *syncContext, input string, worktrees []resolver.WorktreeInfo, prefixes []string, useMerge, push bool) error {
 	service, task := operations.ResolveTaskInput(input, sc.repoRoot, sc.cfg.PrefixSet())
 	wt, found := resolver.FindBranchForTask(service, task, worktrees, prefixes)
@@ -150,46 +323,55 @@ func syncOne(ctx context.Context, sc *syncContext, input string, worktrees []res
 	if err := operations.GuardKnownPrefix(sc.cfg.PrefixSet(), wt.Branch, sc.cfg.DefaultSource, false); err != nil {
 		return err
 	}
+	if err := operations.GuardUnstacked(ctx, sc.r, wt.Branch, input); err != nil {
+		return err
+	}
+	base := sc.base(wt.Branch)
 
-	dirty, err := git.IsDirty(ctx, sc.r, wt.Path)
-	if err != nil {
+	if done, err := syncOnePredict(ctx, sc, wt, useMerge); done {
 		return err
 	}
-	if dirty {
-		return errhint.WithFix(
-			fmt.Errorf("worktree %q has uncommitted changes", task),
-			"Commit or stash changes before syncing: cd "+wt.Path,
-		)
+
+	dirty, err := syncOneDirty(ctx, sc, wt, task)
+	if err != nil {
+		return err
 	}
 
 	if sc.dryRun {
-		if isJSON(sc.cmd) {
-			swr := output.SyncWorktreeJSON{Branch: wt.Branch, Planned: true}
-			return writeSyncOneJSON(sc.cmd, sc.cfg.DefaultSource, useMerge, true, swr, output.SyncSummary{})
+		return syncOneDryRun(sc, wt, base, dirty, useMerge, push)
+	}
+
+	ref, sha := sc.target(ctx, wt.Branch)
+	sr := operations.SyncWorktreeResult{Branch: wt.Branch, BaseSHA: sha}
+	if dirty {
+		sc.s.Update("Stashing uncommitted changes...")
+		if err := operations.StashForSync(ctx, sc.r, &sr, wt.Path); err != nil {
+			return fmt.Errorf("stash changes in %s: %w", wt.Path, err)
 		}
-		printSyncDryRun(sc.cmd, wt.Branch, sc.cfg.DefaultSource, useMerge, push)
-		return nil
 	}
 
 	verb := "Rebasing"
 	if useMerge {
 		verb = "Merging"
 	}
-	sc.s.Update(fmt.Sprintf("%s onto %s...", verb, sc.cfg.DefaultSource))
-	if err := operations.SyncBranch(ctx, sc.r, wt.Path, sc.cfg.DefaultSource, useMerge); err != nil {
-		return err
+	sc.s.Update(fmt.Sprintf("%s onto %s...", verb, describeBase(ref, sha)))
+	lockfiles, err := operations.SyncBranchWith(ctx, sc.r, wt.Path, ref, useMerge, sc.lockfiles)
+	if err != nil {
+		return errors.Join(err, syncOneReapply(sc, &sr, wt.Path))
 	}
 
+	sr.Synced, sr.LockfilesRegenerated = true, lockfiles
+
 	sc.s.Stop()
-	if !isJSON(sc.cmd) {
-		fmt.Fprintf(sc.cmd.OutOrStdout(), "%s %s onto %s\n", operations.SyncMethodLabel(useMerge), wt.Branch, sc.cfg.DefaultSource)
-	}
+	printSyncedOne(sc, sr, describeBase(ref, sha), useMerge)
+	stashErr := syncOneReapply(sc, &sr, wt.Path)
 
-	swr := output.SyncWorktreeJSON{Branch: wt.Branch, Synced: true}
+	swr := syncWorktreeJSON(sr)
+	swr.Onto, swr.Base = sc.onto(wt.Branch), ref
 	if push {
 		pushResult, err := syncOneHandlePush(ctx, sc, wt, useMerge)
 		if err != nil {
-			return err
+			return errors.Join(err, stashErr)
 		}
 		swr.Pushed, swr.PushSkipped, swr.PushFailed, swr.PushError =
 			pushResult.Pushed, pushResult.PushSkipped, pushResult.PushFailed, pushResult.PushError
@@ -199,13 +381,80 @@ func syncOne(ctx context.Context, sc *syncContext, input string, worktrees []res
 		summary := output.SyncSummary{
 			Synced: 1, Pushed: boolToInt(swr.Pushed),
 			PushSkipped: boolToInt(swr.PushSkipped), PushFailed: boolToInt(swr.PushFailed),
+			Stashed: boolToInt(swr.Stashed),
 		}
-		return writeSyncOneJSON(sc.cmd, sc.cfg.DefaultSource, useMerge, false, swr, summary)
+		return writeSyncOneJSON(sc, useMerge, false, swr, summary)
 	}
 
+	return stashErr
+}
+
+// printSyncedOne reports in text mode that syncOne synced sr.Branch onto
+// base, and any lockfiles it regenerated on the way.
+func printSyncedOne(sc *syncContext, sr operations.SyncWorktreeResult, base string, useMerge bool) {
+	if isJSON(sc.cmd) {
+		return
+	}
+	fmt.Fprintf(sc.cmd.OutOrStdout(), "%s %s onto %s\n", operations.SyncMethodLabel(useMerge), sr.Branch, base)
+	if len(sr.LockfilesRegenerated) > 0 {
+		fmt.Fprintf(sc.cmd.OutOrStdout(), "Regenerated conflicting lockfiles: %s\n", strings.Join(sr.LockfilesRegenerated, ", "))
+	}
+}
+
+// syncOneDryRun previews syncOne, including the autostash of a dirty worktree.
+func syncOneDryRun(sc *syncContext, wt resolver.WorktreeInfo, base string, dirty, useMerge, push bool) error {
+	if isJSON(sc.cmd) {
+		ref, sha := sc.target(sc.cmd.Context(), wt.Branch)
+		swr := output.SyncWorktreeJSON{Branch: wt.Branch, Onto: sc.onto(wt.Branch), Base: ref, BaseSHA: sha, Planned: true, Stashed: dirty}
+		return writeSyncOneJSON(sc, useMerge, true, swr, output.SyncSummary{})
+	}
+	printSyncDryRun(sc.cmd, wt.Branch, base, useMerge, push)
+	if dirty {
+		fmt.Fprintf(sc.cmd.OutOrStdout(), "[dry-run] would stash uncommitted changes in %s and reapply them\n", wt.Path)
+	}
 	return nil
 }
 
+// syncOneDirty reports whether wt has uncommitted changes to stash around
+// the sync. Without autostash they are an error.
+func syncOneDirty(ctx context.Context, sc *syncContext, wt resolver.WorktreeInfo, task string) (bool, error) {
+	dirty, err := git.IsDirty(ctx, sc.r, wt.Path)
+	if err != nil || !dirty {
+		return false, err
+	}
+	if !sc.autostash {
+		return false, errhint.WithFix(
+			fmt.Errorf("worktree %q has uncommitted changes", task),
+			"Commit or stash changes before syncing (cd "+wt.Path+"), or rerun with --autostash",
+		)
+	}
+	return true, nil
+}
+
+// syncOneReapply reapplies the changes syncOne stashed, if any. In text mode
+// a stash that had to be kept is returned as an error saying how to recover
+// it; JSON mode reports it through sr instead.
+func syncOneReapply(sc *syncContext, sr *operations.SyncWorktreeResult, dir string) error {
+	if !sr.Stashed {
+		return nil
+	}
+	operations.ReapplySyncStash(sc.r, sr, dir)
+	if sr.StashRef == "" {
+		if !isJSON(sc.cmd) {
+			fmt.Fprintln(sc.cmd.OutOrStdout(), "Reapplied stashed changes")
+		}
+		return nil
+	}
+	if isJSON(sc.cmd) && sr.Synced {
+		return nil
+	}
+	msg := "stashed changes could not be reapplied"
+	if sr.StashConflict {
+		msg = "stashed changes conflicted when reapplied"
+	}
+	return errhint.WithFix(fmt.Errorf("%s; they are kept in stash %s", msg, sr.StashRef), sr.StashHint)
+}
+
 // syncOneHandlePush runs the push step and folds its outcome into partial
 // SyncWorktreeJSON fields. Returns a non-nil error only in text mode, where a
 // push failure still aborts the command; JSON mode reports it via the result instead.
@@ -250,66 +499,131 @@ func syncOnePush(ctx context.Context, sc *syncContext, wt resolver.WorktreeInfo,
 
 // writeSyncOneJSON writes the single-worktree sync envelope for syncOne's
 // dry-run and normal-path JSON branches.
-func writeSyncOneJSON(cmd *cobra.Command, mainBranch string, useMerge, dryRun bool, swr output.SyncWorktreeJSON, summary output.SyncSummary) error {
-	return output.WriteJSON(cmd.OutOrStdout(), version, "sync", output.SyncData{
-		MainBranch: mainBranch,
+func writeSyncOneJSON(sc *syncContext, useMerge, dryRun bool, swr output.SyncWorktreeJSON, summary output.SyncSummary) error {
+	return output.WriteJSON(sc.cmd.OutOrStdout(), version, "sync", output.SyncData{
+		MainBranch: sc.cfg.DefaultSource,
 		Method:     syncMethodLabelLower(useMerge),
 		All:        false,
 		DryRun:     dryRun,
+		Fetch:      sc.fetchJSON(),
 		Summary:    summary,
 		Worktrees:  []output.SyncWorktreeJSON{swr},
 	})
 }
 
-func syncAll(ctx context.Context, sc *syncContext, worktrees []resolver.WorktreeInfo, prefixes []string, useMerge, includeInherited, push bool) error { //nolint:unparam // error return matches RunE contract
+func syncAll(ctx context.Context, sc *syncContext, worktrees []resolver.WorktreeInfo, prefixes []string, useMerge, includeInherited, push bool) error {
 	allTasks := operations.CollectTasks(worktrees, prefixes)
 	eligible := operations.FilterEligible(worktrees, prefixes, sc.cfg.DefaultSource, allTasks, includeInherited)
+	eligible, stacked := excludeStacked(eligible, operations.StackParents(ctx, sc.r))
 
+	if sc.predictOnly {
+		preds, err := sc.predictSync(ctx, eligible)
+		if err != nil {
+			return err
+		}
+		return reportPredictOnly(sc, preds, useMerge, true)
+	}
 	sc.res = &syncResult{}
+	if sc.predict {
+		var err error
+		if eligible, err = sc.skipPredicted(ctx, eligible, useMerge); err != nil {
+			return err
+		}
+	}
+
+	if sc.stopOnConflict && !sc.dryRun {
+		opts := operations.SyncOptions{UseMerge: useMerge, Push: push, Autostash: sc.autostash}
+		run, err := operations.StartSyncRun(ctx, sc.r, eligible, func(branch string) string {
+			ref, _ := sc.target(ctx, branch)
+			return ref
+		}, opts)
+		if err != nil {
+			return err
+		}
+		sc.run = run
+	}
+
+	syncEach(ctx, sc, eligible, useMerge, push)
+
+	sc.s.Stop()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := reportSyncAll(sc, useMerge); err != nil {
+		return err
+	}
+	if stacked > 0 && !isJSON(sc.cmd) {
+		fmt.Fprintf(sc.cmd.OutOrStdout(), "Skipped %d stacked worktree(s); restack them with: rimba sync --stack\n", stacked)
+	}
+	return finishSyncRun(sc)
+}
+
+// syncEach syncs worktrees, up to four at a time. Once a worktree in a
+// --stop-on-conflict run stops on a conflict, no further worktree starts;
+// the rest stay pending in the run.
+func syncEach(ctx context.Context, sc *syncContext, worktrees []resolver.WorktreeInfo, useMerge, push bool) {
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 4) // bounded: git worktrees share object store
 
 	var completed int
-	for _, wt := range eligible {
+	for _, wt := range worktrees {
 		wg.Add(1)
 		go func(wt resolver.WorktreeInfo) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			syncWorktree(ctx, sc, sc.cfg.DefaultSource, wt, useMerge, push)
+			sc.mu.Lock()
+			halted := sc.halted
+			sc.mu.Unlock()
+			if halted {
+				return
+			}
+
+			ref, sha := sc.target(ctx, wt.Branch)
+			syncWorktree(ctx, sc, ref, sha, wt, useMerge, push)
 
 			sc.mu.Lock()
 			completed++
-			sc.s.Update(fmt.Sprintf("[%d/%d] Syncing worktrees...", completed, len(eligible)))
+			sc.s.Update(fmt.Sprintf("[%d/%d] Synced %s onto %s", completed, len(worktrees), wt.Branch, describeBase(ref, sha)))
 			sc.mu.Unlock()
 		}(wt)
 	}
 	wg.Wait()
+}
 
-	sc.s.Stop()
-	if ctx.Err() != nil {
-		return ctx.Err()
+// reportSyncAll writes the outcome of a bulk sync: the JSON envelope, or the
+// text summary.
+func reportSyncAll(sc *syncContext, useMerge bool) error {
+	var pending []resolver.WorktreeInfo
+	if sc.run != nil {
+		pending = sc.run.Pending()
+		sc.res.pending = len(pending)
 	}
 
 	if isJSON(sc.cmd) {
-		worktrees := make([]output.SyncWorktreeJSON, 0, len(sc.jsonResults))
+		worktrees := make([]output.SyncWorktreeJSON, 0, len(sc.jsonResults)+len(pending))
 		for _, sr := range sc.jsonResults {
-			worktrees = append(worktrees, output.SyncWorktreeJSON{
-				Branch: sr.Branch, Synced: sr.Synced, Skipped: sr.Skipped, SkipReason: sr.SkipReason,
-				Failed: sr.Failed, FailureHint: sr.FailureHint, Pushed: sr.Pushed,
-				PushSkipped: sr.PushSkipped, PushFailed: sr.PushFailed, PushError: sr.PushError,
-				Planned: sc.dryRun,
-			})
+			swr := syncWorktreeJSON(sr)
+			swr.Onto, swr.Planned = sc.onto(sr.Branch), sc.dryRun
+			swr.Base, _ = sc.target(sc.cmd.Context(), sr.Branch)
+			worktrees = append(worktrees, swr)
+		}
+		for _, wt := range pending {
+			worktrees = append(worktrees, output.SyncWorktreeJSON{Branch: wt.Branch, Onto: sc.onto(wt.Branch), Pending: true})
 		}
 		return output.WriteJSON(sc.cmd.OutOrStdout(), version, "sync", output.SyncData{
 			MainBranch: sc.cfg.DefaultSource,
 			Method:     syncMethodLabelLower(useMerge),
 			All:        true,
 			DryRun:     sc.dryRun,
+			Stopped:    sc.run != nil && sc.run.Stopped(),
+			Fetch:      sc.fetchJSON(),
 			Summary: output.SyncSummary{
 				Synced: sc.res.synced, SkippedDirty: sc.res.skippedDirty, Failed: sc.res.failed,
 				Pushed: sc.res.pushed, PushSkipped: sc.res.pushSkipped, PushFailed: sc.res.pushFailed,
+				Pending: sc.res.pending, Stashed: sc.res.stashed, PredictedConflicts: sc.res.predicted,
 			},
 			Worktrees: worktrees,
 		})
@@ -321,25 +635,86 @@ func syncAll(ctx context.Context, sc *syncContext, worktrees []resolver.Worktree
 	return nil
 }
 
-func syncWorktree(ctx context.Context, sc *syncContext, mainBranch string, wt resolver.WorktreeInfo, useMerge, push bool) {
+// base returns the branch branch syncs onto: its recorded base, else the
+// one config derives for its prefix type.
+func (sc *syncContext) base(branch string) string {
+	if sc.baseOf != nil {
+		return sc.baseOf(branch)
+	}
+	return sc.cfg.BaseBranch(branch)
+}
+
+// target returns the ref branch syncs onto and the commit it points at: its
+// base, mapped onto the remote's tracking branch under --fetch. The SHA is
+// empty when bases is nil or the ref does not resolve.
+func (sc *syncContext) target(ctx context.Context, branch string) (ref, sha string) {
+	base := sc.base(branch)
+	if sc.bases == nil {
+		return base, ""
+	}
+	return sc.bases.Resolve(ctx, base)
+}
+
+// fetchJSON returns the JSON form of the --fetch made, or nil without one.
+func (sc *syncContext) fetchJSON() *output.SyncFetchJSON {
+	if sc.fetch == nil {
+		return nil
+	}
+	f := sc.fetch
+	return &output.SyncFetchJSON{
+		Remote: f.Remote, DefaultBranch: f.Branch, Head: f.Head, SHA: f.SHA,
+		HeadChangedFrom: f.PreviousHead, FastForwarded: f.FastForwarded, FastForwardSkipped: f.FastForwardSkip,
+	}
+}
+
+// onto returns branch's base for a result's Onto field, or "" when it is the
+// repo's default branch.
+func (sc *syncContext) onto(branch string) string {
+	if base := sc.base(branch); base != sc.cfg.DefaultSource {
+		return base
+	}
+	return ""
+}
+
+func syncWorktree(ctx context.Context, sc *syncContext, mainBranch, baseSHA string, wt resolver.WorktreeInfo, useMerge, push bool) {
 	if sc.dryRun {
 		sc.mu.Lock()
 		defer sc.mu.Unlock()
-		sc.jsonResults = append(sc.jsonResults, operations.SyncWorktreeResult{Branch: wt.Branch})
+		sc.jsonResults = append(sc.jsonResults, operations.SyncWorktreeResult{Branch: wt.Branch, BaseSHA: baseSHA})
 		if !isJSON(sc.cmd) {
 			printSyncDryRun(sc.cmd, wt.Branch, mainBranch, useMerge, push)
 		}
 		return
 	}
 
-	sr := operations.SyncWorktree(ctx, sc.r, mainBranch, wt, useMerge, push)
+	sr := operations.SyncWorktreeWith(ctx, sc.r, mainBranch, wt, operations.SyncOptions{
+		UseMerge: useMerge, Push: push, Autostash: sc.autostash, KeepConflict: sc.run != nil,
+		Lockfiles: sc.lockfiles,
+	})
+	sr.BaseSHA = baseSHA
 
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
+	sc.tally(sr, wt.Path, useMerge)
+}
 
+// tally folds one worktree's outcome into the bulk sync's results, and into
+// the --stop-on-conflict run when there is one. Callers hold sc.mu.
+func (sc *syncContext) tally(sr operations.SyncWorktreeResult, path string, useMerge bool) {
 	sc.jsonResults = append(sc.jsonResults, sr)
+	sc.recordRun(sr)
+	if sr.Stashed {
+		sc.res.stashed++
+	}
 
 	switch {
+	case sr.PredictedConflict:
+		sc.res.predicted++
+		if !isJSON(sc.cmd) {
+			fmt.Fprintf(sc.cmd.OutOrStdout(), "Skipping %s (predicted conflict)\n", sr.Branch)
+		}
+		sc.res.failures = append(sc.res.failures, fmt.Sprintf("  %s: predicted to conflict in %s\n    To resolve: %s",
+			sr.Branch, strings.Join(sr.ConflictFiles, ", "), sr.FailureHint))
 	case sr.Skipped:
 		sc.res.skippedDirty++
 		printSyncSkipWarning(sc.cmd, sr)
@@ -356,13 +731,34 @@ func syncWorktree(ctx context.Context, sc *syncContext, mainBranch string, wt re
 		}
 		if sr.PushFailed {
 			sc.res.pushFailed++
-			pushHint := fmt.Sprintf("cd %s && git push --force-with-lease", wt.Path)
+			pushHint := fmt.Sprintf("cd %s && git push --force-with-lease", path)
 			if useMerge {
-				pushHint = fmt.Sprintf("cd %s && git push", wt.Path)
+				pushHint = fmt.Sprintf("cd %s && git push", path)
 			}
 			sc.res.failures = append(sc.res.failures, fmt.Sprintf("  %s: push failed: %s\n    To resolve: %s", sr.Branch, sr.PushError, pushHint))
 		}
 	}
+	if len(sr.LockfilesRegenerated) > 0 {
+		sc.res.failures = append(sc.res.failures, fmt.Sprintf("  %s: regenerated conflicting lockfiles: %s",
+			sr.Branch, strings.Join(sr.LockfilesRegenerated, ", ")))
+	}
+	if note := stashNote(sr); note != "" {
+		sc.res.failures = append(sc.res.failures, note)
+	}
+}
+
+// recordRun saves sr in the --stop-on-conflict run, if any, halting it on a
+// conflict. Callers hold sc.mu.
+func (sc *syncContext) recordRun(sr operations.SyncWorktreeResult) {
+	if sc.run == nil {
+		return
+	}
+	if err := sc.run.Record(sc.cmd.Context(), sc.r, sr); err != nil && !isJSON(sc.cmd) {
+		fmt.Fprintf(sc.cmd.ErrOrStderr(), "Warning: could not save sync run: %v\n", err)
+	}
+	if sr.Conflicted {
+		sc.halted = true
+	}
 }
 
 // printSyncSkipWarning prints the text-mode skip notice for a single
@@ -378,6 +774,46 @@ func printSyncSkipWarning(cmd *cobra.Command, sr operations.SyncWorktreeResult)
 	}
 }
 
+// syncWorktreeJSON converts a worktree's sync outcome to its JSON form;
+// callers fill in Onto, Base and Planned.
+func syncWorktreeJSON(sr operations.SyncWorktreeResult) output.SyncWorktreeJSON {
+	return output.SyncWorktreeJSON{
+		Branch: sr.Branch, Synced: sr.Synced, Skipped: sr.Skipped, SkipReason: sr.SkipReason,
+		Failed: sr.Failed, FailureHint: sr.FailureHint, Conflicted: sr.Conflicted,
+		PredictedConflict: sr.PredictedConflict, ConflictFiles: sr.ConflictFiles,
+		LockfilesRegenerated: sr.LockfilesRegenerated,
+		Pushed:               sr.Pushed, PushSkipped: sr.PushSkipped, PushFailed: sr.PushFailed, PushError: sr.PushError,
+		BaseSHA: sr.BaseSHA,
+		Stashed: sr.Stashed, StashRef: sr.StashRef, StashConflict: sr.StashConflict,
+		StashError: sr.StashError, StashHint: sr.StashHint,
+	}
+}
+
+// stashNote describes a worktree's kept autostash for the text summary, or
+// returns "" when there is none.
+func stashNote(sr operations.SyncWorktreeResult) string {
+	switch {
+	case sr.StashRef == "":
+		return ""
+	case sr.Conflicted:
+		return fmt.Sprintf("  %s: uncommitted changes are held in stash %s until 'rimba sync --continue' or '--abort'", sr.Branch, sr.StashRef)
+	case sr.StashConflict:
+		return fmt.Sprintf("  %s: stashed changes conflicted when reapplied (kept in stash %s)\n    To resolve: %s", sr.Branch, sr.StashRef, sr.StashHint)
+	}
+	reason, _, _ := strings.Cut(sr.StashError, "\n")
+	return fmt.Sprintf("  %s: stashed changes could not be reapplied (kept in stash %s): %s\n    To resolve: %s",
+		sr.Branch, sr.StashRef, reason, sr.StashHint)
+}
+
+// describeBase names a sync target for text output: the ref, followed by
+// its short SHA when known.
+func describeBase(ref, sha string) string {
+	if sha == "" {
+		return ref
+	}
+	return fmt.Sprintf("%s (%s)", ref, shortSHA(sha))
+}
+
 func printSyncDryRun(cmd *cobra.Command, branch, mainBranch string, useMerge, push bool) {
 	verb := "rebase"
 	if useMerge {
@@ -397,12 +833,21 @@ func printSyncSummary(cmd *cobra.Command, mainBranch string, useMerge bool, res
 	if res.skippedDirty > 0 {
 		fmt.Fprintf(cmd.OutOrStdout(), ", %d skipped (dirty)", res.skippedDirty)
 	}
+	if res.predicted > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), ", %d skipped (predicted conflict)", res.predicted)
+	}
 	if res.failed > 0 {
 		fmt.Fprintf(cmd.OutOrStdout(), ", %d failed (conflict)", res.failed)
 	}
 	if res.pushFailed > 0 {
 		fmt.Fprintf(cmd.OutOrStdout(), ", %d push failed", res.pushFailed)
 	}
+	if res.pending > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), ", %d pending", res.pending)
+	}
+	if res.stashed > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), ", %d autostashed", res.stashed)
+	}
 	fmt.Fprintln(cmd.OutOrStdout())
 
 	for _, f := range res.failures {
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// validatePredictFlags rejects --predict with --stack: a restack replays
+// each child onto its parent's new tip, which does not exist until the
+// parent is synced, so there is nothing to predict against.
+func validatePredictFlags(stack, predict bool) error {
+	if stack && predict {
+		return errhint.WithFix(
+			errors.New("--predict cannot be combined with --stack"),
+			"predict the stack roots first: rimba sync --all --predict-only",
+		)
+	}
+	return nil
+}
+
+// predictSync predicts which worktrees would conflict with the ref each
+// syncs onto. Worktrees that cannot be predicted are warned about in text
+// mode and synced as usual.
+func (sc *syncContext) predictSync(ctx context.Context, worktrees []resolver.WorktreeInfo) ([]operations.SyncPrediction, error) {
+	sc.s.Update(fmt.Sprintf("Predicting conflicts for %d worktree(s)...", len(worktrees)))
+	preds := operations.PredictSyncConflicts(ctx, sc.r, worktrees, func(branch string) string {
+		ref, _ := sc.target(ctx, branch)
+		return ref
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !isJSON(sc.cmd) && !sc.predictOnly {
+		for _, p := range preds {
+			if p.Err != nil {
+				fmt.Fprintf(sc.cmd.ErrOrStderr(), "Warning: could not predict %s: %v; syncing it anyway\n", p.Worktree.Branch, p.Err)
+			}
+		}
+	}
+	return preds, nil
+}
+
+// skipPredicted drops the worktrees predicted to conflict from a bulk sync,
+// tallying each as skipped, and returns the rest.
+func (sc *syncContext) skipPredicted(ctx context.Context, worktrees []resolver.WorktreeInfo, useMerge bool) ([]resolver.WorktreeInfo, error) {
+	preds, err := sc.predictSync(ctx, worktrees)
+	if err != nil {
+		return nil, err
+	}
+	clean, predicted := operations.SplitPredicted(preds, useMerge)
+	for _, sr := range predicted {
+		sc.tally(sr, "", useMerge)
+	}
+	return clean, nil
+}
+
+// syncOnePredict predicts whether wt would conflict when sc.predict is set.
+// It reports whether syncOne is done: under --predict-only, or when a
+// conflict is predicted, which is an error in text mode.
+func syncOnePredict(ctx context.Context, sc *syncContext, wt resolver.WorktreeInfo, useMerge bool) (bool, error) {
+	if !sc.predict {
+		return false, nil
+	}
+	preds, err := sc.predictSync(ctx, []resolver.WorktreeInfo{wt})
+	if err != nil {
+		return true, err
+	}
+	if sc.predictOnly {
+		return true, reportPredictOnly(sc, preds, useMerge, false)
+	}
+	p := preds[0]
+	if !p.Conflicts {
+		return false, nil
+	}
+
+	sc.s.Stop()
+	sr := p.Result(useMerge)
+	if isJSON(sc.cmd) {
+		swr := syncWorktreeJSON(sr)
+		swr.Onto = sc.onto(wt.Branch)
+		swr.Base, swr.BaseSHA = sc.target(ctx, wt.Branch)
+		return true, writeSyncOneJSON(sc, useMerge, sc.dryRun, swr, output.SyncSummary{PredictedConflicts: 1})
+	}
+	return true, errhint.WithFix(
+		fmt.Errorf("%s is predicted to conflict with %s in %s", wt.Branch, p.Base, strings.Join(p.Files, ", ")),
+		"resolve the conflicts by hand: "+sr.FailureHint,
+	)
+}
+
+// reportPredictOnly writes the predictions of --predict-only, which syncs
+// nothing: the JSON envelope, or one line per worktree and a summary.
+func reportPredictOnly(sc *syncContext, preds []operations.SyncPrediction, useMerge, all bool) error {
+	sc.s.Stop()
+	var conflicting, unknown int
+	worktrees := make([]output.SyncWorktreeJSON, 0, len(preds))
+	for _, p := range preds {
+		swr := output.SyncWorktreeJSON{
+			Branch: p.Worktree.Branch, Onto: sc.onto(p.Worktree.Branch), Base: p.Base,
+			Planned: !p.Conflicts && p.Err == nil, PredictedConflict: p.Conflicts, ConflictFiles: p.Files,
+		}
+		_, swr.BaseSHA = sc.target(sc.cmd.Context(), p.Worktree.Branch)
+		switch {
+		case p.Err != nil:
+			unknown++
+			swr.PredictError = p.Err.Error()
+		case p.Conflicts:
+			conflicting++
+		}
+		worktrees = append(worktrees, swr)
+	}
+
+	if isJSON(sc.cmd) {
+		return output.WriteJSON(sc.cmd.OutOrStdout(), version, "sync", output.SyncData{
+			MainBranch:  sc.cfg.DefaultSource,
+			Method:      syncMethodLabelLower(useMerge),
+			All:         all,
+			DryRun:      sc.dryRun,
+			PredictOnly: true,
+			Fetch:       sc.fetchJSON(),
+			Summary:     output.SyncSummary{PredictedConflicts: conflicting},
+			Worktrees:   worktrees,
+		})
+	}
+
+	out := sc.cmd.OutOrStdout()
+	for _, swr := range worktrees {
+		onto := describeBase(swr.Base, swr.BaseSHA)
+		switch {
+		case swr.PredictError != "":
+			fmt.Fprintf(out, "%s: could not predict onto %s: %s\n", swr.Branch, onto, swr.PredictError)
+		case swr.PredictedConflict:
+			fmt.Fprintf(out, "%s: would conflict with %s in:\n", swr.Branch, onto)
+			for _, f := range swr.ConflictFiles {
+				fmt.Fprintf(out, "    %s\n", f)
+			}
+		default:
+			fmt.Fprintf(out, "%s: syncs cleanly onto %s\n", swr.Branch, onto)
+		}
+	}
+	fmt.Fprintf(out, "Predicted %d worktree(s): %d clean, %d would conflict", len(preds), len(preds)-conflicting-unknown, conflicting)
+	if unknown > 0 {
+		fmt.Fprintf(out, ", %d could not be predicted", unknown)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
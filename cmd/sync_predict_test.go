@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// predictTestRunner predicts a conflict in auth.go for branchFeature and a
+// clean sync for every other branch, collecting the dirs rebased.
+func predictTestRunner(rebased *[]string) *mockRunner {
+	var mu sync.Mutex
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == "merge-tree" && args[len(args)-1] == branchFeature {
+				return "tree123\n\nCONFLICT (content): Merge conflict in auth.go\n", errors.New("exit status 1")
+			}
+			return "", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			if args[0] == cmdRebase {
+				mu.Lock()
+				*rebased = append(*rebased, dir)
+				mu.Unlock()
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestValidatePredictFlags(t *testing.T) {
+	if err := validatePredictFlags(true, true); err == nil || !strings.Contains(err.Error(), "--stack") {
+		t.Errorf("err = %v, want --stack rejected", err)
+	}
+	if err := validatePredictFlags(false, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSyncAllPredictSkipsConflicts(t *testing.T) {
+	var rebased []string
+	cmd, buf := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: predictTestRunner(&rebased), cfg: testSyncConfig(), s: testSyncSpinner(cmd), predict: true}
+
+	if err := syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, false); err != nil {
+		t.Fatalf(fatalSyncAll, err)
+	}
+	if len(rebased) != 1 || rebased[0] == pathWtFeatureLogin {
+		t.Errorf("rebased = %v, want only the worktree predicted clean", rebased)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Skipping feature/login (predicted conflict)",
+		"1 skipped (predicted conflict)",
+		"feature/login: predicted to conflict in auth.go",
+		"To resolve: cd " + pathWtFeatureLogin + " && git rebase main",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSyncAllPredictOnly(t *testing.T) {
+	var rebased []string
+	cmd, buf := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: predictTestRunner(&rebased), cfg: testSyncConfig(), s: testSyncSpinner(cmd), predict: true, predictOnly: true}
+
+	if err := syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, true); err != nil {
+		t.Fatalf(fatalSyncAll, err)
+	}
+	if len(rebased) != 0 {
+		t.Errorf("rebased = %v, want nothing synced", rebased)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"feature/login: would conflict with main in:\n    auth.go",
+		"bugfix/typo: syncs cleanly onto main",
+		"Predicted 2 worktree(s): 1 clean, 1 would conflict",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSyncAllPredictOnlyJSON(t *testing.T) {
+	var rebased []string
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	sc := &syncContext{cmd: cmd, r: predictTestRunner(&rebased), cfg: testSyncConfig(), s: testSyncSpinner(cmd), predict: true, predictOnly: true}
+
+	if err := syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, false); err != nil {
+		t.Fatalf(fatalSyncAll, err)
+	}
+	for _, want := range []string{`"predict_only": true`, `"predicted_conflict": true`, `"conflict_files": [`, `"predicted_conflicts": 1`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s: %s", want, buf.String())
+		}
+	}
+}
+
+func TestSyncOnePredictedConflict(t *testing.T) {
+	var rebased []string
+	cmd, _ := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: predictTestRunner(&rebased), cfg: testSyncConfig(), s: testSyncSpinner(cmd), predict: true}
+
+	err := syncOne(context.Background(), sc, "login", testSyncWorktrees(), testSyncPrefixes(), false, false)
+	if err == nil || !strings.Contains(err.Error(), "predicted to conflict with main in auth.go") {
+		t.Fatalf("err = %v, want predicted conflict", err)
+	}
+	if len(rebased) != 0 {
+		t.Errorf("rebased = %v, want nothing synced", rebased)
+	}
+
+	// A worktree predicted clean is synced as usual.
+	if err := syncOne(context.Background(), sc, "typo", testSyncWorktrees(), testSyncPrefixes(), false, false); err != nil {
+		t.Fatalf("syncOne(typo): %v", err)
+	}
+	if len(rebased) != 1 {
+		t.Errorf("rebased = %v, want typo synced", rebased)
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/lugassawan/rimba/internal/spinner"
+	"github.com/spf13/cobra"
+)
+
+// syncRunFlags holds the flags that start, resume, or roll back a
+// --stop-on-conflict run.
+type syncRunFlags struct {
+	stopOnConflict, cont, abort bool
+}
+
+func readSyncRunFlags(cmd *cobra.Command) syncRunFlags {
+	var f syncRunFlags
+	f.stopOnConflict, _ = cmd.Flags().GetBool(flagStopOnConflict)
+	f.cont, _ = cmd.Flags().GetBool(flagContinue)
+	f.abort, _ = cmd.Flags().GetBool(flagAbort)
+	return f
+}
+
+// validate checks the run flags against the rest of the invocation; others
+// reports whether a task or any other sync flag was given.
+func (f syncRunFlags) validate(all, others bool) error {
+	switch {
+	case f.cont && f.abort:
+		return errors.New("--continue and --abort cannot be combined")
+	case (f.cont || f.abort) && (others || f.stopOnConflict):
+		return errhint.WithFix(
+			errors.New("--continue and --abort take no task or other sync flags"),
+			"the run keeps its own worktrees and settings: rimba sync --continue",
+		)
+	case f.stopOnConflict && !all:
+		return errhint.WithFix(
+			errors.New("--stop-on-conflict requires --all"),
+			"rimba sync --all --stop-on-conflict",
+		)
+	}
+	return nil
+}
+
+// syncResume continues the stopped sync run — or, with abort, rolls it back.
+// Neither fetches: the run finishes against the refs it started with.
+func syncResume(cmd *cobra.Command, r git.Runner, cfg *config.Config, abort bool) error {
+	if abort {
+		return syncAbort(cmd, r)
+	}
+
+	run, err := operations.LoadSyncRun(cmd.Context(), r)
+	if err != nil {
+		return err
+	}
+	repoRoot, err := git.MainRepoRoot(cmd.Context(), r)
+	if err != nil {
+		return err
+	}
+	lockfiles, err := lockfileResolver(cmd, repoRoot, cfg)
+	if err != nil {
+		return err
+	}
+
+	s := spinner.New(spinnerOpts(cmd))
+	defer s.Stop()
+	s.Start("Resuming sync run...")
+
+	sc := &syncContext{
+		cmd: cmd, r: r, cfg: cfg, s: s, repoRoot: repoRoot, autostash: run.Autostash(),
+		baseOf: run.Base, bases: operations.NewSyncBases(r, ""), run: run, res: &syncResult{},
+		lockfiles: lockfiles,
+	}
+	resumed, err := run.ResumeConflicted(cmd.Context(), r)
+	if err != nil {
+		return err
+	}
+	for _, sr := range resumed {
+		sc.tally(sr, run.Path(sr.Branch), run.Merge())
+	}
+	if !sc.halted {
+		syncEach(cmd.Context(), sc, run.Pending(), run.Merge(), run.Push())
+	}
+
+	s.Stop()
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	if err := reportSyncAll(sc, run.Merge()); err != nil {
+		return err
+	}
+	return finishSyncRun(sc)
+}
+
+// finishSyncRun clears a --stop-on-conflict run that synced everything, or
+// fails with how to resume the one that stopped.
+func finishSyncRun(sc *syncContext) error {
+	if sc.run == nil {
+		return nil
+	}
+	done, err := sc.run.Finish()
+	if err != nil {
+		return err
+	}
+	if done {
+		return nil
+	}
+	if isJSON(sc.cmd) {
+		return &output.SilentError{ExitCode: 1}
+	}
+	return errhint.WithFix(
+		errors.New("sync stopped on a conflict"),
+		"resolve it and stage the files, then run 'rimba sync --continue' (or 'rimba sync --abort' to roll the run back)",
+	)
+}
+
+// syncAbort rolls back the stopped sync run and reports each worktree reset.
+func syncAbort(cmd *cobra.Command, r git.Runner) error {
+	rollbacks, err := operations.AbortSyncRun(cmd.Context(), r)
+	if err != nil && rollbacks == nil {
+		return err
+	}
+	failed := err != nil
+	for _, rb := range rollbacks {
+		failed = failed || rb.Err != nil
+	}
+
+	if isJSON(cmd) {
+		data := output.SyncAbortData{Aborted: !failed, Worktrees: make([]output.SyncRollbackJSON, 0, len(rollbacks))}
+		for _, rb := range rollbacks {
+			data.Worktrees = append(data.Worktrees, output.SyncRollbackJSON{
+				Branch: rb.Branch, Path: rb.Path, ResetTo: rb.SHA, Pushed: rb.Pushed,
+				Moved: rb.Moved, MovedHint: rb.MovedHint, Error: errStr(rb.Err),
+			})
+		}
+		_ = output.WriteJSON(cmd.OutOrStdout(), version, "sync", data)
+		if failed {
+			return &output.SilentError{ExitCode: 1}
+		}
+		return nil
+	}
+
+	out := cmd.OutOrStdout()
+	reset := 0
+	for _, rb := range rollbacks {
+		switch {
+		case rb.Err != nil:
+			fmt.Fprintf(out, "Failed to reset %s: %v\n", rb.Branch, rb.Err)
+		case rb.Moved:
+			fmt.Fprintf(out, "Left %s as it is: it moved on since the sync\n    To resolve: %s\n", rb.Branch, rb.MovedHint)
+		default:
+			reset++
+			fmt.Fprintf(out, "Reset %s to %s\n", rb.Branch, shortSHA(rb.SHA))
+		}
+		if rb.Stash != "" {
+			fmt.Fprintf(out, "  uncommitted changes are kept in stash %s\n    To resolve: %s\n", rb.Stash, rb.StashHint)
+		}
+		if rb.Err == nil && !rb.Moved && rb.Pushed {
+			fmt.Fprintf(out, "  %s was already pushed; the remote keeps the synced branch\n", rb.Branch)
+		}
+	}
+	if failed {
+		return errhint.WithFix(
+			errors.Join(errors.New("could not roll back every worktree"), err),
+			"fix the worktrees above, then rerun: rimba sync --abort",
+		)
+	}
+	fmt.Fprintf(out, "Aborted sync run: %d worktree(s) rolled back\n", reset)
+	return nil
+}
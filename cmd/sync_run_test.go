@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/lugassawan/rimba/internal/syncrun"
+)
+
+// syncRunTestRunner serves a --stop-on-conflict run over testSyncWorktrees
+// with state under commonDir. Each worktree's git dir is a subdirectory of
+// gitDirs; a rebase in conflictPath stops there with conflict.go unmerged
+// until *resolved is set. Resets are collected in resets.
+func syncRunTestRunner(commonDir, gitDirs, conflictPath string, resolved *bool, resets *[]string) *mockRunner {
+	var mu sync.Mutex
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case len(args) >= 2 && args[1] == cmdGitCommonDir:
+				return commonDir, nil
+			case args[0] == cmdRevParse:
+				return "pre123", nil
+			}
+			return "", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			gitDir := filepath.Join(gitDirs, filepath.Base(dir))
+			state := filepath.Join(gitDir, "rebase-merge")
+			switch {
+			case slices.Contains(args, "--absolute-git-dir"):
+				return gitDir, os.MkdirAll(gitDir, 0o755)
+			case args[0] == "diff":
+				if *resolved {
+					return "", nil
+				}
+				return "conflict.go", nil
+			case slices.Contains(args, "--continue") || slices.Contains(args, "--abort"):
+				return "", os.RemoveAll(state)
+			case args[0] == cmdRebase && dir == conflictPath:
+				_ = os.MkdirAll(state, 0o755)
+				return "", errors.New("could not apply abc")
+			case args[0] == "reset":
+				mu.Lock()
+				*resets = append(*resets, dir)
+				mu.Unlock()
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestSyncRunFlagsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   syncRunFlags
+		all     bool
+		others  bool
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "stop with all", flags: syncRunFlags{stopOnConflict: true}, all: true, others: true},
+		{name: "continue alone", flags: syncRunFlags{cont: true}},
+		{name: "abort alone", flags: syncRunFlags{abort: true}},
+		{name: "stop without all", flags: syncRunFlags{stopOnConflict: true}, others: true, wantErr: "requires --all"},
+		{name: "continue and abort", flags: syncRunFlags{cont: true, abort: true}, wantErr: "cannot be combined"},
+		{name: "continue with task", flags: syncRunFlags{cont: true}, others: true, wantErr: "take no task"},
+		{name: "abort with stop", flags: syncRunFlags{abort: true, stopOnConflict: true}, wantErr: "take no task"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flags.validate(tt.all, tt.others)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSyncAllStopOnConflictThenContinue(t *testing.T) {
+	commonDir := t.TempDir()
+	var resolved bool
+	var resets []string
+	r := syncRunTestRunner(commonDir, t.TempDir(), pathWtFeatureLogin, &resolved, &resets)
+
+	cmd, buf := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd), stopOnConflict: true}
+	err := syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, false)
+	if err == nil || !strings.Contains(err.Error(), "rimba sync --continue") {
+		t.Fatalf("syncAll err = %v, want stopped with --continue hint", err)
+	}
+	if !strings.Contains(buf.String(), "1 failed (conflict)") {
+		t.Errorf("output = %q, want conflict count", buf.String())
+	}
+	state, _ := syncrun.Load(commonDir)
+	if state == nil || len(state.With(syncrun.StatusConflicted)) != 1 {
+		t.Fatalf("saved run = %+v, want one conflicted worktree", state)
+	}
+
+	// Still unresolved: --continue stops again.
+	cmd, _ = newTestCmd()
+	if err := syncResume(cmd, r, testSyncConfig(), false); err == nil {
+		t.Fatal("continue with unresolved conflicts succeeded")
+	}
+
+	resolved = true
+	cmd, buf = newTestCmd()
+	if err := syncResume(cmd, r, testSyncConfig(), false); err != nil {
+		t.Fatalf("syncResume: %v", err)
+	}
+	// The other worktree was synced before the stop or is picked up now.
+	if !strings.Contains(buf.String(), "Rebased") || strings.Contains(buf.String(), "failed") {
+		t.Errorf("output = %q, want the resumed worktree rebased", buf.String())
+	}
+	if state, _ := syncrun.Load(commonDir); state != nil {
+		t.Errorf("run still saved after finishing: %+v", state)
+	}
+}
+
+func TestSyncStopOnConflictJSON(t *testing.T) {
+	var resolved bool
+	var resets []string
+	r := syncRunTestRunner(t.TempDir(), t.TempDir(), pathWtFeatureLogin, &resolved, &resets)
+
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	sc := &syncContext{cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd), stopOnConflict: true}
+	err := syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, false)
+	var silent *output.SilentError
+	if !errors.As(err, &silent) {
+		t.Fatalf("err = %v, want SilentError", err)
+	}
+	for _, want := range []string{`"stopped": true`, `"conflicted": true`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s: %s", want, buf.String())
+		}
+	}
+}
+
+func TestSyncAbortRollsBack(t *testing.T) {
+	commonDir := t.TempDir()
+	var resolved bool
+	var resets []string
+	r := syncRunTestRunner(commonDir, t.TempDir(), pathWtFeatureLogin, &resolved, &resets)
+
+	cmd, _ := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd), stopOnConflict: true}
+	_ = syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, false)
+
+	cmd, buf := newTestCmd()
+	if err := syncResume(cmd, r, testSyncConfig(), true); err != nil {
+		t.Fatalf("syncResume(abort): %v", err)
+	}
+	// The conflicted worktree is always reset; the other only if it was
+	// synced before the run stopped.
+	if !slices.Contains(resets, pathWtFeatureLogin) {
+		t.Errorf("resets = %v, want %s", resets, pathWtFeatureLogin)
+	}
+	if !strings.Contains(buf.String(), "Reset feature/login to pre123") || !strings.Contains(buf.String(), "Aborted sync run") {
+		t.Errorf("output = %q", buf.String())
+	}
+	if state, _ := syncrun.Load(commonDir); state != nil {
+		t.Errorf("run still saved after abort: %+v", state)
+	}
+}
+
+func TestSyncResumeWithoutRun(t *testing.T) {
+	var resolved bool
+	var resets []string
+	r := syncRunTestRunner(t.TempDir(), t.TempDir(), "", &resolved, &resets)
+
+	cmd, _ := newTestCmd()
+	err := syncResume(cmd, r, testSyncConfig(), false)
+	if err == nil || !strings.Contains(err.Error(), "no sync run in progress") {
+		t.Errorf("err = %v, want no run", err)
+	}
+}
+
+// lockfileRunFixture saves a run whose one pending worktree, at a real
+// directory, stops its rebase on a go.sum-only conflict, and returns a
+// runner serving it. rebased counts the rebases started.
+func lockfileRunFixture(t *testing.T, rebased *int) (string, *mockRunner) {
+	t.Helper()
+	commonDir := filepath.Join(t.TempDir(), ".git")
+	wtDir, gitDir := t.TempDir(), t.TempDir()
+	run := &syncrun.Run{
+		StartedAt: time.Now().UTC(),
+		Worktrees: []syncrun.Worktree{{Branch: branchFeature, Path: wtDir, Base: branchMain, PreSHA: "pre123", Status: syncrun.StatusPending}},
+	}
+	if err := run.Save(commonDir); err != nil {
+		t.Fatal(err)
+	}
+	state := filepath.Join(gitDir, "rebase-merge")
+	return commonDir, &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[1] == cmdGitCommonDir {
+				return commonDir, nil
+			}
+			return "", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			switch {
+			case slices.Contains(args, "--absolute-git-dir"):
+				return gitDir, nil
+			case slices.Contains(args, "--diff-filter=U"):
+				return "go.sum", nil
+			case slices.Contains(args, "--continue"):
+				return "", os.RemoveAll(state)
+			case args[0] == cmdRebase:
+				*rebased++
+				_ = os.MkdirAll(state, 0o755)
+				return "", errors.New("could not apply abc")
+			}
+			return "", nil
+		},
+	}
+}
+
+func lockfileSyncConfig() *config.Config {
+	cfg := testSyncConfig()
+	cfg.Deps = &config.DepsConfig{
+		ResolveLockfiles: true,
+		Modules:          []config.ModuleConfig{{Dir: "vendor", Lockfile: "go.sum", Install: "go mod download", Lock: "touch go.sum"}},
+	}
+	return cfg
+}
+
+func TestSyncContinueResolvesLockfileConflict(t *testing.T) {
+	t.Setenv("RIMBA_TRUST_YES", "1")
+	var rebased int
+	commonDir, r := lockfileRunFixture(t, &rebased)
+
+	cmd, buf := newTestCmd()
+	if err := syncResume(cmd, r, lockfileSyncConfig(), false); err != nil {
+		t.Fatalf("syncResume: %v\n%s", err, buf.String())
+	}
+	if rebased != 1 || !strings.Contains(buf.String(), "go.sum") {
+		t.Errorf("output = %q, want the pending worktree rebased with go.sum regenerated", buf.String())
+	}
+	if state, _ := syncrun.Load(commonDir); state != nil {
+		t.Errorf("run still saved after finishing: %+v", state)
+	}
+}
+
+func TestSyncContinueGatesLockfileResolution(t *testing.T) {
+	t.Setenv("RIMBA_TRUST_YES", "")
+	var rebased int
+	commonDir, r := lockfileRunFixture(t, &rebased)
+
+	cmd, _ := newTestCmd()
+	cmd.SetIn(strings.NewReader("")) // non-interactive: declines
+	err := syncResume(cmd, r, lockfileSyncConfig(), false)
+	if err == nil || !strings.Contains(err.Error(), "require approval") {
+		t.Fatalf("err = %v, want the trust gate to refuse", err)
+	}
+	if rebased != 0 {
+		t.Error("rebased before the install commands were approved")
+	}
+	if state, _ := syncrun.Load(commonDir); state == nil {
+		t.Error("run dropped by a refused --continue")
+	}
+}
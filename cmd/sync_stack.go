@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// validateSyncFlags rejects flag combinations sync cannot honour.
+func validateSyncFlags(all, stack, useMerge bool, nArgs int) error {
+	switch {
+	case stack && all:
+		return errhint.WithFix(
+			errors.New("--stack cannot be combined with --all"),
+			"run 'rimba sync --all' for unstacked worktrees, then 'rimba sync --stack'",
+		)
+	case stack && useMerge:
+		return errhint.WithFix(
+			errors.New("--stack cannot be combined with --merge"),
+			"drop --merge: restacking always rebases children with --onto their parent",
+		)
+	case !all && !stack && nArgs == 0:
+		return errors.New("provide a task name or use --all to sync all worktrees")
+	}
+	return nil
+}
+
+// excludeStacked drops worktrees stacked on another live branch, returning
+// the remainder and how many were dropped.
+func excludeStacked(worktrees []resolver.WorktreeInfo, parents map[string]string) ([]resolver.WorktreeInfo, int) {
+	if len(parents) == 0 {
+		return worktrees, 0
+	}
+	kept := make([]resolver.WorktreeInfo, 0, len(worktrees))
+	for _, wt := range worktrees {
+		if parents[wt.Branch] == "" {
+			kept = append(kept, wt)
+		}
+	}
+	return kept, len(worktrees) - len(kept)
+}
+
+// syncStack handles `rimba sync --stack [task]`: restack the task's
+// descendants (syncing the task first), or every stack when no task is given.
+func syncStack(ctx context.Context, sc *syncContext, args []string, worktrees []resolver.WorktreeInfo, prefixes []string, push bool) error {
+	root := ""
+	if len(args) == 1 {
+		service, task := operations.ResolveTaskInput(args[0], sc.repoRoot, sc.cfg.PrefixSet())
+		wt, found := resolver.FindBranchForTask(service, task, worktrees, prefixes)
+		if !found {
+			return fmt.Errorf(operations.ErrWorktreeNotFoundFmt, args[0])
+		}
+		root = wt.Branch
+	}
+
+	sc.s.Start("Restacking worktrees...")
+	results, err := operations.RestackWorktrees(ctx, sc.r, operations.RestackParams{
+		MainBranch: sc.cfg.DefaultSource,
+		Root:       root,
+		Worktrees:  worktrees,
+		Push:       push,
+		DryRun:     sc.dryRun,
+	}, func(msg string) { sc.s.Update(msg) })
+	sc.s.Stop()
+	if err != nil {
+		return err
+	}
+
+	res := tallyStackResults(results)
+	if isJSON(sc.cmd) {
+		items := make([]output.SyncWorktreeJSON, 0, len(results))
+		for _, sr := range results {
+			items = append(items, output.SyncWorktreeJSON{
+				Branch: sr.Branch, Synced: sr.Synced, Skipped: sr.Skipped, SkipReason: sr.SkipReason,
+				Failed: sr.Failed, FailureHint: sr.FailureHint, Onto: sr.Onto, Pushed: sr.Pushed,
+				PushSkipped: sr.PushSkipped, PushFailed: sr.PushFailed, PushError: sr.PushError,
+				Planned: sc.dryRun,
+			})
+		}
+		return output.WriteJSON(sc.cmd.OutOrStdout(), version, "sync", output.SyncData{
+			MainBranch: sc.cfg.DefaultSource,
+			Method:     syncMethodLabelLower(false),
+			All:        root == "",
+			Stack:      true,
+			DryRun:     sc.dryRun,
+			Summary: output.SyncSummary{
+				Synced: res.synced, SkippedDirty: res.skippedDirty, Failed: res.failed,
+				Pushed: res.pushed, PushSkipped: res.pushSkipped, PushFailed: res.pushFailed,
+			},
+			Worktrees: items,
+		})
+	}
+
+	printStackResults(sc, results, res, push)
+	return nil
+}
+
+// tallyStackResults folds restack results into the shared sync counters.
+func tallyStackResults(results []operations.SyncWorktreeResult) *syncResult {
+	res := &syncResult{}
+	for _, sr := range results {
+		switch {
+		case sr.Skipped:
+			res.skippedDirty++
+		case sr.Failed:
+			res.failed++
+			res.failures = append(res.failures, fmt.Sprintf("  %s: To resolve: %s", sr.Branch, sr.FailureHint))
+		case sr.Synced:
+			res.synced++
+			res.pushed += boolToInt(sr.Pushed)
+			res.pushSkipped += boolToInt(sr.PushSkipped)
+			if sr.PushFailed {
+				res.pushFailed++
+				res.failures = append(res.failures, fmt.Sprintf("  %s: push failed: %s", sr.Branch, sr.PushError))
+			}
+		}
+	}
+	return res
+}
+
+// printStackResults prints one line per restacked worktree, then a summary.
+func printStackResults(sc *syncContext, results []operations.SyncWorktreeResult, res *syncResult, push bool) {
+	out := sc.cmd.OutOrStdout()
+	if len(results) == 0 {
+		fmt.Fprintln(out, "No stacked worktrees to restack.")
+		return
+	}
+
+	for _, sr := range results {
+		switch {
+		case sc.dryRun && !sr.Skipped:
+			printSyncDryRun(sc.cmd, sr.Branch, sr.Onto, false, push)
+		case sr.Skipped:
+			printSyncSkipWarning(sc.cmd, sr)
+		case sr.Synced:
+			fmt.Fprintf(out, "Restacked %s onto %s\n", sr.Branch, sr.Onto)
+		}
+	}
+	if sc.dryRun {
+		return
+	}
+
+	fmt.Fprintf(out, "Restacked %d worktree(s)", res.synced)
+	if res.pushed > 0 {
+		fmt.Fprintf(out, ", %d pushed", res.pushed)
+	}
+	if res.skippedDirty > 0 {
+		fmt.Fprintf(out, ", %d skipped", res.skippedDirty)
+	}
+	if res.failed > 0 {
+		fmt.Fprintf(out, ", %d failed (conflict)", res.failed)
+	}
+	if res.pushFailed > 0 {
+		fmt.Fprintf(out, ", %d push failed", res.pushFailed)
+	}
+	fmt.Fprintln(out)
+
+	for _, f := range res.failures {
+		fmt.Fprintln(out, f)
+	}
+}
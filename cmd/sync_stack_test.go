@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/stack"
+)
+
+const (
+	branchLoginUI = "feature/login-ui"
+	pathWtLoginUI = "/wt/feature-login-ui"
+)
+
+// stackSyncRunner answers the common-dir and ref lookups a restack makes;
+// every ref resolves to a fresh tip so each linked child gets rebased.
+func stackSyncRunner(commonDir string, rebases *[]string) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == cmdRevParse && args[1] == cmdGitCommonDir {
+				return commonDir, nil
+			}
+			if len(args) >= 1 && args[0] == cmdRevParse {
+				return "new-tip", nil
+			}
+			return "", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			if len(args) >= 1 && args[0] == cmdRebase {
+				*rebases = append(*rebases, dir)
+			}
+			return "", nil
+		},
+	}
+}
+
+func stackSyncWorktrees() []resolver.WorktreeInfo {
+	return append(testSyncWorktrees(), resolver.WorktreeInfo{Branch: branchLoginUI, Path: pathWtLoginUI})
+}
+
+func seedLoginStack(t *testing.T) string {
+	t.Helper()
+	commonDir := t.TempDir()
+	s := &stack.Store{Links: map[string]stack.Link{branchLoginUI: {Parent: branchFeature, Base: "old-tip"}}}
+	if err := s.Save(commonDir); err != nil {
+		t.Fatalf("seed stack: %v", err)
+	}
+	return commonDir
+}
+
+func TestValidateSyncFlags(t *testing.T) {
+	tests := []struct {
+		name                 string
+		all, stack, useMerge bool
+		nArgs                int
+		wantErr              string
+	}{
+		{name: "task", nArgs: 1},
+		{name: "all", all: true},
+		{name: "stack without task", stack: true},
+		{name: "stack with task", stack: true, nArgs: 1},
+		{name: "nothing", wantErr: "provide a task name"},
+		{name: "stack and all", stack: true, all: true, wantErr: "--stack cannot be combined with --all"},
+		{name: "stack and merge", stack: true, useMerge: true, nArgs: 1, wantErr: "--stack cannot be combined with --merge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSyncFlags(tt.all, tt.stack, tt.useMerge, tt.nArgs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExcludeStacked(t *testing.T) {
+	kept, n := excludeStacked(stackSyncWorktrees(), map[string]string{branchLoginUI: branchFeature})
+	if n != 1 {
+		t.Errorf("excluded = %d, want 1", n)
+	}
+	for _, wt := range kept {
+		if wt.Branch == branchLoginUI {
+			t.Errorf("stacked worktree %s was kept", branchLoginUI)
+		}
+	}
+}
+
+func TestSyncStackSyncsRootThenRestacksChild(t *testing.T) {
+	var rebases []string
+	cmd, buf := newTestCmd()
+	r := stackSyncRunner(seedLoginStack(t), &rebases)
+	sc := &syncContext{cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd)}
+
+	if err := syncStack(context.Background(), sc, []string{"login"}, stackSyncWorktrees(), testSyncPrefixes(), false); err != nil {
+		t.Fatalf("syncStack: %v", err)
+	}
+
+	if len(rebases) != 2 || rebases[0] != pathWtFeatureLogin || rebases[1] != pathWtLoginUI {
+		t.Errorf("rebased dirs = %v, want parent then child", rebases)
+	}
+	out := buf.String()
+	for _, want := range []string{"Restacked feature/login onto main", "Restacked feature/login-ui onto feature/login", "Restacked 2 worktree(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestSyncStackNothingStacked(t *testing.T) {
+	var rebases []string
+	cmd, buf := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: stackSyncRunner(t.TempDir(), &rebases), cfg: testSyncConfig(), s: testSyncSpinner(cmd)}
+
+	if err := syncStack(context.Background(), sc, nil, testSyncWorktrees(), testSyncPrefixes(), false); err != nil {
+		t.Fatalf("syncStack: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No stacked worktrees to restack.") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestSyncStackDryRunJSON(t *testing.T) {
+	var rebases []string
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	sc := &syncContext{cmd: cmd, r: stackSyncRunner(seedLoginStack(t), &rebases), cfg: testSyncConfig(), s: testSyncSpinner(cmd), dryRun: true}
+
+	if err := syncStack(context.Background(), sc, nil, stackSyncWorktrees(), testSyncPrefixes(), true); err != nil {
+		t.Fatalf("syncStack: %v", err)
+	}
+	if len(rebases) != 0 {
+		t.Errorf("dry run rebased %v", rebases)
+	}
+	out := buf.String()
+	for _, want := range []string{`"stack": true`, `"onto": "feature/login"`, `"planned": true`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %s, want %s", out, want)
+		}
+	}
+}
+
+func TestSyncAllSkipsStackedWorktrees(t *testing.T) {
+	var rebases []string
+	cmd, buf := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: stackSyncRunner(seedLoginStack(t), &rebases), cfg: testSyncConfig(), s: testSyncSpinner(cmd)}
+
+	if err := syncAll(context.Background(), sc, stackSyncWorktrees(), testSyncPrefixes(), false, false, false); err != nil {
+		t.Fatalf("syncAll: %v", err)
+	}
+	for _, dir := range rebases {
+		if dir == pathWtLoginUI {
+			t.Error("--all must not rebase a stacked worktree onto main")
+		}
+	}
+	if !strings.Contains(buf.String(), "Skipped 1 stacked worktree(s)") {
+		t.Errorf("output = %q, want stacked skip note", buf.String())
+	}
+}
+
+func TestSyncOneRefusesStackedWorktree(t *testing.T) {
+	var rebases []string
+	cmd, _ := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: stackSyncRunner(seedLoginStack(t), &rebases), cfg: testSyncConfig(), s: testSyncSpinner(cmd)}
+
+	err := syncOne(context.Background(), sc, "login-ui", stackSyncWorktrees(), testSyncPrefixes(), false, false)
+	if err == nil || !strings.Contains(err.Error(), "rimba sync --stack login-ui") {
+		t.Fatalf("err = %v, want a restack hint", err)
+	}
+	if len(rebases) != 0 {
+		t.Errorf("rebased %v, want the stacked worktree left alone", rebases)
+	}
+}
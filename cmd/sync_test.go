@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/spinner"
 	"github.com/spf13/cobra"
@@ -103,6 +106,34 @@ func TestSyncOneSuccess(t *testing.T) {
 	})
 }
 
+func TestSyncOneOntoPrefixTypeBase(t *testing.T) {
+	cmd, buf := newTestCmd()
+	var rebaseArgs []string
+	r := &mockRunner{
+		run: func(_ ...string) (string, error) { return "", nil },
+		runInDir: func(_ string, args ...string) (string, error) {
+			if len(args) > 0 && args[0] == cmdRebase {
+				rebaseArgs = args
+			}
+			return "", nil
+		},
+	}
+	cfg := testSyncConfig()
+	cfg.Resolver = &config.ResolverConfig{Prefix: []config.PrefixEntry{{Prefix: "hotfix/", Source: "release/current"}}}
+	sc := &syncContext{cmd: cmd, r: r, cfg: cfg, s: testSyncSpinner(cmd)}
+	worktrees := []resolver.WorktreeInfo{{Branch: "hotfix/crash", Path: "/wt/hotfix-crash"}}
+
+	if err := syncOne(context.Background(), sc, "crash", worktrees, cfg.PrefixSet().Strip(), false, false); err != nil {
+		t.Fatalf("syncOne: %v", err)
+	}
+	if !slices.Contains(rebaseArgs, "release/current") {
+		t.Errorf("rebase args = %v, want release/current", rebaseArgs)
+	}
+	if !strings.Contains(buf.String(), "onto release/current") {
+		t.Errorf("output = %q, want 'onto release/current'", buf.String())
+	}
+}
+
 func TestSyncOneOrphanedHardErrors(t *testing.T) {
 	// Only "TASK-" is configured, so the "PROJ-*" branch is orphaned;
 	// sync has no --force flag, so this guard can never be bypassed here.
@@ -169,6 +200,97 @@ func TestSyncOneDirty(t *testing.T) {
 	if !strings.Contains(err.Error(), "uncommitted changes") {
 		t.Errorf("error = %q, want 'uncommitted changes'", err.Error())
 	}
+	if !strings.Contains(err.Error(), "--autostash") {
+		t.Errorf("error = %q, want --autostash hint", err.Error())
+	}
+}
+
+// autostashTestRunner serves a feature/login worktree that is dirty until
+// its changes are stashed as stash123; stash applies fail with applyErr.
+func autostashTestRunner(applyErr error) *mockRunner {
+	var mu sync.Mutex
+	stashed := false
+	return &mockRunner{
+		run: func(_ ...string) (string, error) { return "", nil },
+		runInDir: func(dir string, args ...string) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case args[0] == cmdStatus && dir == pathWtFeatureLogin && !stashed:
+				return dirtyOutput, nil
+			case args[0] == cmdStash && args[1] == "push":
+				stashed = true
+			case args[0] == cmdRevParse && args[1] == "stash@{0}":
+				return "stash123", nil
+			case args[0] == cmdStash && args[1] == cmdList:
+				return "stash123 stash@{0}", nil
+			case args[0] == cmdStash && args[1] == "apply":
+				return "", applyErr
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestSyncOneAutostash(t *testing.T) {
+	cmd, buf := newTestCmd()
+	sc := &syncContext{cmd: cmd, r: autostashTestRunner(nil), cfg: testSyncConfig(), s: testSyncSpinner(cmd), autostash: true}
+
+	if err := syncOne(context.Background(), sc, taskLogin, testSyncWorktrees(), testSyncPrefixes(), false, false); err != nil {
+		t.Fatalf("syncOne: %v", err)
+	}
+	for _, want := range []string{"Rebased feature/login onto main", "Reapplied stashed changes"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output = %q, want %q", buf.String(), want)
+		}
+	}
+}
+
+func TestSyncOneAutostashConflict(t *testing.T) {
+	cmd, _ := newTestCmd()
+	r := autostashTestRunner(errors.New("CONFLICT (content): Merge conflict in main.go"))
+	sc := &syncContext{cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd), autostash: true}
+
+	err := syncOne(context.Background(), sc, taskLogin, testSyncWorktrees(), testSyncPrefixes(), false, false)
+	if err == nil {
+		t.Fatal("expected error for a stash that conflicted")
+	}
+	for _, want := range []string{"kept in stash stash123", "git stash drop"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want %q", err.Error(), want)
+		}
+	}
+}
+
+func TestSyncAllAutostashJSON(t *testing.T) {
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	r := autostashTestRunner(errors.New("CONFLICT (content): Merge conflict in main.go"))
+	sc := &syncContext{cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd), autostash: true}
+
+	if err := syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, false); err != nil {
+		t.Fatalf("syncAll: %v", err)
+	}
+	for _, want := range []string{`"stashed": true`, `"stash_conflict": true`, `"stash_ref": "stash123"`, `"stashed": 1`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s: %s", want, buf.String())
+		}
+	}
+}
+
+func TestSyncAllAutostashSummary(t *testing.T) {
+	cmd, buf := newTestCmd()
+	r := autostashTestRunner(errors.New("CONFLICT (content): Merge conflict in main.go"))
+	sc := &syncContext{cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd), autostash: true}
+
+	if err := syncAll(context.Background(), sc, testSyncWorktrees(), testSyncPrefixes(), false, false, false); err != nil {
+		t.Fatalf("syncAll: %v", err)
+	}
+	for _, want := range []string{"1 autostashed", "feature/login: stashed changes conflicted when reapplied (kept in stash stash123)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output = %q, want %q", buf.String(), want)
+		}
+	}
 }
 
 func TestSyncOneErrorPaths(t *testing.T) {
@@ -456,7 +578,7 @@ func TestSyncWorktreeClean(t *testing.T) {
 
 	sc := &syncContext{cmd: cmd, r: r, res: &syncResult{}}
 	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
-	syncWorktree(context.Background(), sc, branchMain, wt, false, false)
+	syncWorktree(context.Background(), sc, branchMain, "", wt, false, false)
 
 	if sc.res.synced != 1 {
 		t.Errorf("synced = %d, want 1", sc.res.synced)
@@ -478,7 +600,7 @@ func TestSyncWorktreeDirtyAndError(t *testing.T) {
 
 		sc := &syncContext{cmd: cmd, r: r, res: &syncResult{}}
 		wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
-		syncWorktree(context.Background(), sc, branchMain, wt, false, false)
+		syncWorktree(context.Background(), sc, branchMain, "", wt, false, false)
 
 		if sc.res.skippedDirty != 1 {
 			t.Errorf("skippedDirty = %d, want 1", sc.res.skippedDirty)
@@ -499,7 +621,7 @@ func TestSyncWorktreeDirtyAndError(t *testing.T) {
 
 		sc := &syncContext{cmd: cmd, r: r, res: &syncResult{}}
 		wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
-		syncWorktree(context.Background(), sc, branchMain, wt, false, false)
+		syncWorktree(context.Background(), sc, branchMain, "", wt, false, false)
 
 		if sc.res.skippedDirty != 1 {
 			t.Errorf("skippedDirty = %d, want 1", sc.res.skippedDirty)
@@ -524,7 +646,7 @@ func TestSyncWorktreeDoSyncFailure(t *testing.T) {
 
 	sc := &syncContext{cmd: cmd, r: r, res: &syncResult{}}
 	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
-	syncWorktree(context.Background(), sc, branchMain, wt, false, false)
+	syncWorktree(context.Background(), sc, branchMain, "", wt, false, false)
 
 	if sc.res.failed != 1 {
 		t.Errorf("failed = %d, want 1", sc.res.failed)
@@ -548,7 +670,7 @@ func TestSyncWorktreeMergeFailure(t *testing.T) {
 
 	sc := &syncContext{cmd: cmd, r: r, res: &syncResult{}}
 	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
-	syncWorktree(context.Background(), sc, branchMain, wt, true, false)
+	syncWorktree(context.Background(), sc, branchMain, "", wt, true, false)
 
 	if sc.res.failed != 1 {
 		t.Errorf("failed = %d, want 1", sc.res.failed)
@@ -691,7 +813,7 @@ func TestSyncWorktreeSkipWarningOnStderr(t *testing.T) {
 
 	sc := &syncContext{cmd: cmd, r: r, res: &syncResult{}}
 	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
-	syncWorktree(context.Background(), sc, branchMain, wt, false, false)
+	syncWorktree(context.Background(), sc, branchMain, "", wt, false, false)
 
 	if strings.Contains(outBuf.String(), "Warning") {
 		t.Errorf("warning leaked to stdout: %q", outBuf.String())
@@ -878,7 +1000,7 @@ func TestSyncWorktreeJSONSilencesSkipWarning(t *testing.T) {
 
 	sc := &syncContext{cmd: cmd, r: r, res: &syncResult{}}
 	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
-	syncWorktree(context.Background(), sc, branchMain, wt, false, false)
+	syncWorktree(context.Background(), sc, branchMain, "", wt, false, false)
 
 	if sc.res.skippedDirty != 1 {
 		t.Errorf("skippedDirty = %d, want 1", sc.res.skippedDirty)
@@ -914,3 +1036,56 @@ func TestSyncAllCancelledSkipsSummary(t *testing.T) {
 		t.Errorf("partial summary should not be printed on cancellation: %q", buf.String())
 	}
 }
+
+func TestSyncOneFetchJSON(t *testing.T) {
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	var rebasedOnto string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == cmdRevParse && args[len(args)-1] == "refs/remotes/origin/main^{commit}" {
+				return "tip9999999\n", nil
+			}
+			return "", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			if args[0] == cmdRebase {
+				rebasedOnto = args[len(args)-1]
+			}
+			return "", nil
+		},
+	}
+	sc := &syncContext{
+		cmd: cmd, r: r, cfg: testSyncConfig(), s: testSyncSpinner(cmd),
+		bases: operations.NewSyncBases(r, "origin"),
+		fetch: &operations.SyncFetch{Remote: "origin", Branch: branchMain, SHA: "tip9999999", FastForwardSkip: "the main worktree has uncommitted changes"},
+	}
+
+	if err := syncOne(context.Background(), sc, "login", testSyncWorktrees(), testSyncPrefixes(), false, false); err != nil {
+		t.Fatalf("syncOne: %v", err)
+	}
+	if rebasedOnto != "origin/main" {
+		t.Errorf("rebased onto %q, want origin/main", rebasedOnto)
+	}
+	for _, want := range []string{`"base": "origin/main"`, `"base_sha": "tip9999999"`, `"remote": "origin"`, `"fast_forward_skipped"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s: %s", want, buf.String())
+		}
+	}
+}
+
+func TestPrintSyncFetch(t *testing.T) {
+	cmd, buf := newTestCmd()
+	printSyncFetch(cmd, operations.SyncFetch{
+		Remote: "origin", Branch: "trunk", Head: "trunk", PreviousHead: branchMain,
+		SHA: "abc1234567", FastForwarded: true,
+	})
+	for _, want := range []string{
+		"origin's default branch changed from main to trunk; syncing onto origin/trunk",
+		"Fast-forwarded trunk to origin/trunk (abc1234)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q: %s", want, buf.String())
+		}
+	}
+}
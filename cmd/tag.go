@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagTag = "tag"
+
+	tagActionAdd    = "add"
+	tagActionRemove = "remove"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <task> [add|remove <tag>...]",
+	Short: "Show, add, or remove a worktree's tags",
+	Long: `Tags group worktrees so list, exec, and clean can select them with --tag.
+With only a task, prints the worktree's tags. Tags may not contain commas or
+whitespace. Like the rest of a worktree's metadata, tags are keyed by branch
+and survive archive, restore, and rename.`,
+	Example: `  rimba tag auth                      # show tags
+  rimba tag auth add spike needs-review
+  rimba tag auth remove spike
+  rimba list --tag needs-review       # filter by tag`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return completeWorktreeTasks(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			return []string{tagActionAdd, tagActionRemove}, cobra.ShellCompDirectiveNoFileComp
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateTagArgs(args); err != nil {
+			return err
+		}
+
+		r := newRunner(cmd.Context())
+		wt, err := findWorktree(cmd.Context(), r, args[0])
+		if err != nil {
+			return err
+		}
+
+		var m meta.Meta
+		var missing []string
+		if len(args) == 1 {
+			s, err := operations.LoadMeta(cmd.Context(), r)
+			if err != nil {
+				return err
+			}
+			m = s.Get(wt.Branch)
+		} else {
+			tags := args[2:]
+			m, err = operations.UpdateMeta(cmd.Context(), r, wt.Branch, func(m *meta.Meta) {
+				if args[1] == tagActionAdd {
+					m.AddTags(tags...)
+					return
+				}
+				missing = m.RemoveTags(tags...)
+			})
+			if err != nil {
+				return errhint.WithFix(err, "check that the git common dir is writable, then retry")
+			}
+		}
+
+		if isJSON(cmd) {
+			return output.WriteJSON(cmd.OutOrStdout(), version, "tag", output.MetaData{Task: args[0], Branch: wt.Branch, Meta: m})
+		}
+		for _, t := range missing {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s was not tagged %q\n", wt.Branch, t)
+		}
+		if len(m.Tags) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No tags on %s\n", wt.Branch)
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Tags on %s: %s\n", wt.Branch, strings.Join(m.Tags, ", "))
+		return nil
+	},
+}
+
+// validateTagArgs checks the action and tag arguments after the task.
+func validateTagArgs(args []string) error {
+	if len(args) == 1 {
+		return nil
+	}
+	if args[1] != tagActionAdd && args[1] != tagActionRemove {
+		return errhint.WithFix(
+			fmt.Errorf("unknown tag action %q", args[1]),
+			"run: rimba tag <task> add <tag>...  OR  rimba tag <task> remove <tag>...",
+		)
+	}
+	if len(args) == 2 {
+		return errhint.WithFix(
+			errors.New("no tags given"),
+			fmt.Sprintf("run: rimba tag %s %s <tag>...", args[0], args[1]),
+		)
+	}
+	for _, t := range args[2:] {
+		if err := meta.ValidateTag(t); err != nil {
+			return errhint.WithFix(err, "use a single word such as needs-review")
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/meta"
+)
+
+func TestTagAddThenRemove(t *testing.T) {
+	commonDir := t.TempDir()
+	restore := overrideNewRunner(metaCmdRunner(commonDir))
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	if err := tagCmd.RunE(cmd, []string{taskLogin, tagActionAdd, "urgent", "spike"}); err != nil {
+		t.Fatalf("tag add: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Tags on feature/login: spike, urgent") {
+		t.Errorf("output = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := tagCmd.RunE(cmd, []string{taskLogin, tagActionRemove, "spike", "missing"}); err != nil {
+		t.Fatalf("tag remove: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `was not tagged "missing"`) || !strings.Contains(out, "Tags on feature/login: urgent") {
+		t.Errorf("output = %q", out)
+	}
+	if m := loadTestMeta(t, commonDir); !m.HasTag("urgent") || m.HasTag("spike") {
+		t.Errorf("stored tags = %v", m.Tags)
+	}
+}
+
+func TestTagShowNone(t *testing.T) {
+	restore := overrideNewRunner(metaCmdRunner(t.TempDir()))
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	if err := tagCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("tagCmd.RunE: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No tags on feature/login") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestTagJSON(t *testing.T) {
+	commonDir := t.TempDir()
+	seed := &meta.Store{Worktrees: map[string]meta.Meta{branchFeature: {Tags: []string{"spike"}}}}
+	if err := seed.Save(commonDir); err != nil {
+		t.Fatal(err)
+	}
+	restore := overrideNewRunner(metaCmdRunner(commonDir))
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	_ = cmd.Flags().Set(flagJSON, "true")
+	if err := tagCmd.RunE(cmd, []string{taskLogin}); err != nil {
+		t.Fatalf("tagCmd.RunE: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"tags": [`) {
+		t.Errorf("output = %s", buf.String())
+	}
+}
+
+func TestValidateTagArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "show", args: []string{taskLogin}},
+		{name: "add", args: []string{taskLogin, tagActionAdd, "spike"}},
+		{name: "unknown action", args: []string{taskLogin, "set", "spike"}, wantErr: "unknown tag action"},
+		{name: "no tags", args: []string{taskLogin, tagActionRemove}, wantErr: "no tags given"},
+		{name: "invalid tag", args: []string{taskLogin, tagActionAdd, "a,b"}, wantErr: "commas or whitespace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTagArgs(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
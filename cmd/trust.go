@@ -102,6 +102,9 @@ func runTrustShow(cmd *cobra.Command, repoRoot string, cfg *config.Config) error
 	for _, c := range trust.Commands(cfg) {
 		fmt.Fprintf(out, "  %s\n", c)
 	}
+	if cfg.ResolveLockfiles() {
+		fmt.Fprintf(out, "\n%s\n", noteResolveLockfiles)
+	}
 
 	h := trust.Hash(cfg)
 	fmt.Fprintf(out, "\nHash: %s\n", h)
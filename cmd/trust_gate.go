@@ -8,6 +8,7 @@ import (
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/trust"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +17,8 @@ const (
 	flagYes = "yes"
 
 	hintYes = "approve committed shell commands without prompting (use in CI; see 'rimba trust')"
+
+	noteResolveLockfiles = "[deps] resolve_lockfiles is on: sync and merge also run the install commands to regenerate conflicting lockfiles."
 )
 
 // ensureTrust checks that the user has approved the committed shell commands
@@ -46,6 +49,19 @@ func ensureTrust(cmd *cobra.Command, repoRoot string, cfg *config.Config) error
 	return trust.Record(repoRoot, h)
 }
 
+// lockfileResolver returns the resolver for lockfile-only conflicts — nil
+// unless [deps] resolve_lockfiles is set — once the trust gate has approved
+// the install commands it runs.
+func lockfileResolver(cmd *cobra.Command, repoRoot string, cfg *config.Config) (*operations.LockfileResolver, error) {
+	if !cfg.ResolveLockfiles() {
+		return nil, nil
+	}
+	if err := ensureTrust(cmd, repoRoot, cfg); err != nil {
+		return nil, err
+	}
+	return operations.NewLockfileResolver(cfg), nil
+}
+
 // promptTrust displays the committed shell commands and asks the user to
 // approve them. Returns true only for "y" or "yes". Default is no.
 func promptTrust(cmd *cobra.Command, cfg *config.Config) bool {
@@ -55,6 +71,9 @@ func promptTrust(cmd *cobra.Command, cfg *config.Config) bool {
 	for _, c := range cmds {
 		fmt.Fprintf(out, "  %s\n", c)
 	}
+	if cfg.ResolveLockfiles() {
+		fmt.Fprintf(out, "\n%s\n", noteResolveLockfiles)
+	}
 	fmt.Fprint(out, "\nRun these commands? [y/N] ")
 
 	reader := bufio.NewReader(cmd.InOrStdin())
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/journal"
+	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/output"
+	"github.com/spf13/cobra"
+)
+
+const flagList = "list"
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [n]",
+	Short: "Reverse the last remove, merge cleanup, clean, or rename",
+	Long: `Reverses the last n destructive operations (default 1), newest first.
+remove, merge (when it cleans up the source), clean --merged/--stale, and
+rename record each step in a journal under the git common dir, along with the
+branch tip, worktree path, and copy_files entries needed to reverse it.
+
+Undo recreates deleted branches at their recorded tip, recreates removed
+worktrees at their old path with their copy_files and metadata, and moves
+renamed worktrees and branches back. Uncommitted changes discarded by
+--force, dependencies, and remote branches are not restored. The journal keeps
+the last 50 operations; --list shows them, numbered as undo counts them.`,
+	Example: `  rimba undo --list    # show journaled operations
+  rimba undo           # reverse the last operation
+  rimba undo 3         # reverse the last three operations`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool(flagList)
+		n, err := undoCount(args, list)
+		if err != nil {
+			return err
+		}
+
+		r := newRunner(cmd.Context())
+		out := cmd.OutOrStdout()
+		if list {
+			entries, err := operations.JournalEntries(cmd.Context(), r, n)
+			if err != nil {
+				return err
+			}
+			if isJSON(cmd) {
+				return output.WriteJSON(out, version, "undo", output.UndoData{List: true, Entries: entries})
+			}
+			printJournal(out, entries)
+			return nil
+		}
+
+		undone, err := operations.Undo(cmd.Context(), r, n)
+		if errors.Is(err, operations.ErrNothingToUndo) {
+			return errhint.WithFix(err, "only remove, merge, clean, and rename are journaled; see: rimba undo --list")
+		}
+		if isJSON(cmd) && err == nil {
+			return output.WriteJSON(out, version, "undo", output.UndoData{Entries: undone})
+		}
+		recreated := false
+		for _, e := range undone {
+			recreated = printUndone(out, e) || recreated
+		}
+		if recreated {
+			fmt.Fprintln(out, "Dependencies were not reinstalled; run: rimba deps install <task>")
+		}
+		return err
+	},
+}
+
+// undoCount parses the optional count. --list defaults to the whole journal.
+func undoCount(args []string, list bool) (int, error) {
+	if len(args) == 0 {
+		if list {
+			return journal.MaxEntries, nil
+		}
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, errhint.WithFix(
+			fmt.Errorf("invalid count %q", args[0]),
+			"pass a positive number of operations, e.g. rimba undo 2",
+		)
+	}
+	return n, nil
+}
+
+// printJournal lists entries newest first, numbered as undo counts them.
+func printJournal(out io.Writer, entries []journal.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "Nothing to undo.")
+		return
+	}
+	for i, e := range entries {
+		fmt.Fprintf(out, "%d  %s  %s\n", i+1, e.At.Local().Format(time.DateTime), e.Op)
+		for _, s := range e.Steps {
+			fmt.Fprintf(out, "     %s\n", describeStep(s))
+		}
+	}
+}
+
+// printUndone reports what undoing e put back, returning whether it
+// recreated any worktree.
+func printUndone(out io.Writer, e journal.Entry) (recreated bool) {
+	fmt.Fprintf(out, "Undid %s from %s:\n", e.Op, e.At.Local().Format(time.DateTime))
+	for i := len(e.Steps) - 1; i >= 0; i-- {
+		s := e.Steps[i]
+		switch s.Action {
+		case journal.ActionRename:
+			fmt.Fprintf(out, "  Renamed %s back to %s\n", s.NewBranch, s.Branch)
+			fmt.Fprintf(out, "  Moved:  %s\n", s.Path)
+		default:
+			fmt.Fprintf(out, "  Recreated %s at %s\n", s.Branch, s.Path)
+			recreated = true
+		}
+	}
+	return recreated
+}
+
+// describeStep renders a journaled step with the branch tip it can restore.
+func describeStep(s journal.Step) string {
+	if s.Action == journal.ActionRemove && s.SHA != "" {
+		return fmt.Sprintf("%s (%s @ %s)", s.Desc, s.Branch, shortSHA(s.SHA))
+	}
+	return s.Desc
+}
+
+func shortSHA(sha string) string {
+	const n = 7
+	if len(sha) > n {
+		return sha[:n]
+	}
+	return sha
+}
+
+func init() {
+	undoCmd.Flags().Bool(flagList, false, "list journaled operations instead of undoing them")
+	rootCmd.AddCommand(undoCmd)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/journal"
+)
+
+func seedJournal(t *testing.T, commonDir string) {
+	t.Helper()
+	j := &journal.Journal{}
+	e := journal.NewEntry("clean")
+	e.Steps = []journal.Step{{
+		Action: journal.ActionRemove,
+		Desc:   "remove worktree: /wt/login",
+		Branch: branchFeature,
+		Path:   "/wt/login",
+		SHA:    "def4567890",
+	}}
+	j.Append(e)
+	if err := j.Save(commonDir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUndoList(t *testing.T) {
+	commonDir := t.TempDir()
+	seedJournal(t, commonDir)
+	restore := overrideNewRunner(metaCmdRunner(commonDir))
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagList, false, "")
+	_ = cmd.Flags().Set(flagList, "true")
+	if err := undoCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("undo --list: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"1  ", "clean", "remove worktree: /wt/login (feature/login @ def4567)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestUndoListJSON(t *testing.T) {
+	commonDir := t.TempDir()
+	seedJournal(t, commonDir)
+	restore := overrideNewRunner(metaCmdRunner(commonDir))
+	defer restore()
+
+	cmd, buf := newTestCmd()
+	cmd.Flags().Bool(flagList, false, "")
+	_ = cmd.Flags().Set(flagList, "true")
+	_ = cmd.Flags().Set(flagJSON, "true")
+	if err := undoCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("undo --list --json: %v", err)
+	}
+	var env struct {
+		Data struct {
+			List    bool            `json:"list"`
+			Entries []journal.Entry `json:"entries"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	if !env.Data.List || len(env.Data.Entries) != 1 || env.Data.Entries[0].Op != "clean" {
+		t.Errorf("data = %+v", env.Data)
+	}
+}
+
+func TestUndoEmptyJournal(t *testing.T) {
+	restore := overrideNewRunner(metaCmdRunner(t.TempDir()))
+	defer restore()
+
+	cmd, _ := newTestCmd()
+	cmd.Flags().Bool(flagList, false, "")
+	err := undoCmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Fatalf("err = %v, want nothing-to-undo error", err)
+	}
+}
+
+func TestUndoCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		list    bool
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 1},
+		{name: "list defaults to all", list: true, want: journal.MaxEntries},
+		{name: "explicit", args: []string{"3"}, want: 3},
+		{name: "zero", args: []string{"0"}, wantErr: true},
+		{name: "not a number", args: []string{"two"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := undoCount(tt.args, tt.list)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("undoCount = %d, %v; want %d, err %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
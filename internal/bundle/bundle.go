@@ -0,0 +1,258 @@
+// Package bundle reads and writes portable worktree bundles: one gzipped tar
+// holding a manifest, a git bundle of the branch's own commits and archived
+// uncommitted changes, and the worktree's copy_files entries.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/meta"
+)
+
+// Manifest describes the task a bundle carries.
+type Manifest struct {
+	Version int    `json:"version"`
+	Task    string `json:"task"`
+	Service string `json:"service,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Branch  string `json:"branch"`
+	// Head is the branch tip; Base the merge-base with the default branch,
+	// which the importing clone must already have.
+	Head string `json:"head"`
+	Base string `json:"base"`
+	// Changes is the stash commit holding uncommitted and untracked changes.
+	Changes   string    `json:"changes,omitempty"`
+	Files     []string  `json:"files,omitempty"`
+	Meta      meta.Meta `json:"meta,omitzero"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Contents locates a bundle's payload on disk: the inputs to Write, or what
+// Read extracted. Either field is empty when that part is absent.
+type Contents struct {
+	GitBundle string
+	FilesDir  string
+}
+
+// Version is the bundle format this build writes and the newest it reads.
+const Version = 1
+
+const (
+	manifestName  = "manifest.json"
+	gitBundleName = "repo.bundle"
+	filesDirName  = "files"
+)
+
+// ErrUnsafePath is returned when a bundle entry would extract outside the
+// destination directory.
+var ErrUnsafePath = errors.New("unsafe path in bundle")
+
+// Write creates the bundle file at dst from m and c.
+func Write(dst string, m Manifest, c Contents) (retErr error) {
+	m.Version = Version
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal bundle manifest: %w", err)
+	}
+
+	f, err := os.Create(filepath.Clean(dst))
+	if err != nil {
+		return fmt.Errorf("create bundle: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); retErr == nil && cerr != nil {
+			retErr = fmt.Errorf("close bundle: %w", cerr)
+		}
+		if retErr != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	if err := writeBytes(tw, manifestName, data); err != nil {
+		return err
+	}
+	if c.GitBundle != "" {
+		if err := writeFile(tw, gitBundleName, c.GitBundle); err != nil {
+			return err
+		}
+	}
+	if c.FilesDir != "" {
+		if err := writeTree(tw, filesDirName, c.FilesDir); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("write bundle: %w", err)
+	}
+	return nil
+}
+
+// Read extracts the bundle at src into destDir and returns its manifest
+// along with where the payload landed.
+func Read(src, destDir string) (Manifest, Contents, error) {
+	var m Manifest
+	var c Contents
+
+	f, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return m, c, fmt.Errorf("open bundle: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return m, c, fmt.Errorf("read bundle %s: %w", src, err)
+	}
+	tr := tar.NewReader(gz)
+	sawManifest := false
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return m, c, fmt.Errorf("read bundle %s: %w", src, err)
+		}
+		if hdr.Name == manifestName {
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return m, c, fmt.Errorf("parse bundle manifest: %w", err)
+			}
+			sawManifest = true
+			continue
+		}
+		if err := extract(tr, hdr, destDir); err != nil {
+			return m, c, err
+		}
+	}
+
+	if !sawManifest {
+		return m, c, fmt.Errorf("%s is not a rimba bundle: no manifest", src)
+	}
+	if m.Version > Version {
+		return m, c, fmt.Errorf("bundle format v%d is newer than this rimba supports (v%d)", m.Version, Version)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, gitBundleName)); err == nil {
+		c.GitBundle = filepath.Join(destDir, gitBundleName)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, filesDirName)); err == nil {
+		c.FilesDir = filepath.Join(destDir, filesDirName)
+	}
+	return m, c, nil
+}
+
+// extract writes one tar entry under destDir. Only regular files and
+// directories inside the known payload locations are accepted.
+func extract(tr *tar.Reader, hdr *tar.Header, destDir string) error {
+	name := path.Clean(hdr.Name)
+	if name != gitBundleName && name != filesDirName && !strings.HasPrefix(name, filesDirName+"/") {
+		return fmt.Errorf("%w: %s", ErrUnsafePath, hdr.Name)
+	}
+	if !fs.ValidPath(name) {
+		return fmt.Errorf("%w: %s", ErrUnsafePath, hdr.Name)
+	}
+	target := filepath.Join(destDir, filepath.FromSlash(name))
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, 0750)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, hdr.FileInfo().Mode().Perm()) //nolint:gosec // target validated above
+		if err != nil {
+			return err
+		}
+		if _, err := io.CopyN(out, tr, hdr.Size); err != nil {
+			_ = out.Close()
+			return fmt.Errorf("extract %s: %w", name, err)
+		}
+		return out.Close()
+	default:
+		return fmt.Errorf("%w: %s is not a regular file or directory", ErrUnsafePath, hdr.Name)
+	}
+}
+
+func writeBytes(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg, ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeFile(tw *tar.Writer, name, src string) error {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	defer func() { _ = in.Close() }()
+
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, in); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeTree adds dir's regular files and directories under prefix.
+// Symlinks are skipped, matching how copy_files are copied.
+func writeTree(tw *tar.Writer, prefix, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(prefix, filepath.ToSlash(rel))
+		switch {
+		case d.IsDir():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			hdr, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = name + "/"
+			return tw.WriteHeader(hdr)
+		case d.Type().IsRegular():
+			return writeFile(tw, name, p)
+		default:
+			return nil
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/bundle"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+const branchPortable = "feature/portable"
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	testutil.CreateFile(t, src, "repo.bundle", "git bundle bytes")
+	files := filepath.Join(src, "files")
+	if err := os.MkdirAll(filepath.Join(files, "config"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	testutil.CreateFile(t, files, ".env", "TOKEN=x")
+	testutil.CreateFile(t, filepath.Join(files, "config"), "local.toml", "k = 1")
+
+	path := filepath.Join(t.TempDir(), "portable.rimba")
+	in := bundle.Manifest{
+		Task:    "portable",
+		Type:    "feature",
+		Branch:  branchPortable,
+		Head:    "abc",
+		Base:    "def",
+		Changes: "123",
+		Files:   []string{".env", "config/local.toml"},
+		Meta:    meta.Meta{Note: "half done"},
+	}
+	if err := bundle.Write(path, in, bundle.Contents{GitBundle: filepath.Join(src, "repo.bundle"), FilesDir: files}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	dest := t.TempDir()
+	got, c, err := bundle.Read(path, dest)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.Version != bundle.Version || got.Branch != branchPortable || got.Changes != "123" || got.Meta.Note != "half done" {
+		t.Errorf("manifest = %+v", got)
+	}
+	if data, _ := os.ReadFile(c.GitBundle); string(data) != "git bundle bytes" {
+		t.Errorf("git bundle = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(c.FilesDir, "config", "local.toml")); string(data) != "k = 1" {
+		t.Errorf("nested file = %q", data)
+	}
+}
+
+func TestWriteReadManifestOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bare.rimba")
+	if err := bundle.Write(path, bundle.Manifest{Task: "bare", Branch: branchPortable}, bundle.Contents{}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_, c, err := bundle.Read(path, t.TempDir())
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if c.GitBundle != "" || c.FilesDir != "" {
+		t.Errorf("contents = %+v, want empty", c)
+	}
+}
+
+func TestReadRejectsUnsafePath(t *testing.T) {
+	for _, name := range []string{"../escape", "files/../../escape", "/etc/passwd", "other.txt"} {
+		t.Run(name, func(t *testing.T) {
+			path := writeRawBundle(t, map[string]string{"manifest.json": `{"version":1}`, name: "x"})
+			_, _, err := bundle.Read(path, t.TempDir())
+			if !errors.Is(err, bundle.ErrUnsafePath) {
+				t.Errorf("err = %v, want ErrUnsafePath", err)
+			}
+		})
+	}
+}
+
+func TestReadRejectsNewerVersion(t *testing.T) {
+	path := writeRawBundle(t, map[string]string{"manifest.json": `{"version":99}`})
+	_, _, err := bundle.Read(path, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("err = %v, want version error", err)
+	}
+}
+
+func TestReadRequiresManifest(t *testing.T) {
+	path := writeRawBundle(t, map[string]string{"repo.bundle": "x"})
+	_, _, err := bundle.Read(path, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "no manifest") {
+		t.Errorf("err = %v, want missing-manifest error", err)
+	}
+}
+
+func TestReadNotABundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.txt")
+	testutil.CreateFile(t, filepath.Dir(path), "plain.txt", "not gzip")
+	if _, _, err := bundle.Read(path, t.TempDir()); err == nil {
+		t.Error("expected error for a non-gzip file")
+	}
+}
+
+// writeRawBundle builds a bundle file from raw entries, bypassing Write's
+// validation so Read's checks can be exercised.
+func writeRawBundle(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "raw.rimba")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, body := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []interface{ Close() error }{tw, gz, f} {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
@@ -47,6 +47,10 @@ type Config struct {
 	Open          map[string]string    `toml:"open,omitempty"`
 	Resolver      *ResolverConfig      `toml:"resolver,omitempty"`
 	Observability *ObservabilityConfig `toml:"observability,omitempty"`
+	Sync          *SyncConfig          `toml:"sync,omitempty"`
+	Merge         *MergeConfig         `toml:"merge,omitempty"`
+	MergeQueue    *MergeQueueConfig    `toml:"merge_queue,omitempty"`
+	Profiles      map[string]Profile   `toml:"profiles,omitempty"`
 }
 
 // DefaultObservabilityRetentionDays is used when [observability] retention_days is unset.
@@ -83,6 +87,79 @@ func (c *Config) ObservabilityRetentionDays() int {
 	return *c.Observability.RetentionDays
 }
 
+// SyncConfig holds optional defaults for rimba sync.
+type SyncConfig struct {
+	// Autostash stashes a dirty worktree's changes around the sync instead of
+	// skipping it. The --autostash flag overrides it.
+	Autostash bool `toml:"autostash,omitempty"`
+	// Fetch syncs onto the remote-tracking default branch, fetched first,
+	// instead of the local one. The --fetch flag overrides it.
+	Fetch bool `toml:"fetch,omitempty"`
+	// Remote is the remote --fetch fetches and syncs onto.
+	Remote string `toml:"remote,omitempty"`
+}
+
+// DefaultSyncRemote is the remote --fetch uses when [sync] remote is unset.
+const DefaultSyncRemote = "origin"
+
+// SyncAutostash reports whether rimba sync stashes dirty worktrees by default.
+func (c *Config) SyncAutostash() bool {
+	return c.Sync != nil && c.Sync.Autostash
+}
+
+// SyncFetch reports whether rimba sync fetches and syncs onto the remote
+// default branch by default.
+func (c *Config) SyncFetch() bool {
+	return c.Sync != nil && c.Sync.Fetch
+}
+
+// SyncRemote returns the remote rimba sync --fetch uses, DefaultSyncRemote
+// when unset.
+func (c *Config) SyncRemote() string {
+	if c.Sync == nil || c.Sync.Remote == "" {
+		return DefaultSyncRemote
+	}
+	return c.Sync.Remote
+}
+
+// MergeConfig holds optional settings for rimba merge.
+type MergeConfig struct {
+	// Verify are shell commands run against the merge result in a scratch
+	// worktree; the target is updated only when all of them pass.
+	Verify []string `toml:"verify,omitempty"`
+}
+
+// MergeVerify returns the commands rimba merge verifies a merge with, nil
+// when none are configured.
+func (c *Config) MergeVerify() []string {
+	if c.Merge == nil {
+		return nil
+	}
+	return c.Merge.Verify
+}
+
+// MergeQueueConfig holds optional settings for rimba merge-queue.
+type MergeQueueConfig struct {
+	// Verify are shell commands run in the integration worktree after each
+	// branch is merged there; the branch lands only when all of them pass.
+	Verify []string `toml:"verify,omitempty"`
+}
+
+// MergeQueueVerify returns the commands rimba merge-queue verifies each
+// branch with, nil when none are configured.
+func (c *Config) MergeQueueVerify() []string {
+	if c.MergeQueue == nil {
+		return nil
+	}
+	return c.MergeQueue.Verify
+}
+
+// ResolveLockfiles reports whether sync and merge regenerate lockfiles to
+// resolve conflicts that touch nothing else.
+func (c *Config) ResolveLockfiles() bool {
+	return c.Deps != nil && c.Deps.ResolveLockfiles
+}
+
 // EffectiveCommandTimeout returns the parsed CommandTimeout, or DefaultCommandTimeout
 // when the field is empty, unparseable, or non-positive.
 func (c *Config) EffectiveCommandTimeout() time.Duration {
@@ -102,6 +179,9 @@ type DepsConfig struct {
 	Modules    []ModuleConfig `toml:"modules,omitempty"`
 	// Concurrency caps parallel module installs. 0 = auto.
 	Concurrency int `toml:"concurrency,omitempty"`
+	// ResolveLockfiles lets sync and merge resolve conflicts that touch only
+	// lockfiles by regenerating them. Off unless opted in.
+	ResolveLockfiles bool `toml:"resolve_lockfiles,omitempty"`
 }
 
 // ModuleConfig defines a manually configured dependency module, or (when
@@ -112,6 +192,9 @@ type ModuleConfig struct {
 	Lockfile string `toml:"lockfile,omitempty"`
 	Install  string `toml:"install,omitempty"`
 	WorkDir  string `toml:"work_dir,omitempty"`
+	// Lock regenerates Lockfile without installing, for resolve_lockfiles.
+	// Unset, the built-in preset for the lockfile's name is used, then Install.
+	Lock string `toml:"lock,omitempty"`
 	// Eager overrides the eager/lazy default for this module's Dir. nil
 	// means unset (fall through to service-scope inference, then the
 	// Recursive-flag heuristic).
@@ -167,6 +250,8 @@ func (c *Config) Validate() error {
 	errs = appendIf(errs, validateDeps(c.Deps)...)
 	errs = appendIf(errs, validateOpen(c.Open)...)
 	errs = appendIf(errs, validateResolver(c.Resolver)...)
+	errs = appendIf(errs, validateSync(c.Sync)...)
+	errs = appendIf(errs, c.validateProfiles()...)
 	return errors.Join(errs...)
 }
 
@@ -244,6 +329,18 @@ func Merge(team, local *Config) *Config {
 	if local.Observability != nil {
 		merged.Observability = local.Observability
 	}
+	if local.Sync != nil {
+		merged.Sync = local.Sync
+	}
+	if local.Merge != nil {
+		merged.Merge = local.Merge
+	}
+	if local.MergeQueue != nil {
+		merged.MergeQueue = local.MergeQueue
+	}
+	if local.Profiles != nil {
+		merged.Profiles = local.Profiles
+	}
 
 	return &merged
 }
@@ -414,6 +511,21 @@ func validateOpen(open map[string]string) []error {
 	return errs
 }
 
+// validateSync rejects a [sync] remote git would read as an option or that
+// cannot be a remote name.
+func validateSync(sc *SyncConfig) []error {
+	if sc == nil || sc.Remote == "" {
+		return nil
+	}
+	if strings.HasPrefix(sc.Remote, "-") || strings.ContainsAny(sc.Remote, " \t\n") {
+		return []error{errhint.WithFix(
+			fmt.Errorf("config: sync.remote %q is not a valid remote name", sc.Remote),
+			"set [sync] remote to a configured remote (see git remote) in .rimba/settings.toml",
+		)}
+	}
+	return nil
+}
+
 // validateCommandTimeout rejects non-empty durations that are unparseable or non-positive.
 func validateCommandTimeout(s string) []error {
 	if s == "" {
@@ -987,6 +987,82 @@ func TestMergeObservabilityReplaces(t *testing.T) {
 	}
 }
 
+func TestSyncAutostash(t *testing.T) {
+	if (&config.Config{}).SyncAutostash() {
+		t.Error("SyncAutostash() = true without a [sync] section")
+	}
+	team := &config.Config{Sync: &config.SyncConfig{Autostash: true}}
+	if !team.SyncAutostash() {
+		t.Error("SyncAutostash() = false, want true")
+	}
+
+	// A local [sync] section replaces the team one, even to turn it off.
+	merged := config.Merge(team, &config.Config{Sync: &config.SyncConfig{}})
+	if merged.SyncAutostash() {
+		t.Error("merged SyncAutostash() = true, want local override false")
+	}
+}
+
+func TestSyncFetchAndRemote(t *testing.T) {
+	cfg := &config.Config{}
+	if cfg.SyncFetch() || cfg.SyncRemote() != config.DefaultSyncRemote {
+		t.Errorf("unset: SyncFetch() = %v, SyncRemote() = %q", cfg.SyncFetch(), cfg.SyncRemote())
+	}
+	cfg.Sync = &config.SyncConfig{Fetch: true, Remote: "upstream"}
+	if !cfg.SyncFetch() || cfg.SyncRemote() != "upstream" {
+		t.Errorf("set: SyncFetch() = %v, SyncRemote() = %q", cfg.SyncFetch(), cfg.SyncRemote())
+	}
+}
+
+func TestResolveLockfiles(t *testing.T) {
+	cfg := &config.Config{}
+	if cfg.ResolveLockfiles() {
+		t.Error("unset: ResolveLockfiles() = true, want false")
+	}
+	cfg.Deps = &config.DepsConfig{ResolveLockfiles: true}
+	if !cfg.ResolveLockfiles() {
+		t.Error("set: ResolveLockfiles() = false, want true")
+	}
+}
+
+func TestMergeVerify(t *testing.T) {
+	if got := (&config.Config{}).MergeVerify(); got != nil {
+		t.Errorf("unset: MergeVerify() = %v, want nil", got)
+	}
+	team := &config.Config{Merge: &config.MergeConfig{Verify: []string{"make test"}}}
+	if got := config.Merge(team, &config.Config{}).MergeVerify(); len(got) != 1 || got[0] != "make test" {
+		t.Errorf("team MergeVerify() = %v, want make test", got)
+	}
+	local := &config.Config{Merge: &config.MergeConfig{Verify: []string{"make lint"}}}
+	if got := config.Merge(team, local).MergeVerify(); len(got) != 1 || got[0] != "make lint" {
+		t.Errorf("merged MergeVerify() = %v, want the local override", got)
+	}
+}
+
+func TestMergeQueueVerify(t *testing.T) {
+	if got := (&config.Config{}).MergeQueueVerify(); got != nil {
+		t.Errorf("unset: MergeQueueVerify() = %v, want nil", got)
+	}
+	team := &config.Config{MergeQueue: &config.MergeQueueConfig{Verify: []string{"make test"}}}
+	local := &config.Config{MergeQueue: &config.MergeQueueConfig{Verify: []string{"make lint", "make test"}}}
+	if got := config.Merge(team, local).MergeQueueVerify(); len(got) != 2 || got[0] != "make lint" {
+		t.Errorf("merged MergeQueueVerify() = %v, want the local override", got)
+	}
+}
+
+func TestValidateSyncRemote(t *testing.T) {
+	for _, remote := range []string{"--upload-pack=x", "my remote"} {
+		cfg := &config.Config{WorktreeDir: "../wt", Sync: &config.SyncConfig{Remote: remote}}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "sync.remote") {
+			t.Errorf("Validate(remote %q) = %v, want sync.remote error", remote, err)
+		}
+	}
+	cfg := &config.Config{WorktreeDir: "../wt", Sync: &config.SyncConfig{Remote: "upstream"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate(upstream) = %v", err)
+	}
+}
+
 func TestDepsConcurrency(t *testing.T) {
 	tests := []struct {
 		name string
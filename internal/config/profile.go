@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/gitref"
+)
+
+// Profile is a named [profiles.<name>] section that overrides how worktrees
+// created with --profile <name> are set up. Unset fields inherit the
+// top-level value; set slices replace it rather than appending.
+type Profile struct {
+	// Source is the branch new worktrees branch from instead of the
+	// repo's default branch.
+	Source string `toml:"source,omitempty"`
+	// Type is the prefix type (e.g. "bugfix") used when no prefix flag
+	// or positional prefix is given.
+	Type       string      `toml:"type,omitempty"`
+	CopyFiles  []string    `toml:"copy_files,omitempty"`
+	PostCreate []string    `toml:"post_create,omitempty"`
+	Deps       *DepsConfig `toml:"deps,omitempty"`
+}
+
+// ProfileNames returns the configured profile names, sorted.
+func (c *Config) ProfileNames() []string {
+	return slices.Sorted(maps.Keys(c.Profiles))
+}
+
+// Profile returns the named profile, or an error listing the defined ones.
+func (c *Config) Profile(name string) (Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		fix := "define [profiles." + name + "] in .rimba/settings.toml"
+		if names := c.ProfileNames(); len(names) > 0 {
+			fix = "use one of: " + strings.Join(names, ", ") + ", or " + fix
+		}
+		return Profile{}, errhint.WithFix(fmt.Errorf("unknown profile %q", name), fix)
+	}
+	return p, nil
+}
+
+// WithProfile returns a copy of c with the named profile's overrides applied:
+// copy_files, post_create, and deps replace the top-level values when set,
+// and Source replaces DefaultSource. An empty name returns c unchanged.
+func (c *Config) WithProfile(name string) (*Config, error) {
+	if name == "" {
+		return c, nil
+	}
+	p, err := c.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *c // shallow copy
+	if p.Source != "" {
+		merged.DefaultSource = p.Source
+	}
+	if p.CopyFiles != nil {
+		merged.CopyFiles = p.CopyFiles
+	}
+	if p.PostCreate != nil {
+		merged.PostCreate = p.PostCreate
+	}
+	if p.Deps != nil {
+		merged.Deps = p.Deps
+	}
+	return &merged, nil
+}
+
+// validateProfiles checks each [profiles.<name>] section: a usable name, a
+// ref-safe source, a known prefix type, and well-formed deps modules.
+func (c *Config) validateProfiles() []error {
+	var errs []error
+	ps := c.PrefixSet()
+	for _, name := range c.ProfileNames() {
+		p := c.Profiles[name]
+		if name == "" || strings.ContainsAny(name, "/ ") {
+			errs = append(errs, errhint.WithFix(
+				fmt.Errorf("config: profiles[%q]: name must be non-empty and contain no '/' or spaces", name),
+				"rename the [profiles.<name>] section in .rimba/settings.toml",
+			))
+		}
+		if p.Source != "" {
+			if err := gitref.Validate(p.Source); err != nil {
+				errs = append(errs, errhint.WithFix(
+					fmt.Errorf("config: profiles.%s.source: %w", name, err),
+					"set source to a valid branch name in .rimba/settings.toml",
+				))
+			}
+		}
+		if p.Type != "" && !ps.ValidType(p.Type) {
+			errs = append(errs, errhint.WithFix(
+				fmt.Errorf("config: profiles.%s.type: unknown prefix type %q", name, p.Type),
+				"use one of: "+strings.Join(ps.TypeNames(), ", "),
+			))
+		}
+		for _, err := range validateDeps(p.Deps) {
+			// Re-root "config: deps.modules[...]" under the profile.
+			errs = append(errs, fmt.Errorf("config: profiles.%s.%s", name, strings.TrimPrefix(err.Error(), "config: ")))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,142 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+)
+
+const profilePerf = "perf"
+
+func profileConfig() *config.Config {
+	return &config.Config{
+		DefaultSource: testDefaultBranch,
+		CopyFiles:     []string{".env"},
+		PostCreate:    []string{"make setup"},
+		Profiles: map[string]config.Profile{
+			profilePerf: {
+				Source:     testDevelopBranch,
+				CopyFiles:  []string{".env", "bench.env"},
+				PostCreate: []string{"make build"},
+			},
+			"review": {
+				Type: "chore",
+				Deps: &config.DepsConfig{Modules: []config.ModuleConfig{}},
+			},
+		},
+	}
+}
+
+func TestWithProfileOverrides(t *testing.T) {
+	cfg := profileConfig()
+	got, err := cfg.WithProfile(profilePerf)
+	if err != nil {
+		t.Fatalf("WithProfile: %v", err)
+	}
+	if got.DefaultSource != testDevelopBranch {
+		t.Errorf("DefaultSource = %q, want %q", got.DefaultSource, testDevelopBranch)
+	}
+	if !reflect.DeepEqual(got.CopyFiles, []string{".env", "bench.env"}) || !reflect.DeepEqual(got.PostCreate, []string{"make build"}) {
+		t.Errorf("CopyFiles = %v, PostCreate = %v", got.CopyFiles, got.PostCreate)
+	}
+	if cfg.DefaultSource != testDefaultBranch || cfg.PostCreate[0] != "make setup" {
+		t.Error("WithProfile must not mutate the receiver")
+	}
+}
+
+func TestWithProfileInheritsUnsetFields(t *testing.T) {
+	got, err := profileConfig().WithProfile("review")
+	if err != nil {
+		t.Fatalf("WithProfile: %v", err)
+	}
+	if got.DefaultSource != testDefaultBranch || got.PostCreate[0] != "make setup" || got.CopyFiles[0] != ".env" {
+		t.Errorf("unset fields should inherit, got %+v", got)
+	}
+	if got.Deps == nil {
+		t.Error("deps should come from the profile")
+	}
+}
+
+func TestWithProfileEmptyNameReturnsReceiver(t *testing.T) {
+	cfg := profileConfig()
+	if got, err := cfg.WithProfile(""); err != nil || got != cfg {
+		t.Errorf("WithProfile(\"\") = %p, %v; want receiver", got, err)
+	}
+}
+
+func TestWithProfileUnknown(t *testing.T) {
+	_, err := profileConfig().WithProfile("nope")
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "nope"`) || !strings.Contains(err.Error(), "perf, review") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile config.Profile
+		want    string
+	}{
+		{name: "bad source", profile: config.Profile{Source: "-evil"}, want: "profiles.bad.source"},
+		{name: "bad type", profile: config.Profile{Type: "nope"}, want: `unknown prefix type "nope"`},
+		{
+			name:    "bad deps",
+			profile: config.Profile{Deps: &config.DepsConfig{Modules: []config.ModuleConfig{{Dir: ""}}}},
+			want:    "profiles.bad.deps.modules[0]: dir is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Profiles: map[string]config.Profile{"bad": tt.profile}}
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if err := profileConfig().Validate(); err != nil {
+		t.Errorf("valid profiles: %v", err)
+	}
+}
+
+func TestLoadProfilesFromTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.FileName)
+	data := `copy_files = [".env"]
+
+[profiles.perf]
+source = "develop"
+type = "chore"
+copy_files = [".env", "bench.env"]
+post_create = ["make build"]
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf(fatalLoad, err)
+	}
+	p, err := cfg.Profile(profilePerf)
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if p.Source != testDevelopBranch || p.Type != "chore" || len(p.CopyFiles) != 2 || p.PostCreate[0] != "make build" {
+		t.Errorf("profile = %+v", p)
+	}
+}
+
+func TestMergeProfilesReplaces(t *testing.T) {
+	team := profileConfig()
+	local := &config.Config{Profiles: map[string]config.Profile{"mine": {}}}
+	if got := config.Merge(team, local).ProfileNames(); !reflect.DeepEqual(got, []string{"mine"}) {
+		t.Errorf("ProfileNames = %v, want local's", got)
+	}
+	if got := config.Merge(team, &config.Config{}).ProfileNames(); len(got) != 2 {
+		t.Errorf("ProfileNames = %v, want team's", got)
+	}
+}
@@ -16,6 +16,16 @@ import (
 type PrefixEntry struct {
 	Prefix  string   `toml:"prefix"`
 	Aliases []string `toml:"aliases,omitempty"`
+	// Source is the branch worktrees of this type branch from instead of
+	// the repo's default branch (e.g. "release/current" for hotfixes).
+	Source string `toml:"source,omitempty"`
+	// SyncTarget is the branch sync, merge, and conflict-check compare this
+	// type against; it defaults to Source, then the repo's default branch.
+	SyncTarget string `toml:"sync_target,omitempty"`
+	// SkipDeps skips dependency installation for new worktrees of this type.
+	SkipDeps bool `toml:"skip_deps,omitempty"`
+	// PostCreate runs after the top-level post_create hooks.
+	PostCreate []string `toml:"post_create,omitempty"`
 }
 
 // ResolverConfig holds the optional [resolver] section, letting teams declare
@@ -38,6 +48,49 @@ func (c *Config) PrefixSet() *resolver.PrefixSet {
 	return resolver.NewPrefixSet(specs)
 }
 
+// PrefixDefaults returns the [[resolver.prefix]] entry registered for prefix
+// (e.g. "hotfix/"), or a zero entry when it has none.
+func (c *Config) PrefixDefaults(prefix string) PrefixEntry {
+	if c.Resolver == nil || prefix == "" {
+		return PrefixEntry{}
+	}
+	for _, e := range c.Resolver.Prefix {
+		if e.Prefix == prefix {
+			return e
+		}
+	}
+	return PrefixEntry{}
+}
+
+// AddSource returns the branch a new worktree with prefix branches from when
+// no source is given: the profile's source, then the prefix type's, then the
+// repo's default branch.
+func (c *Config) AddSource(profile, prefix string) string {
+	if src := c.Profiles[profile].Source; src != "" {
+		return src
+	}
+	if src := c.PrefixDefaults(prefix).Source; src != "" {
+		return src
+	}
+	return c.DefaultSource
+}
+
+// BaseBranch returns the branch that branch is synced onto, merged into, and
+// checked for conflicts against: its prefix type's sync_target, then the
+// type's source, then the repo's default branch.
+func (c *Config) BaseBranch(branch string) string {
+	_, _, prefix := resolver.ServiceFromBranch(branch, c.PrefixSet().Strip())
+	e := c.PrefixDefaults(prefix)
+	switch {
+	case e.SyncTarget != "":
+		return e.SyncTarget
+	case e.Source != "":
+		return e.Source
+	default:
+		return c.DefaultSource
+	}
+}
+
 // PrefixSetFromContext is total: it never panics, degrading to
 // resolver.DefaultPrefixSet() when ctx carries no Config.
 func PrefixSetFromContext(ctx context.Context) *resolver.PrefixSet {
@@ -64,6 +117,7 @@ func validateResolver(rc *ResolverConfig) []error {
 	for i, entry := range rc.Prefix {
 		errs = append(errs, validateResolverPrefix(i, entry.Prefix, seenPrefixes, builtins, ownTokens)...)
 		errs = append(errs, validateResolverAliases(entry, seenAliases, builtins, ownTokens)...)
+		errs = append(errs, validateResolverBranches(i, entry)...)
 	}
 	return errs
 }
@@ -131,6 +185,33 @@ func validatePrefixNotColliding(index int, prefix string, builtins *resolver.Pre
 	return nil
 }
 
+// validateResolverBranches checks that an entry's source and sync_target are
+// ref-safe branch names.
+func validateResolverBranches(index int, entry PrefixEntry) []error {
+	var errs []error
+	if err := validateResolverBranch(index, "source", entry.Source); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateResolverBranch(index, "sync_target", entry.SyncTarget); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// validateResolverBranch validates one optional branch field of an entry.
+func validateResolverBranch(index int, key, branch string) error {
+	if branch == "" {
+		return nil
+	}
+	if err := gitref.Validate(branch); err != nil {
+		return errhint.WithFix(
+			fmt.Errorf("config: resolver.prefix[%d].%s: %w", index, key, err),
+			"set "+key+" to a valid branch name in [[resolver.prefix]] in .rimba/settings.toml",
+		)
+	}
+	return nil
+}
+
 // validateResolverAliases checks entry's Aliases: non-empty, no '/', unique,
 // and not shadowing a built-in or another entry's own canonical token.
 func validateResolverAliases(entry PrefixEntry, seenAliases map[string]bool, builtins *resolver.PrefixSet, ownTokens map[string]string) []error {
@@ -256,6 +256,24 @@ func TestConfigValidateResolver(t *testing.T) {
 			wantErr:    true,
 			wantSubstr: []string{"collides with built-in prefix", "bugfix/"},
 		},
+		{
+			name: "per-type defaults on a built-in prefix are valid",
+			resolver: &config.ResolverConfig{
+				Prefix: []config.PrefixEntry{
+					{Prefix: "hotfix/", Source: "release/current", SyncTarget: "release/current", PostCreate: []string{"make"}},
+					{Prefix: "docs/", SkipDeps: true},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsafe source and sync_target rejected",
+			resolver: &config.ResolverConfig{
+				Prefix: []config.PrefixEntry{{Prefix: "hotfix/", Source: "release..x", SyncTarget: "bad target"}},
+			},
+			wantErr:    true,
+			wantSubstr: []string{"resolver.prefix[0].source", "resolver.prefix[0].sync_target"},
+		},
 		{
 			name: "two custom prefixes normalizing to the same type collide",
 			resolver: &config.ResolverConfig{
@@ -458,3 +476,97 @@ func TestResolverConfigTOMLRoundTrip(t *testing.T) {
 		t.Errorf("raw TOML = %q, must use singular 'prefix' key, not 'prefixes'", raw)
 	}
 }
+
+// --- per-type defaults ---
+
+func perTypeConfig() *config.Config {
+	return &config.Config{
+		DefaultSource: "main",
+		Resolver: &config.ResolverConfig{
+			Prefix: []config.PrefixEntry{
+				{Prefix: "hotfix/", Source: "release/current"},
+				{Prefix: "docs/", SkipDeps: true},
+				{Prefix: testProjPrefix, Source: "develop", SyncTarget: "staging"},
+			},
+		},
+		Profiles: map[string]config.Profile{"perf": {Source: "perf-base"}, "lint": {}},
+	}
+}
+
+func TestConfigPrefixDefaults(t *testing.T) {
+	cfg := perTypeConfig()
+	if got := cfg.PrefixDefaults("docs/"); !got.SkipDeps {
+		t.Errorf("PrefixDefaults(docs/) = %+v, want SkipDeps", got)
+	}
+	if got := cfg.PrefixDefaults("feature/"); !reflect.DeepEqual(got, config.PrefixEntry{}) {
+		t.Errorf("PrefixDefaults(feature/) = %+v, want zero entry", got)
+	}
+	if got := (&config.Config{}).PrefixDefaults("hotfix/"); !reflect.DeepEqual(got, config.PrefixEntry{}) {
+		t.Errorf("PrefixDefaults with nil resolver = %+v, want zero entry", got)
+	}
+}
+
+func TestConfigAddSource(t *testing.T) {
+	cfg := perTypeConfig()
+	tests := []struct {
+		profile, prefix, want string
+	}{
+		{"", "feature/", "main"},
+		{"", "hotfix/", "release/current"},
+		{"perf", "hotfix/", "perf-base"},
+		{"lint", "hotfix/", "release/current"},
+	}
+	for _, tt := range tests {
+		if got := cfg.AddSource(tt.profile, tt.prefix); got != tt.want {
+			t.Errorf("AddSource(%q, %q) = %q, want %q", tt.profile, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestConfigBaseBranch(t *testing.T) {
+	cfg := perTypeConfig()
+	tests := map[string]string{
+		"feature/login":        "main",
+		"hotfix/crash":         "release/current",
+		"auth-api/hotfix/leak": "release/current",
+		"PROJ-123":             "staging",
+		"unprefixed":           "main",
+	}
+	for branch, want := range tests {
+		if got := cfg.BaseBranch(branch); got != want {
+			t.Errorf("BaseBranch(%q) = %q, want %q", branch, got, want)
+		}
+	}
+}
+
+func TestPrefixEntryDefaultsTOML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	content := `worktree_dir = "../wt"
+
+[[resolver.prefix]]
+prefix = "hotfix/"
+source = "release/current"
+sync_target = "release/next"
+skip_deps = true
+post_create = ["make release-env"]
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := config.PrefixEntry{
+		Prefix:     "hotfix/",
+		Source:     "release/current",
+		SyncTarget: "release/next",
+		SkipDeps:   true,
+		PostCreate: []string{"make release-env"},
+	}
+	if got := cfg.PrefixDefaults("hotfix/"); !reflect.DeepEqual(got, want) {
+		t.Errorf("PrefixDefaults(hotfix/) = %+v, want %+v", got, want)
+	}
+}
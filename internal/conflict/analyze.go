@@ -0,0 +1,143 @@
+package conflict
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// Options selects how Analyze detects overlaps.
+type Options struct {
+	// Hunks compares the line ranges each branch changes in a file
+	// (git diff -U0 -M) instead of only which files it changes.
+	Hunks bool
+	// Worktree adds the uncommitted changes in each branch's worktree —
+	// staged and unstaged, plus untracked files with Untracked — to the
+	// branch's committed ones.
+	Worktree  bool
+	Untracked bool
+	// Cache, when set, supplies the committed diffs of branches and bases
+	// that have not moved since it was saved, and records the rest.
+	Cache *Cache
+}
+
+// Analyze collects each branch's changes against baseOf(branch) and
+// detects the overlaps between them.
+func Analyze(ctx context.Context, r git.Runner, baseOf func(branch string) string, branches []resolver.WorktreeInfo, opts Options) (*CheckResult, error) {
+	var uncommitted map[string][]string
+	if opts.Worktree {
+		var err error
+		if uncommitted, err = CollectUncommitted(ctx, r, branches, opts.Untracked); err != nil {
+			return nil, err
+		}
+	}
+
+	opts.Cache.resolve(ctx, r, cacheRefs(baseOf, branches))
+
+	var result *CheckResult
+	if opts.Hunks {
+		changes, err := collectHunks(ctx, r, baseOf, branches, opts)
+		if err != nil {
+			return nil, err
+		}
+		result = DetectHunkOverlaps(changes)
+	} else {
+		diffs, err := collectDiffs(ctx, r, baseOf, branches, opts.Cache)
+		if err != nil {
+			return nil, err
+		}
+		result = DetectOverlaps(withUncommitted(diffs, uncommitted))
+	}
+	markUncommitted(result.Overlaps, uncommitted)
+	return result, nil
+}
+
+// CollectUncommitted returns the files with staged or unstaged changes in
+// each branch's worktree — with untracked, its untracked files too — in
+// parallel. Branches without a worktree directory — it is gone, or the
+// branch is an open PR's fetched head — have none.
+func CollectUncommitted(ctx context.Context, r git.Runner, branches []resolver.WorktreeInfo, untracked bool) (map[string][]string, error) {
+	return collect(branches, func(wt resolver.WorktreeInfo) ([]string, error) {
+		if !hasWorktree(wt) {
+			return nil, nil
+		}
+		files, err := git.UncommittedFiles(ctx, r, wt.Path)
+		if err != nil || !untracked {
+			return files, err
+		}
+		others, err := git.UntrackedFiles(ctx, r, wt.Path)
+		return append(files, others...), err
+	})
+}
+
+// collectDiffs is CollectDiffsFrom through cache.
+func collectDiffs(ctx context.Context, r git.Runner, baseOf func(branch string) string, branches []resolver.WorktreeInfo, cache *Cache) (map[string][]string, error) {
+	return collect(branches, func(wt resolver.WorktreeInfo) ([]string, error) {
+		return cache.diffNameOnly(ctx, r, baseOf(wt.Branch), wt.Branch)
+	})
+}
+
+// collectHunks is CollectHunksFrom through opts.Cache, diffing each
+// branch's worktree rather than its tip with opts.Worktree, and counting
+// untracked files as added with opts.Untracked.
+func collectHunks(ctx context.Context, r git.Runner, baseOf func(branch string) string, branches []resolver.WorktreeInfo, opts Options) (map[string][]git.FileHunks, error) {
+	return collect(branches, func(wt resolver.WorktreeInfo) ([]git.FileHunks, error) {
+		if !opts.Worktree || !hasWorktree(wt) {
+			return opts.Cache.diffHunks(ctx, r, baseOf(wt.Branch), wt.Branch)
+		}
+		files, err := git.DiffHunksInWorktree(ctx, r, wt.Path, baseOf(wt.Branch))
+		if err != nil || !opts.Untracked {
+			return files, err
+		}
+		others, err := git.UntrackedFiles(ctx, r, wt.Path)
+		for _, f := range others {
+			files = append(files, git.FileHunks{Path: f, Hunks: []git.LineRange{{}}})
+		}
+		return files, err
+	})
+}
+
+// withUncommitted adds each branch's uncommitted files to its committed
+// ones in diffs.
+func withUncommitted(diffs, uncommitted map[string][]string) map[string][]string {
+	for branch, files := range uncommitted {
+		for _, f := range files {
+			if !slices.Contains(diffs[branch], f) {
+				diffs[branch] = append(diffs[branch], f)
+			}
+		}
+	}
+	return diffs
+}
+
+// markUncommitted records on each overlap the branches with uncommitted
+// changes to its file, under its original or renamed path.
+func markUncommitted(overlaps []FileOverlap, uncommitted map[string][]string) {
+	for i := range overlaps {
+		o := &overlaps[i]
+		for _, branch := range o.Branches {
+			files := uncommitted[branch]
+			if slices.Contains(files, o.File) || (o.Renames[branch] != "" && slices.Contains(files, o.Renames[branch])) {
+				o.Uncommitted = append(o.Uncommitted, branch)
+			}
+		}
+	}
+}
+
+// cacheRefs returns the branches and their bases, whose commits key the
+// cached diffs.
+func cacheRefs(baseOf func(branch string) string, branches []resolver.WorktreeInfo) []string {
+	refs := make([]string, 0, 2*len(branches))
+	for _, wt := range branches {
+		refs = append(refs, wt.Branch, baseOf(wt.Branch))
+	}
+	return refs
+}
+
+// hasWorktree reports whether wt's branch is checked out in a worktree
+// directory that still exists.
+func hasWorktree(wt resolver.WorktreeInfo) bool {
+	return wt.Path != "" && !wt.Prunable
+}
@@ -0,0 +1,105 @@
+package conflict
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// uncommittedRunner serves feature/a with a.go committed and shared.go
+// edited in its worktree, and feature/b with shared.go committed and
+// notes.md untracked in its worktree.
+func uncommittedRunner() *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			if strings.HasSuffix(args[len(args)-1], branchA) {
+				return "a.go", nil
+			}
+			return "shared.go", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			switch {
+			case args[0] == "diff" && dir == "/wt/a":
+				return "shared.go", nil
+			case args[0] == "ls-files" && dir == "/wt/b":
+				return "notes.md", nil
+			}
+			return "", nil
+		},
+	}
+}
+
+var uncommittedBranches = []resolver.WorktreeInfo{
+	{Branch: branchA, Path: "/wt/a"},
+	{Branch: branchB, Path: "/wt/b"},
+}
+
+func mainBase(string) string { return "main" }
+
+func TestAnalyzeIncludesWorktreeChanges(t *testing.T) {
+	result, err := Analyze(context.Background(), uncommittedRunner(), mainBase, uncommittedBranches, Options{Worktree: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(result.Overlaps) != 1 || result.Overlaps[0].File != "shared.go" {
+		t.Fatalf("overlaps = %+v, want shared.go", result.Overlaps)
+	}
+	if got := result.Overlaps[0].Uncommitted; !slices.Equal(got, []string{branchA}) {
+		t.Errorf("uncommitted = %v, want feature/a", got)
+	}
+	if result.TotalFiles != 2 {
+		t.Errorf("total files = %d, want 2 without untracked files", result.TotalFiles)
+	}
+}
+
+func TestAnalyzeWithoutWorktreeIgnoresUncommitted(t *testing.T) {
+	result, err := Analyze(context.Background(), uncommittedRunner(), mainBase, uncommittedBranches, Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(result.Overlaps) != 0 {
+		t.Errorf("overlaps = %+v, want none from committed changes", result.Overlaps)
+	}
+}
+
+func TestCollectUncommittedUntracked(t *testing.T) {
+	files, err := CollectUncommitted(context.Background(), uncommittedRunner(), uncommittedBranches, true)
+	if err != nil {
+		t.Fatalf("CollectUncommitted: %v", err)
+	}
+	if !slices.Equal(files[branchA], []string{"shared.go"}) || !slices.Equal(files[branchB], []string{"notes.md"}) {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestAnalyzeHunksInWorktree(t *testing.T) {
+	r := &mockRunner{
+		run: func(...string) (string, error) { return "", nil },
+		runInDir: func(dir string, args ...string) (string, error) {
+			switch {
+			case args[0] == "merge-base":
+				return "abc123\n", nil
+			case args[0] == "diff" && args[1] == "-U0" && dir == "/wt/a":
+				return "diff --git a/shared.go b/shared.go\n@@ -10,2 +10,2 @@", nil
+			case args[0] == "diff" && args[1] == "-U0":
+				return "diff --git a/shared.go b/shared.go\n@@ -11 +11 @@", nil
+			case args[0] == "diff" && dir == "/wt/b":
+				return "shared.go", nil
+			}
+			return "", nil
+		},
+	}
+	result, err := Analyze(context.Background(), r, mainBase, uncommittedBranches, Options{Hunks: true, Worktree: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(result.Overlaps) != 1 || result.Overlaps[0].Severity != SeverityHigh {
+		t.Fatalf("overlaps = %+v, want shared.go lines 10-11", result.Overlaps)
+	}
+	if got := result.Overlaps[0].Uncommitted; !slices.Equal(got, []string{branchB}) {
+		t.Errorf("uncommitted = %v, want feature/b", got)
+	}
+}
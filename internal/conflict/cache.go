@@ -0,0 +1,220 @@
+package conflict
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/fsutil"
+	"github.com/lugassawan/rimba/internal/git"
+)
+
+// cacheFile is where the cache lives, relative to the git common dir.
+const cacheFile = "rimba/conflict-cache.json"
+
+// cacheVersion is bumped whenever cached values change shape or meaning;
+// a cache of another version is discarded.
+const cacheVersion = 2
+
+// cacheTTL is how long an entry may go unused before Save drops it. A
+// branch that moves on never asks for its old entries again.
+const cacheTTL = 14 * 24 * time.Hour
+
+// Cache persists each branch's diff against its base, keyed by the base
+// and branch commit SHAs, and each pair's dry merge, keyed by both SHAs,
+// so a rerun only recomputes what changed since. Uncommitted changes are
+// never cached. A nil *Cache caches nothing.
+type Cache struct {
+	Version int                                     `json:"version"`
+	Diffs   map[string]*cacheEntry[[]string]        `json:"diffs"`
+	Hunks   map[string]*cacheEntry[[]git.FileHunks] `json:"hunks"`
+	Merges  map[string]*cacheEntry[cachedMerge]     `json:"merges"`
+
+	path  string
+	today time.Time // entries are stamped as used at most once a day
+	mu    sync.Mutex
+	shas  map[string]string // resolved refs; written only by resolve, before lookups
+	dirty bool
+}
+
+type cacheEntry[T any] struct {
+	Value T         `json:"value"`
+	Used  time.Time `json:"used"`
+}
+
+type cachedMerge struct {
+	HasConflicts  bool     `json:"has_conflicts"`
+	ConflictFiles []string `json:"conflict_files,omitempty"`
+}
+
+// LoadCache reads the cache under commonDir. A missing, unreadable or
+// outdated cache yields an empty one: the cache only ever saves work.
+func LoadCache(commonDir string) *Cache {
+	c := &Cache{}
+	if data, err := os.ReadFile(filepath.Join(commonDir, cacheFile)); err == nil {
+		if json.Unmarshal(data, c) != nil || c.Version != cacheVersion {
+			c = &Cache{}
+		}
+	}
+	c.Version = cacheVersion
+	c.path = filepath.Join(commonDir, cacheFile)
+	c.today = time.Now().UTC().Truncate(24 * time.Hour)
+	c.shas = make(map[string]string)
+	if c.Diffs == nil {
+		c.Diffs = make(map[string]*cacheEntry[[]string])
+	}
+	if c.Hunks == nil {
+		c.Hunks = make(map[string]*cacheEntry[[]git.FileHunks])
+	}
+	if c.Merges == nil {
+		c.Merges = make(map[string]*cacheEntry[cachedMerge])
+	}
+	return c
+}
+
+// Save drops entries unused for cacheTTL and writes the cache back
+// atomically when anything changed. A nil cache saves nothing.
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := c.today.Add(-cacheTTL)
+	c.dirty = expire(c.Diffs, cutoff) || c.dirty
+	c.dirty = expire(c.Hunks, cutoff) || c.dirty
+	c.dirty = expire(c.Merges, cutoff) || c.dirty
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal conflict cache: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(c.path, data); err != nil {
+		return fmt.Errorf("write conflict cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// resolve records the commit SHAs of refs, so their entries can be looked
+// up. When any of them does not resolve, none is recorded, and the work
+// involving them is done uncached. It must not run alongside lookups.
+func (c *Cache) resolve(ctx context.Context, r git.Runner, refs []string) {
+	if c == nil {
+		return
+	}
+	var missing []string
+	for _, ref := range refs {
+		if _, ok := c.shas[ref]; !ok && !slices.Contains(missing, ref) {
+			missing = append(missing, ref)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	resolved, err := git.ResolveRefs(ctx, r, missing)
+	if err != nil {
+		return
+	}
+	for ref, sha := range resolved {
+		c.shas[ref] = sha
+	}
+}
+
+// diffNameOnly is git.DiffNameOnly, cached by the base and branch SHAs.
+func (c *Cache) diffNameOnly(ctx context.Context, r git.Runner, base, branch string) ([]string, error) {
+	if c == nil {
+		return git.DiffNameOnly(ctx, r, base, branch)
+	}
+	return cached(c, c.Diffs, c.key(base, "...", branch), func() ([]string, error) {
+		return git.DiffNameOnly(ctx, r, base, branch)
+	})
+}
+
+// diffHunks is diffHunksFromMergeBase, cached by the base and branch SHAs.
+func (c *Cache) diffHunks(ctx context.Context, r git.Runner, base, branch string) ([]git.FileHunks, error) {
+	if c == nil {
+		return diffHunksFromMergeBase(ctx, r, base, branch)
+	}
+	return cached(c, c.Hunks, c.key(base, "...", branch), func() ([]git.FileHunks, error) {
+		return diffHunksFromMergeBase(ctx, r, base, branch)
+	})
+}
+
+// mergeTree is git.MergeTree, cached by both branches' SHAs in either
+// order: a merge conflicts the same both ways.
+func (c *Cache) mergeTree(ctx context.Context, r git.Runner, branch1, branch2 string) (git.MergeTreeResult, error) {
+	if c == nil {
+		return git.MergeTree(ctx, r, branch1, branch2)
+	}
+	key := c.key(branch1, " ", branch2)
+	if c.shas[branch2] < c.shas[branch1] {
+		key = c.key(branch2, " ", branch1)
+	}
+	m, err := cached(c, c.Merges, key, func() (cachedMerge, error) {
+		mt, err := git.MergeTree(ctx, r, branch1, branch2)
+		return cachedMerge(mt), err
+	})
+	return git.MergeTreeResult(m), err
+}
+
+// key joins the SHAs of a and b with sep, or returns "" — do not cache —
+// when either is unresolved.
+func (c *Cache) key(a, sep, b string) string {
+	shaA, shaB := c.shas[a], c.shas[b]
+	if shaA == "" || shaB == "" {
+		return ""
+	}
+	return shaA + sep + shaB
+}
+
+// cached returns the entry for key in entries, computing and storing it
+// when missing. An empty key bypasses the cache.
+func cached[T any](c *Cache, entries map[string]*cacheEntry[T], key string, compute func() (T, error)) (T, error) {
+	if key == "" {
+		return compute()
+	}
+	c.mu.Lock()
+	if e, ok := entries[key]; ok {
+		if e.Used.Before(c.today) {
+			e.Used = c.today
+			c.dirty = true
+		}
+		v := e.Value
+		c.mu.Unlock()
+		return v, nil
+	}
+	c.mu.Unlock()
+
+	v, err := compute()
+	if err != nil {
+		return v, err
+	}
+	c.mu.Lock()
+	entries[key] = &cacheEntry[T]{Value: v, Used: c.today}
+	c.dirty = true
+	c.mu.Unlock()
+	return v, nil
+}
+
+// expire deletes the entries last used before cutoff, reporting whether
+// any were.
+func expire[T any](entries map[string]*cacheEntry[T], cutoff time.Time) bool {
+	expired := false
+	for key, e := range entries {
+		if e.Used.Before(cutoff) {
+			delete(entries, key)
+			expired = true
+		}
+	}
+	return expired
+}
@@ -0,0 +1,150 @@
+package conflict
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// shaRunner resolves refs to the SHAs in tips and counts the diffs and
+// merge-trees it runs.
+type shaRunner struct {
+	mu     sync.Mutex
+	tips   map[string]string
+	diffs  int
+	merges int
+}
+
+func (s *shaRunner) runner() *mockRunner {
+	return &mockRunner{run: func(args ...string) (string, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch args[0] {
+		case "rev-parse":
+			var shas []string
+			for _, arg := range args[2:] {
+				shas = append(shas, s.tips[strings.TrimSuffix(arg, "^{commit}")])
+			}
+			return strings.Join(shas, "\n"), nil
+		case "merge-tree":
+			s.merges++
+			return "tree", nil
+		}
+		s.diffs++
+		return "shared.go", nil
+	}}
+}
+
+func cacheBranches() []resolver.WorktreeInfo {
+	return []resolver.WorktreeInfo{{Branch: branchA, Path: "/wt/a"}, {Branch: branchB, Path: "/wt/b"}}
+}
+
+func TestCacheReusesUnchangedBranches(t *testing.T) {
+	dir := t.TempDir()
+	s := &shaRunner{tips: map[string]string{"main": "m1", branchA: "a1", branchB: "b1"}}
+	ctx := context.Background()
+
+	run := func(hunks bool) {
+		t.Helper()
+		cache := LoadCache(dir)
+		if _, err := Analyze(ctx, s.runner(), mainBase, cacheBranches(), Options{Hunks: hunks, Cache: cache}); err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		if _, err := DryMergeAllCached(ctx, s.runner(), cacheBranches(), cache); err != nil {
+			t.Fatalf("DryMergeAllCached: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	run(false)
+	if s.diffs != 2 || s.merges != 1 {
+		t.Fatalf("first run: %d diffs, %d merges; want 2, 1", s.diffs, s.merges)
+	}
+
+	run(false)
+	if s.diffs != 2 || s.merges != 1 {
+		t.Errorf("rerun: %d diffs, %d merges; want everything from the cache", s.diffs, s.merges)
+	}
+
+	s.tips[branchB] = "b2"
+	run(false)
+	if s.diffs != 3 || s.merges != 2 {
+		t.Errorf("after b moved: %d diffs, %d merges; want b's diff and the pair recomputed", s.diffs, s.merges)
+	}
+
+	run(true)
+	if s.diffs != 5 {
+		t.Errorf("hunks: %d diffs, want hunks cached apart from file lists", s.diffs)
+	}
+}
+
+func TestCacheDisabledWhenRefsDoNotResolve(t *testing.T) {
+	diffs := 0
+	r := &mockRunner{run: func(args ...string) (string, error) {
+		if args[0] == "rev-parse" {
+			return "", os.ErrNotExist
+		}
+		diffs++
+		return "shared.go", nil
+	}}
+	cache := LoadCache(t.TempDir())
+
+	for range 2 {
+		if _, err := Analyze(context.Background(), r, mainBase, cacheBranches(), Options{Cache: cache}); err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+	}
+	if diffs != 4 {
+		t.Errorf("diffs = %d, want every run uncached", diffs)
+	}
+}
+
+func TestCacheSaveExpiresStaleEntries(t *testing.T) {
+	dir := t.TempDir()
+	cache := LoadCache(dir)
+	cache.Diffs["old...old"] = &cacheEntry[[]string]{Value: []string{"x.go"}, Used: cache.today.Add(-cacheTTL - 24*time.Hour)}
+	cache.Diffs["new...new"] = &cacheEntry[[]string]{Value: []string{"y.go"}, Used: cache.today}
+	cache.dirty = true
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded := LoadCache(dir)
+	if _, ok := reloaded.Diffs["old...old"]; ok {
+		t.Error("stale entry survived Save")
+	}
+	if e := reloaded.Diffs["new...new"]; e == nil || e.Value[0] != "y.go" {
+		t.Errorf("fresh entry = %+v, want it kept", e)
+	}
+}
+
+func TestLoadCacheDiscardsUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, cacheFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"{not json", `{"version":999,"diffs":{"a...b":{"value":["x.go"]}}}`} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if c := LoadCache(dir); len(c.Diffs) != 0 || c.Version != cacheVersion {
+			t.Errorf("LoadCache(%q) = %+v, want an empty cache", content, c.Diffs)
+		}
+	}
+}
+
+func TestNilCacheSavesNothing(t *testing.T) {
+	var c *Cache
+	if err := c.Save(); err != nil {
+		t.Errorf("nil Save = %v, want nil", err)
+	}
+}
@@ -24,6 +24,21 @@ type FileOverlap struct {
 	File     string   `json:"file"`
 	Branches []string `json:"branches"`
 	Severity Severity `json:"severity"`
+	// Lines are the line ranges the branches both change, from a
+	// hunk-aware check (Options.Hunks); branches changing disjoint lines
+	// of File have none.
+	Lines []LineOverlap `json:"lines,omitempty"`
+	// Renames maps each branch that renamed File to its new path.
+	Renames map[string]string `json:"renames,omitempty"`
+	// Uncommitted are the branches whose worktree has uncommitted changes
+	// to File (Options.Worktree).
+	Uncommitted []string `json:"uncommitted,omitempty"`
+	// PRs are the open PRs among Branches (AttachPRs).
+	PRs []PR `json:"prs,omitempty"`
+	// Owners are File's owners in CODEOWNERS (AttachOwners).
+	Owners []string `json:"owners,omitempty"`
+
+	hunks bool // from a hunk-aware check: only Lines weigh in a merge plan
 }
 
 // CheckResult holds the outcome of an overlap detection.
@@ -39,6 +54,8 @@ type DryMergeResult struct {
 	Branch2       string   `json:"branch2"`
 	HasConflicts  bool     `json:"has_conflicts"`
 	ConflictFiles []string `json:"conflict_files,omitempty"`
+	// PRs are the open PRs among Branch1 and Branch2 (AttachDryMergePRs).
+	PRs []PR `json:"prs,omitempty"`
 }
 
 // DetectOverlaps analyzes a map of branch→files and returns files modified in 2+ branches.
@@ -75,60 +92,37 @@ func DetectOverlaps(diffs map[string][]string) *CheckResult {
 		})
 	}
 
-	// Sort: high severity first, then alphabetically
-	slices.SortFunc(result.Overlaps, func(a, b FileOverlap) int {
-		if a.Severity != b.Severity {
-			if a.Severity == SeverityHigh {
-				return -1
-			}
-			return 1
-		}
-		return strings.Compare(a.File, b.File)
-	})
-
+	sortOverlaps(result.Overlaps)
 	return result
 }
 
 // CollectDiffs runs git diff --name-only for each branch vs mainBranch, in parallel.
 func CollectDiffs(ctx context.Context, r git.Runner, mainBranch string, branches []resolver.WorktreeInfo) (map[string][]string, error) {
-	diffs := make(map[string][]string)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	var firstErr error
-	sem := make(chan struct{}, 8)
-
-	for _, wt := range branches {
-		wg.Add(1)
-		go func(wt resolver.WorktreeInfo) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			files, err := git.DiffNameOnly(ctx, r, mainBranch, wt.Branch)
-
-			mu.Lock()
-			defer mu.Unlock()
-			if err != nil {
-				if firstErr == nil {
-					firstErr = fmt.Errorf("diff %s: %w", wt.Branch, err)
-				}
-				return
-			}
-			diffs[wt.Branch] = files
-		}(wt)
-	}
-	wg.Wait()
+	return CollectDiffsFrom(ctx, r, func(string) string { return mainBranch }, branches)
+}
 
-	if firstErr != nil {
-		return nil, firstErr
-	}
-	return diffs, nil
+// CollectDiffsFrom is CollectDiffs with a per-branch base: each branch is
+// diffed against baseOf(branch), e.g. its prefix type's base branch.
+func CollectDiffsFrom(ctx context.Context, r git.Runner, baseOf func(branch string) string, branches []resolver.WorktreeInfo) (map[string][]string, error) {
+	return collectDiffs(ctx, r, baseOf, branches, nil)
 }
 
 type pair struct{ i, j int }
 
 // DryMergeAll runs git merge-tree for all unique branch pairs, in parallel.
 func DryMergeAll(ctx context.Context, r git.Runner, branches []resolver.WorktreeInfo) ([]DryMergeResult, error) {
+	return DryMergeAllCached(ctx, r, branches, nil)
+}
+
+// DryMergeAllCached is DryMergeAll, reusing the pairs in cache whose
+// branches have not moved since and adding the rest to it.
+func DryMergeAllCached(ctx context.Context, r git.Runner, branches []resolver.WorktreeInfo, cache *Cache) ([]DryMergeResult, error) {
+	refs := make([]string, len(branches))
+	for i, wt := range branches {
+		refs[i] = wt.Branch
+	}
+	cache.resolve(ctx, r, refs)
+
 	var pairs []pair
 	for i := range branches {
 		for j := i + 1; j < len(branches); j++ {
@@ -149,7 +143,7 @@ func DryMergeAll(ctx context.Context, r git.Runner, branches []resolver.Worktree
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			mt, err := git.MergeTree(ctx, r, branches[p.i].Branch, branches[p.j].Branch)
+			mt, err := cache.mergeTree(ctx, r, branches[p.i].Branch, branches[p.j].Branch)
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -179,3 +173,52 @@ func DryMergeAll(ctx context.Context, r git.Runner, branches []resolver.Worktree
 func SeverityLabel(o FileOverlap) string {
 	return fmt.Sprintf("%s (%d)", o.Severity, len(o.Branches))
 }
+
+// collect runs diff for each branch's worktree, in parallel, keyed by branch.
+func collect[T any](branches []resolver.WorktreeInfo, diff func(wt resolver.WorktreeInfo) (T, error)) (map[string]T, error) {
+	diffs := make(map[string]T)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	sem := make(chan struct{}, 8)
+
+	for _, wt := range branches {
+		wg.Add(1)
+		go func(wt resolver.WorktreeInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			d, err := diff(wt)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("diff %s: %w", wt.Branch, err)
+				}
+				return
+			}
+			diffs[wt.Branch] = d
+		}(wt)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return diffs, nil
+}
+
+// sortOverlaps sorts overlaps high severity first, then alphabetically.
+func sortOverlaps(overlaps []FileOverlap) {
+	slices.SortFunc(overlaps, func(a, b FileOverlap) int {
+		if a.Severity != b.Severity {
+			if a.Severity == SeverityHigh {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.File, b.File)
+	})
+}
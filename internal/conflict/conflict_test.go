@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/lugassawan/rimba/internal/resolver"
@@ -11,15 +12,19 @@ import (
 
 // mockRunner implements git.Runner for testing within the conflict package.
 type mockRunner struct {
-	run func(args ...string) (string, error)
+	run      func(args ...string) (string, error)
+	runInDir func(dir string, args ...string) (string, error)
 }
 
 func (m *mockRunner) Run(_ context.Context, args ...string) (string, error) {
 	return m.run(args...)
 }
 
-func (m *mockRunner) RunInDir(_ context.Context, _ string, _ ...string) (string, error) {
-	return "", nil
+func (m *mockRunner) RunInDir(_ context.Context, dir string, args ...string) (string, error) {
+	if m.runInDir == nil {
+		return "", nil
+	}
+	return m.runInDir(dir, args...)
 }
 
 func TestDetectOverlapsNoOverlap(t *testing.T) {
@@ -210,6 +215,42 @@ func TestCollectDiffsSuccess(t *testing.T) {
 	}
 }
 
+func TestCollectDiffsFromPerBranchBase(t *testing.T) {
+	var mu sync.Mutex
+	ranges := map[string]bool{}
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			mu.Lock()
+			ranges[args[len(args)-1]] = true
+			mu.Unlock()
+			return "shared.go", nil
+		},
+	}
+	branches := []resolver.WorktreeInfo{
+		{Branch: "feature/a", Path: "/wt/a"},
+		{Branch: "hotfix/b", Path: "/wt/b"},
+	}
+	baseOf := func(branch string) string {
+		if strings.HasPrefix(branch, "hotfix/") {
+			return "release/current"
+		}
+		return "main"
+	}
+
+	diffs, err := CollectDiffsFrom(context.Background(), r, baseOf, branches)
+	if err != nil {
+		t.Fatalf("CollectDiffsFrom: %v", err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(diffs))
+	}
+	for _, want := range []string{"main...feature/a", "release/current...hotfix/b"} {
+		if !ranges[want] {
+			t.Errorf("diff ranges = %v, want %q", ranges, want)
+		}
+	}
+}
+
 func TestCollectDiffsEmpty(t *testing.T) {
 	r := &mockRunner{
 		run: func(_ ...string) (string, error) {
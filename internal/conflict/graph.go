@@ -0,0 +1,122 @@
+package conflict
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Graph is the conflict matrix PlanMergeOrder works from, as branches
+// joined by weighted edges.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a branch, with its step in the merge plan.
+type GraphNode struct {
+	Branch string `json:"branch"`
+	// Order is the branch's step in the plan; 0 for an open PR, which
+	// takes no step.
+	Order int `json:"order,omitempty"`
+	PR    *PR `json:"pr,omitempty"`
+}
+
+// GraphEdge joins two branches with the weight of their conflicts, as
+// PlanMerge counts them.
+type GraphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Weight int    `json:"weight"`
+}
+
+// BuildGraph returns the conflict graph of the planned branches in steps
+// and the open PRs in prs, keyed by ref, with pairs covered by dryMerges
+// weighed by their conflicting files. Nodes come in plan order, then the
+// PRs by ref; only pairs that conflict get an edge, and none joins two PRs.
+func BuildGraph(overlaps []FileOverlap, dryMerges []DryMergeResult, steps []MergeStep, prs map[string]PR) Graph {
+	nodes := make([]GraphNode, 0, len(steps)+len(prs))
+	for _, s := range steps {
+		nodes = append(nodes, GraphNode{Branch: s.Branch, Order: s.Order})
+	}
+	for _, ref := range slices.Sorted(maps.Keys(prs)) {
+		pr := prs[ref]
+		nodes = append(nodes, GraphNode{Branch: ref, PR: &pr})
+	}
+
+	idx := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		idx[n.Branch] = i
+	}
+	matrix := conflictMatrix(overlaps, dryMerges, idx, len(nodes))
+
+	edges := make([]GraphEdge, 0)
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			if matrix[i][j] == 0 || (nodes[i].PR != nil && nodes[j].PR != nil) {
+				continue
+			}
+			edges = append(edges, GraphEdge{From: nodes[i].Branch, To: nodes[j].Branch, Weight: matrix[i][j]})
+		}
+	}
+	return Graph{Nodes: nodes, Edges: edges}
+}
+
+// DOT renders the graph in Graphviz DOT, each node labeled by label and
+// prefixed with its plan step.
+func (g Graph) DOT(label func(GraphNode) string) string {
+	var b strings.Builder
+	b.WriteString("graph conflicts {\n")
+	for _, n := range g.Nodes {
+		attrs := ""
+		if n.PR != nil {
+			attrs = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s%s];\n", dotQuote(n.Branch), dotQuote(nodeLabel(n, label)), attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -- %s [label=\"%d\"];\n", dotQuote(e.From), dotQuote(e.To), e.Weight)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, each node labeled by
+// label and prefixed with its plan step. Nodes get positional ids, as
+// branch names are not valid Mermaid ids.
+func (g Graph) Mermaid(label func(GraphNode) string) string {
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, n := range g.Nodes {
+		id := "n" + strconv.Itoa(i)
+		ids[n.Branch] = id
+		open, closing := "[", "]"
+		if n.PR != nil {
+			open, closing = "([", "])"
+		}
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", id, open, mermaidEscape(nodeLabel(n, label)), closing)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s ---|%d| %s\n", ids[e.From], e.Weight, ids[e.To])
+	}
+	return b.String()
+}
+
+// nodeLabel returns label(n), prefixed with n's plan step when it has one.
+func nodeLabel(n GraphNode, label func(GraphNode) string) string {
+	if n.Order == 0 {
+		return label(n)
+	}
+	return fmt.Sprintf("%d. %s", n.Order, label(n))
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,136 @@
+package conflict
+
+import (
+	"strings"
+	"testing"
+)
+
+const prRef = "refs/rimba/prs/12"
+
+func graphOverlaps() []FileOverlap {
+	return []FileOverlap{
+		{File: "shared1.go", Branches: []string{branchA, branchB}},
+		{File: "shared2.go", Branches: []string{branchA, branchB}},
+		{File: "shared3.go", Branches: []string{branchA, prRef}},
+	}
+}
+
+func TestBuildGraph(t *testing.T) {
+	prs := map[string]PR{prRef: {Ref: prRef, Number: 12, Author: "alice"}}
+	branches := []string{branchA, branchB, branchC}
+	steps := PlanMergeOrderAround(graphOverlaps(), branches, []string{prRef})
+
+	g := BuildGraph(graphOverlaps(), nil, steps, prs)
+
+	if len(g.Nodes) != 4 {
+		t.Fatalf("nodes = %+v, want 4", g.Nodes)
+	}
+	if g.Nodes[0].Branch != branchC || g.Nodes[0].Order != 1 {
+		t.Errorf("first node = %+v, want %s at step 1", g.Nodes[0], branchC)
+	}
+	if last := g.Nodes[3]; last.Branch != prRef || last.Order != 0 || last.PR == nil || last.PR.Number != 12 {
+		t.Errorf("last node = %+v, want PR #12 without a step", last)
+	}
+
+	want := map[[2]string]int{{branchB, branchA}: 2, {branchA, prRef}: 1}
+	if len(g.Edges) != len(want) {
+		t.Fatalf("edges = %+v, want %v", g.Edges, want)
+	}
+	for _, e := range g.Edges {
+		if w, ok := want[[2]string{e.From, e.To}]; !ok || w != e.Weight {
+			t.Errorf("unexpected edge %+v", e)
+		}
+	}
+}
+
+func TestBuildGraphSkipsEdgesBetweenPRs(t *testing.T) {
+	other := "refs/rimba/prs/13"
+	prs := map[string]PR{
+		prRef: {Ref: prRef, Number: 12, Author: "alice"},
+		other: {Ref: other, Number: 13, Author: "bob"},
+	}
+	overlaps := []FileOverlap{{File: "x.go", Branches: []string{prRef, other}}}
+
+	g := BuildGraph(overlaps, nil, PlanMergeOrder(overlaps, []string{branchA}), prs)
+
+	if len(g.Nodes) != 3 {
+		t.Errorf("nodes = %+v, want 3", g.Nodes)
+	}
+	if len(g.Edges) != 0 {
+		t.Errorf("edges = %+v, want none", g.Edges)
+	}
+}
+
+func TestBuildGraphHunkWeights(t *testing.T) {
+	overlaps := []FileOverlap{{
+		File:     "x.go",
+		Branches: []string{branchA, branchB},
+		Lines: []LineOverlap{
+			{Branches: []string{branchA, branchB}, Start: 1, End: 2},
+			{Branches: []string{branchA, branchB}, Start: 8, End: 9},
+		},
+		hunks: true,
+	}}
+
+	g := BuildGraph(overlaps, nil, PlanMergeOrder(overlaps, []string{branchA, branchB}), nil)
+
+	if len(g.Edges) != 1 || g.Edges[0].Weight != 2 {
+		t.Errorf("edges = %+v, want one of weight 2", g.Edges)
+	}
+}
+
+func TestGraphDOT(t *testing.T) {
+	prs := map[string]PR{prRef: {Ref: prRef, Number: 12, Author: "alice"}}
+	steps := PlanMergeOrderAround(graphOverlaps(), []string{branchA, branchB}, []string{prRef})
+	g := BuildGraph(graphOverlaps(), nil, steps, prs)
+
+	got := g.DOT(testNodeLabel)
+
+	for _, want := range []string{
+		"graph conflicts {\n",
+		`"feature/b" [label="1. b"];`,
+		`"feature/a" [label="2. a"];`,
+		`"refs/rimba/prs/12" [label="#12 (@alice)", style=dashed];`,
+		`"feature/b" -- "feature/a" [label="2"];`,
+		`"feature/a" -- "refs/rimba/prs/12" [label="1"];`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("DOT missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestGraphDOTEscapesQuotes(t *testing.T) {
+	g := Graph{Nodes: []GraphNode{{Branch: `we"ird`, Order: 1}}}
+
+	got := g.DOT(func(n GraphNode) string { return n.Branch })
+
+	if !strings.Contains(got, `"we\"ird" [label="1. we\"ird"];`) {
+		t.Errorf("DOT did not escape quotes:\n%s", got)
+	}
+}
+
+func TestGraphMermaid(t *testing.T) {
+	prs := map[string]PR{prRef: {Ref: prRef, Number: 12, Author: "alice"}}
+	steps := PlanMergeOrderAround(graphOverlaps(), []string{branchA, branchB}, []string{prRef})
+	g := BuildGraph(graphOverlaps(), nil, steps, prs)
+
+	got := g.Mermaid(testNodeLabel)
+
+	want := "graph LR\n" +
+		"  n0[\"1. b\"]\n" +
+		"  n1[\"2. a\"]\n" +
+		"  n2([\"#12 (@alice)\"])\n" +
+		"  n0 ---|2| n1\n" +
+		"  n1 ---|1| n2\n"
+	if got != want {
+		t.Errorf("Mermaid =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func testNodeLabel(n GraphNode) string {
+	if n.PR != nil {
+		return n.PR.Label()
+	}
+	return strings.TrimPrefix(n.Branch, "feature/")
+}
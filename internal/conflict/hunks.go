@@ -0,0 +1,176 @@
+package conflict
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// LineOverlap is a base-side line range that two branches both change, or
+// change adjacent lines of. Start and End are 0 when either branch changes
+// the file as a whole (binary, mode-only or rename-only changes).
+type LineOverlap struct {
+	Branches []string `json:"branches"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+}
+
+// CollectHunksFrom runs git diff -U0 -M for each branch vs baseOf(branch),
+// in parallel.
+func CollectHunksFrom(ctx context.Context, r git.Runner, baseOf func(branch string) string, branches []resolver.WorktreeInfo) (map[string][]git.FileHunks, error) {
+	return collectHunks(ctx, r, baseOf, branches, Options{})
+}
+
+// DetectHunkOverlaps analyzes a map of branch→changed files with their line
+// ranges and returns files changed in 2+ branches, keyed by base-side path
+// so a rename still meets edits of the original file. Severity is high when
+// two branches change the same or adjacent lines and low when their changes
+// to the file are disjoint. Line numbers only compare between branches
+// diffed from the same merge-base (git.FileHunks.Base); two that forked at
+// different commits overlap on the whole file.
+// This is a pure function with no git dependency.
+func DetectHunkOverlaps(changes map[string][]git.FileHunks) *CheckResult {
+	result := &CheckResult{TotalBranches: len(changes)}
+
+	fileMap := make(map[string]map[string]git.FileHunks)
+	for branch, files := range changes {
+		for _, f := range files {
+			if fileMap[f.Path] == nil {
+				fileMap[f.Path] = make(map[string]git.FileHunks)
+			}
+			fileMap[f.Path][branch] = f
+		}
+	}
+	result.TotalFiles = len(fileMap)
+
+	for file, byBranch := range fileMap {
+		if len(byBranch) < 2 {
+			continue
+		}
+		result.Overlaps = append(result.Overlaps, fileHunkOverlap(file, byBranch))
+	}
+	sortOverlaps(result.Overlaps)
+	return result
+}
+
+// LinesLabel returns a display string for the overlapping lines of a
+// hunk-aware file overlap, e.g. "12-30, 45", "whole file", or "-" when the
+// branches change disjoint lines.
+func LinesLabel(o FileOverlap) string {
+	if len(o.Lines) == 0 {
+		return "-"
+	}
+	labels := make([]string, 0, len(o.Lines))
+	for _, l := range o.Lines {
+		label := fmt.Sprintf("%d-%d", l.Start, l.End)
+		switch {
+		case l.Start == 0:
+			label = "whole file"
+		case l.Start == l.End:
+			label = fmt.Sprint(l.Start)
+		}
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+// fileHunkOverlap builds the overlap of file between the branches in
+// byBranch, comparing every pair's line ranges — or, when the pair's ranges
+// number lines of different merge-bases, counting it a whole-file overlap.
+func fileHunkOverlap(file string, byBranch map[string]git.FileHunks) FileOverlap {
+	o := FileOverlap{File: file, Severity: SeverityLow, hunks: true}
+	for branch, f := range byBranch {
+		o.Branches = append(o.Branches, branch)
+		if f.NewPath != "" {
+			if o.Renames == nil {
+				o.Renames = make(map[string]string)
+			}
+			o.Renames[branch] = f.NewPath
+		}
+	}
+	slices.Sort(o.Branches)
+
+	for a := range o.Branches {
+		for b := a + 1; b < len(o.Branches); b++ {
+			pair := []string{o.Branches[a], o.Branches[b]}
+			x, y := byBranch[pair[0]], byBranch[pair[1]]
+			if x.Base != y.Base {
+				o.Lines = append(o.Lines, LineOverlap{Branches: pair})
+				continue
+			}
+			o.Lines = append(o.Lines, pairOverlaps(pair, x.Hunks, y.Hunks)...)
+		}
+	}
+	if len(o.Lines) > 0 {
+		o.Severity = SeverityHigh
+	}
+	slices.SortFunc(o.Lines, func(x, y LineOverlap) int {
+		return cmp.Or(cmp.Compare(x.Start, y.Start), slices.Compare(x.Branches, y.Branches))
+	})
+	return o
+}
+
+// pairOverlaps returns the merged line ranges where hunks of two branches
+// overlap or touch. A nil side changes the whole file.
+func pairOverlaps(pair []string, a, b []git.LineRange) []LineOverlap {
+	if a == nil || b == nil {
+		return []LineOverlap{{Branches: pair}}
+	}
+	var spans []LineOverlap
+	for _, x := range a {
+		xlo, xhi := lineSpan(x)
+		for _, y := range b {
+			ylo, yhi := lineSpan(y)
+			if xlo <= yhi+1 && ylo <= xhi+1 {
+				lo := min(xlo, ylo)
+				spans = append(spans, LineOverlap{Branches: pair, Start: lo, End: max(lo, xhi, yhi)})
+			}
+		}
+	}
+	slices.SortFunc(spans, func(x, y LineOverlap) int { return cmp.Compare(x.Start, y.Start) })
+
+	var out []LineOverlap
+	for _, s := range spans {
+		if n := len(out); n > 0 && s.Start <= out[n-1].End+1 {
+			out[n-1].End = max(out[n-1].End, s.End)
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// lineSpan returns the first and last base-side line a hunk changes. An
+// insertion after line n changes no line; it spans (n+1, n), so it touches
+// both lines it sits between.
+func lineSpan(r git.LineRange) (lo, hi int) {
+	if r.Count == 0 {
+		return r.Start + 1, r.Start
+	}
+	return r.Start, r.Start + r.Count - 1
+}
+
+// diffHunksFromMergeBase is git.DiffHunks with each file's Base set to the
+// merge-base of base and branch, which the three-dot diff numbers lines of.
+func diffHunksFromMergeBase(ctx context.Context, r git.Runner, base, branch string) ([]git.FileHunks, error) {
+	files, err := git.DiffHunks(ctx, r, base, branch)
+	if err != nil || len(files) == 0 {
+		return files, err
+	}
+	mb, err := git.MergeBase(ctx, r, base, branch)
+	if err != nil {
+		return nil, fmt.Errorf("merge-base of %s and %s: %w", base, branch, err)
+	}
+	mb = strings.TrimSpace(mb)
+	for i := range files {
+		files[i].Base = mb
+	}
+	return files, nil
+}
@@ -0,0 +1,157 @@
+package conflict
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+func hunks(path string, ranges ...git.LineRange) git.FileHunks {
+	return git.FileHunks{Path: path, Hunks: ranges}
+}
+
+func TestDetectHunkOverlapsDisjointLinesAreLow(t *testing.T) {
+	result := DetectHunkOverlaps(map[string][]git.FileHunks{
+		branchA: {hunks("big.go", git.LineRange{Start: 10, Count: 5})},
+		branchB: {hunks("big.go", git.LineRange{Start: 2900, Count: 3})},
+	})
+	if len(result.Overlaps) != 1 {
+		t.Fatalf("overlaps = %+v, want big.go", result.Overlaps)
+	}
+	o := result.Overlaps[0]
+	if o.Severity != SeverityLow || len(o.Lines) != 0 {
+		t.Errorf("overlap = %+v, want low with no overlapping lines", o)
+	}
+	if got := LinesLabel(o); got != "-" {
+		t.Errorf("LinesLabel = %q, want -", got)
+	}
+}
+
+func TestDetectHunkOverlapsOverlappingAndAdjacentLines(t *testing.T) {
+	result := DetectHunkOverlaps(map[string][]git.FileHunks{
+		branchA: {hunks("big.go", git.LineRange{Start: 10, Count: 5}, git.LineRange{Start: 40, Count: 1})},
+		branchB: {hunks("big.go", git.LineRange{Start: 12, Count: 10}, git.LineRange{Start: 40, Count: 0})},
+	})
+	o := result.Overlaps[0]
+	want := []LineOverlap{
+		{Branches: []string{branchA, branchB}, Start: 10, End: 21},
+		{Branches: []string{branchA, branchB}, Start: 40, End: 40},
+	}
+	if o.Severity != SeverityHigh || !slices.EqualFunc(o.Lines, want, lineOverlapEqual) {
+		t.Errorf("overlap = %+v, want high over %+v", o, want)
+	}
+	if got := LinesLabel(o); got != "10-21, 40" {
+		t.Errorf("LinesLabel = %q", got)
+	}
+}
+
+func TestDetectHunkOverlapsFollowsRenames(t *testing.T) {
+	renamed := hunks("old.go", git.LineRange{Start: 5, Count: 1})
+	renamed.NewPath = "new.go"
+	result := DetectHunkOverlaps(map[string][]git.FileHunks{
+		branchA: {renamed},
+		branchB: {hunks("old.go", git.LineRange{Start: 5, Count: 2})},
+	})
+	if len(result.Overlaps) != 1 || result.TotalFiles != 1 {
+		t.Fatalf("result = %+v, want one overlap on old.go", result)
+	}
+	o := result.Overlaps[0]
+	if o.File != "old.go" || o.Renames[branchA] != "new.go" || o.Severity != SeverityHigh {
+		t.Errorf("overlap = %+v, want old.go renamed by feature/a", o)
+	}
+}
+
+func TestDetectHunkOverlapsWholeFileChange(t *testing.T) {
+	result := DetectHunkOverlaps(map[string][]git.FileHunks{
+		branchA: {hunks("logo.png")},
+		branchB: {hunks("logo.png", git.LineRange{Start: 1, Count: 1})},
+	})
+	o := result.Overlaps[0]
+	if o.Severity != SeverityHigh || len(o.Lines) != 1 || LinesLabel(o) != "whole file" {
+		t.Errorf("overlap = %+v, want a whole-file overlap", o)
+	}
+}
+
+func TestPlanMergeOrderWeighsHunkOverlaps(t *testing.T) {
+	// a and b edit disjoint ends of shared.go; b and c edit the same lines.
+	result := DetectHunkOverlaps(map[string][]git.FileHunks{
+		branchA: {hunks("shared.go", git.LineRange{Start: 1, Count: 2})},
+		branchB: {hunks("shared.go", git.LineRange{Start: 500, Count: 2})},
+		branchC: {hunks("shared.go", git.LineRange{Start: 501, Count: 4})},
+	})
+	steps := PlanMergeOrder(result.Overlaps, []string{branchA, branchB, branchC})
+	if steps[0].Branch != branchA || steps[0].Conflicts != 0 {
+		t.Errorf("first step = %+v, want feature/a with no conflicts", steps[0])
+	}
+	if steps[1].Conflicts != 1 {
+		t.Errorf("second step = %+v, want one overlapping range", steps[1])
+	}
+}
+
+func TestCollectHunksFrom(t *testing.T) {
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == git.CmdMergeBase {
+				return "fork\n", nil
+			}
+			if !slices.Contains(args, "-U0") || !slices.Contains(args, "-M") {
+				t.Errorf("args = %v, want -U0 -M", args)
+			}
+			if strings.HasSuffix(args[len(args)-1], "feature/a") {
+				return "diff --git a/x.go b/x.go\n@@ -3,2 +3 @@", nil
+			}
+			return "", nil
+		},
+	}
+	branches := []resolver.WorktreeInfo{{Branch: branchA}, {Branch: branchB}}
+	result, err := Analyze(context.Background(), r, func(string) string { return "main" }, branches, Options{Hunks: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if result.TotalFiles != 1 || result.TotalBranches != 2 || len(result.Overlaps) != 0 {
+		t.Errorf("result = %+v, want x.go changed on feature/a only", result)
+	}
+}
+
+func TestDetectHunkOverlapsDifferentMergeBases(t *testing.T) {
+	// feature/b forked later: its line 500 need not be feature/a's.
+	a := hunks("big.go", git.LineRange{Start: 10, Count: 5})
+	a.Base = "fork-a"
+	b := hunks("big.go", git.LineRange{Start: 500, Count: 3})
+	b.Base = "fork-b"
+	o := DetectHunkOverlaps(map[string][]git.FileHunks{branchA: {a}, branchB: {b}}).Overlaps[0]
+	if o.Severity != SeverityHigh || LinesLabel(o) != "whole file" {
+		t.Errorf("overlap = %+v, want a whole-file overlap", o)
+	}
+}
+
+func TestCollectHunksFromBranchesForkedAtDifferentCommits(t *testing.T) {
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			branch := args[len(args)-1]
+			if args[0] == git.CmdMergeBase {
+				return "fork-" + branch + "\n", nil
+			}
+			if strings.HasSuffix(branch, branchA) {
+				return "diff --git a/shared.go b/shared.go\n@@ -10,2 +10,2 @@", nil
+			}
+			return "diff --git a/shared.go b/shared.go\n@@ -500 +500 @@", nil
+		},
+	}
+	branches := []resolver.WorktreeInfo{{Branch: branchA}, {Branch: branchB}}
+	result, err := Analyze(context.Background(), r, func(string) string { return "main" }, branches, Options{Hunks: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(result.Overlaps) != 1 || LinesLabel(result.Overlaps[0]) != "whole file" {
+		t.Errorf("overlaps = %+v, want shared.go overlapping as a whole file", result.Overlaps)
+	}
+}
+
+func lineOverlapEqual(a, b LineOverlap) bool {
+	return a.Start == b.Start && a.End == b.End && slices.Equal(a.Branches, b.Branches)
+}
@@ -0,0 +1,213 @@
+package conflict
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CodeOwnersPaths are where a CODEOWNERS file is looked up, relative to the
+// repository root, in the order GitHub does: the first found is used.
+var CodeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners maps paths to their owners, as a CODEOWNERS file does.
+type CodeOwners struct {
+	rules []ownerRule
+}
+
+type ownerRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// ParseCodeOwners parses a CODEOWNERS file. Lines it cannot use — blank,
+// comments, negations (which GitHub does not support either) — are skipped.
+func ParseCodeOwners(data string) CodeOwners {
+	var c CodeOwners
+	for line := range strings.SplitSeq(data, "\n") {
+		fields := strings.Fields(line)
+		if i := slices.IndexFunc(fields, func(f string) bool { return strings.HasPrefix(f, "#") }); i >= 0 {
+			fields = fields[:i]
+		}
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+		c.rules = append(c.rules, ownerRule{pattern: ownerPattern(fields[0]), owners: fields[1:]})
+	}
+	return c
+}
+
+// Empty reports whether c has no rules, e.g. when no CODEOWNERS file was
+// found.
+func (c CodeOwners) Empty() bool {
+	return len(c.rules) == 0
+}
+
+// Owners returns the owners of file. As in GitHub, the last matching rule
+// wins, and a matching rule without owners leaves file unowned.
+func (c CodeOwners) Owners(file string) []string {
+	for _, r := range slices.Backward(c.rules) {
+		if !r.pattern.MatchString(file) {
+			continue
+		}
+		if len(r.owners) == 0 {
+			return nil
+		}
+		return r.owners
+	}
+	return nil
+}
+
+// AttachOwners records on each overlap the owners of its file.
+func AttachOwners(result *CheckResult, owners CodeOwners) {
+	for i := range result.Overlaps {
+		result.Overlaps[i].Owners = owners.Owners(result.Overlaps[i].File)
+	}
+}
+
+// OwnedOverlaps returns the overlaps whose file owner owns (see SameOwner).
+func OwnedOverlaps(overlaps []FileOverlap, owner string) []FileOverlap {
+	return slices.DeleteFunc(slices.Clone(overlaps), func(o FileOverlap) bool {
+		return !ownedBy(o.Owners, owner)
+	})
+}
+
+// OwnedDryMerges narrows conflicting dry merges to the files owner owns,
+// dropping those with none. Clean merges are kept as they are.
+func OwnedDryMerges(results []DryMergeResult, owners CodeOwners, owner string) []DryMergeResult {
+	owned := make([]DryMergeResult, 0, len(results))
+	for _, d := range results {
+		if d.HasConflicts {
+			d.ConflictFiles = slices.DeleteFunc(slices.Clone(d.ConflictFiles), func(f string) bool {
+				return !ownedBy(owners.Owners(f), owner)
+			})
+			if len(d.ConflictFiles) == 0 {
+				continue
+			}
+		}
+		owned = append(owned, d)
+	}
+	return owned
+}
+
+// SameOwner reports whether a and b name the same owner, ignoring case and
+// a leading @: "@org/team", "org/team" and "@Org/Team" are all one.
+func SameOwner(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "@"), strings.TrimPrefix(b, "@"))
+}
+
+// DirHeat is a directory's share of the overlaps, its subdirectories'
+// included.
+type DirHeat struct {
+	Dir      string   `json:"dir"`
+	Overlaps int      `json:"overlaps"`
+	High     int      `json:"high"`
+	Branches []string `json:"branches"`
+}
+
+// Heatmap rolls the overlaps up by directory: each overlap counts toward
+// every directory above its file, and files at the repository root toward
+// ".". The hottest directories come first — most overlaps, then most high
+// severity ones — then alphabetically.
+func Heatmap(overlaps []FileOverlap) []DirHeat {
+	byDir := make(map[string]*DirHeat)
+	for _, o := range overlaps {
+		for _, dir := range parentDirs(o.File) {
+			h := byDir[dir]
+			if h == nil {
+				h = &DirHeat{Dir: dir}
+				byDir[dir] = h
+			}
+			h.add(o)
+		}
+	}
+
+	heat := make([]DirHeat, 0, len(byDir))
+	for _, h := range byDir {
+		slices.Sort(h.Branches)
+		heat = append(heat, *h)
+	}
+	slices.SortFunc(heat, func(a, b DirHeat) int {
+		if a.Overlaps != b.Overlaps {
+			return b.Overlaps - a.Overlaps
+		}
+		if a.High != b.High {
+			return b.High - a.High
+		}
+		return strings.Compare(a.Dir, b.Dir)
+	})
+	return heat
+}
+
+// add counts o toward h.
+func (h *DirHeat) add(o FileOverlap) {
+	h.Overlaps++
+	if o.Severity == SeverityHigh {
+		h.High++
+	}
+	for _, b := range o.Branches {
+		if !slices.Contains(h.Branches, b) {
+			h.Branches = append(h.Branches, b)
+		}
+	}
+}
+
+// parentDirs returns the directories above file, innermost first, or "."
+// for a file at the root.
+func parentDirs(file string) []string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return []string{"."}
+	}
+	var dirs []string
+	for ; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func ownedBy(owners []string, owner string) bool {
+	return slices.ContainsFunc(owners, func(o string) bool { return SameOwner(o, owner) })
+}
+
+// ownerPattern compiles a CODEOWNERS pattern, which follows .gitignore
+// rules: a pattern with a slash before its end is anchored to the root,
+// one without matches at any depth, and one naming a directory matches
+// everything under it. As in GitHub, "dir/*" matches only dir's own files.
+func ownerPattern(p string) *regexp.Regexp {
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	dirOnly := strings.HasSuffix(p, "/")
+	shallow := strings.HasSuffix(p, "/*")
+	p = strings.Trim(p, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	switch {
+	case dirOnly:
+		b.WriteString("/.*")
+	case !shallow:
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,133 @@
+package conflict
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testCodeOwners = `# Default owners
+*                 @org/core
+
+*.md              @org/docs   # inline comment
+/build/logs/      @ops
+docs/*            docs@example.com
+**/migrations     @org/db
+apps/             @org/apps
+/internal/gen
+!vendor/          @nobody
+`
+
+func TestCodeOwnersOwners(t *testing.T) {
+	c := ParseCodeOwners(testCodeOwners)
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"README.md", []string{"@org/docs"}},
+		{"pkg/guide.md", []string{"@org/docs"}},
+		{"build/logs/today.log", []string{"@ops"}},
+		{"src/build/logs/today.log", []string{"@org/core"}},
+		{"docs/getting-started.md", []string{"docs@example.com"}},
+		{"docs/build-app/troubleshooting.go", []string{"@org/core"}},
+		{"migrations/001.sql", []string{"@org/db"}},
+		{"db/migrations/001.sql", []string{"@org/db"}},
+		{"apps/web/main.go", []string{"@org/apps"}},
+		{"nested/apps/main.go", []string{"@org/apps"}},
+		{"apps.go", []string{"@org/core"}},
+		{"internal/gen/api.go", nil},
+		{"vendor/lib.go", []string{"@org/core"}},
+	}
+	for _, tt := range tests {
+		if got := c.Owners(tt.file); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestParseCodeOwnersEmpty(t *testing.T) {
+	c := ParseCodeOwners("# nothing here\n\n")
+
+	if !c.Empty() {
+		t.Error("Empty() = false, want true")
+	}
+	if got := c.Owners("main.go"); got != nil {
+		t.Errorf("Owners = %v, want none", got)
+	}
+}
+
+func TestAttachOwnersAndOwnedOverlaps(t *testing.T) {
+	result := &CheckResult{Overlaps: []FileOverlap{
+		{File: "main.go", Branches: []string{branchA, branchB}},
+		{File: "apps/web/app.go", Branches: []string{branchA, branchB}},
+	}}
+
+	AttachOwners(result, ParseCodeOwners(testCodeOwners))
+
+	if got := result.Overlaps[1].Owners; !reflect.DeepEqual(got, []string{"@org/apps"}) {
+		t.Errorf("owners = %v, want [@org/apps]", got)
+	}
+	owned := OwnedOverlaps(result.Overlaps, "Org/Apps")
+	if len(owned) != 1 || owned[0].File != "apps/web/app.go" {
+		t.Errorf("OwnedOverlaps = %+v, want apps/web/app.go only", owned)
+	}
+	if len(result.Overlaps) != 2 {
+		t.Errorf("OwnedOverlaps modified its input: %+v", result.Overlaps)
+	}
+}
+
+func TestOwnedDryMerges(t *testing.T) {
+	results := []DryMergeResult{
+		{Branch1: branchA, Branch2: branchB, HasConflicts: true, ConflictFiles: []string{"main.go", "apps/app.go"}},
+		{Branch1: branchA, Branch2: branchC, HasConflicts: true, ConflictFiles: []string{"main.go"}},
+		{Branch1: branchB, Branch2: branchC},
+	}
+
+	got := OwnedDryMerges(results, ParseCodeOwners(testCodeOwners), "@org/apps")
+
+	if len(got) != 2 {
+		t.Fatalf("OwnedDryMerges = %+v, want the a/c pair dropped", got)
+	}
+	if !reflect.DeepEqual(got[0].ConflictFiles, []string{"apps/app.go"}) {
+		t.Errorf("conflict files = %v, want [apps/app.go]", got[0].ConflictFiles)
+	}
+	if got[1].HasConflicts || got[1].Branch2 != branchC {
+		t.Errorf("clean merge = %+v, want it kept", got[1])
+	}
+	if len(results[0].ConflictFiles) != 2 {
+		t.Errorf("OwnedDryMerges modified its input: %+v", results[0])
+	}
+}
+
+func TestSameOwner(t *testing.T) {
+	if !SameOwner("@Org/Team", "org/team") {
+		t.Error(`SameOwner("@Org/Team", "org/team") = false, want true`)
+	}
+	if SameOwner("@org/team", "@org/other") {
+		t.Error(`SameOwner("@org/team", "@org/other") = true, want false`)
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	overlaps := []FileOverlap{
+		{File: "internal/conflict/a.go", Branches: []string{branchB, branchA}, Severity: SeverityHigh},
+		{File: "internal/conflict/b.go", Branches: []string{branchA, branchC}, Severity: SeverityLow},
+		{File: "internal/git/c.go", Branches: []string{branchA, branchB}, Severity: SeverityLow},
+		{File: "cmd/x.go", Branches: []string{branchA, branchB}, Severity: SeverityHigh},
+		{File: "go.mod", Branches: []string{branchA, branchB}, Severity: SeverityLow},
+	}
+
+	got := Heatmap(overlaps)
+
+	want := []DirHeat{
+		{Dir: "internal", Overlaps: 3, High: 1, Branches: []string{branchA, branchB, branchC}},
+		{Dir: "internal/conflict", Overlaps: 2, High: 1, Branches: []string{branchA, branchB, branchC}},
+		{Dir: "cmd", Overlaps: 1, High: 1, Branches: []string{branchA, branchB}},
+		{Dir: ".", Overlaps: 1, Branches: []string{branchA, branchB}},
+		{Dir: "internal/git", Overlaps: 1, Branches: []string{branchA, branchB}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Heatmap =\n%+v\nwant\n%+v", got, want)
+	}
+}
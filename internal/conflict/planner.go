@@ -1,95 +1,450 @@
 package conflict
 
-import "slices"
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+)
+
+// MaxExactBranches bounds the exhaustive search of PlanMerge, which grows
+// as 2^n; larger plans fall back to a greedy order.
+const MaxExactBranches = 16
 
 // MergeStep represents one step in the recommended merge order.
 type MergeStep struct {
-	Order     int
-	Branch    string
+	Order  int
+	Branch string
+	// Conflicts weighs the branch's conflicts with the branches merged
+	// after it and those outside the plan, listed in Against.
 	Conflicts int
+	Against   []Weight
+	// Pinned is set for a branch pinned first (PlanOptions.First).
+	Pinned bool
+	// After are the constraints that make the branch wait for others.
+	After []Constraint
+}
+
+// Weight is the conflict weight against one branch.
+type Weight struct {
+	Branch string `json:"branch"`
+	Weight int    `json:"weight"`
+}
+
+// Constraint makes After merge after Before. Reason says why, e.g.
+// "stacked".
+type Constraint struct {
+	Before string
+	After  string
+	Reason string
+}
+
+// PlanOptions refine a merge plan.
+type PlanOptions struct {
+	// Others are branches outside the plan, such as open PRs: a branch's
+	// conflicts with them count at every step, but they take no step.
+	Others []string
+	// DryMerges weigh each pair of branches they cover by the files git
+	// merge-tree found conflicting, in place of their overlaps.
+	DryMerges []DryMergeResult
+	// Constraints are ordering constraints the plan must keep.
+	Constraints []Constraint
+	// First are branches pinned to lead the plan, in this order.
+	First []string
 }
 
-// PlanMergeOrder computes a greedy merge order that minimizes conflicts at each step.
-// It builds an NxN conflict matrix from overlaps, then repeatedly picks the branch
-// with the fewest total conflicts against remaining branches.
-// This is a pure function with no git dependency.
+// Plan is a recommended merge order.
+type Plan struct {
+	Steps []MergeStep
+	// Exact reports that no other order costs less; plans of more than
+	// MaxExactBranches branches are greedy.
+	Exact bool
+}
+
+// PlanMergeOrder computes a merge order that minimizes conflicts. It builds
+// an NxN conflict matrix from overlaps and orders branches as PlanMerge
+// does. This is a pure function with no git dependency.
 func PlanMergeOrder(overlaps []FileOverlap, branches []string) []MergeStep {
+	return PlanMergeOrderAround(overlaps, branches, nil)
+}
+
+// PlanMergeOrderAround is PlanMergeOrder with branches that land outside
+// the plan, such as open PRs: a branch's conflicts with them count at every
+// step, but they take no step themselves.
+func PlanMergeOrderAround(overlaps []FileOverlap, branches, others []string) []MergeStep {
+	plan, _ := PlanMerge(overlaps, branches, PlanOptions{Others: others}) // without constraints there is always an order
+	return plan.Steps
+}
+
+// PlanMerge orders branches to minimize the cost of merging them: each
+// step's conflicts, weighed once for every branch still waiting to merge —
+// itself included — as a conflicting merge early on churns every branch
+// behind it. Up to MaxExactBranches branches are searched exhaustively,
+// more are ordered greedily, picking the branch with the fewest conflicts
+// left at each step. Ties go to the alphabetically first branch.
+//
+// Constraints and pins naming branches outside the plan are ignored; ones
+// that contradict each other are an error.
+func PlanMerge(overlaps []FileOverlap, branches []string, opts PlanOptions) (Plan, error) {
 	if len(branches) == 0 {
-		return nil
+		return Plan{Exact: true}, nil
 	}
 
-	idx := make(map[string]int, len(branches))
-	for i, b := range branches {
+	p := newPlanner(overlaps, branches, opts)
+	if err := p.checkCycles(); err != nil {
+		return Plan{}, err
+	}
+
+	exact := len(p.branches) <= MaxExactBranches
+	var order []int
+	if exact {
+		order = p.exactOrder()
+	} else {
+		order = p.greedyOrder()
+	}
+	return Plan{Steps: p.steps(order), Exact: exact}, nil
+}
+
+// Explain says why the step is where it is, naming branches with label.
+func (s MergeStep) Explain(label func(branch string) string) string {
+	var parts []string
+	if s.Pinned {
+		parts = append(parts, "pinned first")
+	}
+	for _, c := range s.After {
+		parts = append(parts, fmt.Sprintf("after %s (%s)", label(c.Before), c.Reason))
+	}
+	if len(s.Against) == 0 {
+		parts = append(parts, "no conflicts with later branches")
+	} else {
+		against := make([]string, len(s.Against))
+		for i, w := range s.Against {
+			against[i] = fmt.Sprintf("%s (%d)", label(w.Branch), w.Weight)
+		}
+		parts = append(parts, "conflicts with "+strings.Join(against, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// planner holds a plan's branches, sorted so ties break alphabetically,
+// and their conflict matrix, whose rows past len(branches) are the
+// branches outside the plan.
+type planner struct {
+	branches []string
+	others   []string
+	matrix   [][]int
+	outside  []int          // each branch's total conflicts with the others
+	preds    [][]int        // branches each must merge after
+	after    [][]Constraint // the constraints behind preds, bar pins
+	pinned   []bool
+}
+
+func newPlanner(overlaps []FileOverlap, branches []string, opts PlanOptions) *planner {
+	sorted := slices.Clone(branches)
+	slices.Sort(sorted)
+	n := len(sorted)
+
+	all := append(slices.Clone(sorted), opts.Others...)
+	idx := make(map[string]int, len(all))
+	for i, b := range all {
 		idx[b] = i
 	}
 
-	matrix := buildConflictMatrix(overlaps, idx, len(branches))
-	return selectMergeOrder(matrix, branches)
+	p := &planner{
+		branches: sorted,
+		others:   opts.Others,
+		matrix:   conflictMatrix(overlaps, opts.DryMerges, idx, len(all)),
+		outside:  make([]int, n),
+		preds:    make([][]int, n),
+		after:    make([][]Constraint, n),
+		pinned:   make([]bool, n),
+	}
+	for i := range n {
+		for j := n; j < len(all); j++ {
+			p.outside[i] += p.matrix[i][j]
+		}
+	}
+	p.addConstraints(opts, idx)
+	return p
 }
 
-// buildConflictMatrix builds an NxN matrix counting file overlaps between branch pairs.
-func buildConflictMatrix(overlaps []FileOverlap, idx map[string]int, n int) [][]int {
-	matrix := make([][]int, n)
+// addConstraints records the predecessors of each branch: those of
+// opts.Constraints, and for pins, the earlier pins and — for every
+// unpinned branch — all of them.
+func (p *planner) addConstraints(opts PlanOptions, idx map[string]int) {
+	n := len(p.branches)
+	inPlan := func(b string) (int, bool) {
+		i, ok := idx[b]
+		return i, ok && i < n
+	}
+	for _, c := range opts.Constraints {
+		before, okB := inPlan(c.Before)
+		after, okA := inPlan(c.After)
+		if okB && okA && before != after {
+			p.preds[after] = append(p.preds[after], before)
+			p.after[after] = append(p.after[after], c)
+		}
+	}
+
+	var pins []int
+	for _, b := range opts.First {
+		if i, ok := inPlan(b); ok && !p.pinned[i] {
+			p.pinned[i] = true
+			p.preds[i] = append(p.preds[i], pins...)
+			pins = append(pins, i)
+		}
+	}
 	for i := range n {
-		matrix[i] = make([]int, n)
+		if !p.pinned[i] {
+			p.preds[i] = append(p.preds[i], pins...)
+		}
 	}
+}
 
-	for _, o := range overlaps {
-		for a := range len(o.Branches) {
-			for b := a + 1; b < len(o.Branches); b++ {
-				ia, okA := idx[o.Branches[a]]
-				ib, okB := idx[o.Branches[b]]
-				if okA && okB {
-					matrix[ia][ib]++
-					matrix[ib][ia]++
-				}
+// checkCycles reports constraints that no order can keep.
+func (p *planner) checkCycles() error {
+	stuck := p.unorderable()
+	if len(stuck) == 0 {
+		return nil
+	}
+	names := make([]string, len(stuck))
+	for i, b := range stuck {
+		names[i] = p.branches[b]
+	}
+	return errhint.WithFix(
+		fmt.Errorf("merge order constraints form a cycle among %s", strings.Join(names, ", ")),
+		"a pinned branch cannot wait for an unpinned one: pin the branches it is stacked on too, before it",
+	)
+}
+
+// unorderable returns the branches on a cycle of constraints: those left
+// once every branch that can merge has, bar those only waiting on them.
+func (p *planner) unorderable() []int {
+	merged := make([]bool, len(p.branches))
+	for progress := true; progress; {
+		progress = false
+		for i := range p.branches {
+			if !merged[i] && p.ready(i, merged) {
+				merged[i] = true
+				progress = true
 			}
 		}
 	}
-	return matrix
+
+	// A stuck branch no other stuck branch waits for only waits itself.
+	for progress := true; progress; {
+		progress = false
+		for i := range p.branches {
+			if !merged[i] && !p.awaited(i, merged) {
+				merged[i] = true
+				progress = true
+			}
+		}
+	}
+
+	var stuck []int
+	for i := range p.branches {
+		if !merged[i] {
+			stuck = append(stuck, i)
+		}
+	}
+	return stuck
 }
 
-// selectMergeOrder greedily picks branches with fewest conflicts against remaining branches.
-func selectMergeOrder(matrix [][]int, branches []string) []MergeStep {
-	n := len(branches)
-	remaining := make([]int, n)
-	for i := range n {
-		remaining[i] = i
+// awaited reports whether an unmerged branch waits for branch i.
+func (p *planner) awaited(i int, merged []bool) bool {
+	for j, preds := range p.preds {
+		if !merged[j] && slices.Contains(preds, i) {
+			return true
+		}
 	}
+	return false
+}
 
-	steps := make([]MergeStep, 0, n)
-	for order := 1; len(remaining) > 0; order++ {
-		bestIdx := 0
-		bestConflicts := totalConflicts(matrix, remaining[0], remaining)
+// ready reports whether every predecessor of branch i is merged.
+func (p *planner) ready(i int, merged []bool) bool {
+	for _, pred := range p.preds[i] {
+		if !merged[pred] {
+			return false
+		}
+	}
+	return true
+}
 
-		for i := 1; i < len(remaining); i++ {
-			c := totalConflicts(matrix, remaining[i], remaining)
-			if c < bestConflicts || (c == bestConflicts && branches[remaining[i]] < branches[remaining[bestIdx]]) {
-				bestIdx = i
-				bestConflicts = c
+// stepConflicts totals branch i's conflicts with the branches remaining
+// reports and those outside the plan.
+func (p *planner) stepConflicts(i int, remaining func(j int) bool) int {
+	total := p.outside[i]
+	for j := range p.branches {
+		if j != i && remaining(j) {
+			total += p.matrix[i][j]
+		}
+	}
+	return total
+}
+
+// exactOrder searches every order that keeps the constraints: cost[s] is
+// the least cost of merging the set s of remaining branches, built up from
+// smaller sets, and the order is read back from the full set, taking the
+// first branch that achieves it.
+func (p *planner) exactOrder() []int {
+	n := len(p.branches)
+	full := 1<<n - 1
+	predMask := make([]int, n)
+	for i, preds := range p.preds {
+		for _, pred := range preds {
+			predMask[i] |= 1 << pred
+		}
+	}
+
+	// stepCost is the cost of merging i next, with set remaining.
+	stepCost := func(i, set int) int {
+		return bits.OnesCount(uint(set)) * p.stepConflicts(i, func(j int) bool { return set&(1<<j) != 0 })
+	}
+	// next lists the branches of set that can merge next: their
+	// predecessors have all left it.
+	next := func(set int, yield func(i, rest int)) {
+		for i := range n {
+			if set&(1<<i) != 0 && predMask[i]&set == 0 {
+				yield(i, set&^(1<<i))
 			}
 		}
+	}
 
-		picked := remaining[bestIdx]
-		steps = append(steps, MergeStep{
-			Order:     order,
-			Branch:    branches[picked],
-			Conflicts: bestConflicts,
+	cost := make([]int, full+1)
+	for set := 1; set <= full; set++ {
+		cost[set] = -1
+		next(set, func(i, rest int) {
+			if c := stepCost(i, set) + cost[rest]; cost[rest] >= 0 && (cost[set] < 0 || c < cost[set]) {
+				cost[set] = c
+			}
 		})
+	}
 
-		remaining = slices.Delete(remaining, bestIdx, bestIdx+1)
+	order := make([]int, 0, n)
+	for set := full; set != 0; {
+		picked := -1
+		next(set, func(i, rest int) {
+			if picked < 0 && cost[rest] >= 0 && stepCost(i, set)+cost[rest] == cost[set] {
+				picked = i
+			}
+		})
+		order = append(order, picked)
+		set &^= 1 << picked
 	}
+	return order
+}
+
+// greedyOrder repeatedly picks, among the branches whose predecessors have
+// merged, the one with the fewest conflicts left.
+func (p *planner) greedyOrder() []int {
+	n := len(p.branches)
+	merged := make([]bool, n)
+	remaining := func(j int) bool { return !merged[j] }
+
+	order := make([]int, 0, n)
+	for len(order) < n {
+		best, bestConflicts := -1, 0
+		for i := range n {
+			if merged[i] || !p.ready(i, merged) {
+				continue
+			}
+			if c := p.stepConflicts(i, remaining); best < 0 || c < bestConflicts {
+				best, bestConflicts = i, c
+			}
+		}
+		merged[best] = true
+		order = append(order, best)
+	}
+	return order
+}
+
+// steps describes order as merge steps.
+func (p *planner) steps(order []int) []MergeStep {
+	merged := make([]bool, len(p.branches))
+	steps := make([]MergeStep, 0, len(order))
+	for k, i := range order {
+		merged[i] = true
+		var against []Weight
+		for j, b := range p.branches {
+			if !merged[j] && p.matrix[i][j] > 0 {
+				against = append(against, Weight{Branch: b, Weight: p.matrix[i][j]})
+			}
+		}
+		for j, b := range p.others {
+			if w := p.matrix[i][len(p.branches)+j]; w > 0 {
+				against = append(against, Weight{Branch: b, Weight: w})
+			}
+		}
+		slices.SortStableFunc(against, func(a, b Weight) int { return b.Weight - a.Weight })
 
+		conflicts := 0
+		for _, w := range against {
+			conflicts += w.Weight
+		}
+		steps = append(steps, MergeStep{
+			Order:     k + 1,
+			Branch:    p.branches[i],
+			Conflicts: conflicts,
+			Against:   against,
+			Pinned:    p.pinned[i],
+			After:     p.after[i],
+		})
+	}
 	return steps
 }
 
-// totalConflicts computes the sum of conflicts between branchIdx and all other remaining branches.
-func totalConflicts(matrix [][]int, branchIdx int, remaining []int) int {
-	total := 0
-	for _, r := range remaining {
-		if r != branchIdx {
-			total += matrix[branchIdx][r]
+// conflictMatrix builds an NxN matrix counting overlaps between branch
+// pairs: one per shared file, or, for a hunk-aware overlap, one per line
+// range both branches change — so disjoint edits of a file weigh nothing.
+// A pair covered by dryMerges weighs its conflicting files instead.
+func conflictMatrix(overlaps []FileOverlap, dryMerges []DryMergeResult, idx map[string]int, n int) [][]int {
+	matrix := buildConflictMatrix(overlaps, idx, n)
+	for _, d := range dryMerges {
+		ia, okA := idx[d.Branch1]
+		ib, okB := idx[d.Branch2]
+		if !okA || !okB {
+			continue
 		}
+		w := 0
+		if d.HasConflicts {
+			w = max(len(d.ConflictFiles), 1)
+		}
+		matrix[ia][ib], matrix[ib][ia] = w, w
 	}
-	return total
+	return matrix
+}
+
+// buildConflictMatrix builds an NxN matrix counting overlaps between branch
+// pairs from overlaps alone.
+func buildConflictMatrix(overlaps []FileOverlap, idx map[string]int, n int) [][]int {
+	matrix := make([][]int, n)
+	for i := range n {
+		matrix[i] = make([]int, n)
+	}
+	add := func(a, b string) {
+		ia, okA := idx[a]
+		ib, okB := idx[b]
+		if okA && okB {
+			matrix[ia][ib]++
+			matrix[ib][ia]++
+		}
+	}
+
+	for _, o := range overlaps {
+		if o.hunks {
+			for _, l := range o.Lines {
+				add(l.Branches[0], l.Branches[1])
+			}
+			continue
+		}
+		for a := range len(o.Branches) {
+			for b := a + 1; b < len(o.Branches); b++ {
+				add(o.Branches[a], o.Branches[b])
+			}
+		}
+	}
+	return matrix
 }
@@ -1,6 +1,10 @@
 package conflict
 
 import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -110,3 +114,252 @@ func TestPlanMergeOrderSequentialOrdering(t *testing.T) {
 		}
 	}
 }
+
+func TestPlanMergeOrderAroundCountsOutsideBranches(t *testing.T) {
+	const pr = "refs/rimba/prs/7"
+	// a and b share one file, a tie broken alphabetically — but a also
+	// overlaps an open PR twice, so b lands first.
+	overlaps := []FileOverlap{
+		{File: "x.go", Branches: []string{branchA, branchB}},
+		{File: "y.go", Branches: []string{branchA, pr}},
+		{File: "z.go", Branches: []string{branchA, pr}},
+	}
+
+	steps := PlanMergeOrderAround(overlaps, []string{branchA, branchB}, []string{pr})
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps (no step for the PR), got %+v", steps)
+	}
+	if steps[0].Branch != branchB || steps[0].Conflicts != 1 {
+		t.Errorf("step 1 = %+v, want %s with 1 conflict", steps[0], branchB)
+	}
+	if steps[1].Branch != branchA || steps[1].Conflicts != 2 {
+		t.Errorf("step 2 = %+v, want %s with 2 conflicts (the PR's)", steps[1], branchA)
+	}
+}
+
+func TestPlanMergeBeatsGreedy(t *testing.T) {
+	const branchD = "feature/d"
+	// Greedy takes a first (4 conflicts, tied with b and d, alphabetical),
+	// leaving c's 3 conflicts with a early; b, d, a, c costs less.
+	overlaps := []FileOverlap{
+		{File: "ac1.go", Branches: []string{branchA, branchC}},
+		{File: "ac2.go", Branches: []string{branchA, branchC}},
+		{File: "ac3.go", Branches: []string{branchA, branchC}},
+		{File: "ad.go", Branches: []string{branchA, branchD}},
+		{File: "bc1.go", Branches: []string{branchB, branchC}},
+		{File: "bc2.go", Branches: []string{branchB, branchC}},
+		{File: "bd1.go", Branches: []string{branchB, branchD}},
+		{File: "bd2.go", Branches: []string{branchB, branchD}},
+		{File: "cd.go", Branches: []string{branchC, branchD}},
+	}
+
+	plan, err := PlanMerge(overlaps, []string{branchA, branchB, branchC, branchD}, PlanOptions{})
+	if err != nil {
+		t.Fatalf("PlanMerge: %v", err)
+	}
+	if !plan.Exact {
+		t.Error("expected an exact plan")
+	}
+	if got, want := stepBranches(plan.Steps), []string{branchB, branchD, branchA, branchC}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestPlanMergeMatchesExhaustiveOrder(t *testing.T) {
+	branches := []string{"b0", "b1", "b2", "b3", "b4", "b5"}
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for range 50 {
+		var overlaps []FileOverlap
+		for i := range branches {
+			for j := i + 1; j < len(branches); j++ {
+				for range rnd.IntN(3) {
+					overlaps = append(overlaps, FileOverlap{Branches: []string{branches[i], branches[j]}})
+				}
+			}
+		}
+
+		plan, err := PlanMerge(overlaps, branches, PlanOptions{})
+		if err != nil {
+			t.Fatalf("PlanMerge: %v", err)
+		}
+		got := planCost(overlaps, stepBranches(plan.Steps))
+		best := got
+		permute(slices.Clone(branches), 0, func(order []string) {
+			best = min(best, planCost(overlaps, order))
+		})
+		if got != best {
+			t.Fatalf("plan %v costs %d, best order costs %d", stepBranches(plan.Steps), got, best)
+		}
+	}
+}
+
+func TestPlanMergeGreedyBeyondExactLimit(t *testing.T) {
+	branches := make([]string, MaxExactBranches+1)
+	for i := range branches {
+		branches[i] = fmt.Sprintf("feature/%02d", len(branches)-i)
+	}
+
+	plan, err := PlanMerge(nil, branches, PlanOptions{})
+	if err != nil {
+		t.Fatalf("PlanMerge: %v", err)
+	}
+	if plan.Exact {
+		t.Error("expected a greedy plan beyond MaxExactBranches")
+	}
+	if plan.Steps[0].Branch != "feature/01" {
+		t.Errorf("first = %q, want feature/01 (alphabetical)", plan.Steps[0].Branch)
+	}
+}
+
+func TestPlanMergeKeepsConstraints(t *testing.T) {
+	stacked := Constraint{Before: branchC, After: branchA, Reason: "stacked"}
+	for _, n := range []int{3, MaxExactBranches + 1} { // exact and greedy
+		branches := []string{branchA, branchB, branchC}
+		for i := len(branches); i < n; i++ {
+			branches = append(branches, fmt.Sprintf("feature/x%02d", i))
+		}
+
+		plan, err := PlanMerge(nil, branches, PlanOptions{Constraints: []Constraint{stacked}})
+		if err != nil {
+			t.Fatalf("PlanMerge: %v", err)
+		}
+		order := stepBranches(plan.Steps)
+		if slices.Index(order, branchA) < slices.Index(order, branchC) {
+			t.Errorf("%d branches: order = %v, want %s after %s", n, order, branchA, branchC)
+		}
+		step := plan.Steps[slices.Index(order, branchA)]
+		if len(step.After) != 1 || step.After[0] != stacked {
+			t.Errorf("%d branches: step %+v, want it to record the constraint", n, step)
+		}
+	}
+}
+
+func TestPlanMergePinsFirst(t *testing.T) {
+	overlaps := []FileOverlap{{File: "x.go", Branches: []string{branchA, branchC}}}
+
+	plan, err := PlanMerge(overlaps, []string{branchA, branchB, branchC}, PlanOptions{First: []string{branchC, branchA}})
+	if err != nil {
+		t.Fatalf("PlanMerge: %v", err)
+	}
+	if got, want := stepBranches(plan.Steps), []string{branchC, branchA, branchB}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if !plan.Steps[0].Pinned || !plan.Steps[1].Pinned || plan.Steps[2].Pinned {
+		t.Errorf("pinned = %v, %v, %v; want true, true, false", plan.Steps[0].Pinned, plan.Steps[1].Pinned, plan.Steps[2].Pinned)
+	}
+}
+
+func TestPlanMergeRejectsContradictoryConstraints(t *testing.T) {
+	opts := PlanOptions{
+		Constraints: []Constraint{{Before: branchC, After: branchA, Reason: "stacked"}},
+		First:       []string{branchA},
+	}
+
+	_, err := PlanMerge(nil, []string{branchA, branchB, branchC}, opts)
+	if err == nil {
+		t.Fatal("expected an error for a pinned branch stacked on an unpinned one")
+	}
+	if !strings.Contains(err.Error(), "cycle among feature/a, feature/c") {
+		t.Errorf("error = %q, want the cycle's branches only", err)
+	}
+}
+
+func TestPlanMergeIgnoresConstraintsOutsidePlan(t *testing.T) {
+	opts := PlanOptions{
+		Constraints: []Constraint{{Before: "feature/merged", After: branchA, Reason: "stacked"}},
+		First:       []string{"feature/gone"},
+	}
+
+	plan, err := PlanMerge(nil, []string{branchA, branchB}, opts)
+	if err != nil {
+		t.Fatalf("PlanMerge: %v", err)
+	}
+	if plan.Steps[0].Branch != branchA || len(plan.Steps[0].After) != 0 {
+		t.Errorf("steps = %+v, want %s first, unconstrained", plan.Steps, branchA)
+	}
+}
+
+func TestPlanMergeWeighsDryMerges(t *testing.T) {
+	// a overlaps b twice but merges with it cleanly; its one overlap with
+	// c is a real conflict in two files.
+	overlaps := []FileOverlap{
+		{File: "x.go", Branches: []string{branchA, branchB}},
+		{File: "y.go", Branches: []string{branchA, branchB}},
+		{File: "z.go", Branches: []string{branchA, branchC}},
+	}
+	dry := []DryMergeResult{
+		{Branch1: branchA, Branch2: branchB},
+		{Branch1: branchA, Branch2: branchC, HasConflicts: true, ConflictFiles: []string{"z.go", "w.go"}},
+	}
+
+	plan, err := PlanMerge(overlaps, []string{branchA, branchB, branchC}, PlanOptions{DryMerges: dry})
+	if err != nil {
+		t.Fatalf("PlanMerge: %v", err)
+	}
+	if got, want := stepBranches(plan.Steps), []string{branchB, branchA, branchC}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if plan.Steps[1].Conflicts != 2 {
+		t.Errorf("a's conflicts = %d, want 2 (merge-tree files)", plan.Steps[1].Conflicts)
+	}
+}
+
+func TestMergeStepExplain(t *testing.T) {
+	label := func(b string) string { return strings.TrimPrefix(b, "feature/") }
+	tests := []struct {
+		step MergeStep
+		want string
+	}{
+		{MergeStep{}, "no conflicts with later branches"},
+		{
+			MergeStep{Pinned: true, Against: []Weight{{Branch: branchB, Weight: 2}, {Branch: branchC, Weight: 1}}},
+			"pinned first; conflicts with b (2), c (1)",
+		},
+		{
+			MergeStep{After: []Constraint{{Before: branchA, After: branchB, Reason: "stacked"}}},
+			"after a (stacked); no conflicts with later branches",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.step.Explain(label); got != tt.want {
+			t.Errorf("Explain(%+v) = %q, want %q", tt.step, got, tt.want)
+		}
+	}
+}
+
+func stepBranches(steps []MergeStep) []string {
+	out := make([]string, len(steps))
+	for i, s := range steps {
+		out[i] = s.Branch
+	}
+	return out
+}
+
+// planCost is the cost PlanMerge minimizes: each step's conflicts with the
+// branches after it, weighed by the branches left to merge.
+func planCost(overlaps []FileOverlap, order []string) int {
+	idx := make(map[string]int, len(order))
+	for i, b := range order {
+		idx[b] = i
+	}
+	matrix := buildConflictMatrix(overlaps, idx, len(order))
+	cost := 0
+	for i := range order {
+		for j := i + 1; j < len(order); j++ {
+			cost += (len(order) - i) * matrix[i][j]
+		}
+	}
+	return cost
+}
+
+func permute(s []string, k int, visit func([]string)) {
+	if k == len(s) {
+		visit(s)
+		return
+	}
+	for i := k; i < len(s); i++ {
+		s[k], s[i] = s[i], s[k]
+		permute(s, k+1, visit)
+		s[k], s[i] = s[i], s[k]
+	}
+}
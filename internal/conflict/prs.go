@@ -0,0 +1,67 @@
+package conflict
+
+import (
+	"fmt"
+	"slices"
+)
+
+// PR is an open pull request checked as a branch: its head, fetched into
+// the rimba-private ref Ref.
+type PR struct {
+	Ref    string `json:"ref"`
+	Number int    `json:"number"`
+	Author string `json:"author"`
+}
+
+// Label returns the PR's display name, e.g. "#42 (@alice)".
+func (p PR) Label() string {
+	return fmt.Sprintf("#%d (@%s)", p.Number, p.Author)
+}
+
+// AttachPRs records on each overlap the open PRs among its branches, with
+// prs keyed by ref, and drops overlaps between open PRs alone: they are for
+// the PRs' authors to resolve, not the caller.
+func AttachPRs(result *CheckResult, prs map[string]PR) {
+	if len(prs) == 0 {
+		return
+	}
+	result.Overlaps = slices.DeleteFunc(result.Overlaps, func(o FileOverlap) bool {
+		return allPRs(o.Branches, prs)
+	})
+	for i := range result.Overlaps {
+		result.Overlaps[i].PRs = prsAmong(result.Overlaps[i].Branches, prs)
+	}
+}
+
+// AttachDryMergePRs is AttachPRs for dry merge results.
+func AttachDryMergePRs(results []DryMergeResult, prs map[string]PR) []DryMergeResult {
+	if len(prs) == 0 {
+		return results
+	}
+	results = slices.DeleteFunc(results, func(d DryMergeResult) bool {
+		return allPRs([]string{d.Branch1, d.Branch2}, prs)
+	})
+	for i := range results {
+		results[i].PRs = prsAmong([]string{results[i].Branch1, results[i].Branch2}, prs)
+	}
+	return results
+}
+
+func allPRs(branches []string, prs map[string]PR) bool {
+	for _, b := range branches {
+		if _, ok := prs[b]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func prsAmong(branches []string, prs map[string]PR) []PR {
+	var out []PR
+	for _, b := range branches {
+		if pr, ok := prs[b]; ok {
+			out = append(out, pr)
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package conflict
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttachPRs(t *testing.T) {
+	const prRef, otherRef = "refs/rimba/prs/12", "refs/rimba/prs/15"
+	prs := map[string]PR{
+		prRef:    {Ref: prRef, Number: 12, Author: "alice"},
+		otherRef: {Ref: otherRef, Number: 15, Author: "bob"},
+	}
+	result := &CheckResult{Overlaps: []FileOverlap{
+		{File: "a.go", Branches: []string{branchA, branchB}},
+		{File: "b.go", Branches: []string{branchA, prRef}},
+		{File: "c.go", Branches: []string{prRef, otherRef}},
+	}}
+
+	AttachPRs(result, prs)
+
+	if len(result.Overlaps) != 2 {
+		t.Fatalf("overlaps = %+v, want the PR-only one dropped", result.Overlaps)
+	}
+	if result.Overlaps[0].PRs != nil {
+		t.Errorf("a.go PRs = %+v, want none", result.Overlaps[0].PRs)
+	}
+	if want := []PR{prs[prRef]}; !reflect.DeepEqual(result.Overlaps[1].PRs, want) {
+		t.Errorf("b.go PRs = %+v, want %+v", result.Overlaps[1].PRs, want)
+	}
+}
+
+func TestAttachDryMergePRs(t *testing.T) {
+	const prRef = "refs/rimba/prs/12"
+	prs := map[string]PR{prRef: {Ref: prRef, Number: 12, Author: "alice"}}
+	results := AttachDryMergePRs([]DryMergeResult{
+		{Branch1: branchA, Branch2: prRef, HasConflicts: true},
+		{Branch1: branchA, Branch2: branchB},
+	}, prs)
+
+	if want := []PR{prs[prRef]}; !reflect.DeepEqual(results[0].PRs, want) {
+		t.Errorf("PRs = %+v, want %+v", results[0].PRs, want)
+	}
+	if results[1].PRs != nil {
+		t.Errorf("PRs = %+v, want none", results[1].PRs)
+	}
+}
+
+func TestPRLabel(t *testing.T) {
+	if got := (PR{Number: 42, Author: "alice"}).Label(); got != "#42 (@alice)" {
+		t.Errorf("Label() = %q, want %q", got, "#42 (@alice)")
+	}
+}
@@ -0,0 +1,53 @@
+package deps
+
+import (
+	"context"
+	"path"
+
+	"github.com/lugassawan/rimba/internal/config"
+)
+
+// LockCommand returns the command that regenerates the lockfile at file, a
+// slash-separated path relative to the worktree root, and the directory to
+// run it in. A configured module whose lockfile is file uses its lock
+// command; otherwise the built-in preset for the file's name decides, and
+// only without one does the module's install command stand in, since
+// installs are often frozen (npm ci, --frozen-lockfile) and refuse to touch
+// the lockfile. ok is false when file is not a lockfile rimba knows how to
+// regenerate.
+func LockCommand(file string, configured []config.ModuleConfig) (command, workDir string, ok bool) {
+	dir := path.Dir(file)
+	if dir == "." {
+		dir = ""
+	}
+	var install string
+	for _, mc := range configured {
+		if mc.Lockfile != file {
+			continue
+		}
+		if mc.WorkDir != "" {
+			dir = mc.WorkDir
+		}
+		if mc.Lock != "" {
+			return mc.Lock, dir, true
+		}
+		install = mc.Install
+		break
+	}
+	name := path.Base(file)
+	for _, p := range presets {
+		if p.Lockfile == name && p.LockCmd != "" {
+			return p.LockCmd, dir, true
+		}
+	}
+	if install != "" {
+		return install, dir, true
+	}
+	return "", "", false
+}
+
+// RegenerateLockfile runs command in workDir, relative to worktreePath, to
+// regenerate lockfile. Failures carry the command to rerun by hand.
+func RegenerateLockfile(ctx context.Context, worktreePath, lockfile, workDir, command string) error {
+	return runInstall(ctx, worktreePath, Module{Dir: lockfile, Lockfile: lockfile, InstallCmd: command, WorkDir: workDir})
+}
@@ -0,0 +1,83 @@
+package deps
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+)
+
+func TestLockCommandPresets(t *testing.T) {
+	tests := []struct {
+		file, wantCmd, wantDir string
+	}{
+		{LockfilePnpm, "pnpm install --lockfile-only", ""},
+		{"service-api/" + LockfileGo, "go mod tidy", "service-api"},
+		{"crates/core/" + LockfileCargo, "cargo update --workspace", "crates/core"},
+		{LockfilePoetry, "poetry lock", ""},
+	}
+	for _, tt := range tests {
+		cmd, dir, ok := LockCommand(tt.file, nil)
+		if !ok || cmd != tt.wantCmd || dir != tt.wantDir {
+			t.Errorf("LockCommand(%q) = %q, %q, %v; want %q, %q", tt.file, cmd, dir, ok, tt.wantCmd, tt.wantDir)
+		}
+	}
+}
+
+func TestLockCommandNotALockfile(t *testing.T) {
+	for _, file := range []string{"main.go", "package.json", LockfileGradle} {
+		if _, _, ok := LockCommand(file, nil); ok {
+			t.Errorf("LockCommand(%q) ok = true, want false", file)
+		}
+	}
+}
+
+func TestLockCommandConfiguredModule(t *testing.T) {
+	configured := []config.ModuleConfig{
+		{Dir: "vendor", Lockfile: "tools/deps.lock", Install: "make deps-lock", WorkDir: "tools/build"},
+		{Dir: DirNodeModules, Lockfile: LockfilePnpm}, // no install: the preset decides
+	}
+	cmd, dir, ok := LockCommand("tools/deps.lock", configured)
+	if !ok || cmd != "make deps-lock" || dir != "tools/build" {
+		t.Errorf("configured = %q, %q, %v", cmd, dir, ok)
+	}
+	if cmd, _, _ := LockCommand(LockfilePnpm, configured); cmd != "pnpm install --lockfile-only" {
+		t.Errorf("pnpm = %q, want the preset command", cmd)
+	}
+}
+
+func TestLockCommandPrefersLockOverInstall(t *testing.T) {
+	configured := []config.ModuleConfig{
+		{Dir: DirNodeModules, Lockfile: LockfileNpm, Install: "npm ci"},
+		{Dir: testDirAPI + "/vendor", Lockfile: testDirAPI + "/go.sum", Install: "go mod vendor", Lock: "make tidy", WorkDir: testDirAPI},
+	}
+	if cmd, _, _ := LockCommand(LockfileNpm, configured); cmd != "npm install --package-lock-only" {
+		t.Errorf("npm = %q, want the preset lock command over the frozen install", cmd)
+	}
+	cmd, dir, ok := LockCommand(testDirAPI+"/go.sum", configured)
+	if !ok || cmd != "make tidy" || dir != testDirAPI {
+		t.Errorf("configured lock = %q, %q, %v; want make tidy in %s", cmd, dir, ok, testDirAPI)
+	}
+}
+
+func TestRegenerateLockfile(t *testing.T) {
+	wt := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(wt, testDirAPI), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegenerateLockfile(context.Background(), wt, testDirAPI+"/go.sum", testDirAPI, "echo regenerated > go.sum"); err != nil {
+		t.Fatalf("RegenerateLockfile: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(wt, testDirAPI, "go.sum"))
+	if err != nil || strings.TrimSpace(string(data)) != "regenerated" {
+		t.Errorf("go.sum = %q, %v", data, err)
+	}
+
+	err = RegenerateLockfile(context.Background(), wt, LockfileGo, "", "exit 3")
+	if err == nil || !strings.Contains(err.Error(), LockfileGo) {
+		t.Errorf("err = %v, want a failure naming the lockfile", err)
+	}
+}
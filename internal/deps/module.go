@@ -51,10 +51,13 @@ type preset struct {
 	Lockfile   string
 	Dir        string
 	InstallCmd string
-	Recursive  bool
-	ExtraDirs  []string
-	CloneOnly  bool
-	Relocate   bool
+	// LockCmd regenerates Lockfile from the manifests next to it, for
+	// resolving lockfile conflicts; empty when rimba cannot regenerate it.
+	LockCmd   string
+	Recursive bool
+	ExtraDirs []string
+	CloneOnly bool
+	Relocate  bool
 }
 
 // presets defines built-in ecosystem detection rules, ordered by priority.
@@ -62,12 +65,14 @@ type preset struct {
 var presets = []preset{
 	{
 		Lockfile:   LockfilePnpm,
+		LockCmd:    "pnpm install --lockfile-only",
 		Dir:        DirNodeModules,
 		InstallCmd: "pnpm install --frozen-lockfile",
 		Recursive:  true,
 	},
 	{
 		Lockfile:   LockfileYarn,
+		LockCmd:    "yarn install",
 		Dir:        DirNodeModules,
 		InstallCmd: "yarn install",
 		Recursive:  true,
@@ -75,29 +80,34 @@ var presets = []preset{
 	},
 	{
 		Lockfile:   LockfileNpm,
+		LockCmd:    "npm install --package-lock-only",
 		Dir:        DirNodeModules,
 		InstallCmd: "npm ci",
 		Recursive:  true,
 	},
 	{
 		Lockfile:   LockfileGo,
+		LockCmd:    "go mod tidy",
 		Dir:        DirVendor,
 		InstallCmd: "go mod vendor",
 		CloneOnly:  true,
 	},
 	{
 		Lockfile:  LockfileCargo,
+		LockCmd:   "cargo update --workspace",
 		Dir:       DirTarget,
 		CloneOnly: true,
 	},
 	{
 		Lockfile:  LockfileUv,
+		LockCmd:   "uv lock",
 		Dir:       DirVenv,
 		CloneOnly: true,
 		Relocate:  true,
 	},
 	{
 		Lockfile:  LockfilePoetry,
+		LockCmd:   "poetry lock",
 		Dir:       DirVenv,
 		CloneOnly: true,
 		Relocate:  true,
@@ -0,0 +1,46 @@
+package fsutil_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/fsutil"
+)
+
+func TestWriteFileAtomicCreatesParentDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	if err := fsutil.WriteFileAtomic(path, []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("WriteFileAtomic: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != `{"a":1}` {
+		t.Errorf("content = %q, want %q", got, `{"a":1}`)
+	}
+}
+
+func TestWriteFileAtomicReplacesAndLeavesNoTemp(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	if err := fsutil.WriteFileAtomic(path, []byte("old")); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, []byte("new")); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "new" {
+		t.Errorf("content = %q, want %q", got, "new")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only the target file", len(entries))
+	}
+}
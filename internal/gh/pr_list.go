@@ -0,0 +1,58 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+)
+
+// MaxOpenPRs bounds ListOpenPRs: every PR listed is fetched and diffed.
+const MaxOpenPRs = 100
+
+// OpenPR is an open pull request as listed by gh pr list.
+type OpenPR struct {
+	Number            int
+	Author            string
+	HeadRefName       string
+	IsCrossRepository bool
+}
+
+type openPRListEntry struct {
+	Number int `json:"number"`
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	HeadRefName       string `json:"headRefName"`
+	IsCrossRepository bool   `json:"isCrossRepository"`
+}
+
+// ListOpenPRs returns up to MaxOpenPRs open PRs against base, newest first.
+func ListOpenPRs(ctx context.Context, r Runner, base string) ([]OpenPR, error) {
+	out, err := r.Run(ctx, "pr", "list", "--base", base, "--state", "open",
+		"--json", "number,author,headRefName,isCrossRepository",
+		"--limit", strconv.Itoa(MaxOpenPRs),
+	)
+	if err != nil {
+		return nil, errhint.WithFix(
+			fmt.Errorf("list open PRs against %s: %w", base, err),
+			"check network access and gh auth: gh auth status",
+		)
+	}
+	var entries []openPRListEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, errhint.WithFix(fmt.Errorf("parse gh pr list: %w", err), ghShapeHint)
+	}
+	prs := make([]OpenPR, len(entries))
+	for i, e := range entries {
+		prs[i] = OpenPR{
+			Number:            e.Number,
+			Author:            e.Author.Login,
+			HeadRefName:       e.HeadRefName,
+			IsCrossRepository: e.IsCrossRepository,
+		}
+	}
+	return prs, nil
+}
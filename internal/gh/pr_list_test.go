@@ -0,0 +1,72 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestListOpenPRs(t *testing.T) {
+	var gotArgs []string
+	r := &mockRunner{
+		run: func(_ context.Context, args ...string) ([]byte, error) {
+			gotArgs = args
+			return []byte(`[
+				{"number":51,"author":{"login":"alice"},"headRefName":"feature/search","isCrossRepository":false},
+				{"number":48,"author":{"login":"bob"},"headRefName":"main","isCrossRepository":true}
+			]`), nil
+		},
+	}
+
+	got, err := ListOpenPRs(context.Background(), r, "main")
+	if err != nil {
+		t.Fatalf("ListOpenPRs: %v", err)
+	}
+	want := []OpenPR{
+		{Number: 51, Author: "alice", HeadRefName: "feature/search"},
+		{Number: 48, Author: "bob", HeadRefName: "main", IsCrossRepository: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListOpenPRs = %+v, want %+v", got, want)
+	}
+	for _, arg := range []string{"--base", "main", "--state", "open"} {
+		if !slices.Contains(gotArgs, arg) {
+			t.Errorf("args %v missing %q", gotArgs, arg)
+		}
+	}
+}
+
+func TestListOpenPRsNone(t *testing.T) {
+	r := &mockRunner{
+		run: func(_ context.Context, _ ...string) ([]byte, error) {
+			return []byte("[]\n"), nil
+		},
+	}
+	got, err := ListOpenPRs(context.Background(), r, "main")
+	if err != nil {
+		t.Fatalf("ListOpenPRs: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ListOpenPRs = %+v, want none", got)
+	}
+}
+
+func TestListOpenPRsErrors(t *testing.T) {
+	failing := &mockRunner{
+		run: func(_ context.Context, _ ...string) ([]byte, error) {
+			return nil, errors.New("HTTP 401")
+		},
+	}
+	_, err := ListOpenPRs(context.Background(), failing, "main")
+	assertContains(t, err, "list open PRs against main")
+
+	garbled := &mockRunner{
+		run: func(_ context.Context, _ ...string) ([]byte, error) {
+			return []byte("{not json"), nil
+		},
+	}
+	_, err = ListOpenPRs(context.Background(), garbled, "main")
+	assertContains(t, err, "parse gh pr list")
+}
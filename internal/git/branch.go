@@ -151,6 +151,22 @@ func AheadBehind(ctx context.Context, r Runner, dir string) (ahead, behind int,
 	return ahead, behind, nil
 }
 
+// AheadBehindOf returns how many commits branch has that base lacks (ahead)
+// and base has that branch lacks (behind).
+func AheadBehindOf(ctx context.Context, r Runner, base, branch string) (ahead, behind int, _ error) {
+	out, err := r.Run(ctx, cmdRevList, "--left-right", "--count", flagEndOfOptions, base+"..."+branch)
+	if err != nil {
+		return 0, 0, err
+	}
+	parts := strings.Fields(out)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	parseCount(parts[0], &behind)
+	parseCount(parts[1], &ahead)
+	return ahead, behind, nil
+}
+
 // FirstParentChainSHAs returns the set of commit SHAs on mergeRef's mainline
 // (first-parent) history.
 func FirstParentChainSHAs(ctx context.Context, r Runner, mergeRef string) (map[string]bool, error) {
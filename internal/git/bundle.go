@@ -0,0 +1,31 @@
+package git
+
+import (
+	"context"
+	"fmt"
+)
+
+const cmdBundle = "bundle"
+
+// CreateBundle writes a git bundle at path holding revs, which follow
+// git rev-list syntax (e.g. a ref plus ^<base> to leave out shared history).
+func CreateBundle(ctx context.Context, r Runner, path string, revs ...string) error {
+	args := append([]string{cmdBundle, "create", path}, revs...)
+	if _, err := r.Run(ctx, args...); err != nil {
+		return fmt.Errorf("create bundle: %w", err)
+	}
+	return nil
+}
+
+// Unbundle stores the objects of the bundle at path in the repository
+// without touching any refs. It fails when the repository lacks the commits
+// the bundle was cut from.
+func Unbundle(ctx context.Context, r Runner, path string) error {
+	if _, err := r.Run(ctx, cmdBundle, "verify", "--quiet", path); err != nil {
+		return fmt.Errorf("verify bundle: %w", err)
+	}
+	if _, err := r.Run(ctx, cmdBundle, "unbundle", path); err != nil {
+		return fmt.Errorf("unbundle: %w", err)
+	}
+	return nil
+}
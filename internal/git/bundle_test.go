@@ -0,0 +1,68 @@
+package git_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+func TestCreateBundleAndUnbundle(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	src := testutil.NewTestRepo(t)
+	base := strings.TrimSpace(testutil.GitCmd(t, src, "rev-parse", "HEAD"))
+	dst := t.TempDir()
+	testutil.GitCmd(t, dst, "clone", "--quiet", src, ".")
+
+	testutil.GitCmd(t, src, "checkout", "-b", "feature/portable")
+	testutil.CreateFile(t, src, "work.txt", "work")
+	testutil.GitCmd(t, src, "add", ".")
+	testutil.GitCmd(t, src, "commit", "-m", "work")
+	head := strings.TrimSpace(testutil.GitCmd(t, src, "rev-parse", "HEAD"))
+
+	path := filepath.Join(t.TempDir(), "repo.bundle")
+	ctx := context.Background()
+	if err := git.CreateBundle(ctx, &git.ExecRunner{Dir: src}, path, "refs/heads/feature/portable", "^"+base); err != nil {
+		t.Fatalf("CreateBundle: %v", err)
+	}
+
+	r := &git.ExecRunner{Dir: dst}
+	if err := git.Unbundle(ctx, r, path); err != nil {
+		t.Fatalf("Unbundle: %v", err)
+	}
+	if _, err := git.ResolveRef(ctx, r, head); err != nil {
+		t.Errorf("bundled commit %s should exist after Unbundle: %v", head, err)
+	}
+}
+
+func TestUnbundleMissingPrerequisite(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	src := testutil.NewTestRepo(t)
+	testutil.CreateFile(t, src, "more.txt", "more")
+	testutil.GitCmd(t, src, "add", ".")
+	testutil.GitCmd(t, src, "commit", "-m", "more")
+	testutil.CreateFile(t, src, "tip.txt", "tip")
+	testutil.GitCmd(t, src, "add", ".")
+	testutil.GitCmd(t, src, "commit", "-m", "tip")
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "repo.bundle")
+	if err := git.CreateBundle(ctx, &git.ExecRunner{Dir: src}, path, "HEAD", "^HEAD~1"); err != nil {
+		t.Fatalf("CreateBundle: %v", err)
+	}
+
+	// An unrelated repository lacks HEAD~1.
+	other := testutil.NewTestRepo(t)
+	if err := git.Unbundle(ctx, &git.ExecRunner{Dir: other}, path); err == nil {
+		t.Fatal("expected Unbundle to fail without the prerequisite commit")
+	}
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// CommitsSince returns the non-merge commits on branch after base, oldest
+// first — the commits a cherry-pick of base..branch replays.
+func CommitsSince(ctx context.Context, r Runner, base, branch string) ([]string, error) {
+	out, err := r.Run(ctx, cmdRevList, "--reverse", "--no-merges", flagEndOfOptions, base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// CommitSubjects returns the subjects of the non-merge commits on branch
+// after base, oldest first. --no-show-signature keeps log.showSignature
+// from mixing gpg output into the subjects.
+func CommitSubjects(ctx context.Context, r Runner, base, branch string) ([]string, error) {
+	out, err := r.Run(ctx, CmdLog, "--reverse", "--no-merges", "--no-show-signature", "--format=%s",
+		flagEndOfOptions, base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// CherryPick runs `git cherry-pick -x <commits>` inside dir, recording each
+// original commit in the new message. On a conflict git stops with the
+// cherry-pick in progress for the caller to resolve, continue, or abort.
+func CherryPick(ctx context.Context, r Runner, dir string, commits []string) error {
+	args := append([]string{"cherry-pick", "-x", flagEndOfOptions}, commits...)
+	_, err := r.RunInDir(ctx, dir, args...)
+	return err
+}
+
+// CherryPickAbort runs `git cherry-pick --abort` inside dir.
+// Intentionally non-cancellable, like AbortRebase.
+func CherryPickAbort(r Runner, dir string) error {
+	_, err := r.RunInDir(context.Background(), dir, "cherry-pick", "--abort")
+	return err
+}
+
+// UnmergedFiles returns the paths left conflicted in dir's index.
+func UnmergedFiles(ctx context.Context, r Runner, dir string) ([]string, error) {
+	out, err := r.RunInDir(ctx, dir, CmdDiff, "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
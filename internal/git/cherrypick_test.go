@@ -0,0 +1,130 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCommitsSince(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			captured = args
+			return "aaa\nbbb", nil
+		},
+	}
+
+	commits, err := CommitsSince(context.Background(), r, "fork", branchFeature)
+	if err != nil {
+		t.Fatalf("CommitsSince: %v", err)
+	}
+	if !slices.Equal(commits, []string{"aaa", "bbb"}) {
+		t.Errorf("commits = %v, want [aaa bbb]", commits)
+	}
+	want := []string{cmdRevList, "--reverse", "--no-merges", flagEndOfOptions, "fork.." + branchFeature}
+	if !slices.Equal(captured, want) {
+		t.Errorf("args = %v, want %v", captured, want)
+	}
+}
+
+func TestCommitsSinceEmptyAndError(t *testing.T) {
+	empty := &mockRunner{run: func(...string) (string, error) { return "", nil }}
+	if commits, err := CommitsSince(context.Background(), empty, "fork", branchFeature); err != nil || commits != nil {
+		t.Errorf("CommitsSince = %v, %v; want nil, nil", commits, err)
+	}
+
+	failing := &mockRunner{run: func(...string) (string, error) { return "", errors.New("bad revision") }}
+	if _, err := CommitsSince(context.Background(), failing, "fork", branchFeature); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestCommitSubjects(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			captured = args
+			return "add one\nadd two", nil
+		},
+	}
+
+	subjects, err := CommitSubjects(context.Background(), r, "fork", branchFeature)
+	if err != nil {
+		t.Fatalf("CommitSubjects: %v", err)
+	}
+	if !slices.Equal(subjects, []string{"add one", "add two"}) {
+		t.Errorf("subjects = %v, want [add one add two]", subjects)
+	}
+	want := []string{CmdLog, "--reverse", "--no-merges", "--no-show-signature", "--format=%s", flagEndOfOptions, "fork.." + branchFeature}
+	if !slices.Equal(captured, want) {
+		t.Errorf("args = %v, want %v", captured, want)
+	}
+}
+
+func TestCherryPick(t *testing.T) {
+	var capturedDir string
+	var captured []string
+	r := &mockRunner{
+		runInDir: func(dir string, args ...string) (string, error) {
+			capturedDir, captured = dir, args
+			return "", nil
+		},
+	}
+
+	if err := CherryPick(context.Background(), r, fakeDir, []string{"aaa", "bbb"}); err != nil {
+		t.Fatalf("CherryPick: %v", err)
+	}
+	if capturedDir != fakeDir {
+		t.Errorf("dir = %q, want %q", capturedDir, fakeDir)
+	}
+	want := []string{"cherry-pick", "-x", flagEndOfOptions, "aaa", "bbb"}
+	if !slices.Equal(captured, want) {
+		t.Errorf("args = %v, want %v", captured, want)
+	}
+}
+
+func TestCherryPickAbort(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		runInDir: func(_ string, args ...string) (string, error) {
+			captured = args
+			return "", nil
+		},
+	}
+
+	if err := CherryPickAbort(r, fakeDir); err != nil {
+		t.Fatalf("CherryPickAbort: %v", err)
+	}
+	if strings.Join(captured, " ") != "cherry-pick --abort" {
+		t.Errorf("args = %v, want cherry-pick --abort", captured)
+	}
+}
+
+func TestUnmergedFiles(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		runInDir: func(_ string, args ...string) (string, error) {
+			captured = args
+			return "a.go\nb.go", nil
+		},
+	}
+
+	files, err := UnmergedFiles(context.Background(), r, fakeDir)
+	if err != nil {
+		t.Fatalf("UnmergedFiles: %v", err)
+	}
+	if !slices.Equal(files, []string{"a.go", "b.go"}) {
+		t.Errorf("files = %v, want [a.go b.go]", files)
+	}
+	if !slices.Contains(captured, "--diff-filter=U") {
+		t.Errorf("args = %v, want --diff-filter=U", captured)
+	}
+
+	clean := &mockRunner{runInDir: func(string, ...string) (string, error) { return "", nil }}
+	if files, err := UnmergedFiles(context.Background(), clean, fakeDir); err != nil || files != nil {
+		t.Errorf("UnmergedFiles(clean) = %v, %v; want nil, nil", files, err)
+	}
+}
@@ -2,9 +2,17 @@ package git
 
 import (
 	"context"
+	"strconv"
 	"strings"
 )
 
+// The a/ and b/ prefixes diffHeaderPath parses, set explicitly so the
+// user's diff.noprefix or diff.mnemonicPrefix cannot change them.
+const (
+	flagSrcPrefix = "--src-prefix=a/"
+	flagDstPrefix = "--dst-prefix=b/"
+)
+
 // DiffNameOnly returns files changed between base and branch using three-dot diff.
 // The three-dot notation (base...branch) shows changes on branch since it diverged from base.
 func DiffNameOnly(ctx context.Context, r Runner, base, branch string) ([]string, error) {
@@ -12,10 +20,79 @@ func DiffNameOnly(ctx context.Context, r Runner, base, branch string) ([]string,
 	if err != nil {
 		return nil, err
 	}
-	if out == "" {
-		return nil, nil
+	return splitFileList(out), nil
+}
+
+// LineRange is the base-side lines a diff hunk replaces, as in its
+// "-start,count" header. A zero Count is an insertion after line Start.
+type LineRange struct {
+	Start int `json:"start"`
+	Count int `json:"count"`
+}
+
+// FileHunks is one file a branch changed, with the line ranges it changed.
+type FileHunks struct {
+	// Path is the file's path on the base side — for a renamed file, its
+	// old path — or, for an added file, its new path.
+	Path string `json:"path"`
+	// NewPath is the branch-side path of a renamed file.
+	NewPath string `json:"new_path,omitempty"`
+	// Hunks are nil when the change has no line ranges: a binary,
+	// mode-only or rename-only change, which touches the whole file.
+	Hunks []LineRange `json:"hunks"`
+	// Base is the merge-base commit whose lines Hunks number, when known.
+	Base string `json:"base,omitempty"`
+}
+
+// DiffHunks returns the files changed between base and branch (three-dot
+// diff, following renames) with the base-side line ranges of each hunk.
+func DiffHunks(ctx context.Context, r Runner, base, branch string) ([]FileHunks, error) {
+	out, err := r.Run(ctx, CmdDiff, "-U0", "-M", "--no-color", "--no-ext-diff", flagSrcPrefix, flagDstPrefix, flagEndOfOptions, base+"..."+branch)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiffHunks(out), nil
+}
+
+// DiffHunksInWorktree is DiffHunks for the worktree at dir, comparing its
+// working tree — staged and unstaged changes included — with the
+// merge-base of base and its HEAD, which it records as each file's Base.
+// Untracked files are not included.
+func DiffHunksInWorktree(ctx context.Context, r Runner, dir, base string) ([]FileHunks, error) {
+	mb, err := r.RunInDir(ctx, dir, CmdMergeBase, "--", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	mb = strings.TrimSpace(mb)
+	out, err := r.RunInDir(ctx, dir, CmdDiff, "-U0", "-M", "--no-color", "--no-ext-diff", flagSrcPrefix, flagDstPrefix, mb, "--")
+	if err != nil {
+		return nil, err
+	}
+	files := parseDiffHunks(out)
+	for i := range files {
+		files[i].Base = mb
 	}
-	return strings.Split(out, "\n"), nil
+	return files, nil
+}
+
+// UncommittedFiles returns the files with staged or unstaged changes in
+// the worktree at dir.
+func UncommittedFiles(ctx context.Context, r Runner, dir string) ([]string, error) {
+	out, err := r.RunInDir(ctx, dir, CmdDiff, "--name-only", "HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+	return splitFileList(out), nil
+}
+
+// UntrackedFiles returns the untracked, not ignored, files in the worktree
+// at dir.
+func UntrackedFiles(ctx context.Context, r Runner, dir string) ([]string, error) {
+	out, err := r.RunInDir(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	return splitFileList(out), nil
 }
 
 // MergeTreeResult holds output of git merge-tree --write-tree.
@@ -57,3 +134,68 @@ func parseMergeTreeOutput(output string) MergeTreeResult {
 	}
 	return result
 }
+
+// parseDiffHunks parses `git diff -U0 -M` output into the files it changes
+// and the base-side range of each hunk.
+func parseDiffHunks(output string) []FileHunks {
+	var files []FileHunks
+	for line := range strings.SplitSeq(output, "\n") {
+		if header, ok := strings.CutPrefix(line, "diff --git "); ok {
+			files = append(files, FileHunks{Path: diffHeaderPath(header)})
+			continue
+		}
+		if len(files) == 0 {
+			continue
+		}
+		f := &files[len(files)-1]
+		switch {
+		case strings.HasPrefix(line, "rename from "):
+			f.Path = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			f.NewPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "@@ -"):
+			if hunk, ok := parseHunkHeader(line); ok {
+				f.Hunks = append(f.Hunks, hunk)
+			}
+		}
+	}
+	return files
+}
+
+// diffHeaderPath returns the path of a "a/<path> b/<path>" diff header,
+// which names the same path twice unless the file was renamed — in which
+// case the rename lines that follow give both paths.
+func diffHeaderPath(header string) string {
+	rest := strings.TrimPrefix(header, "a/")
+	if half := (len(rest) - len(" b/")) / 2; half > 0 && rest[half:half+len(" b/")] == " b/" {
+		return rest[:half]
+	}
+	a, _, _ := strings.Cut(rest, " b/")
+	return a
+}
+
+// parseHunkHeader returns the base-side range of a "@@ -start[,count]
+// +start[,count] @@" hunk header; an omitted count is 1.
+func parseHunkHeader(line string) (LineRange, bool) {
+	old, _, _ := strings.Cut(strings.TrimPrefix(line, "@@ -"), " ")
+	startStr, countStr, hasCount := strings.Cut(old, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return LineRange{}, false
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return LineRange{}, false
+		}
+	}
+	return LineRange{Start: start, Count: count}, true
+}
+
+// splitFileList splits one-path-per-line output, nil when it is empty.
+func splitFileList(out string) []string {
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
@@ -3,7 +3,9 @@ package git
 import (
 	"context"
 	"errors"
+	"fmt"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/lugassawan/rimba/testutil"
@@ -232,3 +234,141 @@ func TestParseMergeTreeOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDiffHunks(t *testing.T) {
+	out := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3 +3 @@ func main() {
+-	old()
++	new()
+@@ -10,0 +11,2 @@ func main() {
++	a()
++	b()
+diff --git a/old name.go b/new name.go
+similarity index 90%
+rename from old name.go
+rename to new name.go
+@@ -5,2 +5 @@
+-x
+-y
++z
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ`
+
+	got := parseDiffHunks(out)
+	want := []FileHunks{
+		{Path: "main.go", Hunks: []LineRange{{Start: 3, Count: 1}, {Start: 10, Count: 0}}},
+		{Path: "old name.go", NewPath: "new name.go", Hunks: []LineRange{{Start: 5, Count: 2}}},
+		{Path: "logo.png"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d files, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Path != want[i].Path || got[i].NewPath != want[i].NewPath || !slices.Equal(got[i].Hunks, want[i].Hunks) {
+			t.Errorf("file %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDiffHunksFollowsRenames(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegrationGit)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	var lines strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&lines, "line %d\n", i)
+	}
+	testutil.CreateFile(t, repo, "big.txt", lines.String())
+	testutil.GitCmd(t, repo, "add", "big.txt")
+	testutil.GitCmd(t, repo, "commit", "-m", "add big.txt")
+
+	testutil.GitCmd(t, repo, "switch", "-c", "feature/rename")
+	testutil.GitCmd(t, repo, "mv", "big.txt", "moved.txt")
+	testutil.CreateFile(t, repo, "moved.txt", strings.Replace(lines.String(), "line 18\n", "line eighteen\n", 1))
+	testutil.GitCmd(t, repo, "commit", "-am", "move and edit")
+
+	files, err := DiffHunks(context.Background(), &ExecRunner{Dir: repo}, "main", "feature/rename")
+	if err != nil {
+		t.Fatalf("DiffHunks: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "big.txt" || files[0].NewPath != "moved.txt" {
+		t.Fatalf("files = %+v, want big.txt renamed to moved.txt", files)
+	}
+	if !slices.Equal(files[0].Hunks, []LineRange{{Start: 18, Count: 1}}) {
+		t.Errorf("hunks = %+v, want line 18", files[0].Hunks)
+	}
+}
+
+func TestDiffHunksIgnoresPrefixConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegrationGit)
+	}
+
+	for _, setting := range []string{"diff.noprefix", "diff.mnemonicPrefix"} {
+		t.Run(setting, func(t *testing.T) {
+			repo := testutil.NewTestRepo(t)
+			testutil.GitCmd(t, repo, "config", setting, "true")
+			testutil.CreateFile(t, repo, "a b.txt", "one\ntwo\nthree\n")
+			testutil.GitCmd(t, repo, "add", "a b.txt")
+			testutil.GitCmd(t, repo, "commit", "-m", "add a b.txt")
+			testutil.GitCmd(t, repo, "switch", "-c", "feature/prefix")
+			testutil.CreateFile(t, repo, "a b.txt", "one\nTWO\nthree\n")
+			testutil.GitCmd(t, repo, "commit", "-am", "edit")
+			testutil.CreateFile(t, repo, "a b.txt", "one\nTWO\nTHREE\n")
+			r := &ExecRunner{Dir: repo}
+			ctx := context.Background()
+
+			files, err := DiffHunks(ctx, r, "main", "feature/prefix")
+			if err != nil {
+				t.Fatalf("DiffHunks: %v", err)
+			}
+			if len(files) != 1 || files[0].Path != "a b.txt" {
+				t.Errorf("DiffHunks = %+v, want a b.txt", files)
+			}
+			files, err = DiffHunksInWorktree(ctx, r, repo, "main")
+			if err != nil {
+				t.Fatalf("DiffHunksInWorktree: %v", err)
+			}
+			if len(files) != 1 || files[0].Path != "a b.txt" {
+				t.Errorf("DiffHunksInWorktree = %+v, want a b.txt", files)
+			}
+		})
+	}
+}
+
+func TestWorktreeChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegrationGit)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	testutil.CreateFile(t, repo, "a.txt", "one\ntwo\nthree\n")
+	testutil.GitCmd(t, repo, "add", "a.txt")
+	testutil.GitCmd(t, repo, "commit", "-m", "add a.txt")
+	testutil.GitCmd(t, repo, "switch", "-c", "feature/wip")
+	testutil.CreateFile(t, repo, "a.txt", "one\nTWO\nthree\n")
+	testutil.CreateFile(t, repo, "new.txt", "untracked\n")
+	r := &ExecRunner{Dir: repo}
+	ctx := context.Background()
+
+	files, err := UncommittedFiles(ctx, r, repo)
+	if err != nil || !slices.Equal(files, []string{"a.txt"}) {
+		t.Errorf("UncommittedFiles = %v, %v; want a.txt", files, err)
+	}
+	others, err := UntrackedFiles(ctx, r, repo)
+	if err != nil || !slices.Equal(others, []string{"new.txt"}) {
+		t.Errorf("UntrackedFiles = %v, %v; want new.txt", others, err)
+	}
+	hunks, err := DiffHunksInWorktree(ctx, r, repo, "main")
+	if err != nil {
+		t.Fatalf("DiffHunksInWorktree: %v", err)
+	}
+	if len(hunks) != 1 || hunks[0].Path != "a.txt" || !slices.Equal(hunks[0].Hunks, []LineRange{{Start: 2, Count: 1}}) {
+		t.Errorf("hunks = %+v, want a.txt line 2", hunks)
+	}
+}
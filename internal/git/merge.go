@@ -17,6 +17,43 @@ func Merge(ctx context.Context, r Runner, dir, branch string, noFF bool) error {
 	return err
 }
 
+// MergeWithMessage runs `git merge --no-ff -m <message> <branch>` inside
+// dir, always recording a merge commit — for merges on a detached HEAD,
+// whose default message would name HEAD as the target.
+func MergeWithMessage(ctx context.Context, r Runner, dir, branch, message string) error {
+	_, err := r.RunInDir(ctx, dir, "merge", "--no-ff", "-m", message, "--", branch)
+	return err
+}
+
+// MergeSquash runs `git merge --squash <branch>` inside dir, staging branch's
+// changes without committing them or recording a merge.
+func MergeSquash(ctx context.Context, r Runner, dir, branch string) error {
+	_, err := r.RunInDir(ctx, dir, "merge", "--squash", "--", branch)
+	return err
+}
+
+// Commit records the staged changes in dir with message, without opening an
+// editor.
+func Commit(ctx context.Context, r Runner, dir, message string) error {
+	_, err := r.RunInDir(ctx, dir, "commit", "--cleanup=strip", "-m", message)
+	return err
+}
+
+// ResetMerge runs `git reset --merge` in dir, discarding a squash merge's
+// staged changes and conflicts, which leave no MERGE_HEAD to abort.
+// Intentionally non-cancellable, like MergeAbort.
+func ResetMerge(r Runner, dir string) error {
+	_, err := r.RunInDir(context.Background(), dir, "reset", "--merge")
+	return err
+}
+
+// MergeFFOnly fast-forwards the branch checked out in dir to ref, failing
+// when the branch has diverged from it.
+func MergeFFOnly(ctx context.Context, r Runner, dir, ref string) error {
+	_, err := r.RunInDir(ctx, dir, "merge", "--ff-only", "--", ref)
+	return err
+}
+
 // MergeInProgress reports whether a merge is in progress in dir (MERGE_HEAD exists).
 // Returns (false, nil) when no merge is in progress, (true, nil) when one is,
 // and (false, err) when the check itself fails (infrastructure error).
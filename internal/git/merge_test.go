@@ -3,6 +3,7 @@ package git_test
 import (
 	"context"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/lugassawan/rimba/internal/git"
@@ -171,3 +172,141 @@ func TestMergeAbort(t *testing.T) {
 		t.Error("expected MergeInProgress=false after MergeAbort")
 	}
 }
+
+func TestMergeFFOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	wtPath := filepath.Join(filepath.Dir(repo), "wt-merge-ff")
+	if err := git.AddWorktree(context.Background(), r, wtPath, "feat/ff-source", "main"); err != nil {
+		t.Fatalf(fatalAddWorktree, err)
+	}
+	testutil.CreateFile(t, wtPath, "ahead.txt", "ahead")
+	testutil.GitCmd(t, wtPath, "add", ".")
+	testutil.GitCmd(t, wtPath, "commit", "-m", "ahead")
+
+	if err := git.MergeFFOnly(context.Background(), r, repo, "feat/ff-source"); err != nil {
+		t.Fatalf("MergeFFOnly: %v", err)
+	}
+
+	// Diverge: main gets its own commit, so the next fast-forward is refused.
+	testutil.CreateFile(t, wtPath, "more.txt", "more")
+	testutil.GitCmd(t, wtPath, "add", ".")
+	testutil.GitCmd(t, wtPath, "commit", "-m", "more")
+	testutil.CreateFile(t, repo, "local.txt", "local")
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "local")
+
+	if err := git.MergeFFOnly(context.Background(), r, repo, "feat/ff-source"); err == nil {
+		t.Fatal("expected MergeFFOnly to refuse a diverged branch")
+	}
+}
+
+func TestMergeSquashCommitIsSquashMerged(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	wtPath := filepath.Join(filepath.Dir(repo), "wt-merge-squash")
+	if err := git.AddWorktree(context.Background(), r, wtPath, "feat/squash-source", "main"); err != nil {
+		t.Fatalf(fatalAddWorktree, err)
+	}
+	for _, name := range []string{"one.txt", "two.txt"} {
+		testutil.CreateFile(t, wtPath, name, name)
+		testutil.GitCmd(t, wtPath, "add", ".")
+		testutil.GitCmd(t, wtPath, "commit", "-m", "add "+name)
+	}
+
+	subjects, err := git.CommitSubjects(context.Background(), r, "main", "feat/squash-source")
+	if err != nil || len(subjects) != 2 || subjects[0] != "add one.txt" {
+		t.Fatalf("CommitSubjects = %v, %v; want both subjects, oldest first", subjects, err)
+	}
+
+	if err := git.MergeSquash(context.Background(), r, repo, "feat/squash-source"); err != nil {
+		t.Fatalf("MergeSquash: %v", err)
+	}
+	if err := git.Commit(context.Background(), r, repo, "feat(squash-source): add files\n\n# dropped comment"); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if msg := testutil.GitCmd(t, repo, "log", "-1", "--format=%B"); strings.Contains(msg, "#") {
+		t.Errorf("commit message = %q, want comment lines stripped", msg)
+	}
+	squashed, err := git.IsSquashMerged(context.Background(), r, "main", "feat/squash-source")
+	if err != nil || !squashed {
+		t.Errorf("IsSquashMerged = %v, %v; want the squash commit recognised", squashed, err)
+	}
+}
+
+func TestMergeSquashConflictResetMerge(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	wtPath := filepath.Join(filepath.Dir(repo), "wt-squash-conflict")
+	if err := git.AddWorktree(context.Background(), r, wtPath, "feat/squash-conflict", "main"); err != nil {
+		t.Fatalf(fatalAddWorktree, err)
+	}
+	testutil.CreateFile(t, wtPath, "conflict.txt", "source version")
+	testutil.GitCmd(t, wtPath, "add", ".")
+	testutil.GitCmd(t, wtPath, "commit", "-m", "source change")
+	testutil.CreateFile(t, repo, "conflict.txt", "main version")
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "main change")
+
+	if err := git.MergeSquash(context.Background(), r, repo, "feat/squash-conflict"); err == nil {
+		t.Fatal("expected conflict error from MergeSquash")
+	}
+	if err := git.ResetMerge(r, repo); err != nil {
+		t.Fatalf("ResetMerge: %v", err)
+	}
+	if dirty, err := git.IsDirty(context.Background(), r, repo); err != nil || dirty {
+		t.Errorf("IsDirty after ResetMerge = %v, %v; want clean", dirty, err)
+	}
+}
+
+func TestMergeWithMessageOnDetachedWorktree(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+	testutil.GitCmd(t, repo, "switch", "-c", "feat/queued")
+	testutil.CreateFile(t, repo, "queued.txt", "queued")
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "queued")
+	testutil.GitCmd(t, repo, "switch", "main")
+
+	wtPath := filepath.Join(filepath.Dir(repo), "wt-detached")
+	if err := git.AddDetachedWorktree(context.Background(), r, wtPath, "main"); err != nil {
+		t.Fatalf("AddDetachedWorktree: %v", err)
+	}
+	if err := git.MergeWithMessage(context.Background(), r, wtPath, "feat/queued", "Merge branch 'feat/queued'"); err != nil {
+		t.Fatalf("MergeWithMessage: %v", err)
+	}
+
+	head, err := git.HeadSHA(context.Background(), r, wtPath)
+	if err != nil {
+		t.Fatalf("HeadSHA: %v", err)
+	}
+	if main := strings.TrimSpace(testutil.GitCmd(t, repo, "rev-parse", "main")); head == main {
+		t.Error("merge on the detached worktree moved main")
+	}
+	if got := strings.TrimSpace(testutil.GitCmd(t, wtPath, "log", "-1", "--format=%s")); got != "Merge branch 'feat/queued'" {
+		t.Errorf("subject = %q, want the given message", got)
+	}
+	if parents := strings.Fields(testutil.GitCmd(t, wtPath, "log", "-1", "--format=%P")); len(parents) != 2 {
+		t.Errorf("parents = %v, want a merge commit", parents)
+	}
+}
@@ -116,6 +116,44 @@ func TestAheadBehind(t *testing.T) {
 	}
 }
 
+func TestAheadBehindOf(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			captured = args
+			return "2\t5", nil
+		},
+	}
+	ahead, behind, err := AheadBehindOf(context.Background(), r, "release/2.3", "feature/auth")
+	if err != nil {
+		t.Fatalf("AheadBehindOf: %v", err)
+	}
+	if ahead != 5 || behind != 2 {
+		t.Errorf("ahead/behind = %d/%d, want 5/2", ahead, behind)
+	}
+	if got := captured[len(captured)-1]; got != "release/2.3...feature/auth" {
+		t.Errorf("range = %q, want release/2.3...feature/auth", got)
+	}
+}
+
+func TestAheadBehindOfErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		out  string
+		err  error
+	}{
+		{"run_error", "", errors.New(errNotARepo)},
+		{"malformed", "5", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &mockRunner{run: func(_ ...string) (string, error) { return tt.out, tt.err }}
+			if _, _, err := AheadBehindOf(context.Background(), r, branchMain, "feature/auth"); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
 func TestMergedBranches(t *testing.T) {
 	var captured []string
 	r := &mockRunner{
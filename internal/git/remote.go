@@ -58,12 +58,35 @@ func DeleteRemoteBranch(ctx context.Context, r Runner, remote, branch string) er
 	)
 }
 
+// RemoteHead returns the branch <remote>/HEAD points at — the remote's
+// default branch as last recorded locally — or "" when it is not set.
+func RemoteHead(ctx context.Context, r Runner, remote string) string {
+	out, err := r.Run(ctx, "symbolic-ref", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(out), "refs/remotes/"+remote+"/")
+}
+
+// UpdateRemoteHead re-reads the remote's default branch and points
+// <remote>/HEAD at it (`git remote set-head <remote> --auto`).
+func UpdateRemoteHead(ctx context.Context, r Runner, remote string) error {
+	_, err := r.Run(ctx, "remote", "set-head", remote, "--auto")
+	return err
+}
+
 // AddRemote adds a new remote with the given name and URL.
 func AddRemote(ctx context.Context, r Runner, name, url string) error {
 	_, err := r.Run(ctx, "remote", "add", name, url)
 	return err
 }
 
+// RemoveRemote removes the named remote and its remote-tracking branches.
+func RemoveRemote(ctx context.Context, r Runner, name string) error {
+	_, err := r.Run(ctx, "remote", "remove", name)
+	return err
+}
+
 // ListRemotes returns the names of all configured remotes by running `git remote`.
 // It returns an empty (non-nil) slice when there are no remotes configured.
 func ListRemotes(ctx context.Context, r Runner) ([]string, error) {
@@ -104,6 +104,41 @@ func TestRemoteExistsFalse(t *testing.T) {
 	}
 }
 
+func TestRemoteHead(t *testing.T) {
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if strings.Join(args, " ") != "symbolic-ref refs/remotes/upstream/HEAD" {
+				t.Errorf("args = %v", args)
+			}
+			return "refs/remotes/upstream/release/next\n", nil
+		},
+	}
+	if got := RemoteHead(context.Background(), r, "upstream"); got != "release/next" {
+		t.Errorf("RemoteHead = %q, want release/next", got)
+	}
+
+	r.run = func(...string) (string, error) { return "", errors.New("not a symbolic ref") }
+	if got := RemoteHead(context.Background(), r, "origin"); got != "" {
+		t.Errorf("RemoteHead = %q, want empty when unset", got)
+	}
+}
+
+func TestUpdateRemoteHead(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			captured = args
+			return "", nil
+		},
+	}
+	if err := UpdateRemoteHead(context.Background(), r, "origin"); err != nil {
+		t.Fatalf("UpdateRemoteHead: %v", err)
+	}
+	if strings.Join(captured, " ") != "remote set-head origin --auto" {
+		t.Errorf("args = %v", captured)
+	}
+}
+
 func TestAddRemote(t *testing.T) {
 	var captured []string
 	r := &mockRunner{
@@ -137,6 +172,22 @@ func TestAddRemoteError(t *testing.T) {
 	}
 }
 
+func TestRemoveRemote(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			captured = args
+			return "", nil
+		},
+	}
+	if err := RemoveRemote(context.Background(), r, "gh-fork-alice"); err != nil {
+		t.Fatalf("RemoveRemote: %v", err)
+	}
+	if strings.Join(captured, " ") != "remote remove gh-fork-alice" {
+		t.Errorf("args = %v", captured)
+	}
+}
+
 func TestRemotePruneNormal(t *testing.T) {
 	var captured []string
 	r := &mockRunner{
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/lugassawan/rimba/internal/errhint"
@@ -106,6 +107,70 @@ func DefaultBranch(ctx context.Context, r Runner) (string, error) {
 	)
 }
 
+// ResolveRef returns the commit SHA that ref points to.
+func ResolveRef(ctx context.Context, r Runner, ref string) (string, error) {
+	return r.Run(ctx, cmdRevParse, flagVerify, flagEndOfOptions, ref+"^{commit}")
+}
+
+// ResolveRefs returns the commit SHA each of refs points at, keyed by ref,
+// in a single rev-parse. It fails when any of them does not resolve.
+func ResolveRefs(ctx context.Context, r Runner, refs []string) (map[string]string, error) {
+	args := []string{cmdRevParse, flagEndOfOptions}
+	for _, ref := range refs {
+		args = append(args, ref+"^{commit}")
+	}
+	out, err := r.Run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	// Older git versions echo --end-of-options back as if it were a revision.
+	shas := slices.DeleteFunc(strings.Fields(out), func(f string) bool { return f == flagEndOfOptions })
+	if len(shas) != len(refs) {
+		return nil, fmt.Errorf("rev-parse resolved %d of %d refs", len(shas), len(refs))
+	}
+	resolved := make(map[string]string, len(refs))
+	for i, ref := range refs {
+		resolved[ref] = shas[i]
+	}
+	return resolved, nil
+}
+
+// HeadSHA returns the commit SHA checked out in the worktree at dir.
+func HeadSHA(ctx context.Context, r Runner, dir string) (string, error) {
+	out, err := r.RunInDir(ctx, dir, cmdRevParse, flagVerify, "HEAD")
+	return strings.TrimSpace(out), err
+}
+
+// UpdateRef points ref at sha, creating the ref if needed. Used for
+// rimba-private refs (refs/rimba/...) that keep objects reachable.
+func UpdateRef(ctx context.Context, r Runner, ref, sha string) error {
+	if _, err := r.Run(ctx, "update-ref", ref, sha); err != nil {
+		return fmt.Errorf("update %s: %w", ref, err)
+	}
+	return nil
+}
+
+// DeleteRef removes ref. A ref that is already gone is not an error.
+func DeleteRef(ctx context.Context, r Runner, ref string) error {
+	if _, err := r.Run(ctx, "update-ref", "-d", ref); err != nil {
+		if _, resolveErr := ResolveRef(ctx, r, ref); resolveErr != nil {
+			return nil // already gone — idempotent
+		}
+		return fmt.Errorf("delete %s: %w", ref, err)
+	}
+	return nil
+}
+
+// ListRefs returns the full names of the refs under prefix, e.g.
+// "refs/rimba/prs/".
+func ListRefs(ctx context.Context, r Runner, prefix string) ([]string, error) {
+	out, err := r.Run(ctx, "for-each-ref", "--format=%(refname)", prefix)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
 // CommonDir returns the absolute path to the git common directory, shared by
 // the main worktree and every linked worktree. Exported for callers (e.g.
 // `rimba doctor`) that need to locate <commonDir>/worktrees/*/index.lock.
@@ -113,6 +178,14 @@ func CommonDir(ctx context.Context, r Runner) (string, error) {
 	return resolveCommonDir(ctx, r)
 }
 
+// UserName returns the configured user.name, falling back to user.email.
+func UserName(ctx context.Context, r Runner) (string, error) {
+	if name, err := r.Run(ctx, cmdConfig, "user.name"); err == nil && name != "" {
+		return name, nil
+	}
+	return r.Run(ctx, cmdConfig, "user.email")
+}
+
 // resolveCommonDir returns the absolute path to the git common directory.
 // --git-common-dir may return a relative path; this resolves it against the repo root.
 func resolveCommonDir(ctx context.Context, r Runner) (string, error) {
@@ -3,6 +3,8 @@ package git_test
 import (
 	"context"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/lugassawan/rimba/internal/git"
@@ -171,3 +173,95 @@ func TestDefaultBranch(t *testing.T) {
 		t.Errorf("DefaultBranch = %q, want %q", branch, "main")
 	}
 }
+
+func TestResolveRef(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	want := testutil.GitCmd(t, repo, "rev-parse", "HEAD")
+	got, err := git.ResolveRef(context.Background(), r, "main")
+	if err != nil {
+		t.Fatalf("ResolveRef: %v", err)
+	}
+	if got != strings.TrimSpace(want) {
+		t.Errorf("ResolveRef = %q, want %q", got, want)
+	}
+
+	if _, err := git.ResolveRef(context.Background(), r, "no-such-branch"); err == nil {
+		t.Error("expected error for unknown ref")
+	}
+}
+
+func TestUserName(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	testutil.GitCmd(t, repo, "config", "user.name", "Dana")
+	got, err := git.UserName(context.Background(), r)
+	if err != nil || got != "Dana" {
+		t.Errorf("UserName = %q, %v; want Dana", got, err)
+	}
+}
+
+func TestUpdateAndDeleteRef(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+	ctx := context.Background()
+	const ref = "refs/rimba/test/feature/login"
+
+	head := strings.TrimSpace(testutil.GitCmd(t, repo, "rev-parse", "HEAD"))
+	if err := git.UpdateRef(ctx, r, ref, head); err != nil {
+		t.Fatalf("UpdateRef: %v", err)
+	}
+	if got, err := git.ResolveRef(ctx, r, ref); err != nil || got != head {
+		t.Fatalf("ResolveRef = %q, %v; want %q", got, err, head)
+	}
+	if got, err := git.ListRefs(ctx, r, "refs/rimba/test/"); err != nil || !slices.Equal(got, []string{ref}) {
+		t.Fatalf("ListRefs = %v, %v; want [%s]", got, err, ref)
+	}
+
+	if err := git.DeleteRef(ctx, r, ref); err != nil {
+		t.Fatalf("DeleteRef: %v", err)
+	}
+	if _, err := git.ResolveRef(ctx, r, ref); err == nil {
+		t.Error("ref should be gone after DeleteRef")
+	}
+	if err := git.DeleteRef(ctx, r, ref); err != nil {
+		t.Errorf("DeleteRef on a missing ref = %v, want nil", err)
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+	ctx := context.Background()
+	testutil.GitCmd(t, repo, "branch", "feature/login")
+	head := strings.TrimSpace(testutil.GitCmd(t, repo, "rev-parse", "HEAD"))
+
+	got, err := git.ResolveRefs(ctx, r, []string{"HEAD", "feature/login"})
+	if err != nil {
+		t.Fatalf("ResolveRefs: %v", err)
+	}
+	if got["HEAD"] != head || got["feature/login"] != head {
+		t.Errorf("ResolveRefs = %v, want both at %s", got, head)
+	}
+	if _, err := git.ResolveRefs(ctx, r, []string{"HEAD", "no-such-branch"}); err == nil {
+		t.Error("ResolveRefs with an unknown ref: want an error")
+	}
+}
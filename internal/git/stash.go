@@ -28,6 +28,14 @@ func StashApply(r Runner, dir, sha string) error {
 	return err
 }
 
+// StashApplyIndex applies the stash identified by sha in dir, restoring the
+// index as well as the working tree so staged and unstaged changes come back
+// exactly as they were stashed.
+func StashApplyIndex(ctx context.Context, r Runner, dir, sha string) error {
+	_, err := r.RunInDir(ctx, dir, "stash", "apply", "--index", sha)
+	return err
+}
+
 // StashDrop drops the stash entry whose commit SHA matches sha.
 // git stash drop requires stash@{N} form; this function resolves the SHA to the ref first.
 // Intentionally non-cancellable: stash cleanup must complete to avoid orphaned stash entries.
@@ -88,3 +88,31 @@ func TestStashDrop(t *testing.T) {
 		t.Errorf("stash list should be empty after drop, got: %s", stashList)
 	}
 }
+
+func TestStashApplyIndexRestoresStagedChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegration)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+	testutil.CreateFile(t, repo, "staged.txt", "staged")
+	testutil.GitCmd(t, repo, "add", "staged.txt")
+	testutil.CreateFile(t, repo, "untracked.txt", "untracked")
+
+	sha, err := git.StashPushAndRef(context.Background(), r, repo, "index test")
+	if err != nil {
+		t.Fatalf("StashPushAndRef: %v", err)
+	}
+	if err := git.StashApplyIndex(context.Background(), r, repo, sha); err != nil {
+		t.Fatalf("StashApplyIndex: %v", err)
+	}
+
+	status := testutil.GitCmd(t, repo, "status", "--porcelain")
+	if !strings.Contains(status, "A  staged.txt") {
+		t.Errorf("staged.txt should be staged again, status:\n%s", status)
+	}
+	if !strings.Contains(status, "?? untracked.txt") {
+		t.Errorf("untracked.txt should be restored, status:\n%s", status)
+	}
+}
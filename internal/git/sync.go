@@ -2,9 +2,18 @@ package git
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
+// Sides of a conflict CheckoutSide can take.
+const (
+	CheckoutOurs   = "--ours"
+	CheckoutTheirs = "--theirs"
+)
+
 // FetchArgs configures Fetch. The zero value performs a plain `git fetch <remote>`.
 type FetchArgs struct {
 	Prune bool // append --prune: drop remote-tracking refs whose upstream branch was deleted
@@ -22,12 +31,26 @@ func Fetch(ctx context.Context, r Runner, remote string, args FetchArgs) error {
 	return err
 }
 
+// FetchRefspecs runs `git fetch --no-tags <remote> <refspec>...`, updating
+// the local refs the refspecs map the remote's refs to.
+func FetchRefspecs(ctx context.Context, r Runner, remote string, refspecs ...string) error {
+	_, err := r.Run(ctx, append([]string{"fetch", "--no-tags", remote}, refspecs...)...)
+	return err
+}
+
 // Rebase runs `git rebase <branch>` inside the given directory.
 func Rebase(ctx context.Context, r Runner, dir, branch string) error {
 	_, err := r.RunInDir(ctx, dir, "rebase", "--", branch)
 	return err
 }
 
+// RebaseOnto runs `git rebase --onto <onto> <upstream>` inside the given
+// directory, replaying the commits after upstream onto onto.
+func RebaseOnto(ctx context.Context, r Runner, dir, onto, upstream string) error {
+	_, err := r.RunInDir(ctx, dir, "rebase", "--onto="+onto, "--", upstream)
+	return err
+}
+
 // AbortRebase runs `git rebase --abort` inside the given directory.
 // Intentionally non-cancellable: rebase recovery must succeed even after Ctrl-C.
 func AbortRebase(r Runner, dir string) error {
@@ -81,3 +104,56 @@ func PushSetUpstream(ctx context.Context, r Runner, dir, remote, branch string)
 	_, err := r.RunInDir(ctx, dir, "push", "-u", remote, "--", branch)
 	return err
 }
+
+// RebaseInProgress reports whether a rebase is stopped in dir, waiting to be
+// continued or aborted.
+func RebaseInProgress(ctx context.Context, r Runner, dir string) (bool, error) {
+	gitDir, err := r.RunInDir(ctx, dir, cmdRevParse, "--absolute-git-dir")
+	if err != nil {
+		return false, fmt.Errorf("checking rebase state: %w", err)
+	}
+	for _, state := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(gitDir, state)); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RebaseContinue runs `git rebase --continue` inside dir, keeping each
+// commit's message rather than opening an editor.
+func RebaseContinue(ctx context.Context, r Runner, dir string) error {
+	_, err := r.RunInDir(ctx, dir, "-c", "core.editor=true", "rebase", "--continue")
+	return err
+}
+
+// MergeContinue concludes a stopped merge in dir by committing the resolved
+// index with the prepared merge message.
+func MergeContinue(ctx context.Context, r Runner, dir string) error {
+	_, err := r.RunInDir(ctx, dir, "commit", "--no-edit")
+	return err
+}
+
+// CheckoutSide resolves the conflicted paths in dir by checking out one
+// side's version: "--ours" or "--theirs". During a rebase ours is the branch
+// being rebased onto; during a merge it is the checked-out branch.
+func CheckoutSide(ctx context.Context, r Runner, dir, side string, paths []string) error {
+	args := append([]string{"checkout", side, "--"}, paths...)
+	_, err := r.RunInDir(ctx, dir, args...)
+	return err
+}
+
+// Add stages paths in dir's index, marking conflicted ones resolved.
+func Add(ctx context.Context, r Runner, dir string, paths []string) error {
+	args := append([]string{"add", "--"}, paths...)
+	_, err := r.RunInDir(ctx, dir, args...)
+	return err
+}
+
+// ResetHard runs `git reset --hard <sha>` inside dir, discarding the working
+// tree and moving the checked-out branch to sha.
+// Intentionally non-cancellable, like AbortRebase: rollback must complete.
+func ResetHard(r Runner, dir, sha string) error {
+	_, err := r.RunInDir(context.Background(), dir, "reset", "--hard", sha)
+	return err
+}
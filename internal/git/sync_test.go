@@ -3,7 +3,10 @@ package git
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/lugassawan/rimba/testutil"
@@ -60,6 +63,25 @@ func TestFetch(t *testing.T) {
 	}
 }
 
+func TestFetchRefspecs(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			captured = args
+			return "", nil
+		},
+	}
+
+	spec := "+refs/pull/7/head:refs/rimba/prs/7"
+	if err := FetchRefspecs(context.Background(), r, remoteOrigin, spec); err != nil {
+		t.Fatalf("FetchRefspecs: %v", err)
+	}
+	want := []string{"fetch", "--no-tags", remoteOrigin, spec}
+	if !slices.Equal(captured, want) {
+		t.Errorf("args = %v, want %v", captured, want)
+	}
+}
+
 func TestFetchError(t *testing.T) {
 	r := &mockRunner{
 		run: func(_ ...string) (string, error) {
@@ -130,6 +152,63 @@ func TestRebaseLeadingDashBranchName(t *testing.T) {
 	}
 }
 
+func TestRebaseOnto(t *testing.T) {
+	var capturedDir string
+	var capturedArgs []string
+	r := &mockRunner{
+		runInDir: func(dir string, args ...string) (string, error) {
+			capturedDir = dir
+			capturedArgs = args
+			return "", nil
+		},
+	}
+
+	if err := RebaseOnto(context.Background(), r, fakeDir, branchFeature, fakeSHA); err != nil {
+		t.Fatalf("RebaseOnto: %v", err)
+	}
+
+	if capturedDir != fakeDir {
+		t.Errorf("dir = %q, want %q", capturedDir, fakeDir)
+	}
+	want := []string{"rebase", "--onto=" + branchFeature, "--", fakeSHA}
+	if !slices.Equal(capturedArgs, want) {
+		t.Errorf("args = %v, want %v", capturedArgs, want)
+	}
+}
+
+func TestRebaseOntoReplaysOnlyChildCommits(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipIntegrationGit)
+	}
+
+	repo := testutil.NewTestRepo(t)
+	r := &ExecRunner{Dir: repo}
+
+	testutil.GitCmd(t, repo, "checkout", "-b", "parent")
+	testutil.CreateFile(t, repo, "parent.txt", "v1")
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "parent v1")
+	oldParent := strings.TrimSpace(testutil.GitCmd(t, repo, "rev-parse", "HEAD"))
+
+	testutil.GitCmd(t, repo, "checkout", "-b", "child")
+	testutil.CreateFile(t, repo, "child.txt", "child")
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "child work")
+
+	testutil.GitCmd(t, repo, "checkout", "parent")
+	testutil.GitCmd(t, repo, "commit", "--amend", "-m", "parent v1 amended")
+	testutil.GitCmd(t, repo, "checkout", "child")
+
+	if err := RebaseOnto(context.Background(), r, repo, "parent", oldParent); err != nil {
+		t.Fatalf("RebaseOnto: %v", err)
+	}
+
+	log := testutil.GitCmd(t, repo, "log", "--format=%s", "main..child")
+	if strings.Count(log, "\n") != 2 || !strings.Contains(log, "parent v1 amended") {
+		t.Errorf("child history = %q, want child work on top of the amended parent only", log)
+	}
+}
+
 func TestAbortRebase(t *testing.T) {
 	var capturedDir string
 	var capturedArgs []string
@@ -424,3 +503,68 @@ func TestPushSetUpstreamError(t *testing.T) {
 	}
 	assertContains(t, err, errPushFail)
 }
+
+func TestRebaseInProgress(t *testing.T) {
+	gitDir := t.TempDir()
+	r := &mockRunner{
+		runInDir: func(_ string, args ...string) (string, error) {
+			if slices.Contains(args, "--absolute-git-dir") {
+				return gitDir, nil
+			}
+			return "", nil
+		},
+	}
+
+	if stopped, err := RebaseInProgress(context.Background(), r, fakeDir); err != nil || stopped {
+		t.Errorf("RebaseInProgress = %v, %v; want false, nil", stopped, err)
+	}
+	if err := os.Mkdir(filepath.Join(gitDir, "rebase-merge"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if stopped, err := RebaseInProgress(context.Background(), r, fakeDir); err != nil || !stopped {
+		t.Errorf("RebaseInProgress = %v, %v; want true, nil", stopped, err)
+	}
+}
+
+func TestRebaseInProgressError(t *testing.T) {
+	r := &mockRunner{
+		runInDir: func(string, ...string) (string, error) { return "", errors.New("not a git repository") },
+	}
+	if _, err := RebaseInProgress(context.Background(), r, fakeDir); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestContinueAndResetArgs(t *testing.T) {
+	var captured []string
+	r := &mockRunner{
+		runInDir: func(_ string, args ...string) (string, error) {
+			captured = args
+			return "", nil
+		},
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{"rebase continue", func() error { return RebaseContinue(context.Background(), r, fakeDir) }, "-c core.editor=true rebase --continue"},
+		{"merge continue", func() error { return MergeContinue(context.Background(), r, fakeDir) }, "commit --no-edit"},
+		{"reset hard", func() error { return ResetHard(r, fakeDir, fakeSHA) }, "reset --hard " + fakeSHA},
+		{"checkout side", func() error {
+			return CheckoutSide(context.Background(), r, fakeDir, CheckoutTheirs, []string{"go.sum", "web/pnpm-lock.yaml"})
+		}, "checkout --theirs -- go.sum web/pnpm-lock.yaml"},
+		{"add", func() error { return Add(context.Background(), r, fakeDir, []string{"go.sum"}) }, "add -- go.sum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got := strings.Join(captured, " "); got != tt.want {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -40,6 +40,13 @@ func AddWorktreeFromBranch(ctx context.Context, r Runner, path, branch string) e
 	return err
 }
 
+// AddDetachedWorktree creates a worktree at path with ref checked out on a
+// detached HEAD, for scratch work that must not move any branch.
+func AddDetachedWorktree(ctx context.Context, r Runner, path, ref string) error {
+	_, err := r.Run(ctx, cmdWorktree, "add", "--detach", "--", path, ref)
+	return err
+}
+
 // RemoveWorktree removes the worktree at the given path.
 func RemoveWorktree(ctx context.Context, r Runner, path string, force bool) error {
 	args := []string{cmdWorktree, "remove"}
@@ -0,0 +1,170 @@
+// Package journal records rimba's destructive operations — removed
+// worktrees, deleted branches, renames — with enough state to reverse them.
+// Entries are kept newest last under the git common dir; files saved
+// alongside an entry live in their own directory keyed by the entry's ID.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/fsutil"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/stack"
+)
+
+// Action names the kind of change a Step made.
+type Action string
+
+// Step is one destructive change and what it takes to reverse it.
+type Step struct {
+	Action Action `json:"action"`
+	// Desc is the plan step as the operation described it.
+	Desc   string `json:"desc"`
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+	// SHA is the branch tip before a removal, pinned under Ref. Empty when
+	// the branch was kept.
+	SHA string `json:"sha,omitempty"`
+	// NewBranch and NewPath are where a rename moved Branch and Path.
+	NewBranch string `json:"new_branch,omitempty"`
+	NewPath   string `json:"new_path,omitempty"`
+	// Files lists the copy_files entries saved under FilesDir.
+	Files []string  `json:"files,omitempty"`
+	Meta  meta.Meta `json:"meta,omitzero"`
+	// Stack holds the stack links a removal rewrote, keyed by child branch:
+	// Branch's own and those of the branches stacked on it.
+	Stack map[string]stack.Link `json:"stack,omitempty"`
+}
+
+// Entry is one operation: every step it took, in order.
+type Entry struct {
+	ID    string    `json:"id"`
+	Op    string    `json:"op"`
+	At    time.Time `json:"at"`
+	Steps []Step    `json:"steps"`
+}
+
+// Journal holds the recorded entries, oldest first.
+type Journal struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Step actions.
+const (
+	ActionRemove Action = "remove"
+	ActionRename Action = "rename"
+)
+
+// MaxEntries caps the journal; older entries are dropped as new ones arrive.
+const MaxEntries = 50
+
+const (
+	// journalFile is where the journal lives, relative to the git common dir.
+	journalFile = "rimba/journal.json"
+	// filesDir holds each entry's saved files, relative to the git common dir.
+	filesDir = "rimba/journal"
+	// refPrefix namespaces the refs that keep deleted branch tips reachable.
+	refPrefix = "refs/rimba/journal/"
+)
+
+// NewEntry starts an entry for op, stamped now. Its ID is unique enough to
+// name the entry's files directory before the entry is appended.
+func NewEntry(op string) Entry {
+	now := time.Now().UTC()
+	return Entry{
+		ID: now.Format("20060102T150405.000000000"),
+		Op: op,
+		At: now.Truncate(time.Second),
+	}
+}
+
+// Load reads the journal under commonDir. A missing file yields an empty journal.
+func Load(commonDir string) (*Journal, error) {
+	j := &Journal{}
+	data, err := os.ReadFile(journalPath(commonDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return j, nil
+		}
+		return nil, fmt.Errorf("read undo journal: %w", err)
+	}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("parse undo journal: %w", err)
+	}
+	return j, nil
+}
+
+// Save writes the journal under commonDir atomically.
+func (j *Journal) Save(commonDir string) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal undo journal: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(journalPath(commonDir), data); err != nil {
+		return fmt.Errorf("write undo journal: %w", err)
+	}
+	return nil
+}
+
+// Append adds e and returns the entries dropped to stay within MaxEntries,
+// whose files the caller should remove.
+func (j *Journal) Append(e Entry) (dropped []Entry) {
+	j.Entries = append(j.Entries, e)
+	if over := len(j.Entries) - MaxEntries; over > 0 {
+		dropped = append(dropped, j.Entries[:over]...)
+		j.Entries = append([]Entry(nil), j.Entries[over:]...)
+	}
+	return dropped
+}
+
+// Latest returns up to n entries, newest first.
+func (j *Journal) Latest(n int) []Entry {
+	n = min(n, len(j.Entries))
+	out := make([]Entry, 0, n)
+	for i := len(j.Entries) - 1; i >= len(j.Entries)-n; i-- {
+		out = append(out, j.Entries[i])
+	}
+	return out
+}
+
+// Replace swaps in e for the entry with the same ID, or drops that entry
+// when e has no steps left.
+func (j *Journal) Replace(e Entry) {
+	for i := range j.Entries {
+		if j.Entries[i].ID != e.ID {
+			continue
+		}
+		if len(e.Steps) == 0 {
+			j.Entries = append(j.Entries[:i], j.Entries[i+1:]...)
+		} else {
+			j.Entries[i] = e
+		}
+		return
+	}
+}
+
+// EntryDir returns the directory holding the files saved with entry id.
+func EntryDir(commonDir, id string) string {
+	return filepath.Join(commonDir, filesDir, id)
+}
+
+// FilesDir returns where the copy_files entries of branch are saved for
+// entry id.
+func FilesDir(commonDir, id, branch string) string {
+	return filepath.Join(EntryDir(commonDir, id), filepath.FromSlash(branch))
+}
+
+// Ref returns the private ref that keeps the tip of branch, deleted by
+// entry id, from being garbage-collected until the entry is undone or
+// dropped.
+func Ref(id, branch string) string {
+	return refPrefix + id + "/" + branch
+}
+
+func journalPath(commonDir string) string {
+	return filepath.Join(commonDir, journalFile)
+}
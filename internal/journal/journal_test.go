@@ -0,0 +1,115 @@
+package journal_test
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/journal"
+	"github.com/lugassawan/rimba/internal/meta"
+)
+
+const branchAuth = "feature/auth"
+
+func TestLoadMissingReturnsEmpty(t *testing.T) {
+	j, err := journal.Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(j.Entries) != 0 {
+		t.Errorf("Entries = %v, want empty", j.Entries)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	e := journal.NewEntry("remove")
+	e.Steps = []journal.Step{{
+		Action: journal.ActionRemove,
+		Desc:   "remove worktree: /wt/auth",
+		Branch: branchAuth,
+		Path:   "/wt/auth",
+		SHA:    "abc123",
+		Files:  []string{".env"},
+		Meta:   meta.Meta{Note: "spike"},
+	}}
+	j := &journal.Journal{}
+	j.Append(e)
+	if err := j.Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := journal.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got.Entries) != 1 {
+		t.Fatalf("Entries = %d, want 1", len(got.Entries))
+	}
+	s := got.Entries[0].Steps[0]
+	if got.Entries[0].ID != e.ID || s.SHA != "abc123" || s.Meta.Note != "spike" || s.Files[0] != ".env" {
+		t.Errorf("entry = %+v", got.Entries[0])
+	}
+}
+
+func TestAppendDropsOldest(t *testing.T) {
+	j := &journal.Journal{}
+	for i := range journal.MaxEntries + 2 {
+		if dropped := j.Append(journal.Entry{ID: strconv.Itoa(i)}); i < journal.MaxEntries && len(dropped) != 0 {
+			t.Fatalf("entry %d dropped %v early", i, dropped)
+		}
+	}
+	if len(j.Entries) != journal.MaxEntries {
+		t.Fatalf("Entries = %d, want %d", len(j.Entries), journal.MaxEntries)
+	}
+	if j.Entries[0].ID != "2" {
+		t.Errorf("oldest kept = %s, want 2", j.Entries[0].ID)
+	}
+}
+
+func TestLatestNewestFirst(t *testing.T) {
+	j := &journal.Journal{}
+	for _, id := range []string{"a", "b", "c"} {
+		j.Append(journal.Entry{ID: id})
+	}
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 1, want: "c"},
+		{n: 2, want: "cb"},
+		{n: 10, want: "cba"},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, e := range j.Latest(tt.n) {
+			got += e.ID
+		}
+		if got != tt.want {
+			t.Errorf("Latest(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	j := &journal.Journal{}
+	j.Append(journal.Entry{ID: "a", Steps: []journal.Step{{Branch: "x"}, {Branch: "y"}}})
+	j.Append(journal.Entry{ID: "b", Steps: []journal.Step{{Branch: "z"}}})
+
+	j.Replace(journal.Entry{ID: "a", Steps: []journal.Step{{Branch: "x"}}})
+	if len(j.Entries[0].Steps) != 1 {
+		t.Errorf("steps = %v, want one left", j.Entries[0].Steps)
+	}
+	j.Replace(journal.Entry{ID: "b"})
+	if len(j.Entries) != 1 || j.Entries[0].ID != "a" {
+		t.Errorf("entries = %+v, want only a", j.Entries)
+	}
+}
+
+func TestFilesDir(t *testing.T) {
+	got := journal.FilesDir("/repo/.git", "id1", branchAuth)
+	want := filepath.Join("/repo/.git", "rimba", "journal", "id1", "feature", "auth")
+	if got != want {
+		t.Errorf("FilesDir = %s, want %s", got, want)
+	}
+}
@@ -118,6 +118,7 @@ func TestSyncWorktreeNotFoundHint(t *testing.T) {
 			return porcelain, nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -33,13 +34,18 @@ func (m *mockGhRunner) Run(ctx context.Context, args ...string) ([]byte, error)
 	return m.run(ctx, args...)
 }
 
-// mockRunner implements git.Runner for unit tests.
+// mockRunner implements git.Runner for unit tests. A non-empty commonDir
+// answers `git rev-parse --git-common-dir` ahead of run.
 type mockRunner struct {
-	run      func(args ...string) (string, error)
-	runInDir func(dir string, args ...string) (string, error)
+	run       func(args ...string) (string, error)
+	runInDir  func(dir string, args ...string) (string, error)
+	commonDir string
 }
 
 func (m *mockRunner) Run(_ context.Context, args ...string) (string, error) {
+	if m.commonDir != "" && len(args) >= 2 && args[0] == gitRevParse && args[1] == "--git-common-dir" {
+		return m.commonDir, nil
+	}
 	if m.run == nil {
 		return "", nil
 	}
@@ -66,6 +72,13 @@ func newGhAuthOK(prJSON string) *mockGhRunner {
 	}
 }
 
+// testCommonDir returns a git common dir under a temp dir, so the state a
+// tool reads and writes (recorded bases, journals) stays inside the test.
+func testCommonDir(t *testing.T) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), ".git")
+}
+
 // testConfig returns a minimal config suitable for testing.
 func testConfig() *config.Config {
 	return &config.Config{
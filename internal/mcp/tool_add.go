@@ -39,6 +39,12 @@ func registerAddTool(s *server.MCPServer, hctx *HandlerContext) {
 		mcp.WithBoolean("skip_hooks",
 			mcp.Description("Skip post-create hooks (applies to task and pr modes)"),
 		),
+		mcp.WithString("profile",
+			mcp.Description("Name of a [profiles.<name>] config section whose copy_files, post_create, deps, source, and type to use (applies to task and pr modes; source and type to task mode only)"),
+		),
+		mcp.WithBoolean("keep_on_failure",
+			mcp.Description("Keep a partially created worktree when a step fails instead of rolling back (applies to task and pr modes)"),
+		),
 	)
 	s.AddTool(tool, withRecorder(hctx, "add", handleAdd(hctx)))
 }
@@ -78,13 +84,19 @@ func handleAddTask(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRe
 	ps := hctx.PrefixSet()
 	service, task := operations.ResolveTaskInput(rawTask, hctx.RepoRoot, ps)
 
-	prefixType := resolveMCPPrefixType(req, rawTask, ps)
-
 	cfg, cfgErr := hctx.requireConfig()
 	if cfgErr != nil {
 		return errorResult(cfgErr), nil
 	}
 
+	profile := req.GetString("profile", "")
+	setupCfg, err := cfg.WithProfile(profile)
+	if err != nil {
+		return errorResult(err), nil
+	}
+	prefixType := resolveMCPPrefixType(req, rawTask, ps, cfg.Profiles[profile].Type)
+
+	// The gate checks cfg itself, whose command set covers every profile.
 	if err := trust.GateNonInteractive(hctx.RepoRoot, cfg); err != nil {
 		return errorResult(err), nil
 	}
@@ -97,7 +109,7 @@ func handleAddTask(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRe
 
 	source := req.GetString("source", "")
 	if source == "" {
-		source = cfg.DefaultSource
+		source = cfg.AddSource(profile, prefix)
 	}
 
 	result, err := operations.AddWorktree(ctx, hctx.Runner, operations.AddParams{
@@ -105,7 +117,8 @@ func handleAddTask(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRe
 		Service:           service,
 		Prefix:            prefix,
 		Source:            source,
-		PostCreateOptions: buildPostCreateOptions(hctx, cfg, req),
+		Base:              operations.TrackedBase(cfg, resolver.FullBranchName(service, prefix, task), source),
+		PostCreateOptions: buildPostCreateOptions(hctx, setupCfg, req).WithPrefixDefaults(cfg.PrefixDefaults(prefix)),
 	}, nil)
 	if err != nil {
 		return errorResult(err), nil
@@ -116,13 +129,14 @@ func handleAddTask(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRe
 		Branch: result.Branch,
 		Path:   result.Path,
 		Source: result.Source,
+		Steps:  result.Transaction.Completed,
 	})
 }
 
 // resolveMCPPrefixType falls back to rawTask's leading segment (mirroring
-// cmd/add.go's resolveAddPrefix) when "type" is omitted; the "type" enum
-// itself stays canonical-only.
-func resolveMCPPrefixType(req mcp.CallToolRequest, rawTask string, ps *resolver.PrefixSet) string {
+// cmd/add.go's resolveAddPrefix), then to fallback (the profile's type), when
+// "type" is omitted; the "type" enum itself stays canonical-only.
+func resolveMCPPrefixType(req mcp.CallToolRequest, rawTask string, ps *resolver.PrefixSet, fallback string) string {
 	if t := req.GetString("type", ""); t != "" {
 		return t
 	}
@@ -133,6 +147,9 @@ func resolveMCPPrefixType(req mcp.CallToolRequest, rawTask string, ps *resolver.
 		}
 	}
 
+	if fallback != "" {
+		return fallback
+	}
 	return string(resolver.DefaultPrefixType)
 }
 
@@ -157,10 +174,15 @@ func handleAddPR(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRequ
 		return errorResult(errors.New("gh runner not configured; this is a server startup bug")), nil
 	}
 
+	setupCfg, err := cfg.WithProfile(req.GetString("profile", ""))
+	if err != nil {
+		return errorResult(err), nil
+	}
+
 	result, err := operations.AddPRWorktree(ctx, hctx.Runner, hctx.GH, operations.AddPRParams{
 		PRNumber:          prNum,
 		TaskOverride:      req.GetString("task", ""),
-		PostCreateOptions: buildPostCreateOptions(hctx, cfg, req),
+		PostCreateOptions: buildPostCreateOptions(hctx, setupCfg, req),
 	}, nil)
 	if err != nil {
 		return errorResult(err), nil
@@ -171,6 +193,7 @@ func handleAddPR(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRequ
 		Branch: result.Branch,
 		Path:   result.Path,
 		Source: result.Source,
+		Steps:  result.Transaction.Completed,
 	})
 }
 
@@ -208,5 +231,6 @@ func buildPostCreateOptions(hctx *HandlerContext, cfg *config.Config, req mcp.Ca
 		SkipHooks:     req.GetBool("skip_hooks", false),
 		PostCreate:    cfg.PostCreate,
 		Concurrency:   cfg.DepsConcurrency(),
+		KeepOnFailure: req.GetBool("keep_on_failure", false),
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/trust"
 	"github.com/lugassawan/rimba/testutil"
@@ -127,6 +128,7 @@ func TestAddToolSuccessCustomSource(t *testing.T) {
 			return "", nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	cfg := testConfig()
 	cfg.CopyFiles = nil
 	hctx := testContext(r)
@@ -485,7 +487,7 @@ func TestAddToolWithDepsAndHooks(t *testing.T) {
 			}
 			// AddWorktree: create the directory
 			if len(args) > 0 && args[0] == gitWorktree && len(args) > 1 && args[1] == gitWorktreeAdd {
-				_ = os.MkdirAll(args[2], 0o755)
+				_ = os.MkdirAll(args[len(args)-2], 0o755)
 				return "", nil
 			}
 			// ListWorktrees for deps
@@ -561,7 +563,7 @@ func TestAddToolTrustGatePreTrusted(t *testing.T) {
 				return "", errors.New("not found")
 			}
 			if len(args) > 0 && args[0] == gitWorktree && len(args) > 1 && args[1] == gitWorktreeAdd {
-				_ = os.MkdirAll(args[2], 0o755)
+				_ = os.MkdirAll(args[len(args)-2], 0o755)
 				return "", nil
 			}
 			if len(args) > 0 && args[0] == gitWorktree && len(args) > 1 && args[1] == gitList {
@@ -593,7 +595,8 @@ func TestAddToolTrustGateEnvEscapeHatch(t *testing.T) {
 		t.Fatal(err)
 	}
 	cfg := testConfig()
-	cfg.PostCreate = []string{"pnpm install"}
+	// A hook that succeeds anywhere: a failing hook now rolls the add back.
+	cfg.PostCreate = []string{"true"}
 	cfg.CopyFiles = nil
 
 	r := &mockRunner{
@@ -602,7 +605,7 @@ func TestAddToolTrustGateEnvEscapeHatch(t *testing.T) {
 				return "", errors.New("not found")
 			}
 			if len(args) > 0 && args[0] == gitWorktree && len(args) > 1 && args[1] == gitWorktreeAdd {
-				_ = os.MkdirAll(args[2], 0o755)
+				_ = os.MkdirAll(args[len(args)-2], 0o755)
 				return "", nil
 			}
 			if len(args) > 0 && args[0] == gitWorktree && len(args) > 1 && args[1] == gitList {
@@ -965,7 +968,7 @@ func TestResolveMCPPrefixTypeCustomAlias(t *testing.T) {
 	req := mcplib.CallToolRequest{}
 	req.Params.Arguments = map[string]any{"task": "proj/my-task"}
 
-	got := resolveMCPPrefixType(req, "proj/my-task", ps)
+	got := resolveMCPPrefixType(req, "proj/my-task", ps, "")
 	if got != "PROJ-" {
 		t.Errorf("resolveMCPPrefixType = %q, want %q", got, "PROJ-")
 	}
@@ -979,7 +982,7 @@ func TestResolveMCPPrefixTypeExplicitTypeWins(t *testing.T) {
 	req := mcplib.CallToolRequest{}
 	req.Params.Arguments = map[string]any{"type": "hotfix"}
 
-	got := resolveMCPPrefixType(req, "feature/my-task", ps)
+	got := resolveMCPPrefixType(req, "feature/my-task", ps, "")
 	if got != "hotfix" {
 		t.Errorf("resolveMCPPrefixType = %q, want %q", got, "hotfix")
 	}
@@ -999,3 +1002,31 @@ func TestAddToolWithRecorderWrappingPreservesResponse(t *testing.T) {
 		t.Errorf("expected 'task is required' unchanged by wrapping, got: %s", errText)
 	}
 }
+
+func TestAddToolProfile(t *testing.T) {
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) > 0 && args[0] == gitRevParse {
+				return "", errors.New("not found")
+			}
+			return "", nil
+		},
+	}
+	r.commonDir = testCommonDir(t)
+	cfg := testConfig()
+	cfg.CopyFiles = nil
+	cfg.Profiles = map[string]config.Profile{"perf": {Source: "develop", Type: "chore"}}
+	hctx := &HandlerContext{Runner: r, Config: cfg, RepoRoot: t.TempDir(), Version: "test"}
+	handler := handleAdd(hctx)
+
+	result := callTool(t, handler, map[string]any{"task": "bench", "profile": "perf", "skip_deps": true, "skip_hooks": true})
+	data := unmarshalJSON[addResult](t, result)
+	if data.Branch != "chore/bench" || data.Source != "develop" {
+		t.Errorf("branch = %q, source = %q; want the profile's type and source", data.Branch, data.Source)
+	}
+
+	result = callTool(t, handler, map[string]any{"task": "bench", "profile": "nope"})
+	if errText := resultError(t, result); !strings.Contains(errText, `unknown profile "nope"`) {
+		t.Errorf("error = %s", errText)
+	}
+}
@@ -13,13 +13,13 @@ import (
 
 func registerArchiveTool(s *server.MCPServer, hctx *HandlerContext) {
 	tool := mcp.NewTool("archive",
-		mcp.WithDescription("Archive a worktree: remove its directory but keep the branch for later restoration with restore"),
+		mcp.WithDescription("Archive a worktree: remove its directory but keep the branch, uncommitted changes, and copy_files entries for later restoration with restore"),
 		mcp.WithString("task",
 			mcp.Description("Task identifier to archive (e.g. 'my-task' or 'auth-api/my-task' for monorepo)"),
 			mcp.Required(),
 		),
 		mcp.WithBoolean("force",
-			mcp.Description("Force archival even if the worktree has uncommitted changes"),
+			mcp.Description("Discard uncommitted changes instead of saving them for restore"),
 		),
 		mcp.WithBoolean("dry_run",
 			mcp.Description("Preview what would be archived without making changes"),
@@ -55,19 +55,22 @@ func handleArchive(hctx *HandlerContext) server.ToolHandlerFunc {
 
 		dryRun := req.GetBool("dry_run", false)
 		result, err := operations.ArchiveWorktree(ctx, hctx.Runner, operations.ArchiveParams{
-			Path:   wt.Path,
-			Branch: wt.Branch,
-			Force:  req.GetBool("force", false),
-			DryRun: dryRun,
+			Path:      wt.Path,
+			Branch:    wt.Branch,
+			CopyFiles: cfg.CopyFiles,
+			Force:     req.GetBool("force", false),
+			DryRun:    dryRun,
 		})
 		if err != nil {
 			return errorResult(err), nil
 		}
 
 		data := archiveResult{
-			Path:   result.Path,
-			Branch: result.Branch,
-			DryRun: dryRun,
+			Path:         result.Path,
+			Branch:       result.Branch,
+			DryRun:       dryRun,
+			SavedChanges: result.Snapshot.Stashed,
+			SavedFiles:   result.Snapshot.Files,
 		}
 		if dryRun && result.Plan != nil {
 			data.Steps = result.Plan.Steps
@@ -116,7 +116,9 @@ func mcpCleanMerged(ctx context.Context, r git.Runner, hctx *HandlerContext, dry
 		mergeRef = git.DefaultRemote + "/" + mainBranch
 	}
 
-	mergedResult, err := operations.FindMergedCandidates(ctx, r, mergeRef, mainBranch)
+	baseOf, _ := operations.BaseResolver(ctx, r, hctx.Config, mainBranch) // main as a guessed base finds fewer merged branches, never more
+	refOf := operations.BaseMergeRefs(ctx, r, mainBranch, mergeRef, baseOf)
+	mergedResult, err := operations.FindMergedCandidatesFor(ctx, r, mergeRef, mainBranch, refOf)
 	if err != nil {
 		return errorResult(err), nil
 	}
@@ -2,19 +2,41 @@ package mcp
 
 import (
 	"context"
+	"errors"
+	"slices"
 
+	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/conflict"
 	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 func registerConflictCheckTool(s *server.MCPServer, hctx *HandlerContext) {
 	tool := mcp.NewTool("conflict-check",
-		mcp.WithDescription("Detect file overlaps between worktree branches that may cause merge conflicts"),
+		mcp.WithDescription("Detect file overlaps between worktree branches that may cause merge conflicts; each overlap names its file's CODEOWNERS owners, and the overlaps are rolled up into a per-directory heatmap; includes the conflict graph as nodes (branches, numbered by merge plan step) and edges weighted by overlaps"),
 		mcp.WithBoolean("dry_merge",
 			mcp.Description("Simulate merges with git merge-tree to detect actual conflicts (requires git 2.38+)"),
 		),
+		mcp.WithBoolean("hunks",
+			mcp.Description("Compare the line ranges each branch changes, following renames; severity is high only for overlapping or adjacent lines"),
+		),
+		mcp.WithBoolean("include_worktree",
+			mcp.Description("Include each worktree's staged and unstaged changes; overlaps list the branches whose side is uncommitted"),
+		),
+		mcp.WithBoolean("include_untracked",
+			mcp.Description("Include each worktree's untracked files too (implies include_worktree)"),
+		),
+		mcp.WithBoolean("no_cache",
+			mcp.Description("Recompute every diff and dry merge instead of reusing results cached by commit SHA"),
+		),
+		mcp.WithString("owner",
+			mcp.Description("Only report overlaps (and dry merge conflicts) in files this CODEOWNERS owner owns, e.g. @org/team; requires a CODEOWNERS file"),
+		),
+		mcp.WithBoolean("with_prs",
+			mcp.Description("Also check the open PRs against the default branch (fetched into refs/rimba/prs/; requires gh); overlaps list the PRs involved with number and author"),
+		),
 	)
 	s.AddTool(tool, withRecorder(hctx, "conflict-check", handleConflictCheck(hctx)))
 }
@@ -29,6 +51,11 @@ func handleConflictCheck(hctx *HandlerContext) server.ToolHandlerFunc {
 		}
 
 		r := hctx.Runner
+		owners := operations.LoadCodeOwners(ctx, r)
+		owner := req.GetString("owner", "")
+		if owner != "" && owners.Empty() {
+			return errorResult(errors.New("owner needs a CODEOWNERS file, and none was found")), nil
+		}
 
 		worktrees, err := operations.ListWorktreeInfos(ctx, r)
 		if err != nil {
@@ -40,36 +67,96 @@ func handleConflictCheck(hctx *HandlerContext) server.ToolHandlerFunc {
 		eligible := operations.FilterEligible(worktrees, prefixes, cfg.DefaultSource, allTasks, true)
 
 		if len(eligible) == 0 {
+			empty := conflict.BuildGraph(nil, nil, nil, nil)
 			return marshalResult(conflictCheckData{
 				Overlaps: make([]overlapItem, 0),
+				Heatmap:  make([]conflict.DirHeat, 0),
+				Nodes:    empty.Nodes,
+				Edges:    empty.Edges,
 			})
 		}
 
-		diffs, err := conflict.CollectDiffs(ctx, r, cfg.DefaultSource, eligible)
+		untracked := req.GetBool("include_untracked", false)
+		opts := conflict.Options{
+			Hunks:     req.GetBool("hunks", false),
+			Worktree:  req.GetBool("include_worktree", false) || untracked,
+			Untracked: untracked,
+			Cache:     conflictCache(ctx, hctx, req),
+		}
+		branches, prs, err := withOpenPRs(ctx, hctx, req, cfg, eligible)
 		if err != nil {
 			return errorResult(err), nil
 		}
-
-		result := conflict.DetectOverlaps(diffs)
+		baseOf, _ := operations.BaseResolver(ctx, r, cfg, cfg.DefaultSource) // a report: configured bases will do
+		result, err := conflict.Analyze(ctx, r, baseOf, branches, opts)
+		if err != nil {
+			return errorResult(err), nil
+		}
+		conflict.AttachPRs(result, prs)
+		conflict.AttachOwners(result, owners)
+		if owner != "" {
+			result.Overlaps = conflict.OwnedOverlaps(result.Overlaps, owner)
+		}
 
 		data := conflictCheckData{
 			Overlaps:      processOverlaps(result),
 			TotalFiles:    result.TotalFiles,
 			TotalBranches: result.TotalBranches,
+			Heatmap:       conflict.Heatmap(result.Overlaps),
 		}
 
+		planOpts, err := operations.MergePlanOptions(ctx, r, eligible, prefixes, nil)
+		if err != nil {
+			return errorResult(err), nil
+		}
 		if dryMerge {
-			dryResults, err := conflict.DryMergeAll(ctx, r, eligible)
+			dryResults, err := conflict.DryMergeAllCached(ctx, r, branches, opts.Cache)
 			if err != nil {
 				return errorResult(err), nil
 			}
-			data.DryMerges = processDryMerges(dryResults)
+			planOpts.DryMerges = conflict.AttachDryMergePRs(dryResults, prs)
+			if owner != "" {
+				planOpts.DryMerges = conflict.OwnedDryMerges(planOpts.DryMerges, owners, owner)
+			}
+			data.DryMerges = processDryMerges(planOpts.DryMerges)
+		}
+		_ = opts.Cache.Save() // best-effort: the next run recomputes what is missing
+
+		_, graph, err := operations.PlanMergeGraph(result.Overlaps, eligible, prs, planOpts)
+		if err != nil {
+			return errorResult(err), nil
 		}
+		data.Nodes, data.Edges = graph.Nodes, graph.Edges
 
 		return marshalResult(data)
 	}
 }
 
+// conflictCache loads the conflict analysis cache, or returns nil — no
+// caching — when no_cache is set.
+func conflictCache(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRequest) *conflict.Cache {
+	if req.GetBool("no_cache", false) {
+		return nil
+	}
+	return operations.LoadConflictCache(ctx, hctx.Runner)
+}
+
+// withOpenPRs adds the open PRs against the default branch to branches
+// when with_prs is set, returning the PRs keyed by their fetched ref.
+func withOpenPRs(ctx context.Context, hctx *HandlerContext, req mcp.CallToolRequest, cfg *config.Config, branches []resolver.WorktreeInfo) ([]resolver.WorktreeInfo, map[string]conflict.PR, error) {
+	if !req.GetBool("with_prs", false) {
+		return branches, nil, nil
+	}
+	if hctx.GH == nil {
+		return nil, nil, errors.New("gh runner not configured; this is a server startup bug")
+	}
+	open, err := operations.FetchOpenPRs(ctx, hctx.Runner, hctx.GH, cfg.DefaultSource, branches, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(slices.Clone(branches), open.Branches...), open.PRs, nil
+}
+
 // processOverlaps converts conflict detection results to overlap items.
 func processOverlaps(result *conflict.CheckResult) []overlapItem {
 	overlaps := make([]overlapItem, 0, len(result.Overlaps))
@@ -78,6 +165,12 @@ func processOverlaps(result *conflict.CheckResult) []overlapItem {
 			File:     o.File,
 			Branches: o.Branches,
 			Severity: string(o.Severity),
+			Lines:    o.Lines,
+			Renames:  o.Renames,
+
+			Uncommitted: o.Uncommitted,
+			PRs:         o.PRs,
+			Owners:      o.Owners,
 		})
 	}
 	return overlaps
@@ -92,6 +185,7 @@ func processDryMerges(dryResults []conflict.DryMergeResult) []dryMergeItem {
 			Branch2:       dr.Branch2,
 			HasConflicts:  dr.HasConflicts,
 			ConflictFiles: dr.ConflictFiles,
+			PRs:           dr.PRs,
 		}
 	}
 	return merges
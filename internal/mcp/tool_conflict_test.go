@@ -2,8 +2,13 @@ package mcp
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/lugassawan/rimba/internal/conflict"
 )
 
 const (
@@ -409,3 +414,204 @@ func TestConflictCheckToolMultipleOverlappingFiles(t *testing.T) {
 		t.Errorf("expected second overlap 'shared2.go', got %q", data.Overlaps[1].File)
 	}
 }
+
+func TestConflictCheckToolHunks(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-a", "feature/task-a"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-b", "feature/task-b"},
+	)
+
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == gitWorktree && args[1] == gitList {
+				return porcelain, nil
+			}
+			// CollectHunksFrom: git diff -U0 -M ... main...branch
+			if len(args) >= 2 && args[0] == gitDiff && args[1] == "-U0" {
+				if strings.Contains(args[len(args)-1], "feature/task-a") {
+					return "diff --git a/shared.go b/shared.go\n@@ -10,5 +10,5 @@", nil
+				}
+				return "diff --git a/shared.go b/shared.go\n@@ -14,2 +14,2 @@", nil
+			}
+			return "", nil
+		},
+	}
+	handler := handleConflictCheck(testContext(r))
+
+	result := callTool(t, handler, map[string]any{"hunks": true})
+	data := unmarshalJSON[conflictCheckData](t, result)
+
+	if len(data.Overlaps) != 1 {
+		t.Fatalf("expected 1 overlap, got %d", len(data.Overlaps))
+	}
+	overlap := data.Overlaps[0]
+	if overlap.Severity != "high" {
+		t.Errorf("expected severity 'high' for overlapping lines, got %q", overlap.Severity)
+	}
+	if len(overlap.Lines) != 1 || overlap.Lines[0].Start != 10 || overlap.Lines[0].End != 15 {
+		t.Errorf("lines = %+v, want 10-15", overlap.Lines)
+	}
+}
+
+func TestConflictCheckToolIncludeWorktree(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-a", "feature/task-a"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-b", "feature/task-b"},
+	)
+
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == gitWorktree && args[1] == gitList {
+				return porcelain, nil
+			}
+			if len(args) >= 3 && args[0] == gitDiff && strings.Contains(args[len(args)-1], "feature/task-a") {
+				return fileShared, nil
+			}
+			return "", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			if args[0] == gitDiff && strings.HasSuffix(dir, "feature-task-b") {
+				return fileShared, nil
+			}
+			return "", nil
+		},
+	}
+	handler := handleConflictCheck(testContext(r))
+
+	result := callTool(t, handler, map[string]any{"include_worktree": true})
+	data := unmarshalJSON[conflictCheckData](t, result)
+
+	if len(data.Overlaps) != 1 {
+		t.Fatalf("expected 1 overlap, got %d", len(data.Overlaps))
+	}
+	if got := data.Overlaps[0].Uncommitted; len(got) != 1 || got[0] != "feature/task-b" {
+		t.Errorf("uncommitted = %v, want feature/task-b", got)
+	}
+}
+
+func TestConflictCheckToolWithPRs(t *testing.T) {
+	ghDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ghDir, "gh"), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", ghDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-a", "feature/task-a"},
+	)
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == gitWorktree && args[1] == gitList {
+				return porcelain, nil
+			}
+			if len(args) >= 2 && args[0] == gitDiff && args[1] == diffNameOnly {
+				if strings.Contains(args[len(args)-1], "refs/rimba/prs/7") {
+					return fileShared + "\npr.go", nil
+				}
+				return fileShared, nil
+			}
+			return "", nil
+		},
+	}
+	hctx := testContext(r)
+	hctx.GH = newGhAuthOK(`[{"number":7,"author":{"login":"alice"},"headRefName":"search"}]`)
+
+	result := callTool(t, handleConflictCheck(hctx), map[string]any{"with_prs": true})
+	data := unmarshalJSON[conflictCheckData](t, result)
+
+	if len(data.Overlaps) != 1 {
+		t.Fatalf("expected 1 overlap, got %+v", data.Overlaps)
+	}
+	want := []conflict.PR{{Ref: "refs/rimba/prs/7", Number: 7, Author: "alice"}}
+	if got := data.Overlaps[0].PRs; !reflect.DeepEqual(got, want) {
+		t.Errorf("prs = %+v, want %+v", got, want)
+	}
+}
+
+func TestConflictCheckToolWithPRsNilGHRunner(t *testing.T) {
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == gitWorktree && args[1] == gitList {
+				return worktreePorcelain(
+					struct{ path, branch string }{"/repo", "main"},
+					struct{ path, branch string }{"/repo/.worktrees/feature-task-a", "feature/task-a"},
+				), nil
+			}
+			return "", nil
+		},
+	}
+
+	result := callTool(t, handleConflictCheck(testContext(r)), map[string]any{"with_prs": true})
+	if errText := resultError(t, result); !strings.Contains(errText, "server startup bug") {
+		t.Errorf("expected startup bug error for nil GH, got: %s", errText)
+	}
+}
+
+// ownersRunner serves two worktrees both changing shared.go, in a
+// repository rooted at a temp dir holding codeowners as its CODEOWNERS.
+func ownersRunner(t *testing.T, codeowners string) *mockRunner {
+	t.Helper()
+	root := t.TempDir()
+	if codeowners != "" {
+		if err := os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte(codeowners), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-a", "feature/task-a"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-b", "feature/task-b"},
+	)
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == gitWorktree && args[1] == gitList {
+				return porcelain, nil
+			}
+			if len(args) >= 2 && args[0] == gitRevParse && args[1] == "--git-common-dir" {
+				return filepath.Join(root, ".git"), nil
+			}
+			if len(args) >= 2 && args[0] == gitDiff && args[1] == diffNameOnly {
+				return "pkg/" + fileShared, nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestConflictCheckToolOwnersAndHeatmap(t *testing.T) {
+	result := callTool(t, handleConflictCheck(testContext(ownersRunner(t, "/pkg/ @org/core\n"))), nil)
+	data := unmarshalJSON[conflictCheckData](t, result)
+
+	if len(data.Overlaps) != 1 || !reflect.DeepEqual(data.Overlaps[0].Owners, []string{"@org/core"}) {
+		t.Fatalf("overlaps = %+v, want pkg/shared.go owned by @org/core", data.Overlaps)
+	}
+	want := []conflict.DirHeat{{Dir: "pkg", Overlaps: 1, Branches: []string{"feature/task-a", "feature/task-b"}}}
+	if !reflect.DeepEqual(data.Heatmap, want) {
+		t.Errorf("heatmap = %+v, want %+v", data.Heatmap, want)
+	}
+}
+
+func TestConflictCheckToolOwnerFilter(t *testing.T) {
+	handler := handleConflictCheck(testContext(ownersRunner(t, "/pkg/ @org/core\n")))
+
+	data := unmarshalJSON[conflictCheckData](t, callTool(t, handler, map[string]any{"owner": "@org/web"}))
+	if len(data.Overlaps) != 0 || len(data.Heatmap) != 0 {
+		t.Errorf("data = %+v, want no overlaps owned by @org/web", data)
+	}
+
+	data = unmarshalJSON[conflictCheckData](t, callTool(t, handler, map[string]any{"owner": "org/core"}))
+	if len(data.Overlaps) != 1 {
+		t.Errorf("overlaps = %+v, want the one owned by @org/core", data.Overlaps)
+	}
+}
+
+func TestConflictCheckToolOwnerWithoutCodeOwners(t *testing.T) {
+	result := callTool(t, handleConflictCheck(testContext(ownersRunner(t, ""))), map[string]any{"owner": "@org/core"})
+
+	if errText := resultError(t, result); !strings.Contains(errText, "CODEOWNERS") {
+		t.Errorf("expected a missing CODEOWNERS error, got: %s", errText)
+	}
+}
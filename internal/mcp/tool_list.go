@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/operations"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -28,6 +29,12 @@ func registerListTool(s *server.MCPServer, hctx *HandlerContext) {
 		mcp.WithBoolean("archived",
 			mcp.Description("Show archived branches instead of active worktrees"),
 		),
+		mcp.WithString("tag",
+			mcp.Description("Only show worktrees carrying this tag (see rimba tag)"),
+		),
+		mcp.WithString("owner",
+			mcp.Description("Only show worktrees with this owner (see rimba note --owner)"),
+		),
 	)
 	s.AddTool(tool, withRecorder(hctx, "list", handleList(hctx)))
 }
@@ -38,6 +45,7 @@ func handleList(hctx *HandlerContext) server.ToolHandlerFunc {
 		dirty := req.GetBool("dirty", false)
 		behind := req.GetBool("behind", false)
 		archived := req.GetBool("archived", false)
+		metaFilter := meta.Filter{Tag: req.GetString("tag", ""), Owner: req.GetString("owner", "")}
 
 		r := hctx.Runner
 
@@ -60,6 +68,7 @@ func handleList(hctx *HandlerContext) server.ToolHandlerFunc {
 			Dirty:       dirty,
 			Behind:      behind,
 			WorktreeDir: filepath.Join(hctx.RepoRoot, cfg.WorktreeDir),
+			Meta:        metaFilter,
 		})
 		if err != nil {
 			return errorResult(err), nil
@@ -79,6 +88,8 @@ func detailsToListItems(rows []resolver.WorktreeDetail) []listItem {
 			Path:      row.Path,
 			IsCurrent: false,
 			Status:    row.Status,
+			Parent:    row.Parent,
+			Meta:      row.Meta,
 		}
 	}
 	return items
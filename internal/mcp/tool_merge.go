@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 
+	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/errhint"
 	"github.com/lugassawan/rimba/internal/operations"
+	"github.com/lugassawan/rimba/internal/trust"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -29,6 +31,15 @@ func registerMergeTool(s *server.MCPServer, hctx *HandlerContext) {
 		mcp.WithBoolean("delete",
 			mcp.Description("Delete source worktree after merging into another worktree"),
 		),
+		mcp.WithBoolean("squash",
+			mcp.Description("Squash the source's commits into one commit on the target"),
+		),
+		mcp.WithString("message",
+			mcp.Description("Commit message for squash (default: generated from the branch type, task and commits)"),
+		),
+		mcp.WithBoolean("no_verify",
+			mcp.Description("Skip the [merge] verify commands run against the merge result before it lands"),
+		),
 	)
 	s.AddTool(tool, withRecorder(hctx, "merge", handleMerge(hctx)))
 }
@@ -57,6 +68,20 @@ func handleMerge(hctx *HandlerContext) server.ToolHandlerFunc {
 			return errorResult(err), nil
 		}
 
+		verify, err := mergeVerify(hctx, cfg, req.GetBool("no_verify", false))
+		if err != nil {
+			return errorResult(err), nil
+		}
+		lockfiles, err := lockfileResolver(hctx, cfg)
+		if err != nil {
+			return errorResult(err), nil
+		}
+
+		baseOf, err := operations.BaseResolver(ctx, hctx.Runner, cfg, cfg.DefaultSource)
+		if err != nil {
+			return errorResult(err), nil
+		}
+
 		intoTask := req.GetString("into", "")
 		var intoService string
 		if intoTask != "" {
@@ -71,9 +96,14 @@ func handleMerge(hctx *HandlerContext) server.ToolHandlerFunc {
 			IntoService:   intoService,
 			RepoRoot:      hctx.RepoRoot,
 			MainBranch:    cfg.DefaultSource,
+			Base:          baseOf(source.Branch),
 			NoFF:          req.GetBool("no_ff", false),
 			Keep:          req.GetBool("keep", false),
 			Delete:        req.GetBool("delete", false),
+			Lockfiles:     lockfiles,
+			Squash:        req.GetBool("squash", false),
+			Message:       req.GetString("message", ""),
+			Verify:        verify,
 		}, nil)
 		if err != nil {
 			return errorResult(err), nil
@@ -84,6 +114,44 @@ func handleMerge(hctx *HandlerContext) server.ToolHandlerFunc {
 			Into:          result.TargetLabel,
 			SourceRemoved: result.SourceRemoved,
 			RemoteDeleted: result.RemoteDeleted,
+
+			LockfilesRegenerated: result.LockfilesRegenerated,
+			Squashed:             result.Squashed,
+			CommitMessage:        result.CommitMessage,
+			Verify:               toMergeVerifyResult(result.Verify),
 		})
 	}
 }
+
+// mergeVerify returns the [merge] verify commands to run, nil when noVerify
+// is set, once the trust gate has approved them.
+func mergeVerify(hctx *HandlerContext, cfg *config.Config, noVerify bool) ([]string, error) {
+	verify := cfg.MergeVerify()
+	if noVerify || len(verify) == 0 {
+		return nil, nil
+	}
+	if err := trust.GateNonInteractive(hctx.RepoRoot, cfg); err != nil {
+		return nil, err
+	}
+	return verify, nil
+}
+
+// lockfileResolver returns the resolver for lockfile-only conflicts — nil
+// unless [deps] resolve_lockfiles is set — once the trust gate has approved
+// the install commands it runs. Sync uses it too.
+func lockfileResolver(hctx *HandlerContext, cfg *config.Config) (*operations.LockfileResolver, error) {
+	if !cfg.ResolveLockfiles() {
+		return nil, nil
+	}
+	if err := trust.GateNonInteractive(hctx.RepoRoot, cfg); err != nil {
+		return nil, err
+	}
+	return operations.NewLockfileResolver(cfg), nil
+}
+
+func toMergeVerifyResult(v *operations.MergeVerify) *mergeVerifyResult {
+	if v == nil {
+		return nil
+	}
+	return &mergeVerifyResult{Commands: v.Commands, Passed: v.Passed, Failure: v.Failure}
+}
@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"context"
+	"strings"
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/conflict"
@@ -13,13 +14,28 @@ import (
 
 func registerMergePlanTool(s *server.MCPServer, hctx *HandlerContext) {
 	tool := mcp.NewTool("merge-plan",
-		mcp.WithDescription("Recommend a merge order for active worktree branches that minimizes conflicts"),
+		mcp.WithDescription("Recommend a merge order for active worktree branches that minimizes conflicts, keeping stacked branches after their parents; each step gives the reason for its place. Includes the conflict graph as nodes (branches, numbered by step) and edges weighted by conflicts"),
+		mcp.WithBoolean("dry_merge",
+			mcp.Description("Weigh branches by the files git merge-tree finds conflicting rather than their overlaps (requires git 2.38+)"),
+		),
+		mcp.WithString("first",
+			mcp.Description("Comma-separated tasks pinned to lead the plan, in this order"),
+		),
+		mcp.WithBoolean("hunks",
+			mcp.Description("Weigh branches by the line ranges they both change rather than the files they share"),
+		),
+		mcp.WithBoolean("no_cache",
+			mcp.Description("Recompute every diff instead of reusing results cached by commit SHA"),
+		),
+		mcp.WithBoolean("with_prs",
+			mcp.Description("Count overlaps with the open PRs against the default branch toward each branch's conflicts (requires gh); the PRs take no step"),
+		),
 	)
 	s.AddTool(tool, withRecorder(hctx, "merge-plan", handleMergePlan(hctx)))
 }
 
 func handleMergePlan(hctx *HandlerContext) server.ToolHandlerFunc {
-	return func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		cfg, err := hctx.requireConfig()
 		if err != nil {
 			return errorResult(err), nil
@@ -41,28 +57,64 @@ func handleMergePlan(hctx *HandlerContext) server.ToolHandlerFunc {
 		eligible := operations.FilterEligible(worktrees, prefixes, cfg.DefaultSource, allTasks, true)
 
 		if len(eligible) == 0 {
-			return marshalResult(mergePlanResult{Steps: []mergePlanStep{}})
+			empty := conflict.BuildGraph(nil, nil, nil, nil)
+			return marshalResult(mergePlanResult{Steps: []mergePlanStep{}, Exact: true, Nodes: empty.Nodes, Edges: empty.Edges})
 		}
 
-		diffs, err := conflict.CollectDiffs(ctx, r, cfg.DefaultSource, eligible)
+		planOpts, err := operations.MergePlanOptions(ctx, r, eligible, prefixes, splitTasks(req.GetString("first", "")))
 		if err != nil {
 			return errorResult(err), nil
 		}
+		branches, prs, err := withOpenPRs(ctx, hctx, req, cfg, eligible)
+		if err != nil {
+			return errorResult(err), nil
+		}
+		opts := conflict.Options{Hunks: req.GetBool("hunks", false), Cache: conflictCache(ctx, hctx, req)}
+		baseOf, _ := operations.BaseResolver(ctx, r, cfg, cfg.DefaultSource) // a report: configured bases will do
+		overlapResult, err := conflict.Analyze(ctx, r, baseOf, branches, opts)
+		if err != nil {
+			return errorResult(err), nil
+		}
+		if req.GetBool("dry_merge", false) {
+			planOpts.DryMerges, err = conflict.DryMergeAllCached(ctx, r, branches, opts.Cache)
+			if err != nil {
+				return errorResult(err), nil
+			}
+		}
+		_ = opts.Cache.Save() // best-effort: the next run recomputes what is missing
 
-		overlapResult := conflict.DetectOverlaps(diffs)
-
-		branchNames := make([]string, len(eligible))
-		for i, wt := range eligible {
-			branchNames[i] = wt.Branch
+		plan, graph, err := operations.PlanMergeGraph(overlapResult.Overlaps, eligible, prs, planOpts)
+		if err != nil {
+			return errorResult(err), nil
 		}
 
-		steps := conflict.PlanMergeOrder(overlapResult.Overlaps, branchNames)
+		return marshalResult(mergePlanResult{
+			Steps: toMergePlanSteps(plan.Steps, prefixes, prs),
+			Exact: plan.Exact,
+			Nodes: graph.Nodes,
+			Edges: graph.Edges,
+		})
+	}
+}
 
-		return marshalResult(mergePlanResult{Steps: toMergePlanSteps(steps, prefixes)})
+// splitTasks splits a comma-separated task list, dropping empty entries.
+func splitTasks(s string) []string {
+	var tasks []string
+	for t := range strings.SplitSeq(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tasks = append(tasks, t)
+		}
 	}
+	return tasks
 }
 
-func toMergePlanSteps(steps []conflict.MergeStep, prefixes []string) []mergePlanStep {
+func toMergePlanSteps(steps []conflict.MergeStep, prefixes []string, prs map[string]conflict.PR) []mergePlanStep {
+	label := func(branch string) string {
+		if pr, ok := prs[branch]; ok {
+			return pr.Label()
+		}
+		return branch
+	}
 	result := make([]mergePlanStep, len(steps))
 	for i, step := range steps {
 		task, _ := resolver.TaskAndType(step.Branch, prefixes)
@@ -71,6 +123,9 @@ func toMergePlanSteps(steps []conflict.MergeStep, prefixes []string) []mergePlan
 			Task:      task,
 			Branch:    step.Branch,
 			Conflicts: step.Conflicts,
+			Against:   step.Against,
+			Pinned:    step.Pinned,
+			Reason:    step.Explain(label),
 		}
 	}
 	return result
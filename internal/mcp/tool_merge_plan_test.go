@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/conflict"
 )
 
 func TestMergePlanToolRequiresConfig(t *testing.T) {
@@ -87,10 +88,25 @@ func TestMergePlanToolHappyPath(t *testing.T) {
 		{Order: 3, Task: "task-b", Branch: "feature/task-b", Conflicts: 0},
 	}
 	for i, w := range want {
-		if data.Steps[i] != w {
-			t.Errorf("step %d = %+v, want %+v", i, data.Steps[i], w)
+		got := data.Steps[i]
+		if got.Order != w.Order || got.Task != w.Task || got.Branch != w.Branch || got.Conflicts != w.Conflicts {
+			t.Errorf("step %d = %+v, want %+v", i, got, w)
 		}
 	}
+	if got := data.Steps[1].Reason; got != "conflicts with feature/task-b (1)" {
+		t.Errorf("step 2 reason = %q", got)
+	}
+	if !data.Exact {
+		t.Error("expected an exact plan for 3 branches")
+	}
+
+	if len(data.Nodes) != 3 || data.Nodes[0].Branch != "feature/task-c" || data.Nodes[0].Order != 1 {
+		t.Errorf("nodes = %+v, want task-c first at step 1", data.Nodes)
+	}
+	wantEdge := conflict.GraphEdge{From: "feature/task-a", To: "feature/task-b", Weight: 1}
+	if len(data.Edges) != 1 || data.Edges[0] != wantEdge {
+		t.Errorf("edges = %+v, want %+v", data.Edges, wantEdge)
+	}
 }
 
 func TestMergePlanToolListWorktreesFails(t *testing.T) {
@@ -151,6 +151,7 @@ func TestMergeToolHappyPathMergeToMain(t *testing.T) {
 	)
 
 	r := mergeHappyPathRunner(porcelain, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -176,6 +177,7 @@ func TestMergeToolKeepSourceWorktree(t *testing.T) {
 
 	var removeWorktreeCalled bool
 	r := mergeHappyPathRunner(porcelain, &removeWorktreeCalled)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -190,6 +192,42 @@ func TestMergeToolKeepSourceWorktree(t *testing.T) {
 	}
 }
 
+func TestMergeToolSquashWithMessage(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", branchFeatureMyTask},
+	)
+
+	happy := mergeHappyPathRun(porcelain, nil)
+	var committed []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 1 && args[0] == "log" {
+				return "add form\nadd tests", nil
+			}
+			return happy(args...)
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			if len(args) >= 1 && args[0] == "commit" {
+				committed = args
+			}
+			return "", nil
+		},
+	}
+	r.commonDir = testCommonDir(t)
+	handler := handleMerge(testContext(r))
+
+	result := callTool(t, handler, map[string]any{"source": "my-task", "squash": true, "message": "Ship my task"})
+	data := unmarshalJSON[mergeResult](t, result)
+
+	if data.Squashed != 2 || data.CommitMessage != "Ship my task" {
+		t.Errorf("squashed = %d, commit_message = %q; want 2 commits with the given message", data.Squashed, data.CommitMessage)
+	}
+	if len(committed) == 0 || committed[len(committed)-1] != "Ship my task" {
+		t.Errorf("commit args = %v, want the given message committed", committed)
+	}
+}
+
 func TestMergeToolIntoAnotherWorktree(t *testing.T) {
 	porcelain := worktreePorcelain(
 		struct{ path, branch string }{"/repo", "main"},
@@ -218,6 +256,7 @@ func TestMergeToolIntoAnotherWorktree(t *testing.T) {
 			return "", nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -249,6 +288,7 @@ func TestMergeToolIntoAnotherWorktreeWithDelete(t *testing.T) {
 	)
 
 	r := mergeHappyPathRunner(porcelain, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -271,6 +311,7 @@ func TestMergeToolTargetNotFound(t *testing.T) {
 			return porcelain, nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -320,6 +361,7 @@ func TestMergeToolDirtyChecks(t *testing.T) {
 					return "", nil
 				},
 			}
+			r.commonDir = testCommonDir(t)
 			hctx := testContext(r)
 			handler := handleMerge(hctx)
 
@@ -356,6 +398,7 @@ func TestMergeToolTargetDirtyIntoWorktree(t *testing.T) {
 			return "", nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -389,6 +432,7 @@ func TestMergeToolMergeFails(t *testing.T) {
 			return "", nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -431,6 +475,7 @@ func TestMergeToolRemoveWorktreeFails(t *testing.T) {
 			return "", nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -474,6 +519,7 @@ func TestMergeToolDeleteBranchFails(t *testing.T) {
 			return "", nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -534,6 +580,7 @@ func TestMergeToolServiceScoped(t *testing.T) {
 	)
 
 	r := mergeHappyPathRunner(porcelain, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := &HandlerContext{
 		Runner:   r,
 		Config:   testConfig(),
@@ -564,6 +611,7 @@ func TestMergeToolNoFF(t *testing.T) {
 
 	var mergeArgs []string
 	r := mergeNoFFRunner(porcelain, &mergeArgs)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleMerge(hctx)
 
@@ -573,3 +621,33 @@ func TestMergeToolNoFF(t *testing.T) {
 		t.Errorf("expected --no-ff in merge args, got: %v", mergeArgs)
 	}
 }
+
+func TestMergeToolVerifyTrustGateUntrusted(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", branchFeatureMyTask},
+	)
+	hctx := testContext(&mockRunner{run: mergeHappyPathRun(porcelain, nil), runInDir: func(string, ...string) (string, error) { return "", nil }})
+	hctx.RepoRoot = t.TempDir()
+	hctx.Config.Merge = &config.MergeConfig{Verify: []string{"make test"}}
+
+	errText := resultError(t, callTool(t, handleMerge(hctx), map[string]any{"source": "my-task"}))
+	if !strings.Contains(errText, "rimba trust") {
+		t.Errorf("untrusted error should mention 'rimba trust', got: %s", errText)
+	}
+}
+
+func TestMergeToolNoVerifySkipsVerify(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", branchFeatureMyTask},
+	)
+	hctx := testContext(&mockRunner{commonDir: testCommonDir(t), run: mergeHappyPathRun(porcelain, nil), runInDir: func(string, ...string) (string, error) { return "", nil }})
+	hctx.Config.Merge = &config.MergeConfig{Verify: []string{"make test"}}
+
+	result := callTool(t, handleMerge(hctx), map[string]any{"source": "my-task", "no_verify": true})
+	data := unmarshalJSON[mergeResult](t, result)
+	if data.Verify != nil {
+		t.Errorf("verify = %+v, want none with no_verify", data.Verify)
+	}
+}
@@ -3,13 +3,10 @@ package mcp
 import (
 	"context"
 	"errors"
-	"path/filepath"
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/errhint"
-	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/operations"
-	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/internal/trust"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -17,7 +14,7 @@ import (
 
 func registerRestoreTool(s *server.MCPServer, hctx *HandlerContext) {
 	tool := mcp.NewTool("restore",
-		mcp.WithDescription("Restore an archived worktree from its preserved branch"),
+		mcp.WithDescription("Restore an archived worktree from its preserved branch, reapplying any uncommitted changes saved by archive"),
 		mcp.WithString("task",
 			mcp.Description("Task identifier to restore (e.g. 'my-task' or 'auth-api/my-task' for monorepo)"),
 			mcp.Required(),
@@ -28,6 +25,9 @@ func registerRestoreTool(s *server.MCPServer, hctx *HandlerContext) {
 		mcp.WithBoolean("skip_hooks",
 			mcp.Description("Skip post-create hooks"),
 		),
+		mcp.WithBoolean("keep_on_failure",
+			mcp.Description("Keep a partially restored worktree when a step fails instead of rolling back"),
+		),
 	)
 	s.AddTool(tool, withRecorder(hctx, "restore", handleRestore(hctx)))
 }
@@ -61,42 +61,26 @@ func handleRestore(hctx *HandlerContext) server.ToolHandlerFunc {
 			return errorResult(err), nil
 		}
 
-		wtDir := filepath.Join(hctx.RepoRoot, cfg.WorktreeDir)
-		wtPath := resolver.WorktreePath(wtDir, branch)
-
-		if err := git.AddWorktreeFromBranch(ctx, hctx.Runner, wtPath, branch); err != nil {
-			return errorResult(err), nil
-		}
-
-		var configModules []config.ModuleConfig
-		if cfg.Deps != nil {
-			configModules = cfg.Deps.Modules
-		}
-
-		pcResult, err := operations.PostCreateSetup(ctx, hctx.Runner, operations.PostCreateParams{
-			RepoRoot:      hctx.RepoRoot,
-			WtPath:        wtPath,
-			Task:          task,
-			Service:       service,
-			CopyFiles:     cfg.CopyFiles,
-			SkipDeps:      req.GetBool("skip_deps", false),
-			AutoDetect:    cfg.IsAutoDetectDeps(),
-			ConfigModules: configModules,
-			SkipHooks:     req.GetBool("skip_hooks", false),
-			PostCreate:    cfg.PostCreate,
-			Concurrency:   cfg.DepsConcurrency(),
+		result, err := operations.RestoreWorktree(ctx, hctx.Runner, operations.RestoreParams{
+			Task:              task,
+			Service:           service,
+			Branch:            branch,
+			PostCreateOptions: buildPostCreateOptions(hctx, cfg, req),
 		}, nil)
 		if err != nil {
 			return errorResult(err), nil
 		}
 
 		return marshalResult(restoreResult{
-			Task:            task,
-			Branch:          branch,
-			Path:            wtPath,
-			Copied:          pcResult.Copied,
-			Skipped:         pcResult.Skipped,
-			SkippedSymlinks: pcResult.SkippedSymlinks,
+			Task:             result.Task,
+			Branch:           result.Branch,
+			Path:             result.Path,
+			ReappliedChanges: result.Snapshot.Stashed,
+			RestoredFiles:    result.Snapshot.Files,
+			Copied:           result.Copied,
+			Skipped:          result.Skipped,
+			SkippedSymlinks:  result.SkippedSymlinks,
+			Steps:            result.Transaction.Completed,
 		})
 	}
 }
@@ -201,6 +201,8 @@ func TestRestoreToolTrustGatePreTrusted(t *testing.T) {
 			case len(args) >= 2 && args[0] == gitWorktree && args[1] == gitList:
 				return worktreePorcelain(struct{ path, branch string }{tmpDir, "main"}), nil
 			case len(args) >= 2 && args[0] == gitWorktree && args[1] == gitWorktreeAdd:
+				// The hook runs in the new worktree, so it must exist.
+				_ = os.MkdirAll(args[len(args)-2], 0o755)
 				return "", nil
 			}
 			return "", nil
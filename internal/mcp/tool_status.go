@@ -28,6 +28,7 @@ type statusCollectedEntry struct {
 	status     resolver.WorktreeStatus
 	commitTime time.Time
 	hasTime    bool
+	base       *statusBase // nil when the worktree tracks the main branch
 }
 
 func handleStatus(hctx *HandlerContext) server.ToolHandlerFunc {
@@ -59,6 +60,7 @@ func handleStatus(hctx *HandlerContext) server.ToolHandlerFunc {
 			})
 		}
 
+		baseOf, _ := operations.BaseResolver(ctx, r, hctx.Config, mainBranch) // a report: configured bases will do
 		results := parallel.Collect(ctx, len(candidates), 8, func(ctx context.Context, i int) statusCollectedEntry {
 			itemCtx, cancel := git.WithItemTimeout(ctx)
 			defer cancel()
@@ -70,7 +72,10 @@ func handleStatus(hctx *HandlerContext) server.ToolHandlerFunc {
 				ct = t
 				hasTime = true
 			}
-			return statusCollectedEntry{entry: e, status: st, commitTime: ct, hasTime: hasTime}
+			return statusCollectedEntry{
+				entry: e, status: st, commitTime: ct, hasTime: hasTime,
+				base: collectStatusBase(itemCtx, r, mainBranch, baseOf(e.Branch), e.Branch),
+			}
 		})
 
 		staleThreshold := time.Now().Add(-time.Duration(staleDays) * 24 * time.Hour)
@@ -119,6 +124,7 @@ func buildStatusItem(r statusCollectedEntry, staleThreshold time.Time, prefixes
 		Type:   typeName,
 		Branch: r.entry.Branch,
 		Status: r.status,
+		Base:   r.base,
 	}
 
 	if r.hasTime {
@@ -130,3 +136,13 @@ func buildStatusItem(r statusCollectedEntry, staleThreshold time.Time, prefixes
 
 	return item
 }
+
+// collectStatusBase compares branch against base, or returns nil when base is
+// mainBranch. A failed count still reports the base, with zero counts.
+func collectStatusBase(ctx context.Context, r git.Runner, mainBranch, base, branch string) *statusBase {
+	if base == mainBranch {
+		return nil
+	}
+	ahead, behind, _ := git.AheadBehindOf(ctx, r, base, branch)
+	return &statusBase{Branch: base, Ahead: ahead, Behind: behind}
+}
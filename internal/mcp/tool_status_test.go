@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/lugassawan/rimba/internal/config"
 )
 
 func TestStatusToolEmpty(t *testing.T) {
@@ -338,3 +340,43 @@ func TestStatusToolListError(t *testing.T) {
 		t.Error("expected error for list failure")
 	}
 }
+
+func TestStatusToolReportsBase(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/wt/feature-login", "feature/login"},
+		struct{ path, branch string }{"/wt/bugfix-crash", "bugfix/crash"},
+	)
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case args[0] == gitSymbolicRef:
+				return refsOriginMain, nil
+			case args[0] == gitWorktree:
+				return porcelain, nil
+			case args[0] == gitRevList && args[1] == "--left-right":
+				return "4\t1", nil
+			}
+			return "", nil
+		},
+		runInDir: func(_ string, _ ...string) (string, error) { return "", nil },
+	}
+	hctx := testContext(r)
+	hctx.Config.Resolver = &config.ResolverConfig{Prefix: []config.PrefixEntry{
+		{Prefix: "bugfix/", SyncTarget: "release/2.2"},
+	}}
+
+	data := unmarshalJSON[statusData](t, callTool(t, handleStatus(hctx), nil))
+	for _, w := range data.Worktrees {
+		switch w.Branch {
+		case "feature/login":
+			if w.Base != nil {
+				t.Errorf("feature/login base = %+v, want none", w.Base)
+			}
+		case "bugfix/crash":
+			if w.Base == nil || w.Base.Branch != "release/2.2" || w.Base.Ahead != 1 || w.Base.Behind != 4 {
+				t.Errorf("bugfix/crash base = %+v, want release/2.2 ahead 1 behind 4", w.Base)
+			}
+		}
+	}
+}
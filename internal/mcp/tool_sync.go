@@ -32,6 +32,15 @@ func registerSyncTool(s *server.MCPServer, hctx *HandlerContext) {
 		mcp.WithBoolean("no_push",
 			mcp.Description("Skip pushing after sync"),
 		),
+		mcp.WithBoolean("autostash",
+			mcp.Description("Stash uncommitted changes around the sync instead of skipping dirty worktrees (default from [sync] autostash in config)"),
+		),
+		mcp.WithBoolean("predict",
+			mcp.Description("Predict conflicts with git merge-tree first and skip the worktrees predicted to conflict"),
+		),
+		mcp.WithBoolean("predict_only",
+			mcp.Description("Report predicted conflicts without syncing any worktree"),
+		),
 	)
 	s.AddTool(tool, withRecorder(hctx, "sync", handleSync(hctx)))
 }
@@ -39,10 +48,13 @@ func registerSyncTool(s *server.MCPServer, hctx *HandlerContext) {
 // syncOpts bundles shared sync configuration derived from a single request.
 type syncOpts struct {
 	mainBranch   string
-	useMerge     bool
-	push         bool
+	baseBranch   func(branch string) string // per-branch sync target
+	sync         operations.SyncOptions
 	fetchWarning string
 	ps           *resolver.PrefixSet
+	// predict skips worktrees predicted to conflict; predictOnly reports
+	// the predictions and syncs nothing.
+	predict, predictOnly bool
 }
 
 func handleSync(hctx *HandlerContext) server.ToolHandlerFunc {
@@ -52,11 +64,14 @@ func handleSync(hctx *HandlerContext) server.ToolHandlerFunc {
 		useMerge := req.GetBool("merge", false)
 		includeInherited := req.GetBool("include_inherited", false)
 		noPush := req.GetBool("no_push", false)
+		predictOnly := req.GetBool("predict_only", false)
+		predict := req.GetBool("predict", false) || predictOnly
 
 		cfg, cfgErr := hctx.requireConfig()
 		if cfgErr != nil {
 			return errorResult(cfgErr), nil
 		}
+		autostash := req.GetBool("autostash", cfg.SyncAutostash())
 
 		if !all && task == "" {
 			return errorResult(errhint.WithFix(errors.New("provide a task name or set all=true to sync all worktrees"),
@@ -81,14 +96,28 @@ func handleSync(hctx *HandlerContext) server.ToolHandlerFunc {
 			return errorResult(err), nil
 		}
 
+		baseOf, err := operations.BaseResolver(ctx, r, cfg, cfg.DefaultSource)
+		if err != nil {
+			return errorResult(err), nil
+		}
+		lockfiles, err := lockfileResolver(hctx, cfg)
+		if err != nil {
+			return errorResult(err), nil
+		}
+
 		ps := cfg.PrefixSet()
 		prefixes := ps.Strip()
 		opts := syncOpts{
-			mainBranch:   cfg.DefaultSource,
-			useMerge:     useMerge,
-			push:         !noPush,
+			mainBranch: cfg.DefaultSource,
+			baseBranch: baseOf,
+			sync: operations.SyncOptions{
+				UseMerge: useMerge, Push: !noPush, Autostash: autostash,
+				Lockfiles: lockfiles,
+			},
 			fetchWarning: fetchWarning,
 			ps:           ps,
+			predict:      predict,
+			predictOnly:  predictOnly,
 		}
 
 		if !all {
@@ -110,8 +139,21 @@ func syncSingle(ctx context.Context, r git.Runner, service, task string, worktre
 	if err := operations.GuardKnownPrefix(opts.ps, wt.Branch, opts.mainBranch, false); err != nil {
 		return errorResult(err), nil
 	}
+	if err := operations.GuardUnstacked(ctx, r, wt.Branch, task); err != nil {
+		return errorResult(err), nil
+	}
+
+	if opts.predict {
+		p := operations.PredictSyncConflicts(ctx, r, []resolver.WorktreeInfo{wt}, opts.baseBranch)[0]
+		if opts.predictOnly {
+			return predictOnlyResult([]operations.SyncPrediction{p}, opts)
+		}
+		if p.Conflicts {
+			return marshalResult(syncResult{FetchWarning: opts.fetchWarning, Results: []syncWorktreeResult{predictedConflictResult(p, opts)}})
+		}
+	}
 
-	sr := operations.SyncWorktree(ctx, r, opts.mainBranch, wt, opts.useMerge, opts.push)
+	sr := operations.SyncWorktreeOnto(ctx, r, opts.mainBranch, opts.baseBranch(wt.Branch), wt, opts.sync)
 
 	results := []syncWorktreeResult{convertSyncResult(sr)}
 
@@ -122,12 +164,56 @@ func syncMultiple(ctx context.Context, r git.Runner, worktrees []resolver.Worktr
 	allTasks := operations.CollectTasks(worktrees, prefixes)
 	eligible := operations.FilterEligible(worktrees, prefixes, opts.mainBranch, allTasks, includeInherited)
 
+	var skipped []syncWorktreeResult
+	if opts.predict {
+		preds := operations.PredictSyncConflicts(ctx, r, eligible, opts.baseBranch)
+		if err := ctx.Err(); err != nil {
+			return errorResult(err), nil
+		}
+		if opts.predictOnly {
+			return predictOnlyResult(preds, opts)
+		}
+		for _, p := range preds {
+			if p.Conflicts {
+				skipped = append(skipped, predictedConflictResult(p, opts))
+			}
+		}
+		eligible, _ = operations.SplitPredicted(preds, opts.sync.UseMerge)
+	}
+
 	// No per-item timeout here — sync operations (fetch/rebase) are long-running by design.
 	results := parallel.Collect(ctx, len(eligible), 4, func(ctx context.Context, i int) syncWorktreeResult {
-		return convertSyncResult(operations.SyncWorktree(ctx, r, opts.mainBranch, eligible[i], opts.useMerge, opts.push))
+		wt := eligible[i]
+		return convertSyncResult(operations.SyncWorktreeOnto(ctx, r, opts.mainBranch, opts.baseBranch(wt.Branch), wt, opts.sync))
 	})
 
-	return marshalResult(syncResult{FetchWarning: opts.fetchWarning, Results: results})
+	return marshalResult(syncResult{FetchWarning: opts.fetchWarning, Results: append(results, skipped...)})
+}
+
+// predictOnlyResult reports predict_only predictions, one result each.
+func predictOnlyResult(preds []operations.SyncPrediction, opts syncOpts) (*mcp.CallToolResult, error) {
+	results := make([]syncWorktreeResult, 0, len(preds))
+	for _, p := range preds {
+		res := syncWorktreeResult{Branch: p.Worktree.Branch, PredictedConflict: p.Conflicts, ConflictFiles: p.Files}
+		if p.Err != nil {
+			res.PredictError = p.Err.Error()
+		}
+		if p.Base != opts.mainBranch {
+			res.Onto = p.Base
+		}
+		results = append(results, res)
+	}
+	return marshalResult(syncResult{FetchWarning: opts.fetchWarning, PredictOnly: true, Results: results})
+}
+
+// predictedConflictResult is the skipped result of a worktree predicted to
+// conflict.
+func predictedConflictResult(p operations.SyncPrediction, opts syncOpts) syncWorktreeResult {
+	res := convertSyncResult(p.Result(opts.sync.UseMerge))
+	if p.Base != opts.mainBranch {
+		res.Onto = p.Base
+	}
+	return res
 }
 
 // mcpFetchNonFatal fetches from git.DefaultRemote ("origin") and returns a
@@ -151,9 +237,21 @@ func convertSyncResult(sr operations.SyncWorktreeResult) syncWorktreeResult {
 		SkipReason:  sr.SkipReason,
 		Failed:      sr.Failed,
 		FailureHint: sr.FailureHint,
+		Onto:        sr.Onto,
 		Pushed:      sr.Pushed,
-		PushSkipped: sr.PushSkipped,
-		PushFailed:  sr.PushFailed,
-		PushError:   sr.PushError,
+
+		PredictedConflict: sr.PredictedConflict,
+		ConflictFiles:     sr.ConflictFiles,
+		PushSkipped:       sr.PushSkipped,
+		PushFailed:        sr.PushFailed,
+		PushError:         sr.PushError,
+
+		LockfilesRegenerated: sr.LockfilesRegenerated,
+
+		Stashed:       sr.Stashed,
+		StashRef:      sr.StashRef,
+		StashConflict: sr.StashConflict,
+		StashError:    sr.StashError,
+		StashHint:     sr.StashHint,
 	}
 }
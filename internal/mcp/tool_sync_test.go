@@ -77,6 +77,7 @@ func TestSyncToolTaskNotFound(t *testing.T) {
 			return porcelain, nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -102,6 +103,7 @@ func TestSyncToolOrphanedHardErrors(t *testing.T) {
 			return porcelain, nil
 		},
 	}
+	r.commonDir = testCommonDir(t)
 	hctx := &HandlerContext{
 		Runner: r,
 		Config: &config.Config{
@@ -130,6 +132,10 @@ type syncMockConfig struct {
 	statusError bool
 	useMerge    bool
 	noUpstream  bool
+	// stashConflict makes reapplying an autostash conflict.
+	stashConflict bool
+	// predictConflict is the branch git merge-tree predicts to conflict.
+	predictConflict string
 }
 
 // newSyncMockRunner creates a mock runner for sync tests. The porcelain string
@@ -145,6 +151,9 @@ func newSyncMockRunner(porcelain string, cfg syncMockConfig, pushCalled, mergeCa
 			if len(args) >= 2 && args[0] == gitWorktree && args[1] == gitList {
 				return porcelain, nil
 			}
+			if args[0] == "merge-tree" && args[len(args)-1] == cfg.predictConflict {
+				return "tree123\n\nCONFLICT (content): Merge conflict in api.go\n", errors.New("exit status 1")
+			}
 			return "", nil
 		},
 		runInDir: newSyncRunInDir(cfg, pushCalled, mergeCalled),
@@ -161,6 +170,18 @@ func syncMockStatus(cfg syncMockConfig) (string, error) {
 	return "", nil
 }
 
+// syncMockStash serves the stash commands of an autostash; the stash SHA is
+// whatever rev-parse returns.
+func syncMockStash(cfg syncMockConfig, sub string) (string, error) {
+	switch {
+	case sub == gitList:
+		return originFeatureTask + " stash@{0}", nil
+	case sub == "apply" && cfg.stashConflict:
+		return "", errors.New("CONFLICT (content): Merge conflict in dirty.go")
+	}
+	return "", nil
+}
+
 func syncMockPush(cfg syncMockConfig, pushCalled *bool) (string, error) {
 	if pushCalled != nil {
 		*pushCalled = true
@@ -199,6 +220,8 @@ func newSyncRunInDir(cfg syncMockConfig, pushCalled, mergeCalled *bool) func(dir
 			return originFeatureTask, nil
 		case pushCmd:
 			return syncMockPush(cfg, pushCalled)
+		case "stash":
+			return syncMockStash(cfg, args[1])
 		default:
 			return "", nil
 		}
@@ -212,6 +235,7 @@ func TestSyncSingleSuccess(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -239,6 +263,81 @@ func TestSyncSingleSuccess(t *testing.T) {
 	}
 }
 
+func TestSyncToolFailsOnUnreadableBases(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", "feature/my-task"},
+	)
+
+	var pushed bool
+	r := newSyncMockRunner(porcelain, syncMockConfig{}, &pushed, nil)
+	r.commonDir = testCommonDir(t)
+	if err := os.MkdirAll(filepath.Join(r.commonDir, "rimba"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(r.commonDir, "rimba", "meta.json"), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	errText := resultError(t, callTool(t, handleSync(testContext(r)), map[string]any{"task": "my-task"}))
+	if !strings.Contains(errText, "cannot read the bases") {
+		t.Errorf("expected unreadable-bases error, got: %s", errText)
+	}
+	if pushed {
+		t.Error("sync pushed despite not knowing the branch's base")
+	}
+}
+
+func TestSyncToolLockfilesTrustGateUntrusted(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", "feature/my-task"},
+	)
+
+	var pushed bool
+	r := newSyncMockRunner(porcelain, syncMockConfig{}, &pushed, nil)
+	r.commonDir = testCommonDir(t)
+	hctx := testContext(r)
+	hctx.RepoRoot = t.TempDir()
+	hctx.Config.Deps = &config.DepsConfig{
+		ResolveLockfiles: true,
+		Modules:          []config.ModuleConfig{{Dir: "vendor", Lockfile: "go.sum", Install: "go mod tidy"}},
+	}
+
+	errText := resultError(t, callTool(t, handleSync(hctx), map[string]any{"task": "my-task"}))
+	if !strings.Contains(errText, "rimba trust") {
+		t.Errorf("untrusted error should mention 'rimba trust', got: %s", errText)
+	}
+	if pushed {
+		t.Error("sync ran before the install commands were approved")
+	}
+}
+
+func TestSyncSingleOntoPrefixTypeBase(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/hotfix-crash", "hotfix/crash"},
+	)
+
+	r := newSyncMockRunner(porcelain, syncMockConfig{}, nil, nil)
+	r.commonDir = testCommonDir(t)
+	hctx := testContext(r)
+	hctx.Config.Resolver = &config.ResolverConfig{Prefix: []config.PrefixEntry{
+		{Prefix: "hotfix/", SyncTarget: "release/current"},
+	}}
+	handler := handleSync(hctx)
+
+	result := callTool(t, handler, map[string]any{"task": "crash", "no_push": true})
+	data := unmarshalJSON[syncResult](t, result)
+
+	if len(data.Results) != 1 || !data.Results[0].Synced {
+		t.Fatalf("results = %+v, want one synced result", data.Results)
+	}
+	if data.Results[0].Onto != "release/current" {
+		t.Errorf("onto = %q, want release/current", data.Results[0].Onto)
+	}
+}
+
 func TestSyncSingleNoPush(t *testing.T) {
 	porcelain := worktreePorcelain(
 		struct{ path, branch string }{"/repo", "main"},
@@ -247,6 +346,7 @@ func TestSyncSingleNoPush(t *testing.T) {
 
 	var pushCalled bool
 	r := newSyncMockRunner(porcelain, syncMockConfig{}, &pushCalled, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -276,6 +376,7 @@ func TestSyncSingleMergeMode(t *testing.T) {
 
 	var usedMerge bool
 	r := newSyncMockRunner(porcelain, syncMockConfig{useMerge: true}, nil, &usedMerge)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -305,6 +406,7 @@ func TestSyncSingleServiceScoped(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := &HandlerContext{
 		Runner:   r,
 		Config:   testConfig(),
@@ -336,6 +438,7 @@ func TestSyncMultipleAllWorktrees(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{noUpstream: true}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -355,6 +458,63 @@ func TestSyncMultipleAllWorktrees(t *testing.T) {
 	}
 }
 
+func TestSyncMultiplePredictSkipsConflicts(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-a", "feature/task-a"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-task-b", "feature/task-b"},
+	)
+
+	r := newSyncMockRunner(porcelain, syncMockConfig{noUpstream: true, predictConflict: "feature/task-b"}, nil, nil)
+	r.commonDir = testCommonDir(t)
+	handler := handleSync(testContext(r))
+
+	result := callTool(t, handler, map[string]any{"all": true, "predict": true})
+	data := unmarshalJSON[syncResult](t, result)
+
+	if len(data.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(data.Results))
+	}
+	for _, sr := range data.Results {
+		switch sr.Branch {
+		case "feature/task-a":
+			if !sr.Synced {
+				t.Errorf("expected %s to be synced", sr.Branch)
+			}
+		case "feature/task-b":
+			if sr.Synced || !sr.Skipped || !sr.PredictedConflict || len(sr.ConflictFiles) != 1 {
+				t.Errorf("expected %s skipped with a predicted conflict, got %+v", sr.Branch, sr)
+			}
+		}
+	}
+}
+
+func TestSyncSinglePredictOnly(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", "feature/my-task"},
+	)
+
+	var pushCalled bool
+	r := newSyncMockRunner(porcelain, syncMockConfig{predictConflict: "feature/my-task"}, &pushCalled, nil)
+	r.commonDir = testCommonDir(t)
+	handler := handleSync(testContext(r))
+
+	result := callTool(t, handler, map[string]any{"task": "my-task", "predict_only": true})
+	data := unmarshalJSON[syncResult](t, result)
+
+	if !data.PredictOnly || len(data.Results) != 1 {
+		t.Fatalf("expected one predict_only result, got %+v", data)
+	}
+	sr := data.Results[0]
+	if sr.Synced || !sr.PredictedConflict || sr.ConflictFiles[0] != "api.go" {
+		t.Errorf("expected a predicted conflict in api.go and no sync, got %+v", sr)
+	}
+	if pushCalled {
+		t.Error("predict_only pushed")
+	}
+}
+
 func TestSyncSingleDirtySkipped(t *testing.T) {
 	porcelain := worktreePorcelain(
 		struct{ path, branch string }{"/repo", "main"},
@@ -362,6 +522,7 @@ func TestSyncSingleDirtySkipped(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{dirty: true}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -380,6 +541,59 @@ func TestSyncSingleDirtySkipped(t *testing.T) {
 	}
 }
 
+func TestSyncSingleAutostash(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", "feature/my-task"},
+	)
+
+	r := newSyncMockRunner(porcelain, syncMockConfig{dirty: true}, nil, nil)
+	r.commonDir = testCommonDir(t)
+	hctx := testContext(r)
+	handler := handleSync(hctx)
+
+	result := callTool(t, handler, map[string]any{"task": "my-task", "autostash": true})
+	data := unmarshalJSON[syncResult](t, result)
+
+	if len(data.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(data.Results))
+	}
+	sr := data.Results[0]
+	if !sr.Synced || !sr.Stashed {
+		t.Errorf("result = %+v, want synced with autostash", sr)
+	}
+	if sr.StashRef != "" {
+		t.Errorf("stash_ref = %q, want the stash dropped", sr.StashRef)
+	}
+}
+
+func TestSyncSingleAutostashConflictFromConfig(t *testing.T) {
+	porcelain := worktreePorcelain(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/repo/.worktrees/feature-my-task", "feature/my-task"},
+	)
+
+	r := newSyncMockRunner(porcelain, syncMockConfig{dirty: true, stashConflict: true}, nil, nil)
+	r.commonDir = testCommonDir(t)
+	hctx := testContext(r)
+	hctx.Config.Sync = &config.SyncConfig{Autostash: true}
+	handler := handleSync(hctx)
+
+	result := callTool(t, handler, map[string]any{"task": "my-task"})
+	data := unmarshalJSON[syncResult](t, result)
+
+	if len(data.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(data.Results))
+	}
+	sr := data.Results[0]
+	if !sr.StashConflict || sr.StashRef != originFeatureTask {
+		t.Errorf("result = %+v, want the conflicted stash kept", sr)
+	}
+	if !strings.Contains(sr.StashHint, "git stash drop") {
+		t.Errorf("stash_hint = %q", sr.StashHint)
+	}
+}
+
 func TestSyncSingleRebaseFails(t *testing.T) {
 	porcelain := worktreePorcelain(
 		struct{ path, branch string }{"/repo", "main"},
@@ -387,6 +601,7 @@ func TestSyncSingleRebaseFails(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{rebaseFails: true}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -415,6 +630,7 @@ func TestSyncSinglePushFails(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{pushFails: true}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -565,6 +781,7 @@ func TestSyncMultipleNoEligible(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
@@ -583,6 +800,7 @@ func TestSyncSingleStatusCheckError(t *testing.T) {
 	)
 
 	r := newSyncMockRunner(porcelain, syncMockConfig{statusError: true}, nil, nil)
+	r.commonDir = testCommonDir(t)
 	hctx := testContext(r)
 	handler := handleSync(hctx)
 
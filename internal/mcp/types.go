@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"github.com/lugassawan/rimba/internal/conflict"
 	"github.com/lugassawan/rimba/internal/output"
 )
 
@@ -13,6 +14,7 @@ type (
 	statusSummary    = output.StatusSummary
 	statusItem       = output.StatusItem
 	statusAge        = output.StatusAge
+	statusBase       = output.StatusBase
 	execData         = output.ExecData
 	execResult       = output.ExecResult
 	logItem          = output.LogItem
@@ -24,6 +26,11 @@ type conflictCheckData struct {
 	DryMerges     []dryMergeItem `json:"dry_merges,omitempty"`
 	TotalFiles    int            `json:"total_files"`
 	TotalBranches int            `json:"total_branches"`
+	// Heatmap rolls the overlaps up by directory.
+	Heatmap []conflict.DirHeat `json:"heatmap"`
+	// Nodes and Edges are the conflict graph, numbered by merge plan step.
+	Nodes []conflict.GraphNode `json:"nodes"`
+	Edges []conflict.GraphEdge `json:"edges"`
 }
 
 // overlapItem represents a file touched by multiple branches.
@@ -31,14 +38,25 @@ type overlapItem struct {
 	File     string   `json:"file"`
 	Branches []string `json:"branches"`
 	Severity string   `json:"severity"`
+	// Lines and Renames are set by a hunk-aware check.
+	Lines   []conflict.LineOverlap `json:"lines,omitempty"`
+	Renames map[string]string      `json:"renames,omitempty"`
+	// Uncommitted are the branches whose side of the overlap is
+	// uncommitted work in their worktree.
+	Uncommitted []string `json:"uncommitted,omitempty"`
+	// PRs are the open PRs among Branches (with_prs).
+	PRs []conflict.PR `json:"prs,omitempty"`
+	// Owners are File's owners in CODEOWNERS.
+	Owners []string `json:"owners,omitempty"`
 }
 
 // dryMergeItem represents the result of a simulated merge.
 type dryMergeItem struct {
-	Branch1       string   `json:"branch1"`
-	Branch2       string   `json:"branch2"`
-	HasConflicts  bool     `json:"has_conflicts"`
-	ConflictFiles []string `json:"conflict_files,omitempty"`
+	Branch1       string        `json:"branch1"`
+	Branch2       string        `json:"branch2"`
+	HasConflicts  bool          `json:"has_conflicts"`
+	ConflictFiles []string      `json:"conflict_files,omitempty"`
+	PRs           []conflict.PR `json:"prs,omitempty"`
 }
 
 // addResult holds the outcome of a worktree add.
@@ -47,6 +65,8 @@ type addResult struct {
 	Branch string `json:"branch"`
 	Path   string `json:"path"`
 	Source string `json:"source,omitempty"`
+	// Steps lists the transaction steps that completed (task and pr modes).
+	Steps []string `json:"steps,omitempty"`
 }
 
 // removeResult holds the outcome of a worktree removal.
@@ -63,11 +83,28 @@ type mergeResult struct {
 	Into          string `json:"into"`
 	SourceRemoved bool   `json:"source_removed"`
 	RemoteDeleted bool   `json:"remote_deleted,omitempty"`
+	// LockfilesRegenerated lists lockfiles whose conflicts were resolved by
+	// regenerating them ([deps] resolve_lockfiles).
+	LockfilesRegenerated []string `json:"lockfiles_regenerated,omitempty"`
+	// Squashed counts the commits a squash folded into one commit.
+	Squashed      int    `json:"squashed,omitempty"`
+	CommitMessage string `json:"commit_message,omitempty"`
+	// Verify is the outcome of the [merge] verify commands; omitted when
+	// none ran.
+	Verify *mergeVerifyResult `json:"verify,omitempty"`
+}
+
+// mergeVerifyResult mirrors operations.MergeVerify as JSON.
+type mergeVerifyResult struct {
+	Commands []string `json:"commands"`
+	Passed   bool     `json:"passed"`
+	Failure  string   `json:"failure,omitempty"`
 }
 
 // syncResult holds the outcome of a sync operation.
 type syncResult struct {
 	FetchWarning string               `json:"fetch_warning,omitempty"`
+	PredictOnly  bool                 `json:"predict_only,omitempty"` // results are predictions; nothing was synced
 	Results      []syncWorktreeResult `json:"results"`
 }
 
@@ -79,10 +116,24 @@ type syncWorktreeResult struct {
 	SkipReason  string `json:"skip_reason,omitempty"`
 	Failed      bool   `json:"failed"`
 	FailureHint string `json:"failure_hint,omitempty"`
+	Onto        string `json:"onto,omitempty"` // prefix type's base branch, when not the default
 	Pushed      bool   `json:"pushed"`
 	PushSkipped bool   `json:"push_skipped"`
 	PushFailed  bool   `json:"push_failed"`
 	PushError   string `json:"push_error,omitempty"`
+	// Predict: files git merge-tree expects to conflict, or why no
+	// prediction could be made.
+	PredictedConflict bool     `json:"predicted_conflict,omitempty"`
+	ConflictFiles     []string `json:"conflict_files,omitempty"`
+	PredictError      string   `json:"predict_error,omitempty"`
+	// Lockfiles regenerated to resolve their conflicts ([deps] resolve_lockfiles).
+	LockfilesRegenerated []string `json:"lockfiles_regenerated,omitempty"`
+	// Autostash: StashRef is set when the stashed changes could not be reapplied.
+	Stashed       bool   `json:"stashed,omitempty"`
+	StashRef      string `json:"stash_ref,omitempty"`
+	StashConflict bool   `json:"stash_conflict,omitempty"`
+	StashError    string `json:"stash_error,omitempty"`
+	StashHint     string `json:"stash_hint,omitempty"`
 }
 
 // cleanResult holds the outcome of a clean operation.
@@ -119,14 +170,22 @@ type renameResult struct {
 // mergePlanResult holds the recommended merge order.
 type mergePlanResult struct {
 	Steps []mergePlanStep `json:"steps"`
+	// Exact reports that the order was found by exhaustive search.
+	Exact bool                 `json:"exact"`
+	Nodes []conflict.GraphNode `json:"nodes"`
+	Edges []conflict.GraphEdge `json:"edges"`
 }
 
 // mergePlanStep represents one step in the recommended merge order.
 type mergePlanStep struct {
-	Order     int    `json:"order"`
-	Task      string `json:"task"`
-	Branch    string `json:"branch"`
-	Conflicts int    `json:"conflicts"`
+	Order     int               `json:"order"`
+	Task      string            `json:"task"`
+	Branch    string            `json:"branch"`
+	Conflicts int               `json:"conflicts"`
+	Against   []conflict.Weight `json:"against,omitempty"`
+	Pinned    bool              `json:"pinned,omitempty"`
+	// Reason explains the step's place in the order.
+	Reason string `json:"reason"`
 }
 
 // logResult holds the last-commit entries for each worktree.
@@ -136,18 +195,23 @@ type logResult struct {
 
 // archiveResult holds the outcome of an archive operation.
 type archiveResult struct {
-	Path   string   `json:"path"`
-	Branch string   `json:"branch"`
-	DryRun bool     `json:"dry_run"`
-	Steps  []string `json:"steps,omitempty"`
+	Path         string   `json:"path"`
+	Branch       string   `json:"branch"`
+	DryRun       bool     `json:"dry_run"`
+	SavedChanges bool     `json:"saved_changes,omitempty"`
+	SavedFiles   []string `json:"saved_files,omitempty"`
+	Steps        []string `json:"steps,omitempty"`
 }
 
 // restoreResult holds the outcome of a worktree restore operation.
 type restoreResult struct {
-	Task            string   `json:"task"`
-	Branch          string   `json:"branch"`
-	Path            string   `json:"path"`
-	Copied          []string `json:"copied,omitempty"`
-	Skipped         []string `json:"skipped,omitempty"`
-	SkippedSymlinks []string `json:"skipped_symlinks,omitempty"`
+	Task             string   `json:"task"`
+	Branch           string   `json:"branch"`
+	Path             string   `json:"path"`
+	ReappliedChanges bool     `json:"reapplied_changes,omitempty"`
+	RestoredFiles    []string `json:"restored_files,omitempty"`
+	Copied           []string `json:"copied,omitempty"`
+	Skipped          []string `json:"skipped,omitempty"`
+	SkippedSymlinks  []string `json:"skipped_symlinks,omitempty"`
+	Steps            []string `json:"steps,omitempty"`
 }
@@ -0,0 +1,118 @@
+// Package mergequeue persists a `rimba merge-queue` run — which branches it
+// has landed on the target, which one failed to merge or verify, and which
+// are still queued — so the queue can be continued once the failing branch
+// is fixed. The state lives under the git common dir and is removed when the
+// queue ends.
+package mergequeue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/fsutil"
+)
+
+// Status is where one branch stands in a queue.
+type Status string
+
+// Branch is one branch in a queue.
+type Branch struct {
+	Branch string `json:"branch"`
+	Status Status `json:"status"`
+	// PreSHA is the target's tip before the branch landed; SHA is its tip
+	// after.
+	PreSHA string `json:"pre_sha,omitempty"`
+	SHA    string `json:"sha,omitempty"`
+	// Reason says why a failed branch did not land.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Queue is the persisted state of one merge queue.
+type Queue struct {
+	StartedAt time.Time `json:"started_at"`
+	// Target is the branch the queue lands onto; TargetPath is the
+	// worktree it is checked out in.
+	Target     string   `json:"target"`
+	TargetPath string   `json:"target_path"`
+	Branches   []Branch `json:"branches"`
+}
+
+// Branch statuses.
+const (
+	StatusPending Status = "pending"
+	StatusLanded  Status = "landed"
+	// StatusFailed marks the branch the queue stopped on: it conflicted
+	// with the target or failed verification, and the target was left at
+	// PreSHA.
+	StatusFailed Status = "failed"
+)
+
+// queueFile is where the queue lives, relative to the git common dir.
+const queueFile = "rimba/merge-queue.json"
+
+// Load reads the queue under commonDir. It returns nil, nil when no queue is
+// in progress.
+func Load(commonDir string) (*Queue, error) {
+	data, err := os.ReadFile(queuePath(commonDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read merge queue: %w", err)
+	}
+	q := &Queue{}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("parse merge queue: %w", err)
+	}
+	return q, nil
+}
+
+// Clear removes the queue under commonDir. A missing queue is not an error.
+func Clear(commonDir string) error {
+	if err := os.Remove(queuePath(commonDir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove merge queue: %w", err)
+	}
+	return nil
+}
+
+// Save writes the queue under commonDir atomically.
+func (q *Queue) Save(commonDir string) error {
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal merge queue: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(queuePath(commonDir), data); err != nil {
+		return fmt.Errorf("write merge queue: %w", err)
+	}
+	return nil
+}
+
+// Update applies fn to branch's entry, reporting whether branch is queued.
+func (q *Queue) Update(branch string, fn func(b *Branch)) bool {
+	for i := range q.Branches {
+		if q.Branches[i].Branch == branch {
+			fn(&q.Branches[i])
+			return true
+		}
+	}
+	return false
+}
+
+// With returns the branches whose status is status, in queue order.
+func (q *Queue) With(status Status) []Branch {
+	var out []Branch
+	for _, b := range q.Branches {
+		if b.Status == status {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func queuePath(commonDir string) string {
+	return filepath.Join(commonDir, queueFile)
+}
@@ -0,0 +1,80 @@
+package mergequeue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/mergequeue"
+)
+
+const branchAuth = "feature/auth"
+
+func testQueue() *mergequeue.Queue {
+	return &mergequeue.Queue{
+		StartedAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Target:     "main",
+		TargetPath: "/repo",
+		Branches: []mergequeue.Branch{
+			{Branch: branchAuth, Status: mergequeue.StatusLanded, PreSHA: "abc123", SHA: "def456"},
+			{Branch: "bugfix/typo", Status: mergequeue.StatusPending},
+		},
+	}
+}
+
+func TestLoadMissingReturnsNil(t *testing.T) {
+	q, err := mergequeue.Load(t.TempDir())
+	if err != nil || q != nil {
+		t.Errorf("Load = %+v, %v; want nil, nil", q, err)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := testQueue().Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := mergequeue.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Target != "main" || got.TargetPath != "/repo" || len(got.Branches) != 2 {
+		t.Fatalf("queue = %+v", got)
+	}
+	if b := got.Branches[0]; b.Status != mergequeue.StatusLanded || b.PreSHA != "abc123" || b.SHA != "def456" {
+		t.Errorf("branch = %+v", b)
+	}
+}
+
+func TestUpdateAndWith(t *testing.T) {
+	q := testQueue()
+	if !q.Update("bugfix/typo", func(b *mergequeue.Branch) {
+		b.Status, b.Reason = mergequeue.StatusFailed, "verify failed"
+	}) {
+		t.Fatal("Update reported branch missing")
+	}
+	if q.Update("feature/gone", func(*mergequeue.Branch) {}) {
+		t.Error("Update reported an unknown branch queued")
+	}
+	if got := q.With(mergequeue.StatusFailed); len(got) != 1 || got[0].Reason != "verify failed" {
+		t.Errorf("failed = %+v", got)
+	}
+	if got := q.With(mergequeue.StatusPending); len(got) != 0 {
+		t.Errorf("pending = %+v, want none", got)
+	}
+}
+
+func TestClear(t *testing.T) {
+	dir := t.TempDir()
+	if err := testQueue().Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := mergequeue.Clear(dir); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if q, _ := mergequeue.Load(dir); q != nil {
+		t.Errorf("queue after Clear = %+v", q)
+	}
+	if err := mergequeue.Clear(dir); err != nil {
+		t.Errorf("Clear of missing queue: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+// Package meta records per-worktree metadata — a note, tags, an owner, a
+// linked issue, who created it and when, and free-form fields — keyed by
+// branch so it survives archive/restore and follows renames.
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/fsutil"
+)
+
+// Meta is the metadata recorded for one branch.
+type Meta struct {
+	Note      string    `json:"note,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	Issue     string    `json:"issue,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	// Base is the branch the worktree was created from or last rebased onto
+	// with `rimba rebase`, when it is not the configured base.
+	Base   string            `json:"base,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Store maps each branch to its Meta.
+type Store struct {
+	Worktrees map[string]Meta `json:"worktrees"`
+}
+
+// Filter selects branches by their metadata. Zero fields match anything.
+type Filter struct {
+	Tag   string
+	Owner string
+}
+
+// storeFile is where metadata lives, relative to the git common dir.
+const storeFile = "rimba/meta.json"
+
+// Load reads the store under commonDir. A missing file yields an empty store.
+func Load(commonDir string) (*Store, error) {
+	s := &Store{Worktrees: make(map[string]Meta)}
+	data, err := os.ReadFile(storePath(commonDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read metadata store: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse metadata store: %w", err)
+	}
+	if s.Worktrees == nil {
+		s.Worktrees = make(map[string]Meta)
+	}
+	return s, nil
+}
+
+// Save writes the store under commonDir atomically.
+func (s *Store) Save(commonDir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal metadata store: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(storePath(commonDir), data); err != nil {
+		return fmt.Errorf("write metadata store: %w", err)
+	}
+	return nil
+}
+
+// Get returns branch's metadata; the zero Meta when none is recorded or
+// s is nil, so best-effort callers need not check a failed Load.
+func (s *Store) Get(branch string) Meta {
+	if s == nil {
+		return Meta{}
+	}
+	return s.Worktrees[branch]
+}
+
+// Update applies fn to branch's metadata, dropping the entry once it is empty.
+func (s *Store) Update(branch string, fn func(m *Meta)) {
+	m := s.Worktrees[branch]
+	fn(&m)
+	if m.IsZero() {
+		delete(s.Worktrees, branch)
+		return
+	}
+	s.Worktrees[branch] = m
+}
+
+// Delete drops branch's metadata.
+func (s *Store) Delete(branch string) {
+	delete(s.Worktrees, branch)
+}
+
+// Rename moves oldBranch's metadata to newBranch.
+func (s *Store) Rename(oldBranch, newBranch string) {
+	m, ok := s.Worktrees[oldBranch]
+	if !ok {
+		return
+	}
+	delete(s.Worktrees, oldBranch)
+	s.Worktrees[newBranch] = m
+}
+
+// IsZero reports whether m records nothing.
+func (m *Meta) IsZero() bool {
+	return m.Note == "" && len(m.Tags) == 0 && m.Owner == "" && m.Issue == "" &&
+		m.CreatedAt.IsZero() && m.CreatedBy == "" && m.Base == "" && len(m.Fields) == 0
+}
+
+// HasTag reports whether m carries tag.
+func (m *Meta) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// AddTags adds tags not already present, keeping Tags sorted.
+func (m *Meta) AddTags(tags ...string) {
+	for _, t := range tags {
+		if !m.HasTag(t) {
+			m.Tags = append(m.Tags, t)
+		}
+	}
+	slices.Sort(m.Tags)
+}
+
+// RemoveTags drops tags, reporting the ones that were not present.
+func (m *Meta) RemoveTags(tags ...string) (missing []string) {
+	for _, t := range tags {
+		i := slices.Index(m.Tags, t)
+		if i < 0 {
+			missing = append(missing, t)
+			continue
+		}
+		m.Tags = slices.Delete(m.Tags, i, i+1)
+	}
+	if len(m.Tags) == 0 {
+		m.Tags = nil
+	}
+	return missing
+}
+
+// SetField sets a free-form field; an empty value removes it.
+func (m *Meta) SetField(key, value string) {
+	if value == "" {
+		delete(m.Fields, key)
+		if len(m.Fields) == 0 {
+			m.Fields = nil
+		}
+		return
+	}
+	if m.Fields == nil {
+		m.Fields = make(map[string]string)
+	}
+	m.Fields[key] = value
+}
+
+// IsEmpty reports whether f matches every branch.
+func (f Filter) IsEmpty() bool {
+	return f.Tag == "" && f.Owner == ""
+}
+
+// Match reports whether m satisfies every set criterion of f.
+func (f Filter) Match(m Meta) bool {
+	if f.Tag != "" && !m.HasTag(f.Tag) {
+		return false
+	}
+	return f.Owner == "" || strings.EqualFold(f.Owner, m.Owner)
+}
+
+// ValidateTag rejects tags that would not survive a round trip through the
+// command line or the comma-joined TAGS column.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return errors.New("tag must not be empty")
+	}
+	if strings.ContainsAny(tag, ", \t\n") {
+		return fmt.Errorf("tag %q must not contain commas or whitespace", tag)
+	}
+	return nil
+}
+
+// ParseField splits a "key=value" argument. An empty value is allowed and
+// means "remove the field".
+func ParseField(arg string) (key, value string, err error) {
+	key, value, ok := strings.Cut(arg, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("field %q must be in key=value form", arg)
+	}
+	return key, value, nil
+}
+
+func storePath(commonDir string) string {
+	return filepath.Join(commonDir, storeFile)
+}
@@ -0,0 +1,145 @@
+package meta_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/meta"
+)
+
+const (
+	branchAuth  = "feature/auth"
+	branchLogin = "feature/login"
+	tagSpike    = "spike"
+	tagUrgent   = "urgent"
+)
+
+func TestLoadMissingReturnsEmpty(t *testing.T) {
+	s, err := meta.Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(s.Worktrees) != 0 {
+		t.Errorf("Worktrees = %v, want empty", s.Worktrees)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := &meta.Store{Worktrees: map[string]meta.Meta{
+		branchAuth: {Note: "token refresh", Tags: []string{tagSpike}, CreatedAt: created, Fields: map[string]string{"env": "staging"}},
+	}}
+	if err := s.Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := meta.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	m := got.Get(branchAuth)
+	if m.Note != "token refresh" || !m.CreatedAt.Equal(created) || m.Fields["env"] != "staging" {
+		t.Errorf("Get = %+v", m)
+	}
+}
+
+func TestUpdateDropsEmptyEntry(t *testing.T) {
+	s := &meta.Store{Worktrees: make(map[string]meta.Meta)}
+	s.Update(branchAuth, func(m *meta.Meta) { m.Note = "x" })
+	s.Update(branchAuth, func(m *meta.Meta) { m.Note = "" })
+	if _, ok := s.Worktrees[branchAuth]; ok {
+		t.Error("empty metadata should leave the store")
+	}
+}
+
+func TestRenameMovesEntry(t *testing.T) {
+	s := &meta.Store{Worktrees: map[string]meta.Meta{branchAuth: {Owner: "dana"}}}
+	s.Rename(branchAuth, branchLogin)
+	if _, ok := s.Worktrees[branchAuth]; ok {
+		t.Error("old branch still present")
+	}
+	if s.Get(branchLogin).Owner != "dana" {
+		t.Errorf("new branch = %+v", s.Get(branchLogin))
+	}
+}
+
+func TestAddRemoveTags(t *testing.T) {
+	var m meta.Meta
+	m.AddTags(tagUrgent, tagSpike, tagUrgent)
+	if want := []string{tagSpike, tagUrgent}; !slices.Equal(m.Tags, want) {
+		t.Fatalf("Tags = %v, want %v", m.Tags, want)
+	}
+
+	missing := m.RemoveTags(tagSpike, "nope")
+	if !slices.Equal(missing, []string{"nope"}) {
+		t.Errorf("missing = %v", missing)
+	}
+	m.RemoveTags(tagUrgent)
+	if m.Tags != nil {
+		t.Errorf("Tags = %v, want nil", m.Tags)
+	}
+}
+
+func TestSetFieldEmptyRemoves(t *testing.T) {
+	var m meta.Meta
+	m.SetField("env", "prod")
+	m.SetField("env", "")
+	if !m.IsZero() {
+		t.Errorf("meta = %+v, want zero", m)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	m := meta.Meta{Tags: []string{tagSpike}, Owner: "Dana"}
+	tests := []struct {
+		name string
+		f    meta.Filter
+		want bool
+	}{
+		{"empty", meta.Filter{}, true},
+		{"tag", meta.Filter{Tag: tagSpike}, true},
+		{"other tag", meta.Filter{Tag: tagUrgent}, false},
+		{"owner case-insensitive", meta.Filter{Owner: "dana"}, true},
+		{"tag and wrong owner", meta.Filter{Tag: tagSpike, Owner: "lee"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.Match(m); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, bad := range []string{"", "a,b", "two words"} {
+		if meta.ValidateTag(bad) == nil {
+			t.Errorf("ValidateTag(%q) = nil, want error", bad)
+		}
+	}
+	if err := meta.ValidateTag("needs-review"); err != nil {
+		t.Errorf("ValidateTag: %v", err)
+	}
+}
+
+func TestParseField(t *testing.T) {
+	k, v, err := meta.ParseField("env=staging=2")
+	if err != nil || k != "env" || v != "staging=2" {
+		t.Errorf("ParseField = %q, %q, %v", k, v, err)
+	}
+	if _, _, err := meta.ParseField("=x"); err == nil {
+		t.Error("want error for empty key")
+	}
+	if _, _, err := meta.ParseField("novalue"); err == nil {
+		t.Error("want error without '='")
+	}
+}
+
+func TestBaseIsNotZero(t *testing.T) {
+	m := meta.Meta{Base: "release/2.3"}
+	if m.IsZero() {
+		t.Error("meta with only a base should not be zero")
+	}
+}
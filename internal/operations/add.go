@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/deps"
@@ -27,6 +28,9 @@ type PostCreateOptions struct {
 	SkipHooks     bool
 	PostCreate    []string // hook commands
 	Concurrency   int      // max parallel module installs; 0 = Manager default
+	// KeepOnFailure leaves a partially built worktree in place instead of
+	// rolling it back.
+	KeepOnFailure bool
 }
 
 // AddParams holds the inputs for creating a new worktree.
@@ -35,7 +39,18 @@ type AddParams struct {
 	Service string
 	Prefix  string // e.g. "feature/"
 	Source  string // source branch
+	// Parent, when set, is the branch of the worktree this one stacks on.
+	// It replaces Source and is recorded so `rimba sync --stack` can restack.
+	Parent string
+	// Base, when set, is recorded as the branch the worktree tracks — what
+	// sync, merge, clean, and conflict-check compare it against — because
+	// Source differs from the configured base.
+	Base string
 	PostCreateOptions
+
+	// txn, when set, continues a transaction the caller began (AddPRWorktree
+	// adds a fork remote first); otherwise AddWorktree starts its own.
+	txn *Txn
 }
 
 // AddResult holds the outcome of creating a worktree.
@@ -45,17 +60,34 @@ type AddResult struct {
 	Branch          string
 	Path            string
 	Source          string
+	Parent          string // stack parent branch; empty for an unstacked worktree
 	Copied          []string
 	Skipped         []string // copy_files entries not found
 	SkippedSymlinks []string // nested symlinks inside copied directories
 	DepsResults     []deps.InstallResult
 	HookResults     []deps.HookResult
+	// Transaction records the steps that completed and, after a failure,
+	// whether they were rolled back.
+	Transaction TxnReport
+}
+
+// WithPrefixDefaults applies a [[resolver.prefix]] entry's setup overrides:
+// its skip_deps, and its post_create hooks after the top-level ones.
+func (o PostCreateOptions) WithPrefixDefaults(e config.PrefixEntry) PostCreateOptions {
+	o.SkipDeps = o.SkipDeps || e.SkipDeps
+	if len(e.PostCreate) > 0 {
+		o.PostCreate = append(slices.Clip(o.PostCreate), e.PostCreate...)
+	}
+	return o
 }
 
 // AddWorktree creates a new worktree, copies files, installs deps, and runs hooks.
 func AddWorktree(ctx context.Context, r git.Runner, params AddParams, onProgress progress.Func) (AddResult, error) {
 	branch := resolver.FullBranchName(params.Service, params.Prefix, params.Task)
 	wtPath := resolver.WorktreePath(params.WorktreeDir, branch)
+	if params.Parent != "" {
+		params.Source = params.Parent
+	}
 
 	result := AddResult{
 		Task:    params.Task,
@@ -63,33 +95,71 @@ func AddWorktree(ctx context.Context, r git.Runner, params AddParams, onProgress
 		Branch:  branch,
 		Path:    wtPath,
 		Source:  params.Source,
+		Parent:  params.Parent,
+	}
+
+	txn := params.txn
+	if txn == nil {
+		txn = &Txn{Keep: params.KeepOnFailure}
+	}
+	fail := func(err error) (AddResult, error) {
+		err = txn.Rollback(err)
+		result.Transaction = txn.Report()
+		return result, err
 	}
 
 	// Validate
 	if err := ValidateBranchInput(params.Task, params.Service); err != nil {
-		return result, err
+		return fail(err)
 	}
 	if git.BranchExists(ctx, r, branch) {
-		return result, errhint.WithFix(
+		return fail(errhint.WithFix(
 			fmt.Errorf("branch %q already exists", branch),
 			"run 'rimba list' to see existing tasks, or use a different task name",
-		)
+		))
 	}
 	if _, err := os.Stat(wtPath); err == nil {
-		return result, errhint.WithFix(
+		return fail(errhint.WithFix(
 			fmt.Errorf("worktree path already exists: %s", wtPath),
 			"run 'rimba list' to see existing tasks, or use a different task name",
-		)
+		))
 	}
 
 	// Create worktree
 	progress.Notify(onProgress, "Creating worktree...")
-	rec := observability.FromContext(ctx)
-	stop := rec.StartSpan("create")
-	err := git.AddWorktree(ctx, r, wtPath, branch, params.Source)
+	stop := observability.FromContext(ctx).StartSpan("create")
+	err := CreateWorktreeStep(ctx, r, txn, wtPath, branch, params.Source)
 	stop()
 	if err != nil {
-		return result, err
+		return fail(err)
+	}
+
+	if err := RecordCreatedStep(ctx, r, txn, branch, params.Base); err != nil {
+		if txn.RollsBack() {
+			return fail(fmt.Errorf("base %q not recorded: %w", params.Base, err))
+		}
+		return fail(errhint.WithFix(
+			fmt.Errorf("worktree created but base %q not recorded: %w", params.Base, err),
+			"remove it and retry: rimba remove "+params.Task,
+		))
+	}
+
+	if params.Parent != "" {
+		err := txn.Do(ctx, StepLinkStack, func() error {
+			return LinkStackParent(ctx, r, branch, params.Parent)
+		}, func(ctx context.Context) error {
+			unlinkStacked(ctx, r, branch, false)
+			return nil
+		})
+		if err != nil {
+			if txn.RollsBack() {
+				return fail(fmt.Errorf("stack link to %q not recorded: %w", params.Parent, err))
+			}
+			return fail(errhint.WithFix(
+				fmt.Errorf("worktree created but stack link to %q not recorded: %w", params.Parent, err),
+				"remove it and retry: rimba remove "+params.Task,
+			))
+		}
 	}
 
 	// Post-create setup: copy files, deps, hooks
@@ -105,19 +175,37 @@ func AddWorktree(ctx context.Context, r git.Runner, params AddParams, onProgress
 		SkipHooks:     params.SkipHooks,
 		PostCreate:    params.PostCreate,
 		Concurrency:   params.Concurrency,
+		Txn:           txn,
 	}, onProgress)
-	if err != nil {
-		return result, err
-	}
 	result.Copied = pcResult.Copied
 	result.Skipped = pcResult.Skipped
 	result.SkippedSymlinks = pcResult.SkippedSymlinks
 	result.DepsResults = pcResult.DepsResults
 	result.HookResults = pcResult.HookResults
+	if err != nil {
+		return fail(err)
+	}
+	result.Transaction = txn.Report()
 
 	return result, nil
 }
 
+// CreateWorktreeStep creates the worktree at wtPath on a new branch from
+// source as txn's StepCreateWorktree, registering its removal, along with the
+// branch, as the compensating action.
+func CreateWorktreeStep(ctx context.Context, r git.Runner, txn *Txn, wtPath, branch, source string) error {
+	err := txn.Do(ctx, StepCreateWorktree, func() error {
+		return git.AddWorktree(ctx, r, wtPath, branch, source)
+	}, func(ctx context.Context) error {
+		return discardNewWorktree(ctx, r, wtPath, branch)
+	})
+	// An interrupted `git worktree add -b` can leave the branch behind.
+	if err != nil && txn.RollsBack() && git.BranchExists(context.Background(), r, branch) {
+		_ = discardNewWorktree(context.Background(), r, wtPath, branch)
+	}
+	return err
+}
+
 // ValidateBranchInput rejects task/service names that are unsafe as git refs
 // (leading dash, path traversal, control/shell chars) before branch creation.
 func ValidateBranchInput(task, service string) error {
@@ -135,3 +223,28 @@ func ValidateBranchInput(task, service string) error {
 	}
 	return nil
 }
+
+// RecordCreatedStep stamps the new worktree's branch (recordCreated). A
+// base to track is recorded as txn's StepRecordBase, failing the add when
+// it cannot be; without one the stamp is best-effort. Removing the worktree
+// drops the metadata, so the step needs no compensating action.
+func RecordCreatedStep(ctx context.Context, r git.Runner, txn *Txn, branch, base string) error {
+	if base == "" {
+		_ = recordCreated(ctx, r, branch, "")
+		return nil
+	}
+	return txn.Do(ctx, StepRecordBase, func() error {
+		return recordCreated(ctx, r, branch, base)
+	}, nil)
+}
+
+// discardNewWorktree is the compensating action for creating a worktree on a
+// new branch: it removes the directory, whatever was built in it, the branch,
+// and the metadata recorded for it.
+func discardNewWorktree(ctx context.Context, r git.Runner, wtPath, branch string) error {
+	if err := git.RemoveWorktree(ctx, r, wtPath, true); err != nil {
+		return err
+	}
+	forgetMeta(ctx, r, branch)
+	return git.DeleteBranch(ctx, r, branch, true)
+}
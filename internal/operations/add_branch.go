@@ -59,6 +59,7 @@ func PromoteBranch(ctx context.Context, worktreeDir string, r git.Runner, repoRo
 		}
 		return "", fmt.Errorf("create worktree: %w", err)
 	}
+	_ = recordCreated(ctx, r, branch, "") // best-effort: no base to lose
 
 	if stashSHA != "" {
 		return wtPath, applyStashToWorktree(r, wtPath, stashSHA)
@@ -44,21 +44,27 @@ func AddPRWorktree(
 		task = "review/" + strconv.Itoa(meta.Number) + "-" + resolver.Slugify(meta.Title)
 	}
 
-	source, err := resolveSource(ctx, gitR, meta, onProgress)
+	txn := &Txn{Keep: params.KeepOnFailure}
+	source, err := resolveSource(ctx, gitR, txn, meta, onProgress)
 	if err != nil {
-		return AddResult{}, err
+		result := AddResult{Task: task}
+		err = txn.Rollback(err)
+		result.Transaction = txn.Report()
+		return result, err
 	}
 
 	return AddWorktree(ctx, gitR, AddParams{
 		Task:              task,
 		Source:            source,
 		PostCreateOptions: params.PostCreateOptions,
+		txn:               txn,
 	}, onProgress)
 }
 
 // resolveSource fetches the appropriate remote ref and returns the source ref
-// for use with git worktree add -b <branch> <path> <source>.
-func resolveSource(ctx context.Context, gitR git.Runner, meta gh.PRMeta, onProgress progress.Func) (string, error) {
+// for use with git worktree add -b <branch> <path> <source>. A fork remote it
+// adds is registered with txn so a failed add removes it again.
+func resolveSource(ctx context.Context, gitR git.Runner, txn *Txn, meta gh.PRMeta, onProgress progress.Func) (string, error) {
 	if !meta.IsCrossRepository {
 		progress.Notify(onProgress, "Fetching origin...")
 		if err := git.Fetch(ctx, gitR, "origin", git.FetchArgs{}); err != nil {
@@ -72,7 +78,12 @@ func resolveSource(ctx context.Context, gitR git.Runner, meta gh.PRMeta, onProgr
 
 	if !git.RemoteExists(ctx, gitR, remoteName) {
 		progress.Notify(onProgress, fmt.Sprintf("Adding fork remote %s...", remoteName))
-		if err := git.AddRemote(ctx, gitR, remoteName, remoteURL); err != nil {
+		err := txn.Do(ctx, StepAddForkRemote, func() error {
+			return git.AddRemote(ctx, gitR, remoteName, remoteURL)
+		}, func(ctx context.Context) error {
+			return git.RemoveRemote(ctx, gitR, remoteName)
+		})
+		if err != nil {
 			return "", errhint.WithFix(err, "check network and fork visibility")
 		}
 	}
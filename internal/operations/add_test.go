@@ -5,12 +5,16 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/gitref"
 	"github.com/lugassawan/rimba/internal/observability"
 	"github.com/lugassawan/rimba/internal/progress"
+	"github.com/lugassawan/rimba/testutil"
 )
 
 func TestAddWorktreeSuccess(t *testing.T) {
@@ -406,7 +410,7 @@ func TestAddWorktreeWithHooks(t *testing.T) {
 				return "", errors.New("not found")
 			}
 			if len(args) > 0 && args[0] == gitCmdWorktree && len(args) > 1 && args[1] == gitSubcmdAdd {
-				_ = os.MkdirAll(args[2], 0o755)
+				_ = os.MkdirAll(args[len(args)-2], 0o755)
 				return "", nil
 			}
 			return "", nil
@@ -431,3 +435,113 @@ func TestAddWorktreeWithHooks(t *testing.T) {
 		t.Fatalf("expected 1 hook result, got %d", len(result.HookResults))
 	}
 }
+
+func TestAddWorktreeRollsBackOnHookFailure(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+	wtDir := t.TempDir()
+
+	result, err := AddWorktree(context.Background(), r, AddParams{
+		Task:   "login",
+		Prefix: "feature/",
+		Source: branchMain,
+		PostCreateOptions: PostCreateOptions{
+			RepoRoot:    repo,
+			WorktreeDir: wtDir,
+			SkipDeps:    true,
+			PostCreate:  []string{"exit 1"},
+		},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Rolled back: create worktree") {
+		t.Fatalf("err = %v, want rolled-back hook failure", err)
+	}
+	if git.BranchExists(context.Background(), r, result.Branch) {
+		t.Error("branch should be deleted on rollback")
+	}
+	if _, err := os.Stat(result.Path); !os.IsNotExist(err) {
+		t.Errorf("worktree should be removed on rollback, stat err = %v", err)
+	}
+	tx := result.Transaction
+	if !tx.RolledBack || tx.Failed != StepRunHooks || !slices.Contains(tx.Completed, StepCreateWorktree) {
+		t.Errorf("transaction = %+v", tx)
+	}
+}
+
+func TestAddWorktreeRollsBackWhenBaseNotRecorded(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+	if err := os.MkdirAll(filepath.Join(repo, ".git", "rimba"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	testutil.CreateFile(t, filepath.Join(repo, ".git", "rimba"), "meta.json", "{not json")
+
+	result, err := AddWorktree(context.Background(), r, AddParams{
+		Task:   "login",
+		Prefix: "feature/",
+		Source: branchMain,
+		Base:   "release/1.0",
+		PostCreateOptions: PostCreateOptions{
+			RepoRoot:    repo,
+			WorktreeDir: t.TempDir(),
+			SkipDeps:    true,
+		},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), `base "release/1.0" not recorded`) {
+		t.Fatalf("err = %v, want an unrecorded base failure", err)
+	}
+	if git.BranchExists(context.Background(), r, result.Branch) {
+		t.Error("branch should be deleted on rollback")
+	}
+	if tx := result.Transaction; !tx.RolledBack || tx.Failed != StepRecordBase {
+		t.Errorf("transaction = %+v", tx)
+	}
+}
+
+func TestAddWorktreeKeepOnFailure(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	result, err := AddWorktree(context.Background(), r, AddParams{
+		Task:   "login",
+		Prefix: "feature/",
+		Source: branchMain,
+		PostCreateOptions: PostCreateOptions{
+			RepoRoot:      repo,
+			WorktreeDir:   t.TempDir(),
+			SkipDeps:      true,
+			PostCreate:    []string{"exit 1"},
+			KeepOnFailure: true,
+		},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Kept completed steps") {
+		t.Fatalf("err = %v, want kept hook failure", err)
+	}
+	if !git.BranchExists(context.Background(), r, result.Branch) {
+		t.Error("branch should be kept")
+	}
+	if _, err := os.Stat(result.Path); err != nil {
+		t.Errorf("worktree should be kept: %v", err)
+	}
+	if result.Transaction.RolledBack {
+		t.Error("transaction should not be rolled back")
+	}
+}
+
+func TestPostCreateOptionsWithPrefixDefaults(t *testing.T) {
+	base := PostCreateOptions{PostCreate: []string{"make setup"}}
+
+	got := base.WithPrefixDefaults(config.PrefixEntry{SkipDeps: true, PostCreate: []string{"make release-env"}})
+	if !got.SkipDeps {
+		t.Error("SkipDeps = false, want the prefix's skip_deps")
+	}
+	if want := []string{"make setup", "make release-env"}; !slices.Equal(got.PostCreate, want) {
+		t.Errorf("PostCreate = %v, want %v", got.PostCreate, want)
+	}
+	if len(base.PostCreate) != 1 {
+		t.Errorf("base PostCreate mutated: %v", base.PostCreate)
+	}
+
+	if got := base.WithPrefixDefaults(config.PrefixEntry{}); got.SkipDeps || len(got.PostCreate) != 1 {
+		t.Errorf("zero entry changed options: %+v", got)
+	}
+}
@@ -9,20 +9,25 @@ import (
 
 // ArchiveParams holds the inputs for an archive operation.
 type ArchiveParams struct {
-	Path   string
-	Branch string
-	Force  bool
-	DryRun bool
+	Path      string
+	Branch    string
+	CopyFiles []string
+	Force     bool
+	DryRun    bool
 }
 
 // ArchiveResult holds the outcome of an archive operation.
 type ArchiveResult struct {
-	Path   string
-	Branch string
-	Plan   *Plan
+	Path     string
+	Branch   string
+	Snapshot ArchiveSnapshot
+	Plan     *Plan
 }
 
 // ArchiveWorktree removes the worktree directory while preserving the local branch.
+// Unless Force is set, uncommitted and untracked changes and the CopyFiles
+// entries are snapshotted first so RestoreArchiveSnapshot can bring them back;
+// Force discards them instead.
 func ArchiveWorktree(ctx context.Context, r git.Runner, params ArchiveParams) (ArchiveResult, error) {
 	plan := &Plan{DryRun: params.DryRun}
 	result := ArchiveResult{
@@ -31,10 +36,21 @@ func ArchiveWorktree(ctx context.Context, r git.Runner, params ArchiveParams) (A
 		Plan:   plan,
 	}
 
+	if !params.Force {
+		snap, err := snapshotWorktree(ctx, r, plan, params)
+		if err != nil {
+			return result, err
+		}
+		result.Snapshot = snap
+	}
+
 	desc := fmt.Sprintf("remove worktree: %s (branch %s preserved)", params.Path, params.Branch)
 	if err := plan.Do(desc, func() error {
 		return git.RemoveWorktree(ctx, r, params.Path, params.Force)
 	}); err != nil {
+		if rbErr := unsnapshotWorktree(ctx, r, params.Path, params.Branch, result.Snapshot); rbErr != nil {
+			return result, fmt.Errorf("%w; also failed to put snapshotted changes back: %w", err, rbErr)
+		}
 		return result, err
 	}
 
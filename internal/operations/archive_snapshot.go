@@ -0,0 +1,223 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/fileutil"
+	"github.com/lugassawan/rimba/internal/git"
+)
+
+// ArchiveSnapshot describes the work set aside when a worktree is archived.
+type ArchiveSnapshot struct {
+	// Stashed reports that uncommitted and untracked changes were saved
+	// under ArchiveRef.
+	Stashed bool
+	// Files lists the copy_files entries saved alongside.
+	Files []string
+}
+
+const (
+	archiveRefPrefix = "refs/rimba/archive/"
+
+	// archiveSnapshotDir holds the saved copy_files entries, one directory per
+	// branch, relative to the git common dir.
+	archiveSnapshotDir = "rimba/archive"
+)
+
+// ArchiveRef returns the hidden ref that holds branch's archived changes.
+func ArchiveRef(branch string) string {
+	return archiveRefPrefix + branch
+}
+
+// IsEmpty reports whether nothing was set aside.
+func (s ArchiveSnapshot) IsEmpty() bool {
+	return !s.Stashed && len(s.Files) == 0
+}
+
+// Unrestored returns the copyFiles entries that did not come back from the
+// snapshot and so should still be copied from the main repo.
+func (s ArchiveSnapshot) Unrestored(copyFiles []string) []string {
+	return fileutil.SkippedEntries(copyFiles, s.Files)
+}
+
+// RestoreArchiveSnapshot reapplies the changes set aside when branch was
+// archived to the freshly recreated worktree at wtPath, then discards the
+// snapshot. A branch archived without a snapshot yields an empty result.
+// On failure the snapshot is kept so nothing is lost.
+func RestoreArchiveSnapshot(ctx context.Context, r git.Runner, branch, wtPath string, copyFiles []string) (ArchiveSnapshot, error) {
+	var snap ArchiveSnapshot
+	ref := ArchiveRef(branch)
+
+	if sha := archiveSnapshotSHA(ctx, r, branch); sha != "" {
+		if err := git.StashApplyIndex(ctx, r, wtPath, sha); err != nil {
+			return snap, errhint.WithFix(
+				fmt.Errorf("reapply archived changes: %w", err),
+				fmt.Sprintf("your changes are preserved in %s — recover them with: cd %s && git stash apply --index %s", ref, wtPath, ref),
+			)
+		}
+		snap.Stashed = true
+	}
+
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		if snap.Stashed {
+			return snap, err
+		}
+		return snap, nil // no state dir, so no saved files either
+	}
+	filesDir := archiveFilesDir(commonDir, branch)
+	if _, err := os.Stat(filesDir); err == nil {
+		copied, _, err := fileutil.CopyEntries(filesDir, wtPath, copyFiles)
+		if err != nil {
+			return snap, errhint.WithFix(
+				fmt.Errorf("restore archived files: %w", err),
+				"copy them manually from "+filesDir,
+			)
+		}
+		snap.Files = copied
+	}
+
+	if snap.IsEmpty() {
+		return snap, nil
+	}
+	return snap, discardArchiveSnapshot(ctx, r, commonDir, branch)
+}
+
+// snapshotWorktree sets aside the worktree's copy_files entries and its
+// uncommitted and untracked changes so that removing the directory loses
+// nothing. The files are saved first: stashing would sweep away any that are
+// untracked. Ignored files other than copy_files are not kept.
+func snapshotWorktree(ctx context.Context, r git.Runner, plan *Plan, params ArchiveParams) (ArchiveSnapshot, error) {
+	var snap ArchiveSnapshot
+	if !plan.DryRun && archiveSnapshotSHA(ctx, r, params.Branch) != "" {
+		ref := ArchiveRef(params.Branch)
+		return snap, errhint.WithFix(
+			fmt.Errorf("an earlier archive snapshot of %s was never restored", params.Branch),
+			fmt.Sprintf("inspect it with: git stash show -p --include-untracked %s  then discard it with: git update-ref -d %s", ref, ref),
+		)
+	}
+
+	if files := existingEntries(params.Path, params.CopyFiles); len(files) > 0 {
+		desc := fmt.Sprintf("save %s to the archive snapshot", strings.Join(files, ", "))
+		if err := plan.Do(desc, func() error {
+			return saveArchiveFiles(ctx, r, params.Path, params.Branch, files)
+		}); err != nil {
+			return snap, err
+		}
+		snap.Files = files
+	}
+
+	dirty, err := git.IsDirty(ctx, r, params.Path)
+	if err == nil && dirty {
+		err = plan.Do("stash uncommitted changes to "+ArchiveRef(params.Branch), func() error {
+			return stashToArchiveRef(ctx, r, params.Path, params.Branch)
+		})
+		snap.Stashed = err == nil
+	}
+	if err != nil {
+		if !plan.DryRun {
+			_ = unsnapshotWorktree(ctx, r, params.Path, params.Branch, snap)
+		}
+		return ArchiveSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// unsnapshotWorktree undoes snapshotWorktree when the worktree could not be
+// removed after all: stashed changes go back into the worktree (the saved
+// files never left it) and the snapshot is discarded.
+func unsnapshotWorktree(ctx context.Context, r git.Runner, wtPath, branch string, snap ArchiveSnapshot) error {
+	if snap.IsEmpty() {
+		return nil
+	}
+	if snap.Stashed {
+		ref := ArchiveRef(branch)
+		if err := git.StashApplyIndex(ctx, r, wtPath, archiveSnapshotSHA(ctx, r, branch)); err != nil {
+			return fmt.Errorf("your changes are preserved in %s — recover them with: cd %s && git stash apply --index %s: %w", ref, wtPath, ref, err)
+		}
+	}
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return err
+	}
+	return discardArchiveSnapshot(ctx, r, commonDir, branch)
+}
+
+// stashToArchiveRef stashes dir's changes and moves the stash commit onto
+// branch's archive ref.
+func stashToArchiveRef(ctx context.Context, r git.Runner, dir, branch string) error {
+	sha, err := git.StashPushAndRef(ctx, r, dir, "rimba: archive "+branch)
+	if err != nil {
+		return err
+	}
+	if err := git.UpdateRef(ctx, r, ArchiveRef(branch), sha); err != nil {
+		if applyErr := git.StashApplyIndex(ctx, r, dir, sha); applyErr != nil {
+			return fmt.Errorf("%w; your changes are preserved in stash %s (look for 'rimba: archive ...' in git stash list): %w", err, sha, applyErr)
+		}
+		_ = git.StashDrop(r, dir, sha)
+		return err
+	}
+	// The ref keeps the stash commit reachable; drop the entry so archives do
+	// not pile up in the stash list every worktree shares. Best-effort.
+	_ = git.StashDrop(r, dir, sha)
+	return nil
+}
+
+// saveArchiveFiles copies the named entries of wtPath into branch's snapshot
+// directory, replacing any leftovers from an earlier archive.
+func saveArchiveFiles(ctx context.Context, r git.Runner, wtPath, branch string, files []string) error {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return err
+	}
+	dir := archiveFilesDir(commonDir, branch)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clear archive snapshot: %w", err)
+	}
+	if _, _, err := fileutil.CopyEntries(wtPath, dir, files); err != nil {
+		return fmt.Errorf("save archive snapshot: %w", err)
+	}
+	return nil
+}
+
+// discardArchiveSnapshot removes branch's archive ref and saved files.
+func discardArchiveSnapshot(ctx context.Context, r git.Runner, commonDir, branch string) error {
+	if err := os.RemoveAll(archiveFilesDir(commonDir, branch)); err != nil {
+		return fmt.Errorf("remove archive snapshot: %w", err)
+	}
+	return git.DeleteRef(ctx, r, ArchiveRef(branch))
+}
+
+// archiveSnapshotSHA returns the commit branch's archive ref points to, or ""
+// when the branch has no archived changes.
+func archiveSnapshotSHA(ctx context.Context, r git.Runner, branch string) string {
+	sha, err := git.ResolveRef(ctx, r, ArchiveRef(branch))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(sha)
+}
+
+// existingEntries returns the entries that exist under dir.
+func existingEntries(dir string, entries []string) []string {
+	var found []string
+	for _, name := range entries {
+		p, err := fileutil.ContainedJoin(dir, name)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(p); err == nil {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+func archiveFilesDir(commonDir, branch string) string {
+	return filepath.Join(commonDir, archiveSnapshotDir, filepath.FromSlash(branch))
+}
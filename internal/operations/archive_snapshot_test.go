@@ -0,0 +1,164 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+const (
+	branchParked = "feature/parked"
+	envFile      = ".env"
+)
+
+// parkedWorktree creates a linked worktree for branchParked holding a staged
+// file, an untracked file, and an ignored .env.
+func parkedWorktree(t *testing.T) (repo, wtPath string) {
+	t.Helper()
+	repo = testutil.NewTestRepo(t)
+	wtPath = filepath.Join(t.TempDir(), "parked")
+	testutil.GitCmd(t, repo, "worktree", "add", "-b", branchParked, wtPath)
+	testutil.CreateFile(t, filepath.Join(repo, ".git", "info"), "exclude", envFile+"\n")
+	testutil.CreateFile(t, wtPath, "staged.txt", "staged")
+	testutil.GitCmd(t, wtPath, "add", "staged.txt")
+	testutil.CreateFile(t, wtPath, "scratch.txt", "untracked")
+	testutil.CreateFile(t, wtPath, envFile, "TOKEN=worktree")
+	return repo, wtPath
+}
+
+func TestArchiveRestoreSnapshotRoundTrip(t *testing.T) {
+	repo, wtPath := parkedWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+	ctx := context.Background()
+
+	result, err := ArchiveWorktree(ctx, r, ArchiveParams{
+		Path:      wtPath,
+		Branch:    branchParked,
+		CopyFiles: []string{envFile, ".envrc"},
+	})
+	if err != nil {
+		t.Fatalf("ArchiveWorktree: %v", err)
+	}
+	if !result.Snapshot.Stashed || !slices.Equal(result.Snapshot.Files, []string{envFile}) {
+		t.Fatalf("snapshot = %+v, want stashed with .env", result.Snapshot)
+	}
+	if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+		t.Fatalf("worktree should be removed, stat err = %v", err)
+	}
+	if list := testutil.GitCmd(t, repo, "stash", "list"); strings.TrimSpace(list) != "" {
+		t.Errorf("archive should not leave a stash entry, got %q", list)
+	}
+
+	testutil.GitCmd(t, repo, "worktree", "add", wtPath, branchParked)
+	snap, err := RestoreArchiveSnapshot(ctx, r, branchParked, wtPath, []string{envFile, ".envrc"})
+	if err != nil {
+		t.Fatalf("RestoreArchiveSnapshot: %v", err)
+	}
+	if !snap.Stashed || !slices.Equal(snap.Files, []string{envFile}) {
+		t.Errorf("restored = %+v, want stashed with .env", snap)
+	}
+
+	status := testutil.GitCmd(t, wtPath, "status", "--porcelain")
+	for _, want := range []string{"A  staged.txt", "?? scratch.txt"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("status missing %q:\n%s", want, status)
+		}
+	}
+	if env, _ := os.ReadFile(filepath.Join(wtPath, envFile)); string(env) != "TOKEN=worktree" {
+		t.Errorf(".env = %q, want the worktree's copy", env)
+	}
+	if archiveSnapshotSHA(ctx, r, branchParked) != "" {
+		t.Error("archive ref should be deleted after restore")
+	}
+}
+
+func TestRestoreArchiveSnapshotWithoutSnapshot(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	snap, err := RestoreArchiveSnapshot(context.Background(), r, branchParked, repo, []string{envFile})
+	if err != nil {
+		t.Fatalf("RestoreArchiveSnapshot: %v", err)
+	}
+	if !snap.IsEmpty() {
+		t.Errorf("snapshot = %+v, want empty", snap)
+	}
+}
+
+func TestArchiveWorktreeForceDiscardsChanges(t *testing.T) {
+	repo, wtPath := parkedWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	result, err := ArchiveWorktree(context.Background(), r, ArchiveParams{
+		Path:      wtPath,
+		Branch:    branchParked,
+		CopyFiles: []string{envFile},
+		Force:     true,
+	})
+	if err != nil {
+		t.Fatalf("ArchiveWorktree: %v", err)
+	}
+	if !result.Snapshot.IsEmpty() {
+		t.Errorf("snapshot = %+v, want none with --force", result.Snapshot)
+	}
+	if archiveSnapshotSHA(context.Background(), r, branchParked) != "" {
+		t.Error("--force must not create an archive ref")
+	}
+}
+
+func TestArchiveWorktreeRefusesLeftoverSnapshot(t *testing.T) {
+	repo, wtPath := parkedWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+	head := strings.TrimSpace(testutil.GitCmd(t, repo, "rev-parse", "HEAD"))
+	testutil.GitCmd(t, repo, "update-ref", ArchiveRef(branchParked), head)
+
+	_, err := ArchiveWorktree(context.Background(), r, ArchiveParams{Path: wtPath, Branch: branchParked})
+	if err == nil || !strings.Contains(err.Error(), "never restored") {
+		t.Fatalf("err = %v, want leftover snapshot error", err)
+	}
+	if _, statErr := os.Stat(wtPath); statErr != nil {
+		t.Errorf("worktree must be kept, stat err = %v", statErr)
+	}
+}
+
+func TestArchiveWorktreeDryRunPlansSnapshot(t *testing.T) {
+	wtPath := t.TempDir()
+	testutil.CreateFile(t, wtPath, envFile, "TOKEN=x")
+	r := &mockRunner{
+		run: func(_ ...string) (string, error) {
+			t.Error("git must not be called in dry-run mode")
+			return "", nil
+		},
+		runInDir: func(_ string, _ ...string) (string, error) { return " M main.go", nil },
+	}
+
+	result, err := ArchiveWorktree(context.Background(), r, ArchiveParams{
+		Path:      wtPath,
+		Branch:    branchParked,
+		CopyFiles: []string{envFile},
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("ArchiveWorktree: %v", err)
+	}
+	steps := strings.Join(result.Plan.Steps, "\n")
+	for _, want := range []string{"save .env to the archive snapshot", "stash uncommitted changes to refs/rimba/archive/" + branchParked} {
+		if !strings.Contains(steps, want) {
+			t.Errorf("steps missing %q:\n%s", want, steps)
+		}
+	}
+}
+
+func TestArchiveSnapshotUnrestored(t *testing.T) {
+	snap := ArchiveSnapshot{Files: []string{envFile}}
+	got := snap.Unrestored([]string{envFile, ".envrc"})
+	if !slices.Equal(got, []string{".envrc"}) {
+		t.Errorf("Unrestored = %v, want [.envrc]", got)
+	}
+}
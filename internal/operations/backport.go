@@ -0,0 +1,154 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/progress"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// BackportParams holds the inputs for backporting a task to release branches.
+type BackportParams struct {
+	Source resolver.WorktreeInfo
+	// Base is the branch Source was cut from; the commits Source has made
+	// since its merge-base with Base are the ones backported.
+	Base string
+	// Config derives each backport branch's configured base; a target equal
+	// to it is not recorded as the worktree's base (TrackedBase).
+	Config      *config.Config
+	Targets     []string
+	Service     string
+	Prefix      string
+	Task        string
+	WorktreeDir string
+	DryRun      bool
+}
+
+// BackportTarget is the outcome of backporting to one target branch.
+type BackportTarget struct {
+	Target     string
+	Branch     string
+	Path       string
+	Picked     bool     // every commit applied cleanly
+	Conflicted bool     // the cherry-pick stopped on conflicts; the worktree is left mid-pick
+	Conflicts  []string // conflicted paths, when Conflicted
+	Resume     string   // command to finish a conflicted backport by hand
+	Error      error    // the target could not be backported at all
+}
+
+// BackportResult holds the outcome of a backport across all targets.
+type BackportResult struct {
+	Branch  string
+	Commits []string
+	Targets []BackportTarget
+	Plan    *Plan
+}
+
+// NeedsAttention reports whether any target conflicted or failed.
+func (r BackportResult) NeedsAttention() bool {
+	for _, t := range r.Targets {
+		if t.Conflicted || t.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// BackportBranch returns the branch a backport of task to target lives on:
+// the task's own prefix, with the target appended to the task name
+// (bugfix/crash → bugfix/crash-release-2.3).
+func BackportBranch(service, prefix, task, target string) string {
+	return resolver.FullBranchName(service, prefix, backportTask(task, target))
+}
+
+// BackportWorktree creates one worktree per target branch and cherry-picks
+// the source branch's commits into each. Targets are independent: a missing
+// target or an existing backport branch fails that target alone, and a
+// conflicting cherry-pick is left in progress for manual resolution while
+// the remaining targets carry on.
+func BackportWorktree(ctx context.Context, r git.Runner, p BackportParams, onProgress progress.Func) (BackportResult, error) {
+	plan := &Plan{DryRun: p.DryRun}
+	result := BackportResult{Branch: p.Source.Branch, Plan: plan}
+
+	mergeBase, err := git.MergeBase(ctx, r, p.Base, p.Source.Branch)
+	if err != nil {
+		return result, fmt.Errorf("find where %s left %s: %w", p.Source.Branch, p.Base, err)
+	}
+	result.Commits, err = git.CommitsSince(ctx, r, strings.TrimSpace(mergeBase), p.Source.Branch)
+	if err != nil {
+		return result, err
+	}
+	if len(result.Commits) == 0 {
+		return result, errhint.WithFix(
+			fmt.Errorf("%s has no commits since %s to backport", p.Source.Branch, p.Base),
+			"commit the fix in the worktree first: cd "+p.Source.Path,
+		)
+	}
+
+	for _, target := range p.Targets {
+		progress.Notifyf(onProgress, "Backporting to %s...", target)
+		result.Targets = append(result.Targets, backportTo(ctx, r, plan, p, target, result.Commits))
+	}
+	return result, nil
+}
+
+// backportTask returns the task name of task's backport to target.
+func backportTask(task, target string) string {
+	return task + "-" + strings.ReplaceAll(target, "/", "-")
+}
+
+// backportTo creates target's backport worktree and cherry-picks commits
+// into it, recording target as the worktree's base. A worktree whose base
+// cannot be recorded is removed again, as sync would move it onto the
+// wrong branch.
+func backportTo(ctx context.Context, r git.Runner, plan *Plan, p BackportParams, target string, commits []string) BackportTarget {
+	branch := BackportBranch(p.Service, p.Prefix, p.Task, target)
+	t := BackportTarget{Target: target, Branch: branch, Path: resolver.WorktreePath(p.WorktreeDir, branch)}
+
+	if _, err := git.ResolveRef(ctx, r, target); err != nil {
+		t.Error = errhint.WithFix(fmt.Errorf("unknown target branch %q", target), "fetch it first: git fetch origin "+target)
+		return t
+	}
+	if git.BranchExists(ctx, r, branch) {
+		t.Error = errhint.WithFix(fmt.Errorf("branch %q already exists", branch), "remove the earlier backport first: rimba remove "+backportTask(p.Task, target))
+		return t
+	}
+
+	if err := plan.Do(fmt.Sprintf("create worktree: %s (branch %s from %s)", t.Path, branch, target), func() error {
+		if err := git.AddWorktree(ctx, r, t.Path, branch, target); err != nil {
+			return err
+		}
+		if err := recordCreated(ctx, r, branch, TrackedBase(p.Config, branch, target)); err != nil {
+			return errors.Join(fmt.Errorf("base %q not recorded: %w", target, err), discardNewWorktree(ctx, r, t.Path, branch))
+		}
+		return nil
+	}); err != nil {
+		t.Error = err
+		return t
+	}
+
+	err := plan.Do(fmt.Sprintf("cherry-pick %d commit(s) from %s onto %s", len(commits), p.Source.Branch, target), func() error {
+		return git.CherryPick(ctx, r, t.Path, commits)
+	})
+	if err == nil {
+		t.Picked = true
+		return t
+	}
+
+	files, filesErr := git.UnmergedFiles(ctx, r, t.Path)
+	if filesErr != nil || len(files) == 0 {
+		_ = git.CherryPickAbort(r, t.Path)
+		t.Error = errors.Join(fmt.Errorf("cherry-pick onto %s failed: %w", target, err), filesErr)
+		return t
+	}
+	t.Conflicted = true
+	t.Conflicts = files
+	t.Resume = fmt.Sprintf("cd %s && git add <files> && git cherry-pick --continue", t.Path)
+	return t
+}
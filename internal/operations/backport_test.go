@@ -0,0 +1,247 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+const (
+	branchRelease22      = "release/2.2"
+	branchBackportAuth23 = "feature/auth-release-2.3"
+)
+
+// backportRunner serves BackportWorktree against commonDir. Every ref
+// resolves except "missing/…" and the branches in existing; rev-list yields
+// commits; a cherry-pick inside a path containing conflictIn stops on a
+// conflict in conflict.go. Worktree adds and RunInDir calls are recorded.
+func backportRunner(commonDir, commits, conflictIn string, existing []string, calls *[]string) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			last := args[len(args)-1]
+			switch {
+			case args[0] == cmdRevParse && args[1] == "--git-common-dir":
+				return commonDir, nil
+			case args[0] == cmdRevParse && strings.HasPrefix(strings.TrimPrefix(last, "refs/heads/"), "missing"):
+				return "", errors.New("unknown revision")
+			case args[0] == cmdRevParse && slices.Contains(existing, strings.TrimPrefix(last, "refs/heads/")):
+				return "sha", nil
+			case args[0] == cmdRevParse && strings.HasPrefix(last, "refs/heads/"):
+				return "", errors.New("not a branch")
+			case args[0] == git.CmdMergeBase:
+				return "fork123\n", nil
+			case args[0] == gitCmdRevList:
+				return commits, nil
+			case args[0] == gitCmdWorktree:
+				*calls = append(*calls, strings.Join(args, " "))
+				return "", nil
+			}
+			return "sha", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			*calls = append(*calls, strings.Join(args, " "))
+			conflicted := conflictIn != "" && strings.Contains(dir, conflictIn)
+			switch {
+			case args[0] == "cherry-pick" && args[1] == "-x" && conflicted:
+				return "", errors.New("could not apply aaa")
+			case args[0] == "diff" && conflicted:
+				return "conflict.go", nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func backportParams(dryRun bool, targets ...string) BackportParams {
+	return BackportParams{
+		Source:      resolver.WorktreeInfo{Branch: branchFeatureAuth, Path: "/wt/feature-auth"},
+		Base:        "main",
+		Config:      &config.Config{DefaultSource: "main"},
+		Targets:     targets,
+		Prefix:      "feature/",
+		Task:        "auth",
+		WorktreeDir: "/wt",
+		DryRun:      dryRun,
+	}
+}
+
+func TestBackportBranch(t *testing.T) {
+	if got := BackportBranch("", "bugfix/", "crash", branchRelease23); got != "bugfix/crash-release-2.3" {
+		t.Errorf("BackportBranch = %q", got)
+	}
+	if got := BackportBranch("api", "bugfix/", "crash", "v2"); got != "api/bugfix/crash-v2" {
+		t.Errorf("BackportBranch(service) = %q", got)
+	}
+}
+
+func TestBackportWorktreePicks(t *testing.T) {
+	commonDir := t.TempDir()
+	var calls []string
+	r := backportRunner(commonDir, "aaa\nbbb", "", nil, &calls)
+
+	res, err := BackportWorktree(context.Background(), r, backportParams(false, branchRelease23), nil)
+	if err != nil {
+		t.Fatalf("BackportWorktree: %v", err)
+	}
+	if !slices.Equal(res.Commits, []string{"aaa", "bbb"}) {
+		t.Errorf("commits = %v", res.Commits)
+	}
+	if len(res.Targets) != 1 || !res.Targets[0].Picked || res.NeedsAttention() {
+		t.Fatalf("targets = %+v, want one picked", res.Targets)
+	}
+	tg := res.Targets[0]
+	if tg.Branch != branchBackportAuth23 || tg.Path != "/wt/feature-auth-release-2.3" {
+		t.Errorf("target = %+v", tg)
+	}
+	if !slices.Contains(calls, "worktree add -b "+branchBackportAuth23+" -- "+tg.Path+" "+branchRelease23) {
+		t.Errorf("calls = %v, want worktree add from the target", calls)
+	}
+	if !slices.Contains(calls, "cherry-pick -x --end-of-options aaa bbb") {
+		t.Errorf("calls = %v, want cherry-pick of both commits", calls)
+	}
+	s, _ := meta.Load(commonDir)
+	if got := s.Get(branchBackportAuth23).Base; got != branchRelease23 {
+		t.Errorf("recorded base = %q, want %s", got, branchRelease23)
+	}
+}
+
+func TestBackportWorktreeSkipsConfiguredBase(t *testing.T) {
+	commonDir := t.TempDir()
+	var calls []string
+	r := backportRunner(commonDir, "aaa", "", nil, &calls)
+	p := backportParams(false, branchRelease23, branchRelease22)
+	p.Config.Resolver = &config.ResolverConfig{Prefix: []config.PrefixEntry{
+		{Prefix: "feature/", SyncTarget: branchRelease23},
+	}}
+
+	if _, err := BackportWorktree(context.Background(), r, p, nil); err != nil {
+		t.Fatalf("BackportWorktree: %v", err)
+	}
+	s, _ := meta.Load(commonDir)
+	if got := s.Get(branchBackportAuth23).Base; got != "" {
+		t.Errorf("base of the configured target = %q, want none recorded", got)
+	}
+	if got := s.Get("feature/auth-release-2.2").Base; got != branchRelease22 {
+		t.Errorf("base of the other target = %q, want %s", got, branchRelease22)
+	}
+}
+
+func TestBackportWorktreeBaseNotRecorded(t *testing.T) {
+	commonDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(commonDir, "rimba"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(commonDir, "rimba", "meta.json"), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	r := backportRunner(commonDir, "aaa", "", nil, &calls)
+
+	res, err := BackportWorktree(context.Background(), r, backportParams(false, branchRelease23), nil)
+	if err != nil {
+		t.Fatalf("BackportWorktree: %v", err)
+	}
+	tg := res.Targets[0]
+	if tg.Error == nil || !strings.Contains(tg.Error.Error(), "not recorded") {
+		t.Fatalf("target = %+v, want a base-not-recorded error", tg)
+	}
+	if !slices.Contains(calls, "worktree remove --force -- "+tg.Path) {
+		t.Errorf("calls = %v, want the new worktree removed", calls)
+	}
+	if slices.ContainsFunc(calls, func(c string) bool { return strings.HasPrefix(c, "cherry-pick") }) {
+		t.Errorf("calls = %v, want nothing picked", calls)
+	}
+}
+
+func TestBackportWorktreeConflictLeftOpen(t *testing.T) {
+	var calls []string
+	r := backportRunner(t.TempDir(), "aaa", "release-2.2", nil, &calls)
+
+	res, err := BackportWorktree(context.Background(), r, backportParams(false, branchRelease23, branchRelease22), nil)
+	if err != nil {
+		t.Fatalf("BackportWorktree: %v", err)
+	}
+	if !res.NeedsAttention() || len(res.Targets) != 2 {
+		t.Fatalf("targets = %+v, want two with attention needed", res.Targets)
+	}
+	if !res.Targets[0].Picked {
+		t.Errorf("first target = %+v, want picked", res.Targets[0])
+	}
+	conflicted := res.Targets[1]
+	if !conflicted.Conflicted || !slices.Equal(conflicted.Conflicts, []string{"conflict.go"}) {
+		t.Errorf("second target = %+v, want conflict in conflict.go", conflicted)
+	}
+	if !strings.Contains(conflicted.Resume, "git cherry-pick --continue") {
+		t.Errorf("resume = %q", conflicted.Resume)
+	}
+	if slices.Contains(calls, "cherry-pick --abort") {
+		t.Error("conflicted cherry-pick was aborted")
+	}
+}
+
+func TestBackportWorktreeTargetFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		existing []string
+		want     string
+	}{
+		{"unknown target", "missing/2.0", nil, "unknown target branch"},
+		{"existing backport", branchRelease23, []string{branchBackportAuth23}, "rimba remove auth-release-2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			r := backportRunner(t.TempDir(), "aaa", "", tt.existing, &calls)
+			res, err := BackportWorktree(context.Background(), r, backportParams(false, tt.target), nil)
+			if err != nil {
+				t.Fatalf("BackportWorktree: %v", err)
+			}
+			if tgErr := res.Targets[0].Error; tgErr == nil || !strings.Contains(tgErr.Error(), tt.want) {
+				t.Errorf("target error = %v, want %q", tgErr, tt.want)
+			}
+			if len(calls) != 0 {
+				t.Errorf("calls = %v, want none", calls)
+			}
+		})
+	}
+}
+
+func TestBackportWorktreeDryRun(t *testing.T) {
+	commonDir := t.TempDir()
+	var calls []string
+	r := backportRunner(commonDir, "aaa", "", nil, &calls)
+
+	res, err := BackportWorktree(context.Background(), r, backportParams(true, branchRelease23), nil)
+	if err != nil {
+		t.Fatalf("BackportWorktree: %v", err)
+	}
+	if len(res.Plan.Steps) != 2 {
+		t.Errorf("steps = %v, want create and cherry-pick", res.Plan.Steps)
+	}
+	if len(calls) != 0 {
+		t.Errorf("dry run ran %v", calls)
+	}
+	if s, _ := meta.Load(commonDir); s.Get(branchBackportAuth23).Base != "" {
+		t.Error("dry run recorded meta")
+	}
+}
+
+func TestBackportWorktreeNoCommits(t *testing.T) {
+	var calls []string
+	r := backportRunner(t.TempDir(), "", "", nil, &calls)
+
+	_, err := BackportWorktree(context.Background(), r, backportParams(false, branchRelease23), nil)
+	if err == nil || !strings.Contains(err.Error(), "no commits") {
+		t.Errorf("err = %v, want no commits error", err)
+	}
+}
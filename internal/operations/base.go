@@ -0,0 +1,181 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// RebaseParams holds the inputs for moving a worktree onto a new base.
+type RebaseParams struct {
+	Worktree resolver.WorktreeInfo
+	// From is the base the worktree tracks today; Onto is the one it moves to.
+	From string
+	Onto string
+	// ConfigBase is the base config derives for the branch; recording Onto
+	// is skipped (and any recorded base cleared) when the two match.
+	ConfigBase string
+	DryRun     bool
+}
+
+// RebaseResult holds the outcome of moving a worktree onto a new base.
+type RebaseResult struct {
+	Branch string
+	Path   string
+	From   string
+	Onto   string
+	Plan   *Plan
+}
+
+// TrackedBase returns the base to record for a new worktree on branch created
+// from source: source itself, or "" when it is the base config already
+// derives for branch, so a later config change still applies.
+func TrackedBase(cfg *config.Config, branch, source string) string {
+	if cfg == nil || source == cfg.BaseBranch(branch) {
+		return ""
+	}
+	return source
+}
+
+// BaseResolver returns a lookup of each branch's base: the base recorded at
+// add time or by `rimba rebase`, else the one config derives for its prefix
+// type, else mainBranch. The metadata store is read once. When it cannot be,
+// the lookup skips recorded bases and the error says so: commands that move
+// commits onto a base must fail on it rather than guess, while reports may
+// go on with the configured bases.
+func BaseResolver(ctx context.Context, r git.Runner, cfg *config.Config, mainBranch string) (func(branch string) string, error) {
+	s, err := LoadMeta(ctx, r)
+	if err != nil {
+		err = errhint.WithFix(
+			fmt.Errorf("cannot read the bases recorded for worktrees: %w", err),
+			"fix or remove .git/rimba/meta.json (removing it loses recorded notes, tags and bases), then retry",
+		)
+	}
+	return func(branch string) string {
+		if base := s.Get(branch).Base; base != "" {
+			return base
+		}
+		if cfg != nil {
+			return cfg.BaseBranch(branch)
+		}
+		return mainBranch
+	}, err
+}
+
+// BaseMergeRefs adapts baseOf for merged-branch detection against mergeRef:
+// "" for branches based on mainBranch, so they use mergeRef itself, and
+// otherwise the base — its remote-tracking ref when mergeRef is one and the
+// base has been fetched.
+func BaseMergeRefs(ctx context.Context, r git.Runner, mainBranch, mergeRef string, baseOf func(branch string) string) func(branch string) string {
+	remote := mergeRef != mainBranch && strings.HasSuffix(mergeRef, "/"+mainBranch)
+	return func(branch string) string {
+		base := baseOf(branch)
+		if base == mainBranch {
+			return ""
+		}
+		if remote {
+			ref := git.DefaultRemote + "/" + base
+			if _, err := git.ResolveRef(ctx, r, ref); err == nil {
+				return ref
+			}
+		}
+		return base
+	}
+}
+
+// RebaseWorktree moves a worktree from its current base onto a new one with
+// `git rebase --onto`, replaying only the commits since it left From, and
+// records Onto as the base it now tracks. A conflicting rebase is aborted so
+// the worktree is left as it was.
+func RebaseWorktree(ctx context.Context, r git.Runner, p RebaseParams) (RebaseResult, error) {
+	wt := p.Worktree
+	plan := &Plan{DryRun: p.DryRun}
+	result := RebaseResult{Branch: wt.Branch, Path: wt.Path, From: p.From, Onto: p.Onto, Plan: plan}
+
+	if err := checkRebasable(ctx, r, wt); err != nil {
+		return result, err
+	}
+	if _, err := git.ResolveRef(ctx, r, p.Onto); err != nil {
+		return result, errhint.WithFix(
+			fmt.Errorf("unknown ref %q: %w", p.Onto, err),
+			"fetch it first or pass an existing branch: git branch --list "+p.Onto,
+		)
+	}
+	upstream, err := git.MergeBase(ctx, r, p.From, wt.Branch)
+	if err != nil {
+		return result, fmt.Errorf("find where %s left %s: %w", wt.Branch, p.From, err)
+	}
+	upstream = strings.TrimSpace(upstream)
+	preSHA, err := git.ResolveRef(ctx, r, "refs/heads/"+wt.Branch)
+	if err != nil {
+		return result, fmt.Errorf("record %s before rebasing: %w", wt.Branch, err)
+	}
+	preSHA = strings.TrimSpace(preSHA)
+
+	desc := fmt.Sprintf("rebase %s onto %s (commits since %s)", wt.Branch, p.Onto, p.From)
+	if err := plan.Do(desc, func() error {
+		if err := git.RebaseOnto(ctx, r, wt.Path, p.Onto, upstream); err != nil {
+			_ = git.AbortRebase(r, wt.Path)
+			return errhint.WithFix(
+				fmt.Errorf("rebase onto %s failed: %w", p.Onto, err),
+				fmt.Sprintf("resolve by hand: cd %s && git rebase --onto %s %s", wt.Path, p.Onto, upstream),
+			)
+		}
+		return nil
+	}); err != nil {
+		return result, err
+	}
+
+	base := p.Onto
+	if base == p.ConfigBase {
+		base = ""
+	}
+	err = plan.Do("record base: "+p.Onto, func() error {
+		if _, err := UpdateMeta(ctx, r, wt.Branch, func(m *meta.Meta) { m.Base = base }); err != nil {
+			return undoRebase(r, wt, preSHA, fmt.Errorf("base %q not recorded: %w", p.Onto, err))
+		}
+		return nil
+	})
+	return result, err
+}
+
+// undoRebase puts wt's branch back at preSHA after its new base could not
+// be recorded: left rebased, the next sync would replay it onto the old one.
+func undoRebase(r git.Runner, wt resolver.WorktreeInfo, preSHA string, cause error) error {
+	if err := git.ResetHard(r, wt.Path, preSHA); err != nil {
+		return errhint.WithFix(
+			errors.Join(cause, fmt.Errorf("reset %s back to %s: %w", wt.Branch, preSHA, err)),
+			fmt.Sprintf("the branch is rebased but tracks its old base; restore it: cd %s && git reset --hard %s", wt.Path, preSHA),
+		)
+	}
+	return errhint.WithFix(cause, "the rebase was undone; fix or remove .git/rimba/meta.json, then retry")
+}
+
+// checkRebasable refuses a dirty worktree, whose changes a rebase would
+// disturb, and a stacked one, whose base is its parent's branch.
+func checkRebasable(ctx context.Context, r git.Runner, wt resolver.WorktreeInfo) error {
+	dirty, err := git.IsDirty(ctx, r, wt.Path)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return errhint.WithFix(
+			fmt.Errorf("worktree %s has uncommitted changes", wt.Branch),
+			"commit or stash them first: cd "+wt.Path+" && git stash",
+		)
+	}
+	if parent := StackParents(ctx, r)[wt.Branch]; parent != "" {
+		return errhint.WithFix(
+			errors.New(wt.Branch+" is stacked on "+parent),
+			"stacked worktrees follow their parent; use 'rimba sync --stack' instead",
+		)
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+const (
+	branchRelease23 = "release/2.3"
+	pathWtRebase    = "/wt/feature-rebase"
+)
+
+// rebaseRunner serves RebaseWorktree against commonDir: clean unless dirty,
+// every ref resolvable except "missing", and `rebase` failing with rebaseErr.
+// Each RunInDir call's args are appended to calls.
+func rebaseRunner(commonDir string, dirty bool, rebaseErr error, calls *[]string) *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case args[0] == cmdRevParse && args[1] == "--git-common-dir":
+				return commonDir, nil
+			case args[0] == cmdRevParse && strings.HasPrefix(args[len(args)-1], "missing"):
+				return "", errors.New("unknown revision")
+			case args[0] == git.CmdMergeBase:
+				return "fork123\n", nil
+			}
+			return "sha", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			*calls = append(*calls, strings.Join(args, " "))
+			switch args[0] {
+			case gitCmdStatus:
+				if dirty {
+					return dirtyStatusFixture, nil
+				}
+				return "", nil
+			case "rebase":
+				if args[1] == "--abort" {
+					return "", nil
+				}
+				return "", rebaseErr
+			}
+			return "", nil
+		},
+	}
+}
+
+func rebaseParams(dryRun bool) RebaseParams {
+	return RebaseParams{
+		Worktree:   resolver.WorktreeInfo{Branch: branchFeatureAuth, Path: pathWtRebase},
+		From:       "main",
+		Onto:       branchRelease23,
+		ConfigBase: "main",
+		DryRun:     dryRun,
+	}
+}
+
+func TestTrackedBase(t *testing.T) {
+	cfg := &config.Config{DefaultSource: "main", Resolver: &config.ResolverConfig{Prefix: []config.PrefixEntry{
+		{Prefix: "hotfix/", Source: branchRelease23},
+	}}}
+	tests := []struct {
+		name, branch, source, want string
+	}{
+		{"default source", branchFeatureAuth, "main", ""},
+		{"explicit release", branchFeatureAuth, branchRelease23, branchRelease23},
+		{"prefix type source", "hotfix/crash", branchRelease23, ""},
+		{"off the prefix type source", "hotfix/crash", "main", "main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrackedBase(cfg, tt.branch, tt.source); got != tt.want {
+				t.Errorf("TrackedBase = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := TrackedBase(nil, branchFeatureAuth, branchRelease23); got != "" {
+		t.Errorf("TrackedBase(nil cfg) = %q, want empty", got)
+	}
+}
+
+func TestBaseResolverPrecedence(t *testing.T) {
+	commonDir := t.TempDir()
+	seedMeta(t, commonDir, map[string]meta.Meta{branchFeatureAuth: {Base: branchRelease23}})
+	cfg := &config.Config{DefaultSource: "develop", Resolver: &config.ResolverConfig{Prefix: []config.PrefixEntry{
+		{Prefix: "bugfix/", SyncTarget: "release/2.2"},
+	}}}
+
+	baseOf, err := BaseResolver(context.Background(), metaRunner(commonDir), cfg, "main")
+	if err != nil {
+		t.Fatalf("BaseResolver: %v", err)
+	}
+	for branch, want := range map[string]string{
+		branchFeatureAuth: branchRelease23, // recorded
+		branchBugfixLogin: "release/2.2",   // prefix type
+		branchChoreLogout: "develop",       // config default
+	} {
+		if got := baseOf(branch); got != want {
+			t.Errorf("base of %s = %q, want %q", branch, got, want)
+		}
+	}
+
+	noCfg, _ := BaseResolver(context.Background(), metaRunner(commonDir), nil, "main")
+	if got := noCfg(branchChoreLogout); got != "main" {
+		t.Errorf("base without config = %q, want main", got)
+	}
+}
+
+func TestBaseResolverUnreadableMeta(t *testing.T) {
+	commonDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(commonDir, "rimba"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(commonDir, "rimba", "meta.json"), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{DefaultSource: "develop"}
+
+	baseOf, err := BaseResolver(context.Background(), metaRunner(commonDir), cfg, "main")
+	if err == nil || !strings.Contains(err.Error(), "cannot read the bases recorded") {
+		t.Fatalf("err = %v, want an unreadable metadata error", err)
+	}
+	if got := baseOf(branchFeatureAuth); got != "develop" {
+		t.Errorf("base = %q, want the configured develop", got)
+	}
+}
+
+func TestBaseMergeRefs(t *testing.T) {
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if strings.HasPrefix(args[len(args)-1], "origin/release/2.3") {
+				return "sha", nil
+			}
+			return "", errors.New("unknown revision")
+		},
+		runInDir: noopRunInDir,
+	}
+	baseOf := func(branch string) string {
+		switch branch {
+		case branchFeatureAuth:
+			return branchRelease23
+		case branchBugfixLogin:
+			return "release/2.2"
+		}
+		return "main"
+	}
+
+	remote := BaseMergeRefs(context.Background(), r, "main", "origin/main", baseOf)
+	if got := remote(branchChoreLogout); got != "" {
+		t.Errorf("main-based ref = %q, want empty", got)
+	}
+	if got := remote(branchFeatureAuth); got != "origin/"+branchRelease23 {
+		t.Errorf("fetched base ref = %q, want origin/%s", got, branchRelease23)
+	}
+	if got := remote(branchBugfixLogin); got != "release/2.2" {
+		t.Errorf("unfetched base ref = %q, want local release/2.2", got)
+	}
+
+	local := BaseMergeRefs(context.Background(), r, "main", "main", baseOf)
+	if got := local(branchFeatureAuth); got != branchRelease23 {
+		t.Errorf("local base ref = %q, want %s", got, branchRelease23)
+	}
+}
+
+func TestRebaseWorktreeRecordsBase(t *testing.T) {
+	commonDir := t.TempDir()
+	var calls []string
+	r := rebaseRunner(commonDir, false, nil, &calls)
+
+	res, err := RebaseWorktree(context.Background(), r, rebaseParams(false))
+	if err != nil {
+		t.Fatalf("RebaseWorktree: %v", err)
+	}
+	if !slices.Contains(calls, "rebase --onto="+branchRelease23+" -- fork123") {
+		t.Errorf("calls = %v, want rebase --onto from the fork point", calls)
+	}
+	if res.From != "main" || res.Onto != branchRelease23 {
+		t.Errorf("result = %+v", res)
+	}
+	s, _ := meta.Load(commonDir)
+	if got := s.Get(branchFeatureAuth).Base; got != branchRelease23 {
+		t.Errorf("recorded base = %q, want %s", got, branchRelease23)
+	}
+}
+
+func TestRebaseWorktreeOntoConfigBaseClears(t *testing.T) {
+	commonDir := t.TempDir()
+	seedMeta(t, commonDir, map[string]meta.Meta{branchFeatureAuth: {Base: branchRelease23, Owner: "dana"}})
+	var calls []string
+	p := rebaseParams(false)
+	p.From, p.Onto = branchRelease23, "main"
+
+	if _, err := RebaseWorktree(context.Background(), rebaseRunner(commonDir, false, nil, &calls), p); err != nil {
+		t.Fatalf("RebaseWorktree: %v", err)
+	}
+	s, _ := meta.Load(commonDir)
+	if got := s.Get(branchFeatureAuth); got.Base != "" || got.Owner != "dana" {
+		t.Errorf("meta = %+v, want base cleared and owner kept", got)
+	}
+}
+
+func TestRebaseWorktreeDryRun(t *testing.T) {
+	commonDir := t.TempDir()
+	var calls []string
+	res, err := RebaseWorktree(context.Background(), rebaseRunner(commonDir, false, nil, &calls), rebaseParams(true))
+	if err != nil {
+		t.Fatalf("RebaseWorktree: %v", err)
+	}
+	if len(res.Plan.Steps) != 2 {
+		t.Errorf("steps = %v, want rebase and record", res.Plan.Steps)
+	}
+	for _, c := range calls {
+		if strings.HasPrefix(c, "rebase") {
+			t.Errorf("dry run rebased: %v", calls)
+		}
+	}
+	if s, _ := meta.Load(commonDir); s.Get(branchFeatureAuth).Base != "" {
+		t.Error("dry run recorded a base")
+	}
+}
+
+func TestRebaseWorktreeRefusals(t *testing.T) {
+	tests := []struct {
+		name  string
+		dirty bool
+		onto  string
+		want  string
+	}{
+		{"dirty", true, branchRelease23, "uncommitted changes"},
+		{"unknown onto", false, "missing/branch", "unknown ref"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			p := rebaseParams(false)
+			p.Onto = tt.onto
+			_, err := RebaseWorktree(context.Background(), rebaseRunner(t.TempDir(), tt.dirty, nil, &calls), p)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRebaseWorktreeStackedRefused(t *testing.T) {
+	commonDir := t.TempDir()
+	var calls []string
+	r := rebaseRunner(commonDir, false, nil, &calls)
+	if err := LinkStackParent(context.Background(), r, branchFeatureAuth, branchBugfixLogin); err != nil {
+		t.Fatalf("LinkStackParent: %v", err)
+	}
+
+	_, err := RebaseWorktree(context.Background(), r, rebaseParams(false))
+	if err == nil || !strings.Contains(err.Error(), "rimba sync --stack") {
+		t.Errorf("err = %v, want restack hint", err)
+	}
+}
+
+func TestRebaseWorktreeConflictAborts(t *testing.T) {
+	commonDir := t.TempDir()
+	var calls []string
+	r := rebaseRunner(commonDir, false, errors.New("conflict"), &calls)
+
+	_, err := RebaseWorktree(context.Background(), r, rebaseParams(false))
+	if err == nil || !strings.Contains(err.Error(), "git rebase --onto "+branchRelease23+" fork123") {
+		t.Fatalf("err = %v, want manual rebase hint", err)
+	}
+	if !slices.Contains(calls, "rebase --abort") {
+		t.Errorf("calls = %v, want rebase --abort", calls)
+	}
+	if s, _ := meta.Load(commonDir); s.Get(branchFeatureAuth).Base != "" {
+		t.Error("failed rebase recorded a base")
+	}
+}
+
+func TestRebaseWorktreeUndoneWhenBaseNotRecorded(t *testing.T) {
+	commonDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(commonDir, "rimba"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(commonDir, "rimba", "meta.json"), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	r := rebaseRunner(commonDir, false, nil, &calls)
+
+	_, err := RebaseWorktree(context.Background(), r, rebaseParams(false))
+	if err == nil || !strings.Contains(err.Error(), "not recorded") {
+		t.Fatalf("err = %v, want a base not recorded error", err)
+	}
+	if !slices.Contains(calls, "reset --hard sha") {
+		t.Errorf("calls = %v, want the rebase undone with reset --hard to the old tip", calls)
+	}
+}
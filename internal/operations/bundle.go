@@ -0,0 +1,177 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/bundle"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/fileutil"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
+)
+
+// ExportBundleParams holds the inputs for exporting an archived task.
+type ExportBundleParams struct {
+	Dst        string
+	Task       string
+	Service    string
+	Type       string
+	Branch     string
+	MainBranch string
+	// CopyFiles are the copy_files entries to carry; nil leaves them out.
+	CopyFiles []string
+}
+
+// ExportBundle writes Branch's commits since its merge-base with MainBranch,
+// the uncommitted changes and copy_files entries saved when it was archived,
+// and its metadata into one portable file at Dst. Run it after
+// ArchiveWorktree so the archive snapshot exists.
+func ExportBundle(ctx context.Context, r git.Runner, p ExportBundleParams) (bundle.Manifest, error) {
+	m := bundle.Manifest{
+		Task:      p.Task,
+		Service:   p.Service,
+		Type:      p.Type,
+		Branch:    p.Branch,
+		Changes:   archiveSnapshotSHA(ctx, r, p.Branch),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	var err error
+	if m.Head, err = git.ResolveRef(ctx, r, "refs/heads/"+p.Branch); err != nil {
+		return m, fmt.Errorf("resolve %s: %w", p.Branch, err)
+	}
+	if m.Base, err = git.MergeBase(ctx, r, p.MainBranch, p.Branch); err != nil {
+		return m, fmt.Errorf("find merge-base of %s and %s: %w", p.Branch, p.MainBranch, err)
+	}
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return m, err
+	}
+	if s, err := meta.Load(commonDir); err == nil {
+		m.Meta = s.Get(p.Branch)
+	}
+
+	tmp, err := os.MkdirTemp("", "rimba-bundle-*")
+	if err != nil {
+		return m, err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	var c bundle.Contents
+	if revs := bundleRevs(m); len(revs) > 0 {
+		c.GitBundle = filepath.Join(tmp, "repo.bundle")
+		if err := git.CreateBundle(ctx, r, c.GitBundle, revs...); err != nil {
+			return m, err
+		}
+	}
+	filesDir := archiveFilesDir(commonDir, p.Branch)
+	if m.Files = existingEntries(filesDir, p.CopyFiles); len(m.Files) > 0 {
+		c.FilesDir = filesDir
+	}
+
+	if err := bundle.Write(p.Dst, m, c); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// ImportBundle unpacks the bundle at src into this clone as an archived
+// branch: the branch ref, its archive snapshot, and its metadata. Restore
+// then recreates the worktree as it would for a local archive.
+func ImportBundle(ctx context.Context, r git.Runner, src string) (bundle.Manifest, error) {
+	tmp, err := os.MkdirTemp("", "rimba-bundle-*")
+	if err != nil {
+		return bundle.Manifest{}, err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	m, c, err := bundle.Read(src, tmp)
+	if err != nil {
+		return m, err
+	}
+	if git.BranchExists(ctx, r, m.Branch) {
+		return m, errhint.WithFix(
+			fmt.Errorf("branch %q already exists in this clone", m.Branch),
+			fmt.Sprintf("restore it with: rimba restore %s  OR  delete it first: git branch -D %s", m.Task, m.Branch),
+		)
+	}
+
+	if err := unbundleCommits(ctx, r, m, c); err != nil {
+		return m, err
+	}
+	if err := git.UpdateRef(ctx, r, "refs/heads/"+m.Branch, m.Head); err != nil {
+		return m, err
+	}
+	if m.Changes != "" {
+		if err := git.UpdateRef(ctx, r, ArchiveRef(m.Branch), m.Changes); err != nil {
+			return m, err
+		}
+	}
+	if err := importArchiveFiles(ctx, r, m, c); err != nil {
+		return m, err
+	}
+	if !m.Meta.IsZero() {
+		_, _ = UpdateMeta(ctx, r, m.Branch, func(mm *meta.Meta) { *mm = m.Meta }) // best-effort
+	}
+	return m, nil
+}
+
+// bundleRevs lists what the git bundle must carry: the branch's own commits
+// and the archived changes, cut at the merge-base. Nil means there is
+// nothing the importing clone does not already have.
+func bundleRevs(m bundle.Manifest) []string {
+	var revs []string
+	if m.Head != m.Base {
+		revs = append(revs, "refs/heads/"+m.Branch)
+	}
+	if m.Changes != "" {
+		revs = append(revs, ArchiveRef(m.Branch))
+	}
+	if len(revs) == 0 {
+		return nil
+	}
+	return append(revs, "^"+m.Base)
+}
+
+// unbundleCommits stores the bundle's objects and checks the branch tip is
+// now reachable, failing with a fetch hint when the clone lacks the base.
+func unbundleCommits(ctx context.Context, r git.Runner, m bundle.Manifest, c bundle.Contents) error {
+	missingBase := func(err error) error {
+		return errhint.WithFix(
+			fmt.Errorf("this clone lacks the commits %s was built on: %w", m.Branch, err),
+			fmt.Sprintf("fetch them first (git fetch origin), then retry; the bundle needs commit %s", m.Base),
+		)
+	}
+	if c.GitBundle != "" {
+		if err := git.Unbundle(ctx, r, c.GitBundle); err != nil {
+			return missingBase(err)
+		}
+	}
+	if _, err := git.ResolveRef(ctx, r, m.Head); err != nil {
+		return missingBase(err)
+	}
+	return nil
+}
+
+// importArchiveFiles places the bundled copy_files entries where
+// RestoreArchiveSnapshot looks for them.
+func importArchiveFiles(ctx context.Context, r git.Runner, m bundle.Manifest, c bundle.Contents) error {
+	if c.FilesDir == "" || len(m.Files) == 0 {
+		return nil
+	}
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return err
+	}
+	dir := archiveFilesDir(commonDir, m.Branch)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clear archive snapshot: %w", err)
+	}
+	if _, _, err := fileutil.CopyEntries(c.FilesDir, dir, m.Files); err != nil {
+		return fmt.Errorf("import bundled files: %w", err)
+	}
+	return nil
+}
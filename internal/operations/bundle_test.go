@@ -0,0 +1,150 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/bundle"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+// exportParked archives the parked worktree in src with one extra commit and
+// exports it, returning the bundle path.
+func exportParked(t *testing.T, src, wtPath string) string {
+	t.Helper()
+	ctx := context.Background()
+	r := &git.ExecRunner{Dir: src}
+
+	testutil.CreateFile(t, wtPath, "committed.txt", "committed")
+	testutil.GitCmd(t, wtPath, "add", "committed.txt")
+	testutil.GitCmd(t, wtPath, "commit", "-m", "work", "--", "committed.txt")
+	if _, err := UpdateMeta(ctx, r, branchParked, func(m *meta.Meta) { m.Note = "half done" }); err != nil {
+		t.Fatalf("UpdateMeta: %v", err)
+	}
+
+	copyFiles := []string{envFile}
+	if _, err := ArchiveWorktree(ctx, r, ArchiveParams{Path: wtPath, Branch: branchParked, CopyFiles: copyFiles}); err != nil {
+		t.Fatalf("ArchiveWorktree: %v", err)
+	}
+	dst := filepath.Join(t.TempDir(), "parked.rimba")
+	m, err := ExportBundle(ctx, r, ExportBundleParams{
+		Dst:        dst,
+		Task:       "parked",
+		Type:       "feature",
+		Branch:     branchParked,
+		MainBranch: "main",
+		CopyFiles:  copyFiles,
+	})
+	if err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+	if m.Changes == "" || !slices.Equal(m.Files, copyFiles) || m.Head == m.Base {
+		t.Fatalf("manifest = %+v, want changes, .env, and own commits", m)
+	}
+	return dst
+}
+
+func TestExportImportBundleRoundTrip(t *testing.T) {
+	src, wtPath := parkedWorktree(t)
+	clone := filepath.Join(t.TempDir(), "clone")
+	testutil.GitCmd(t, t.TempDir(), "clone", "--quiet", src, clone)
+	testutil.GitCmd(t, clone, "config", "user.email", "test@test.com")
+	testutil.GitCmd(t, clone, "config", "user.name", "Test")
+	path := exportParked(t, src, wtPath)
+
+	ctx := context.Background()
+	r := &git.ExecRunner{Dir: clone}
+	m, err := ImportBundle(ctx, r, path)
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if m.Task != "parked" || m.Branch != branchParked {
+		t.Errorf("manifest = %+v", m)
+	}
+	if s, _ := LoadMeta(ctx, r); s.Get(branchParked).Note != "half done" {
+		t.Error("metadata should travel with the bundle")
+	}
+
+	cloneWt := filepath.Join(t.TempDir(), "parked")
+	testutil.GitCmd(t, clone, "worktree", "add", cloneWt, branchParked)
+	snap, err := RestoreArchiveSnapshot(ctx, r, branchParked, cloneWt, []string{envFile})
+	if err != nil {
+		t.Fatalf("RestoreArchiveSnapshot: %v", err)
+	}
+	if !snap.Stashed || !slices.Equal(snap.Files, []string{envFile}) {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	status := testutil.GitCmd(t, cloneWt, "status", "--porcelain")
+	for _, want := range []string{"A  staged.txt", "?? scratch.txt"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("status missing %q:\n%s", want, status)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cloneWt, "committed.txt")); err != nil {
+		t.Errorf("committed file missing: %v", err)
+	}
+	if env, _ := os.ReadFile(filepath.Join(cloneWt, envFile)); string(env) != "TOKEN=worktree" {
+		t.Errorf(".env = %q", env)
+	}
+}
+
+func TestImportBundleRefusesExistingBranch(t *testing.T) {
+	src, wtPath := parkedWorktree(t)
+	path := exportParked(t, src, wtPath)
+
+	_, err := ImportBundle(context.Background(), &git.ExecRunner{Dir: src}, path)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("err = %v, want existing-branch error", err)
+	}
+}
+
+func TestImportBundleMissingBase(t *testing.T) {
+	src, wtPath := parkedWorktree(t)
+	path := exportParked(t, src, wtPath)
+
+	// An unrelated history. (testutil.NewTestRepo would recreate src's root
+	// commit byte for byte when both run within the same second.)
+	other := t.TempDir()
+	testutil.GitCmd(t, other, "init", "--quiet", "-b", "main")
+	testutil.CreateFile(t, other, "unrelated.txt", "unrelated")
+	testutil.GitCmd(t, other, "add", ".")
+	testutil.GitCmd(t, other, "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "--quiet", "-m", "unrelated root")
+
+	_, err := ImportBundle(context.Background(), &git.ExecRunner{Dir: other}, path)
+	if err == nil || !strings.Contains(err.Error(), "lacks the commits") {
+		t.Fatalf("err = %v, want missing-base error", err)
+	}
+}
+
+func TestBundleRevs(t *testing.T) {
+	tests := []struct {
+		name string
+		m    bundle.Manifest
+		want []string
+	}{
+		{name: "nothing new", m: bundle.Manifest{Branch: branchParked, Head: "a", Base: "a"}},
+		{
+			name: "commits only",
+			m:    bundle.Manifest{Branch: branchParked, Head: "b", Base: "a"},
+			want: []string{"refs/heads/" + branchParked, "^a"},
+		},
+		{
+			name: "changes only",
+			m:    bundle.Manifest{Branch: branchParked, Head: "a", Base: "a", Changes: "s"},
+			want: []string{ArchiveRef(branchParked), "^a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bundleRevs(tt.m); !slices.Equal(got, tt.want) {
+				t.Errorf("bundleRevs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ type CleanCandidate struct {
 	Path     string
 	Branch   string
 	Prunable bool
+	Merged   bool // the branch's commits have landed; stacked children restack onto main
 }
 
 // StaleCandidate extends CleanCandidate with the last commit time.
@@ -51,7 +52,15 @@ type StaleResult struct {
 // FindMergedCandidates returns worktrees whose branches are merged into mergeRef.
 // It checks both regular merges and squash-merges.
 func FindMergedCandidates(ctx context.Context, r git.Runner, mergeRef, mainBranch string) (MergedResult, error) {
-	mergedList, err := git.MergedBranches(ctx, r, mergeRef)
+	return FindMergedCandidatesFor(ctx, r, mergeRef, mainBranch, nil)
+}
+
+// FindMergedCandidatesFor is FindMergedCandidates, but checks each branch
+// against refOf(branch) when that is non-empty — the base a release-line
+// worktree tracks — instead of mergeRef. A base that cannot be read skips
+// its branches with a warning.
+func FindMergedCandidatesFor(ctx context.Context, r git.Runner, mergeRef, mainBranch string, refOf func(branch string) string) (MergedResult, error) {
+	main, err := loadMergeTarget(ctx, r, mergeRef)
 	if err != nil {
 		return MergedResult{}, errhint.WithFix(
 			fmt.Errorf("failed to list merged branches: %w", err),
@@ -59,32 +68,24 @@ func FindMergedCandidates(ctx context.Context, r git.Runner, mergeRef, mainBranc
 		)
 	}
 
-	mergedSet := make(map[string]bool, len(mergedList))
-	for _, b := range mergedList {
-		mergedSet[b] = true
-	}
-
 	entries, err := git.ListWorktrees(ctx, r)
 	if err != nil {
 		return MergedResult{}, err
 	}
 
-	var mainline mainlineLookup
-	if len(mergedList) > 0 {
-		mainline.shas, mainline.err = git.FirstParentChainSHAs(ctx, r, mergeRef)
-	}
-
-	mainlinePIDsByBase := make(map[string]map[string]bool)
-
+	targets := map[string]*mergeTarget{mergeRef: main}
 	var result MergedResult
 	for _, e := range git.FilterEntries(entries, mainBranch) {
-		candidate, warning := classifyMergedEntry(ctx, r, mergeRef, e, mergedSet[e.Branch], mainline, mainlinePIDsByBase)
+		target, warning := targetFor(ctx, r, targets, mergeRef, refOf, e.Branch)
+		if warning == "" {
+			var candidate *CleanCandidate
+			candidate, warning = classifyMergedEntry(ctx, r, target.ref, e, target.merged[e.Branch], target.mainline, target.pids)
+			if candidate != nil {
+				result.Candidates = append(result.Candidates, *candidate)
+			}
+		}
 		if warning != "" {
 			result.Warnings = append(result.Warnings, warning)
-			continue
-		}
-		if candidate != nil {
-			result.Candidates = append(result.Candidates, *candidate)
 		}
 	}
 	return result, nil
@@ -129,11 +130,17 @@ func RemoveCandidates(ctx context.Context, r git.Runner, candidates []CleanCandi
 		paths[i] = c.Path
 	}
 	defer deferSweepManifest(ctx, r, paths)()
+	rec := newRecorder(ctx, r, "clean")
+	defer rec.commit()
 
 	items := make([]CleanedItem, 0, len(candidates))
 	for _, c := range candidates {
 		progress.Notifyf(onProgress, "Removing %s...", c.Branch)
+		step := rec.captureRemoval(ctx, r, c.Path, c.Branch, true)
 		wtRemoved, brDeleted, leftOnDisk, err := removeAndCleanup(ctx, r, c.Path, c.Branch, force, c.Prunable)
+		if wtRemoved {
+			rec.removed(step, brDeleted)
+		}
 		item := CleanedItem{
 			Branch:          c.Branch,
 			Path:            c.Path,
@@ -142,6 +149,10 @@ func RemoveCandidates(ctx context.Context, r git.Runner, candidates []CleanCandi
 			BranchDeleted:   brDeleted,
 			Error:           err,
 		}
+		if brDeleted {
+			unlinkStacked(ctx, r, c.Branch, c.Merged)
+			forgetMeta(ctx, r, c.Branch)
+		}
 		if originPresent && wtRemoved {
 			deleteRemoteForItem(ctx, r, c.Branch, &item)
 		}
@@ -157,6 +168,55 @@ type mainlineLookup struct {
 	err  error
 }
 
+// mergeTarget is one ref merged branches are detected against, with the
+// lookups FindMergedCandidatesFor shares across the branches that use it.
+type mergeTarget struct {
+	ref      string
+	merged   map[string]bool
+	mainline mainlineLookup
+	pids     map[string]map[string]bool // mainline patch IDs by merge-base
+}
+
+// loadMergeTarget lists the branches merged into ref and its first-parent chain.
+func loadMergeTarget(ctx context.Context, r git.Runner, ref string) (*mergeTarget, error) {
+	mergedList, err := git.MergedBranches(ctx, r, ref)
+	if err != nil {
+		return nil, err
+	}
+	t := &mergeTarget{
+		ref:    ref,
+		merged: make(map[string]bool, len(mergedList)),
+		pids:   make(map[string]map[string]bool),
+	}
+	for _, b := range mergedList {
+		t.merged[b] = true
+	}
+	if len(mergedList) > 0 {
+		t.mainline.shas, t.mainline.err = git.FirstParentChainSHAs(ctx, r, ref)
+	}
+	return t, nil
+}
+
+// targetFor returns the merge target for branch, loading and caching its
+// base's on first use; a base that fails to load yields a skip warning.
+func targetFor(ctx context.Context, r git.Runner, targets map[string]*mergeTarget, mergeRef string, refOf func(string) string, branch string) (*mergeTarget, string) {
+	ref := mergeRef
+	if refOf != nil {
+		if alt := refOf(branch); alt != "" {
+			ref = alt
+		}
+	}
+	if t, ok := targets[ref]; ok {
+		return t, ""
+	}
+	t, err := loadMergeTarget(ctx, r, ref)
+	if err != nil {
+		return nil, fmt.Sprintf("skipped %s: base %s: %v", branch, ref, err)
+	}
+	targets[ref] = t
+	return t, ""
+}
+
 // classifyMergedEntry decides whether a worktree entry should be removed:
 // the first-parent guard when isMergedHit, else squash-merge detection.
 func classifyMergedEntry(ctx context.Context, r git.Runner, mergeRef string, e git.WorktreeEntry, isMergedHit bool, mainline mainlineLookup, mainlinePIDsByBase map[string]map[string]bool) (*CleanCandidate, string) {
@@ -167,7 +227,7 @@ func classifyMergedEntry(ctx context.Context, r git.Runner, mergeRef string, e g
 		if git.IsSHAOnChain(e.HEAD, mainline.shas) {
 			return nil, ""
 		}
-		return &CleanCandidate{Path: e.Path, Branch: e.Branch, Prunable: e.Prunable}, ""
+		return &CleanCandidate{Path: e.Path, Branch: e.Branch, Prunable: e.Prunable, Merged: true}, ""
 	}
 
 	squashed, err := squashMergedCached(ctx, r, mergeRef, e.Branch, mainlinePIDsByBase)
@@ -177,11 +237,11 @@ func classifyMergedEntry(ctx context.Context, r git.Runner, mergeRef string, e g
 	if !squashed {
 		return nil, ""
 	}
-	return &CleanCandidate{Path: e.Path, Branch: e.Branch, Prunable: e.Prunable}, ""
+	return &CleanCandidate{Path: e.Path, Branch: e.Branch, Prunable: e.Prunable, Merged: true}, ""
 }
 
 // squashMergedCached is git.IsSquashMerged, but reuses the mainline patch-ID set
-// across candidates sharing a merge-base; cache is scoped to one FindMergedCandidates call.
+// across candidates sharing a merge-base; cache is scoped to one merge target.
 func squashMergedCached(ctx context.Context, r git.Runner, mergeRef, branch string, cache map[string]map[string]bool) (bool, error) {
 	mergeBase, err := git.MergeBase(ctx, r, mergeRef, branch)
 	if err != nil {
@@ -422,6 +422,65 @@ func TestFindMergedCandidatesGitError(t *testing.T) {
 	}
 }
 
+func TestFindMergedCandidatesForUsesBranchBase(t *testing.T) {
+	wt := porcelainEntries(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/wt/done", "feature/done"},
+		struct{ path, branch string }{"/wt/backport", "feature/backport"},
+		struct{ path, branch string }{"/wt/orphan", "feature/orphan"},
+	)
+
+	var mergedRefs []string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case args[0] == gitCmdBranch:
+				mergedRefs = append(mergedRefs, args[1])
+				switch args[1] {
+				case "--merged=origin/main":
+					return "  feature/done\n", nil
+				case "--merged=release/2.3":
+					return "  feature/backport\n", nil
+				}
+				return "", errors.New("unknown ref")
+			case args[0] == gitCmdWorktree:
+				return wt, nil
+			case args[0] == gitCmdRevList:
+				return "otherSha", nil
+			}
+			return "", nil
+		},
+		runInDir: noopRunInDir,
+	}
+	refOf := func(branch string) string {
+		switch branch {
+		case "feature/backport":
+			return "release/2.3"
+		case "feature/orphan":
+			return "release/gone"
+		}
+		return ""
+	}
+
+	result, err := FindMergedCandidatesFor(context.Background(), r, "origin/main", "main", refOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range result.Candidates {
+		got = append(got, c.Branch)
+	}
+	if want := []string{"feature/done", "feature/backport"}; !slices.Equal(got, want) {
+		t.Errorf("candidates = %v, want %v", got, want)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "release/gone") {
+		t.Errorf("warnings = %v, want one for release/gone", result.Warnings)
+	}
+	if want := []string{"--merged=origin/main", "--merged=release/2.3", "--merged=release/gone"}; !slices.Equal(mergedRefs, want) {
+		t.Errorf("merged lookups = %v, want each ref once: %v", mergedRefs, want)
+	}
+}
+
 func TestFindStaleCandidatesFound(t *testing.T) {
 	wt := porcelainEntries(
 		struct{ path, branch string }{"/repo", "main"},
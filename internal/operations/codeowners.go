@@ -0,0 +1,28 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/git"
+)
+
+// LoadCodeOwners parses the main worktree's CODEOWNERS file, looked up
+// where GitHub does (conflict.CodeOwnersPaths). Ownership only annotates a
+// report, so when there is none, or the repository root cannot be found,
+// it returns empty CodeOwners, which own nothing.
+func LoadCodeOwners(ctx context.Context, r git.Runner) conflict.CodeOwners {
+	root, err := git.MainRepoRoot(ctx, r)
+	if err != nil {
+		return conflict.CodeOwners{}
+	}
+	for _, rel := range conflict.CodeOwnersPaths {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err == nil {
+			return conflict.ParseCodeOwners(string(data))
+		}
+	}
+	return conflict.CodeOwners{}
+}
@@ -0,0 +1,54 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadCodeOwnersPrefersGitHubDir(t *testing.T) {
+	root := t.TempDir()
+	writeCodeOwners(t, root, "CODEOWNERS", "* @root\n")
+	writeCodeOwners(t, root, filepath.Join(".github", "CODEOWNERS"), "* @github\n")
+	writeCodeOwners(t, root, filepath.Join("docs", "CODEOWNERS"), "* @docs\n")
+	sr := &stackRunner{commonDir: filepath.Join(root, ".git")}
+
+	owners := LoadCodeOwners(context.Background(), sr.runner())
+
+	if got := owners.Owners("main.go"); !reflect.DeepEqual(got, []string{"@github"}) {
+		t.Errorf("owners = %v, want [@github]", got)
+	}
+}
+
+func TestLoadCodeOwnersFallsBackToDocs(t *testing.T) {
+	root := t.TempDir()
+	writeCodeOwners(t, root, filepath.Join("docs", "CODEOWNERS"), "* @docs\n")
+	sr := &stackRunner{commonDir: filepath.Join(root, ".git")}
+
+	owners := LoadCodeOwners(context.Background(), sr.runner())
+
+	if got := owners.Owners("main.go"); !reflect.DeepEqual(got, []string{"@docs"}) {
+		t.Errorf("owners = %v, want [@docs]", got)
+	}
+}
+
+func TestLoadCodeOwnersMissing(t *testing.T) {
+	sr := &stackRunner{commonDir: filepath.Join(t.TempDir(), ".git")}
+
+	if owners := LoadCodeOwners(context.Background(), sr.runner()); !owners.Empty() {
+		t.Error("owners not empty without a CODEOWNERS file")
+	}
+}
+
+func writeCodeOwners(t *testing.T, root, rel, data string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,19 @@
+package operations
+
+import (
+	"context"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/git"
+)
+
+// LoadConflictCache loads the conflict analysis cache from the repository's
+// common dir. The cache only saves work, so when the common dir cannot be
+// found it returns nil, which caches nothing.
+func LoadConflictCache(ctx context.Context, r git.Runner) *conflict.Cache {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return nil
+	}
+	return conflict.LoadCache(commonDir)
+}
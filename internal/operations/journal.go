@@ -0,0 +1,162 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/fileutil"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/journal"
+	"github.com/lugassawan/rimba/internal/meta"
+)
+
+// recorder collects one operation's destructive steps for the undo journal.
+// Journaling is best-effort: a nil recorder (no usable state dir) records
+// nothing, and a failure to write the journal never fails the operation.
+type recorder struct {
+	r         git.Runner
+	commonDir string
+	copyFiles []string
+	entry     journal.Entry
+	pinned    []string // refs captureRemoval pinned branch tips under
+}
+
+// newRecorder starts journaling op. copy_files come from the config on ctx,
+// so the entries a removal would lose are saved alongside.
+func newRecorder(ctx context.Context, r git.Runner, op string) *recorder {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return nil
+	}
+	rec := &recorder{r: r, commonDir: commonDir, entry: journal.NewEntry(op)}
+	if cfg := config.FromContext(ctx); cfg != nil {
+		rec.copyFiles = cfg.CopyFiles
+	}
+	return rec
+}
+
+// captureRemoval snapshots what removing the worktree at path would lose:
+// the branch tip (when the branch is to be deleted), pinned under
+// journal.Ref so gc cannot collect it, its metadata and stack links, and its
+// copy_files entries. Call it before removing; pass the step to removed once
+// the removal has happened.
+func (rec *recorder) captureRemoval(ctx context.Context, r git.Runner, path, branch string, deleteBranch bool) journal.Step {
+	step := journal.Step{
+		Action: journal.ActionRemove,
+		Desc:   "remove worktree: " + path,
+		Branch: branch,
+		Path:   path,
+	}
+	if rec == nil {
+		return step
+	}
+	if deleteBranch {
+		if sha, err := git.ResolveRef(ctx, r, "refs/heads/"+branch); err == nil {
+			step.SHA = sha
+			ref := journal.Ref(rec.entry.ID, branch)
+			if git.UpdateRef(ctx, r, ref, sha) == nil {
+				rec.pinned = append(rec.pinned, ref)
+			}
+		}
+		if s, err := meta.Load(rec.commonDir); err == nil {
+			step.Meta = s.Get(branch)
+		}
+		step.Stack = stackLinks(rec.commonDir, branch)
+	}
+	if files := existingEntries(path, rec.copyFiles); len(files) > 0 {
+		dir := journal.FilesDir(rec.commonDir, rec.entry.ID, branch)
+		if copied, _, err := fileutil.CopyEntries(path, dir, files); err == nil {
+			step.Files = copied
+		}
+	}
+	return step
+}
+
+// removed records a captured removal. A branch that survived keeps no SHA,
+// so undo does not try to recreate it.
+func (rec *recorder) removed(step journal.Step, branchDeleted bool) {
+	if rec == nil {
+		return
+	}
+	if !branchDeleted {
+		step.SHA = ""
+		step.Meta = meta.Meta{}
+		step.Stack = nil
+	}
+	rec.entry.Steps = append(rec.entry.Steps, step)
+}
+
+// renamed records a completed rename.
+func (rec *recorder) renamed(res RenameResult) {
+	if rec == nil {
+		return
+	}
+	rec.entry.Steps = append(rec.entry.Steps, journal.Step{
+		Action:    journal.ActionRename,
+		Desc:      fmt.Sprintf("rename %s → %s", res.OldBranch, res.NewBranch),
+		Branch:    res.OldBranch,
+		Path:      res.OldPath,
+		NewBranch: res.NewBranch,
+		NewPath:   res.NewPath,
+	})
+}
+
+// commit appends the recorded entry to the journal, or discards the files it
+// saved when no step happened. Tips pinned for branches that survived are
+// unpinned, and so is everything kept for the entries the journal drops.
+func (rec *recorder) commit() {
+	if rec == nil {
+		return
+	}
+	rec.unpinUnused()
+	if len(rec.entry.Steps) == 0 {
+		_ = os.RemoveAll(journal.EntryDir(rec.commonDir, rec.entry.ID))
+		return
+	}
+	j, err := journal.Load(rec.commonDir)
+	if err != nil {
+		discardEntry(rec.r, rec.commonDir, rec.entry)
+		return
+	}
+	dropped := j.Append(rec.entry)
+	if err := j.Save(rec.commonDir); err != nil {
+		discardEntry(rec.r, rec.commonDir, rec.entry)
+		return
+	}
+	for _, e := range dropped {
+		discardEntry(rec.r, rec.commonDir, e)
+	}
+}
+
+// unpinUnused deletes the refs captureRemoval pinned for branches no
+// recorded step deleted.
+func (rec *recorder) unpinUnused() {
+	for _, ref := range rec.pinned {
+		used := slices.ContainsFunc(rec.entry.Steps, func(s journal.Step) bool {
+			return s.SHA != "" && journal.Ref(rec.entry.ID, s.Branch) == ref
+		})
+		if !used {
+			_ = git.DeleteRef(context.Background(), rec.r, ref)
+		}
+	}
+}
+
+// discardEntry removes what e kept outside the journal: its saved files and
+// pinned branch tips.
+func discardEntry(r git.Runner, commonDir string, e journal.Entry) {
+	for _, s := range e.Steps {
+		unpinStep(r, e.ID, s)
+	}
+	_ = os.RemoveAll(journal.EntryDir(commonDir, e.ID))
+}
+
+// unpinStep deletes the ref pinning the branch tip step s of entry id
+// recorded, if any.
+func unpinStep(r git.Runner, id string, s journal.Step) {
+	if s.SHA != "" {
+		_ = git.DeleteRef(context.Background(), r, journal.Ref(id, s.Branch))
+	}
+}
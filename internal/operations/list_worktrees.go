@@ -9,6 +9,7 @@ import (
 	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/gh"
 	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/parallel"
 	"github.com/lugassawan/rimba/internal/resolver"
 )
@@ -29,6 +30,7 @@ type ListWorktreesRequest struct {
 	Service     string // monorepo service filter
 	CurrentPath string // marks matching worktree as IsCurrent; "" to skip
 	WorktreeDir string // absolute; used to shorten display paths
+	Meta        meta.Filter
 }
 
 // PRInfo is the per-branch PR/CI summary.
@@ -49,7 +51,7 @@ type ListWorktreesResult struct {
 }
 
 // ListWorktrees is the shared pipeline for `rimba list` and the MCP list tool:
-// candidate filter → optional gh auth check → parallel status (+PR) collection
+// candidate filter → metadata filter → optional gh auth check → parallel status (+PR) collection
 // → dirty/behind filter → service filter → sort.
 //
 // Pass ghR=nil to skip PR/CI lookup. When req.Full is true and auth fails, the
@@ -68,6 +70,16 @@ func ListWorktrees(
 	prefixes := config.PrefixSetFromContext(ctx).Strip()
 	candidates := buildListCandidates(entries, req.WorktreeDir, req.CurrentPath, req.TypeFilter, prefixes)
 
+	// Metadata is display-only unless filtering on it, so an unreadable
+	// store degrades to blank columns instead of failing the listing.
+	metaStore, err := LoadMeta(ctx, gitR)
+	if err != nil && !req.Meta.IsEmpty() {
+		return ListWorktreesResult{}, err
+	}
+	if metaStore != nil && !req.Meta.IsEmpty() {
+		candidates = filterCandidatesByMeta(candidates, metaStore, req.Meta)
+	}
+
 	var (
 		prInfos   map[string]PRInfo
 		ghWarning string
@@ -104,9 +116,11 @@ func ListWorktrees(
 		}
 	}
 
+	attachMeta(rows, metaStore)
 	rows = FilterDetailsByStatus(rows, req.Dirty, req.Behind)
 	rows = resolver.FilterByService(rows, req.Service)
 	resolver.SortDetailsByTask(rows)
+	rows = stackDetails(rows, StackParents(ctx, gitR))
 
 	return ListWorktreesResult{Rows: rows, PRInfos: prInfos, GhWarning: ghWarning}, nil
 }
@@ -159,6 +173,16 @@ func buildListCandidates(entries []git.WorktreeEntry, wtDir, currentPath, typeFi
 	return candidates
 }
 
+func filterCandidatesByMeta(candidates []listCandidate, s *meta.Store, f meta.Filter) []listCandidate {
+	var kept []listCandidate
+	for _, c := range candidates {
+		if f.Match(s.Get(c.entry.Branch)) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
 // queryPRInfo runs one gh pr list under a timeout. Errors degrade silently
 // so one slow or broken query does not fail the whole table.
 func queryPRInfo(ctx context.Context, ghR gh.Runner, branch string) PRInfo {
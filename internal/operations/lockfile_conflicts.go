@@ -0,0 +1,113 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/deps"
+	"github.com/lugassawan/rimba/internal/git"
+)
+
+// maxLockfileStops caps how many stops of one rebase LockfileResolver
+// resolves — one per replayed commit that touched a lockfile.
+const maxLockfileStops = 100
+
+// errNotLockfileConflict reports a stop LockfileResolver leaves alone: no
+// rebase or merge is in progress, or a conflict touches more than lockfiles.
+var errNotLockfileConflict = errors.New("not a lockfile-only conflict")
+
+// LockfileResolver resolves rebase and merge stops whose conflicts touch
+// only lockfiles: it takes one side of each, reruns the module's command to
+// regenerate it against the merged manifests, stages it and continues
+// ([deps] resolve_lockfiles).
+type LockfileResolver struct {
+	// Modules are the configured [[deps.modules]]; deps.LockCommand picks
+	// the command that regenerates each lockfile from them.
+	Modules []config.ModuleConfig
+}
+
+// NewLockfileResolver returns the resolver cfg opts into, or nil when
+// lockfile conflicts are left to the user.
+func NewLockfileResolver(cfg *config.Config) *LockfileResolver {
+	if !cfg.ResolveLockfiles() {
+		return nil
+	}
+	return &LockfileResolver{Modules: cfg.Deps.Modules}
+}
+
+// Resolve resolves the rebase or merge stopped in dir, taking side
+// (git.CheckoutOurs or git.CheckoutTheirs) of each conflicted lockfile
+// before regenerating it, and continues it — through every later stop of a
+// rebase that is also lockfile-only. It returns the regenerated lockfiles.
+// An error leaves the rebase or merge stopped for the caller to abort or
+// keep.
+func (l *LockfileResolver) Resolve(ctx context.Context, r git.Runner, dir string, useMerge bool, side string) ([]string, error) {
+	var regenerated []string
+	for range maxLockfileStops {
+		stopped, err := syncInProgress(ctx, r, dir, useMerge)
+		if err != nil {
+			return nil, err
+		}
+		if !stopped {
+			return nil, errNotLockfileConflict
+		}
+		files, err := l.resolveStop(ctx, r, dir, side)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if !slices.Contains(regenerated, f) {
+				regenerated = append(regenerated, f)
+			}
+		}
+
+		contErr := continueSync(ctx, r, dir, useMerge)
+		if contErr == nil {
+			return regenerated, nil
+		}
+		// A rebase stops again on the next conflicting commit; anything
+		// else is a failure to continue.
+		if stopped, _ := syncInProgress(ctx, r, dir, useMerge); !stopped || useMerge {
+			return nil, fmt.Errorf("continue after regenerating lockfiles: %w", contErr)
+		}
+	}
+	return nil, fmt.Errorf("still conflicted after regenerating lockfiles at %d stops", maxLockfileStops)
+}
+
+// resolveStop regenerates the lockfiles conflicted at the current stop in
+// dir and stages them, returning them.
+func (l *LockfileResolver) resolveStop(ctx context.Context, r git.Runner, dir, side string) ([]string, error) {
+	files, err := git.UnmergedFiles(ctx, r, dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errNotLockfileConflict
+	}
+	type regen struct{ file, workDir, command string }
+	regens := make([]regen, 0, len(files))
+	for _, f := range files {
+		command, workDir, ok := deps.LockCommand(f, l.Modules)
+		if !ok {
+			return nil, errNotLockfileConflict
+		}
+		regens = append(regens, regen{f, workDir, command})
+	}
+
+	if err := git.CheckoutSide(ctx, r, dir, side, files); err != nil {
+		return nil, fmt.Errorf("take %s side of %s: %w", strings.TrimPrefix(side, "--"), strings.Join(files, ", "), err)
+	}
+	for _, g := range regens {
+		if err := deps.RegenerateLockfile(ctx, dir, g.file, g.workDir, g.command); err != nil {
+			return nil, err
+		}
+	}
+	if err := git.Add(ctx, r, dir, files); err != nil {
+		return nil, fmt.Errorf("stage %s: %w", strings.Join(files, ", "), err)
+	}
+	return files, nil
+}
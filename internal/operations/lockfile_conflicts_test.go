@@ -0,0 +1,183 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/deps"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+const lockfileGoSum = "go.sum"
+
+// lockfileFake is a worktree whose rebase stops once per entry of stops, on
+// the conflicted files listed there. It records checkouts, adds and aborts.
+type lockfileFake struct {
+	t         *testing.T
+	dir       string // worktree, where lockfiles are regenerated
+	gitDir    string
+	stops     [][]string
+	rebaseErr error
+	checkouts []string
+	added     []string
+	aborted   bool
+}
+
+func newLockfileFake(t *testing.T, stops ...[]string) *lockfileFake {
+	t.Helper()
+	return &lockfileFake{t: t, dir: t.TempDir(), gitDir: t.TempDir(), stops: stops, rebaseErr: errors.New("CONFLICT (content)")}
+}
+
+func (f *lockfileFake) setStopped(stopped bool) {
+	state := filepath.Join(f.gitDir, "rebase-merge")
+	if stopped {
+		if err := os.MkdirAll(state, 0o755); err != nil {
+			f.t.Fatal(err)
+		}
+		return
+	}
+	_ = os.RemoveAll(state)
+}
+
+// next moves the rebase past its current stop, failing while stops remain.
+func (f *lockfileFake) next() error {
+	f.stops = f.stops[1:]
+	if len(f.stops) == 0 {
+		f.setStopped(false)
+		return nil
+	}
+	return f.rebaseErr
+}
+
+func (f *lockfileFake) runner() *mockRunner {
+	return &mockRunner{
+		run: func(_ ...string) (string, error) { return "", nil },
+		runInDir: func(_ string, args ...string) (string, error) {
+			joined := strings.Join(args, " ")
+			switch {
+			case args[0] == gitCmdStatus:
+				return "", nil
+			case args[0] == "rebase" && args[1] == "--":
+				f.setStopped(true)
+				return "", f.rebaseErr
+			case joined == "rebase --abort":
+				f.aborted = true
+				f.setStopped(false)
+			case strings.HasSuffix(joined, "rebase --continue"):
+				return "", f.next()
+			case strings.Contains(joined, "--absolute-git-dir"):
+				return f.gitDir, nil
+			case strings.Contains(joined, "--diff-filter=U"):
+				return strings.Join(f.stops[0], "\n"), nil
+			case args[0] == "checkout":
+				f.checkouts = append(f.checkouts, args[1])
+			case args[0] == "add":
+				f.added = append(f.added, args[2:]...)
+			}
+			return "", nil
+		},
+	}
+}
+
+// goSumModule regenerates go.sum with a shell command instead of go mod tidy.
+func goSumModule(lock string) []config.ModuleConfig {
+	return []config.ModuleConfig{{Dir: "vendor", Lockfile: lockfileGoSum, Install: "go mod download", Lock: lock}}
+}
+
+func TestNewLockfileResolverOptIn(t *testing.T) {
+	if NewLockfileResolver(&config.Config{}) != nil {
+		t.Error("NewLockfileResolver without resolve_lockfiles is non-nil")
+	}
+	cfg := &config.Config{Deps: &config.DepsConfig{ResolveLockfiles: true, Modules: goSumModule("true")}}
+	if l := NewLockfileResolver(cfg); l == nil || len(l.Modules) != 1 {
+		t.Errorf("NewLockfileResolver = %+v, want the configured modules", l)
+	}
+}
+
+func TestLockfileResolverResolvesEveryStop(t *testing.T) {
+	f := newLockfileFake(t, []string{lockfileGoSum}, []string{lockfileGoSum, deps.LockfilePnpm})
+	f.setStopped(true)
+	l := &LockfileResolver{Modules: append(goSumModule("echo tidy > go.sum"),
+		config.ModuleConfig{Dir: "node_modules", Lockfile: deps.LockfilePnpm, Install: "pnpm install --frozen-lockfile", Lock: "true"})}
+
+	files, err := l.Resolve(context.Background(), f.runner(), f.dir, false, git.CheckoutOurs)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !slices.Equal(files, []string{lockfileGoSum, deps.LockfilePnpm}) {
+		t.Errorf("files = %v, want go.sum and pnpm-lock.yaml once each", files)
+	}
+	if !slices.Equal(f.checkouts, []string{git.CheckoutOurs, git.CheckoutOurs}) {
+		t.Errorf("checkouts = %v, want --ours at both stops", f.checkouts)
+	}
+	if len(f.added) != 3 {
+		t.Errorf("added = %v, want every conflicted lockfile staged", f.added)
+	}
+	if data, _ := os.ReadFile(filepath.Join(f.dir, lockfileGoSum)); strings.TrimSpace(string(data)) != "tidy" {
+		t.Errorf("go.sum = %q, want it regenerated", data)
+	}
+}
+
+func TestLockfileResolverLeavesOtherConflicts(t *testing.T) {
+	f := newLockfileFake(t, []string{lockfileGoSum, "go.mod"})
+	f.setStopped(true)
+	l := &LockfileResolver{Modules: goSumModule("true")}
+
+	_, err := l.Resolve(context.Background(), f.runner(), f.dir, false, git.CheckoutOurs)
+	if !errors.Is(err, errNotLockfileConflict) {
+		t.Fatalf("err = %v, want errNotLockfileConflict", err)
+	}
+	if len(f.checkouts) != 0 || len(f.added) != 0 {
+		t.Errorf("checkouts = %v, added = %v; want the conflict untouched", f.checkouts, f.added)
+	}
+}
+
+func TestSyncWorktreeWithLockfiles(t *testing.T) {
+	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
+
+	t.Run("regenerated", func(t *testing.T) {
+		f := newLockfileFake(t, []string{lockfileGoSum})
+		wt := wt
+		wt.Path = f.dir
+		res := SyncWorktreeWith(context.Background(), f.runner(), branchMain, wt, SyncOptions{
+			Lockfiles: &LockfileResolver{Modules: goSumModule("true")},
+		})
+		if !res.Synced || !slices.Equal(res.LockfilesRegenerated, []string{lockfileGoSum}) {
+			t.Errorf("res = %+v, want synced with go.sum regenerated", res)
+		}
+	})
+
+	t.Run("regeneration fails", func(t *testing.T) {
+		f := newLockfileFake(t, []string{lockfileGoSum})
+		wt := wt
+		wt.Path = f.dir
+		res := SyncWorktreeWith(context.Background(), f.runner(), branchMain, wt, SyncOptions{
+			Lockfiles: &LockfileResolver{Modules: goSumModule("exit 1")},
+		})
+		if !res.Failed || !strings.Contains(res.FailureHint, "could not regenerate lockfiles") {
+			t.Errorf("res = %+v, want a failure explaining the regeneration", res)
+		}
+		if !f.aborted {
+			t.Error("rebase not aborted after the lockfiles could not be regenerated")
+		}
+	})
+
+	t.Run("other conflicts", func(t *testing.T) {
+		f := newLockfileFake(t, []string{"main.go"})
+		wt := wt
+		wt.Path = f.dir
+		res := SyncWorktreeWith(context.Background(), f.runner(), branchMain, wt, SyncOptions{
+			Lockfiles: &LockfileResolver{Modules: goSumModule("true")},
+		})
+		if !res.Failed || strings.Contains(res.FailureHint, "lockfiles") || !f.aborted {
+			t.Errorf("res = %+v, aborted = %v; want the usual failure", res, f.aborted)
+		}
+	})
+}
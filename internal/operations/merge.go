@@ -2,7 +2,9 @@ package operations
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/lugassawan/rimba/internal/config"
@@ -23,10 +25,28 @@ type MergeParams struct {
 	IntoService string
 	RepoRoot    string
 	MainBranch  string
-	NoFF        bool
-	Keep        bool
-	Delete      bool
-	DryRun      bool
+	// Base, when set and not MainBranch, is merged into instead of the main
+	// branch when IntoTask is empty (a prefix type's base branch); it must
+	// be checked out in a worktree.
+	Base   string
+	NoFF   bool
+	Keep   bool
+	Delete bool
+	DryRun bool
+	// Lockfiles, when set, resolves a merge that stops only on lockfile
+	// conflicts by regenerating the lockfiles and committing it.
+	Lockfiles *LockfileResolver
+	// Squash squashes the source branch into one commit on the target
+	// instead of merging it, with Message or else a message SquashMessage
+	// builds. EditMessage, when set, edits the message before the commit;
+	// it is not called on a dry run.
+	Squash      bool
+	Message     string
+	EditMessage func(message string) (string, error)
+	// Verify, when set, are run against the merge result in a scratch
+	// worktree before the target is updated ([merge] verify); the merge is
+	// refused if any fails.
+	Verify []string
 }
 
 // MergeResult holds the outcome of a merge operation.
@@ -41,7 +61,16 @@ type MergeResult struct {
 	RemoveError      error // non-nil if cleanup failed
 	RemoteDeleted    bool  // true if the remote branch was deleted (parity with clean --merged, #231)
 	RemoteError      error // non-nil if remote-branch deletion was attempted and failed
-	Plan             *Plan // always non-nil on a successful return; records steps that were (or would be) executed
+	// LockfilesRegenerated lists the lockfiles whose conflicts were resolved
+	// by regenerating them (MergeParams.Lockfiles).
+	LockfilesRegenerated []string
+	// Squashed counts the commits squashed into CommitMessage's commit
+	// (MergeParams.Squash).
+	Squashed      int
+	CommitMessage string
+	// Verify is the outcome of MergeParams.Verify; nil when none ran.
+	Verify *MergeVerify
+	Plan   *Plan // always non-nil on a successful return; records steps that were (or would be) executed
 }
 
 // dirtyResult holds the outcome of an IsDirty check.
@@ -82,13 +111,14 @@ func MergeWorktree(ctx context.Context, r git.Runner, params MergeParams, onProg
 		return result, err
 	}
 
+	if err := verifyMerge(ctx, r, plan, params, targetDir, &result, onProgress); err != nil {
+		return result, err
+	}
+
 	// Execute merge
 	progress.Notify(onProgress, "Merging...")
-	mergeDesc := fmt.Sprintf("merge %s into %s", source.Branch, targetLabel)
-	if err := plan.Do(mergeDesc, func() error {
-		return git.Merge(ctx, r, targetDir, source.Branch, params.NoFF)
-	}); err != nil {
-		return result, abortFailedMerge(ctx, r, targetDir, targetLabel, source.Branch, err)
+	if err := runMerge(ctx, r, plan, params, targetDir, &result); err != nil {
+		return result, err
 	}
 
 	// Auto-cleanup
@@ -101,7 +131,7 @@ func MergeWorktree(ctx context.Context, r git.Runner, params MergeParams, onProg
 		var wtRemoved, brDeleted, leftOnDisk bool
 		rmErr := plan.Do("remove worktree: "+source.Path, func() error {
 			var err error
-			wtRemoved, brDeleted, leftOnDisk, err = removeAndCleanup(ctx, r, source.Path, source.Branch, false, source.Prunable)
+			wtRemoved, brDeleted, leftOnDisk, err = removeMergedSource(ctx, r, source)
 			return err
 		})
 		result.WorktreeRemoved = wtRemoved
@@ -120,12 +150,48 @@ func MergeWorktree(ctx context.Context, r git.Runner, params MergeParams, onProg
 	return result, nil
 }
 
+// runMerge merges or, with params.Squash, squashes result.SourceBranch into
+// the worktree at targetDir, rolling a failed merge back.
+func runMerge(ctx context.Context, r git.Runner, plan *Plan, params MergeParams, targetDir string, result *MergeResult) error {
+	if params.Squash {
+		return squashMerge(ctx, r, plan, params, targetDir, result)
+	}
+	mergeDesc := fmt.Sprintf("merge %s into %s", result.SourceBranch, result.TargetLabel)
+	if err := plan.Do(mergeDesc, func() error {
+		var err error
+		result.LockfilesRegenerated, err = mergeResolvingLockfiles(ctx, r, targetDir, result.SourceBranch, params)
+		return err
+	}); err != nil {
+		return abortFailedMerge(ctx, r, targetDir, result.TargetLabel, result.SourceBranch, err)
+	}
+	return nil
+}
+
+// removeMergedSource removes the merged source worktree and branch,
+// journaling the removal so it can be undone.
+func removeMergedSource(ctx context.Context, r git.Runner, source resolver.WorktreeInfo) (wtRemoved, brDeleted, leftOnDisk bool, err error) {
+	rec := newRecorder(ctx, r, "merge")
+	defer rec.commit()
+	step := rec.captureRemoval(ctx, r, source.Path, source.Branch, true)
+
+	wtRemoved, brDeleted, leftOnDisk, err = removeAndCleanup(ctx, r, source.Path, source.Branch, false, source.Prunable)
+	if wtRemoved {
+		rec.removed(step, brDeleted)
+	}
+	if brDeleted {
+		unlinkStacked(ctx, r, source.Branch, true)
+		forgetMeta(ctx, r, source.Branch)
+	}
+	return wtRemoved, brDeleted, leftOnDisk, err
+}
+
 // resolveMergeEndpoints resolves the source and target worktrees for a merge.
 // Skips the worktree-list lookup entirely when params.Source is pre-resolved and merging to main.
 func resolveMergeEndpoints(ctx context.Context, r git.Runner, params MergeParams) (source resolver.WorktreeInfo, targetDir, targetLabel string, mergingToMain bool, err error) {
 	mergingToMain = params.IntoTask == ""
 
-	if params.Source != nil && mergingToMain {
+	mergingToBase := mergingToMain && params.Base != "" && params.Base != params.MainBranch
+	if params.Source != nil && mergingToMain && !mergingToBase {
 		return *params.Source, params.RepoRoot, params.MainBranch, true, nil
 	}
 
@@ -144,6 +210,13 @@ func resolveMergeEndpoints(ctx context.Context, r git.Runner, params MergeParams
 		}
 	}
 
+	if mergingToBase {
+		dir, err := baseWorktreeDir(worktrees, params.Base, source.Branch)
+		if err != nil {
+			return resolver.WorktreeInfo{}, "", "", mergingToMain, err
+		}
+		return source, dir, params.Base, true, nil
+	}
 	if mergingToMain {
 		return source, params.RepoRoot, params.MainBranch, true, nil
 	}
@@ -155,6 +228,19 @@ func resolveMergeEndpoints(ctx context.Context, r git.Runner, params MergeParams
 	return source, target.Path, target.Branch, mergingToMain, nil
 }
 
+// baseWorktreeDir returns the directory of the worktree that has base checked
+// out, for merging a branch into its prefix type's base branch.
+func baseWorktreeDir(worktrees []resolver.WorktreeInfo, base, branch string) (string, error) {
+	i := slices.IndexFunc(worktrees, func(wt resolver.WorktreeInfo) bool { return wt.Branch == base })
+	if i < 0 {
+		return "", errhint.WithFix(
+			fmt.Errorf("base branch %q of %s is not checked out in any worktree", base, branch),
+			"check it out first: git worktree add <path> "+base+", or merge elsewhere with --into <task>",
+		)
+	}
+	return worktrees[i].Path, nil
+}
+
 // deleteMergedRemote deletes the remote tracking branch after a merge cleanup.
 // It is a no-op when the remote is absent or when the worktree was not actually
 // removed. Failures are captured in result.RemoteError and never abort the merge.
@@ -175,6 +261,24 @@ func deleteMergedRemote(ctx context.Context, r git.Runner, plan *Plan, branch st
 	})
 }
 
+// mergeResolvingLockfiles merges branch into the worktree at targetDir. With
+// params.Lockfiles, a merge stopped only on lockfile conflicts is resolved
+// from the target's side and committed; it returns the regenerated lockfiles.
+func mergeResolvingLockfiles(ctx context.Context, r git.Runner, targetDir, branch string, params MergeParams) ([]string, error) {
+	err := git.Merge(ctx, r, targetDir, branch, params.NoFF)
+	if err == nil || params.Lockfiles == nil {
+		return nil, err
+	}
+	files, lockErr := params.Lockfiles.Resolve(ctx, r, targetDir, true, git.CheckoutOurs)
+	switch {
+	case lockErr == nil:
+		return files, nil
+	case errors.Is(lockErr, errNotLockfileConflict):
+		return nil, err
+	}
+	return nil, errors.Join(err, fmt.Errorf("could not regenerate lockfiles: %w", lockErr))
+}
+
 // abortFailedMerge attempts to roll back a failed merge in targetDir.
 // It checks MERGE_HEAD first so we only abort when a merge is actually in progress.
 // If the abort also fails, both errors are surfaced with a manual-cleanup hint.
@@ -0,0 +1,52 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// MergePlanOptions returns the ordering constraints of a merge plan of
+// branches: each stacked branch merges after its parent, and the tasks in
+// first lead the plan, in that order. A task in first must name one of
+// branches.
+func MergePlanOptions(ctx context.Context, r git.Runner, branches []resolver.WorktreeInfo, prefixes, first []string) (conflict.PlanOptions, error) {
+	var opts conflict.PlanOptions
+	for _, task := range first {
+		wt, ok := resolver.FindBranchForTask("", task, branches, prefixes)
+		if !ok {
+			return conflict.PlanOptions{}, errhint.WithFix(
+				fmt.Errorf("cannot pin %q first: no active worktree branch matches it", task),
+				"run: rimba list  to see available worktrees",
+			)
+		}
+		opts.First = append(opts.First, wt.Branch)
+	}
+
+	parents := StackParents(ctx, r)
+	for _, child := range slices.Sorted(maps.Keys(parents)) {
+		opts.Constraints = append(opts.Constraints, conflict.Constraint{Before: parents[child], After: child, Reason: "stacked"})
+	}
+	return opts, nil
+}
+
+// PlanMergeGraph plans the merge order of branches around the open PRs in
+// prs, keeping opts, and returns the plan with its conflict graph.
+func PlanMergeGraph(overlaps []conflict.FileOverlap, branches []resolver.WorktreeInfo, prs map[string]conflict.PR, opts conflict.PlanOptions) (conflict.Plan, conflict.Graph, error) {
+	names := make([]string, len(branches))
+	for i, wt := range branches {
+		names[i] = wt.Branch
+	}
+	opts.Others = slices.Sorted(maps.Keys(prs))
+	plan, err := conflict.PlanMerge(overlaps, names, opts)
+	if err != nil {
+		return conflict.Plan{}, conflict.Graph{}, err
+	}
+	return plan, conflict.BuildGraph(overlaps, opts.DryMerges, plan.Steps, prs), nil
+}
@@ -0,0 +1,65 @@
+package operations
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/stack"
+)
+
+func TestMergePlanOptions(t *testing.T) {
+	sr := &stackRunner{commonDir: t.TempDir()}
+	seedStack(t, sr.commonDir, map[string]stack.Link{
+		branchAuthUI:    {Parent: branchAuth},
+		branchAuthTests: {Parent: branchAuthUI},
+	})
+
+	opts, err := MergePlanOptions(context.Background(), sr.runner(), stackWorktrees(), []string{"feature/"}, []string{"auth"})
+	if err != nil {
+		t.Fatalf("MergePlanOptions: %v", err)
+	}
+	if !slices.Equal(opts.First, []string{branchAuth}) {
+		t.Errorf("First = %v, want [%s]", opts.First, branchAuth)
+	}
+	want := []conflict.Constraint{
+		{Before: branchAuthUI, After: branchAuthTests, Reason: "stacked"},
+		{Before: branchAuth, After: branchAuthUI, Reason: "stacked"},
+	}
+	if !slices.Equal(opts.Constraints, want) {
+		t.Errorf("Constraints = %+v, want %+v", opts.Constraints, want)
+	}
+}
+
+func TestMergePlanOptionsUnknownPin(t *testing.T) {
+	sr := &stackRunner{commonDir: t.TempDir()}
+
+	_, err := MergePlanOptions(context.Background(), sr.runner(), stackWorktrees(), []string{"feature/"}, []string{"missing"})
+	if err == nil || !strings.Contains(err.Error(), `cannot pin "missing" first`) {
+		t.Errorf("err = %v, want an unknown pin error", err)
+	}
+}
+
+func TestPlanMergeGraph(t *testing.T) {
+	const pr = "refs/rimba/prs/3"
+	overlaps := []conflict.FileOverlap{
+		{File: "x.go", Branches: []string{branchAuth, branchAuthUI}},
+		{File: "y.go", Branches: []string{branchAuthUI, pr}},
+	}
+	branches := []resolver.WorktreeInfo{{Branch: branchAuth}, {Branch: branchAuthUI}}
+	prs := map[string]conflict.PR{pr: {Ref: pr, Number: 3, Author: "bob"}}
+
+	plan, graph, err := PlanMergeGraph(overlaps, branches, prs, conflict.PlanOptions{})
+	if err != nil {
+		t.Fatalf("PlanMergeGraph: %v", err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].Branch != branchAuth {
+		t.Errorf("steps = %+v, want %s first, the PR taking no step", plan.Steps, branchAuth)
+	}
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 {
+		t.Errorf("graph = %+v, want 3 nodes and 2 edges", graph)
+	}
+}
@@ -0,0 +1,296 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/deps"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/mergequeue"
+	"github.com/lugassawan/rimba/internal/progress"
+)
+
+// MergeQueueParams holds the inputs for starting a merge queue.
+type MergeQueueParams struct {
+	// Target is the branch the queue lands onto; it must be checked out,
+	// clean, in TargetPath.
+	Target     string
+	TargetPath string
+	// Branches are landed one at a time, in order.
+	Branches []string
+	// Verify are the commands each branch must pass in the integration
+	// worktree before it lands ([merge_queue] verify).
+	Verify []string
+}
+
+// MergeQueueStep is the outcome of one branch a queue run reached.
+type MergeQueueStep struct {
+	Branch string
+	Landed bool
+	PreSHA string // target tip before the branch
+	SHA    string // target tip once the branch landed
+	Reason string // why the branch did not land
+}
+
+// MergeQueueResult is the outcome of running a merge queue.
+type MergeQueueResult struct {
+	Target string
+	// Steps are the branches this run reached, in order.
+	Steps []MergeQueueStep
+	// Landed are all branches the queue has landed, earlier runs included.
+	Landed []string
+	// Pending are the branches still queued behind a failed one.
+	Pending []string
+	// Stopped reports that a branch failed and the queue was kept for
+	// --continue.
+	Stopped bool
+}
+
+// StartMergeQueue persists a queue of params.Branches and lands them on
+// params.Target one at a time: each is merged into the target's tip in a
+// temporary integration worktree and verified there, and the target is
+// fast-forwarded only once verification passes. The queue stops on the
+// first branch that conflicts or fails, leaving the target at its previous
+// SHA. Only one queue may be in progress at a time.
+func StartMergeQueue(ctx context.Context, r git.Runner, params MergeQueueParams, onProgress progress.Func) (MergeQueueResult, error) {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return MergeQueueResult{}, err
+	}
+	existing, err := mergequeue.Load(commonDir)
+	if err != nil {
+		return MergeQueueResult{}, err
+	}
+	if existing != nil {
+		return MergeQueueResult{}, errhint.WithFix(
+			errors.New("a merge queue is already in progress"),
+			"continue it with 'rimba merge-queue --continue', or drop it with 'rimba merge-queue --abort'",
+		)
+	}
+	if err := checkQueueTarget(ctx, r, params.Target, params.TargetPath); err != nil {
+		return MergeQueueResult{}, err
+	}
+
+	q := &mergequeue.Queue{
+		StartedAt:  time.Now().UTC().Truncate(time.Second),
+		Target:     params.Target,
+		TargetPath: params.TargetPath,
+	}
+	for _, b := range params.Branches {
+		q.Branches = append(q.Branches, mergequeue.Branch{Branch: b, Status: mergequeue.StatusPending})
+	}
+	if err := q.Save(commonDir); err != nil {
+		return MergeQueueResult{}, err
+	}
+	return runMergeQueue(ctx, r, commonDir, q, params.Verify, onProgress)
+}
+
+// ContinueMergeQueue retries the branch the queue in progress stopped on —
+// after it has been fixed — and lands the branches queued behind it.
+func ContinueMergeQueue(ctx context.Context, r git.Runner, verify []string, onProgress progress.Func) (MergeQueueResult, error) {
+	commonDir, q, err := loadMergeQueue(ctx, r)
+	if err != nil {
+		return MergeQueueResult{}, err
+	}
+	if err := checkQueueTarget(ctx, r, q.Target, q.TargetPath); err != nil {
+		return MergeQueueResult{}, err
+	}
+	for _, b := range q.With(mergequeue.StatusFailed) {
+		q.Update(b.Branch, func(b *mergequeue.Branch) {
+			b.Status, b.Reason = mergequeue.StatusPending, ""
+		})
+	}
+	return runMergeQueue(ctx, r, commonDir, q, verify, onProgress)
+}
+
+// AbortMergeQueue drops the queue in progress and returns the branches it
+// had landed, which stay on the target.
+func AbortMergeQueue(ctx context.Context, r git.Runner) ([]string, error) {
+	commonDir, q, err := loadMergeQueue(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return queuedBranchNames(q.With(mergequeue.StatusLanded)), mergequeue.Clear(commonDir)
+}
+
+// runMergeQueue lands q's pending branches in order, persisting each
+// outcome, until one fails. The queue is cleared once every branch landed.
+func runMergeQueue(ctx context.Context, r git.Runner, commonDir string, q *mergequeue.Queue, verify []string, onProgress progress.Func) (MergeQueueResult, error) {
+	result := MergeQueueResult{Target: q.Target}
+	pending := q.With(mergequeue.StatusPending)
+	for i, b := range pending {
+		progress.Notifyf(onProgress, "Merging %s (%d/%d)...", b.Branch, i+1, len(pending))
+		step, err := landQueuedBranch(ctx, r, q, b.Branch, verify, onProgress)
+		if err != nil {
+			return result, err
+		}
+		recordQueueStep(q, step)
+		if err := q.Save(commonDir); err != nil {
+			return result, err
+		}
+		result.Steps = append(result.Steps, step)
+		if !step.Landed {
+			result.Stopped = true
+			break
+		}
+	}
+
+	result.Landed = queuedBranchNames(q.With(mergequeue.StatusLanded))
+	result.Pending = queuedBranchNames(q.With(mergequeue.StatusPending))
+	if result.Stopped {
+		return result, nil
+	}
+	return result, mergequeue.Clear(commonDir)
+}
+
+// landQueuedBranch merges branch into the target's tip in a temporary
+// integration worktree, runs verify there, and fast-forwards the target to
+// the result only when every command passes — so a branch that conflicts or
+// fails verification leaves the target at its previous SHA, with the reason
+// in the step. An error is a failure the queue cannot pin on the branch.
+func landQueuedBranch(ctx context.Context, r git.Runner, q *mergequeue.Queue, branch string, verify []string, onProgress progress.Func) (MergeQueueStep, error) {
+	step := MergeQueueStep{Branch: branch}
+	pre, err := git.ResolveRef(ctx, r, "refs/heads/"+q.Target)
+	if err != nil {
+		return step, fmt.Errorf("resolve %s: %w", q.Target, err)
+	}
+	step.PreSHA = strings.TrimSpace(pre)
+
+	dir, cleanup, err := addIntegrationWorktree(ctx, r, step.PreSHA)
+	if err != nil {
+		return step, err
+	}
+	defer cleanup()
+
+	if err := git.MergeWithMessage(ctx, r, dir, branch, fmt.Sprintf("Merge branch '%s'", branch)); err != nil {
+		step.Reason = queueMergeFailure(ctx, r, dir, q.Target, err)
+		return step, nil
+	}
+	verifyErr := firstVerifyFailure(deps.RunPostCreateHooks(ctx, dir, verify, onProgress))
+	if err := ctx.Err(); err != nil {
+		return step, err
+	}
+	if verifyErr != nil {
+		step.Reason = verifyErr.Error()
+		return step, nil
+	}
+
+	sha, err := git.HeadSHA(ctx, r, dir)
+	if err != nil {
+		return step, err
+	}
+	if err := git.MergeFFOnly(ctx, r, q.TargetPath, sha); err != nil {
+		step.Reason = fmt.Sprintf("could not fast-forward %s: %v", q.Target, err)
+		return step, nil
+	}
+	step.Landed, step.SHA = true, sha
+	return step, nil
+}
+
+// addIntegrationWorktree checks sha out on a detached HEAD in a temporary
+// worktree, returning its path and a cleanup that removes it.
+func addIntegrationWorktree(ctx context.Context, r git.Runner, sha string) (string, func(), error) {
+	tmp, err := os.MkdirTemp("", "rimba-integration-*")
+	if err != nil {
+		return "", nil, err
+	}
+	dir := filepath.Join(tmp, "integration")
+	if err := git.AddDetachedWorktree(ctx, r, dir, sha); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", nil, fmt.Errorf("create integration worktree: %w", err)
+	}
+	return dir, func() {
+		_ = git.RemoveWorktree(context.Background(), r, dir, true)
+		_ = os.RemoveAll(tmp)
+	}, nil
+}
+
+// queueMergeFailure describes why branch could not be merged into target in
+// dir: the conflicted files, or the merge error itself.
+func queueMergeFailure(ctx context.Context, r git.Runner, dir, target string, mergeErr error) string {
+	if files, _ := git.UnmergedFiles(ctx, r, dir); len(files) > 0 {
+		return fmt.Sprintf("conflicts with %s: %s", target, strings.Join(files, ", "))
+	}
+	return fmt.Sprintf("merge into %s failed: %v", target, mergeErr)
+}
+
+// firstVerifyFailure returns the first failed verify command, if any.
+func firstVerifyFailure(results []deps.HookResult) error {
+	for _, res := range results {
+		if res.Error != nil {
+			return fmt.Errorf("verify %q failed: %w", res.Command, res.Error)
+		}
+	}
+	return nil
+}
+
+// recordQueueStep stores step's outcome on its branch in q.
+func recordQueueStep(q *mergequeue.Queue, step MergeQueueStep) {
+	q.Update(step.Branch, func(b *mergequeue.Branch) {
+		b.Status = mergequeue.StatusFailed
+		if step.Landed {
+			b.Status = mergequeue.StatusLanded
+		}
+		b.PreSHA, b.SHA, b.Reason = step.PreSHA, step.SHA, step.Reason
+	})
+}
+
+// checkQueueTarget refuses to land on target unless it is checked out,
+// clean, in targetPath.
+func checkQueueTarget(ctx context.Context, r git.Runner, target, targetPath string) error {
+	branch, err := git.CurrentBranch(ctx, r, targetPath)
+	if err != nil {
+		return err
+	}
+	if branch != target {
+		return errhint.WithFix(
+			fmt.Errorf("%s is not checked out in %s (found %s)", target, targetPath, branch),
+			"switch back to it first: cd "+targetPath+" && git switch "+target,
+		)
+	}
+	dirty, err := git.IsDirty(ctx, r, targetPath)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return errhint.WithFix(
+			fmt.Errorf("target %q has uncommitted changes", target),
+			"Commit or stash changes before merging: cd "+targetPath,
+		)
+	}
+	return nil
+}
+
+// loadMergeQueue returns the queue in progress and the common dir it lives
+// under, or an error when there is none.
+func loadMergeQueue(ctx context.Context, r git.Runner) (string, *mergequeue.Queue, error) {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return "", nil, err
+	}
+	q, err := mergequeue.Load(commonDir)
+	if err != nil {
+		return "", nil, err
+	}
+	if q == nil {
+		return "", nil, errhint.WithFix(
+			errors.New("no merge queue in progress"),
+			"start one with: rimba merge-queue",
+		)
+	}
+	return commonDir, q, nil
+}
+
+func queuedBranchNames(branches []mergequeue.Branch) []string {
+	names := make([]string, 0, len(branches))
+	for _, b := range branches {
+		names = append(names, b.Branch)
+	}
+	return names
+}
@@ -0,0 +1,159 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/mergequeue"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+const (
+	branchQueueA = "feature/a"
+	branchQueueB = "feature/b"
+)
+
+// queueRepo returns a repo whose main has two branches to land:
+// feature/a adding a.txt and feature/b adding b.txt.
+func queueRepo(t *testing.T) (string, git.Runner) {
+	t.Helper()
+	repo := testutil.NewTestRepo(t)
+	for _, b := range []string{branchQueueA, branchQueueB} {
+		file := strings.TrimPrefix(b, "feature/") + ".txt"
+		testutil.GitCmd(t, repo, "switch", "-c", b, branchMain)
+		testutil.CreateFile(t, repo, file, b+"\n")
+		testutil.GitCmd(t, repo, "add", file)
+		testutil.GitCmd(t, repo, "commit", "-m", "add "+file)
+	}
+	testutil.GitCmd(t, repo, "switch", branchMain)
+	return repo, &git.ExecRunner{Dir: repo}
+}
+
+func queueParams(repo string, verify ...string) MergeQueueParams {
+	return MergeQueueParams{
+		Target:     branchMain,
+		TargetPath: repo,
+		Branches:   []string{branchQueueA, branchQueueB},
+		Verify:     verify,
+	}
+}
+
+func headSubject(t *testing.T, repo string) string {
+	t.Helper()
+	return strings.TrimSpace(testutil.GitCmd(t, repo, "log", "-1", "--format=%s", branchMain))
+}
+
+func TestStartMergeQueueLandsInOrder(t *testing.T) {
+	repo, r := queueRepo(t)
+	result, err := StartMergeQueue(context.Background(), r, queueParams(repo, "test -f a.txt"), nil)
+	if err != nil {
+		t.Fatalf("StartMergeQueue: %v", err)
+	}
+	if result.Stopped || !slices.Equal(result.Landed, []string{branchQueueA, branchQueueB}) {
+		t.Fatalf("result = %+v, want both landed", result)
+	}
+	for _, f := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(repo, f)); err != nil {
+			t.Errorf("%s missing from main's worktree: %v", f, err)
+		}
+	}
+	if got := headSubject(t, repo); got != "Merge branch 'feature/b'" {
+		t.Errorf("main's tip = %q, want the merge of feature/b", got)
+	}
+	if q, _ := mergequeue.Load(filepath.Join(repo, ".git")); q != nil {
+		t.Errorf("queue left behind: %+v", q)
+	}
+	if out := testutil.GitCmd(t, repo, "worktree", "list"); strings.Count(out, "\n") != 1 {
+		t.Errorf("integration worktree left behind:\n%s", out)
+	}
+}
+
+func TestMergeQueueStopsOnFailedVerifyAndContinues(t *testing.T) {
+	repo, r := queueRepo(t)
+	result, err := StartMergeQueue(context.Background(), r, queueParams(repo, "test ! -f b.txt"), nil)
+	if err != nil {
+		t.Fatalf("StartMergeQueue: %v", err)
+	}
+	if !result.Stopped || !slices.Equal(result.Landed, []string{branchQueueA}) {
+		t.Fatalf("result = %+v, want feature/a landed and the queue stopped", result)
+	}
+	failed := result.Steps[1]
+	if failed.Landed || !strings.Contains(failed.Reason, `verify "test ! -f b.txt" failed`) {
+		t.Errorf("feature/b step = %+v, want a verify failure", failed)
+	}
+	if tip := strings.TrimSpace(testutil.GitCmd(t, repo, "rev-parse", branchMain)); tip != failed.PreSHA {
+		t.Errorf("main = %s, want it left at %s", tip, failed.PreSHA)
+	}
+	if _, err := StartMergeQueue(context.Background(), r, queueParams(repo), nil); err == nil || !strings.Contains(err.Error(), "already in progress") {
+		t.Errorf("second StartMergeQueue err = %v, want already in progress", err)
+	}
+
+	result, err = ContinueMergeQueue(context.Background(), r, []string{"true"}, nil)
+	if err != nil {
+		t.Fatalf("ContinueMergeQueue: %v", err)
+	}
+	if result.Stopped || len(result.Steps) != 1 || !slices.Equal(result.Landed, []string{branchQueueA, branchQueueB}) {
+		t.Errorf("result = %+v, want feature/b landed on continue", result)
+	}
+	if _, err := ContinueMergeQueue(context.Background(), r, nil, nil); err == nil || !strings.Contains(err.Error(), "no merge queue") {
+		t.Errorf("ContinueMergeQueue after the queue ended: err = %v", err)
+	}
+}
+
+func TestMergeQueueStopsOnConflict(t *testing.T) {
+	repo, r := queueRepo(t)
+	testutil.CreateFile(t, repo, "a.txt", "main's a\n")
+	testutil.GitCmd(t, repo, "add", "a.txt")
+	testutil.GitCmd(t, repo, "commit", "-m", "a on main")
+
+	result, err := StartMergeQueue(context.Background(), r, queueParams(repo), nil)
+	if err != nil {
+		t.Fatalf("StartMergeQueue: %v", err)
+	}
+	if !result.Stopped || len(result.Landed) != 0 || !slices.Equal(result.Pending, []string{branchQueueB}) {
+		t.Fatalf("result = %+v, want stopped on feature/a with feature/b pending", result)
+	}
+	if reason := result.Steps[0].Reason; reason != "conflicts with main: a.txt" {
+		t.Errorf("reason = %q", reason)
+	}
+	if got := headSubject(t, repo); got != "a on main" {
+		t.Errorf("main's tip = %q, want it untouched", got)
+	}
+}
+
+func TestAbortMergeQueueKeepsLanded(t *testing.T) {
+	repo, r := queueRepo(t)
+	if _, err := StartMergeQueue(context.Background(), r, queueParams(repo, "test ! -f b.txt"), nil); err != nil {
+		t.Fatalf("StartMergeQueue: %v", err)
+	}
+	landed, err := AbortMergeQueue(context.Background(), r)
+	if err != nil {
+		t.Fatalf("AbortMergeQueue: %v", err)
+	}
+	if !slices.Equal(landed, []string{branchQueueA}) {
+		t.Errorf("landed = %v, want feature/a", landed)
+	}
+	if got := headSubject(t, repo); got != "Merge branch 'feature/a'" {
+		t.Errorf("main's tip = %q, want feature/a still landed", got)
+	}
+	if _, err := AbortMergeQueue(context.Background(), r); err == nil {
+		t.Error("AbortMergeQueue with no queue: want an error")
+	}
+}
+
+func TestStartMergeQueueRefusesDirtyTarget(t *testing.T) {
+	repo, r := queueRepo(t)
+	testutil.CreateFile(t, repo, "README.md", "changed\n")
+	_, err := StartMergeQueue(context.Background(), r, queueParams(repo), nil)
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Fatalf("err = %v, want uncommitted changes", err)
+	}
+	if q, _ := mergequeue.Load(filepath.Join(repo, ".git")); q != nil {
+		t.Errorf("queue saved for a refused start: %+v", q)
+	}
+}
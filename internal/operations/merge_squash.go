@@ -0,0 +1,146 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// conventionalTypes maps built-in prefix types to the Conventional Commits
+// type of a squash commit header; other types are used as named.
+var conventionalTypes = map[string]string{
+	string(resolver.PrefixFeature): "feat",
+	string(resolver.PrefixBugfix):  "fix",
+	string(resolver.PrefixHotfix):  "fix",
+}
+
+// conventionalHeader matches the "type(scope)!: " that starts a Conventional
+// Commits subject.
+var conventionalHeader = regexp.MustCompile(`^[A-Za-z]+(\([^)]*\))?!?: `)
+
+// SquashMessage builds the commit message for squashing branch into target:
+// a Conventional Commits header from the branch's prefix type and task — the
+// single commit's subject, or the task — followed by the subjects of the
+// squashed commits. It also returns how many commits are squashed.
+func SquashMessage(ctx context.Context, r git.Runner, branch, target string) (string, int, error) {
+	subjects, err := git.CommitSubjects(ctx, r, target, branch)
+	if err != nil {
+		return "", 0, fmt.Errorf("list commits to squash: %w", err)
+	}
+	if len(subjects) == 0 {
+		return "", 0, errhint.WithFix(
+			fmt.Errorf("nothing to squash: %s has no commits that %s lacks", branch, target),
+			"commit your work on "+branch+" first, or remove the worktree if it is already merged",
+		)
+	}
+	task, typeName := resolver.TaskAndType(branch, config.PrefixSetFromContext(ctx).Strip())
+	return buildSquashMessage(typeName, task, subjects), len(subjects), nil
+}
+
+// buildSquashMessage formats a squash commit message for task of prefix
+// type typeName ("" when the branch has none) over subjects, oldest first.
+func buildSquashMessage(typeName, task string, subjects []string) string {
+	summary := strings.ReplaceAll(task, "-", " ")
+	if len(subjects) == 1 {
+		summary = conventionalHeader.ReplaceAllString(subjects[0], "")
+	}
+	header := summary
+	if kind := conventionalType(typeName); kind != "" {
+		header = fmt.Sprintf("%s(%s): %s", kind, task, summary)
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("\n\nSquashed commits:")
+	for _, s := range subjects {
+		b.WriteString("\n- " + s)
+	}
+	return b.String()
+}
+
+// conventionalType returns the Conventional Commits type for a prefix type
+// name, lower-cased and without the separator a custom prefix ends in.
+func conventionalType(typeName string) string {
+	if kind, ok := conventionalTypes[typeName]; ok {
+		return kind
+	}
+	return strings.ToLower(strings.TrimRight(typeName, "-_/."))
+}
+
+// squashMerge squashes result.SourceBranch into the worktree at targetDir
+// and commits it with params.Message, or a message built by SquashMessage,
+// after params.EditMessage has edited it. A failed squash is rolled back.
+func squashMerge(ctx context.Context, r git.Runner, plan *Plan, params MergeParams, targetDir string, result *MergeResult) error {
+	branch, target := result.SourceBranch, result.TargetLabel
+	message, n, err := SquashMessage(ctx, r, branch, target)
+	if err != nil {
+		return err
+	}
+	if params.Message != "" {
+		message = params.Message
+	}
+	result.Squashed = n
+
+	if err := plan.Do(fmt.Sprintf("squash-merge %d commit(s) of %s into %s", n, branch, target), func() error {
+		var err error
+		result.LockfilesRegenerated, err = squashResolvingLockfiles(ctx, r, targetDir, branch, params)
+		return err
+	}); err != nil {
+		return rollbackSquash(r, targetDir, target, branch, err)
+	}
+	if !params.DryRun && params.EditMessage != nil {
+		if message, err = params.EditMessage(message); err != nil {
+			return rollbackSquash(r, targetDir, target, branch, fmt.Errorf("edit commit message: %w", err))
+		}
+	}
+	result.CommitMessage = message
+
+	header, _, _ := strings.Cut(message, "\n")
+	if err := plan.Do("commit: "+header, func() error {
+		return git.Commit(ctx, r, targetDir, message)
+	}); err != nil {
+		return rollbackSquash(r, targetDir, target, branch, err)
+	}
+	return nil
+}
+
+// squashResolvingLockfiles squashes branch into the worktree at targetDir.
+// With params.Lockfiles, a squash stopped only on lockfile conflicts has
+// them regenerated from the target's side and staged for the squash commit;
+// it returns the regenerated lockfiles.
+func squashResolvingLockfiles(ctx context.Context, r git.Runner, targetDir, branch string, params MergeParams) ([]string, error) {
+	err := git.MergeSquash(ctx, r, targetDir, branch)
+	if err == nil || params.Lockfiles == nil {
+		return nil, err
+	}
+	files, lockErr := params.Lockfiles.resolveStop(ctx, r, targetDir, git.CheckoutOurs)
+	switch {
+	case lockErr == nil:
+		return files, nil
+	case errors.Is(lockErr, errNotLockfileConflict):
+		return nil, err
+	}
+	return nil, errors.Join(err, fmt.Errorf("could not regenerate lockfiles: %w", lockErr))
+}
+
+// rollbackSquash discards the changes a failed squash merge staged in
+// targetDir. A squash leaves no MERGE_HEAD, so abortFailedMerge cannot.
+func rollbackSquash(r git.Runner, targetDir, target, branch string, squashErr error) error {
+	if err := git.ResetMerge(r, targetDir); err != nil {
+		return errhint.WithFix(
+			fmt.Errorf("squash merge failed and rollback failed: %w (rollback: %w)", squashErr, err),
+			"clean up manually: cd "+targetDir+" && git reset --merge",
+		)
+	}
+	return errhint.WithFix(
+		fmt.Errorf("squash merge failed: %w", squashErr),
+		fmt.Sprintf("%s restored to pre-merge state; reconcile %s, then re-run rimba merge --squash", target, branch),
+	)
+}
@@ -0,0 +1,188 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// squashRunner serves MergeWorktree with Squash: feature/login has the
+// given commit subjects, and `merge --squash` fails with squashErr, leaving
+// unmerged conflicted. It records the commands run in the target worktree.
+type squashRunner struct {
+	subjects  []string
+	squashErr error
+	unmerged  []string
+	ran       []string
+}
+
+func (s *squashRunner) runner() *mockRunner {
+	wt := mergeWorktreeList()
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch args[0] {
+			case gitCmdWorktree:
+				return wt, nil
+			case "log":
+				return strings.Join(s.subjects, "\n"), nil
+			}
+			return "", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			if args[0] == gitCmdStatus {
+				return "", nil
+			}
+			s.ran = append(s.ran, strings.Join(args, " "))
+			switch {
+			case args[0] == gitCmdMerge:
+				return "", s.squashErr
+			case slices.Contains(args, "--diff-filter=U"):
+				return strings.Join(s.unmerged, "\n"), nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestBuildSquashMessage(t *testing.T) {
+	tests := []struct {
+		name, typeName, task string
+		subjects             []string
+		wantHeader           string
+	}{
+		{"feature", "feature", "add-login", []string{"wip", "tests"}, "feat(add-login): add login"},
+		{"bugfix single commit", "bugfix", "typo", []string{"fix: correct the typo"}, "fix(typo): correct the typo"},
+		{"custom type", "PROJ-", "123", []string{"a", "b"}, "proj(123): 123"},
+		{"no type", "", "bare-branch", []string{"a", "b"}, "bare branch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := buildSquashMessage(tt.typeName, tt.task, tt.subjects)
+			header, body, _ := strings.Cut(msg, "\n\n")
+			if header != tt.wantHeader {
+				t.Errorf("header = %q, want %q", header, tt.wantHeader)
+			}
+			for _, s := range tt.subjects {
+				if !strings.Contains(body, "- "+s) {
+					t.Errorf("body = %q, want subject %q listed", body, s)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeWorktreeSquash(t *testing.T) {
+	s := &squashRunner{subjects: []string{"add form", "add tests"}}
+	var edited string
+	result, err := MergeWorktree(context.Background(), s.runner(), MergeParams{
+		SourceTask: "login",
+		RepoRoot:   "/repo",
+		MainBranch: branchMain,
+		Squash:     true,
+		EditMessage: func(msg string) (string, error) {
+			edited = msg
+			return "feat(login): sign in with email", nil
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MergeWorktree: %v", err)
+	}
+	if !strings.HasPrefix(edited, "feat(login): login\n") {
+		t.Errorf("message offered for editing = %q", edited)
+	}
+	if result.Squashed != 2 || result.CommitMessage != "feat(login): sign in with email" {
+		t.Errorf("result = %+v, want 2 commits squashed with the edited message", result)
+	}
+	want := []string{"merge --squash -- " + branchFeatureLogin, "commit --cleanup=strip -m feat(login): sign in with email"}
+	if !slices.Equal(s.ran[:2], want) {
+		t.Errorf("ran = %v, want %v first", s.ran, want)
+	}
+}
+
+func TestMergeWorktreeSquashMessageFlag(t *testing.T) {
+	s := &squashRunner{subjects: []string{"one"}}
+	result, err := MergeWorktree(context.Background(), s.runner(), MergeParams{
+		SourceTask: "login", RepoRoot: "/repo", MainBranch: branchMain,
+		Squash: true, Message: "Ship login", Keep: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("MergeWorktree: %v", err)
+	}
+	if result.CommitMessage != "Ship login" || !slices.Contains(s.ran, "commit --cleanup=strip -m Ship login") {
+		t.Errorf("result = %+v, ran = %v; want the given message committed", result, s.ran)
+	}
+}
+
+func TestMergeWorktreeSquashConflictRollsBack(t *testing.T) {
+	s := &squashRunner{subjects: []string{"one"}, squashErr: errors.New("CONFLICT (content)")}
+	_, err := MergeWorktree(context.Background(), s.runner(), MergeParams{
+		SourceTask: "login", RepoRoot: "/repo", MainBranch: branchMain, Squash: true,
+		EditMessage: func(string) (string, error) {
+			t.Error("EditMessage called after a failed squash")
+			return "", nil
+		},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "squash merge failed") {
+		t.Fatalf("err = %v, want squash merge failure", err)
+	}
+	if !slices.Contains(s.ran, "reset --merge") {
+		t.Errorf("ran = %v, want the squash rolled back with reset --merge", s.ran)
+	}
+}
+
+func TestSquashResolvingLockfiles(t *testing.T) {
+	s := &squashRunner{squashErr: errors.New("CONFLICT (content)"), unmerged: []string{lockfileGoSum}}
+	params := MergeParams{Lockfiles: &LockfileResolver{Modules: goSumModule("echo tidy > go.sum")}}
+
+	files, err := squashResolvingLockfiles(context.Background(), s.runner(), t.TempDir(), branchFeatureLogin, params)
+	if err != nil {
+		t.Fatalf("squashResolvingLockfiles: %v", err)
+	}
+	if !slices.Equal(files, []string{lockfileGoSum}) {
+		t.Errorf("files = %v, want go.sum regenerated", files)
+	}
+	if !slices.Contains(s.ran, "checkout --ours -- go.sum") || !slices.Contains(s.ran, "add -- go.sum") {
+		t.Errorf("ran = %v, want the target's go.sum taken and staged", s.ran)
+	}
+
+	s = &squashRunner{squashErr: errors.New("CONFLICT (content)"), unmerged: []string{"main.go", lockfileGoSum}}
+	if _, err := squashResolvingLockfiles(context.Background(), s.runner(), t.TempDir(), branchFeatureLogin, params); err == nil {
+		t.Error("a conflict beyond lockfiles was resolved")
+	}
+}
+
+func TestMergeWorktreeSquashDryRun(t *testing.T) {
+	s := &squashRunner{subjects: []string{"add form", "add tests"}}
+	result, err := MergeWorktree(context.Background(), s.runner(), MergeParams{
+		SourceTask: "login", RepoRoot: "/repo", MainBranch: branchMain, Squash: true, DryRun: true,
+		EditMessage: func(string) (string, error) {
+			t.Error("EditMessage called on a dry run")
+			return "", nil
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MergeWorktree: %v", err)
+	}
+	if len(s.ran) != 0 {
+		t.Errorf("ran = %v, want nothing run on a dry run", s.ran)
+	}
+	steps := result.Plan.Steps
+	if len(steps) < 2 || steps[0] != "squash-merge 2 commit(s) of feature/login into main" || steps[1] != "commit: feat(login): login" {
+		t.Errorf("steps = %v", steps)
+	}
+}
+
+func TestMergeWorktreeSquashNothingToSquash(t *testing.T) {
+	s := &squashRunner{}
+	_, err := MergeWorktree(context.Background(), s.runner(), MergeParams{
+		SourceTask: "login", RepoRoot: "/repo", MainBranch: branchMain, Squash: true,
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "nothing to squash") {
+		t.Fatalf("err = %v, want nothing to squash", err)
+	}
+	if len(s.ran) != 0 {
+		t.Errorf("ran = %v, want nothing run", s.ran)
+	}
+}
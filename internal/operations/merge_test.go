@@ -168,6 +168,57 @@ func TestMergeWorktreeCustomPrefix(t *testing.T) {
 	}
 }
 
+func TestMergeWorktreeMergeToBaseBranch(t *testing.T) {
+	list := porcelainEntries(
+		struct{ path, branch string }{"/repo", "main"},
+		struct{ path, branch string }{"/wt/release", "release/current"},
+		struct{ path, branch string }{"/wt/hotfix-crash", "hotfix/crash"},
+	)
+	var mergeDir string
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == gitCmdWorktree {
+				return list, nil
+			}
+			return "", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			if len(args) >= 1 && args[0] == gitCmdMerge {
+				mergeDir = dir
+			}
+			return "", nil
+		},
+	}
+
+	source := &resolver.WorktreeInfo{Path: "/wt/hotfix-crash", Branch: "hotfix/crash"}
+	result, err := MergeWorktree(context.Background(), r, MergeParams{
+		Source:     source,
+		SourceTask: "crash",
+		RepoRoot:   "/repo",
+		MainBranch: "main",
+		Base:       "release/current",
+		Keep:       true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mergeDir != "/wt/release" || result.TargetLabel != "release/current" || !result.MergingToMain {
+		t.Errorf("merged in %q, label %q, toMain %v; want /wt/release, release/current, true",
+			mergeDir, result.TargetLabel, result.MergingToMain)
+	}
+
+	_, err = MergeWorktree(context.Background(), r, MergeParams{
+		Source:     source,
+		SourceTask: "crash",
+		RepoRoot:   "/repo",
+		MainBranch: "main",
+		Base:       "release/next",
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), `base branch "release/next"`) {
+		t.Errorf("err = %v, want base branch not checked out", err)
+	}
+}
+
 func TestMergeWorktreeMergeToMainKeep(t *testing.T) {
 	r := mergeRunner(nil)
 
@@ -0,0 +1,65 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/deps"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/progress"
+)
+
+// MergeVerify is the outcome of running [merge] verify against a merge
+// result before it landed.
+type MergeVerify struct {
+	Commands []string
+	Passed   bool
+	Failure  string // the failed command and its error, when !Passed
+}
+
+// verifyMerge merges result.SourceBranch into the target's HEAD in a
+// temporary integration worktree and runs params.Verify there, so a merge
+// that conflicts or fails verification is refused before the worktree at
+// targetDir is touched. It is a no-op without verify commands.
+func verifyMerge(ctx context.Context, r git.Runner, plan *Plan, params MergeParams, targetDir string, result *MergeResult, onProgress progress.Func) error {
+	if len(params.Verify) == 0 {
+		return nil
+	}
+	result.Verify = &MergeVerify{Commands: params.Verify}
+	desc := "verify merge in a scratch worktree: " + strings.Join(params.Verify, "; ")
+	return plan.Do(desc, func() error {
+		progress.Notify(onProgress, "Verifying merge...")
+		head, err := git.HeadSHA(ctx, r, targetDir)
+		if err != nil {
+			return err
+		}
+		dir, cleanup, err := addIntegrationWorktree(ctx, r, head)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		// A squash lands the same tree as a merge, so both verify a merge.
+		if _, err := mergeResolvingLockfiles(ctx, r, dir, result.SourceBranch, params); err != nil {
+			return errhint.WithFix(
+				fmt.Errorf("merge failed: %s", queueMergeFailure(ctx, r, dir, result.TargetLabel, err)),
+				fmt.Sprintf("target %s unchanged; reconcile %s, then re-run rimba merge", result.TargetLabel, result.SourceBranch),
+			)
+		}
+		verifyErr := firstVerifyFailure(deps.RunPostCreateHooks(ctx, dir, params.Verify, onProgress))
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if verifyErr != nil {
+			result.Verify.Failure = verifyErr.Error()
+			return errhint.WithFix(
+				fmt.Errorf("merge verification failed: %w", verifyErr),
+				fmt.Sprintf("target %s unchanged; fix %s, then re-run rimba merge (or skip verification with --no-verify)", result.TargetLabel, result.SourceBranch),
+			)
+		}
+		result.Verify.Passed = true
+		return nil
+	})
+}
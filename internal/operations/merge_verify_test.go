@@ -0,0 +1,91 @@
+package operations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+func verifyMergeParams(repo string, verify ...string) MergeParams {
+	return MergeParams{
+		Source:     &resolver.WorktreeInfo{Branch: branchQueueA, Path: repo},
+		RepoRoot:   repo,
+		MainBranch: branchMain,
+		NoFF:       true,
+		Keep:       true,
+		Verify:     verify,
+	}
+}
+
+func TestMergeWorktreeVerifyPasses(t *testing.T) {
+	repo, r := queueRepo(t)
+	result, err := MergeWorktree(context.Background(), r, verifyMergeParams(repo, "test -f a.txt"), nil)
+	if err != nil {
+		t.Fatalf("MergeWorktree: %v", err)
+	}
+	if result.Verify == nil || !result.Verify.Passed {
+		t.Errorf("Verify = %+v, want passed", result.Verify)
+	}
+	if len(result.Plan.Steps) != 2 || !strings.HasPrefix(result.Plan.Steps[0], "verify merge in a scratch worktree: test -f a.txt") {
+		t.Errorf("steps = %v, want the verify step before the merge", result.Plan.Steps)
+	}
+	if got := headSubject(t, repo); got != "Merge branch 'feature/a'" {
+		t.Errorf("main's tip = %q, want the merge of feature/a", got)
+	}
+	if out := testutil.GitCmd(t, repo, "worktree", "list"); strings.Count(out, "\n") != 1 {
+		t.Errorf("scratch worktree left behind:\n%s", out)
+	}
+}
+
+func TestMergeWorktreeVerifyFailsLeavesTarget(t *testing.T) {
+	repo, r := queueRepo(t)
+	before := testutil.GitCmd(t, repo, "rev-parse", branchMain)
+
+	result, err := MergeWorktree(context.Background(), r, verifyMergeParams(repo, "true", "test ! -f a.txt"), nil)
+	if err == nil || !strings.Contains(err.Error(), `verify "test ! -f a.txt" failed`) {
+		t.Fatalf("err = %v, want the failed verify command", err)
+	}
+	if result.Verify == nil || result.Verify.Passed || !strings.Contains(result.Verify.Failure, "test ! -f a.txt") {
+		t.Errorf("Verify = %+v, want the failure recorded", result.Verify)
+	}
+	if after := testutil.GitCmd(t, repo, "rev-parse", branchMain); after != before {
+		t.Errorf("main moved from %s to %s on a failed verify", before, after)
+	}
+	if out := testutil.GitCmd(t, repo, "worktree", "list"); strings.Count(out, "\n") != 1 {
+		t.Errorf("scratch worktree left behind:\n%s", out)
+	}
+}
+
+func TestMergeWorktreeVerifyRefusesConflict(t *testing.T) {
+	repo, r := queueRepo(t)
+	testutil.CreateFile(t, repo, "a.txt", "main's a\n")
+	testutil.GitCmd(t, repo, "add", "a.txt")
+	testutil.GitCmd(t, repo, "commit", "-m", "a on main")
+
+	_, err := MergeWorktree(context.Background(), r, verifyMergeParams(repo, "true"), nil)
+	if err == nil || !strings.Contains(err.Error(), "conflicts with main: a.txt") {
+		t.Fatalf("err = %v, want the conflict", err)
+	}
+	if got := headSubject(t, repo); got != "a on main" {
+		t.Errorf("main's tip = %q, want it untouched", got)
+	}
+}
+
+func TestMergeWorktreeVerifyDryRun(t *testing.T) {
+	repo, r := queueRepo(t)
+	params := verifyMergeParams(repo, "false")
+	params.DryRun = true
+	result, err := MergeWorktree(context.Background(), r, params, nil)
+	if err != nil {
+		t.Fatalf("MergeWorktree: %v", err)
+	}
+	if len(result.Plan.Steps) == 0 || result.Plan.Steps[0] != "verify merge in a scratch worktree: false" {
+		t.Errorf("steps = %v, want the verify step recorded", result.Plan.Steps)
+	}
+	if result.Verify == nil || result.Verify.Passed {
+		t.Errorf("Verify = %+v, want not run", result.Verify)
+	}
+}
@@ -0,0 +1,115 @@
+package operations
+
+import (
+	"context"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// LoadMeta loads the worktree metadata store from the repository's common dir.
+func LoadMeta(ctx context.Context, r git.Runner) (*meta.Store, error) {
+	s, _, err := loadMeta(ctx, r)
+	return s, err
+}
+
+// UpdateMeta applies fn to branch's metadata and saves the store, returning
+// the updated metadata.
+func UpdateMeta(ctx context.Context, r git.Runner, branch string, fn func(m *meta.Meta)) (meta.Meta, error) {
+	s, commonDir, err := loadMeta(ctx, r)
+	if err != nil {
+		return meta.Meta{}, err
+	}
+	s.Update(branch, fn)
+	if err := s.Save(commonDir); err != nil {
+		return meta.Meta{}, err
+	}
+	return s.Get(branch), nil
+}
+
+// SelectByMeta keeps the items whose branch's metadata matches f. An empty
+// filter returns items unchanged without touching the store.
+func SelectByMeta[T any](ctx context.Context, r git.Runner, items []T, branch func(T) string, f meta.Filter) ([]T, error) {
+	if f.IsEmpty() {
+		return items, nil
+	}
+	s, err := LoadMeta(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	var kept []T
+	for _, it := range items {
+		if f.Match(s.Get(branch(it))) {
+			kept = append(kept, it)
+		}
+	}
+	return kept, nil
+}
+
+// WorktreeBranch returns wt's branch; the key SelectByMeta needs for worktrees.
+func WorktreeBranch(wt resolver.WorktreeInfo) string {
+	return wt.Branch
+}
+
+// attachMeta fills each row's Meta from s; a nil store leaves them zero.
+func attachMeta(rows []resolver.WorktreeDetail, s *meta.Store) {
+	for i := range rows {
+		rows[i].Meta = s.Get(rows[i].Branch)
+	}
+}
+
+// recordCreated stamps a new worktree's branch with who created it and
+// when, and the base it tracks when that is not the configured one. The
+// stamp alone is optional — a worktree without it is still valid — but a
+// base is not: sync and merge would fall back to the configured base, so
+// callers recording one must fail on the error.
+func recordCreated(ctx context.Context, r git.Runner, branch, base string) error {
+	by, _ := git.UserName(ctx, r)
+	_, err := UpdateMeta(ctx, r, branch, func(m *meta.Meta) {
+		m.CreatedAt = time.Now().UTC().Truncate(time.Second)
+		m.CreatedBy = by
+		m.Base = base
+	})
+	return err
+}
+
+// forgetMeta drops a deleted branch's metadata. Best-effort.
+func forgetMeta(ctx context.Context, r git.Runner, branch string) {
+	s, commonDir, err := loadMeta(ctx, r)
+	if err != nil {
+		return
+	}
+	if _, ok := s.Worktrees[branch]; !ok {
+		return
+	}
+	s.Delete(branch)
+	_ = s.Save(commonDir)
+}
+
+// renameMeta carries metadata across a branch rename. Best-effort.
+func renameMeta(ctx context.Context, r git.Runner, oldBranch, newBranch string) {
+	s, commonDir, err := loadMeta(ctx, r)
+	if err != nil {
+		return
+	}
+	if _, ok := s.Worktrees[oldBranch]; !ok {
+		return
+	}
+	s.Rename(oldBranch, newBranch)
+	_ = s.Save(commonDir)
+}
+
+// loadMeta loads the metadata store along with the common dir it lives under.
+func loadMeta(ctx context.Context, r git.Runner) (s *meta.Store, commonDir string, err error) {
+	commonDir, err = stateDir(ctx, r)
+	if err != nil {
+		return nil, "", err
+	}
+	s, err = meta.Load(commonDir)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, commonDir, nil
+}
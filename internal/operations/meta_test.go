@@ -0,0 +1,128 @@
+package operations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+const tagSpike = "spike"
+
+// metaRunner wraps threeWorktreeRunner with a common dir holding the
+// metadata store and a configured git user.
+func metaRunner(commonDir string) *mockRunner {
+	base := threeWorktreeRunner()
+	run := base.run
+	base.run = func(args ...string) (string, error) {
+		if len(args) >= 2 && args[0] == cmdRevParse && args[1] == "--git-common-dir" {
+			return commonDir, nil
+		}
+		if len(args) == 2 && args[0] == "config" && args[1] == "user.name" {
+			return "Dana", nil
+		}
+		return run(args...)
+	}
+	return base
+}
+
+func seedMeta(t *testing.T, commonDir string, worktrees map[string]meta.Meta) {
+	t.Helper()
+	if err := (&meta.Store{Worktrees: worktrees}).Save(commonDir); err != nil {
+		t.Fatalf("seed meta: %v", err)
+	}
+}
+
+func TestUpdateMetaPersists(t *testing.T) {
+	commonDir := t.TempDir()
+	r := metaRunner(commonDir)
+
+	m, err := UpdateMeta(context.Background(), r, branchFeatureAuth, func(m *meta.Meta) { m.AddTags(tagSpike) })
+	if err != nil {
+		t.Fatalf("UpdateMeta: %v", err)
+	}
+	if !m.HasTag(tagSpike) {
+		t.Errorf("returned meta = %+v", m)
+	}
+	s, _ := meta.Load(commonDir)
+	if got := s.Get(branchFeatureAuth); !got.HasTag(tagSpike) {
+		t.Errorf("stored meta = %+v", got)
+	}
+}
+
+func TestSelectByMetaKeepsMatches(t *testing.T) {
+	commonDir := t.TempDir()
+	seedMeta(t, commonDir, map[string]meta.Meta{branchBugfixLogin: {Tags: []string{tagSpike}}})
+	wts := []resolver.WorktreeInfo{{Branch: branchFeatureAuth}, {Branch: branchBugfixLogin}}
+
+	got, err := SelectByMeta(context.Background(), metaRunner(commonDir), wts, WorktreeBranch, meta.Filter{Tag: tagSpike})
+	if err != nil {
+		t.Fatalf("SelectByMeta: %v", err)
+	}
+	if len(got) != 1 || got[0].Branch != branchBugfixLogin {
+		t.Errorf("got %+v, want only %s", got, branchBugfixLogin)
+	}
+}
+
+func TestSelectByMetaEmptyFilterSkipsStore(t *testing.T) {
+	wts := []resolver.WorktreeInfo{{Branch: branchFeatureAuth}}
+	// No common dir: an empty filter must not need the store.
+	got, err := SelectByMeta(context.Background(), threeWorktreeRunner(), wts, WorktreeBranch, meta.Filter{})
+	if err != nil || len(got) != 1 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+}
+
+func TestRecordCreatedStampsUser(t *testing.T) {
+	commonDir := t.TempDir()
+	recordCreated(context.Background(), metaRunner(commonDir), branchFeatureAuth, "")
+
+	s, _ := meta.Load(commonDir)
+	m := s.Get(branchFeatureAuth)
+	if m.CreatedBy != "Dana" || m.CreatedAt.IsZero() {
+		t.Errorf("meta = %+v, want created by Dana with a timestamp", m)
+	}
+}
+
+func TestForgetAndRenameMeta(t *testing.T) {
+	commonDir := t.TempDir()
+	seedMeta(t, commonDir, map[string]meta.Meta{
+		branchFeatureAuth: {Note: "auth"},
+		branchBugfixLogin: {Note: "login"},
+	})
+	r := metaRunner(commonDir)
+
+	forgetMeta(context.Background(), r, branchFeatureAuth)
+	renameMeta(context.Background(), r, branchBugfixLogin, branchChoreLogout)
+
+	s, _ := meta.Load(commonDir)
+	if _, ok := s.Worktrees[branchFeatureAuth]; ok {
+		t.Error("forgotten branch still has metadata")
+	}
+	if s.Get(branchChoreLogout).Note != "login" {
+		t.Errorf("renamed metadata = %+v", s.Worktrees)
+	}
+}
+
+func TestListWorktreesFiltersAndAttachesMeta(t *testing.T) {
+	commonDir := t.TempDir()
+	seedMeta(t, commonDir, map[string]meta.Meta{
+		branchBugfixLogin: {Owner: "dana", Note: "flaky login"},
+		branchChoreLogout: {Owner: "lee"},
+	})
+
+	res, err := ListWorktrees(context.Background(), metaRunner(commonDir), nil, ListWorktreesRequest{
+		WorktreeDir: wtDirTest,
+		Meta:        meta.Filter{Owner: "dana"},
+	})
+	if err != nil {
+		t.Fatalf("ListWorktrees: %v", err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0].Branch != branchBugfixLogin {
+		t.Fatalf("rows = %+v, want only %s", res.Rows, branchBugfixLogin)
+	}
+	if res.Rows[0].Meta.Note != "flaky login" {
+		t.Errorf("Meta = %+v, want the stored note attached", res.Rows[0].Meta)
+	}
+}
@@ -0,0 +1,86 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/gh"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/progress"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// PRRefPrefix is the rimba-private namespace open PRs' heads are fetched
+// into, as refs/rimba/prs/<number>.
+const PRRefPrefix = "refs/rimba/prs/"
+
+// OpenPRBranches are the open PRs fetched for a conflict check.
+type OpenPRBranches struct {
+	Branches []resolver.WorktreeInfo // one per PR, Branch set to its fetched ref
+	PRs      map[string]conflict.PR  // keyed by ref
+}
+
+// FetchOpenPRs lists the open PRs against base and fetches their heads from
+// origin into PRRefPrefix, so they can be checked for conflicts like local
+// branches. PRs whose head is one of the local branches are skipped — the
+// worktree stands for them — and refs of PRs no longer open are deleted.
+func FetchOpenPRs(ctx context.Context, gitR git.Runner, ghR gh.Runner, base string, local []resolver.WorktreeInfo, onProgress progress.Func) (OpenPRBranches, error) {
+	if err := gh.CheckAuth(ctx, ghR); err != nil {
+		return OpenPRBranches{}, err
+	}
+
+	progress.Notify(onProgress, "Listing open PRs...")
+	open, err := gh.ListOpenPRs(ctx, ghR, base)
+	if err != nil {
+		return OpenPRBranches{}, err
+	}
+
+	result := OpenPRBranches{PRs: make(map[string]conflict.PR)}
+	var refspecs []string
+	for _, pr := range open {
+		if !pr.IsCrossRepository && slices.ContainsFunc(local, func(wt resolver.WorktreeInfo) bool { return wt.Branch == pr.HeadRefName }) {
+			continue
+		}
+		ref := PRRefPrefix + strconv.Itoa(pr.Number)
+		refspecs = append(refspecs, fmt.Sprintf("+refs/pull/%d/head:%s", pr.Number, ref))
+		result.Branches = append(result.Branches, resolver.WorktreeInfo{Branch: ref})
+		result.PRs[ref] = conflict.PR{Ref: ref, Number: pr.Number, Author: pr.Author}
+	}
+
+	if err := prunePRRefs(ctx, gitR, result.PRs); err != nil {
+		return OpenPRBranches{}, err
+	}
+	if len(refspecs) == 0 {
+		return result, nil
+	}
+
+	progress.Notify(onProgress, fmt.Sprintf("Fetching %d open PR(s)...", len(refspecs)))
+	if err := git.FetchRefspecs(ctx, gitR, git.DefaultRemote, refspecs...); err != nil {
+		return OpenPRBranches{}, errhint.WithFix(
+			fmt.Errorf("fetch open PR heads: %w", err),
+			"check network access and that origin is the GitHub repository the PRs are opened against",
+		)
+	}
+	return result, nil
+}
+
+// prunePRRefs deletes the refs under PRRefPrefix of PRs not in open.
+func prunePRRefs(ctx context.Context, r git.Runner, open map[string]conflict.PR) error {
+	refs, err := git.ListRefs(ctx, r, PRRefPrefix)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if _, ok := open[ref]; ok {
+			continue
+		}
+		if err := git.DeleteRef(ctx, r, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/conflict"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+const openPRsJSON = `[
+	{"number":12,"author":{"login":"alice"},"headRefName":"feature/search","isCrossRepository":false},
+	{"number":9,"author":{"login":"me"},"headRefName":"feature/login","isCrossRepository":false}
+]`
+
+func TestFetchOpenPRs(t *testing.T) {
+	withFakeGhOnPath(t)
+	var fetched, deleted []string
+	gitR := &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch args[0] {
+			case "for-each-ref":
+				return "refs/rimba/prs/12\nrefs/rimba/prs/3", nil
+			case "update-ref":
+				deleted = append(deleted, args[2])
+			case gitCmdFetch:
+				fetched = args
+			}
+			return "", nil
+		},
+		runInDir: noopRunInDir,
+	}
+	local := []resolver.WorktreeInfo{{Path: pathWtFeatureLogin, Branch: branchFeature}}
+
+	got, err := FetchOpenPRs(context.Background(), gitR, newGhAuthOK(openPRsJSON), branchMain, local, nil)
+	if err != nil {
+		t.Fatalf("FetchOpenPRs: %v", err)
+	}
+
+	const ref = "refs/rimba/prs/12"
+	if want := []resolver.WorktreeInfo{{Branch: ref}}; !reflect.DeepEqual(got.Branches, want) {
+		t.Errorf("Branches = %+v, want %+v (PR #9 is the local %s)", got.Branches, want, branchFeature)
+	}
+	if want := (conflict.PR{Ref: ref, Number: 12, Author: "alice"}); got.PRs[ref] != want {
+		t.Errorf("PRs[%s] = %+v, want %+v", ref, got.PRs[ref], want)
+	}
+	if want := []string{gitCmdFetch, "--no-tags", "origin", "+refs/pull/12/head:" + ref}; !slices.Equal(fetched, want) {
+		t.Errorf("fetch args = %v, want %v", fetched, want)
+	}
+	if want := []string{"refs/rimba/prs/3"}; !slices.Equal(deleted, want) {
+		t.Errorf("deleted refs = %v, want %v (PR #3 is no longer open)", deleted, want)
+	}
+}
+
+func TestFetchOpenPRsNoneOpen(t *testing.T) {
+	withFakeGhOnPath(t)
+	gitR := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == gitCmdFetch {
+				t.Errorf("unexpected fetch with no open PRs: %v", args)
+			}
+			return "", nil
+		},
+		runInDir: noopRunInDir,
+	}
+
+	got, err := FetchOpenPRs(context.Background(), gitR, newGhAuthOK("[]"), branchMain, nil, nil)
+	if err != nil {
+		t.Fatalf("FetchOpenPRs: %v", err)
+	}
+	if len(got.Branches) != 0 || len(got.PRs) != 0 {
+		t.Errorf("FetchOpenPRs = %+v, want none", got)
+	}
+}
+
+func TestFetchOpenPRsFetchFails(t *testing.T) {
+	withFakeGhOnPath(t)
+	gitR := &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] == gitCmdFetch {
+				return "", errGitFailed
+			}
+			return "", nil
+		},
+		runInDir: noopRunInDir,
+	}
+
+	_, err := FetchOpenPRs(context.Background(), gitR, newGhAuthOK(openPRsJSON), branchMain, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "fetch open PR heads") {
+		t.Fatalf("err = %v, want a fetch open PR heads error", err)
+	}
+}
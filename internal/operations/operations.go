@@ -3,6 +3,7 @@ package operations
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/lugassawan/rimba/internal/config"
@@ -121,3 +122,16 @@ func ambiguityError(task string, matches []resolver.WorktreeInfo) error {
 	return fmt.Errorf("multiple worktrees match %q:\n%s\nSpecify the service: rimba <command> <service>/%s",
 		task, strings.Join(branches, "\n"), task)
 }
+
+// stateDir returns the git common dir that rimba's per-repo state files
+// (stacks, metadata) live under, shared by every worktree of the repo.
+func stateDir(ctx context.Context, r git.Runner) (string, error) {
+	commonDir, err := git.CommonDir(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(commonDir) {
+		return "", fmt.Errorf("git common dir %q is not absolute", commonDir)
+	}
+	return commonDir, nil
+}
@@ -28,6 +28,10 @@ type PostCreateParams struct {
 	PostCreate    []string // hook commands
 	SourcePath    string   // if non-empty, prefer copying deps from this worktree
 	Concurrency   int      // max parallel module installs; 0 = Manager default
+	// Txn, when set, runs each phase as a transaction step; a failed
+	// dependency install or hook then fails the step instead of only
+	// being reported.
+	Txn *Txn
 }
 
 // PostCreateResult holds the outcome of the post-create setup sequence.
@@ -47,11 +51,55 @@ func PostCreateSetup(ctx context.Context, r git.Runner, params PostCreateParams,
 
 	// Copy files
 	progress.Notify(onProgress, "Copying files...")
-	stop := rec.StartSpan("copy")
+	err := params.Txn.Do(ctx, StepCopyFiles, func() error {
+		stop := rec.StartSpan("copy")
+		defer stop()
+		return copyPostCreateFiles(params, &result)
+	}, nil)
+	if err != nil {
+		return result, err
+	}
+
+	// Dependencies
+	if !params.SkipDeps {
+		err := params.Txn.Do(ctx, StepInstallDeps, func() error {
+			stop := rec.StartSpan("deps")
+			defer stop()
+			progress.Notify(onProgress, "Installing dependencies...")
+			return installPostCreateDeps(ctx, r, params, &result, onProgress)
+		}, nil)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// Post-create hooks
+	if !params.SkipHooks && len(params.PostCreate) > 0 {
+		err := params.Txn.Do(ctx, StepRunHooks, func() error {
+			stop := rec.StartSpan("hooks")
+			defer stop()
+			progress.Notify(onProgress, "Running hooks...")
+			result.HookResults = RunPostCreateHooks(ctx, params.WtPath, params.PostCreate, onProgress)
+			if params.Txn == nil {
+				return nil
+			}
+			return firstHookFailure(result.HookResults)
+		}, nil)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func copyPostCreateFiles(params PostCreateParams, result *PostCreateResult) error {
 	copied, skippedSymlinks, err := fileutil.CopyEntries(params.RepoRoot, params.WtPath, params.CopyFiles)
-	stop()
 	if err != nil {
-		return result, errhint.WithFix(
+		if params.Txn.RollsBack() {
+			return fmt.Errorf("failed to copy files: %w", err)
+		}
+		return errhint.WithFix(
 			fmt.Errorf("failed to copy files: %w\nTo retry, manually copy files to: %s", err, params.WtPath),
 			"rimba remove "+params.Task,
 		)
@@ -59,43 +107,60 @@ func PostCreateSetup(ctx context.Context, r git.Runner, params PostCreateParams,
 	result.Copied = copied
 	result.Skipped = fileutil.SkippedEntries(params.CopyFiles, copied)
 	result.SkippedSymlinks = skippedSymlinks
+	return nil
+}
 
-	// Dependencies
-	if !params.SkipDeps {
-		stop := rec.StartSpan("deps")
-		progress.Notify(onProgress, "Installing dependencies...")
-		wtEntries, err := git.ListWorktrees(ctx, r)
-		if err != nil {
-			stop()
-			return result, errhint.WithFix(
-				fmt.Errorf("failed to list worktrees for dependency setup: %w", err),
-				"rimba remove "+params.Task,
-			)
+func installPostCreateDeps(ctx context.Context, r git.Runner, params PostCreateParams, result *PostCreateResult, onProgress progress.Func) error {
+	wtEntries, err := git.ListWorktrees(ctx, r)
+	if err != nil {
+		err = fmt.Errorf("failed to list worktrees for dependency setup: %w", err)
+		if params.Txn.RollsBack() {
+			return err
 		}
+		return errhint.WithFix(err, "rimba remove "+params.Task)
+	}
 
-		dp := DepsParams{
-			WtPath:        params.WtPath,
-			Service:       params.Service,
-			AutoDetect:    params.AutoDetect,
-			ConfigModules: params.ConfigModules,
-			Entries:       wtEntries,
-			Concurrency:   params.Concurrency,
-		}
-		if params.SourcePath != "" {
-			result.DepsResults = InstallDepsPreferSource(ctx, r, params.SourcePath, dp, onProgress)
-		} else {
-			result.DepsResults = InstallDeps(ctx, r, dp, onProgress)
-		}
-		stop()
+	dp := DepsParams{
+		WtPath:        params.WtPath,
+		Service:       params.Service,
+		AutoDetect:    params.AutoDetect,
+		ConfigModules: params.ConfigModules,
+		Entries:       wtEntries,
+		Concurrency:   params.Concurrency,
+	}
+	if params.SourcePath != "" {
+		result.DepsResults = InstallDepsPreferSource(ctx, r, params.SourcePath, dp, onProgress)
+	} else {
+		result.DepsResults = InstallDeps(ctx, r, dp, onProgress)
+	}
+	if params.Txn == nil {
+		return nil
 	}
+	return firstInstallFailure(result.DepsResults)
+}
 
-	// Post-create hooks
-	if !params.SkipHooks && len(params.PostCreate) > 0 {
-		stop := rec.StartSpan("hooks")
-		progress.Notify(onProgress, "Running hooks...")
-		result.HookResults = RunPostCreateHooks(ctx, params.WtPath, params.PostCreate, onProgress)
-		stop()
+// firstInstallFailure returns the first failed module install, if any.
+func firstInstallFailure(results []deps.InstallResult) error {
+	for _, res := range results {
+		if res.Error != nil {
+			return errhint.WithFix(
+				fmt.Errorf("dependency install failed for %s: %w", res.Module.Dir, res.Error),
+				"fix the install, or retry with --skip-deps and run: rimba deps install <task>",
+			)
+		}
 	}
+	return nil
+}
 
-	return result, nil
+// firstHookFailure returns the first failed post-create hook, if any.
+func firstHookFailure(results []deps.HookResult) error {
+	for _, res := range results {
+		if res.Error != nil {
+			return errhint.WithFix(
+				fmt.Errorf("post-create hook %q failed: %w", res.Command, res.Error),
+				"fix the hook, or retry with --skip-hooks",
+			)
+		}
+	}
+	return nil
 }
@@ -30,6 +30,10 @@ func RemoveWorktree(ctx context.Context, r git.Runner, wt resolver.WorktreeInfo,
 		Path:   wt.Path,
 	}
 
+	rec := newRecorder(ctx, r, "remove")
+	defer rec.commit()
+	step := rec.captureRemoval(ctx, r, wt.Path, wt.Branch, !keepBranch)
+
 	progress.Notify(onProgress, "Removing worktree...")
 	defer deferSweepManifest(ctx, r, []string{wt.Path})()
 	leftOnDisk, err := removeWorktreeEntry(ctx, r, wt.Path, force, wt.Prunable)
@@ -43,10 +47,14 @@ func RemoveWorktree(ctx context.Context, r git.Runner, wt resolver.WorktreeInfo,
 		progress.Notify(onProgress, "Deleting branch...")
 		if err := git.DeleteBranch(ctx, r, wt.Branch, true); err != nil {
 			result.BranchError = branchDeleteFailedErr(wt.Branch, err)
+			rec.removed(step, false)
 			return result, nil
 		}
 		result.BranchDeleted = true
+		unlinkStacked(ctx, r, wt.Branch, false)
+		forgetMeta(ctx, r, wt.Branch)
 	}
+	rec.removed(step, result.BranchDeleted)
 
 	return result, nil
 }
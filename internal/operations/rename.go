@@ -104,12 +104,18 @@ func RenameWorktree(ctx context.Context, r git.Runner, p RenameParams) (RenameRe
 		)
 	}
 
+	renameStacked(ctx, r, p.WT.Branch, newBranch)
+	renameMeta(ctx, r, p.WT.Branch, newBranch)
+
 	result := RenameResult{
 		OldBranch: p.WT.Branch,
 		NewBranch: newBranch,
 		OldPath:   p.WT.Path,
 		NewPath:   newPath,
 	}
+	rec := newRecorder(ctx, r, "rename")
+	rec.renamed(result)
+	rec.commit()
 
 	if p.Push {
 		publishRenamed(ctx, r, newBranch, newPath, p.WT.Branch, hadOriginUpstream, &result)
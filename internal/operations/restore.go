@@ -0,0 +1,132 @@
+package operations
+
+import (
+	"context"
+
+	"github.com/lugassawan/rimba/internal/bundle"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/progress"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// RestoreParams holds the inputs for restoring an archived worktree.
+type RestoreParams struct {
+	Task    string
+	Service string
+	Branch  string
+	// Bundle, when set, is a file written by `rimba archive --bundle`;
+	// the task is imported from it first and Task, Service, and Branch
+	// come from its manifest.
+	Bundle string
+	PostCreateOptions
+}
+
+// RestoreResult holds the outcome of restoring an archived worktree.
+type RestoreResult struct {
+	Task     string
+	Service  string
+	Branch   string
+	Path     string
+	Imported bool // the task came from Bundle
+	// Snapshot is what came back from the archive snapshot.
+	Snapshot ArchiveSnapshot
+	PostCreateResult
+	Transaction TxnReport
+}
+
+// RestoreWorktree recreates an archived branch's worktree, reapplies the
+// work archive set aside, and runs the post-create setup, as a transaction:
+// on failure the worktree is removed again, the snapshot put back, and an
+// imported bundle forgotten, unless KeepOnFailure is set.
+func RestoreWorktree(ctx context.Context, r git.Runner, p RestoreParams, onProgress progress.Func) (RestoreResult, error) {
+	result := RestoreResult{Task: p.Task, Service: p.Service, Branch: p.Branch}
+	txn := &Txn{Keep: p.KeepOnFailure}
+	fail := func(err error) (RestoreResult, error) {
+		err = txn.Rollback(err)
+		result.Transaction = txn.Report()
+		return result, err
+	}
+
+	if p.Bundle != "" {
+		progress.Notify(onProgress, "Importing bundle...")
+		var m bundle.Manifest
+		err := txn.Do(ctx, StepImportBundle, func() error {
+			var err error
+			m, err = ImportBundle(ctx, r, p.Bundle)
+			return err
+		}, func(ctx context.Context) error {
+			return forgetImported(ctx, r, m.Branch)
+		})
+		if err != nil {
+			return fail(err)
+		}
+		result.Task, result.Service, result.Branch, result.Imported = m.Task, m.Service, m.Branch, true
+	}
+	result.Path = resolver.WorktreePath(p.WorktreeDir, result.Branch)
+
+	progress.Notify(onProgress, "Restoring worktree...")
+	err := txn.Do(ctx, StepCreateWorktree, func() error {
+		return git.AddWorktreeFromBranch(ctx, r, result.Path, result.Branch)
+	}, func(ctx context.Context) error {
+		// The branch is the archive itself; only the directory goes.
+		return git.RemoveWorktree(ctx, r, result.Path, true)
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	err = txn.Do(ctx, StepRestoreSnapshot, func() error {
+		var err error
+		result.Snapshot, err = RestoreArchiveSnapshot(ctx, r, result.Branch, result.Path, p.CopyFiles)
+		return err
+	}, func(ctx context.Context) error {
+		return resnapshotWorktree(ctx, r, result.Path, result.Branch, result.Snapshot)
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	result.PostCreateResult, err = PostCreateSetup(ctx, r, PostCreateParams{
+		RepoRoot:      p.RepoRoot,
+		WtPath:        result.Path,
+		Task:          result.Task,
+		Service:       result.Service,
+		CopyFiles:     result.Snapshot.Unrestored(p.CopyFiles),
+		SkipDeps:      p.SkipDeps,
+		AutoDetect:    p.AutoDetect,
+		ConfigModules: p.ConfigModules,
+		SkipHooks:     p.SkipHooks,
+		PostCreate:    p.PostCreate,
+		Concurrency:   p.Concurrency,
+		Txn:           txn,
+	}, onProgress)
+	if err != nil {
+		return fail(err)
+	}
+	result.Transaction = txn.Report()
+	return result, nil
+}
+
+// resnapshotWorktree puts back an archive snapshot that was reapplied to the
+// worktree at wtPath, so removing the worktree again loses nothing.
+func resnapshotWorktree(ctx context.Context, r git.Runner, wtPath, branch string, snap ArchiveSnapshot) error {
+	if snap.IsEmpty() {
+		return nil
+	}
+	_, err := snapshotWorktree(ctx, r, &Plan{}, ArchiveParams{Path: wtPath, Branch: branch, CopyFiles: snap.Files})
+	return err
+}
+
+// forgetImported removes what ImportBundle added for branch: the branch, its
+// archive snapshot, and its metadata.
+func forgetImported(ctx context.Context, r git.Runner, branch string) error {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return err
+	}
+	if err := discardArchiveSnapshot(ctx, r, commonDir, branch); err != nil {
+		return err
+	}
+	forgetMeta(ctx, r, branch)
+	return git.DeleteBranch(ctx, r, branch, true)
+}
@@ -0,0 +1,107 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+// archivedParked archives parkedWorktree, leaving branchParked with a
+// snapshot, and returns the worktree dir a restore recreates it under.
+func archivedParked(t *testing.T) (r *git.ExecRunner, repo, wtDir string) {
+	t.Helper()
+	repo, wtPath := parkedWorktree(t)
+	r = &git.ExecRunner{Dir: repo}
+	if _, err := ArchiveWorktree(context.Background(), r, ArchiveParams{
+		Path:      wtPath,
+		Branch:    branchParked,
+		CopyFiles: []string{envFile},
+	}); err != nil {
+		t.Fatalf("ArchiveWorktree: %v", err)
+	}
+	return r, repo, t.TempDir()
+}
+
+func TestRestoreWorktree(t *testing.T) {
+	r, repo, wtDir := archivedParked(t)
+
+	result, err := RestoreWorktree(context.Background(), r, RestoreParams{
+		Task:   "parked",
+		Branch: branchParked,
+		PostCreateOptions: PostCreateOptions{
+			RepoRoot:    repo,
+			WorktreeDir: wtDir,
+			CopyFiles:   []string{envFile},
+			SkipDeps:    true,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("RestoreWorktree: %v", err)
+	}
+	if result.Path != resolver.WorktreePath(wtDir, branchParked) || !result.Snapshot.Stashed {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(result.Path, "scratch.txt")); err != nil {
+		t.Errorf("archived changes not reapplied: %v", err)
+	}
+	if want := []string{StepCreateWorktree, StepRestoreSnapshot, StepCopyFiles}; strings.Join(result.Transaction.Completed, ",") != strings.Join(want, ",") {
+		t.Errorf("completed = %v, want %v", result.Transaction.Completed, want)
+	}
+}
+
+func TestRestoreWorktreeRollbackKeepsArchive(t *testing.T) {
+	r, repo, wtDir := archivedParked(t)
+	ctx := context.Background()
+
+	result, err := RestoreWorktree(ctx, r, RestoreParams{
+		Task:   "parked",
+		Branch: branchParked,
+		PostCreateOptions: PostCreateOptions{
+			RepoRoot:    repo,
+			WorktreeDir: wtDir,
+			CopyFiles:   []string{envFile},
+			SkipDeps:    true,
+			PostCreate:  []string{"exit 1"},
+		},
+	}, nil)
+	if err == nil || !result.Transaction.RolledBack {
+		t.Fatalf("err = %v, transaction = %+v; want a rolled-back failure", err, result.Transaction)
+	}
+	if _, err := os.Stat(result.Path); !os.IsNotExist(err) {
+		t.Errorf("worktree should be removed on rollback, stat err = %v", err)
+	}
+	if !git.BranchExists(ctx, r, branchParked) {
+		t.Error("archived branch must survive a rolled-back restore")
+	}
+	if archiveSnapshotSHA(ctx, r, branchParked) == "" {
+		t.Fatal("archived changes should be set aside again")
+	}
+
+	// A second attempt brings everything back.
+	result, err = RestoreWorktree(ctx, r, RestoreParams{
+		Task:   "parked",
+		Branch: branchParked,
+		PostCreateOptions: PostCreateOptions{
+			RepoRoot:    repo,
+			WorktreeDir: wtDir,
+			CopyFiles:   []string{envFile},
+			SkipDeps:    true,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("RestoreWorktree retry: %v", err)
+	}
+	if env, _ := os.ReadFile(filepath.Join(result.Path, envFile)); string(env) != "TOKEN=worktree" {
+		t.Errorf(".env = %q, want the archived copy", env)
+	}
+	status := testutil.GitCmd(t, result.Path, "status", "--porcelain")
+	if !strings.Contains(status, "A  staged.txt") || !strings.Contains(status, "?? scratch.txt") {
+		t.Errorf("status after retry:\n%s", status)
+	}
+}
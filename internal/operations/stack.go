@@ -0,0 +1,331 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/progress"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/stack"
+)
+
+// RestackParams holds the inputs for restacking stacked worktrees.
+type RestackParams struct {
+	MainBranch string
+	// Root limits the restack to Root itself (when stacked) and its
+	// descendants; "" restacks every recorded stack.
+	Root      string
+	Worktrees []resolver.WorktreeInfo
+	Push      bool
+	DryRun    bool // report the planned order without rebasing
+}
+
+// LinkStackParent records child as stacked on parent at parent's current tip.
+func LinkStackParent(ctx context.Context, r git.Runner, child, parent string) error {
+	s, commonDir, err := loadStack(ctx, r)
+	if err != nil {
+		return err
+	}
+	base, err := git.ResolveRef(ctx, r, parent)
+	if err != nil {
+		return fmt.Errorf("resolve stack parent %q: %w", parent, err)
+	}
+	if err := s.Set(child, parent, base); err != nil {
+		return err
+	}
+	return s.Save(commonDir)
+}
+
+// StackParents returns child → parent for every stacked branch. Best-effort:
+// nil when the store is unavailable, so listings degrade to a flat view.
+func StackParents(ctx context.Context, r git.Runner) map[string]string {
+	s, _, ok := openStack(ctx, r)
+	if !ok {
+		return nil
+	}
+	return s.Parents()
+}
+
+// GuardUnstacked refuses to sync branch onto a base of its own when it is
+// stacked on another branch, whose tip it follows through a restack. task
+// names the worktree in the hint.
+func GuardUnstacked(ctx context.Context, r git.Runner, branch, task string) error {
+	parent := StackParents(ctx, r)[branch]
+	if parent == "" {
+		return nil
+	}
+	return errhint.WithFix(
+		fmt.Errorf("%s is stacked on %s", branch, parent),
+		"stacked worktrees follow their parent; restack it instead: rimba sync --stack "+task,
+	)
+}
+
+// RestackWorktrees rebases each stacked worktree with --onto its parent's
+// current tip (or the main branch once the parent has merged), parents
+// before children. An unstacked Root is first rebased onto the main branch.
+// A child whose parent failed or was skipped is skipped too, so a conflict
+// never cascades into a half-restacked stack.
+func RestackWorktrees(ctx context.Context, r git.Runner, p RestackParams, onProgress progress.Func) ([]SyncWorktreeResult, error) {
+	s, commonDir, err := loadStack(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	byBranch := make(map[string]resolver.WorktreeInfo, len(p.Worktrees))
+	for _, wt := range p.Worktrees {
+		byBranch[wt.Branch] = wt
+	}
+
+	order := s.Order(p.Root)
+	results := make([]SyncWorktreeResult, 0, len(order)+1)
+	blocked := make(map[string]bool)
+	if _, stacked := s.Links[p.Root]; p.Root != "" && !stacked {
+		res := syncStackRoot(ctx, r, p, byBranch[p.Root])
+		if res.Skipped || res.Failed {
+			blocked[p.Root] = true
+		}
+		results = append(results, res)
+	}
+
+	changed := false
+	for _, branch := range order {
+		link := s.Links[branch]
+		wt, found := byBranch[branch]
+		res := planRestack(branch, link, p.MainBranch, found, blocked)
+
+		if !res.Skipped && !p.DryRun {
+			progress.Notifyf(onProgress, "Restacking %s onto %s...", branch, res.Onto)
+			var newBase string
+			res, newBase = restackWorktree(ctx, r, wt, res.Onto, link.Base, p.Push)
+			if res.Synced {
+				changed = true
+				advanceLink(s, branch, link, newBase)
+			}
+		}
+
+		if res.Skipped || res.Failed {
+			blocked[branch] = true
+		}
+		results = append(results, res)
+	}
+
+	if changed {
+		if err := s.Save(commonDir); err != nil {
+			return results, errhint.WithFix(err, "re-run 'rimba sync --stack'; already-restacked worktrees are skipped as up to date")
+		}
+	}
+	return results, nil
+}
+
+// stackDetails fills each row's Parent and reorders rows so a stacked
+// worktree directly follows its parent.
+func stackDetails(rows []resolver.WorktreeDetail, parents map[string]string) []resolver.WorktreeDetail {
+	if len(parents) == 0 {
+		return rows
+	}
+	branches := make([]string, len(rows))
+	for i := range rows {
+		rows[i].Parent = parents[rows[i].Branch]
+		branches[i] = rows[i].Branch
+	}
+	order, _ := stack.Tree(branches, parents)
+	return permute(rows, order)
+}
+
+// stackStatusEntries is stackDetails for the status dashboard.
+func stackStatusEntries(entries []StatusEntry, parents map[string]string) []StatusEntry {
+	if len(parents) == 0 {
+		return entries
+	}
+	branches := make([]string, len(entries))
+	for i := range entries {
+		entries[i].Parent = parents[entries[i].Entry.Branch]
+		branches[i] = entries[i].Entry.Branch
+	}
+	order, _ := stack.Tree(branches, parents)
+	return permute(entries, order)
+}
+
+// permute returns items rearranged into the given index order.
+func permute[T any](items []T, order []int) []T {
+	out := make([]T, 0, len(order))
+	for _, i := range order {
+		out = append(out, items[i])
+	}
+	return out
+}
+
+// syncStackRoot rebases the bottom of a stack onto the main branch, as a
+// plain `rimba sync` would, before its descendants are restacked on it.
+func syncStackRoot(ctx context.Context, r git.Runner, p RestackParams, wt resolver.WorktreeInfo) SyncWorktreeResult {
+	if p.DryRun {
+		return SyncWorktreeResult{Branch: wt.Branch, Onto: p.MainBranch}
+	}
+	res := SyncWorktree(ctx, r, p.MainBranch, wt, false, p.Push)
+	res.Onto = p.MainBranch
+	return res
+}
+
+// planRestack resolves branch's restack target and reports up front why it
+// must be skipped: a blocked parent or a missing worktree.
+func planRestack(branch string, link stack.Link, mainBranch string, found bool, blocked map[string]bool) SyncWorktreeResult {
+	res := SyncWorktreeResult{Branch: branch, Onto: link.Parent}
+	if res.Onto == "" {
+		res.Onto = mainBranch
+	}
+	switch {
+	case blocked[link.Parent]:
+		res.Skipped, res.SkipReason = true, fmt.Sprintf("parent %s was not restacked", link.Parent)
+	case !found:
+		res.Skipped, res.SkipReason = true, "no worktree (archived?)"
+	}
+	return res
+}
+
+// advanceLink records newBase as branch's stack base; a child whose parent
+// has merged leaves the store once it sits on the main branch.
+func advanceLink(s *stack.Store, branch string, link stack.Link, newBase string) {
+	if link.Parent == "" {
+		delete(s.Links, branch)
+		return
+	}
+	s.Links[branch] = stack.Link{Parent: link.Parent, Base: newBase}
+}
+
+// restackWorktree rebases wt's commits after upstream onto onto. upstream is
+// the parent tip wt was last stacked on; when unknown, the merge-base stands in.
+func restackWorktree(ctx context.Context, r git.Runner, wt resolver.WorktreeInfo, onto, upstream string, push bool) (res SyncWorktreeResult, newBase string) {
+	res = SyncWorktreeResult{Branch: wt.Branch, Onto: onto}
+
+	dirty, err := git.IsDirty(ctx, r, wt.Path)
+	if err != nil {
+		res.Skipped, res.SkipReason = true, fmt.Sprintf("could not check status: %v", err)
+		return res, ""
+	}
+	if dirty {
+		res.Skipped, res.SkipReason = true, "dirty"
+		return res, ""
+	}
+
+	tip, err := git.ResolveRef(ctx, r, onto)
+	if err != nil {
+		res.Skipped, res.SkipReason = true, fmt.Sprintf("could not resolve %s: %v", onto, err)
+		return res, ""
+	}
+	if upstream == "" {
+		if upstream, err = git.MergeBase(ctx, r, onto, wt.Branch); err != nil {
+			res.Skipped, res.SkipReason = true, fmt.Sprintf("no merge-base with %s: %v", onto, err)
+			return res, ""
+		}
+	}
+
+	res.Synced = true
+	if upstream == tip {
+		return res, tip // already on the parent's tip; nothing to push either
+	}
+
+	if err := git.RebaseOnto(ctx, r, wt.Path, onto, upstream); err != nil {
+		_ = git.AbortRebase(r, wt.Path)
+		res.Synced = false
+		res.Failed = true
+		res.FailureHint = fmt.Sprintf("cd %s && git rebase --onto %s %s", wt.Path, onto, upstream)
+		return res, ""
+	}
+
+	if push {
+		pushed, skipped, pushErr := PushBranch(ctx, r, wt.Path, false)
+		res.Pushed, res.PushSkipped = pushed, skipped
+		if pushErr != nil {
+			res.PushFailed, res.PushError = true, pushErr.Error()
+		}
+	}
+	return res, tip
+}
+
+// unlinkStacked drops branch from the stack store after its branch was
+// deleted; merged says whether its commits already landed. Best-effort.
+func unlinkStacked(ctx context.Context, r git.Runner, branch string, merged bool) {
+	s, commonDir, ok := openStack(ctx, r)
+	if !ok {
+		return
+	}
+	if _, stacked := s.Links[branch]; !stacked && len(s.Children(branch)) == 0 {
+		return
+	}
+	s.Remove(branch, merged)
+	_ = s.Save(commonDir)
+}
+
+// renameStacked carries stack links across a branch rename. Best-effort.
+func renameStacked(ctx context.Context, r git.Runner, oldBranch, newBranch string) {
+	s, commonDir, ok := openStack(ctx, r)
+	if !ok {
+		return
+	}
+	if _, stacked := s.Links[oldBranch]; !stacked && len(s.Children(oldBranch)) == 0 {
+		return
+	}
+	s.Rename(oldBranch, newBranch)
+	_ = s.Save(commonDir)
+}
+
+// stackLinks returns the links removing branch would rewrite: its own and
+// those of the branches stacked on it, or nil when it is not in a stack.
+// Best-effort.
+func stackLinks(commonDir, branch string) map[string]stack.Link {
+	s, err := stack.Load(commonDir)
+	if err != nil {
+		return nil
+	}
+	links := make(map[string]stack.Link)
+	if l, ok := s.Links[branch]; ok {
+		links[branch] = l
+	}
+	for _, child := range s.Children(branch) {
+		links[child] = s.Links[child]
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return links
+}
+
+// restoreStacked puts back links captured by stackLinks, skipping branches
+// deleted since. Best-effort.
+func restoreStacked(ctx context.Context, r git.Runner, links map[string]stack.Link) {
+	if len(links) == 0 {
+		return
+	}
+	s, commonDir, ok := openStack(ctx, r)
+	if !ok {
+		return
+	}
+	for branch, l := range links {
+		if git.BranchExists(ctx, r, branch) {
+			s.Links[branch] = l
+		}
+	}
+	_ = s.Save(commonDir)
+}
+
+// loadStack loads the stack store from the repository's common dir.
+func loadStack(ctx context.Context, r git.Runner) (s *stack.Store, commonDir string, err error) {
+	commonDir, err = stateDir(ctx, r)
+	if err != nil {
+		return nil, "", err
+	}
+	s, err = stack.Load(commonDir)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, commonDir, nil
+}
+
+// openStack is the best-effort form of loadStack, reporting ok=false when
+// the common dir cannot be resolved or the store is unreadable.
+func openStack(ctx context.Context, r git.Runner) (s *stack.Store, commonDir string, ok bool) {
+	s, commonDir, err := loadStack(ctx, r)
+	return s, commonDir, err == nil
+}
@@ -0,0 +1,234 @@
+package operations
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/stack"
+)
+
+const (
+	branchAuthUI    = "feature/auth-ui"
+	branchAuthTests = "feature/auth-tests"
+	pathWtAuthUI    = "/wt/feature-auth-ui"
+	pathWtAuthTests = "/wt/feature-auth-tests"
+)
+
+// stackRunner serves the git calls RestackWorktrees makes: the common dir,
+// ref tips, a clean status, and rebases (recorded as "<dir>: <args>").
+type stackRunner struct {
+	commonDir string
+	tips      map[string]string
+	failDir   string
+	calls     []string
+}
+
+func (s *stackRunner) runner() *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			if len(args) >= 2 && args[0] == cmdRevParse && args[1] == "--git-common-dir" {
+				return s.commonDir, nil
+			}
+			if len(args) == 4 && args[0] == cmdRevParse {
+				return s.tips[strings.TrimSuffix(args[3], "^{commit}")], nil
+			}
+			return "", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			if args[0] != "rebase" {
+				return "", nil
+			}
+			s.calls = append(s.calls, dir+": "+strings.Join(args, " "))
+			if dir == s.failDir && args[1] != "--abort" {
+				return "", errGitFailed
+			}
+			return "", nil
+		},
+	}
+}
+
+func seedStack(t *testing.T, commonDir string, links map[string]stack.Link) {
+	t.Helper()
+	if err := (&stack.Store{Links: links}).Save(commonDir); err != nil {
+		t.Fatalf("seed stack: %v", err)
+	}
+}
+
+func stackWorktrees() []resolver.WorktreeInfo {
+	return []resolver.WorktreeInfo{
+		{Branch: branchAuth, Path: "/wt/feature-auth"},
+		{Branch: branchAuthUI, Path: pathWtAuthUI},
+		{Branch: branchAuthTests, Path: pathWtAuthTests},
+	}
+}
+
+func TestLinkStackParentRecordsParentTip(t *testing.T) {
+	sr := &stackRunner{commonDir: t.TempDir(), tips: map[string]string{branchAuth: "sha-auth"}}
+
+	if err := LinkStackParent(context.Background(), sr.runner(), branchAuthUI, branchAuth); err != nil {
+		t.Fatalf("LinkStackParent: %v", err)
+	}
+
+	s, err := stack.Load(sr.commonDir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got, want := s.Links[branchAuthUI], (stack.Link{Parent: branchAuth, Base: "sha-auth"}); got != want {
+		t.Errorf("link = %+v, want %+v", got, want)
+	}
+}
+
+func TestGuardUnstacked(t *testing.T) {
+	sr := &stackRunner{commonDir: t.TempDir()}
+	seedStack(t, sr.commonDir, map[string]stack.Link{branchAuthUI: {Parent: branchAuth, Base: "auth-old"}})
+
+	err := GuardUnstacked(context.Background(), sr.runner(), branchAuthUI, "auth-ui")
+	if err == nil || !strings.Contains(err.Error(), "rimba sync --stack auth-ui") {
+		t.Errorf("stacked err = %v, want a restack hint", err)
+	}
+	if err := GuardUnstacked(context.Background(), sr.runner(), branchAuth, "auth"); err != nil {
+		t.Errorf("unstacked err = %v, want nil", err)
+	}
+}
+
+func TestRestackWorktreesParentsFirst(t *testing.T) {
+	sr := &stackRunner{
+		commonDir: t.TempDir(),
+		tips:      map[string]string{branchAuth: "auth-new", branchAuthUI: "ui-new"},
+	}
+	seedStack(t, sr.commonDir, map[string]stack.Link{
+		branchAuthUI:    {Parent: branchAuth, Base: "auth-old"},
+		branchAuthTests: {Parent: branchAuthUI, Base: "ui-old"},
+	})
+
+	results, err := RestackWorktrees(context.Background(), sr.runner(), RestackParams{
+		MainBranch: branchMain,
+		Root:       branchAuthUI,
+		Worktrees:  stackWorktrees(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("RestackWorktrees: %v", err)
+	}
+
+	want := []string{
+		pathWtAuthUI + ": rebase --onto=" + branchAuth + " -- auth-old",
+		pathWtAuthTests + ": rebase --onto=" + branchAuthUI + " -- ui-old",
+	}
+	if !slices.Equal(sr.calls, want) {
+		t.Errorf("calls = %v, want %v", sr.calls, want)
+	}
+	if len(results) != 2 || !results[0].Synced || !results[1].Synced {
+		t.Fatalf("results = %+v, want both synced", results)
+	}
+
+	s, _ := stack.Load(sr.commonDir)
+	if s.Links[branchAuthTests].Base != "ui-new" {
+		t.Errorf("child base = %q, want the parent's new tip", s.Links[branchAuthTests].Base)
+	}
+}
+
+func TestRestackWorktreesSkipsChildrenOfFailedParent(t *testing.T) {
+	sr := &stackRunner{
+		commonDir: t.TempDir(),
+		tips:      map[string]string{branchAuth: "auth-new", branchAuthUI: "ui-new"},
+		failDir:   pathWtAuthUI,
+	}
+	seedStack(t, sr.commonDir, map[string]stack.Link{
+		branchAuthUI:    {Parent: branchAuth, Base: "auth-old"},
+		branchAuthTests: {Parent: branchAuthUI, Base: "ui-old"},
+	})
+
+	results, err := RestackWorktrees(context.Background(), sr.runner(), RestackParams{
+		MainBranch: branchMain,
+		Worktrees:  stackWorktrees(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("RestackWorktrees: %v", err)
+	}
+
+	if !results[0].Failed || !strings.Contains(results[0].FailureHint, "git rebase --onto") {
+		t.Errorf("parent result = %+v, want failed with a rebase --onto hint", results[0])
+	}
+	if !results[1].Skipped || !strings.Contains(results[1].SkipReason, branchAuthUI) {
+		t.Errorf("child result = %+v, want skipped because of its parent", results[1])
+	}
+	if !slices.Contains(sr.calls, pathWtAuthUI+": rebase --abort") {
+		t.Errorf("calls = %v, want the failed rebase aborted", sr.calls)
+	}
+}
+
+func TestRestackWorktreesMergedParentMovesOntoMain(t *testing.T) {
+	sr := &stackRunner{commonDir: t.TempDir(), tips: map[string]string{branchMain: "main-new"}}
+	seedStack(t, sr.commonDir, map[string]stack.Link{
+		branchAuthUI: {Base: "auth-old"},
+	})
+
+	results, err := RestackWorktrees(context.Background(), sr.runner(), RestackParams{
+		MainBranch: branchMain,
+		Worktrees:  stackWorktrees(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("RestackWorktrees: %v", err)
+	}
+
+	if want := []string{pathWtAuthUI + ": rebase --onto=" + branchMain + " -- auth-old"}; !slices.Equal(sr.calls, want) {
+		t.Errorf("calls = %v, want %v", sr.calls, want)
+	}
+	if len(results) != 1 || results[0].Onto != branchMain {
+		t.Errorf("results = %+v, want one restack onto %s", results, branchMain)
+	}
+	s, _ := stack.Load(sr.commonDir)
+	if _, ok := s.Links[branchAuthUI]; ok {
+		t.Error("child should leave the store once it sits on main")
+	}
+}
+
+func TestRestackWorktreesDryRunChangesNothing(t *testing.T) {
+	sr := &stackRunner{commonDir: t.TempDir(), tips: map[string]string{branchAuth: "auth-new"}}
+	seedStack(t, sr.commonDir, map[string]stack.Link{
+		branchAuthUI: {Parent: branchAuth, Base: "auth-old"},
+	})
+
+	results, err := RestackWorktrees(context.Background(), sr.runner(), RestackParams{
+		MainBranch: branchMain,
+		Root:       branchAuth,
+		Worktrees:  stackWorktrees(),
+		DryRun:     true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("RestackWorktrees: %v", err)
+	}
+
+	if len(sr.calls) != 0 {
+		t.Errorf("dry run rebased: %v", sr.calls)
+	}
+	if len(results) != 2 || results[0].Branch != branchAuth || results[1].Onto != branchAuth {
+		t.Errorf("results = %+v, want root then child", results)
+	}
+}
+
+func TestStackDetailsOrdersChildrenUnderParent(t *testing.T) {
+	rows := []resolver.WorktreeDetail{
+		{Task: "auth", Branch: branchAuth},
+		{Task: "auth-tests", Branch: branchAuthTests},
+		{Task: "auth-ui", Branch: branchAuthUI},
+		{Task: "login", Branch: branchFeature},
+	}
+	parents := map[string]string{branchAuthUI: branchAuth, branchAuthTests: branchAuthUI}
+
+	got := stackDetails(rows, parents)
+
+	var order []string
+	for _, r := range got {
+		order = append(order, r.Task)
+	}
+	if want := []string{"auth", "auth-ui", "auth-tests", "login"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if got[2].Parent != branchAuthUI {
+		t.Errorf("Parent = %q, want %q", got[2].Parent, branchAuthUI)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/fsutil"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/parallel"
@@ -30,6 +31,11 @@ type StatusEntry struct {
 	HasTime    bool
 	SizeBytes  *int64
 	Recent7D   *int
+	Parent     string // stack parent branch, when stacked
+	// Base is the branch the worktree tracks when it is not the main
+	// branch; BaseAhead and BaseBehind count commits against it.
+	Base                  string
+	BaseAhead, BaseBehind int
 }
 
 // StatusSummary is the aggregate counts across all dashboard entries.
@@ -40,6 +46,9 @@ type StatusSummary struct {
 // StatusDashboardRequest configures a StatusDashboard call.
 type StatusDashboardRequest struct {
 	Detail bool // when true, fan-out DirSize + 7D-velocity, build Footprint, sort by size desc
+	// Config, when set, supplies prefix-type bases for worktrees without a
+	// recorded one; nil compares those against the main branch.
+	Config *config.Config
 }
 
 // StatusDashboardResult carries entries and optional disk footprint.
@@ -86,7 +95,13 @@ func StatusDashboard(ctx context.Context, gitR git.Runner, req StatusDashboardRe
 		}(mainEntry.Path)
 	}
 
-	entries := collectStatusEntries(ctx, gitR, candidates, req.Detail)
+	baseOf, _ := BaseResolver(ctx, gitR, req.Config, mainBranch) // a report: configured bases will do
+	entries := collectStatusEntries(ctx, gitR, candidates, req.Detail, func(branch string) string {
+		if base := baseOf(branch); base != mainBranch {
+			return base
+		}
+		return ""
+	})
 	mainWG.Wait()
 
 	var footprint *DiskFootprint
@@ -98,6 +113,8 @@ func StatusDashboard(ctx context.Context, gitR git.Runner, req StatusDashboardRe
 		fp := BuildDiskFootprint(sizes, mainSize, mainErr)
 		footprint = &fp
 		sortEntriesBySizeDesc(entries)
+	} else {
+		entries = stackStatusEntries(entries, StackParents(ctx, gitR))
 	}
 
 	return StatusDashboardResult{Entries: entries, Footprint: footprint}, nil
@@ -126,8 +143,9 @@ func SummarizeStatus(entries []StatusEntry, staleThreshold time.Time) StatusSumm
 // Each op gets its own withItemTimeout budget, so a slow one (e.g. a large
 // DirSize walk) can't starve the others — a candidate can now take up to
 // 4x itemQueryTimeout worst case, versus 1x with a shared budget. Per-item
-// errors leave the pointer nil (non-fatal).
-func collectStatusEntries(ctx context.Context, gitR git.Runner, candidates []git.WorktreeEntry, detail bool) []StatusEntry {
+// errors leave the pointer nil (non-fatal). A branch with a non-main base
+// (from baseOf) also gets ahead/behind counts against it.
+func collectStatusEntries(ctx context.Context, gitR git.Runner, candidates []git.WorktreeEntry, detail bool, baseOf func(branch string) string) []StatusEntry {
 	return parallel.Collect(ctx, len(candidates), 8, func(ctx context.Context, i int) StatusEntry {
 		e := candidates[i]
 
@@ -144,7 +162,12 @@ func collectStatusEntries(ctx context.Context, gitR git.Runner, candidates []git
 		}
 		cancelTime()
 
-		se := StatusEntry{Entry: e, Status: st, CommitTime: ct, HasTime: hasTime}
+		se := StatusEntry{Entry: e, Status: st, CommitTime: ct, HasTime: hasTime, Base: baseOf(e.Branch)}
+		if se.Base != "" {
+			baseCtx, cancelBase := withItemTimeout(ctx)
+			se.BaseAhead, se.BaseBehind, _ = git.AheadBehindOf(baseCtx, gitR, se.Base, e.Branch)
+			cancelBase()
+		}
 		if detail {
 			sizeCtx, cancelSize := withItemTimeout(ctx)
 			if n, err := dirSizeFn(sizeCtx, e.Path); err == nil {
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lugassawan/rimba/internal/config"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/resolver"
 )
@@ -509,3 +510,42 @@ func TestSummarizeStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestStatusDashboardComparesAgainstBase(t *testing.T) {
+	porcelain := porcelainEntries(
+		struct{ path, branch string }{pathMainRepo, "main"},
+		struct{ path, branch string }{pathWtFeatureAuth, branchFeatureAuth},
+		struct{ path, branch string }{pathWtBugfixLogin, branchBugfixLogin},
+	)
+	r := (&statusDashboardRunner{porcelain: porcelain}).build()
+	run := r.run
+	r.run = func(args ...string) (string, error) {
+		if len(args) >= 2 && args[0] == gitCmdRevList && args[1] == "--left-right" {
+			if args[len(args)-1] != "release/2.2..."+branchBugfixLogin {
+				t.Errorf("unexpected base comparison %v", args)
+			}
+			return "3\t1", nil
+		}
+		return run(args...)
+	}
+	cfg := &config.Config{DefaultSource: "main", Resolver: &config.ResolverConfig{Prefix: []config.PrefixEntry{
+		{Prefix: "bugfix/", SyncTarget: "release/2.2"},
+	}}}
+
+	res, err := StatusDashboard(context.Background(), r, StatusDashboardRequest{Config: cfg})
+	if err != nil {
+		t.Fatalf("StatusDashboard: %v", err)
+	}
+	for _, e := range res.Entries {
+		switch e.Entry.Branch {
+		case branchFeatureAuth:
+			if e.Base != "" {
+				t.Errorf("feature/auth base = %q, want none", e.Base)
+			}
+		case branchBugfixLogin:
+			if e.Base != "release/2.2" || e.BaseAhead != 1 || e.BaseBehind != 3 {
+				t.Errorf("bugfix/login base = %q ↑%d ↓%d, want release/2.2 ↑1 ↓3", e.Base, e.BaseAhead, e.BaseBehind)
+			}
+		}
+	}
+}
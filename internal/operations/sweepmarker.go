@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lugassawan/rimba/internal/fsutil"
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/proc"
 )
@@ -99,7 +100,7 @@ func writeSweepManifest(commonDir string, worktreePaths []string) (cleanup func(
 	}
 
 	path := filepath.Join(sweepsDir, fmt.Sprintf("sweep-%d.json", manifest.PID))
-	if err := atomicWriteFile(sweepsDir, path, data); err != nil {
+	if err := fsutil.WriteFileAtomic(path, data); err != nil {
 		return noop
 	}
 
@@ -210,23 +211,3 @@ func readWorktreeAdminDir(worktreePath string) (adminDir string, ok bool) {
 	}
 	return strings.TrimSpace(dir), true
 }
-
-// atomicWriteFile writes data to a temp file in dir then renames it to
-// path, so a reader never observes a partially-written manifest.
-func atomicWriteFile(dir, path string, data []byte) error {
-	tmp, err := os.CreateTemp(dir, "sweep-*.tmp")
-	if err != nil {
-		return err
-	}
-	tmpPath := tmp.Name()
-	defer func() { _ = os.Remove(tmpPath) }() // no-op once the rename below succeeds
-
-	if _, err := tmp.Write(data); err != nil {
-		_ = tmp.Close()
-		return err
-	}
-	if err := tmp.Close(); err != nil {
-		return err
-	}
-	return os.Rename(tmpPath, path) //nolint:gosec // path is built from commonDir + this process's own PID, not user input
-}
@@ -2,7 +2,10 @@ package operations
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/lugassawan/rimba/internal/git"
 	"github.com/lugassawan/rimba/internal/resolver"
@@ -16,13 +19,50 @@ type SyncWorktreeResult struct {
 	SkipReason  string // "dirty" or "could not check status: <err>"
 	Failed      bool
 	FailureHint string // e.g. "cd /path && git rebase main"
+	Conflicted  bool   // the rebase or merge stopped on conflicts and was left in progress (--stop-on-conflict)
+	Onto        string // restack target or prefix-type base branch; empty for a plain sync onto main
+	BaseSHA     string // commit the worktree was synced onto, when the caller resolved it
+	// PredictedConflict marks a worktree skipped because git merge-tree
+	// predicted conflicts in ConflictFiles (--predict).
+	PredictedConflict bool
+	ConflictFiles     []string
+	// LockfilesRegenerated lists the lockfiles whose conflicts the sync
+	// resolved by regenerating them ([deps] resolve_lockfiles).
+	LockfilesRegenerated []string
 	// Push status (only meaningful when Synced=true)
 	Pushed      bool
 	PushSkipped bool // no upstream tracking branch
 	PushFailed  bool
 	PushError   string // error message for display
+	// Autostash status (only meaningful when Stashed=true)
+	Stashed       bool   // dirty changes were stashed around the sync (--autostash)
+	StashRef      string // SHA of the stash while it is kept: not yet reapplied, or could not be
+	StashConflict bool   // reapplying the stash conflicted; the worktree holds the conflicts
+	StashError    string // why the kept stash could not be reapplied or dropped
+	StashHint     string // how to recover the kept stash
 }
 
+// SyncOptions selects how SyncWorktreeWith syncs a worktree.
+type SyncOptions struct {
+	UseMerge bool
+	Push     bool
+	// Autostash stashes a dirty worktree's changes before the rebase or merge
+	// and reapplies them afterwards, instead of skipping the worktree.
+	Autostash bool
+	// KeepConflict leaves a rebase or merge that stops on conflicts in
+	// progress for the user to resolve, and marks the result Conflicted
+	// (--stop-on-conflict). A stash made for it is kept until the run resumes.
+	KeepConflict bool
+	// Lockfiles, when set, resolves a rebase or merge that stops only on
+	// lockfile conflicts by regenerating the lockfiles and continuing.
+	Lockfiles *LockfileResolver
+}
+
+// stashMu serialises autostash pushes, applies and drops. Every worktree
+// shares the repository's stash list, and git.StashPushAndRef and
+// git.StashDrop address entries by their position in it.
+var stashMu sync.Mutex
+
 // SyncBranch synchronises a worktree with the main branch using rebase or merge.
 // On rebase failure the failed rebase is aborted so the worktree stays clean.
 func SyncBranch(ctx context.Context, r git.Runner, dir, mainBranch string, useMerge bool) error {
@@ -36,6 +76,18 @@ func SyncBranch(ctx context.Context, r git.Runner, dir, mainBranch string, useMe
 	return nil
 }
 
+// SyncBranchWith is SyncBranch that, given lockfiles, resolves a rebase or
+// merge stopped only on lockfile conflicts and continues it. It returns the
+// regenerated lockfiles; when they could not be regenerated, the reason is
+// joined to the sync error.
+func SyncBranchWith(ctx context.Context, r git.Runner, dir, base string, useMerge bool, lockfiles *LockfileResolver) ([]string, error) {
+	files, lockErr, err := syncBranch(ctx, r, dir, base, SyncOptions{UseMerge: useMerge, Lockfiles: lockfiles})
+	if err != nil && lockErr != nil {
+		return nil, errors.Join(err, fmt.Errorf("could not regenerate lockfiles: %w", lockErr))
+	}
+	return files, err
+}
+
 // PushBranch pushes the current branch after a successful sync.
 // Uses force-with-lease after rebase, regular push after merge.
 // Returns (pushed, skipped, error). pushed is true only on success.
@@ -79,9 +131,27 @@ func FilterEligible(worktrees []resolver.WorktreeInfo, prefixes []string, mainBr
 	return eligible
 }
 
+// SyncWorktreeOnto syncs wt onto base, recording base in Onto when it is not
+// mainBranch (a prefix type with its own sync target).
+func SyncWorktreeOnto(ctx context.Context, r git.Runner, mainBranch, base string, wt resolver.WorktreeInfo, opts SyncOptions) SyncWorktreeResult {
+	res := SyncWorktreeWith(ctx, r, base, wt, opts)
+	if base != mainBranch {
+		res.Onto = base
+	}
+	return res
+}
+
 // SyncWorktree checks a worktree's status and syncs it with the main branch.
 // It returns a result describing what happened rather than writing to stdout.
 func SyncWorktree(ctx context.Context, r git.Runner, mainBranch string, wt resolver.WorktreeInfo, useMerge, push bool) SyncWorktreeResult {
+	return SyncWorktreeWith(ctx, r, mainBranch, wt, SyncOptions{UseMerge: useMerge, Push: push})
+}
+
+// SyncWorktreeWith is SyncWorktree with the full set of options. With
+// Autostash a dirty worktree is stashed, synced and has its changes
+// reapplied; when they cannot be reapplied cleanly the stash is kept and its
+// SHA left in StashRef.
+func SyncWorktreeWith(ctx context.Context, r git.Runner, base string, wt resolver.WorktreeInfo, opts SyncOptions) SyncWorktreeResult {
 	res := SyncWorktreeResult{Branch: wt.Branch}
 
 	dirty, err := git.IsDirty(ctx, r, wt.Path)
@@ -90,35 +160,64 @@ func SyncWorktree(ctx context.Context, r git.Runner, mainBranch string, wt resol
 		res.SkipReason = fmt.Sprintf("could not check status: %v", err)
 		return res
 	}
-	if dirty {
+	if dirty && !opts.Autostash {
 		res.Skipped = true
 		res.SkipReason = "dirty"
 		return res
 	}
-
-	if err := SyncBranch(ctx, r, wt.Path, mainBranch, useMerge); err != nil {
-		verb := "rebase"
-		if useMerge {
-			verb = "merge"
+	if dirty {
+		if err := StashForSync(ctx, r, &res, wt.Path); err != nil {
+			res.Failed = true
+			res.FailureHint = fmt.Sprintf("could not stash changes in %s: %v", wt.Path, err)
+			return res
 		}
-		res.Failed = true
-		res.FailureHint = fmt.Sprintf("cd %s && git %s %s", wt.Path, verb, mainBranch)
-		return res
 	}
 
-	res.Synced = true
+	syncWorktree(ctx, r, &res, base, wt.Path, opts)
+	// A stopped --stop-on-conflict sync keeps the stash until the run resumes.
+	if res.Stashed && !res.Conflicted {
+		ReapplySyncStash(r, &res, wt.Path)
+	}
+	return res
+}
 
-	if push {
-		pushed, skipped, pushErr := PushBranch(ctx, r, wt.Path, useMerge)
-		res.Pushed = pushed
-		res.PushSkipped = skipped
-		if pushErr != nil {
-			res.PushFailed = true
-			res.PushError = pushErr.Error()
-		}
+// StashForSync stashes the uncommitted changes in dir, including untracked
+// files, before res.Branch is synced, and records the stash in res.
+func StashForSync(ctx context.Context, r git.Runner, res *SyncWorktreeResult, dir string) error {
+	stashMu.Lock()
+	defer stashMu.Unlock()
+	sha, err := git.StashPushAndRef(ctx, r, dir, autostashMessage(res.Branch))
+	if err != nil {
+		return err
 	}
+	res.Stashed, res.StashRef = true, sha
+	return nil
+}
 
-	return res
+// ReapplySyncStash applies the stash recorded by StashForSync in dir and
+// drops it, clearing StashRef. A stash that fails to apply or drop is kept,
+// with the reason and how to recover it in res. StashApply and StashDrop are
+// intentionally non-cancellable (recovery paths must complete).
+func ReapplySyncStash(r git.Runner, res *SyncWorktreeResult, dir string) {
+	stashMu.Lock()
+	defer stashMu.Unlock()
+	find := fmt.Sprintf("git stash list (find '%s')", autostashMessage(res.Branch))
+	if err := git.StashApply(r, dir, res.StashRef); err != nil {
+		res.StashError = err.Error()
+		if stashApplyConflicted(err) {
+			res.StashConflict = true
+			res.StashHint = fmt.Sprintf("cd %s, resolve the conflicts, then drop the stash: %s && git stash drop stash@{N}", dir, find)
+			return
+		}
+		res.StashHint = keptStashHint(dir, res.Branch)
+		return
+	}
+	if err := git.StashDrop(r, dir, res.StashRef); err != nil {
+		res.StashError = fmt.Sprintf("reapplied, but could not drop the stash: %v", err)
+		res.StashHint = fmt.Sprintf("cd %s && %s && git stash drop stash@{N}", dir, find)
+		return
+	}
+	res.StashRef = ""
 }
 
 // SyncMethodLabel returns a past-tense label for the sync method used.
@@ -128,3 +227,91 @@ func SyncMethodLabel(useMerge bool) string {
 	}
 	return "Rebased"
 }
+
+// syncWorktree rebases or merges the worktree at dir onto base and pushes it
+// when asked, folding the outcome into res.
+func syncWorktree(ctx context.Context, r git.Runner, res *SyncWorktreeResult, base, dir string, opts SyncOptions) {
+	files, lockErr, err := syncBranch(ctx, r, dir, base, opts)
+	if err != nil {
+		res.Failed = true
+		if opts.KeepConflict {
+			if stopped, _ := syncInProgress(ctx, r, dir, opts.UseMerge); stopped {
+				res.Conflicted = true
+				res.FailureHint = conflictHint(dir)
+				return
+			}
+		}
+		verb := "rebase"
+		if opts.UseMerge {
+			verb = "merge"
+		}
+		res.FailureHint = fmt.Sprintf("cd %s && git %s %s", dir, verb, base)
+		if lockErr != nil {
+			reason, _, _ := strings.Cut(lockErr.Error(), "\n")
+			res.FailureHint = fmt.Sprintf("could not regenerate lockfiles: %s; %s", reason, res.FailureHint)
+		}
+		return
+	}
+
+	res.Synced = true
+	res.LockfilesRegenerated = files
+	if opts.Push {
+		pushSynced(ctx, r, res, dir, opts.UseMerge)
+	}
+}
+
+// syncBranch is SyncBranch, except that with opts.KeepConflict a failed
+// rebase is left in progress rather than aborted, and with opts.Lockfiles a
+// stop on lockfile conflicts alone is resolved. It returns the regenerated
+// lockfiles, and why they could not be when the sync fails after trying.
+func syncBranch(ctx context.Context, r git.Runner, dir, base string, opts SyncOptions) ([]string, error, error) {
+	var err error
+	if (opts.KeepConflict || opts.Lockfiles != nil) && !opts.UseMerge {
+		err = git.Rebase(ctx, r, dir, base)
+	} else {
+		err = SyncBranch(ctx, r, dir, base, opts.UseMerge)
+	}
+	if err == nil || opts.Lockfiles == nil {
+		return nil, nil, err
+	}
+
+	// Rebasing, the base is ours; merging it in, it is theirs.
+	side := git.CheckoutOurs
+	if opts.UseMerge {
+		side = git.CheckoutTheirs
+	}
+	files, lockErr := opts.Lockfiles.Resolve(ctx, r, dir, opts.UseMerge, side)
+	if lockErr == nil {
+		return files, nil, nil
+	}
+	if !opts.KeepConflict && !opts.UseMerge {
+		_ = git.AbortRebase(r, dir)
+	}
+	if errors.Is(lockErr, errNotLockfileConflict) {
+		lockErr = nil
+	}
+	return nil, lockErr, err
+}
+
+// pushSynced pushes a synced worktree, folding the outcome into res.
+func pushSynced(ctx context.Context, r git.Runner, res *SyncWorktreeResult, dir string, useMerge bool) {
+	pushed, skipped, pushErr := PushBranch(ctx, r, dir, useMerge)
+	res.Pushed = pushed
+	res.PushSkipped = skipped
+	if pushErr != nil {
+		res.PushFailed = true
+		res.PushError = pushErr.Error()
+	}
+}
+
+// keptStashHint tells the user how to get back the changes branch's sync
+// stashed in dir.
+func keptStashHint(dir, branch string) string {
+	return fmt.Sprintf("cd %s && git stash list (find '%s'), then git stash apply stash@{N}", dir, autostashMessage(branch))
+}
+
+// autostashMessage is the message a sync stashes branch's changes under, by
+// which the user can find a stash that was kept.
+func autostashMessage(branch string) string {
+	return "rimba: autostash sync " + branch
+}
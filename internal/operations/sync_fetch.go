@@ -0,0 +1,140 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+)
+
+// SyncFetch is the outcome of fetching before `rimba sync --fetch`.
+type SyncFetch struct {
+	Remote string
+	// Branch is the default branch synced onto: the configured default, or
+	// the remote's new default when <remote>/HEAD moved off it.
+	Branch string
+	// Head and PreviousHead are the branches <remote>/HEAD points at after
+	// and before the fetch; PreviousHead is set only when they differ.
+	Head         string
+	PreviousHead string
+	SHA          string // tip of <remote>/<Branch>
+	// FastForwarded reports that the main worktree's local Branch was
+	// fast-forwarded to SHA; FastForwardSkip says why it was not, and is
+	// empty when it was or was already current.
+	FastForwarded   bool
+	FastForwardSkip string
+}
+
+// SyncBases resolves the base each worktree syncs onto — the
+// remote-tracking branch after a --fetch, else the local branch — and the
+// tip it points at, resolving each base once. Safe for concurrent use.
+type SyncBases struct {
+	r      git.Runner
+	remote string // "" resolves bases as given
+	mu     sync.Mutex
+	cache  map[string]syncBase
+}
+
+type syncBase struct{ ref, sha string }
+
+// NewSyncBases returns a SyncBases that maps each base onto remote's
+// remote-tracking branch where one exists; with an empty remote, bases are
+// resolved as given.
+func NewSyncBases(r git.Runner, remote string) *SyncBases {
+	return &SyncBases{r: r, remote: remote, cache: make(map[string]syncBase)}
+}
+
+// Ref returns the remote-tracking branch synced onto, e.g. origin/main.
+func (f SyncFetch) Ref() string { return f.Remote + "/" + f.Branch }
+
+// Resolve returns the ref to sync onto for base and the commit it points at.
+// The SHA is empty when the ref cannot be resolved; the sync then reports
+// the failure itself.
+func (b *SyncBases) Resolve(ctx context.Context, base string) (ref, sha string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cached, ok := b.cache[base]; ok {
+		return cached.ref, cached.sha
+	}
+
+	ref = base
+	if b.remote != "" && !strings.HasPrefix(base, b.remote+"/") {
+		if out, err := git.ResolveRef(ctx, b.r, "refs/remotes/"+b.remote+"/"+base); err == nil {
+			ref, sha = b.remote+"/"+base, strings.TrimSpace(out)
+		}
+	}
+	if sha == "" {
+		out, _ := git.ResolveRef(ctx, b.r, ref)
+		sha = strings.TrimSpace(out)
+	}
+	b.cache[base] = syncBase{ref: ref, sha: sha}
+	return ref, sha
+}
+
+// FetchForSync fetches remote once for `rimba sync --fetch` and refreshes
+// the remote's recorded default branch. When that moved off defaultBranch,
+// the sync follows it. The main worktree's local default branch is then
+// fast-forwarded to the fetched tip when it is checked out there and clean;
+// an empty mainRoot skips that.
+func FetchForSync(ctx context.Context, r git.Runner, remote, defaultBranch, mainRoot string) (SyncFetch, error) {
+	res := SyncFetch{Remote: remote, Branch: defaultBranch}
+
+	before := git.RemoteHead(ctx, r, remote)
+	if err := git.Fetch(ctx, r, remote, git.FetchArgs{}); err != nil {
+		return res, errhint.WithFix(
+			fmt.Errorf("fetch %s: %w", remote, err),
+			"check the remote with 'git remote -v', or sync onto local branches without --fetch",
+		)
+	}
+	// Best effort: needs the remote to advertise its HEAD.
+	_ = git.UpdateRemoteHead(ctx, r, remote)
+	res.Head = git.RemoteHead(ctx, r, remote)
+	if before != "" && res.Head != "" && before != res.Head {
+		res.PreviousHead = before
+		if before == defaultBranch {
+			res.Branch = res.Head
+		}
+	}
+
+	sha, err := git.ResolveRef(ctx, r, res.Ref())
+	if err != nil {
+		return res, errhint.WithFix(
+			fmt.Errorf("%s has no branch %s", remote, res.Branch),
+			"set the remote's default branch: git remote set-head "+remote+" --auto",
+		)
+	}
+	res.SHA = strings.TrimSpace(sha)
+	if mainRoot != "" {
+		res.FastForwarded, res.FastForwardSkip = fastForwardDefault(ctx, r, mainRoot, res)
+	}
+	return res, nil
+}
+
+// fastForwardDefault fast-forwards f.Branch in the main worktree to the
+// fetched tip, returning why it did not when it could not.
+func fastForwardDefault(ctx context.Context, r git.Runner, mainRoot string, f SyncFetch) (bool, string) {
+	current, err := git.CurrentBranch(ctx, r, mainRoot)
+	if err != nil {
+		return false, "the main worktree has no branch checked out"
+	}
+	if current != f.Branch {
+		return false, fmt.Sprintf("the main worktree has %s checked out", current)
+	}
+	if local, err := git.ResolveRef(ctx, r, "refs/heads/"+f.Branch); err == nil && strings.TrimSpace(local) == f.SHA {
+		return false, ""
+	}
+	dirty, err := git.IsDirty(ctx, r, mainRoot)
+	if err != nil {
+		return false, fmt.Sprintf("could not check the main worktree: %v", err)
+	}
+	if dirty {
+		return false, "the main worktree has uncommitted changes"
+	}
+	if err := git.MergeFFOnly(ctx, r, mainRoot, f.Ref()); err != nil {
+		return false, fmt.Sprintf("local %s has diverged from %s", f.Branch, f.Ref())
+	}
+	return true, ""
+}
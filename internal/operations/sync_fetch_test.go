@@ -0,0 +1,183 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	branchTrunk   = "trunk"
+	fetchedTipSHA = "fetched123"
+)
+
+// syncFetchFake serves FetchForSync. <remote>/HEAD reads as heads[0] before
+// the fetch and heads[1] after `remote set-head`; the main worktree has
+// current checked out at localSHA.
+type syncFetchFake struct {
+	heads     [2]string
+	fetchErr  error
+	current   string
+	localSHA  string
+	dirty     bool
+	ffErr     error
+	setHead   bool
+	ffApplied string
+}
+
+func (f *syncFetchFake) runner() *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case args[0] == gitCmdSymbolicRef:
+				head := f.heads[0]
+				if f.setHead {
+					head = f.heads[1]
+				}
+				if head == "" {
+					return "", errors.New("not a symbolic ref")
+				}
+				return "refs/remotes/origin/" + head + "\n", nil
+			case args[0] == gitCmdFetch:
+				return "", f.fetchErr
+			case args[0] == gitCmdRemote:
+				f.setHead = true
+				return "", nil
+			case strings.HasPrefix(args[len(args)-1], "refs/heads/"):
+				return f.localSHA + "\n", nil
+			}
+			return fetchedTipSHA + "\n", nil
+		},
+		runInDir: func(_ string, args ...string) (string, error) {
+			switch args[0] {
+			case gitCmdSymbolicRef:
+				return f.current, nil
+			case gitCmdStatus:
+				if f.dirty {
+					return " M file.go\n", nil
+				}
+				return "", nil
+			case "merge":
+				if f.ffErr != nil {
+					return "", f.ffErr
+				}
+				f.ffApplied = args[len(args)-1]
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestFetchForSyncFastForwardsCleanMain(t *testing.T) {
+	f := &syncFetchFake{heads: [2]string{branchMain, branchMain}, current: branchMain, localSHA: "old456"}
+	res, err := FetchForSync(context.Background(), f.runner(), "origin", branchMain, pathMainRepo)
+	if err != nil {
+		t.Fatalf("FetchForSync: %v", err)
+	}
+	if res.Ref() != "origin/main" || res.SHA != fetchedTipSHA || res.PreviousHead != "" {
+		t.Errorf("res = %+v", res)
+	}
+	if !res.FastForwarded || f.ffApplied != "origin/main" {
+		t.Errorf("FastForwarded = %v, merged %q; want a fast-forward to origin/main", res.FastForwarded, f.ffApplied)
+	}
+}
+
+func TestFetchForSyncFollowsChangedHead(t *testing.T) {
+	f := &syncFetchFake{heads: [2]string{branchMain, branchTrunk}, current: branchMain, localSHA: "old456"}
+	res, err := FetchForSync(context.Background(), f.runner(), "origin", branchMain, pathMainRepo)
+	if err != nil {
+		t.Fatalf("FetchForSync: %v", err)
+	}
+	if res.PreviousHead != branchMain || res.Head != branchTrunk || res.Branch != branchTrunk {
+		t.Errorf("res = %+v, want origin/HEAD followed from main to trunk", res)
+	}
+	// The main worktree still has main checked out, so trunk is not touched.
+	if res.FastForwarded || !strings.Contains(res.FastForwardSkip, "main checked out") {
+		t.Errorf("FastForwardSkip = %q", res.FastForwardSkip)
+	}
+}
+
+func TestFetchForSyncKeepsConfiguredDefault(t *testing.T) {
+	// origin/HEAD moved, but between branches the config does not sync onto.
+	f := &syncFetchFake{heads: [2]string{branchTrunk, "next"}, current: branchMain, localSHA: fetchedTipSHA}
+	res, err := FetchForSync(context.Background(), f.runner(), "origin", branchMain, pathMainRepo)
+	if err != nil {
+		t.Fatalf("FetchForSync: %v", err)
+	}
+	if res.Branch != branchMain || res.PreviousHead != branchTrunk {
+		t.Errorf("res = %+v, want main kept and the head change reported", res)
+	}
+	if res.FastForwarded || res.FastForwardSkip != "" {
+		t.Errorf("res = %+v, want no fast-forward needed", res)
+	}
+}
+
+func TestFetchForSyncSkipsFastForward(t *testing.T) {
+	tests := []struct {
+		name  string
+		fake  syncFetchFake
+		wantS string
+	}{
+		{name: "dirty", fake: syncFetchFake{dirty: true}, wantS: "uncommitted changes"},
+		{name: "diverged", fake: syncFetchFake{ffErr: errors.New("not possible to fast-forward")}, wantS: "diverged"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.fake
+			f.heads = [2]string{branchMain, branchMain}
+			f.current, f.localSHA = branchMain, "old456"
+			res, err := FetchForSync(context.Background(), f.runner(), "origin", branchMain, pathMainRepo)
+			if err != nil {
+				t.Fatalf("FetchForSync: %v", err)
+			}
+			if res.FastForwarded || !strings.Contains(res.FastForwardSkip, tt.wantS) {
+				t.Errorf("FastForwardSkip = %q, want %q", res.FastForwardSkip, tt.wantS)
+			}
+		})
+	}
+}
+
+func TestFetchForSyncFetchError(t *testing.T) {
+	f := &syncFetchFake{fetchErr: errors.New("could not read from remote")}
+	_, err := FetchForSync(context.Background(), f.runner(), "origin", branchMain, pathMainRepo)
+	if err == nil || !strings.Contains(err.Error(), "fetch origin") {
+		t.Fatalf("err = %v, want fetch error", err)
+	}
+}
+
+func TestSyncBasesResolve(t *testing.T) {
+	var lookups int
+	r := &mockRunner{
+		run: func(args ...string) (string, error) {
+			lookups++
+			ref := args[len(args)-1]
+			switch ref {
+			case refsRemotesOriginMain + "^{commit}":
+				return "remote111\n", nil
+			case "release/2.3^{commit}":
+				return "local222\n", nil
+			}
+			return "", errors.New("unknown revision")
+		},
+		runInDir: noopRunInDir,
+	}
+	bases := NewSyncBases(r, "origin")
+
+	if ref, sha := bases.Resolve(context.Background(), branchMain); ref != "origin/main" || sha != "remote111" {
+		t.Errorf("Resolve(main) = %q, %q", ref, sha)
+	}
+	// No remote-tracking branch: falls back to the local one.
+	if ref, sha := bases.Resolve(context.Background(), "release/2.3"); ref != "release/2.3" || sha != "local222" {
+		t.Errorf("Resolve(release/2.3) = %q, %q", ref, sha)
+	}
+	before := lookups
+	bases.Resolve(context.Background(), branchMain)
+	if lookups != before {
+		t.Error("Resolve looked main up again, want it cached")
+	}
+
+	if ref, sha := NewSyncBases(r, "").Resolve(context.Background(), branchMain); ref != branchMain || sha != "" {
+		t.Errorf("Resolve without remote = %q, %q", ref, sha)
+	}
+}
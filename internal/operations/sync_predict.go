@@ -0,0 +1,71 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/parallel"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// SyncPrediction is the predicted outcome of syncing a worktree onto its
+// base, made with git merge-tree without touching the worktree.
+type SyncPrediction struct {
+	Worktree  resolver.WorktreeInfo
+	Base      string
+	Conflicts bool
+	Files     []string // files predicted to conflict
+	Err       error    // merge-tree could not run; nothing was predicted
+}
+
+// PredictSyncConflicts predicts, four at a time, whether each worktree's
+// branch conflicts with the base baseOf returns for it. A merge-tree
+// prediction approximates a rebase: it compares the end states, so a rebase
+// can still stop on a commit whose changes a later commit reverts.
+func PredictSyncConflicts(ctx context.Context, r git.Runner, worktrees []resolver.WorktreeInfo, baseOf func(branch string) string) []SyncPrediction {
+	return parallel.Collect(ctx, len(worktrees), 4, func(ctx context.Context, i int) SyncPrediction {
+		wt := worktrees[i]
+		p := SyncPrediction{Worktree: wt, Base: baseOf(wt.Branch)}
+		mt, err := git.MergeTree(ctx, r, p.Base, wt.Branch)
+		if err != nil {
+			p.Err = err
+			return p
+		}
+		p.Conflicts, p.Files = mt.HasConflicts, mt.ConflictFiles
+		return p
+	})
+}
+
+// SplitPredicted returns the worktrees to sync — those predicted to sync
+// cleanly or that could not be predicted — and a skipped result for each
+// one predicted to conflict.
+func SplitPredicted(preds []SyncPrediction, useMerge bool) ([]resolver.WorktreeInfo, []SyncWorktreeResult) {
+	var clean []resolver.WorktreeInfo
+	var skipped []SyncWorktreeResult
+	for _, p := range preds {
+		if p.Conflicts {
+			skipped = append(skipped, p.Result(useMerge))
+			continue
+		}
+		clean = append(clean, p.Worktree)
+	}
+	return clean, skipped
+}
+
+// Result returns the skipped sync result of a worktree predicted to
+// conflict, with how to run the sync by hand and resolve the conflicts.
+func (p SyncPrediction) Result(useMerge bool) SyncWorktreeResult {
+	verb := "rebase"
+	if useMerge {
+		verb = "merge"
+	}
+	return SyncWorktreeResult{
+		Branch:            p.Worktree.Branch,
+		Skipped:           true,
+		SkipReason:        fmt.Sprintf("predicted conflict in %d file(s)", len(p.Files)),
+		FailureHint:       fmt.Sprintf("cd %s && git %s %s", p.Worktree.Path, verb, p.Base),
+		PredictedConflict: true,
+		ConflictFiles:     p.Files,
+	}
+}
@@ -0,0 +1,74 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+// mergeTreeRunner answers git merge-tree: branchFeature conflicts in
+// auth.go, branchBugfixTypo cannot be predicted, anything else is clean.
+func mergeTreeRunner(bases *[]string) *mockRunner {
+	var mu sync.Mutex
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			if args[0] != "merge-tree" {
+				return "", nil
+			}
+			mu.Lock()
+			*bases = append(*bases, args[len(args)-2])
+			mu.Unlock()
+			switch args[len(args)-1] {
+			case branchFeature:
+				return "tree123\n\nCONFLICT (content): Merge conflict in auth.go\n", errors.New("exit status 1")
+			case branchBugfixTypo:
+				return "", errors.New("unknown option --write-tree")
+			}
+			return "tree456\n", nil
+		},
+		runInDir: noopRunInDir,
+	}
+}
+
+func TestPredictSyncConflicts(t *testing.T) {
+	var bases []string
+	worktrees := []resolver.WorktreeInfo{
+		{Branch: branchFeature, Path: pathWtFeatureLogin},
+		{Branch: branchBugfixTypo, Path: "/wt/bugfix-typo"},
+		{Branch: "feature/clean", Path: "/wt/feature-clean"},
+	}
+	preds := PredictSyncConflicts(context.Background(), mergeTreeRunner(&bases), worktrees, func(string) string { return "origin/main" })
+
+	if len(preds) != 3 {
+		t.Fatalf("got %d predictions, want 3", len(preds))
+	}
+	if !preds[0].Conflicts || !slices.Equal(preds[0].Files, []string{"auth.go"}) {
+		t.Errorf("preds[0] = %+v, want a conflict in auth.go", preds[0])
+	}
+	if preds[1].Err == nil || preds[1].Conflicts {
+		t.Errorf("preds[1] = %+v, want a prediction error", preds[1])
+	}
+	if preds[2].Conflicts || preds[2].Err != nil {
+		t.Errorf("preds[2] = %+v, want clean", preds[2])
+	}
+	for _, b := range bases {
+		if b != "origin/main" {
+			t.Errorf("merge-tree base = %q, want origin/main", b)
+		}
+	}
+
+	clean, skipped := SplitPredicted(preds, false)
+	if len(clean) != 2 || clean[0].Branch != branchBugfixTypo {
+		t.Errorf("clean = %+v, want the unpredicted and clean worktrees", clean)
+	}
+	if len(skipped) != 1 || !skipped[0].Skipped || !skipped[0].PredictedConflict {
+		t.Fatalf("skipped = %+v, want one predicted conflict", skipped)
+	}
+	if want := "cd " + pathWtFeatureLogin + " && git rebase origin/main"; skipped[0].FailureHint != want {
+		t.Errorf("FailureHint = %q, want %q", skipped[0].FailureHint, want)
+	}
+}
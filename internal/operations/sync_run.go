@@ -0,0 +1,390 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/syncrun"
+)
+
+// SyncRun is a `rimba sync --stop-on-conflict` run in progress. Recording a
+// result persists it at once, so an interrupted run can still be continued
+// or aborted. Safe for concurrent use.
+type SyncRun struct {
+	commonDir string
+	mu        sync.Mutex
+	state     *syncrun.Run
+}
+
+// SyncRollback is the outcome of rolling back one worktree on --abort.
+type SyncRollback struct {
+	Branch string
+	Path   string
+	SHA    string // the pre-sync tip the branch was reset to
+	Pushed bool   // the synced branch had been pushed; the remote keeps it
+	// Moved marks a branch left as it is because it moved on from where the
+	// run left it (movedSinceSync) — resetting would drop the commits made
+	// since. MovedHint says how to roll it back by hand.
+	Moved     bool
+	MovedHint string
+	// Stash is the SHA of uncommitted changes that could not be reapplied
+	// after the reset; StashHint says how to recover them.
+	Stash     string
+	StashHint string
+	Err       error
+}
+
+// StartSyncRun records the branch tip of each worktree before a
+// --stop-on-conflict run touches it, and persists the run with every
+// worktree pending. Only one run may be in progress at a time.
+func StartSyncRun(ctx context.Context, r git.Runner, worktrees []resolver.WorktreeInfo, baseOf func(branch string) string, opts SyncOptions) (*SyncRun, error) {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := syncrun.Load(commonDir)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errhint.WithFix(
+			errors.New("a sync run is already in progress"),
+			"finish it with 'rimba sync --continue', or roll it back with 'rimba sync --abort'",
+		)
+	}
+
+	state := &syncrun.Run{
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+		Merge:     opts.UseMerge,
+		Push:      opts.Push,
+		Autostash: opts.Autostash,
+	}
+	for _, wt := range worktrees {
+		sha, err := git.ResolveRef(ctx, r, "refs/heads/"+wt.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("record %s before syncing: %w", wt.Branch, err)
+		}
+		state.Worktrees = append(state.Worktrees, syncrun.Worktree{
+			Branch: wt.Branch,
+			Path:   wt.Path,
+			Base:   baseOf(wt.Branch),
+			PreSHA: strings.TrimSpace(sha),
+			Status: syncrun.StatusPending,
+		})
+	}
+	if err := state.Save(commonDir); err != nil {
+		return nil, err
+	}
+	return &SyncRun{commonDir: commonDir, state: state}, nil
+}
+
+// LoadSyncRun returns the sync run in progress, or an error when there is none.
+func LoadSyncRun(ctx context.Context, r git.Runner) (*SyncRun, error) {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	state, err := syncrun.Load(commonDir)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, errhint.WithFix(
+			errors.New("no sync run in progress"),
+			"start one with: rimba sync --all --stop-on-conflict",
+		)
+	}
+	return &SyncRun{commonDir: commonDir, state: state}, nil
+}
+
+// Merge reports whether the run merges rather than rebases.
+func (s *SyncRun) Merge() bool { return s.state.Merge }
+
+// Push reports whether the run pushes each synced worktree.
+func (s *SyncRun) Push() bool { return s.state.Push }
+
+// Autostash reports whether the run stashes dirty worktrees around the sync.
+func (s *SyncRun) Autostash() bool { return s.state.Autostash }
+
+// Base returns the branch branch syncs onto in this run.
+func (s *SyncRun) Base(branch string) string {
+	return s.find(branch).Base
+}
+
+// Path returns the worktree path of branch in this run.
+func (s *SyncRun) Path(branch string) string {
+	return s.find(branch).Path
+}
+
+// Pending returns the worktrees the run has not reached yet, in run order.
+func (s *SyncRun) Pending() []resolver.WorktreeInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []resolver.WorktreeInfo
+	for _, w := range s.state.With(syncrun.StatusPending) {
+		out = append(out, resolver.WorktreeInfo{Branch: w.Branch, Path: w.Path})
+	}
+	return out
+}
+
+// Stopped reports whether a worktree is still waiting on a conflict.
+func (s *SyncRun) Stopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.state.With(syncrun.StatusConflicted)) > 0
+}
+
+// Record stores a worktree's sync outcome and persists the run. A synced
+// worktree's new branch tip, or the HEAD a conflicted one stopped at, is
+// stored with it, so --abort can tell whether the worktree moved on
+// afterwards.
+func (s *SyncRun) Record(ctx context.Context, r git.Runner, res SyncWorktreeResult) error {
+	var postSHA, stopSHA string
+	switch {
+	case res.Conflicted:
+		stopSHA, _ = git.HeadSHA(ctx, r, s.find(res.Branch).Path)
+	case res.Synced:
+		sha, _ := git.ResolveRef(ctx, r, "refs/heads/"+res.Branch)
+		postSHA = strings.TrimSpace(sha)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Update(res.Branch, func(w *syncrun.Worktree) {
+		w.Stash = ""
+		switch {
+		case res.Conflicted:
+			w.Status = syncrun.StatusConflicted
+			w.Stash = res.StashRef
+			w.StopSHA = stopSHA
+		case res.Synced:
+			w.Status = syncrun.StatusDone
+			w.Pushed = res.Pushed
+			w.PostSHA = postSHA
+		default:
+			w.Status = syncrun.StatusSkipped
+		}
+	})
+	return s.state.Save(s.commonDir)
+}
+
+// Finish clears the run once no worktree is conflicted or pending,
+// reporting whether it did.
+func (s *SyncRun) Finish() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.state.With(syncrun.StatusConflicted)) > 0 || len(s.state.With(syncrun.StatusPending)) > 0 {
+		return false, nil
+	}
+	return true, syncrun.Clear(s.commonDir)
+}
+
+// ResumeConflicted finishes the stopped rebase or merge in each conflicted
+// worktree whose conflicts have been resolved and staged, pushing it when the
+// run pushes, and reapplying the changes stashed before it stopped. A
+// worktree still holding conflicts — or stopping again on a later commit —
+// stays conflicted. Each outcome is recorded.
+func (s *SyncRun) ResumeConflicted(ctx context.Context, r git.Runner) ([]SyncWorktreeResult, error) {
+	s.mu.Lock()
+	conflicted := s.state.With(syncrun.StatusConflicted)
+	s.mu.Unlock()
+
+	results := make([]SyncWorktreeResult, 0, len(conflicted))
+	for _, w := range conflicted {
+		res := resumeSync(ctx, r, w, s.state.Merge, s.state.Push)
+		if err := s.Record(ctx, r, res); err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// AbortSyncRun rolls back every worktree the run in progress touched: a
+// stopped rebase or merge is aborted and the branch is reset to its pre-sync
+// tip. Worktrees the run never reached are left alone, and so are those
+// that have moved on since (SyncRollback.Moved). The run is cleared
+// once every rollback succeeds; failed ones stay recorded for a retry.
+// Pushes are not undone.
+func AbortSyncRun(ctx context.Context, r git.Runner) ([]SyncRollback, error) {
+	run, err := LoadSyncRun(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rollbacks []SyncRollback
+	failed := false
+	for _, w := range run.state.Worktrees {
+		if w.Status != syncrun.StatusDone && w.Status != syncrun.StatusConflicted {
+			continue
+		}
+		rb := SyncRollback{Branch: w.Branch, Path: w.Path, SHA: w.PreSHA, Pushed: w.Pushed}
+		rollbackSync(ctx, r, w, run.state.Merge, &rb)
+		if rb.Err == nil {
+			run.state.Update(w.Branch, func(w *syncrun.Worktree) { w.Status = syncrun.StatusPending })
+		} else {
+			failed = true
+		}
+		rollbacks = append(rollbacks, rb)
+	}
+
+	if failed {
+		return rollbacks, run.state.Save(run.commonDir)
+	}
+	return rollbacks, syncrun.Clear(run.commonDir)
+}
+
+func (s *SyncRun) find(branch string) syncrun.Worktree {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.state.Worktrees {
+		if w.Branch == branch {
+			return w
+		}
+	}
+	return syncrun.Worktree{}
+}
+
+// resumeSync continues w's stopped rebase or merge. When nothing is in
+// progress the user finished it by hand — or abandoned it, which leaves the
+// branch not yet containing its base, and the worktree is skipped.
+func resumeSync(ctx context.Context, r git.Runner, w syncrun.Worktree, useMerge, push bool) SyncWorktreeResult {
+	res := SyncWorktreeResult{Branch: w.Branch, Stashed: w.Stash != "", StashRef: w.Stash}
+
+	stopped, err := syncInProgress(ctx, r, w.Path, useMerge)
+	if err != nil {
+		res.Failed, res.Conflicted = true, true
+		res.FailureHint = err.Error()
+		return res
+	}
+	if stopped {
+		if files, _ := git.UnmergedFiles(ctx, r, w.Path); len(files) > 0 {
+			res.Failed, res.Conflicted = true, true
+			res.FailureHint = fmt.Sprintf("still conflicted: %s; %s", strings.Join(files, ", "), conflictHint(w.Path))
+			return res
+		}
+		if err := continueSync(ctx, r, w.Path, useMerge); err != nil {
+			res.Failed, res.Conflicted = true, true
+			res.FailureHint = conflictHint(w.Path)
+			return res
+		}
+	} else if !git.IsMergeBaseAncestor(ctx, r, w.Base, w.Branch) {
+		res.Skipped = true
+		res.SkipReason = "sync was abandoned"
+		reapplyRunStash(r, &res, w.Path)
+		return res
+	}
+
+	res.Synced = true
+	if push {
+		pushSynced(ctx, r, &res, w.Path, useMerge)
+	}
+	reapplyRunStash(r, &res, w.Path)
+	return res
+}
+
+// reapplyRunStash reapplies the stash a stopped worktree was holding, if any.
+func reapplyRunStash(r git.Runner, res *SyncWorktreeResult, dir string) {
+	if res.Stashed {
+		ReapplySyncStash(r, res, dir)
+	}
+}
+
+// syncInProgress reports whether a stopped rebase (or merge) waits in dir.
+func syncInProgress(ctx context.Context, r git.Runner, dir string, useMerge bool) (bool, error) {
+	if useMerge {
+		return git.MergeInProgress(ctx, r, dir)
+	}
+	return git.RebaseInProgress(ctx, r, dir)
+}
+
+func continueSync(ctx context.Context, r git.Runner, dir string, useMerge bool) error {
+	if useMerge {
+		return git.MergeContinue(ctx, r, dir)
+	}
+	return git.RebaseContinue(ctx, r, dir)
+}
+
+// rollbackSync aborts any stopped rebase or merge in w and resets its branch
+// to the pre-sync tip, folding the outcome into rb. Uncommitted changes
+// survive the reset: the stash the run holds for a stopped worktree, or a
+// fresh one for changes made since, is reapplied afterwards.
+func rollbackSync(ctx context.Context, r git.Runner, w syncrun.Worktree, useMerge bool, rb *SyncRollback) {
+	if movedSinceSync(ctx, r, w, useMerge) {
+		rb.SHA, rb.Moved = "", true
+		rb.MovedHint = fmt.Sprintf("to roll it back anyway, dropping the commits made since the sync: cd %s && git reset --keep %s", w.Path, w.PreSHA)
+		return
+	}
+	res := SyncWorktreeResult{Branch: w.Branch, Stashed: w.Stash != "", StashRef: w.Stash}
+	if stopped, _ := syncInProgress(ctx, r, w.Path, useMerge); stopped {
+		abort := git.AbortRebase
+		if useMerge {
+			abort = git.MergeAbort
+		}
+		rb.Err = abort(r, w.Path)
+	} else {
+		rb.Err = stashBeforeReset(ctx, r, &res, w.Path)
+	}
+	if rb.Err == nil {
+		rb.Err = git.ResetHard(r, w.Path, w.PreSHA)
+	}
+	switch {
+	case rb.Err == nil && res.Stashed:
+		ReapplySyncStash(r, &res, w.Path)
+	case res.StashRef != "":
+		res.StashHint = keptStashHint(w.Path, w.Branch)
+	}
+	rb.Stash, rb.StashHint = res.StashRef, res.StashHint
+}
+
+// movedSinceSync reports whether w has moved on since the run left it: a
+// synced branch from the tip the run synced it to, and a conflicted worktree
+// whose rebase or merge is no longer in progress from both its pre-sync tip
+// and the HEAD it stopped at — finished by hand, say. A run recorded without
+// the synced tip cannot tell, and reports no move.
+func movedSinceSync(ctx context.Context, r git.Runner, w syncrun.Worktree, useMerge bool) bool {
+	switch w.Status {
+	case syncrun.StatusDone:
+		if w.PostSHA == "" {
+			return false
+		}
+		tip, err := git.ResolveRef(ctx, r, "refs/heads/"+w.Branch)
+		return err == nil && strings.TrimSpace(tip) != w.PostSHA
+	case syncrun.StatusConflicted:
+		if stopped, err := syncInProgress(ctx, r, w.Path, useMerge); err != nil || stopped {
+			return false
+		}
+		head, err := git.HeadSHA(ctx, r, w.Path)
+		return err == nil && head != w.PreSHA && head != w.StopSHA
+	}
+	return false
+}
+
+// stashBeforeReset stashes the uncommitted changes in dir so a rollback's
+// reset keeps them. A worktree that already has the run's stash to reapply
+// must be cleaned up by hand first.
+func stashBeforeReset(ctx context.Context, r git.Runner, res *SyncWorktreeResult, dir string) error {
+	dirty, err := git.IsDirty(ctx, r, dir)
+	if err != nil || !dirty {
+		return err
+	}
+	if res.Stashed {
+		return errhint.WithFix(
+			fmt.Errorf("%s has uncommitted changes on top of its autostash %s", dir, res.StashRef),
+			"commit or stash them, then rerun: rimba sync --abort",
+		)
+	}
+	return StashForSync(ctx, r, res, dir)
+}
+
+// conflictHint tells the user how to resume a run stopped in dir.
+func conflictHint(dir string) string {
+	return fmt.Sprintf("cd %s, fix the conflicts and git add them, then run: rimba sync --continue", dir)
+}
@@ -0,0 +1,425 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/syncrun"
+)
+
+const (
+	pathWtAuth  = "/wt/feature-auth"
+	pathWtLogin = "/wt/bugfix-login"
+)
+
+var keepConflict = SyncOptions{KeepConflict: true}
+
+// syncRunFake is a repo whose worktrees each have their own git dir, so a
+// stopped rebase is a real rebase-merge directory. Rebases in the conflict
+// worktree stop; `rebase --continue` finishes once unmerged is empty.
+// A branch's tip is "pre-<branch>" unless tips says otherwise; a worktree's
+// HEAD is heads[dir].
+// The dirty worktree has changes until they are stashed as stashSHA.
+// RunInDir calls are recorded as "<dir>: <args>".
+type syncRunFake struct {
+	commonDir   string
+	gitDirs     map[string]string
+	conflict    string
+	unmerged    string
+	notAncestor bool
+	dirty       string
+	stashed     bool
+	tips        map[string]string
+	heads       map[string]string
+	calls       []string
+}
+
+func newSyncRunFake(t *testing.T, conflict string) *syncRunFake {
+	t.Helper()
+	return &syncRunFake{
+		commonDir: t.TempDir(),
+		gitDirs:   map[string]string{pathWtAuth: t.TempDir(), pathWtLogin: t.TempDir()},
+		conflict:  conflict,
+		unmerged:  "conflict.go",
+	}
+}
+
+func (f *syncRunFake) runner() *mockRunner {
+	return &mockRunner{
+		run: func(args ...string) (string, error) {
+			switch {
+			case args[0] == cmdRevParse && args[1] == "--git-common-dir":
+				return f.commonDir, nil
+			case args[0] == cmdRevParse:
+				ref := strings.TrimSuffix(strings.TrimPrefix(args[len(args)-1], "refs/heads/"), "^{commit}")
+				if tip, ok := f.tips[ref]; ok {
+					return tip + "\n", nil
+				}
+				return "pre-" + ref + "\n", nil
+			case args[0] == git.CmdMergeBase && f.notAncestor:
+				return "", errors.New("exit status 1")
+			}
+			return "", nil
+		},
+		runInDir: func(dir string, args ...string) (string, error) {
+			f.calls = append(f.calls, dir+": "+strings.Join(args, " "))
+			stateDir := filepath.Join(f.gitDirs[dir], "rebase-merge")
+			switch {
+			case slices.Contains(args, "--absolute-git-dir"):
+				return f.gitDirs[dir], nil
+			case args[0] == gitCmdStatus && dir == f.dirty && !f.stashed:
+				return dirtyStatusFixture, nil
+			case args[0] == gitCmdStash && args[1] == gitSubcmdPush:
+				f.stashed = true
+			case args[0] == cmdRevParse && args[1] == "stash@{0}":
+				return stashSHATest, nil
+			case args[0] == cmdRevParse && args[len(args)-1] == "HEAD":
+				return f.heads[dir] + "\n", nil
+			case args[0] == gitCmdStash && args[1] == gitSubcmdList:
+				return stashListLine, nil
+			case args[0] == "diff":
+				return f.unmerged, nil
+			case slices.Contains(args, "--continue") || slices.Contains(args, "--abort"):
+				if f.unmerged != "" && slices.Contains(args, "--continue") {
+					return "", errors.New("needs merge")
+				}
+				return "", os.RemoveAll(stateDir)
+			case args[0] == "rebase" && dir == f.conflict:
+				_ = os.Mkdir(stateDir, 0o755)
+				return "", errors.New("could not apply abc")
+			}
+			return "", nil
+		},
+	}
+}
+
+func (f *syncRunFake) called(call string) bool {
+	return slices.Contains(f.calls, call)
+}
+
+func syncRunWorktrees() []resolver.WorktreeInfo {
+	return []resolver.WorktreeInfo{
+		{Branch: branchFeatureAuth, Path: pathWtAuth},
+		{Branch: branchBugfixLogin, Path: pathWtLogin},
+	}
+}
+
+func startTestSyncRun(t *testing.T, f *syncRunFake) *SyncRun {
+	t.Helper()
+	run, err := StartSyncRun(context.Background(), f.runner(), syncRunWorktrees(), func(string) string { return "main" }, SyncOptions{})
+	if err != nil {
+		t.Fatalf("StartSyncRun: %v", err)
+	}
+	return run
+}
+
+func loadRunState(t *testing.T, commonDir string) *syncrun.Run {
+	t.Helper()
+	state, err := syncrun.Load(commonDir)
+	if err != nil {
+		t.Fatalf("syncrun.Load: %v", err)
+	}
+	return state
+}
+
+func TestStartSyncRunRecordsTips(t *testing.T) {
+	f := newSyncRunFake(t, "")
+	startTestSyncRun(t, f)
+
+	state := loadRunState(t, f.commonDir)
+	if len(state.Worktrees) != 2 {
+		t.Fatalf("worktrees = %+v", state.Worktrees)
+	}
+	if w := state.Worktrees[0]; w.PreSHA != "pre-"+branchFeatureAuth || w.Status != syncrun.StatusPending || w.Base != "main" {
+		t.Errorf("worktree = %+v", w)
+	}
+
+	_, err := StartSyncRun(context.Background(), f.runner(), syncRunWorktrees(), func(string) string { return "main" }, SyncOptions{})
+	if err == nil || !strings.Contains(err.Error(), "already in progress") {
+		t.Errorf("second start err = %v, want already in progress", err)
+	}
+}
+
+func TestLoadSyncRunNone(t *testing.T) {
+	f := newSyncRunFake(t, "")
+	_, err := LoadSyncRun(context.Background(), f.runner())
+	if err == nil || !strings.Contains(err.Error(), "no sync run in progress") {
+		t.Errorf("err = %v, want no run", err)
+	}
+}
+
+func TestSyncWorktreeWithKeepConflictLeavesRebase(t *testing.T) {
+	f := newSyncRunFake(t, pathWtAuth)
+	wt := syncRunWorktrees()[0]
+
+	res := SyncWorktreeWith(context.Background(), f.runner(), "main", wt, keepConflict)
+	if !res.Failed || !res.Conflicted {
+		t.Fatalf("result = %+v, want conflicted", res)
+	}
+	if !strings.Contains(res.FailureHint, "rimba sync --continue") {
+		t.Errorf("hint = %q", res.FailureHint)
+	}
+	if f.called(pathWtAuth + ": rebase --abort") {
+		t.Error("conflicted rebase was aborted")
+	}
+}
+
+func TestSyncRunStopResumeFinish(t *testing.T) {
+	f := newSyncRunFake(t, pathWtAuth)
+	run := startTestSyncRun(t, f)
+	r := f.runner()
+
+	for _, wt := range syncRunWorktrees() {
+		if err := run.Record(context.Background(), r, SyncWorktreeWith(context.Background(), r, "main", wt, keepConflict)); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if !run.Stopped() {
+		t.Fatal("run not stopped after a conflict")
+	}
+	if done, _ := run.Finish(); done {
+		t.Fatal("stopped run finished")
+	}
+
+	// Conflicts still unstaged: the worktree stays conflicted.
+	resumed, err := run.ResumeConflicted(context.Background(), r)
+	if err != nil {
+		t.Fatalf("ResumeConflicted: %v", err)
+	}
+	if len(resumed) != 1 || !resumed[0].Conflicted || !strings.Contains(resumed[0].FailureHint, "conflict.go") {
+		t.Fatalf("resumed = %+v, want still conflicted in conflict.go", resumed)
+	}
+
+	f.unmerged = ""
+	resumed, err = run.ResumeConflicted(context.Background(), r)
+	if err != nil {
+		t.Fatalf("ResumeConflicted: %v", err)
+	}
+	if len(resumed) != 1 || !resumed[0].Synced {
+		t.Fatalf("resumed = %+v, want synced", resumed)
+	}
+	if !f.called(pathWtAuth + ": -c core.editor=true rebase --continue") {
+		t.Errorf("calls = %v, want rebase --continue", f.calls)
+	}
+	if done, err := run.Finish(); !done || err != nil {
+		t.Errorf("Finish = %v, %v; want true, nil", done, err)
+	}
+	if state := loadRunState(t, f.commonDir); state != nil {
+		t.Errorf("run still saved after finishing: %+v", state)
+	}
+}
+
+func TestSyncRunHoldsAutostashUntilResumed(t *testing.T) {
+	f := newSyncRunFake(t, pathWtAuth)
+	f.dirty = pathWtAuth
+	run := startTestSyncRun(t, f)
+	r := f.runner()
+	opts := SyncOptions{Autostash: true, KeepConflict: true}
+
+	res := SyncWorktreeWith(context.Background(), r, "main", syncRunWorktrees()[0], opts)
+	if !res.Conflicted || res.StashRef != stashSHATest {
+		t.Fatalf("result = %+v, want conflicted holding the stash", res)
+	}
+	if f.called(pathWtAuth + ": stash apply " + stashSHATest) {
+		t.Fatal("stash reapplied onto a stopped rebase")
+	}
+	if err := run.Record(context.Background(), r, res); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if w := loadRunState(t, f.commonDir).Worktrees[0]; w.Stash != stashSHATest {
+		t.Errorf("saved worktree = %+v, want stash recorded", w)
+	}
+
+	f.unmerged = ""
+	resumed, err := run.ResumeConflicted(context.Background(), r)
+	if err != nil {
+		t.Fatalf("ResumeConflicted: %v", err)
+	}
+	if len(resumed) != 1 || !resumed[0].Synced || !resumed[0].Stashed || resumed[0].StashRef != "" {
+		t.Fatalf("resumed = %+v, want synced with the stash reapplied", resumed)
+	}
+	if !f.called(pathWtAuth+": stash apply "+stashSHATest) || !f.called(pathWtAuth+": stash drop stash@{0}") {
+		t.Errorf("calls = %v, want stash applied and dropped", f.calls)
+	}
+}
+
+func TestAbortSyncRunReappliesAutostash(t *testing.T) {
+	f := newSyncRunFake(t, pathWtAuth)
+	f.dirty = pathWtAuth
+	run := startTestSyncRun(t, f)
+	r := f.runner()
+	res := SyncWorktreeWith(context.Background(), r, "main", syncRunWorktrees()[0], SyncOptions{Autostash: true, KeepConflict: true})
+	if err := run.Record(context.Background(), r, res); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	rollbacks, err := AbortSyncRun(context.Background(), r)
+	if err != nil || len(rollbacks) != 1 || rollbacks[0].Stash != "" {
+		t.Fatalf("AbortSyncRun = %+v, %v; want one clean rollback", rollbacks, err)
+	}
+	for _, want := range []string{
+		pathWtAuth + ": rebase --abort",
+		pathWtAuth + ": reset --hard pre-" + branchFeatureAuth,
+		pathWtAuth + ": stash apply " + stashSHATest,
+	} {
+		if !f.called(want) {
+			t.Errorf("calls = %v, want %q", f.calls, want)
+		}
+	}
+}
+
+func TestAbortSyncRunKeepsLaterChanges(t *testing.T) {
+	f := newSyncRunFake(t, "")
+	run := startTestSyncRun(t, f)
+	if err := run.Record(context.Background(), f.runner(), SyncWorktreeResult{Branch: branchFeatureAuth, Synced: true}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	f.dirty = pathWtAuth // edited since the sync
+
+	if _, err := AbortSyncRun(context.Background(), f.runner()); err != nil {
+		t.Fatalf("AbortSyncRun: %v", err)
+	}
+	stash := slices.IndexFunc(f.calls, func(c string) bool { return strings.HasPrefix(c, pathWtAuth+": stash push") })
+	reset := slices.Index(f.calls, pathWtAuth+": reset --hard pre-"+branchFeatureAuth)
+	apply := slices.Index(f.calls, pathWtAuth+": stash apply "+stashSHATest)
+	if stash < 0 || reset < stash || apply < reset {
+		t.Errorf("calls = %v, want stash, reset, then reapply", f.calls)
+	}
+}
+
+func TestAbortSyncRunLeavesMovedBranch(t *testing.T) {
+	f := newSyncRunFake(t, "")
+	run := startTestSyncRun(t, f)
+	f.tips = map[string]string{branchFeatureAuth: "synced"}
+	if err := run.Record(context.Background(), f.runner(), SyncWorktreeResult{Branch: branchFeatureAuth, Synced: true}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if w := loadRunState(t, f.commonDir).Worktrees[0]; w.PostSHA != "synced" {
+		t.Fatalf("saved worktree = %+v, want the synced tip recorded", w)
+	}
+	f.tips[branchFeatureAuth] = "committed-since"
+
+	rollbacks, err := AbortSyncRun(context.Background(), f.runner())
+	if err != nil || len(rollbacks) != 1 {
+		t.Fatalf("AbortSyncRun = %+v, %v; want one rollback", rollbacks, err)
+	}
+	rb := rollbacks[0]
+	if !rb.Moved || rb.SHA != "" || !strings.Contains(rb.MovedHint, "git reset --keep pre-"+branchFeatureAuth) {
+		t.Errorf("rollback = %+v, want the moved branch left alone with a hint", rb)
+	}
+	for _, c := range f.calls {
+		if strings.Contains(c, "reset") || strings.Contains(c, "stash") {
+			t.Errorf("touched the moved branch: %v", f.calls)
+		}
+	}
+	if state := loadRunState(t, f.commonDir); state != nil {
+		t.Errorf("run still saved after abort: %+v", state)
+	}
+}
+
+func TestAbortSyncRunLeavesConflictFinishedByHand(t *testing.T) {
+	f := newSyncRunFake(t, pathWtAuth)
+	f.heads = map[string]string{pathWtAuth: "stopped"}
+	run := startTestSyncRun(t, f)
+	r := f.runner()
+	res := SyncWorktreeWith(context.Background(), r, "main", syncRunWorktrees()[0], keepConflict)
+	if err := run.Record(context.Background(), r, res); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if w := loadRunState(t, f.commonDir).Worktrees[0]; w.StopSHA != "stopped" {
+		t.Fatalf("saved worktree = %+v, want the stop point recorded", w)
+	}
+	// The rebase is finished by hand and committed on.
+	if err := os.RemoveAll(filepath.Join(f.gitDirs[pathWtAuth], "rebase-merge")); err != nil {
+		t.Fatal(err)
+	}
+	f.heads[pathWtAuth] = "finished"
+	f.calls = nil
+
+	rollbacks, err := AbortSyncRun(context.Background(), r)
+	if err != nil || len(rollbacks) != 1 {
+		t.Fatalf("AbortSyncRun = %+v, %v; want one rollback", rollbacks, err)
+	}
+	if rb := rollbacks[0]; !rb.Moved || rb.SHA != "" {
+		t.Errorf("rollback = %+v, want the finished worktree left alone", rb)
+	}
+	for _, c := range f.calls {
+		if strings.Contains(c, "reset") || strings.Contains(c, "--abort") {
+			t.Errorf("touched the finished worktree: %v", f.calls)
+		}
+	}
+}
+
+func TestSyncRunResumeAbandoned(t *testing.T) {
+	f := newSyncRunFake(t, "")
+	f.notAncestor = true
+	run := startTestSyncRun(t, f)
+	if err := run.Record(context.Background(), f.runner(), SyncWorktreeResult{Branch: branchFeatureAuth, Failed: true, Conflicted: true}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	resumed, err := run.ResumeConflicted(context.Background(), f.runner())
+	if err != nil {
+		t.Fatalf("ResumeConflicted: %v", err)
+	}
+	if len(resumed) != 1 || !resumed[0].Skipped || resumed[0].SkipReason != "sync was abandoned" {
+		t.Errorf("resumed = %+v, want skipped as abandoned", resumed)
+	}
+}
+
+func TestAbortSyncRunRollsBack(t *testing.T) {
+	f := newSyncRunFake(t, pathWtLogin)
+	run := startTestSyncRun(t, f)
+	r := f.runner()
+	for _, wt := range syncRunWorktrees() {
+		sr := SyncWorktreeWith(context.Background(), r, "main", wt, keepConflict)
+		if err := run.Record(context.Background(), r, sr); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	rollbacks, err := AbortSyncRun(context.Background(), r)
+	if err != nil {
+		t.Fatalf("AbortSyncRun: %v", err)
+	}
+	if len(rollbacks) != 2 {
+		t.Fatalf("rollbacks = %+v, want 2", rollbacks)
+	}
+	for _, want := range []string{
+		pathWtLogin + ": rebase --abort",
+		pathWtLogin + ": reset --hard pre-" + branchBugfixLogin,
+		pathWtAuth + ": reset --hard pre-" + branchFeatureAuth,
+	} {
+		if !f.called(want) {
+			t.Errorf("calls = %v, want %q", f.calls, want)
+		}
+	}
+	if f.called(pathWtAuth + ": rebase --abort") {
+		t.Error("aborted a rebase that was not in progress")
+	}
+	if state := loadRunState(t, f.commonDir); state != nil {
+		t.Errorf("run still saved after abort: %+v", state)
+	}
+}
+
+func TestAbortSyncRunSkipsPending(t *testing.T) {
+	f := newSyncRunFake(t, "")
+	startTestSyncRun(t, f)
+
+	rollbacks, err := AbortSyncRun(context.Background(), f.runner())
+	if err != nil || len(rollbacks) != 0 {
+		t.Errorf("AbortSyncRun = %+v, %v; want nothing rolled back", rollbacks, err)
+	}
+	for _, c := range f.calls {
+		if strings.Contains(c, "reset") {
+			t.Errorf("reset a pending worktree: %v", f.calls)
+		}
+	}
+}
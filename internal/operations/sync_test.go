@@ -3,6 +3,7 @@ package operations
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 
@@ -270,6 +271,90 @@ func TestSyncWorktreeMergeFailure(t *testing.T) {
 	}
 }
 
+// autostashRunner serves a dirty worktree whose changes are stashed as
+// stashSHA; rebases fail with rebaseErr and stash applies with applyErr.
+// RunInDir calls are recorded in calls.
+func autostashRunner(calls *[]string, rebaseErr, applyErr error) *mockRunner {
+	const stashSHA = "stash123"
+	stashed := false
+	return &mockRunner{
+		run: func(_ ...string) (string, error) { return "", nil },
+		runInDir: func(_ string, args ...string) (string, error) {
+			*calls = append(*calls, strings.Join(args, " "))
+			switch {
+			case args[0] == gitCmdStatus && !stashed:
+				return dirtyStatusFixture, nil
+			case args[0] == gitCmdStash && args[1] == gitSubcmdPush:
+				stashed = true
+			case args[0] == cmdRevParse && args[1] == "stash@{0}":
+				return stashSHA, nil
+			case args[0] == gitCmdStash && args[1] == gitSubcmdList:
+				return stashSHA + " stash@{0}", nil
+			case args[0] == gitCmdStash && args[1] == gitSubcmdApply:
+				return "", applyErr
+			case args[0] == cmdRebase:
+				return "", rebaseErr
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestSyncWorktreeAutostash(t *testing.T) {
+	var calls []string
+	r := autostashRunner(&calls, nil, nil)
+	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
+	res := SyncWorktreeWith(context.Background(), r, branchMain, wt, SyncOptions{Autostash: true})
+
+	if !res.Synced || !res.Stashed {
+		t.Fatalf("result = %+v, want synced with autostash", res)
+	}
+	if res.StashRef != "" || res.StashError != "" {
+		t.Errorf("stash kept: %+v", res)
+	}
+	for _, want := range []string{
+		"stash push -u -m rimba: autostash sync " + branchFeature,
+		"stash apply stash123",
+		"stash drop stash@{0}",
+	} {
+		if !slices.Contains(calls, want) {
+			t.Errorf("calls = %v, want %q", calls, want)
+		}
+	}
+}
+
+func TestSyncWorktreeAutostashReapplyConflict(t *testing.T) {
+	var calls []string
+	r := autostashRunner(&calls, nil, errors.New("CONFLICT (content): Merge conflict in main.go"))
+	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
+	res := SyncWorktreeWith(context.Background(), r, branchMain, wt, SyncOptions{Autostash: true})
+
+	if !res.Synced || !res.StashConflict || res.StashRef != "stash123" {
+		t.Fatalf("result = %+v, want synced with the conflicted stash kept", res)
+	}
+	if !strings.Contains(res.StashHint, "git stash drop") {
+		t.Errorf("StashHint = %q, want drop instructions", res.StashHint)
+	}
+	if slices.ContainsFunc(calls, func(c string) bool { return strings.HasPrefix(c, "stash drop") }) {
+		t.Errorf("dropped a stash that did not reapply: %v", calls)
+	}
+}
+
+func TestSyncWorktreeAutostashSyncFailure(t *testing.T) {
+	var calls []string
+	r := autostashRunner(&calls, errors.New("conflict"), nil)
+	wt := resolver.WorktreeInfo{Branch: branchFeature, Path: pathWtFeatureLogin}
+	res := SyncWorktreeWith(context.Background(), r, branchMain, wt, SyncOptions{Autostash: true})
+
+	if !res.Failed || !res.Stashed {
+		t.Fatalf("result = %+v, want failed with autostash", res)
+	}
+	// The aborted rebase leaves the worktree as it was; the changes come back.
+	if !slices.Contains(calls, "stash apply stash123") || res.StashRef != "" {
+		t.Errorf("stash not reapplied after failed sync: %v, %+v", calls, res)
+	}
+}
+
 func TestPushBranchRebase(t *testing.T) {
 	var capturedArgs []string
 	r := &mockRunner{
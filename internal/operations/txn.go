@@ -0,0 +1,113 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Txn runs a multi-step operation as a unit. Each completed step may register
+// a compensating action; Rollback runs them newest first, so a failure — or a
+// cancelled ctx, as on Ctrl-C — leaves things as they were before the
+// operation began. A nil *Txn runs steps without recording them.
+type Txn struct {
+	// Keep leaves completed steps in place on failure (--keep-on-failure).
+	Keep bool
+
+	report TxnReport
+	undo   []txnUndo
+}
+
+// TxnReport is the outcome of a transaction, for results and JSON output.
+type TxnReport struct {
+	Completed  []string
+	Failed     string // the step that failed; empty on success
+	RolledBack bool
+	// RollbackErrors holds compensating actions that failed; what they
+	// were meant to undo is still in place.
+	RollbackErrors []error
+}
+
+type txnUndo struct {
+	step string
+	fn   func(ctx context.Context) error
+}
+
+// Transaction step names used by add, duplicate, and restore.
+const (
+	StepImportBundle    = "import bundle"
+	StepAddForkRemote   = "add fork remote"
+	StepCreateWorktree  = "create worktree"
+	StepRecordBase      = "record base"
+	StepLinkStack       = "link stack parent"
+	StepRestoreSnapshot = "reapply archived changes"
+	StepCopyFiles       = "copy files"
+	StepInstallDeps     = "install dependencies"
+	StepRunHooks        = "run hooks"
+)
+
+// Do runs fn as the step name. On success the step is recorded as completed
+// and undo, when non-nil, is registered to reverse it. A ctx cancelled before
+// or during the step fails it.
+func (t *Txn) Do(ctx context.Context, name string, fn func() error, undo func(ctx context.Context) error) error {
+	if t == nil {
+		return fn()
+	}
+	if err := ctx.Err(); err != nil {
+		t.report.Failed = name
+		return fmt.Errorf("interrupted before %s: %w", name, err)
+	}
+	err := fn()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		t.report.Failed = name
+		return err
+	}
+	t.report.Completed = append(t.report.Completed, name)
+	if undo != nil {
+		t.undo = append(t.undo, txnUndo{step: name, fn: undo})
+	}
+	return nil
+}
+
+// RollsBack reports whether a failure will undo the completed steps.
+func (t *Txn) RollsBack() bool {
+	return t != nil && !t.Keep
+}
+
+// Rollback reverses the completed steps, newest first, unless Keep is set,
+// and returns err annotated with what was undone or kept. Compensating
+// actions run on a fresh context: the operation's ctx may be the reason
+// for the failure.
+func (t *Txn) Rollback(err error) error {
+	if t == nil || len(t.report.Completed) == 0 {
+		return err
+	}
+	if t.Keep {
+		return fmt.Errorf("%w\nKept completed steps (--keep-on-failure): %s", err, strings.Join(t.report.Completed, ", "))
+	}
+
+	ctx := context.Background()
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		u := t.undo[i]
+		if uerr := u.fn(ctx); uerr != nil {
+			t.report.RollbackErrors = append(t.report.RollbackErrors, fmt.Errorf("undo %s: %w", u.step, uerr))
+		}
+	}
+	t.report.RolledBack = true
+	if len(t.report.RollbackErrors) > 0 {
+		return fmt.Errorf("%w\nRollback incomplete: %w", err, errors.Join(t.report.RollbackErrors...))
+	}
+	return fmt.Errorf("%w\nRolled back: %s", err, strings.Join(t.report.Completed, ", "))
+}
+
+// Report returns the transaction's outcome so far.
+func (t *Txn) Report() TxnReport {
+	if t == nil {
+		return TxnReport{}
+	}
+	return t.report
+}
@@ -0,0 +1,112 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const stepOne, stepTwo = "one", "two"
+
+var errStep = errors.New("step failed")
+
+func TestTxnRollbackNewestFirst(t *testing.T) {
+	txn := &Txn{}
+	var undone []string
+	for _, name := range []string{stepOne, stepTwo} {
+		err := txn.Do(context.Background(), name, func() error { return nil }, func(context.Context) error {
+			undone = append(undone, name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Do(%s): %v", name, err)
+		}
+	}
+	if err := txn.Do(context.Background(), "three", func() error { return errStep }, nil); !errors.Is(err, errStep) {
+		t.Fatalf("Do(three) = %v, want errStep", err)
+	}
+
+	err := txn.Rollback(errStep)
+	if !errors.Is(err, errStep) || !strings.Contains(err.Error(), "Rolled back: one, two") {
+		t.Errorf("Rollback = %v", err)
+	}
+	if !slices.Equal(undone, []string{stepTwo, stepOne}) {
+		t.Errorf("undone = %v, want newest first", undone)
+	}
+	report := txn.Report()
+	if !report.RolledBack || report.Failed != "three" || !slices.Equal(report.Completed, []string{stepOne, stepTwo}) {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestTxnKeepSkipsRollback(t *testing.T) {
+	txn := &Txn{Keep: true}
+	undone := false
+	_ = txn.Do(context.Background(), stepOne, func() error { return nil }, func(context.Context) error {
+		undone = true
+		return nil
+	})
+
+	err := txn.Rollback(errStep)
+	if undone || txn.Report().RolledBack {
+		t.Error("Keep should leave completed steps in place")
+	}
+	if !strings.Contains(err.Error(), "Kept completed steps (--keep-on-failure): one") {
+		t.Errorf("Rollback = %v", err)
+	}
+}
+
+func TestTxnRollbackErrors(t *testing.T) {
+	txn := &Txn{}
+	errUndo := errors.New("undo failed")
+	_ = txn.Do(context.Background(), stepOne, func() error { return nil }, func(context.Context) error { return errUndo })
+
+	err := txn.Rollback(errStep)
+	if !errors.Is(err, errStep) || !errors.Is(err, errUndo) || !strings.Contains(err.Error(), "Rollback incomplete") {
+		t.Errorf("Rollback = %v", err)
+	}
+	if len(txn.Report().RollbackErrors) != 1 {
+		t.Errorf("report = %+v", txn.Report())
+	}
+}
+
+func TestTxnCancelledContextFailsStep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	txn := &Txn{}
+	ran := false
+	err := txn.Do(ctx, stepOne, func() error {
+		ran = true
+		cancel()
+		return nil
+	}, nil)
+	if !ran || !errors.Is(err, context.Canceled) {
+		t.Errorf("Do = %v, ran %v; want the step to fail with context.Canceled", err, ran)
+	}
+
+	err = txn.Do(ctx, stepTwo, func() error {
+		t.Error("step should not run after cancellation")
+		return nil
+	}, nil)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "interrupted before two") {
+		t.Errorf("Do = %v", err)
+	}
+	if txn.Report().Failed != stepTwo {
+		t.Errorf("Failed = %q", txn.Report().Failed)
+	}
+}
+
+func TestTxnNil(t *testing.T) {
+	var txn *Txn
+	ran := false
+	if err := txn.Do(context.Background(), stepOne, func() error { ran = true; return nil }, nil); err != nil || !ran {
+		t.Fatalf("Do = %v, ran %v", err, ran)
+	}
+	if txn.RollsBack() {
+		t.Error("nil Txn should not roll back")
+	}
+	if err := txn.Rollback(errStep); err != errStep {
+		t.Errorf("Rollback = %v, want err unchanged", err)
+	}
+}
@@ -0,0 +1,174 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lugassawan/rimba/internal/errhint"
+	"github.com/lugassawan/rimba/internal/fileutil"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/journal"
+	"github.com/lugassawan/rimba/internal/meta"
+)
+
+// ErrNothingToUndo is returned by Undo when the journal is empty.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// JournalEntries returns up to n journaled operations, newest first.
+func JournalEntries(ctx context.Context, r git.Runner, n int) ([]journal.Entry, error) {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	j, err := journal.Load(commonDir)
+	if err != nil {
+		return nil, err
+	}
+	return j.Latest(n), nil
+}
+
+// Undo reverses the last n journaled operations, newest first, recreating
+// removed worktrees and deleted branches and reverting renames. Each step is
+// dropped from the journal as soon as it is reversed, so after a failure the
+// returned entries are the ones fully undone and a retry resumes where this
+// run stopped.
+func Undo(ctx context.Context, r git.Runner, n int) ([]journal.Entry, error) {
+	commonDir, err := stateDir(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	j, err := journal.Load(commonDir)
+	if err != nil {
+		return nil, err
+	}
+	entries := j.Latest(n)
+	if len(entries) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	var undone []journal.Entry
+	for _, e := range entries {
+		if err := undoEntry(ctx, r, commonDir, j, e); err != nil {
+			return undone, err
+		}
+		undone = append(undone, e)
+	}
+	return undone, nil
+}
+
+// undoEntry reverses e's steps last to first, saving the journal after each
+// and unpinning the branch tip it restored.
+func undoEntry(ctx context.Context, r git.Runner, commonDir string, j *journal.Journal, e journal.Entry) error {
+	steps := e.Steps
+	for len(steps) > 0 {
+		s := steps[len(steps)-1]
+		if err := undoStep(ctx, r, commonDir, e.ID, s); err != nil {
+			return fmt.Errorf("undo %s (%s): %w", e.Op, s.Desc, err)
+		}
+		steps = steps[:len(steps)-1]
+		j.Replace(journal.Entry{ID: e.ID, Op: e.Op, At: e.At, Steps: steps})
+		if err := j.Save(commonDir); err != nil {
+			return err
+		}
+		unpinStep(r, e.ID, s)
+	}
+	_ = os.RemoveAll(journal.EntryDir(commonDir, e.ID))
+	return nil
+}
+
+func undoStep(ctx context.Context, r git.Runner, commonDir, id string, s journal.Step) error {
+	switch s.Action {
+	case journal.ActionRemove:
+		return restoreRemoved(ctx, r, commonDir, id, s)
+	case journal.ActionRename:
+		return revertRename(ctx, r, s)
+	default:
+		return fmt.Errorf("unknown journal action %q", s.Action)
+	}
+}
+
+// restoreRemoved recreates a removed worktree, and its branch at the recorded
+// tip when the removal deleted it, then puts back its saved copy_files
+// entries, metadata and stack links.
+func restoreRemoved(ctx context.Context, r git.Runner, commonDir, id string, s journal.Step) error {
+	if _, err := os.Stat(s.Path); err == nil {
+		return errhint.WithFix(
+			fmt.Errorf("%s already exists", s.Path),
+			"move it aside, then retry: rimba undo",
+		)
+	}
+	if err := recreateBranch(ctx, r, s.Branch, s.SHA); err != nil {
+		return err
+	}
+	if err := git.AddWorktreeFromBranch(ctx, r, s.Path, s.Branch); err != nil {
+		return fmt.Errorf("recreate worktree: %w", err)
+	}
+	if len(s.Files) > 0 {
+		dir := journal.FilesDir(commonDir, id, s.Branch)
+		if _, _, err := fileutil.CopyEntries(dir, s.Path, s.Files); err != nil {
+			return errhint.WithFix(
+				fmt.Errorf("restore saved files: %w", err),
+				"copy them manually from "+dir,
+			)
+		}
+	}
+	if !s.Meta.IsZero() {
+		_, _ = UpdateMeta(ctx, r, s.Branch, func(m *meta.Meta) { *m = s.Meta }) // best-effort
+	}
+	restoreStacked(ctx, r, s.Stack)
+	return nil
+}
+
+// recreateBranch points branch back at sha. An empty sha means the removal
+// kept the branch; a branch already at sha is left alone so a retried undo
+// can proceed.
+func recreateBranch(ctx context.Context, r git.Runner, branch, sha string) error {
+	if sha == "" {
+		return nil
+	}
+	if git.BranchExists(ctx, r, branch) {
+		if cur, err := git.ResolveRef(ctx, r, "refs/heads/"+branch); err == nil && strings.TrimSpace(cur) == sha {
+			return nil
+		}
+		return errhint.WithFix(
+			fmt.Errorf("branch %q already exists at a different commit", branch),
+			fmt.Sprintf("rename or delete it, then retry: rimba undo  (the removed tip was %s)", sha),
+		)
+	}
+	if err := git.UpdateRef(ctx, r, "refs/heads/"+branch, sha); err != nil {
+		return errhint.WithFix(
+			fmt.Errorf("recreate branch %q: %w", branch, err),
+			fmt.Sprintf("commit %s may have been garbage-collected; look for it with: git reflog", sha),
+		)
+	}
+	return nil
+}
+
+// revertRename moves the worktree and branch back to their old names.
+func revertRename(ctx context.Context, r git.Runner, s journal.Step) error {
+	if git.BranchExists(ctx, r, s.Branch) {
+		return errhint.WithFix(
+			fmt.Errorf("branch %q already exists", s.Branch),
+			"rename or delete it, then retry: rimba undo",
+		)
+	}
+	if err := git.MoveWorktree(ctx, r, s.NewPath, s.Path, false); err != nil {
+		return errhint.WithFix(
+			fmt.Errorf("move worktree back: %w", err),
+			"check the worktree is still at "+s.NewPath+": rimba list",
+		)
+	}
+	if err := git.RenameBranch(ctx, r, s.NewBranch, s.Branch); err != nil {
+		if rbErr := git.MoveWorktree(context.Background(), r, s.Path, s.NewPath, false); rbErr != nil {
+			return fmt.Errorf("rename branch %q → %q: %w (moving the worktree back to %s also failed: %w)",
+				s.NewBranch, s.Branch, err, s.NewPath, rbErr)
+		}
+		return fmt.Errorf("rename branch %q → %q: %w", s.NewBranch, s.Branch, err)
+	}
+	renameStacked(ctx, r, s.NewBranch, s.Branch)
+	renameMeta(ctx, r, s.NewBranch, s.Branch)
+	return nil
+}
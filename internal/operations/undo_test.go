@@ -0,0 +1,264 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/git"
+	"github.com/lugassawan/rimba/internal/journal"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/internal/stack"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+const branchUndo = "feature/undo"
+
+// undoWorktree creates a linked worktree for branchUndo with one commit of
+// its own and an ignored .env, returning a ctx whose config copies .env.
+func undoWorktree(t *testing.T) (ctx context.Context, repo, wtPath, head string) {
+	t.Helper()
+	repo = testutil.NewTestRepo(t)
+	wtPath = filepath.Join(t.TempDir(), "undo")
+	testutil.GitCmd(t, repo, "worktree", "add", "-b", branchUndo, wtPath)
+	testutil.CreateFile(t, filepath.Join(repo, ".git", "info"), "exclude", envFile+"\n")
+	testutil.CreateFile(t, wtPath, "work.txt", "work")
+	testutil.GitCmd(t, wtPath, "add", "work.txt")
+	testutil.GitCmd(t, wtPath, "commit", "-m", "work")
+	testutil.CreateFile(t, wtPath, envFile, "TOKEN=undo")
+	head = strings.TrimSpace(testutil.GitCmd(t, wtPath, "rev-parse", "HEAD"))
+	ctx = config.WithConfig(context.Background(), &config.Config{CopyFiles: []string{envFile}})
+	return ctx, repo, wtPath, head
+}
+
+func TestUndoRemoveRecreatesWorktreeAndBranch(t *testing.T) {
+	ctx, repo, wtPath, head := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+	if _, err := UpdateMeta(ctx, r, branchUndo, func(m *meta.Meta) { m.Note = "keep me" }); err != nil {
+		t.Fatalf("UpdateMeta: %v", err)
+	}
+
+	wt := resolver.WorktreeInfo{Path: wtPath, Branch: branchUndo}
+	if _, err := RemoveWorktree(ctx, r, wt, "undo", false, true, nil); err != nil {
+		t.Fatalf("RemoveWorktree: %v", err)
+	}
+	if git.BranchExists(ctx, r, branchUndo) {
+		t.Fatal("branch should be deleted")
+	}
+
+	entries, err := JournalEntries(ctx, r, 10)
+	if err != nil || len(entries) != 1 || entries[0].Op != "remove" {
+		t.Fatalf("journal = %+v, %v", entries, err)
+	}
+	pin := journal.Ref(entries[0].ID, branchUndo)
+	if got, _ := git.ResolveRef(ctx, r, pin); got != head {
+		t.Errorf("%s at %q, want the deleted tip %s pinned", pin, got, head)
+	}
+
+	undone, err := Undo(ctx, r, 1)
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if len(undone) != 1 {
+		t.Fatalf("undone = %+v", undone)
+	}
+	if _, err := git.ResolveRef(ctx, r, pin); err == nil {
+		t.Errorf("%s still pinned after undo", pin)
+	}
+	if got, _ := git.ResolveRef(ctx, r, "refs/heads/"+branchUndo); got != head {
+		t.Errorf("branch at %s, want %s", got, head)
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "work.txt")); err != nil {
+		t.Errorf("worktree not recreated: %v", err)
+	}
+	if env, _ := os.ReadFile(filepath.Join(wtPath, envFile)); string(env) != "TOKEN=undo" {
+		t.Errorf(".env = %q", env)
+	}
+	if s, _ := LoadMeta(ctx, r); s.Get(branchUndo).Note != "keep me" {
+		t.Error("metadata should be restored")
+	}
+	if entries, _ := JournalEntries(ctx, r, 10); len(entries) != 0 {
+		t.Errorf("journal = %+v, want empty after undo", entries)
+	}
+}
+
+func TestJournalRotationUnpinsDroppedTips(t *testing.T) {
+	ctx, repo, wtPath, head := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+	commonDir := filepath.Join(repo, ".git")
+
+	j := &journal.Journal{}
+	for i := range journal.MaxEntries {
+		j.Append(journal.Entry{ID: fmt.Sprintf("old%02d", i), Op: "remove", Steps: []journal.Step{
+			{Action: journal.ActionRemove, Branch: "feature/gone", Path: "/gone", SHA: head},
+		}})
+	}
+	if err := j.Save(commonDir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	oldest := journal.Ref("old00", "feature/gone")
+	testutil.GitCmd(t, repo, "update-ref", oldest, head)
+
+	wt := resolver.WorktreeInfo{Path: wtPath, Branch: branchUndo}
+	if _, err := RemoveWorktree(ctx, r, wt, "undo", false, true, nil); err != nil {
+		t.Fatalf("RemoveWorktree: %v", err)
+	}
+	if _, err := git.ResolveRef(ctx, r, oldest); err == nil {
+		t.Errorf("%s still pinned after its entry was dropped", oldest)
+	}
+}
+
+func TestUndoRemoveRestoresStackLinks(t *testing.T) {
+	ctx, repo, wtPath, head := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+	const base, child = "feature/undo-base", "feature/undo-child"
+	testutil.GitCmd(t, repo, "branch", base)
+	testutil.GitCmd(t, repo, "branch", child, branchUndo)
+	links := map[string]stack.Link{
+		branchUndo: {Parent: base, Base: "sha-base"},
+		child:      {Parent: branchUndo, Base: head},
+	}
+	seedStack(t, filepath.Join(repo, ".git"), links)
+
+	wt := resolver.WorktreeInfo{Path: wtPath, Branch: branchUndo}
+	if _, err := RemoveWorktree(ctx, r, wt, "undo", false, true, nil); err != nil {
+		t.Fatalf("RemoveWorktree: %v", err)
+	}
+	if s, _ := stack.Load(filepath.Join(repo, ".git")); s.Links[child].Parent != base {
+		t.Fatalf("child link = %+v, want it moved onto %s", s.Links[child], base)
+	}
+
+	if _, err := Undo(ctx, r, 1); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	s, err := stack.Load(filepath.Join(repo, ".git"))
+	if err != nil {
+		t.Fatalf("stack.Load: %v", err)
+	}
+	if !reflect.DeepEqual(s.Links, links) {
+		t.Errorf("links = %+v, want %+v", s.Links, links)
+	}
+}
+
+func TestUndoRemoveKeepBranch(t *testing.T) {
+	ctx, repo, wtPath, _ := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	wt := resolver.WorktreeInfo{Path: wtPath, Branch: branchUndo}
+	if _, err := RemoveWorktree(ctx, r, wt, "undo", true, true, nil); err != nil {
+		t.Fatalf("RemoveWorktree: %v", err)
+	}
+	if _, err := Undo(ctx, r, 1); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "work.txt")); err != nil {
+		t.Errorf("worktree not recreated: %v", err)
+	}
+}
+
+func TestUndoRename(t *testing.T) {
+	ctx, repo, wtPath, _ := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	res, err := RenameWorktree(ctx, r, RenameParams{
+		WT:      resolver.WorktreeInfo{Path: wtPath, Branch: branchUndo},
+		NewTask: "redo",
+		WtDir:   t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("RenameWorktree: %v", err)
+	}
+
+	if _, err := Undo(ctx, r, 1); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if !git.BranchExists(ctx, r, branchUndo) || git.BranchExists(ctx, r, res.NewBranch) {
+		t.Errorf("branch should be back to %s", branchUndo)
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "work.txt")); err != nil {
+		t.Errorf("worktree not moved back: %v", err)
+	}
+}
+
+func TestUndoMultipleNewestFirst(t *testing.T) {
+	ctx, repo, wtPath, _ := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	res, err := RenameWorktree(ctx, r, RenameParams{
+		WT:      resolver.WorktreeInfo{Path: wtPath, Branch: branchUndo},
+		NewTask: "redo",
+		WtDir:   t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("RenameWorktree: %v", err)
+	}
+	renamed := resolver.WorktreeInfo{Path: res.NewPath, Branch: res.NewBranch}
+	if _, err := RemoveWorktree(ctx, r, renamed, "redo", false, true, nil); err != nil {
+		t.Fatalf("RemoveWorktree: %v", err)
+	}
+
+	undone, err := Undo(ctx, r, 2)
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if len(undone) != 2 || undone[0].Op != "remove" || undone[1].Op != "rename" {
+		t.Fatalf("undone = %+v, want remove then rename", undone)
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "work.txt")); err != nil {
+		t.Errorf("worktree not back at its original path: %v", err)
+	}
+}
+
+func TestUndoRefusesOccupiedPath(t *testing.T) {
+	ctx, repo, wtPath, _ := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	wt := resolver.WorktreeInfo{Path: wtPath, Branch: branchUndo}
+	if _, err := RemoveWorktree(ctx, r, wt, "undo", false, true, nil); err != nil {
+		t.Fatalf("RemoveWorktree: %v", err)
+	}
+	if err := os.MkdirAll(wtPath, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Undo(ctx, r, 1)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("err = %v, want occupied-path error", err)
+	}
+	if entries, _ := JournalEntries(ctx, r, 10); len(entries) != 1 {
+		t.Errorf("journal = %+v, failed undo must keep the entry", entries)
+	}
+}
+
+func TestUndoNothing(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	_, err := Undo(context.Background(), &git.ExecRunner{Dir: repo}, 1)
+	if !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("err = %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestRemoveCandidatesJournalsEachRemoval(t *testing.T) {
+	ctx, repo, wtPath, _ := undoWorktree(t)
+	r := &git.ExecRunner{Dir: repo}
+
+	items := RemoveCandidates(ctx, r, []CleanCandidate{{Path: wtPath, Branch: branchUndo}}, false, true, nil)
+	if items[0].Error != nil {
+		t.Fatalf("RemoveCandidates: %v", items[0].Error)
+	}
+	entries, err := JournalEntries(ctx, r, 10)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("journal = %+v, %v", entries, err)
+	}
+	step := entries[0].Steps[0]
+	if entries[0].Op != "clean" || step.Action != journal.ActionRemove || step.SHA == "" || len(step.Files) != 1 {
+		t.Errorf("entry = %+v", entries[0])
+	}
+}
@@ -1,6 +1,10 @@
 package output
 
-import "github.com/lugassawan/rimba/internal/resolver"
+import (
+	"github.com/lugassawan/rimba/internal/journal"
+	"github.com/lugassawan/rimba/internal/meta"
+	"github.com/lugassawan/rimba/internal/resolver"
+)
 
 // ListItem represents a worktree entry in JSON output.
 // PRNumber and CIStatus are set under --full; nil means unknown.
@@ -14,13 +18,30 @@ type ListItem struct {
 	Status    resolver.WorktreeStatus `json:"status"`
 	PRNumber  *int                    `json:"pr_number,omitempty"`
 	CIStatus  *string                 `json:"ci_status,omitempty"`
+	Parent    string                  `json:"parent,omitempty"`
+	Meta      meta.Meta               `json:"meta,omitzero"`
+}
+
+// MetaData is the JSON output for the note and tag commands.
+type MetaData struct {
+	Task   string    `json:"task"`
+	Branch string    `json:"branch"`
+	Meta   meta.Meta `json:"meta"`
+}
+
+// UndoData is the JSON output for the undo command: the entries undone, or
+// with List set, the journal entries shown.
+type UndoData struct {
+	List    bool            `json:"list"`
+	Entries []journal.Entry `json:"entries"`
 }
 
 // ListArchivedItem represents an archived branch in JSON output.
 type ListArchivedItem struct {
-	Task   string `json:"task"`
-	Type   string `json:"type"`
-	Branch string `json:"branch"`
+	Task   string    `json:"task"`
+	Type   string    `json:"type"`
+	Branch string    `json:"branch"`
+	Meta   meta.Meta `json:"meta,omitzero"`
 }
 
 // StatusData is the top-level JSON output for the status command.
@@ -57,6 +78,15 @@ type StatusItem struct {
 	Age       *StatusAge              `json:"age"`
 	SizeBytes *int64                  `json:"size_bytes,omitempty"`
 	Recent7D  *int                    `json:"recent_7d,omitempty"`
+	Parent    string                  `json:"parent,omitempty"`
+	Base      *StatusBase             `json:"base,omitempty"`
+}
+
+// StatusBase compares a worktree against the non-main base it tracks.
+type StatusBase struct {
+	Branch string `json:"branch"`
+	Ahead  int    `json:"ahead"`
+	Behind int    `json:"behind"`
 }
 
 // StatusAge holds last-commit age information.
@@ -119,12 +149,25 @@ type AddData struct {
 	Branch          string           `json:"branch"`
 	Path            string           `json:"path"`
 	Source          string           `json:"source,omitempty"`
+	Parent          string           `json:"parent,omitempty"`
 	PRNumber        *int             `json:"pr_number,omitempty"`
 	Copied          []string         `json:"copied"`
 	Skipped         []string         `json:"skipped"`
 	SkippedSymlinks []string         `json:"skipped_symlinks"`
 	Deps            []DepResultJSON  `json:"deps"`
 	Hooks           []HookResultJSON `json:"hooks"`
+	// Transaction reports the steps of a task or pr add; Error is set when
+	// one of them failed.
+	Transaction *TransactionJSON `json:"transaction,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// TransactionJSON mirrors operations.TxnReport for JSON output (add).
+type TransactionJSON struct {
+	Completed      []string `json:"completed"`
+	Failed         string   `json:"failed,omitempty"`
+	RolledBack     bool     `json:"rolled_back"`
+	RollbackErrors []string `json:"rollback_errors,omitempty"`
 }
 
 // MergeData is the top-level JSON output for the merge command.
@@ -141,6 +184,24 @@ type MergeData struct {
 	RemoteError     string   `json:"remote_error,omitempty"`
 	DryRun          bool     `json:"dry_run"`
 	Steps           []string `json:"steps,omitempty"`
+	// LockfilesRegenerated lists lockfiles whose conflicts were resolved by
+	// regenerating them ([deps] resolve_lockfiles).
+	LockfilesRegenerated []string `json:"lockfiles_regenerated,omitempty"`
+	// Squashed counts the commits --squash folded into one commit, whose
+	// message is CommitMessage.
+	Squashed      int    `json:"squashed,omitempty"`
+	CommitMessage string `json:"commit_message,omitempty"`
+	// Verify is the outcome of the [merge] verify commands; omitted when
+	// none ran.
+	Verify *MergeVerifyJSON `json:"verify,omitempty"`
+}
+
+// MergeVerifyJSON is the outcome of verifying a merge in a scratch worktree
+// before it landed.
+type MergeVerifyJSON struct {
+	Commands []string `json:"commands"`
+	Passed   bool     `json:"passed"`
+	Failure  string   `json:"failure,omitempty"`
 }
 
 // RemoveData is the top-level JSON output for the remove command.
@@ -172,6 +233,36 @@ type RenameData struct {
 	Hooks          []HookResultJSON `json:"hooks"`
 }
 
+// RebaseData is the top-level JSON output for the rebase command.
+type RebaseData struct {
+	Branch string   `json:"branch"`
+	Path   string   `json:"path"`
+	From   string   `json:"from"`
+	Onto   string   `json:"onto"`
+	DryRun bool     `json:"dry_run"`
+	Steps  []string `json:"steps"`
+}
+
+// BackportData is the top-level JSON output for the backport command.
+type BackportData struct {
+	Branch  string               `json:"branch"`
+	Commits []string             `json:"commits"`
+	DryRun  bool                 `json:"dry_run"`
+	Targets []BackportTargetJSON `json:"targets"`
+}
+
+// BackportTargetJSON describes the backport to one target branch. Status is
+// "picked", "conflict", "failed", or "planned" under --dry-run.
+type BackportTargetJSON struct {
+	Target    string   `json:"target"`
+	Branch    string   `json:"branch"`
+	Path      string   `json:"path"`
+	Status    string   `json:"status"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	Resume    string   `json:"resume,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
 // CleanCandidateJSON describes one worktree eligible for removal in
 // list-mode clean (--merged/--stale), before removal has happened.
 type CleanCandidateJSON struct {
@@ -223,10 +314,24 @@ type SyncSummary struct {
 	Pushed       int `json:"pushed"`
 	PushSkipped  int `json:"push_skipped"`
 	PushFailed   int `json:"push_failed"`
+	Pending      int `json:"pending,omitempty"`
+	Stashed      int `json:"stashed,omitempty"`
+	// PredictedConflicts counts worktrees skipped (or, with --predict-only,
+	// reported) because a conflict with their base was predicted.
+	PredictedConflicts int `json:"predicted_conflicts,omitempty"`
 }
 
 // SyncWorktreeJSON holds the outcome of syncing a single worktree.
 // Planned is set (true) only under --dry-run, where no sync actually ran.
+// Conflicted and Pending describe a --stop-on-conflict run: the worktree
+// left mid-conflict, and those the run has not reached yet. Stashed marks a
+// dirty worktree synced with --autostash; StashRef is set while its stash is
+// kept — held by a stopped run, or because it could not be reapplied.
+// Base and BaseSHA name the ref the worktree was synced onto and its tip.
+// PredictedConflict marks a worktree skipped by --predict, with the files
+// git merge-tree expects to conflict; PredictError says why none was made.
+// LockfilesRegenerated lists lockfiles whose conflicts were resolved by
+// regenerating them ([deps] resolve_lockfiles).
 type SyncWorktreeJSON struct {
 	Branch      string `json:"branch"`
 	Synced      bool   `json:"synced"`
@@ -234,21 +339,101 @@ type SyncWorktreeJSON struct {
 	SkipReason  string `json:"skip_reason,omitempty"`
 	Failed      bool   `json:"failed"`
 	FailureHint string `json:"failure_hint,omitempty"`
+	Conflicted  bool   `json:"conflicted,omitempty"`
+	Pending     bool   `json:"pending,omitempty"`
+	Onto        string `json:"onto,omitempty"`
+	Base        string `json:"base,omitempty"`
+	BaseSHA     string `json:"base_sha,omitempty"`
 	Pushed      bool   `json:"pushed"`
 	PushSkipped bool   `json:"push_skipped"`
 	PushFailed  bool   `json:"push_failed"`
 	PushError   string `json:"push_error,omitempty"`
 	Planned     bool   `json:"planned,omitempty"`
+
+	PredictedConflict bool     `json:"predicted_conflict,omitempty"`
+	ConflictFiles     []string `json:"conflict_files,omitempty"`
+	PredictError      string   `json:"predict_error,omitempty"`
+
+	LockfilesRegenerated []string `json:"lockfiles_regenerated,omitempty"`
+
+	Stashed       bool   `json:"stashed,omitempty"`
+	StashRef      string `json:"stash_ref,omitempty"`
+	StashConflict bool   `json:"stash_conflict,omitempty"`
+	StashError    string `json:"stash_error,omitempty"`
+	StashHint     string `json:"stash_hint,omitempty"`
+}
+
+// SyncRollbackJSON describes one worktree reset by `rimba sync --abort`.
+// Pushed warns that the synced branch had already been pushed.
+type SyncRollbackJSON struct {
+	Branch  string `json:"branch"`
+	Path    string `json:"path"`
+	ResetTo string `json:"reset_to"`
+	Pushed  bool   `json:"pushed,omitempty"`
+	// Moved marks a branch left as it is because it has new commits since
+	// the sync; ResetTo is then empty and MovedHint says how to roll it back.
+	Moved     bool   `json:"moved,omitempty"`
+	MovedHint string `json:"moved_hint,omitempty"`
+	// StashRef holds uncommitted changes that could not be reapplied after the reset.
+	StashRef  string `json:"stash_ref,omitempty"`
+	StashHint string `json:"stash_hint,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SyncAbortData is the JSON output of `rimba sync --abort`.
+type SyncAbortData struct {
+	Aborted   bool               `json:"aborted"`
+	Worktrees []SyncRollbackJSON `json:"worktrees"`
+}
+
+// MergeQueueBranchJSON describes one branch a `rimba merge-queue` run
+// reached. PreSHA is main's tip before it; SHA is main's tip once it landed.
+type MergeQueueBranchJSON struct {
+	Branch string `json:"branch"`
+	Landed bool   `json:"landed"`
+	PreSHA string `json:"pre_sha"`
+	SHA    string `json:"sha,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// MergeQueueData is the JSON output of `rimba merge-queue`. Landed lists
+// every branch the queue has landed, earlier runs included; Pending lists
+// those still queued behind the branch it stopped on.
+type MergeQueueData struct {
+	Target   string                 `json:"target"`
+	Stopped  bool                   `json:"stopped"`
+	Aborted  bool                   `json:"aborted,omitempty"`
+	Branches []MergeQueueBranchJSON `json:"branches"`
+	Landed   []string               `json:"landed"`
+	Pending  []string               `json:"pending"`
+}
+
+// SyncFetchJSON describes the fetch made by `rimba sync --fetch`. Head is
+// the remote's default branch; HeadChangedFrom is set when it changed.
+// FastForwardSkipped says why the main worktree's local default branch
+// was not fast-forwarded.
+type SyncFetchJSON struct {
+	Remote             string `json:"remote"`
+	DefaultBranch      string `json:"default_branch"`
+	Head               string `json:"head,omitempty"`
+	SHA                string `json:"sha"`
+	HeadChangedFrom    string `json:"head_changed_from,omitempty"`
+	FastForwarded      bool   `json:"fast_forwarded"`
+	FastForwardSkipped string `json:"fast_forward_skipped,omitempty"`
 }
 
 // SyncData is the top-level JSON output for the sync command.
 type SyncData struct {
-	MainBranch string             `json:"main_branch"`
-	Method     string             `json:"method"`
-	All        bool               `json:"all"`
-	DryRun     bool               `json:"dry_run"`
-	Summary    SyncSummary        `json:"summary"`
-	Worktrees  []SyncWorktreeJSON `json:"worktrees"`
+	MainBranch  string             `json:"main_branch"`
+	Method      string             `json:"method"`
+	All         bool               `json:"all"`
+	Stack       bool               `json:"stack,omitempty"`
+	DryRun      bool               `json:"dry_run"`
+	PredictOnly bool               `json:"predict_only,omitempty"`
+	Stopped     bool               `json:"stopped,omitempty"`
+	Fetch       *SyncFetchJSON     `json:"fetch,omitempty"`
+	Summary     SyncSummary        `json:"summary"`
+	Worktrees   []SyncWorktreeJSON `json:"worktrees"`
 }
 
 // ReportEnvHeader is the environment header included in every `rimba report`
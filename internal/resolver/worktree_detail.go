@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+
+	"github.com/lugassawan/rimba/internal/meta"
 )
 
 // WorktreeStatus holds the structured git status of a worktree.
@@ -24,6 +26,8 @@ type WorktreeDetail struct {
 	Path      string         `json:"path"`
 	IsCurrent bool           `json:"is_current"`
 	Status    WorktreeStatus `json:"status"`
+	Parent    string         `json:"parent,omitempty"` // stack parent branch, when stacked
+	Meta      meta.Meta      `json:"meta,omitzero"`
 }
 
 // NewWorktreeDetail constructs a WorktreeDetail by resolving the task name, service,
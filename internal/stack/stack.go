@@ -0,0 +1,223 @@
+// Package stack records parent/child links between stacked worktree branches
+// so a child can be restacked onto its parent's new tip.
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/lugassawan/rimba/internal/fsutil"
+)
+
+// Link is one child's position in a stack.
+type Link struct {
+	// Parent is the branch the child is stacked on. Empty once the parent
+	// has merged: the next restack moves the child onto the default branch.
+	Parent string `json:"parent,omitempty"`
+	// Base is the parent tip the child was last stacked on — the upstream
+	// for `git rebase --onto`. Empty means "use the merge-base".
+	Base string `json:"base,omitempty"`
+}
+
+// Store maps each stacked child branch to its Link.
+type Store struct {
+	Links map[string]Link `json:"links"`
+}
+
+// storeFile is where the stack links live, relative to the git common dir.
+const storeFile = "rimba/stacks.json"
+
+// Load reads the store under commonDir. A missing file yields an empty store.
+func Load(commonDir string) (*Store, error) {
+	s := &Store{Links: make(map[string]Link)}
+	data, err := os.ReadFile(storePath(commonDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read stack store: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse stack store: %w", err)
+	}
+	if s.Links == nil {
+		s.Links = make(map[string]Link)
+	}
+	return s, nil
+}
+
+// Save writes the store under commonDir atomically.
+func (s *Store) Save(commonDir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal stack store: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(storePath(commonDir), data); err != nil {
+		return fmt.Errorf("write stack store: %w", err)
+	}
+	return nil
+}
+
+// Set stacks child on parent at base. It refuses links that would form a cycle.
+func (s *Store) Set(child, parent, base string) error {
+	if child == parent || slices.Contains(s.Descendants(child), parent) {
+		return fmt.Errorf("cannot stack %q on %q: it would form a cycle", child, parent)
+	}
+	s.Links[child] = Link{Parent: parent, Base: base}
+	return nil
+}
+
+// Parents returns child → parent for every link whose parent is still a branch.
+func (s *Store) Parents() map[string]string {
+	parents := make(map[string]string, len(s.Links))
+	for child, l := range s.Links {
+		if l.Parent != "" {
+			parents[child] = l.Parent
+		}
+	}
+	return parents
+}
+
+// Children returns the branches stacked directly on parent, sorted.
+func (s *Store) Children(parent string) []string {
+	var children []string
+	for child, l := range s.Links {
+		if l.Parent == parent {
+			children = append(children, child)
+		}
+	}
+	slices.Sort(children)
+	return children
+}
+
+// Descendants returns every branch stacked (transitively) on root, parents
+// before children.
+func (s *Store) Descendants(root string) []string {
+	var out []string
+	seen := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, c := range s.Children(cur) {
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			out = append(out, c)
+			queue = append(queue, c)
+		}
+	}
+	return out
+}
+
+// Order returns the branches to restack in topological order. With a root,
+// that is root itself (when it is stacked) followed by its descendants; with
+// root == "" it is every linked branch.
+func (s *Store) Order(root string) []string {
+	if root != "" {
+		var out []string
+		if _, ok := s.Links[root]; ok {
+			out = append(out, root)
+		}
+		return append(out, s.Descendants(root)...)
+	}
+
+	var roots []string
+	for child, l := range s.Links {
+		if _, stacked := s.Links[l.Parent]; !stacked {
+			roots = append(roots, child)
+		}
+	}
+	slices.Sort(roots)
+
+	var out []string
+	for _, r := range roots {
+		out = append(out, r)
+		out = append(out, s.Descendants(r)...)
+	}
+	return out
+}
+
+// Remove drops branch from the store. Its children take over its own link
+// when merged is false — they still carry its commits — or move to
+// branch's parent, keeping their base, when its commits have merged.
+func (s *Store) Remove(branch string, merged bool) {
+	own, stacked := s.Links[branch]
+	delete(s.Links, branch)
+
+	for _, child := range s.Children(branch) {
+		switch {
+		case merged:
+			s.Links[child] = Link{Parent: own.Parent, Base: s.Links[child].Base}
+		case stacked:
+			s.Links[child] = own
+		default:
+			delete(s.Links, child)
+		}
+	}
+}
+
+// Rename moves oldBranch's link and its children's parent pointers to newBranch.
+func (s *Store) Rename(oldBranch, newBranch string) {
+	if l, ok := s.Links[oldBranch]; ok {
+		delete(s.Links, oldBranch)
+		s.Links[newBranch] = l
+	}
+	for child, l := range s.Links {
+		if l.Parent == oldBranch {
+			l.Parent = newBranch
+			s.Links[child] = l
+		}
+	}
+}
+
+// Tree orders branches so every child directly follows its parent, keeping
+// the input order among roots and siblings. It returns the permutation of
+// input indices and each position's depth (0 for roots). A parent that is
+// absent from branches makes the child a root.
+func Tree(branches []string, parents map[string]string) (order, depths []int) {
+	index := make(map[string]int, len(branches))
+	for i, b := range branches {
+		index[b] = i
+	}
+
+	children := make(map[int][]int)
+	var roots []int
+	for i, b := range branches {
+		if p, ok := index[parents[b]]; ok && parents[b] != "" && p != i {
+			children[p] = append(children[p], i)
+			continue
+		}
+		roots = append(roots, i)
+	}
+
+	visited := make([]bool, len(branches))
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		order = append(order, i)
+		depths = append(depths, depth)
+		for _, c := range children[i] {
+			walk(c, depth+1)
+		}
+	}
+	for _, r := range roots {
+		walk(r, 0)
+	}
+	// A corrupt store could describe a cycle with no root; keep those rows.
+	for i := range branches {
+		walk(i, 0)
+	}
+	return order, depths
+}
+
+func storePath(commonDir string) string {
+	return filepath.Join(commonDir, storeFile)
+}
@@ -0,0 +1,159 @@
+package stack_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/stack"
+)
+
+const (
+	branchAuth  = "feature/auth"
+	branchUI    = "feature/auth-ui"
+	branchTests = "feature/auth-tests"
+	branchOther = "feature/other"
+	shaA        = "aaa111"
+	shaB        = "bbb222"
+)
+
+func newChain(t *testing.T) *stack.Store {
+	t.Helper()
+	s := &stack.Store{Links: make(map[string]stack.Link)}
+	if err := s.Set(branchUI, branchAuth, shaA); err != nil {
+		t.Fatalf("Set ui: %v", err)
+	}
+	if err := s.Set(branchTests, branchUI, shaB); err != nil {
+		t.Fatalf("Set tests: %v", err)
+	}
+	return s
+}
+
+func TestLoadMissingReturnsEmpty(t *testing.T) {
+	s, err := stack.Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(s.Links) != 0 {
+		t.Errorf("Links = %v, want empty", s.Links)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s := newChain(t)
+	if err := s.Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := stack.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Links[branchTests] != (stack.Link{Parent: branchUI, Base: shaB}) {
+		t.Errorf("Links[%s] = %+v", branchTests, got.Links[branchTests])
+	}
+}
+
+func TestSetRejectsCycle(t *testing.T) {
+	s := newChain(t)
+	if err := s.Set(branchAuth, branchTests, shaA); err == nil {
+		t.Fatal("expected cycle error")
+	}
+	if err := s.Set(branchAuth, branchAuth, shaA); err == nil {
+		t.Fatal("expected self-link error")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	s := newChain(t)
+	if err := s.Set(branchOther, "", shaA); err != nil {
+		t.Fatalf("Set other: %v", err)
+	}
+
+	if got, want := s.Order(branchAuth), []string{branchUI, branchTests}; !slices.Equal(got, want) {
+		t.Errorf("Order(auth) = %v, want %v", got, want)
+	}
+	if got, want := s.Order(branchUI), []string{branchUI, branchTests}; !slices.Equal(got, want) {
+		t.Errorf("Order(ui) = %v, want %v", got, want)
+	}
+	if got, want := s.Order(""), []string{branchUI, branchTests, branchOther}; !slices.Equal(got, want) {
+		t.Errorf("Order(\"\") = %v, want %v", got, want)
+	}
+}
+
+func TestRemoveMergedMovesChildrenToGrandparent(t *testing.T) {
+	s := newChain(t)
+	s.Remove(branchUI, true)
+
+	if _, ok := s.Links[branchUI]; ok {
+		t.Error("removed branch still linked")
+	}
+	if got, want := s.Links[branchTests], (stack.Link{Parent: branchAuth, Base: shaB}); got != want {
+		t.Errorf("child link = %+v, want %+v", got, want)
+	}
+}
+
+func TestRemoveUnmergedInheritsParentLink(t *testing.T) {
+	s := newChain(t)
+	s.Remove(branchUI, false)
+
+	if got, want := s.Links[branchTests], (stack.Link{Parent: branchAuth, Base: shaA}); got != want {
+		t.Errorf("child link = %+v, want %+v", got, want)
+	}
+
+	s.Remove(branchAuth, false)
+	if _, ok := s.Links[branchTests]; ok {
+		t.Error("child of an unstacked, unmerged parent should be unlinked")
+	}
+}
+
+func TestRemoveMergedRootLeavesChildPending(t *testing.T) {
+	s := newChain(t)
+	s.Remove(branchAuth, true)
+
+	if got, want := s.Links[branchUI], (stack.Link{Base: shaA}); got != want {
+		t.Errorf("child link = %+v, want %+v", got, want)
+	}
+	if _, ok := s.Parents()[branchUI]; ok {
+		t.Error("pending link should not appear in Parents")
+	}
+}
+
+func TestRename(t *testing.T) {
+	s := newChain(t)
+	s.Rename(branchUI, "feature/login-ui")
+
+	if s.Links["feature/login-ui"].Parent != branchAuth {
+		t.Errorf("renamed link = %+v", s.Links["feature/login-ui"])
+	}
+	if s.Links[branchTests].Parent != "feature/login-ui" {
+		t.Errorf("child parent = %q, want renamed branch", s.Links[branchTests].Parent)
+	}
+}
+
+func TestTree(t *testing.T) {
+	branches := []string{branchTests, branchAuth, branchOther, branchUI}
+	parents := map[string]string{branchUI: branchAuth, branchTests: branchUI}
+
+	order, depths := stack.Tree(branches, parents)
+
+	got := make([]string, len(order))
+	for i, idx := range order {
+		got[i] = branches[idx]
+	}
+	if want := []string{branchAuth, branchUI, branchTests, branchOther}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if want := []int{0, 1, 2, 0}; !slices.Equal(depths, want) {
+		t.Errorf("depths = %v, want %v", depths, want)
+	}
+}
+
+func TestTreeMissingParentIsRoot(t *testing.T) {
+	branches := []string{branchTests}
+	order, depths := stack.Tree(branches, map[string]string{branchTests: branchUI})
+
+	if !slices.Equal(order, []int{0}) || !slices.Equal(depths, []int{0}) {
+		t.Errorf("order = %v depths = %v, want [0] [0]", order, depths)
+	}
+}
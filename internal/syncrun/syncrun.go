@@ -0,0 +1,129 @@
+// Package syncrun persists a `rimba sync --stop-on-conflict` run — which
+// worktrees it has synced, which one stopped on a conflict, and which are
+// still pending — so the run can be continued once the conflict is resolved,
+// or rolled back to where every worktree stood before it began.
+// The state lives under the git common dir and is removed when the run ends.
+package syncrun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/fsutil"
+)
+
+// Status is where one worktree stands in a run.
+type Status string
+
+// Worktree is one worktree in a run.
+type Worktree struct {
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+	// Base is the branch the worktree syncs onto.
+	Base string `json:"base"`
+	// PreSHA is the branch tip before the run touched it; --abort resets to it.
+	PreSHA string `json:"pre_sha"`
+	// PostSHA is the branch tip the run left a synced worktree at; --abort
+	// leaves the branch alone once it has moved on from there.
+	PostSHA string `json:"post_sha,omitempty"`
+	// StopSHA is HEAD where a conflicted worktree's rebase or merge stopped;
+	// --abort leaves the worktree alone once it has moved on from there.
+	StopSHA string `json:"stop_sha,omitempty"`
+	Status  Status `json:"status"`
+	// Pushed records that the synced branch was pushed, which a rollback
+	// cannot take back.
+	Pushed bool `json:"pushed,omitempty"`
+	// Stash is the SHA of the worktree's autostash while a conflict holds
+	// it back; it is reapplied once the worktree is continued or rolled back.
+	Stash string `json:"stash,omitempty"`
+}
+
+// Run is the persisted state of one sync run.
+type Run struct {
+	StartedAt time.Time  `json:"started_at"`
+	Merge     bool       `json:"merge"`
+	Push      bool       `json:"push"`
+	Autostash bool       `json:"autostash,omitempty"`
+	Worktrees []Worktree `json:"worktrees"`
+}
+
+// Worktree statuses.
+const (
+	StatusPending    Status = "pending"
+	StatusDone       Status = "done"
+	StatusConflicted Status = "conflicted"
+	// StatusSkipped marks a worktree left alone: dirty, or its sync failed
+	// before changing anything.
+	StatusSkipped Status = "skipped"
+)
+
+// runFile is where the run lives, relative to the git common dir.
+const runFile = "rimba/sync-run.json"
+
+// Load reads the run under commonDir. It returns nil, nil when no run is in
+// progress.
+func Load(commonDir string) (*Run, error) {
+	data, err := os.ReadFile(runPath(commonDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read sync run: %w", err)
+	}
+	run := &Run{}
+	if err := json.Unmarshal(data, run); err != nil {
+		return nil, fmt.Errorf("parse sync run: %w", err)
+	}
+	return run, nil
+}
+
+// Clear removes the run under commonDir. A missing run is not an error.
+func Clear(commonDir string) error {
+	if err := os.Remove(runPath(commonDir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove sync run: %w", err)
+	}
+	return nil
+}
+
+// Save writes the run under commonDir atomically.
+func (r *Run) Save(commonDir string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal sync run: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(runPath(commonDir), data); err != nil {
+		return fmt.Errorf("write sync run: %w", err)
+	}
+	return nil
+}
+
+// Update applies fn to branch's entry, reporting whether branch is part of
+// the run.
+func (r *Run) Update(branch string, fn func(w *Worktree)) bool {
+	for i := range r.Worktrees {
+		if r.Worktrees[i].Branch == branch {
+			fn(&r.Worktrees[i])
+			return true
+		}
+	}
+	return false
+}
+
+// With returns the worktrees whose status is status, in run order.
+func (r *Run) With(status Status) []Worktree {
+	var out []Worktree
+	for _, w := range r.Worktrees {
+		if w.Status == status {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func runPath(commonDir string) string {
+	return filepath.Join(commonDir, runFile)
+}
@@ -0,0 +1,77 @@
+package syncrun_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lugassawan/rimba/internal/syncrun"
+)
+
+const branchAuth = "feature/auth"
+
+func testRun() *syncrun.Run {
+	return &syncrun.Run{
+		StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Push:      true,
+		Worktrees: []syncrun.Worktree{
+			{Branch: branchAuth, Path: "/wt/auth", Base: "main", PreSHA: "abc123", Status: syncrun.StatusPending},
+			{Branch: "bugfix/typo", Path: "/wt/typo", Base: "release/2.3", PreSHA: "def456", Status: syncrun.StatusPending},
+		},
+	}
+}
+
+func TestLoadMissingReturnsNil(t *testing.T) {
+	run, err := syncrun.Load(t.TempDir())
+	if err != nil || run != nil {
+		t.Errorf("Load = %+v, %v; want nil, nil", run, err)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := testRun().Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := syncrun.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !got.Push || got.Merge || len(got.Worktrees) != 2 {
+		t.Fatalf("run = %+v", got)
+	}
+	if w := got.Worktrees[1]; w.Base != "release/2.3" || w.PreSHA != "def456" || w.Status != syncrun.StatusPending {
+		t.Errorf("worktree = %+v", w)
+	}
+}
+
+func TestUpdateAndWith(t *testing.T) {
+	run := testRun()
+	if !run.Update(branchAuth, func(w *syncrun.Worktree) { w.Status = syncrun.StatusConflicted }) {
+		t.Fatal("Update reported branch missing")
+	}
+	if run.Update("feature/gone", func(*syncrun.Worktree) {}) {
+		t.Error("Update reported an unknown branch present")
+	}
+	if got := run.With(syncrun.StatusConflicted); len(got) != 1 || got[0].Branch != branchAuth {
+		t.Errorf("conflicted = %+v", got)
+	}
+	if got := run.With(syncrun.StatusPending); len(got) != 1 || got[0].Branch != "bugfix/typo" {
+		t.Errorf("pending = %+v", got)
+	}
+}
+
+func TestClear(t *testing.T) {
+	dir := t.TempDir()
+	if err := testRun().Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := syncrun.Clear(dir); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if run, _ := syncrun.Load(dir); run != nil {
+		t.Errorf("run after Clear = %+v", run)
+	}
+	if err := syncrun.Clear(dir); err != nil {
+		t.Errorf("Clear of missing run: %v", err)
+	}
+}
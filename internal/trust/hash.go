@@ -10,19 +10,34 @@ import (
 	"github.com/lugassawan/rimba/internal/config"
 )
 
+// resolveLockfilesMark is hashed after the commands when [deps]
+// resolve_lockfiles is on. Its leading 0x01 keeps it from reading as a
+// command.
+const resolveLockfilesMark = "\x01resolve_lockfiles"
+
 // Commands returns all shell-executing strings from cfg in display order:
-// post_create, then post_rename, then non-empty deps.modules[].install.
+// post_create, then post_rename, then non-empty deps.modules[].install and
+// .lock, then the same per profile, by profile name, then each
+// [[resolver.prefix]] entry's post_create, then [merge] verify and
+// [merge_queue] verify. Profiles and prefix types are included so a single
+// approval covers every --profile or prefix flag a teammate might pick.
 func Commands(cfg *config.Config) []string {
 	var cmds []string
 	cmds = append(cmds, cfg.PostCreate...)
 	cmds = append(cmds, cfg.PostRename...)
-	if cfg.Deps != nil {
-		for _, m := range cfg.Deps.Modules {
-			if strings.TrimSpace(m.Install) != "" {
-				cmds = append(cmds, m.Install)
-			}
+	cmds = appendInstalls(cmds, cfg.Deps)
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		cmds = append(cmds, p.PostCreate...)
+		cmds = appendInstalls(cmds, p.Deps)
+	}
+	if cfg.Resolver != nil {
+		for _, e := range cfg.Resolver.Prefix {
+			cmds = append(cmds, e.PostCreate...)
 		}
 	}
+	cmds = append(cmds, cfg.MergeVerify()...)
+	cmds = append(cmds, cfg.MergeQueueVerify()...)
 	return cmds
 }
 
@@ -42,6 +57,10 @@ func HasCommands(cfg *config.Config) bool {
 // adding an already-approved post_create string to post_rename) does not
 // re-arm the gate; re-consent is only required when the command content itself
 // is new or changed.
+//
+// Turning on [deps] resolve_lockfiles changes the hash as well: it makes sync
+// and merge run the lock and install commands too, which an earlier approval
+// did not cover.
 func Hash(cfg *config.Config) string {
 	cmds := Commands(cfg)
 	if len(cmds) == 0 {
@@ -57,5 +76,23 @@ func Hash(cfg *config.Config) string {
 		h.Write([]byte(c))
 		h.Write([]byte{0}) // NUL delimiter prevents "ab"+"c" == "a"+"bc"
 	}
+	if cfg.ResolveLockfiles() {
+		h.Write([]byte(resolveLockfilesMark))
+	}
 	return "sha256:" + hex.EncodeToString(h.Sum(nil))
 }
+
+func appendInstalls(cmds []string, deps *config.DepsConfig) []string {
+	if deps == nil {
+		return cmds
+	}
+	for _, m := range deps.Modules {
+		if strings.TrimSpace(m.Install) != "" {
+			cmds = append(cmds, m.Install)
+		}
+		if strings.TrimSpace(m.Lock) != "" {
+			cmds = append(cmds, m.Lock)
+		}
+	}
+	return cmds
+}
@@ -1,6 +1,7 @@
 package trust_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/lugassawan/rimba/internal/config"
@@ -116,6 +117,15 @@ func TestHashEmptyInstallSkipped(t *testing.T) {
 	}
 }
 
+func TestHashChangesWithResolveLockfiles(t *testing.T) {
+	cfg := cfgWithCommands(nil, nil, "npm ci")
+	before := trust.Hash(cfg)
+	cfg.Deps.ResolveLockfiles = true
+	if after := trust.Hash(cfg); after == before {
+		t.Errorf("turning on resolve_lockfiles should change hash, got same: %q", after)
+	}
+}
+
 func TestHasCommands(t *testing.T) {
 	if trust.HasCommands(emptyConfig()) {
 		t.Error("HasCommands(empty) should be false")
@@ -159,3 +169,70 @@ func TestCommandsSkipsEmptyInstall(t *testing.T) {
 		t.Errorf("Commands() = %v, want [npm ci]", cmds)
 	}
 }
+
+func TestCommandsIncludeModuleLock(t *testing.T) {
+	cfg := &config.Config{
+		Deps: &config.DepsConfig{
+			Modules: []config.ModuleConfig{
+				{Dir: "node_modules", Lockfile: "package-lock.json", Install: "npm ci", Lock: "npm install --package-lock-only"},
+			},
+		},
+	}
+	want := []string{"npm ci", "npm install --package-lock-only"}
+	if got := trust.Commands(cfg); !slices.Equal(got, want) {
+		t.Errorf("Commands() = %v, want %v", got, want)
+	}
+}
+
+func TestCommandsIncludeProfiles(t *testing.T) {
+	cfg := &config.Config{
+		PostCreate: []string{"make setup"},
+		Profiles: map[string]config.Profile{
+			"perf": {
+				PostCreate: []string{"make build"},
+				Deps:       &config.DepsConfig{Modules: []config.ModuleConfig{{Dir: "web", Install: "pnpm i"}}},
+			},
+			"lint": {PostCreate: []string{"make lint"}},
+		},
+	}
+	want := []string{"make setup", "make lint", "make build", "pnpm i"}
+	if got := trust.Commands(cfg); !slices.Equal(got, want) {
+		t.Errorf("Commands() = %v, want %v", got, want)
+	}
+}
+
+func TestCommandsIncludePrefixPostCreate(t *testing.T) {
+	cfg := &config.Config{
+		PostCreate: []string{"make setup"},
+		Resolver: &config.ResolverConfig{Prefix: []config.PrefixEntry{
+			{Prefix: "docs/", SkipDeps: true},
+			{Prefix: "hotfix/", PostCreate: []string{"make release-env"}},
+		}},
+	}
+	want := []string{"make setup", "make release-env"}
+	if got := trust.Commands(cfg); !slices.Equal(got, want) {
+		t.Errorf("Commands() = %v, want %v", got, want)
+	}
+}
+
+func TestCommandsIncludeMergeQueueVerify(t *testing.T) {
+	cfg := &config.Config{
+		PostCreate: []string{"make setup"},
+		MergeQueue: &config.MergeQueueConfig{Verify: []string{"make test"}},
+	}
+	want := []string{"make setup", "make test"}
+	if got := trust.Commands(cfg); !slices.Equal(got, want) {
+		t.Errorf("Commands() = %v, want %v", got, want)
+	}
+}
+
+func TestCommandsIncludeMergeVerify(t *testing.T) {
+	cfg := &config.Config{
+		Merge:      &config.MergeConfig{Verify: []string{"make lint"}},
+		MergeQueue: &config.MergeQueueConfig{Verify: []string{"make test"}},
+	}
+	want := []string{"make lint", "make test"}
+	if got := trust.Commands(cfg); !slices.Equal(got, want) {
+		t.Errorf("Commands() = %v, want %v", got, want)
+	}
+}
@@ -191,6 +191,9 @@ func TestAddPartialFailCopyHint(t *testing.T) {
 
 	r := rimbaFail(t, repo, "add", "copy-fail-task")
 	assertContains(t, r.Stderr, "failed to copy files")
+	assertContains(t, r.Stderr, "Rolled back: create worktree")
+
+	r = rimbaFail(t, repo, "add", "copy-fail-task", "--keep-on-failure")
 	assertContains(t, r.Stderr, "To retry, manually copy files to:")
 	assertContains(t, r.Stderr, "rimba remove copy-fail-task")
 }
@@ -604,3 +607,40 @@ func TestAddBranchRefusesAlreadyPromoted(t *testing.T) {
 	r := rimbaFail(t, wtPath, "add", "branch:"+branchPromoteMe)
 	assertContains(t, r.Stderr, "already checked out in worktree")
 }
+
+func TestAddRollsBackOnHookFailure(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	cfg := &config.Config{
+		WorktreeDir:   "../rimba-rollback-wt",
+		DefaultSource: "main",
+		PostCreate:    []string{"exit 1"},
+	}
+	writeTeamConfig(t, repo, cfg)
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "add failing post_create hook")
+
+	env := []string{"RIMBA_TRUST_YES=1"}
+	branch := resolver.BranchName(defaultPrefix, "doomed")
+	wtPath := resolver.WorktreePath(filepath.Join(repo, cfg.WorktreeDir), branch)
+
+	r := rimbaWithEnv(t, repo, env, "add", "doomed", flagSkipDepsE2E)
+	if r.ExitCode == 0 {
+		t.Fatal("add with a failing hook should fail")
+	}
+	assertContains(t, r.Stderr, "Rolled back: create worktree")
+	assertFileNotExists(t, wtPath)
+	if out := testutil.GitCmd(t, repo, "branch", "--list", branch); strings.TrimSpace(out) != "" {
+		t.Errorf("branch %s should be deleted, got %q", branch, out)
+	}
+
+	r = rimbaWithEnv(t, repo, env, "add", "doomed", flagSkipDepsE2E, "--keep-on-failure")
+	if r.ExitCode == 0 {
+		t.Fatal("add with a failing hook should fail")
+	}
+	assertContains(t, r.Stderr, "Kept completed steps")
+	assertFileExists(t, wtPath)
+}
@@ -1,6 +1,7 @@
 package e2e_test
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -62,25 +63,80 @@ func TestArchiveForceDirty(t *testing.T) {
 	assertFileNotExists(t, wtPath)
 }
 
-func TestArchiveDirtyFails(t *testing.T) {
+func TestArchiveRestoreKeepsUncommittedWork(t *testing.T) {
 	if testing.Short() {
 		t.Skip(skipE2E)
 	}
 
 	repo := setupInitializedRepo(t)
-	rimbaSuccess(t, repo, "add", "dirty-fail")
+	rimbaSuccess(t, repo, "add", "parked", flagSkipDepsE2E, flagSkipHooksE2E)
 
 	cfg := loadConfig(t, repo)
 	wtDir := filepath.Join(repo, cfg.WorktreeDir)
-	branch := resolver.BranchName(defaultPrefix, "dirty-fail")
+	branch := resolver.BranchName(defaultPrefix, "parked")
 	wtPath := resolver.WorktreePath(wtDir, branch)
 
-	// Make worktree dirty
-	testutil.CreateFile(t, wtPath, "dirty.txt", "uncommitted")
+	// A staged file, an untracked file, and an ignored copy_files entry
+	testutil.CreateFile(t, filepath.Join(repo, ".git", "info"), "exclude", ".env\n")
+	testutil.CreateFile(t, wtPath, "staged.txt", "staged")
+	testutil.GitCmd(t, wtPath, "add", "staged.txt")
+	testutil.CreateFile(t, wtPath, "scratch.txt", "untracked")
+	testutil.CreateFile(t, wtPath, ".env", "TOKEN=worktree")
+
+	r := rimbaSuccess(t, repo, "archive", "parked")
+	assertContains(t, r.Stdout, "Uncommitted changes saved: refs/rimba/archive/"+branch)
+	assertContains(t, r.Stdout, "Files saved: [.env]")
+	assertFileNotExists(t, wtPath)
+
+	r = rimbaSuccess(t, repo, "restore", "parked", flagSkipDepsE2E, flagSkipHooksE2E)
+	assertContains(t, r.Stdout, "Reapplied uncommitted changes")
+
+	status := testutil.GitCmd(t, wtPath, "status", "--porcelain")
+	assertContains(t, status, "A  staged.txt")
+	assertContains(t, status, "?? scratch.txt")
+	env, err := os.ReadFile(filepath.Join(wtPath, ".env"))
+	if err != nil || string(env) != "TOKEN=worktree" {
+		t.Errorf(".env = %q, %v; want the worktree's copy back", env, err)
+	}
+
+	// The snapshot is consumed by the restore
+	if out := testutil.GitCmd(t, repo, "for-each-ref", "refs/rimba/archive/"); strings.TrimSpace(out) != "" {
+		t.Errorf("archive ref should be deleted after restore, got %q", out)
+	}
+}
+
+func TestArchiveBundleRestoreFromBundle(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupInitializedRepo(t)
+	rimbaSuccess(t, repo, "add", "portable", flagSkipDepsE2E, flagSkipHooksE2E)
+
+	cfg := loadConfig(t, repo)
+	wtDir := filepath.Join(repo, cfg.WorktreeDir)
+	branch := resolver.BranchName(defaultPrefix, "portable")
+	wtPath := resolver.WorktreePath(wtDir, branch)
+
+	testutil.CreateFile(t, wtPath, "work.txt", "committed")
 	testutil.GitCmd(t, wtPath, "add", ".")
+	testutil.GitCmd(t, wtPath, "commit", "-m", "work")
+	testutil.CreateFile(t, wtPath, "wip.txt", "uncommitted")
+
+	bundlePath := filepath.Join(t.TempDir(), "portable.rimba")
+	r := rimbaSuccess(t, repo, "archive", "portable", "--bundle", bundlePath)
+	assertContains(t, r.Stdout, "Bundle written: "+bundlePath)
+	assertFileExists(t, bundlePath)
 
-	// Should fail without --force
-	rimbaFail(t, repo, "archive", "dirty-fail")
+	// Simulate a clone that never had the branch.
+	testutil.GitCmd(t, repo, "branch", "-D", branch)
+	testutil.GitCmd(t, repo, "update-ref", "-d", "refs/rimba/archive/"+branch)
+
+	r = rimbaSuccess(t, repo, "restore", "--from-bundle", bundlePath, flagSkipDepsE2E, flagSkipHooksE2E)
+	assertContains(t, r.Stdout, "Imported "+branch)
+	assertContains(t, r.Stdout, "Restored worktree")
+	assertFileExists(t, filepath.Join(wtPath, "work.txt"))
+	assertFileExists(t, filepath.Join(wtPath, "wip.txt"))
 }
 
 func TestArchiveDryRun(t *testing.T) {
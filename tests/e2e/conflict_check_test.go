@@ -1,6 +1,7 @@
 package e2e_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -75,3 +76,129 @@ func TestConflictCheckHighSeverity(t *testing.T) {
 	assertContains(t, r.Stdout, "shared.txt")
 	assertContains(t, r.Stdout, "high (3)")
 }
+
+func TestConflictCheckOwners(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "CODEOWNERS"), []byte("*.txt @org/docs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conflictSetup(t, repo, taskConflictA, "shared.txt", "content from a")
+	conflictSetup(t, repo, taskConflictB, "shared.txt", "content from b")
+
+	r := rimbaSuccess(t, repo, "conflict-check")
+	assertContains(t, r.Stdout, "@org/docs")
+	assertContains(t, r.Stdout, "Hot directories:")
+
+	r = rimbaSuccess(t, repo, "conflict-check", "--owner", "@org/web")
+	assertContains(t, r.Stdout, "No file overlaps found in files owned by @org/web.")
+}
+
+func TestConflictCheckHunks(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	conflictSetup(t, repo, taskConflictA, "shared.txt", "content from a")
+	conflictSetup(t, repo, taskConflictB, "shared.txt", "content from b")
+
+	r := rimbaSuccess(t, repo, "conflict-check", "--hunks")
+	assertContains(t, r.Stdout, "LINES")
+	assertContains(t, r.Stdout, "high (2)")
+}
+
+func TestConflictCheckIncludeWorktree(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	conflictSetup(t, repo, taskConflictA, "file-a.txt", "content a")
+	conflictSetup(t, repo, taskConflictB, "file-b.txt", "content b")
+	wtDir := filepath.Join(repo, loadConfig(t, repo).WorktreeDir)
+	wtA := resolver.WorktreePath(wtDir, resolver.BranchName(defaultPrefix, taskConflictA))
+	wtB := resolver.WorktreePath(wtDir, resolver.BranchName(defaultPrefix, taskConflictB))
+	testutil.CreateFile(t, wtA, "wip.txt", "draft from a")
+	testutil.GitCmd(t, wtA, "add", "wip.txt")
+	testutil.CreateFile(t, wtB, "wip.txt", "draft from b")
+
+	r := rimbaSuccess(t, repo, "conflict-check", "--include-worktree")
+	assertContains(t, r.Stdout, "No file overlaps found")
+
+	r = rimbaSuccess(t, repo, "conflict-check", "--include-untracked")
+	assertContains(t, r.Stdout, "wip.txt")
+	assertContains(t, r.Stdout, "* uncommitted changes in the worktree")
+}
+
+func TestConflictCheckWithPRs(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	conflictSetup(t, repo, taskConflictA, "shared.txt", "content from a")
+
+	// A teammate's PR #12 also adds shared.txt; origin serves its head
+	// under refs/pull/12/head, as GitHub does.
+	bareDir := filepath.Join(t.TempDir(), "origin.git")
+	if err := os.MkdirAll(bareDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitBare(t, bareDir, "init", "--bare")
+	testutil.GitCmd(t, repo, "remote", "add", "origin", bareDir)
+	testutil.GitCmd(t, repo, "checkout", "-b", "pr-source")
+	testutil.CreateFile(t, repo, "shared.txt", "content from the PR")
+	testutil.GitCmd(t, repo, "add", "shared.txt")
+	testutil.GitCmd(t, repo, "commit", "-m", "add shared.txt")
+	testutil.GitCmd(t, repo, "push", "origin", "HEAD:refs/pull/12/head")
+	testutil.GitCmd(t, repo, "checkout", "-")
+	testutil.GitCmd(t, repo, "branch", "-D", "pr-source")
+
+	stubDir, env := stubGh(t)
+	env = append(env, "GH_STUB_DIR="+stubDir)
+	writeStubPR(t, stubDir, "main",
+		`[{"number":12,"author":{"login":"alice"},"headRefName":"search","isCrossRepository":false}]`)
+
+	r := rimbaSuccessWithEnv(t, repo, env, "conflict-check", "--with-prs")
+	assertContains(t, r.Stdout, "shared.txt")
+	assertContains(t, r.Stdout, "#12 (@alice)")
+	assertContains(t, r.Stdout, "1 of them involve open PRs.")
+	testutil.GitCmd(t, repo, "rev-parse", "--verify", "refs/rimba/prs/12")
+}
+
+func TestConflictCheckCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	conflictSetup(t, repo, taskConflictA, "shared.txt", "content from a")
+	conflictSetup(t, repo, taskConflictB, "shared.txt", "content from b")
+
+	first := rimbaSuccess(t, repo, "conflict-check", "--dry-merge")
+	assertContains(t, first.Stdout, "shared.txt")
+	assertFileExists(t, filepath.Join(repo, ".git", "rimba", "conflict-cache.json"))
+
+	cached := rimbaSuccess(t, repo, "conflict-check", "--dry-merge")
+	if cached.Stdout != first.Stdout {
+		t.Errorf("cached run differs:\n%s\nwant:\n%s", cached.Stdout, first.Stdout)
+	}
+
+	// Moving a branch recomputes it: a now also touches other.txt, which
+	// b does too once uncached.
+	conflictSetup(t, repo, taskConflictC, "other.txt", "content from c")
+	wtDir := filepath.Join(repo, loadConfig(t, repo).WorktreeDir)
+	wtA := resolver.WorktreePath(wtDir, resolver.BranchName(defaultPrefix, taskConflictA))
+	testutil.CreateFile(t, wtA, "other.txt", "more from a")
+	testutil.GitCmd(t, wtA, "add", ".")
+	testutil.GitCmd(t, wtA, "commit", "-m", "add other.txt")
+
+	r := rimbaSuccess(t, repo, "conflict-check")
+	assertContains(t, r.Stdout, "other.txt")
+	r = rimbaSuccess(t, repo, "conflict-check", "--no-cache")
+	assertContains(t, r.Stdout, "other.txt")
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/lugassawan/rimba/internal/meta"
 	"github.com/lugassawan/rimba/internal/resolver"
 	"github.com/lugassawan/rimba/testutil"
 )
@@ -271,3 +272,27 @@ func TestDuplicateDryRun(t *testing.T) {
 		t.Errorf("expected 1 worktree dir, got %d — dry-run must not create a new worktree", len(entries))
 	}
 }
+
+func TestDuplicateTracksSourceBase(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupInitializedRepo(t)
+	testutil.GitCmd(t, repo, "branch", "develop")
+	rimbaSuccess(t, repo, "add", "-s", "develop", taskDupA)
+
+	rimbaSuccess(t, repo, "duplicate", taskDupA)
+
+	s, err := meta.Load(filepath.Join(repo, ".git"))
+	if err != nil {
+		t.Fatalf("meta.Load: %v", err)
+	}
+	dup := s.Get(resolver.BranchName(defaultPrefix, taskDupA+"-1"))
+	if dup.Base != "develop" {
+		t.Errorf("duplicate base = %q, want the source's develop", dup.Base)
+	}
+	if dup.CreatedAt.IsZero() {
+		t.Error("duplicate was not stamped with its creation time")
+	}
+}
@@ -49,3 +49,19 @@ func TestMergePlanOrdering(t *testing.T) {
 		t.Errorf("first merge should be %s, got: %s", taskConflictC, dataLines[0])
 	}
 }
+
+func TestMergePlanFormatMermaid(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	conflictSetup(t, repo, taskConflictA, "shared.txt", "content from a")
+	conflictSetup(t, repo, taskConflictB, "shared.txt", "content from b")
+	conflictSetup(t, repo, taskConflictC, "unique-c.txt", "content from c")
+
+	r := rimbaSuccess(t, repo, "merge-plan", "--format", "mermaid")
+	assertContains(t, r.Stdout, "graph LR")
+	assertContains(t, r.Stdout, `n0["1. `+taskConflictC+`"]`)
+	assertContains(t, r.Stdout, "n1 ---|1| n2")
+}
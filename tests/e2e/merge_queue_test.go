@@ -0,0 +1,65 @@
+package e2e_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/internal/resolver"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+func TestMergeQueueNoWorktrees(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	r := rimbaSuccess(t, repo, "merge-queue")
+	assertContains(t, r.Stdout, "No branches to merge")
+}
+
+func TestMergeQueueLandsEveryWorktree(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	conflictSetup(t, repo, taskConflictA, "file-a.txt", "content a")
+	conflictSetup(t, repo, taskConflictC, "file-c.txt", "content c")
+
+	r := rimbaSuccess(t, repo, "merge-queue")
+	assertContains(t, r.Stdout, "Merge queue done: 2 branch(es) landed on main")
+	assertFileExists(t, filepath.Join(repo, "file-a.txt"))
+	assertFileExists(t, filepath.Join(repo, "file-c.txt"))
+}
+
+func TestMergeQueueStopsOnFailedVerifyThenContinues(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	conflictSetup(t, repo, taskConflictA, "broken.txt", "breaks the build")
+
+	cfg := loadConfig(t, repo)
+	cfg.MergeQueue = &config.MergeQueueConfig{Verify: []string{"test ! -f broken.txt"}}
+	writeTeamConfig(t, repo, cfg)
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "add merge queue verify")
+	before := testutil.GitCmd(t, repo, "rev-parse", "main")
+
+	r := rimbaFail(t, repo, "merge-queue", taskConflictA, "--yes")
+	assertContains(t, r.Stdout, "Failed "+resolver.BranchName(defaultPrefix, taskConflictA))
+	assertContains(t, r.Stderr, "rimba merge-queue --continue")
+	if after := testutil.GitCmd(t, repo, "rev-parse", "main"); after != before {
+		t.Errorf("main moved from %s to %s on a failed verify", before, after)
+	}
+
+	wtPath := resolver.WorktreePath(filepath.Join(repo, cfg.WorktreeDir), resolver.BranchName(defaultPrefix, taskConflictA))
+	testutil.GitCmd(t, wtPath, "rm", "-q", "broken.txt")
+	testutil.GitCmd(t, wtPath, "commit", "-m", "fix the build")
+
+	r = rimbaSuccess(t, repo, "merge-queue", "--continue")
+	assertContains(t, r.Stdout, "Merge queue done: 1 branch(es) landed on main")
+}
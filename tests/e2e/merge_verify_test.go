@@ -0,0 +1,55 @@
+package e2e_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/config"
+	"github.com/lugassawan/rimba/testutil"
+)
+
+func TestMergeVerifyFailureLeavesMain(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	mergeSetup(t, repo, taskMergeMain)
+
+	cfg := loadConfig(t, repo)
+	cfg.Merge = &config.MergeConfig{Verify: []string{"test ! -f " + taskMergeMain + ".txt"}}
+	writeTeamConfig(t, repo, cfg)
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "add merge verify")
+	before := testutil.GitCmd(t, repo, "rev-parse", "main")
+
+	r := rimbaFail(t, repo, "merge", taskMergeMain, "--yes")
+	assertContains(t, r.Stderr, "merge verification failed")
+	assertContains(t, r.Stderr, "--no-verify")
+	if after := testutil.GitCmd(t, repo, "rev-parse", "main"); after != before {
+		t.Errorf("main moved from %s to %s on a failed verify", before, after)
+	}
+
+	r = rimbaSuccess(t, repo, "merge", taskMergeMain, "--no-verify")
+	assertContains(t, r.Stdout, "Merged")
+	assertFileExists(t, filepath.Join(repo, taskMergeMain+".txt"))
+}
+
+func TestMergeVerifyPasses(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupCleanInitializedRepo(t)
+	mergeSetup(t, repo, taskMergeMain)
+
+	cfg := loadConfig(t, repo)
+	cfg.Merge = &config.MergeConfig{Verify: []string{"test -f " + taskMergeMain + ".txt"}}
+	writeTeamConfig(t, repo, cfg)
+	testutil.GitCmd(t, repo, "add", ".")
+	testutil.GitCmd(t, repo, "commit", "-m", "add merge verify")
+
+	r := rimbaSuccess(t, repo, "merge", taskMergeMain, "--yes")
+	assertContains(t, r.Stdout, "Verified merge result: test -f "+taskMergeMain+".txt")
+	assertFileExists(t, filepath.Join(repo, taskMergeMain+".txt"))
+}
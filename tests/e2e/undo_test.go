@@ -0,0 +1,33 @@
+package e2e_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lugassawan/rimba/internal/resolver"
+)
+
+const taskUndo = "undo-me"
+
+func TestUndoRemoveRecreatesWorktree(t *testing.T) {
+	if testing.Short() {
+		t.Skip(skipE2E)
+	}
+
+	repo := setupInitializedRepo(t)
+	rimbaSuccess(t, repo, "add", flagSkipDepsE2E, flagSkipHooksE2E, taskUndo)
+	rimbaSuccess(t, repo, "remove", taskUndo)
+
+	list := rimbaSuccess(t, repo, "undo", "--list")
+	assertContains(t, list.Stdout, "remove worktree:")
+
+	r := rimbaSuccess(t, repo, "undo")
+	assertContains(t, r.Stdout, "Undid remove")
+
+	cfg := loadConfig(t, repo)
+	branch := resolver.BranchName(defaultPrefix, taskUndo)
+	wtPath := resolver.WorktreePath(filepath.Join(repo, cfg.WorktreeDir), branch)
+	assertFileExists(t, wtPath)
+
+	rimbaFail(t, repo, "undo")
+}